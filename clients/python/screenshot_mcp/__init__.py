@@ -0,0 +1,12 @@
+"""Python client for the Screenshot MCP Server.
+
+The REST methods and schema types are generated from the server's OpenAPI document
+(``make clients``); the transport, MCP calls and frame streaming are in client.py and
+stream.py.
+"""
+
+from ._generated import *  # noqa: F401,F403
+from .client import APIError, Client, RPCError
+from .stream import Frame, FrameStream, StreamError
+
+__version__ = "1.0.0"
@@ -0,0 +1,1685 @@
+# Code generated by clientgen from docs/openapi.json. DO NOT EDIT.
+
+from __future__ import annotations
+
+from typing import Any, Dict, List, Mapping, Optional, TypedDict, Union
+
+
+ActivityState = TypedDict(
+    "ActivityState",
+    {
+        "capture_paused": bool,
+        "gate": str,
+        "idle_after": int,
+        "idle_for": int,
+        "last_input": Optional[str],
+        "state": str,
+    },
+    total=False,
+)
+
+AdminSessionsResponse = TypedDict(
+    "AdminSessionsResponse",
+    {
+        "count": int,
+        "sessions": List["StreamSessionStats"],
+    },
+    total=False,
+)
+
+AgentInfo = TypedDict(
+    "AgentInfo",
+    {
+        "connected_at": str,
+        "hostname": str,
+        "id": str,
+        "platform": str,
+        "remote_addr": str,
+        "requests": int,
+        "streams": int,
+        "version": str,
+    },
+    total=False,
+)
+
+AgentListResponse = TypedDict(
+    "AgentListResponse",
+    {
+        "agents": List["AgentInfo"],
+        "count": int,
+    },
+    total=False,
+)
+
+ApiError = TypedDict(
+    "ApiError",
+    {
+        "candidates": List["WindowInfo"],
+        "code": str,
+        "desktop_state": str,
+        "error": str,
+    },
+    total=False,
+)
+
+BatchScreenshotRequest = TypedDict(
+    "BatchScreenshotRequest",
+    {
+        "requests": List["ScreenshotRequest"],
+    },
+    total=False,
+)
+
+BatchScreenshotResponse = TypedDict(
+    "BatchScreenshotResponse",
+    {
+        "failed": int,
+        "results": List["BatchScreenshotResult"],
+        "succeeded": int,
+    },
+    total=False,
+)
+
+BatchScreenshotResult = TypedDict(
+    "BatchScreenshotResult",
+    {
+        "code": str,
+        "error": str,
+        "machine": str,
+        "response": "ScreenshotResponse",
+        "target": str,
+    },
+    total=False,
+)
+
+CPUThrottle = TypedDict(
+    "CPUThrottle",
+    {
+        "budget": int,
+        "fps": int,
+        "usage": float,
+    },
+    total=False,
+)
+
+CapabilityStatus = TypedDict(
+    "CapabilityStatus",
+    {
+        "detail": str,
+        "duration": int,
+        "error": str,
+        "required": bool,
+        "status": str,
+        "version": str,
+    },
+    total=False,
+)
+
+CaptureAttempt = TypedDict(
+    "CaptureAttempt",
+    {
+        "duration": int,
+        "error": str,
+        "method": str,
+        "retry": int,
+        "success": bool,
+    },
+    total=False,
+)
+
+CaptureSet = TypedDict(
+    "CaptureSet",
+    {
+        "description": str,
+        "name": str,
+        "requests": List["ScreenshotRequest"],
+    },
+    total=False,
+)
+
+CaptureSetListResponse = TypedDict(
+    "CaptureSetListResponse",
+    {
+        "count": int,
+        "sets": List["CaptureSet"],
+    },
+    total=False,
+)
+
+CaptureSetResponse = TypedDict(
+    "CaptureSetResponse",
+    {
+        "failed": int,
+        "name": str,
+        "results": List["BatchScreenshotResult"],
+        "spread": int,
+        "succeeded": int,
+        "timestamp": str,
+    },
+    total=False,
+)
+
+CaptureTiming = TypedDict(
+    "CaptureTiming",
+    {
+        "capture": int,
+        "captured_at": str,
+        "encode": int,
+        "monotonic_ns": int,
+        "process": int,
+        "send": int,
+    },
+    total=False,
+)
+
+ChromeFrame = TypedDict(
+    "ChromeFrame",
+    {
+        "rect": "Rectangle",
+        "session_id": str,
+        "target_id": str,
+        "title": str,
+        "url": str,
+    },
+    total=False,
+)
+
+ChromeFramesResponse = TypedDict(
+    "ChromeFramesResponse",
+    {
+        "count": int,
+        "frames": List["ChromeFrame"],
+    },
+    total=False,
+)
+
+ChromeInstance = TypedDict(
+    "ChromeInstance",
+    {
+        "debug_port": int,
+        "pid": int,
+        "profile_path": str,
+        "tabs": List["ChromeTab"],
+        "user_agent": str,
+        "version": str,
+    },
+    total=False,
+)
+
+ChromeInstancesResponse = TypedDict(
+    "ChromeInstancesResponse",
+    {
+        "count": int,
+        "instances": List["ChromeInstance"],
+    },
+    total=False,
+)
+
+ChromeTab = TypedDict(
+    "ChromeTab",
+    {
+        "active": bool,
+        "description": str,
+        "devtoolsFrontendUrl": str,
+        "id": str,
+        "title": str,
+        "type": str,
+        "url": str,
+        "webSocketDebuggerUrl": str,
+        "windowId": int,
+    },
+    total=False,
+)
+
+ChromeTabsResponse = TypedDict(
+    "ChromeTabsResponse",
+    {
+        "count": int,
+        "tabs": List["ChromeTab"],
+    },
+    total=False,
+)
+
+ColorProfileInfo = TypedDict(
+    "ColorProfileInfo",
+    {
+        "converted": bool,
+        "description": str,
+        "mode": str,
+        "profile": str,
+        "source": str,
+        "warning": str,
+    },
+    total=False,
+)
+
+ErrorsResponse = TypedDict(
+    "ErrorsResponse",
+    {
+        "errors": List["RecentError"],
+        "panics": List["Report"],
+    },
+    total=False,
+)
+
+HealthResponse = TypedDict(
+    "HealthResponse",
+    {
+        "activity": "ActivityState",
+        "desktop_state": str,
+        "power": "PowerState",
+        "status": str,
+        "timestamp": str,
+        "version": str,
+    },
+    total=False,
+)
+
+JobInfo = TypedDict(
+    "JobInfo",
+    {
+        "cancelled": bool,
+        "id": str,
+        "method": str,
+        "source": str,
+        "started_at": str,
+    },
+    total=False,
+)
+
+JobListResponse = TypedDict(
+    "JobListResponse",
+    {
+        "count": int,
+        "jobs": List["JobInfo"],
+    },
+    total=False,
+)
+
+LogLevelRequest = TypedDict(
+    "LogLevelRequest",
+    {
+        "level": str,
+    },
+    total=False,
+)
+
+LogLevelResponse = TypedDict(
+    "LogLevelResponse",
+    {
+        "level": str,
+    },
+    total=False,
+)
+
+MCPError = TypedDict(
+    "MCPError",
+    {
+        "code": int,
+        "data": Any,
+        "message": str,
+    },
+    total=False,
+)
+
+MCPRequest = TypedDict(
+    "MCPRequest",
+    {
+        "id": Any,
+        "jsonrpc": str,
+        "method": str,
+        "params": Any,
+    },
+    total=False,
+)
+
+MCPResponse = TypedDict(
+    "MCPResponse",
+    {
+        "error": "MCPError",
+        "id": Any,
+        "jsonrpc": str,
+        "result": Any,
+    },
+    total=False,
+)
+
+MacroRecordRequest = TypedDict(
+    "MacroRecordRequest",
+    {
+        "interval": str,
+        "method": str,
+        "name": str,
+        "overwrite": bool,
+        "target": str,
+    },
+    total=False,
+)
+
+MacroResult = TypedDict(
+    "MacroResult",
+    {
+        "duration": int,
+        "frames": int,
+        "name": str,
+        "script": str,
+        "steps": int,
+        "workflow": str,
+    },
+    total=False,
+)
+
+MacroStatusResponse = TypedDict(
+    "MacroStatusResponse",
+    {
+        "name": str,
+        "status": str,
+    },
+    total=False,
+)
+
+Metadata = TypedDict(
+    "Metadata",
+    {
+        "attempts": List["CaptureAttempt"],
+        "black_frame_detected": bool,
+        "capture_method": str,
+        "color_depth": int,
+        "color_profile": "ColorProfileInfo",
+        "dpi_scaling": float,
+        "ghost_window": int,
+        "occluded_by": List[int],
+        "occluded_percent": float,
+        "owned_windows": List["WindowInfo"],
+        "processing_time": int,
+        "properties": Dict[str, str],
+        "reduction": "ResponseReduction",
+        "retries": int,
+        "stability": "StabilityInfo",
+        "timing": "CaptureTiming",
+        "window_hung": bool,
+        "window_minimized": bool,
+        "window_visible": bool,
+    },
+    total=False,
+)
+
+MethodRanking = TypedDict(
+    "MethodRanking",
+    {
+        "class_name": str,
+        "methods": List["MethodScore"],
+    },
+    total=False,
+)
+
+MethodScore = TypedDict(
+    "MethodScore",
+    {
+        "attempts": int,
+        "average_latency": int,
+        "method": str,
+        "ranked": bool,
+        "success_rate": float,
+        "successes": int,
+    },
+    total=False,
+)
+
+MethodUsage = TypedDict(
+    "MethodUsage",
+    {
+        "attempts": int,
+        "success_rate": float,
+        "successes": int,
+    },
+    total=False,
+)
+
+MonitorInfo = TypedDict(
+    "MonitorInfo",
+    {
+        "dpi": int,
+        "index": int,
+        "name": str,
+        "primary": bool,
+        "rect": "Rectangle",
+        "scale_factor": float,
+        "work_area": "Rectangle",
+    },
+    total=False,
+)
+
+OutputListResponse = TypedDict(
+    "OutputListResponse",
+    {
+        "outputs": List["OutputStatus"],
+    },
+    total=False,
+)
+
+OutputRequest = TypedDict(
+    "OutputRequest",
+    {
+        "bitrate": int,
+        "fps": int,
+        "height": int,
+        "target": str,
+        "url": str,
+        "width": int,
+    },
+    total=False,
+)
+
+OutputStatus = TypedDict(
+    "OutputStatus",
+    {
+        "bitrate": int,
+        "failed_frames": int,
+        "fps": int,
+        "frames": int,
+        "height": int,
+        "id": str,
+        "last_error": str,
+        "running": bool,
+        "started_at": str,
+        "stopped_at": Optional[str],
+        "target": str,
+        "url": str,
+        "width": int,
+    },
+    total=False,
+)
+
+PipelineStage = TypedDict(
+    "PipelineStage",
+    {
+        "params": Any,
+        "stage": str,
+    },
+    total=False,
+)
+
+PixelCondition = TypedDict(
+    "PixelCondition",
+    {
+        "color": str,
+        "tolerance": int,
+        "x": int,
+        "y": int,
+    },
+    total=False,
+)
+
+PluginInfo = TypedDict(
+    "PluginInfo",
+    {
+        "description": str,
+        "dir": str,
+        "error": str,
+        "name": str,
+        "running": bool,
+        "stages": List["PluginStage"],
+        "tools": List["PluginTool"],
+        "version": str,
+    },
+    total=False,
+)
+
+PluginListResponse = TypedDict(
+    "PluginListResponse",
+    {
+        "count": int,
+        "plugins": List["PluginInfo"],
+    },
+    total=False,
+)
+
+PluginStage = TypedDict(
+    "PluginStage",
+    {
+        "description": str,
+        "name": str,
+    },
+    total=False,
+)
+
+PluginTool = TypedDict(
+    "PluginTool",
+    {
+        "description": str,
+        "name": str,
+        "params": Any,
+    },
+    total=False,
+)
+
+Point = TypedDict(
+    "Point",
+    {
+        "x": int,
+        "y": int,
+    },
+    total=False,
+)
+
+PopupCapture = TypedDict(
+    "PopupCapture",
+    {
+        "classes": List[str],
+        "max_popups": int,
+        "within": str,
+    },
+    total=False,
+)
+
+PopupImage = TypedDict(
+    "PopupImage",
+    {
+        "data": str,
+        "format": str,
+        "height": int,
+        "reduction": "ResponseReduction",
+        "timestamp": str,
+        "width": int,
+        "window": "WindowInfo",
+    },
+    total=False,
+)
+
+PowerState = TypedDict(
+    "PowerState",
+    {
+        "battery_percent": Optional[int],
+        "battery_saver": bool,
+        "source": str,
+    },
+    total=False,
+)
+
+RankingsResponse = TypedDict(
+    "RankingsResponse",
+    {
+        "adaptive": bool,
+        "rankings": List["MethodRanking"],
+    },
+    total=False,
+)
+
+ReadinessReport = TypedDict(
+    "ReadinessReport",
+    {
+        "capabilities": Dict[str, "CapabilityStatus"],
+        "desktop_state": str,
+        "go_version": str,
+        "monitors": List["MonitorInfo"],
+        "os_version": str,
+        "platform": str,
+        "probe_time": int,
+        "status": str,
+        "timestamp": str,
+        "version": str,
+    },
+    total=False,
+)
+
+RecentError = TypedDict(
+    "RecentError",
+    {
+        "code": str,
+        "error": str,
+        "method": str,
+        "target": str,
+        "time": str,
+    },
+    total=False,
+)
+
+RecordingInfo = TypedDict(
+    "RecordingInfo",
+    {
+        "bytes_written": int,
+        "decimation": int,
+        "directory": str,
+        "end_time": Optional[str],
+        "error": str,
+        "event_count": int,
+        "frame_count": int,
+        "id": str,
+        "mode": str,
+        "options": "RecordingOptions",
+        "output": str,
+        "session_id": str,
+        "start_time": str,
+        "status": str,
+        "window_id": int,
+    },
+    total=False,
+)
+
+RecordingListResponse = TypedDict(
+    "RecordingListResponse",
+    {
+        "count": int,
+        "recordings": List["RecordingInfo"],
+    },
+    total=False,
+)
+
+RecordingOptions = TypedDict(
+    "RecordingOptions",
+    {
+        "format": str,
+        "fps": float,
+        "include_input": bool,
+        "max_duration": int,
+        "max_size": int,
+        "max_width": int,
+        "mode": str,
+        "output": str,
+        "overlay": bool,
+        "playback_fps": float,
+        "quality": int,
+        "window_id": int,
+    },
+    total=False,
+)
+
+RecordingRequest = TypedDict(
+    "RecordingRequest",
+    {
+        "format": str,
+        "fps": float,
+        "include_input": bool,
+        "max_duration": str,
+        "max_size": int,
+        "max_width": int,
+        "mode": str,
+        "output": str,
+        "overlay": bool,
+        "playback_fps": float,
+        "quality": int,
+        "window_id": int,
+    },
+    total=False,
+)
+
+Rectangle = TypedDict(
+    "Rectangle",
+    {
+        "height": int,
+        "width": int,
+        "x": int,
+        "y": int,
+    },
+    total=False,
+)
+
+Report = TypedDict(
+    "Report",
+    {
+        "component": str,
+        "context": Dict[str, str],
+        "id": str,
+        "panic": str,
+        "platform": str,
+        "stack": str,
+        "time": str,
+        "version": str,
+    },
+    total=False,
+)
+
+ResponseReduction = TypedDict(
+    "ResponseReduction",
+    {
+        "max_response_bytes": int,
+        "original_format": str,
+        "original_height": int,
+        "original_size": int,
+        "original_width": int,
+        "quality": int,
+        "scale": float,
+    },
+    total=False,
+)
+
+RpcDebugResponse = TypedDict(
+    "RpcDebugResponse",
+    {
+        "count": int,
+        "exchanges": List["RpcExchange"],
+    },
+    total=False,
+)
+
+RpcExchange = TypedDict(
+    "RpcExchange",
+    {
+        "client_ip": str,
+        "duration": int,
+        "error": str,
+        "error_code": int,
+        "id": Any,
+        "method": str,
+        "request": Any,
+        "response": Any,
+        "seq": int,
+        "time": str,
+        "transport": str,
+    },
+    total=False,
+)
+
+ScreenshotRequest = TypedDict(
+    "ScreenshotRequest",
+    {
+        "capture_ghost": bool,
+        "capture_method": str,
+        "capture_other_desktops": bool,
+        "color_management": str,
+        "fallback_methods": List[str],
+        "format": str,
+        "if_none_match": str,
+        "include_cursor": bool,
+        "include_owned_windows": bool,
+        "machine": str,
+        "match": str,
+        "max_response_bytes": int,
+        "method": str,
+        "options": Dict[str, str],
+        "pipeline": List["PipelineStage"],
+        "popups": "PopupCapture",
+        "quality": int,
+        "region": "Rectangle",
+        "region_relative_to": str,
+        "reject_black_frames": bool,
+        "retry_backoff": str,
+        "retry_count": Optional[int],
+        "sync_to_present": bool,
+        "target": str,
+        "top_level": bool,
+        "wait_for": "WaitCondition",
+        "wait_for_stable": "StabilityCondition",
+    },
+    total=False,
+)
+
+ScreenshotResponse = TypedDict(
+    "ScreenshotResponse",
+    {
+        "cached": bool,
+        "data": str,
+        "error": str,
+        "etag": str,
+        "format": str,
+        "height": int,
+        "metadata": "Metadata",
+        "popups": List["PopupImage"],
+        "size": int,
+        "success": bool,
+        "timestamp": str,
+        "unchanged": bool,
+        "width": int,
+    },
+    total=False,
+)
+
+ScrollCaptureRequest = TypedDict(
+    "ScrollCaptureRequest",
+    {
+        "format": str,
+        "max_pages": int,
+        "max_response_bytes": int,
+        "method": str,
+        "pipeline": List["PipelineStage"],
+        "quality": int,
+        "scroll": str,
+        "settle": str,
+        "target": str,
+        "wheel_notches": int,
+    },
+    total=False,
+)
+
+ScrollCaptureResponse = TypedDict(
+    "ScrollCaptureResponse",
+    {
+        "complete": bool,
+        "pages": int,
+        "screenshot": "ScreenshotResponse",
+        "unmatched": int,
+    },
+    total=False,
+)
+
+StabilityCondition = TypedDict(
+    "StabilityCondition",
+    {
+        "fail_on_timeout": bool,
+        "frames": int,
+        "interval": str,
+        "threshold": float,
+        "timeout": str,
+        "tolerance": int,
+    },
+    total=False,
+)
+
+StabilityInfo = TypedDict(
+    "StabilityInfo",
+    {
+        "difference": float,
+        "frames": int,
+        "stable": bool,
+        "waited": int,
+    },
+    total=False,
+)
+
+StreamMessage = TypedDict(
+    "StreamMessage",
+    {
+        "data": Any,
+        "error": str,
+        "session_id": str,
+        "timestamp": str,
+        "type": str,
+    },
+    total=False,
+)
+
+StreamSessionStats = TypedDict(
+    "StreamSessionStats",
+    {
+        "achieved_fps": float,
+        "average_frame_size": int,
+        "bytes_sent": int,
+        "client_addr": str,
+        "connected": bool,
+        "connected_at": Optional[str],
+        "cpu_throttle": "CPUThrottle",
+        "cpu_usage": float,
+        "dropped_frames": int,
+        "failed_frames": int,
+        "format": str,
+        "frame_count": int,
+        "id": str,
+        "last_error": str,
+        "last_error_at": Optional[str],
+        "last_frame": Optional[str],
+        "missed_frames": int,
+        "monitor": Optional[int],
+        "quality": int,
+        "reconnects": int,
+        "recording_id": str,
+        "requested_fps": int,
+        "start_time": str,
+        "throttled_frames": int,
+        "user_agent": str,
+        "viewers": int,
+        "window_id": int,
+        "window_title": str,
+    },
+    total=False,
+)
+
+StreamStatusResponse = TypedDict(
+    "StreamStatusResponse",
+    {
+        "active_sessions": int,
+        "activity": "ActivityState",
+        "max_sessions": int,
+        "power": "PowerState",
+        "sessions": List["StreamSessionStats"],
+        "total_frames": int,
+        "total_sessions": int,
+        "uptime": str,
+    },
+    total=False,
+)
+
+TargetAlias = TypedDict(
+    "TargetAlias",
+    {
+        "capture_method": str,
+        "description": str,
+        "fallback_methods": List[str],
+        "format": str,
+        "match": str,
+        "method": str,
+        "name": str,
+        "pipeline": List["PipelineStage"],
+        "quality": int,
+        "target": str,
+    },
+    total=False,
+)
+
+TargetInfo = TypedDict(
+    "TargetInfo",
+    {
+        "alias": "TargetAlias",
+        "error": str,
+        "kind": str,
+        "name": str,
+        "status": str,
+        "url": str,
+        "version": str,
+    },
+    total=False,
+)
+
+TargetListResponse = TypedDict(
+    "TargetListResponse",
+    {
+        "count": int,
+        "targets": List["TargetInfo"],
+    },
+    total=False,
+)
+
+TimelineEvent = TypedDict(
+    "TimelineEvent",
+    {
+        "cursor": "Point",
+        "data": Dict[str, Any],
+        "frame": int,
+        "offset": int,
+        "timestamp": str,
+        "type": str,
+        "window": "WindowInfo",
+    },
+    total=False,
+)
+
+TimelineResponse = TypedDict(
+    "TimelineResponse",
+    {
+        "count": int,
+        "events": List["TimelineEvent"],
+    },
+    total=False,
+)
+
+UsageStats = TypedDict(
+    "UsageStats",
+    {
+        "captures": int,
+        "failed_captures": int,
+        "methods": Dict[str, "MethodUsage"],
+        "since": str,
+        "stream_bytes": int,
+        "stream_frames": int,
+        "stream_sessions": int,
+    },
+    total=False,
+)
+
+WaitCondition = TypedDict(
+    "WaitCondition",
+    {
+        "element": str,
+        "interval": str,
+        "pixel": "PixelCondition",
+        "stable_frames": int,
+        "timeout": str,
+        "title_regex": str,
+    },
+    total=False,
+)
+
+WebcamRequest = TypedDict(
+    "WebcamRequest",
+    {
+        "fps": int,
+        "height": int,
+        "target": str,
+        "width": int,
+    },
+    total=False,
+)
+
+WebcamStatus = TypedDict(
+    "WebcamStatus",
+    {
+        "connected": bool,
+        "failed_frames": int,
+        "fps": int,
+        "frames": int,
+        "height": int,
+        "last_error": str,
+        "running": bool,
+        "started_at": Optional[str],
+        "target": str,
+        "width": int,
+    },
+    total=False,
+)
+
+WindowInfo = TypedDict(
+    "WindowInfo",
+    {
+        "class_name": str,
+        "client_rect": "Rectangle",
+        "display_affinity": str,
+        "fullscreen": str,
+        "handle": int,
+        "integrity_level": str,
+        "is_topmost": bool,
+        "is_visible": bool,
+        "monitor": int,
+        "occluded_by": List[int],
+        "occluded_percent": float,
+        "on_current_desktop": Optional[bool],
+        "process_id": int,
+        "rect": "Rectangle",
+        "state": str,
+        "thread_id": int,
+        "title": str,
+        "virtual_desktop_id": str,
+        "z_order": int,
+    },
+    total=False,
+)
+
+WindowListResponse = TypedDict(
+    "WindowListResponse",
+    {
+        "count": int,
+        "windows": List["WindowInfo"],
+    },
+    total=False,
+)
+
+WorkflowCapture = TypedDict(
+    "WorkflowCapture",
+    {
+        "difference": Optional[float],
+        "label": str,
+        "line": int,
+        "reference": str,
+        "screenshot": "ScreenshotResponse",
+    },
+    total=False,
+)
+
+WorkflowInfo = TypedDict(
+    "WorkflowInfo",
+    {
+        "description": str,
+        "name": str,
+        "params": List["WorkflowParam"],
+    },
+    total=False,
+)
+
+WorkflowListResponse = TypedDict(
+    "WorkflowListResponse",
+    {
+        "count": int,
+        "errors": List[str],
+        "workflows": List["WorkflowInfo"],
+    },
+    total=False,
+)
+
+WorkflowParam = TypedDict(
+    "WorkflowParam",
+    {
+        "default": str,
+        "name": str,
+        "required": bool,
+    },
+    total=False,
+)
+
+WorkflowRunRequest = TypedDict(
+    "WorkflowRunRequest",
+    {
+        "params": Dict[str, str],
+    },
+    total=False,
+)
+
+WorkflowRunResponse = TypedDict(
+    "WorkflowRunResponse",
+    {
+        "captures": List["WorkflowCapture"],
+        "duration": int,
+        "steps": int,
+        "workflow": str,
+    },
+    total=False,
+)
+
+
+class GeneratedClient:
+    """REST methods of the screenshot server; Client supplies the transport."""
+
+    def _request(
+        self,
+        method: str,
+        path: str,
+        query: Optional[Mapping[str, Any]] = None,
+        body: Any = None,
+        binary: bool = False,
+    ) -> Any:
+        raise NotImplementedError
+
+    def get_health(
+        self,
+    ) -> HealthResponse:
+        """Server health"""
+        return self._request("GET", "/health")
+
+    def get_readiness(
+        self,
+    ) -> ReadinessReport:
+        """Server readiness. Probes capture, window enumeration, monitors, DWM composition and Chrome discovery. Responds 503 with the same body when a required capability fails."""
+        return self._request("GET", "/health/ready")
+
+    def post_mcp_message(
+        self,
+        body: MCPRequest,
+        *,
+        session_id: str,
+    ) -> None:
+        """Post a JSON-RPC message to an SSE session"""
+        return self._request("POST", "/messages", query={"sessionId": session_id}, body=body)
+
+    def get_metrics(
+        self,
+    ) -> bytes:
+        """Resource counters in the Prometheus text format. GDI handles created, released and outstanding per capture subsystem and kind, the GDI objects of the process on Windows, and the active streaming sessions"""
+        return self._request("GET", "/metrics", binary=True)
+
+    def get_open_api(
+        self,
+    ) -> Dict[str, Any]:
+        """This OpenAPI document"""
+        return self._request("GET", "/openapi.json")
+
+    def call_mcp(
+        self,
+        body: MCPRequest,
+    ) -> MCPResponse:
+        """MCP JSON-RPC 2.0 request"""
+        return self._request("POST", "/rpc", body=body)
+
+    def get_flame(
+        self,
+    ) -> bytes:
+        """Time spent in each capture stage as folded stacks. One "stack microseconds" line per stack of capture, process, encode (convert, compress) and send stages of screenshots and streams, counting its own time, for flamegraph.pl, speedscope or inferno. Totals accumulate since startup or the last DELETE. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/debug/flame", binary=True)
+
+    def reset_flame(
+        self,
+    ) -> None:
+        """Clear the capture stage totals. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("DELETE", "/v1/admin/debug/flame")
+
+    def list_profiles(
+        self,
+    ) -> bytes:
+        """List the pprof profiles of the running server. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/debug/pprof/", binary=True)
+
+    def get_profile(
+        self,
+        profile: Union[str, int],
+        *,
+        seconds: Optional[int] = None,
+        debug: Optional[int] = None,
+        gc: Optional[int] = None,
+    ) -> bytes:
+        """Take a pprof profile or execution trace. profile is a net/http/pprof name: profile (CPU), trace, heap, allocs, goroutine, block, mutex, threadcreate, cmdline or symbol. CPU samples carry a stage label, such as stream;encode;convert, and capture stages are regions of execution traces. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", f"/v1/admin/debug/pprof/{_path(profile)}", query={"seconds": seconds, "debug": debug, "gc": gc}, binary=True)
+
+    def list_rpc_exchanges(
+        self,
+    ) -> RpcDebugResponse:
+        """Recent MCP requests and responses. Available when the server runs with SCREENSHOT_DEBUG_RPC=true. Keeps the last 200 exchanges of every MCP transport with long strings such as image data truncated and tokens, passwords and secrets redacted. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/debug/rpc")
+
+    def clear_rpc_exchanges(
+        self,
+    ) -> None:
+        """Drop the recorded MCP requests and responses. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("DELETE", "/v1/admin/debug/rpc")
+
+    def list_errors(
+        self,
+    ) -> ErrorsResponse:
+        """Recent capture errors and recovered panics. Panics of capture goroutines and handlers are recovered and listed with their stack and context, including those of earlier runs read from the crash log. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/errors")
+
+    def get_log_level(
+        self,
+    ) -> LogLevelResponse:
+        """Current log level. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/loglevel")
+
+    def set_log_level(
+        self,
+        body: LogLevelRequest,
+    ) -> LogLevelResponse:
+        """Change the log level at runtime. Applies to every log sink until the server restarts. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("PUT", "/v1/admin/loglevel", body=body)
+
+    def list_admin_sessions(
+        self,
+    ) -> AdminSessionsResponse:
+        """List stream sessions with their clients. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", "/v1/admin/sessions")
+
+    def get_admin_session(
+        self,
+        id: Union[str, int],
+    ) -> StreamSessionStats:
+        """Inspect a stream session. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("GET", f"/v1/admin/sessions/{_path(id)}")
+
+    def close_admin_session(
+        self,
+        id: Union[str, int],
+        *,
+        reason: Optional[str] = None,
+    ) -> StreamSessionStats:
+        """Force-close a stream session. Sends the client a WebSocket close frame (1008) with the reason; the session can't be resumed. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"""
+        return self._request("DELETE", f"/v1/admin/sessions/{_path(id)}", query={"reason": reason})
+
+    def list_agents(
+        self,
+    ) -> AgentListResponse:
+        """List connected capture agents"""
+        return self._request("GET", "/v1/agents")
+
+    def get_agent(
+        self,
+        id: Union[str, int],
+    ) -> AgentInfo:
+        """Get a connected capture agent. The agent's API, streams included, is served under /v1/agents/{id}/api, e.g. GET /v1/agents/{id}/api/v1/windows"""
+        return self._request("GET", f"/v1/agents/{_path(id)}")
+
+    def list_capture_sets(
+        self,
+    ) -> CaptureSetListResponse:
+        """List capture sets"""
+        return self._request("GET", "/v1/capture-sets")
+
+    def get_capture_set(
+        self,
+        name: Union[str, int],
+    ) -> CaptureSet:
+        """Get a capture set"""
+        return self._request("GET", f"/v1/capture-sets/{_path(name)}")
+
+    def put_capture_set(
+        self,
+        name: Union[str, int],
+        body: CaptureSet,
+    ) -> CaptureSet:
+        """Create or replace a capture set. Members are screenshot requests that capture right away: wait_for, wait_for_stable, popups and if_none_match aren't allowed. Responds 201 for a new set"""
+        return self._request("PUT", f"/v1/capture-sets/{_path(name)}", body=body)
+
+    def delete_capture_set(
+        self,
+        name: Union[str, int],
+    ) -> None:
+        """Delete a capture set"""
+        return self._request("DELETE", f"/v1/capture-sets/{_path(name)}")
+
+    def capture_capture_set(
+        self,
+        name: Union[str, int],
+    ) -> CaptureSetResponse:
+        """Capture a capture set's targets at the same instant. The members share one capture slot and start together; the response carries the instant they started and the spread between the earliest and latest capture. A failed member fails only its own result"""
+        return self._request("POST", f"/v1/capture-sets/{_path(name)}/capture")
+
+    def list_method_rankings(
+        self,
+    ) -> RankingsResponse:
+        """Capture method order learned per window class. Each capture method's success rate and average latency over its last 50 attempts on windows of each class. Fallbacks are tried best first once a method has 5 attempts on a class; adaptive is false for engines without fallbacks"""
+        return self._request("GET", "/v1/capture/rankings")
+
+    def list_chrome_instances(
+        self,
+    ) -> ChromeInstancesResponse:
+        """List Chrome instances with remote debugging"""
+        return self._request("GET", "/v1/chrome/instances")
+
+    def list_chrome_tabs(
+        self,
+    ) -> ChromeTabsResponse:
+        """List tabs of all Chrome instances"""
+        return self._request("GET", "/v1/chrome/tabs")
+
+    def list_chrome_tab_frames(
+        self,
+        id: Union[str, int],
+    ) -> ChromeFramesResponse:
+        """List out-of-process iframes of a tab"""
+        return self._request("GET", f"/v1/chrome/tabs/{_path(id)}/frames")
+
+    def take_chrome_tab_screenshot(
+        self,
+        id: Union[str, int],
+        *,
+        frames: Optional[str] = None,
+    ) -> ScreenshotResponse:
+        """Capture a Chrome tab. With frames=separate the response holds the tab screenshot and one per out-of-process iframe"""
+        return self._request("POST", f"/v1/chrome/tabs/{_path(id)}/screenshot", query={"frames": frames})
+
+    def get_diagnostics(
+        self,
+    ) -> bytes:
+        """Self-test and download a diagnostics bundle. Captures a test window with each capture method and returns a ZIP of the results and captures, system and display information, readiness probes and recent capture errors, to attach to bug reports"""
+        return self._request("GET", "/v1/diagnostics", binary=True)
+
+    def list_jobs(
+        self,
+    ) -> JobListResponse:
+        """List the captures, OCR calls and recordings in flight"""
+        return self._request("GET", "/v1/jobs")
+
+    def cancel_job(
+        self,
+        id: Union[str, int],
+    ) -> None:
+        """Cancel a job. The cancelled call fails with code CANCELLED (HTTP 499, MCP error -32800). Screenshot, batch, scrolling, capture set and workflow calls are jobs, named by the X-Job-ID request header or given an ID sent back in it; MCP calls are jobs named by their JSON-RPC ID. Cancelling a recording stops it"""
+        return self._request("DELETE", f"/v1/jobs/{_path(id)}")
+
+    def record_macro(
+        self,
+        body: MacroRecordRequest,
+    ) -> MacroStatusResponse:
+        """Start recording a macro of the user's input. Records clicks, key presses and typing, with screenshots of the window at the start, every interval and at the end, until stopped. Requires SCREENSHOT_ALLOW_INPUT=true; one macro is recorded at a time"""
+        return self._request("POST", "/v1/macros", body=body)
+
+    def play_macro(
+        self,
+        name: Union[str, int],
+        body: WorkflowRunRequest,
+    ) -> WorkflowRunResponse:
+        """Replay a macro. Runs the macro's workflow, replaying its input; each capture reports the share of pixels that differ from the recorded screenshot"""
+        return self._request("POST", f"/v1/macros/{_path(name)}/play", body=body)
+
+    def stop_macro(
+        self,
+        name: Union[str, int],
+    ) -> MacroResult:
+        """Stop recording a macro and save it as a workflow. Writes <name>.lua and its screenshots, in <name>.frames, to the workflows directory"""
+        return self._request("POST", f"/v1/macros/{_path(name)}/stop")
+
+    def list_outputs(
+        self,
+    ) -> OutputListResponse:
+        """List RTMP outputs"""
+        return self._request("GET", "/v1/outputs")
+
+    def start_output(
+        self,
+        body: OutputRequest,
+    ) -> OutputStatus:
+        """Publish a window or monitor to an RTMP server. Needs ffmpeg on PATH. Frames are scaled to the output's size with black bars and encoded as H.264 with a silent audio track. The status masks the stream key. Responds 503 when ffmpeg can't be started"""
+        return self._request("POST", "/v1/outputs", body=body)
+
+    def get_output(
+        self,
+        id: Union[str, int],
+    ) -> OutputStatus:
+        """Get an RTMP output's status"""
+        return self._request("GET", f"/v1/outputs/{_path(id)}")
+
+    def stop_output(
+        self,
+        id: Union[str, int],
+    ) -> OutputStatus:
+        """Stop an RTMP output and remove it from the list"""
+        return self._request("DELETE", f"/v1/outputs/{_path(id)}")
+
+    def list_plugins(
+        self,
+    ) -> PluginListResponse:
+        """Plugins with their MCP tools and pipeline stages. Plugins are started from the plugins directory (SCREENSHOT_PLUGIN_DIR) at startup. A plugin that failed to start or has exited is listed with its error"""
+        return self._request("GET", "/v1/plugins")
+
+    def get_process_icon(
+        self,
+        pid: Union[str, int],
+        *,
+        size: Optional[str] = None,
+    ) -> bytes:
+        """Process icon as PNG"""
+        return self._request("GET", f"/v1/processes/{_path(pid)}/icon", query={"size": size}, binary=True)
+
+    def list_recordings(
+        self,
+    ) -> RecordingListResponse:
+        """List recordings"""
+        return self._request("GET", "/v1/recordings")
+
+    def start_recording(
+        self,
+        body: RecordingRequest,
+    ) -> RecordingInfo:
+        """Start a recording"""
+        return self._request("POST", "/v1/recordings", body=body)
+
+    def get_recording(
+        self,
+        id: Union[str, int],
+    ) -> RecordingInfo:
+        """Get a recording"""
+        return self._request("GET", f"/v1/recordings/{_path(id)}")
+
+    def get_recording_archive(
+        self,
+        id: Union[str, int],
+    ) -> bytes:
+        """Download a finished recording as ZIP"""
+        return self._request("GET", f"/v1/recordings/{_path(id)}/archive", binary=True)
+
+    def get_recording_frame(
+        self,
+        id: Union[str, int],
+        frame: Union[str, int],
+    ) -> bytes:
+        """A recorded frame image"""
+        return self._request("GET", f"/v1/recordings/{_path(id)}/frames/{_path(frame)}", binary=True)
+
+    def get_recording_output(
+        self,
+        id: Union[str, int],
+    ) -> bytes:
+        """Download a timelapse's assembled GIF or video"""
+        return self._request("GET", f"/v1/recordings/{_path(id)}/output", binary=True)
+
+    def stop_recording(
+        self,
+        id: Union[str, int],
+    ) -> RecordingInfo:
+        """Stop a recording"""
+        return self._request("POST", f"/v1/recordings/{_path(id)}/stop")
+
+    def get_recording_timeline(
+        self,
+        id: Union[str, int],
+    ) -> TimelineResponse:
+        """Metadata timeline of a recording"""
+        return self._request("GET", f"/v1/recordings/{_path(id)}/timeline")
+
+    def take_screenshot_get(
+        self,
+        *,
+        method: Optional[str] = None,
+        target: Optional[str] = None,
+        format: Optional[str] = None,
+        quality: Optional[int] = None,
+        cursor: Optional[bool] = None,
+        match: Optional[str] = None,
+        top_level: Optional[bool] = None,
+        region: Optional[str] = None,
+        region_relative_to: Optional[str] = None,
+        capture_method: Optional[str] = None,
+        fallback_methods: Optional[str] = None,
+        retry_count: Optional[int] = None,
+        retry_backoff: Optional[str] = None,
+        reject_black_frames: Optional[bool] = None,
+        capture_other_desktops: Optional[bool] = None,
+        capture_ghost: Optional[bool] = None,
+        sync_to_present: Optional[bool] = None,
+        include_owned_windows: Optional[bool] = None,
+        max_response_bytes: Optional[int] = None,
+        pipeline: Optional[str] = None,
+        wait_for_stable: Optional[str] = None,
+        color_management: Optional[str] = None,
+    ) -> ScreenshotResponse:
+        """Capture a window with query parameters. Responses carry an ETag; with If-None-Match naming it, an unchanged capture is answered with 304 Not Modified"""
+        return self._request("GET", "/v1/screenshot", query={"method": method, "target": target, "format": format, "quality": quality, "cursor": cursor, "match": match, "top_level": top_level, "region": region, "region_relative_to": region_relative_to, "capture_method": capture_method, "fallback_methods": fallback_methods, "retry_count": retry_count, "retry_backoff": retry_backoff, "reject_black_frames": reject_black_frames, "capture_other_desktops": capture_other_desktops, "capture_ghost": capture_ghost, "sync_to_present": sync_to_present, "include_owned_windows": include_owned_windows, "max_response_bytes": max_response_bytes, "pipeline": pipeline, "wait_for_stable": wait_for_stable, "color_management": color_management})
+
+    def take_screenshot(
+        self,
+        body: ScreenshotRequest,
+    ) -> ScreenshotResponse:
+        """Capture a window, shell surface or the desktop. Responses carry an ETag of the request and what the capture looks like. With If-None-Match (or if_none_match) naming it, an unchanged capture is answered with 304 Not Modified"""
+        return self._request("POST", "/v1/screenshot", body=body)
+
+    def take_screenshot_batch(
+        self,
+        body: BatchScreenshotRequest,
+    ) -> BatchScreenshotResponse:
+        """Capture several windows, possibly on several machines. Requests run concurrently; targets of the form "machine:window" run on a federated target. A failed request fails only its own result"""
+        return self._request("POST", "/v1/screenshot/batch", body=body)
+
+    def take_scrolling_screenshot(
+        self,
+        body: ScrollCaptureRequest,
+    ) -> ScrollCaptureResponse:
+        """Capture a window's scrollable content into one tall image. Scrolls the window down with the mouse wheel or Page Down, capturing it after each scroll, and stitches the captures where they overlap. Requires SCREENSHOT_ALLOW_INPUT=true"""
+        return self._request("POST", "/v1/screenshot/scrolling", body=body)
+
+    def take_shell_screenshot(
+        self,
+        surface: Union[str, int],
+        *,
+        format: Optional[str] = None,
+        cursor: Optional[bool] = None,
+    ) -> ScreenshotResponse:
+        """Capture the taskbar, tray overflow or latest notification. surface is taskbar, tray_overflow or notifications"""
+        return self._request("GET", f"/v1/shell/{_path(surface)}", query={"format": format, "cursor": cursor})
+
+    def get_usage_stats(
+        self,
+    ) -> UsageStats:
+        """Cumulative capture and stream usage"""
+        return self._request("GET", "/v1/stats")
+
+    def get_stream_status(
+        self,
+    ) -> StreamStatusResponse:
+        """Streaming statistics"""
+        return self._request("GET", "/v1/stream/status")
+
+    def list_targets(
+        self,
+        *,
+        check: Optional[bool] = None,
+    ) -> TargetListResponse:
+        """List federated targets and target aliases. The servers of SCREENSHOT_TARGETS and the connected agents, which captures can be addressed to as "name:window", followed by the target aliases"""
+        return self._request("GET", "/v1/targets", query={"check": check})
+
+    def get_target_alias(
+        self,
+        name: Union[str, int],
+    ) -> TargetAlias:
+        """Get a target alias"""
+        return self._request("GET", f"/v1/targets/{_path(name)}")
+
+    def put_target_alias(
+        self,
+        name: Union[str, int],
+        body: TargetAlias,
+    ) -> TargetAlias:
+        """Create or replace a target alias. Requests capture the alias with method "alias" and its name as target, and streams with the target alias:{name}. Its capture method, format and quality apply where a request sets none, and its pipeline, such as redactions, runs ahead of the request's own. Responds 201 for a new alias"""
+        return self._request("PUT", f"/v1/targets/{_path(name)}", body=body)
+
+    def delete_target_alias(
+        self,
+        name: Union[str, int],
+    ) -> None:
+        """Delete a target alias"""
+        return self._request("DELETE", f"/v1/targets/{_path(name)}")
+
+    def list_tray_apps(
+        self,
+    ) -> WindowListResponse:
+        """Windows of processes with notification area icons"""
+        return self._request("GET", "/v1/tray")
+
+    def get_webcam(
+        self,
+    ) -> WebcamStatus:
+        """Get the virtual webcam's status"""
+        return self._request("GET", "/v1/webcam")
+
+    def start_webcam(
+        self,
+        body: WebcamRequest,
+    ) -> WebcamStatus:
+        """Show a window or monitor on the virtual webcam. Windows only; needs softcam's DirectShow filter registered with regsvr32. Frames are scaled to the camera's size with black bars. Responds 409 while the webcam runs and 503 when softcam can't be loaded"""
+        return self._request("POST", "/v1/webcam", body=body)
+
+    def stop_webcam(
+        self,
+    ) -> WebcamStatus:
+        """Stop the virtual webcam"""
+        return self._request("DELETE", "/v1/webcam")
+
+    def list_windows(
+        self,
+        *,
+        title_contains: Optional[str] = None,
+        visible_only: Optional[bool] = None,
+        exclude_system: Optional[bool] = None,
+        virtual_desktop: Optional[str] = None,
+    ) -> WindowListResponse:
+        """List top-level windows"""
+        return self._request("GET", "/v1/windows", query={"title_contains": title_contains, "visible_only": visible_only, "exclude_system": exclude_system, "virtual_desktop": virtual_desktop})
+
+    def get_window(
+        self,
+        handle: Union[str, int],
+    ) -> None:
+        """Window details (not yet implemented)"""
+        return self._request("GET", f"/v1/windows/{_path(handle)}")
+
+    def get_window_icon(
+        self,
+        handle: Union[str, int],
+        *,
+        size: Optional[str] = None,
+    ) -> bytes:
+        """Window icon as PNG"""
+        return self._request("GET", f"/v1/windows/{_path(handle)}/icon", query={"size": size}, binary=True)
+
+    def list_workflows(
+        self,
+    ) -> WorkflowListResponse:
+        """List workflow scripts with their parameters. Workflows are <name>.lua scripts of the workflows directory (SCREENSHOT_WORKFLOW_DIR); scripts that don't load are listed in errors"""
+        return self._request("GET", "/v1/workflows")
+
+    def run_workflow(
+        self,
+        name: Union[str, int],
+        body: WorkflowRunRequest,
+    ) -> WorkflowRunResponse:
+        """Run a workflow script. Calls the script's run function with the params, which focuses windows, waits and captures them with regions redacted, and returns its captures. A failed call fails the run with the script line in the error"""
+        return self._request("POST", f"/v1/workflows/{_path(name)}", body=body)
+
+
+def _path(value: Union[str, int]) -> str:
+    from urllib.parse import quote
+
+    return quote(str(value), safe="")
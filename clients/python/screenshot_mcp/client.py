@@ -0,0 +1,210 @@
+"""HTTP transport, MCP calls and streaming for the generated REST methods."""
+
+from __future__ import annotations
+
+import base64
+import itertools
+import json
+import time
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Mapping, Optional, Union
+
+from ._generated import GeneratedClient, ScreenshotResponse
+from .stream import FrameStream
+
+# Gateway statuses worth retrying when the server itself didn't classify the failure
+_RETRY_STATUSES = {429, 502, 503, 504}
+
+
+class APIError(Exception):
+    """A failed REST call. code is the server's error code, e.g. WINDOW_NOT_FOUND."""
+
+    def __init__(
+        self,
+        status: int,
+        message: str,
+        code: Optional[str] = None,
+        candidates: Optional[List[Dict[str, Any]]] = None,
+        desktop_state: Optional[str] = None,
+    ) -> None:
+        super().__init__(f"{message} ({code or 'HTTP'} {status})")
+        self.status = status
+        self.message = message
+        self.code = code
+        self.candidates = candidates or []
+        self.desktop_state = desktop_state
+
+
+class RPCError(Exception):
+    """A JSON-RPC error returned by an MCP method; capture failures carry data["code"]."""
+
+    def __init__(self, code: int, message: str, data: Any = None) -> None:
+        super().__init__(f"{message} (JSON-RPC {code})")
+        self.code = code
+        self.message = message
+        self.data = data
+
+    @property
+    def error_code(self) -> Optional[str]:
+        if isinstance(self.data, dict):
+            return self.data.get("code")
+        return None
+
+
+class Client(GeneratedClient):
+    """Client for a screenshot server.
+
+    >>> client = Client("http://localhost:8080")
+    >>> shot = client.take_screenshot({"method": "title", "target": "Calculator"})
+    >>> png = Client.image(shot)
+    """
+
+    def __init__(
+        self,
+        base_url: str = "http://localhost:8080",
+        *,
+        token: Optional[str] = None,
+        headers: Optional[Mapping[str, str]] = None,
+        timeout: float = 60.0,
+        retries: int = 2,
+        backoff: float = 0.2,
+    ) -> None:
+        self.base_url = base_url.rstrip("/")
+        self.headers: Dict[str, str] = dict(headers or {})
+        if token:
+            self.headers["Authorization"] = f"Bearer {token}"
+        self.timeout = timeout
+        self.retries = retries
+        self.backoff = backoff
+        self._ids = itertools.count(1)
+
+    @staticmethod
+    def image(response: ScreenshotResponse) -> bytes:
+        """Decode the image of a screenshot response."""
+        return base64.b64decode(response["data"])
+
+    def call(self, method: str, params: Any = None) -> Any:
+        """Invoke an MCP method over POST /rpc and return its result."""
+        request = {"jsonrpc": "2.0", "method": method, "params": params, "id": next(self._ids)}
+        response = self._request("POST", "/rpc", body=request)
+        if response.get("error"):
+            error = response["error"]
+            raise RPCError(error.get("code", 0), error.get("message", ""), error.get("data"))
+        return response.get("result")
+
+    def agent(self, agent_id: str) -> "Client":
+        """A client for a capture agent connected to this server through the relay.
+
+        Its calls and streams go through this server to the agent.
+        """
+        return Client(
+            self.base_url + "/v1/agents/" + urllib.parse.quote(agent_id, safe="") + "/api",
+            headers=self.headers,
+            timeout=self.timeout,
+            retries=self.retries,
+            backoff=self.backoff,
+        )
+
+    def stream(
+        self,
+        handle: Union[int, str] = 0,
+        *,
+        fps: Optional[int] = None,
+        quality: Optional[int] = None,
+        format: Optional[str] = None,
+        ack: bool = False,
+        max_unacked: Optional[int] = None,
+        record: bool = False,
+        record_output: Optional[str] = None,
+    ) -> FrameStream:
+        """Stream a window (0 streams the desktop); iterate the result for frames.
+
+        With ack=True the server waits for each frame to be consumed before sending
+        more, so a slow consumer lowers the frame rate instead of queueing frames.
+        With record=True the delivered frames are also written to a recording
+        ("frames" or "mp4" output), named by the stream's recording_id.
+        """
+        query = {"fps": fps, "quality": quality, "format": format}
+        if ack:
+            query.update(ack=True, max_unacked=max_unacked)
+        if record:
+            query.update(record=True, record_output=record_output)
+        url = self.base_url.replace("http", "ws", 1) + f"/v1/stream/{handle}"
+        encoded = _encode_query(query)
+        if encoded:
+            url += "?" + encoded
+        return FrameStream(url, self.headers, self.timeout)
+
+    def _request(
+        self,
+        method: str,
+        path: str,
+        query: Optional[Mapping[str, Any]] = None,
+        body: Any = None,
+        binary: bool = False,
+    ) -> Any:
+        url = self.base_url + path
+        encoded = _encode_query(query or {})
+        if encoded:
+            url += "?" + encoded
+        data = None
+        headers = dict(self.headers)
+        if body is not None:
+            data = json.dumps(body).encode()
+            headers["Content-Type"] = "application/json"
+
+        delay = self.backoff
+        for attempt in itertools.count():
+            try:
+                return self._send(method, url, data, headers, binary)
+            except APIError as err:
+                if err.code or err.status not in _RETRY_STATUSES or attempt >= self.retries:
+                    raise
+            except urllib.error.URLError:
+                if attempt >= self.retries:
+                    raise
+            time.sleep(delay)
+            delay *= 2
+
+    def _send(self, method: str, url: str, data: Optional[bytes], headers: Dict[str, str], binary: bool) -> Any:
+        request = urllib.request.Request(url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(request, timeout=self.timeout) as response:
+                content = response.read()
+                if binary:
+                    return content
+                if content and response.headers.get_content_type() == "application/json":
+                    return json.loads(content)
+                return None
+        except urllib.error.HTTPError as err:
+            raise _api_error(err) from None
+
+
+def _encode_query(query: Mapping[str, Any]) -> str:
+    values = {}
+    for key, value in query.items():
+        if value is None:
+            continue
+        if isinstance(value, bool):
+            value = "true" if value else "false"
+        values[key] = value
+    return urllib.parse.urlencode(values)
+
+
+def _api_error(err: urllib.error.HTTPError) -> APIError:
+    content = err.read()
+    try:
+        body = json.loads(content)
+    except ValueError:
+        body = None
+    if isinstance(body, dict) and body.get("error"):
+        return APIError(
+            err.code,
+            body["error"],
+            body.get("code"),
+            body.get("candidates"),
+            body.get("desktop_state"),
+        )
+    return APIError(err.code, content.decode(errors="replace").strip() or err.reason)
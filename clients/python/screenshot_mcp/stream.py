@@ -0,0 +1,123 @@
+"""Iterator over the frames of a WebSocket stream."""
+
+from __future__ import annotations
+
+import base64
+import json
+import re
+from dataclasses import dataclass
+from datetime import datetime
+from typing import Any, Dict, Iterator, Mapping, Optional
+
+from websockets.exceptions import ConnectionClosed
+from websockets.sync.client import connect
+
+_TIMESTAMP = re.compile(r"^([^.Z+]*?)(\.\d+)?(Z|[+-]\d\d:\d\d)?$")
+
+
+@dataclass
+class Frame:
+    """A decoded stream frame."""
+
+    number: int
+    width: int
+    height: int
+    format: str
+    data: bytes  # Encoded image
+    timestamp: Optional[datetime]
+    keyframe: bool = False  # Full-quality PNG requested with capture_now()
+    timing: Optional[Dict[str, Any]] = None  # Capture timestamps and server-side latencies (ns)
+
+
+class StreamError(Exception):
+    """The server refused or ended a stream."""
+
+
+class FrameStream:
+    """Frames of a window, delivered as they are iterated.
+
+    >>> with client.stream(handle, fps=5) as frames:
+    ...     for frame in frames:
+    ...         save(frame.data)
+    """
+
+    def __init__(self, url: str, headers: Mapping[str, str], timeout: float) -> None:
+        self._conn = connect(url, additional_headers=dict(headers), open_timeout=timeout, max_size=None)
+        first = json.loads(self._conn.recv())
+        if first.get("type") == "error":
+            self._conn.close()
+            raise StreamError(first.get("error", "stream refused"))
+        self.session_id: str = first.get("session_id", "")
+        self.resume_token: str = first.get("resume_token", "")
+        self.recording_id: str = first.get("recording_id", "")
+        self.desktop_state = "available"
+
+    def __iter__(self) -> Iterator[Frame]:
+        try:
+            for raw in self._conn:
+                message = json.loads(raw)
+                kind = message.get("type")
+                if kind in ("frame", "keyframe"):
+                    data = message.get("data") or {}
+                    yield _decode_frame(data)
+                    # The consumer has taken the frame; let the server send the next one
+                    if data.get("ack_required"):
+                        self._send({"command": "ack", "frame_number": data.get("frame_number", 0)})
+                elif kind in ("desktop_unavailable", "desktop_available"):
+                    self.desktop_state = (message.get("data") or {}).get("state", self.desktop_state)
+        except ConnectionClosed:
+            return
+
+    def capture_now(self) -> None:
+        """Ask for an immediate full-quality PNG keyframe."""
+        self._send({"command": "capture_now"})
+
+    def update_options(self, **options: Any) -> None:
+        """Change the stream's fps, quality, format, max_width or max_height."""
+        self._send({"command": "update_options", "options": options})
+
+    def close(self) -> None:
+        """Stop the server session and close the connection."""
+        try:
+            self._send({"command": "stop"})
+        except ConnectionClosed:
+            pass
+        self._conn.close()
+
+    def __enter__(self) -> "FrameStream":
+        return self
+
+    def __exit__(self, *exc: Any) -> None:
+        self.close()
+
+    def _send(self, message: Dict[str, Any]) -> None:
+        self._conn.send(json.dumps(message))
+
+
+def _decode_frame(data: Dict[str, Any]) -> Frame:
+    _, _, encoded = data.get("data_url", "").partition(";base64,")
+    timestamp = data.get("timestamp")
+    return Frame(
+        number=data.get("frame_number", 0),
+        width=data.get("width", 0),
+        height=data.get("height", 0),
+        format=data.get("format", ""),
+        data=base64.b64decode(encoded),
+        timestamp=_parse_time(timestamp) if timestamp else None,
+        keyframe=data.get("keyframe", False),
+        timing=data.get("timing"),
+    )
+
+
+def _parse_time(value: str) -> Optional[datetime]:
+    # Go timestamps carry nanoseconds, which fromisoformat doesn't accept before 3.11
+    match = _TIMESTAMP.match(value)
+    if not match:
+        return None
+    head, fraction, zone = match.groups()
+    fraction = "." + fraction[1:7].ljust(6, "0") if fraction else ""
+    zone = "+00:00" if zone in (None, "Z") else zone
+    try:
+        return datetime.fromisoformat(head + fraction + zone)
+    except ValueError:
+        return None
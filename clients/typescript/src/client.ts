@@ -0,0 +1,197 @@
+// HTTP transport, MCP calls and streaming for the generated REST methods.
+
+import { GeneratedClient, QueryValue, ScreenshotResponse, WindowInfo } from "./generated.js";
+import { FrameStream, StreamOptions } from "./stream.js";
+
+/** Gateway statuses worth retrying when the server itself didn't classify the failure */
+const retryStatuses = new Set([429, 502, 503, 504]);
+
+export interface ClientOptions {
+  /** Sent as "Authorization: Bearer <token>", for servers behind an authenticating proxy */
+  token?: string;
+  headers?: Record<string, string>;
+  /** Retries of network errors and gateway errors, default 2 */
+  retries?: number;
+  /** Delay before the first retry in milliseconds, doubling after each, default 200 */
+  backoff?: number;
+}
+
+/** A failed REST call; code is the server's error code, e.g. WINDOW_NOT_FOUND */
+export class APIError extends Error {
+  constructor(
+    readonly status: number,
+    message: string,
+    readonly code?: string,
+    readonly candidates: WindowInfo[] = [],
+    readonly desktopState?: string,
+  ) {
+    super(`${message} (${code ?? "HTTP"} ${status})`);
+    this.name = "APIError";
+  }
+}
+
+/** A JSON-RPC error returned by an MCP method; capture failures carry data.code */
+export class RPCError extends Error {
+  constructor(
+    readonly code: number,
+    message: string,
+    readonly data?: unknown,
+  ) {
+    super(`${message} (JSON-RPC ${code})`);
+    this.name = "RPCError";
+  }
+
+  get errorCode(): string | undefined {
+    const data = this.data as { code?: string } | undefined;
+    return data?.code;
+  }
+}
+
+/** Client for a screenshot server */
+export class Client extends GeneratedClient {
+  private readonly baseURL: string;
+  private readonly headers: Record<string, string>;
+  private readonly retries: number;
+  private readonly backoff: number;
+  private nextID = 1;
+
+  constructor(baseURL = "http://localhost:8080", options: ClientOptions = {}) {
+    super();
+    this.baseURL = baseURL.replace(/\/+$/, "");
+    this.headers = { ...options.headers };
+    if (options.token) {
+      this.headers["Authorization"] = `Bearer ${options.token}`;
+    }
+    this.retries = options.retries ?? 2;
+    this.backoff = options.backoff ?? 200;
+  }
+
+  /** Decodes the image of a screenshot response */
+  static image(response: ScreenshotResponse): Uint8Array {
+    const binary = atob(response.data ?? "");
+    const bytes = new Uint8Array(binary.length);
+    for (let i = 0; i < binary.length; i++) {
+      bytes[i] = binary.charCodeAt(i);
+    }
+    return bytes;
+  }
+
+  /** Invokes an MCP method over POST /rpc and returns its result */
+  async call<T = unknown>(method: string, params?: unknown): Promise<T> {
+    const response = await this.request<{
+      result?: T;
+      error?: { code: number; message: string; data?: unknown };
+    }>("POST", "/rpc", undefined, { jsonrpc: "2.0", method, params, id: this.nextID++ });
+    if (response.error) {
+      throw new RPCError(response.error.code, response.error.message, response.error.data);
+    }
+    return response.result as T;
+  }
+
+  /** A client for a capture agent connected to this server; its calls and streams go through this server */
+  agent(id: string): Client {
+    return new Client(`${this.baseURL}/v1/agents/${encodeURIComponent(id)}/api`, {
+      headers: this.headers,
+      retries: this.retries,
+      backoff: this.backoff,
+    });
+  }
+
+  /**
+   * Streams a window (0 streams the desktop). Iterate the result with for await; with
+   * ack the server waits for each frame to be consumed before sending more.
+   */
+  stream(handle: number | string = 0, options: StreamOptions = {}): Promise<FrameStream> {
+    const query: Record<string, QueryValue> = {
+      fps: options.fps,
+      quality: options.quality,
+      format: options.format,
+    };
+    if (options.ack) {
+      query.ack = true;
+      query.max_unacked = options.maxUnacked;
+    }
+    if (options.record) {
+      query.record = true;
+      query.record_output = options.recordOutput;
+    }
+    const url = this.baseURL.replace(/^http/, "ws") + `/v1/stream/${encodeURIComponent(String(handle))}` + encodeQuery(query);
+    return FrameStream.open(url);
+  }
+
+  protected async request<T>(
+    method: string,
+    path: string,
+    query?: Record<string, QueryValue>,
+    body?: unknown,
+    binary?: boolean,
+  ): Promise<T> {
+    const url = this.baseURL + path + encodeQuery(query ?? {});
+    const headers: Record<string, string> = { ...this.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    let delay = this.backoff;
+    for (let attempt = 0; ; attempt++) {
+      try {
+        return await this.send<T>(method, url, headers, body, binary);
+      } catch (err) {
+        const retryable = err instanceof APIError ? !err.code && retryStatuses.has(err.status) : err instanceof TypeError;
+        if (!retryable || attempt >= this.retries) {
+          throw err;
+        }
+      }
+      await new Promise((resolve) => setTimeout(resolve, delay));
+      delay *= 2;
+    }
+  }
+
+  private async send<T>(
+    method: string,
+    url: string,
+    headers: Record<string, string>,
+    body: unknown,
+    binary?: boolean,
+  ): Promise<T> {
+    const response = await fetch(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      throw await apiError(response);
+    }
+    if (binary) {
+      return (await response.arrayBuffer()) as T;
+    }
+    if (response.headers.get("Content-Type")?.startsWith("application/json")) {
+      return (await response.json()) as T;
+    }
+    return undefined as T;
+  }
+}
+
+function encodeQuery(query: Record<string, QueryValue>): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value !== undefined) {
+      params.set(key, String(value));
+    }
+  }
+  const encoded = params.toString();
+  return encoded ? `?${encoded}` : "";
+}
+
+async function apiError(response: Response): Promise<APIError> {
+  const text = await response.text();
+  try {
+    const body = JSON.parse(text);
+    if (body?.error) {
+      return new APIError(response.status, body.error, body.code, body.candidates, body.desktop_state);
+    }
+  } catch {
+    // Not JSON; use the text
+  }
+  return new APIError(response.status, text.trim() || response.statusText);
+}
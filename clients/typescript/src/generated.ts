@@ -0,0 +1,1152 @@
+// Code generated by clientgen from docs/openapi.json. DO NOT EDIT.
+
+export interface ActivityState {
+  capture_paused?: boolean;
+  gate?: string;
+  idle_after?: number;
+  idle_for?: number;
+  last_input?: string | null;
+  state?: string;
+}
+
+export interface AdminSessionsResponse {
+  count?: number;
+  sessions?: StreamSessionStats[];
+}
+
+export interface AgentInfo {
+  connected_at?: string;
+  hostname?: string;
+  id?: string;
+  platform?: string;
+  remote_addr?: string;
+  requests?: number;
+  streams?: number;
+  version?: string;
+}
+
+export interface AgentListResponse {
+  agents?: AgentInfo[];
+  count?: number;
+}
+
+export interface ApiError {
+  candidates?: WindowInfo[];
+  code?: string;
+  desktop_state?: string;
+  error?: string;
+}
+
+export interface BatchScreenshotRequest {
+  requests?: ScreenshotRequest[];
+}
+
+export interface BatchScreenshotResponse {
+  failed?: number;
+  results?: BatchScreenshotResult[];
+  succeeded?: number;
+}
+
+export interface BatchScreenshotResult {
+  code?: string;
+  error?: string;
+  machine?: string;
+  response?: ScreenshotResponse;
+  target?: string;
+}
+
+export interface CPUThrottle {
+  budget?: number;
+  fps?: number;
+  usage?: number;
+}
+
+export interface CapabilityStatus {
+  detail?: string;
+  duration?: number;
+  error?: string;
+  required?: boolean;
+  status?: string;
+  version?: string;
+}
+
+export interface CaptureAttempt {
+  duration?: number;
+  error?: string;
+  method?: string;
+  retry?: number;
+  success?: boolean;
+}
+
+export interface CaptureSet {
+  description?: string;
+  name?: string;
+  requests?: ScreenshotRequest[];
+}
+
+export interface CaptureSetListResponse {
+  count?: number;
+  sets?: CaptureSet[];
+}
+
+export interface CaptureSetResponse {
+  failed?: number;
+  name?: string;
+  results?: BatchScreenshotResult[];
+  spread?: number;
+  succeeded?: number;
+  timestamp?: string;
+}
+
+export interface CaptureTiming {
+  capture?: number;
+  captured_at?: string;
+  encode?: number;
+  monotonic_ns?: number;
+  process?: number;
+  send?: number;
+}
+
+export interface ChromeFrame {
+  rect?: Rectangle;
+  session_id?: string;
+  target_id?: string;
+  title?: string;
+  url?: string;
+}
+
+export interface ChromeFramesResponse {
+  count?: number;
+  frames?: ChromeFrame[];
+}
+
+export interface ChromeInstance {
+  debug_port?: number;
+  pid?: number;
+  profile_path?: string;
+  tabs?: ChromeTab[];
+  user_agent?: string;
+  version?: string;
+}
+
+export interface ChromeInstancesResponse {
+  count?: number;
+  instances?: ChromeInstance[];
+}
+
+export interface ChromeTab {
+  active?: boolean;
+  description?: string;
+  devtoolsFrontendUrl?: string;
+  id?: string;
+  title?: string;
+  type?: string;
+  url?: string;
+  webSocketDebuggerUrl?: string;
+  windowId?: number;
+}
+
+export interface ChromeTabsResponse {
+  count?: number;
+  tabs?: ChromeTab[];
+}
+
+export interface ColorProfileInfo {
+  converted?: boolean;
+  description?: string;
+  mode?: string;
+  profile?: string;
+  source?: string;
+  warning?: string;
+}
+
+export interface ErrorsResponse {
+  errors?: RecentError[];
+  panics?: Report[];
+}
+
+export interface HealthResponse {
+  activity?: ActivityState;
+  desktop_state?: string;
+  power?: PowerState;
+  status?: string;
+  timestamp?: string;
+  version?: string;
+}
+
+export interface JobInfo {
+  cancelled?: boolean;
+  id?: string;
+  method?: string;
+  source?: string;
+  started_at?: string;
+}
+
+export interface JobListResponse {
+  count?: number;
+  jobs?: JobInfo[];
+}
+
+export interface LogLevelRequest {
+  level?: string;
+}
+
+export interface LogLevelResponse {
+  level?: string;
+}
+
+export interface MCPError {
+  code?: number;
+  data?: unknown;
+  message?: string;
+}
+
+export interface MCPRequest {
+  id?: unknown;
+  jsonrpc?: string;
+  method?: string;
+  params?: unknown;
+}
+
+export interface MCPResponse {
+  error?: MCPError;
+  id?: unknown;
+  jsonrpc?: string;
+  result?: unknown;
+}
+
+export interface MacroRecordRequest {
+  interval?: string;
+  method?: string;
+  name?: string;
+  overwrite?: boolean;
+  target?: string;
+}
+
+export interface MacroResult {
+  duration?: number;
+  frames?: number;
+  name?: string;
+  script?: string;
+  steps?: number;
+  workflow?: string;
+}
+
+export interface MacroStatusResponse {
+  name?: string;
+  status?: string;
+}
+
+export interface Metadata {
+  attempts?: CaptureAttempt[];
+  black_frame_detected?: boolean;
+  capture_method?: string;
+  color_depth?: number;
+  color_profile?: ColorProfileInfo;
+  dpi_scaling?: number;
+  ghost_window?: number;
+  occluded_by?: number[];
+  occluded_percent?: number;
+  owned_windows?: WindowInfo[];
+  processing_time?: number;
+  properties?: Record<string, string>;
+  reduction?: ResponseReduction;
+  retries?: number;
+  stability?: StabilityInfo;
+  timing?: CaptureTiming;
+  window_hung?: boolean;
+  window_minimized?: boolean;
+  window_visible?: boolean;
+}
+
+export interface MethodRanking {
+  class_name?: string;
+  methods?: MethodScore[];
+}
+
+export interface MethodScore {
+  attempts?: number;
+  average_latency?: number;
+  method?: string;
+  ranked?: boolean;
+  success_rate?: number;
+  successes?: number;
+}
+
+export interface MethodUsage {
+  attempts?: number;
+  success_rate?: number;
+  successes?: number;
+}
+
+export interface MonitorInfo {
+  dpi?: number;
+  index?: number;
+  name?: string;
+  primary?: boolean;
+  rect?: Rectangle;
+  scale_factor?: number;
+  work_area?: Rectangle;
+}
+
+export interface OutputListResponse {
+  outputs?: OutputStatus[];
+}
+
+export interface OutputRequest {
+  bitrate?: number;
+  fps?: number;
+  height?: number;
+  target?: string;
+  url?: string;
+  width?: number;
+}
+
+export interface OutputStatus {
+  bitrate?: number;
+  failed_frames?: number;
+  fps?: number;
+  frames?: number;
+  height?: number;
+  id?: string;
+  last_error?: string;
+  running?: boolean;
+  started_at?: string;
+  stopped_at?: string | null;
+  target?: string;
+  url?: string;
+  width?: number;
+}
+
+export interface PipelineStage {
+  params?: unknown;
+  stage?: string;
+}
+
+export interface PixelCondition {
+  color?: string;
+  tolerance?: number;
+  x?: number;
+  y?: number;
+}
+
+export interface PluginInfo {
+  description?: string;
+  dir?: string;
+  error?: string;
+  name?: string;
+  running?: boolean;
+  stages?: PluginStage[];
+  tools?: PluginTool[];
+  version?: string;
+}
+
+export interface PluginListResponse {
+  count?: number;
+  plugins?: PluginInfo[];
+}
+
+export interface PluginStage {
+  description?: string;
+  name?: string;
+}
+
+export interface PluginTool {
+  description?: string;
+  name?: string;
+  params?: unknown;
+}
+
+export interface Point {
+  x?: number;
+  y?: number;
+}
+
+export interface PopupCapture {
+  classes?: string[];
+  max_popups?: number;
+  within?: string;
+}
+
+export interface PopupImage {
+  data?: string;
+  format?: string;
+  height?: number;
+  reduction?: ResponseReduction;
+  timestamp?: string;
+  width?: number;
+  window?: WindowInfo;
+}
+
+export interface PowerState {
+  battery_percent?: number | null;
+  battery_saver?: boolean;
+  source?: string;
+}
+
+export interface RankingsResponse {
+  adaptive?: boolean;
+  rankings?: MethodRanking[];
+}
+
+export interface ReadinessReport {
+  capabilities?: Record<string, CapabilityStatus>;
+  desktop_state?: string;
+  go_version?: string;
+  monitors?: MonitorInfo[];
+  os_version?: string;
+  platform?: string;
+  probe_time?: number;
+  status?: string;
+  timestamp?: string;
+  version?: string;
+}
+
+export interface RecentError {
+  code?: string;
+  error?: string;
+  method?: string;
+  target?: string;
+  time?: string;
+}
+
+export interface RecordingInfo {
+  bytes_written?: number;
+  decimation?: number;
+  directory?: string;
+  end_time?: string | null;
+  error?: string;
+  event_count?: number;
+  frame_count?: number;
+  id?: string;
+  mode?: string;
+  options?: RecordingOptions;
+  output?: string;
+  session_id?: string;
+  start_time?: string;
+  status?: string;
+  window_id?: number;
+}
+
+export interface RecordingListResponse {
+  count?: number;
+  recordings?: RecordingInfo[];
+}
+
+export interface RecordingOptions {
+  format?: string;
+  fps?: number;
+  include_input?: boolean;
+  max_duration?: number;
+  max_size?: number;
+  max_width?: number;
+  mode?: string;
+  output?: string;
+  overlay?: boolean;
+  playback_fps?: number;
+  quality?: number;
+  window_id?: number;
+}
+
+export interface RecordingRequest {
+  format?: string;
+  fps?: number;
+  include_input?: boolean;
+  max_duration?: string;
+  max_size?: number;
+  max_width?: number;
+  mode?: string;
+  output?: string;
+  overlay?: boolean;
+  playback_fps?: number;
+  quality?: number;
+  window_id?: number;
+}
+
+export interface Rectangle {
+  height?: number;
+  width?: number;
+  x?: number;
+  y?: number;
+}
+
+export interface Report {
+  component?: string;
+  context?: Record<string, string>;
+  id?: string;
+  panic?: string;
+  platform?: string;
+  stack?: string;
+  time?: string;
+  version?: string;
+}
+
+export interface ResponseReduction {
+  max_response_bytes?: number;
+  original_format?: string;
+  original_height?: number;
+  original_size?: number;
+  original_width?: number;
+  quality?: number;
+  scale?: number;
+}
+
+export interface RpcDebugResponse {
+  count?: number;
+  exchanges?: RpcExchange[];
+}
+
+export interface RpcExchange {
+  client_ip?: string;
+  duration?: number;
+  error?: string;
+  error_code?: number;
+  id?: unknown;
+  method?: string;
+  request?: unknown;
+  response?: unknown;
+  seq?: number;
+  time?: string;
+  transport?: string;
+}
+
+export interface ScreenshotRequest {
+  capture_ghost?: boolean;
+  capture_method?: string;
+  capture_other_desktops?: boolean;
+  color_management?: string;
+  fallback_methods?: string[];
+  format?: string;
+  if_none_match?: string;
+  include_cursor?: boolean;
+  include_owned_windows?: boolean;
+  machine?: string;
+  match?: string;
+  max_response_bytes?: number;
+  method?: string;
+  options?: Record<string, string>;
+  pipeline?: PipelineStage[];
+  popups?: PopupCapture;
+  quality?: number;
+  region?: Rectangle;
+  region_relative_to?: string;
+  reject_black_frames?: boolean;
+  retry_backoff?: string;
+  retry_count?: number | null;
+  sync_to_present?: boolean;
+  target?: string;
+  top_level?: boolean;
+  wait_for?: WaitCondition;
+  wait_for_stable?: StabilityCondition;
+}
+
+export interface ScreenshotResponse {
+  cached?: boolean;
+  data?: string;
+  error?: string;
+  etag?: string;
+  format?: string;
+  height?: number;
+  metadata?: Metadata;
+  popups?: PopupImage[];
+  size?: number;
+  success?: boolean;
+  timestamp?: string;
+  unchanged?: boolean;
+  width?: number;
+}
+
+export interface ScrollCaptureRequest {
+  format?: string;
+  max_pages?: number;
+  max_response_bytes?: number;
+  method?: string;
+  pipeline?: PipelineStage[];
+  quality?: number;
+  scroll?: string;
+  settle?: string;
+  target?: string;
+  wheel_notches?: number;
+}
+
+export interface ScrollCaptureResponse {
+  complete?: boolean;
+  pages?: number;
+  screenshot?: ScreenshotResponse;
+  unmatched?: number;
+}
+
+export interface StabilityCondition {
+  fail_on_timeout?: boolean;
+  frames?: number;
+  interval?: string;
+  threshold?: number;
+  timeout?: string;
+  tolerance?: number;
+}
+
+export interface StabilityInfo {
+  difference?: number;
+  frames?: number;
+  stable?: boolean;
+  waited?: number;
+}
+
+export interface StreamMessage {
+  data?: unknown;
+  error?: string;
+  session_id?: string;
+  timestamp?: string;
+  type?: string;
+}
+
+export interface StreamSessionStats {
+  achieved_fps?: number;
+  average_frame_size?: number;
+  bytes_sent?: number;
+  client_addr?: string;
+  connected?: boolean;
+  connected_at?: string | null;
+  cpu_throttle?: CPUThrottle;
+  cpu_usage?: number;
+  dropped_frames?: number;
+  failed_frames?: number;
+  format?: string;
+  frame_count?: number;
+  id?: string;
+  last_error?: string;
+  last_error_at?: string | null;
+  last_frame?: string | null;
+  missed_frames?: number;
+  monitor?: number | null;
+  quality?: number;
+  reconnects?: number;
+  recording_id?: string;
+  requested_fps?: number;
+  start_time?: string;
+  throttled_frames?: number;
+  user_agent?: string;
+  viewers?: number;
+  window_id?: number;
+  window_title?: string;
+}
+
+export interface StreamStatusResponse {
+  active_sessions?: number;
+  activity?: ActivityState;
+  max_sessions?: number;
+  power?: PowerState;
+  sessions?: StreamSessionStats[];
+  total_frames?: number;
+  total_sessions?: number;
+  uptime?: string;
+}
+
+export interface TargetAlias {
+  capture_method?: string;
+  description?: string;
+  fallback_methods?: string[];
+  format?: string;
+  match?: string;
+  method?: string;
+  name?: string;
+  pipeline?: PipelineStage[];
+  quality?: number;
+  target?: string;
+}
+
+export interface TargetInfo {
+  alias?: TargetAlias;
+  error?: string;
+  kind?: string;
+  name?: string;
+  status?: string;
+  url?: string;
+  version?: string;
+}
+
+export interface TargetListResponse {
+  count?: number;
+  targets?: TargetInfo[];
+}
+
+export interface TimelineEvent {
+  cursor?: Point;
+  data?: Record<string, unknown>;
+  frame?: number;
+  offset?: number;
+  timestamp?: string;
+  type?: string;
+  window?: WindowInfo;
+}
+
+export interface TimelineResponse {
+  count?: number;
+  events?: TimelineEvent[];
+}
+
+export interface UsageStats {
+  captures?: number;
+  failed_captures?: number;
+  methods?: Record<string, MethodUsage>;
+  since?: string;
+  stream_bytes?: number;
+  stream_frames?: number;
+  stream_sessions?: number;
+}
+
+export interface WaitCondition {
+  element?: string;
+  interval?: string;
+  pixel?: PixelCondition;
+  stable_frames?: number;
+  timeout?: string;
+  title_regex?: string;
+}
+
+export interface WebcamRequest {
+  fps?: number;
+  height?: number;
+  target?: string;
+  width?: number;
+}
+
+export interface WebcamStatus {
+  connected?: boolean;
+  failed_frames?: number;
+  fps?: number;
+  frames?: number;
+  height?: number;
+  last_error?: string;
+  running?: boolean;
+  started_at?: string | null;
+  target?: string;
+  width?: number;
+}
+
+export interface WindowInfo {
+  class_name?: string;
+  client_rect?: Rectangle;
+  display_affinity?: string;
+  fullscreen?: string;
+  handle?: number;
+  integrity_level?: string;
+  is_topmost?: boolean;
+  is_visible?: boolean;
+  monitor?: number;
+  occluded_by?: number[];
+  occluded_percent?: number;
+  on_current_desktop?: boolean | null;
+  process_id?: number;
+  rect?: Rectangle;
+  state?: string;
+  thread_id?: number;
+  title?: string;
+  virtual_desktop_id?: string;
+  z_order?: number;
+}
+
+export interface WindowListResponse {
+  count?: number;
+  windows?: WindowInfo[];
+}
+
+export interface WorkflowCapture {
+  difference?: number | null;
+  label?: string;
+  line?: number;
+  reference?: string;
+  screenshot?: ScreenshotResponse;
+}
+
+export interface WorkflowInfo {
+  description?: string;
+  name?: string;
+  params?: WorkflowParam[];
+}
+
+export interface WorkflowListResponse {
+  count?: number;
+  errors?: string[];
+  workflows?: WorkflowInfo[];
+}
+
+export interface WorkflowParam {
+  default?: string;
+  name?: string;
+  required?: boolean;
+}
+
+export interface WorkflowRunRequest {
+  params?: Record<string, string>;
+}
+
+export interface WorkflowRunResponse {
+  captures?: WorkflowCapture[];
+  duration?: number;
+  steps?: number;
+  workflow?: string;
+}
+
+export type QueryValue = string | number | boolean | undefined;
+
+/** REST methods of the screenshot server; Client supplies the transport. */
+export abstract class GeneratedClient {
+  protected abstract request<T>(
+    method: string,
+    path: string,
+    query?: Record<string, QueryValue>,
+    body?: unknown,
+    binary?: boolean,
+  ): Promise<T>;
+
+  /** Server health */
+  getHealth(): Promise<HealthResponse> {
+    return this.request<HealthResponse>("GET", `/health`);
+  }
+
+  /** Server readiness. Probes capture, window enumeration, monitors, DWM composition and Chrome discovery. Responds 503 with the same body when a required capability fails. */
+  getReadiness(): Promise<ReadinessReport> {
+    return this.request<ReadinessReport>("GET", `/health/ready`);
+  }
+
+  /** Post a JSON-RPC message to an SSE session */
+  postMCPMessage(body: MCPRequest, query: { sessionId: string }): Promise<void> {
+    return this.request<void>("POST", `/messages`, query, body);
+  }
+
+  /** Resource counters in the Prometheus text format. GDI handles created, released and outstanding per capture subsystem and kind, the GDI objects of the process on Windows, and the active streaming sessions */
+  getMetrics(): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/metrics`, undefined, undefined, true);
+  }
+
+  /** This OpenAPI document */
+  getOpenAPI(): Promise<Record<string, unknown>> {
+    return this.request<Record<string, unknown>>("GET", `/openapi.json`);
+  }
+
+  /** MCP JSON-RPC 2.0 request */
+  callMCP(body: MCPRequest): Promise<MCPResponse> {
+    return this.request<MCPResponse>("POST", `/rpc`, undefined, body);
+  }
+
+  /** Time spent in each capture stage as folded stacks. One "stack microseconds" line per stack of capture, process, encode (convert, compress) and send stages of screenshots and streams, counting its own time, for flamegraph.pl, speedscope or inferno. Totals accumulate since startup or the last DELETE. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  getFlame(): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/admin/debug/flame`, undefined, undefined, true);
+  }
+
+  /** Clear the capture stage totals. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  resetFlame(): Promise<void> {
+    return this.request<void>("DELETE", `/v1/admin/debug/flame`);
+  }
+
+  /** List the pprof profiles of the running server. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  listProfiles(): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/admin/debug/pprof/`, undefined, undefined, true);
+  }
+
+  /** Take a pprof profile or execution trace. profile is a net/http/pprof name: profile (CPU), trace, heap, allocs, goroutine, block, mutex, threadcreate, cmdline or symbol. CPU samples carry a stage label, such as stream;encode;convert, and capture stages are regions of execution traces. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  getProfile(profile: string | number, query: { seconds?: number; debug?: number; gc?: number } = {}): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/admin/debug/pprof/${encodeURIComponent(String(profile))}`, query, undefined, true);
+  }
+
+  /** Recent MCP requests and responses. Available when the server runs with SCREENSHOT_DEBUG_RPC=true. Keeps the last 200 exchanges of every MCP transport with long strings such as image data truncated and tokens, passwords and secrets redacted. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  listRPCExchanges(): Promise<RpcDebugResponse> {
+    return this.request<RpcDebugResponse>("GET", `/v1/admin/debug/rpc`);
+  }
+
+  /** Drop the recorded MCP requests and responses. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  clearRPCExchanges(): Promise<void> {
+    return this.request<void>("DELETE", `/v1/admin/debug/rpc`);
+  }
+
+  /** Recent capture errors and recovered panics. Panics of capture goroutines and handlers are recovered and listed with their stack and context, including those of earlier runs read from the crash log. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  listErrors(): Promise<ErrorsResponse> {
+    return this.request<ErrorsResponse>("GET", `/v1/admin/errors`);
+  }
+
+  /** Current log level. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  getLogLevel(): Promise<LogLevelResponse> {
+    return this.request<LogLevelResponse>("GET", `/v1/admin/loglevel`);
+  }
+
+  /** Change the log level at runtime. Applies to every log sink until the server restarts. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  setLogLevel(body: LogLevelRequest): Promise<LogLevelResponse> {
+    return this.request<LogLevelResponse>("PUT", `/v1/admin/loglevel`, undefined, body);
+  }
+
+  /** List stream sessions with their clients. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  listAdminSessions(): Promise<AdminSessionsResponse> {
+    return this.request<AdminSessionsResponse>("GET", `/v1/admin/sessions`);
+  }
+
+  /** Inspect a stream session. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  getAdminSession(id: string | number): Promise<StreamSessionStats> {
+    return this.request<StreamSessionStats>("GET", `/v1/admin/sessions/${encodeURIComponent(String(id))}`);
+  }
+
+  /** Force-close a stream session. Sends the client a WebSocket close frame (1008) with the reason; the session can't be resumed. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN) */
+  closeAdminSession(id: string | number, query: { reason?: string } = {}): Promise<StreamSessionStats> {
+    return this.request<StreamSessionStats>("DELETE", `/v1/admin/sessions/${encodeURIComponent(String(id))}`, query);
+  }
+
+  /** List connected capture agents */
+  listAgents(): Promise<AgentListResponse> {
+    return this.request<AgentListResponse>("GET", `/v1/agents`);
+  }
+
+  /** Get a connected capture agent. The agent's API, streams included, is served under /v1/agents/{id}/api, e.g. GET /v1/agents/{id}/api/v1/windows */
+  getAgent(id: string | number): Promise<AgentInfo> {
+    return this.request<AgentInfo>("GET", `/v1/agents/${encodeURIComponent(String(id))}`);
+  }
+
+  /** List capture sets */
+  listCaptureSets(): Promise<CaptureSetListResponse> {
+    return this.request<CaptureSetListResponse>("GET", `/v1/capture-sets`);
+  }
+
+  /** Get a capture set */
+  getCaptureSet(name: string | number): Promise<CaptureSet> {
+    return this.request<CaptureSet>("GET", `/v1/capture-sets/${encodeURIComponent(String(name))}`);
+  }
+
+  /** Create or replace a capture set. Members are screenshot requests that capture right away: wait_for, wait_for_stable, popups and if_none_match aren't allowed. Responds 201 for a new set */
+  putCaptureSet(name: string | number, body: CaptureSet): Promise<CaptureSet> {
+    return this.request<CaptureSet>("PUT", `/v1/capture-sets/${encodeURIComponent(String(name))}`, undefined, body);
+  }
+
+  /** Delete a capture set */
+  deleteCaptureSet(name: string | number): Promise<void> {
+    return this.request<void>("DELETE", `/v1/capture-sets/${encodeURIComponent(String(name))}`);
+  }
+
+  /** Capture a capture set's targets at the same instant. The members share one capture slot and start together; the response carries the instant they started and the spread between the earliest and latest capture. A failed member fails only its own result */
+  captureCaptureSet(name: string | number): Promise<CaptureSetResponse> {
+    return this.request<CaptureSetResponse>("POST", `/v1/capture-sets/${encodeURIComponent(String(name))}/capture`);
+  }
+
+  /** Capture method order learned per window class. Each capture method's success rate and average latency over its last 50 attempts on windows of each class. Fallbacks are tried best first once a method has 5 attempts on a class; adaptive is false for engines without fallbacks */
+  listMethodRankings(): Promise<RankingsResponse> {
+    return this.request<RankingsResponse>("GET", `/v1/capture/rankings`);
+  }
+
+  /** List Chrome instances with remote debugging */
+  listChromeInstances(): Promise<ChromeInstancesResponse> {
+    return this.request<ChromeInstancesResponse>("GET", `/v1/chrome/instances`);
+  }
+
+  /** List tabs of all Chrome instances */
+  listChromeTabs(): Promise<ChromeTabsResponse> {
+    return this.request<ChromeTabsResponse>("GET", `/v1/chrome/tabs`);
+  }
+
+  /** List out-of-process iframes of a tab */
+  listChromeTabFrames(id: string | number): Promise<ChromeFramesResponse> {
+    return this.request<ChromeFramesResponse>("GET", `/v1/chrome/tabs/${encodeURIComponent(String(id))}/frames`);
+  }
+
+  /** Capture a Chrome tab. With frames=separate the response holds the tab screenshot and one per out-of-process iframe */
+  takeChromeTabScreenshot(id: string | number, query: { frames?: "composite" | "separate" } = {}): Promise<ScreenshotResponse> {
+    return this.request<ScreenshotResponse>("POST", `/v1/chrome/tabs/${encodeURIComponent(String(id))}/screenshot`, query);
+  }
+
+  /** Self-test and download a diagnostics bundle. Captures a test window with each capture method and returns a ZIP of the results and captures, system and display information, readiness probes and recent capture errors, to attach to bug reports */
+  getDiagnostics(): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/diagnostics`, undefined, undefined, true);
+  }
+
+  /** List the captures, OCR calls and recordings in flight */
+  listJobs(): Promise<JobListResponse> {
+    return this.request<JobListResponse>("GET", `/v1/jobs`);
+  }
+
+  /** Cancel a job. The cancelled call fails with code CANCELLED (HTTP 499, MCP error -32800). Screenshot, batch, scrolling, capture set and workflow calls are jobs, named by the X-Job-ID request header or given an ID sent back in it; MCP calls are jobs named by their JSON-RPC ID. Cancelling a recording stops it */
+  cancelJob(id: string | number): Promise<void> {
+    return this.request<void>("DELETE", `/v1/jobs/${encodeURIComponent(String(id))}`);
+  }
+
+  /** Start recording a macro of the user's input. Records clicks, key presses and typing, with screenshots of the window at the start, every interval and at the end, until stopped. Requires SCREENSHOT_ALLOW_INPUT=true; one macro is recorded at a time */
+  recordMacro(body: MacroRecordRequest): Promise<MacroStatusResponse> {
+    return this.request<MacroStatusResponse>("POST", `/v1/macros`, undefined, body);
+  }
+
+  /** Replay a macro. Runs the macro's workflow, replaying its input; each capture reports the share of pixels that differ from the recorded screenshot */
+  playMacro(name: string | number, body: WorkflowRunRequest): Promise<WorkflowRunResponse> {
+    return this.request<WorkflowRunResponse>("POST", `/v1/macros/${encodeURIComponent(String(name))}/play`, undefined, body);
+  }
+
+  /** Stop recording a macro and save it as a workflow. Writes <name>.lua and its screenshots, in <name>.frames, to the workflows directory */
+  stopMacro(name: string | number): Promise<MacroResult> {
+    return this.request<MacroResult>("POST", `/v1/macros/${encodeURIComponent(String(name))}/stop`);
+  }
+
+  /** List RTMP outputs */
+  listOutputs(): Promise<OutputListResponse> {
+    return this.request<OutputListResponse>("GET", `/v1/outputs`);
+  }
+
+  /** Publish a window or monitor to an RTMP server. Needs ffmpeg on PATH. Frames are scaled to the output's size with black bars and encoded as H.264 with a silent audio track. The status masks the stream key. Responds 503 when ffmpeg can't be started */
+  startOutput(body: OutputRequest): Promise<OutputStatus> {
+    return this.request<OutputStatus>("POST", `/v1/outputs`, undefined, body);
+  }
+
+  /** Get an RTMP output's status */
+  getOutput(id: string | number): Promise<OutputStatus> {
+    return this.request<OutputStatus>("GET", `/v1/outputs/${encodeURIComponent(String(id))}`);
+  }
+
+  /** Stop an RTMP output and remove it from the list */
+  stopOutput(id: string | number): Promise<OutputStatus> {
+    return this.request<OutputStatus>("DELETE", `/v1/outputs/${encodeURIComponent(String(id))}`);
+  }
+
+  /** Plugins with their MCP tools and pipeline stages. Plugins are started from the plugins directory (SCREENSHOT_PLUGIN_DIR) at startup. A plugin that failed to start or has exited is listed with its error */
+  listPlugins(): Promise<PluginListResponse> {
+    return this.request<PluginListResponse>("GET", `/v1/plugins`);
+  }
+
+  /** Process icon as PNG */
+  getProcessIcon(pid: string | number, query: { size?: "small" | "large" } = {}): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/processes/${encodeURIComponent(String(pid))}/icon`, query, undefined, true);
+  }
+
+  /** List recordings */
+  listRecordings(): Promise<RecordingListResponse> {
+    return this.request<RecordingListResponse>("GET", `/v1/recordings`);
+  }
+
+  /** Start a recording */
+  startRecording(body: RecordingRequest): Promise<RecordingInfo> {
+    return this.request<RecordingInfo>("POST", `/v1/recordings`, undefined, body);
+  }
+
+  /** Get a recording */
+  getRecording(id: string | number): Promise<RecordingInfo> {
+    return this.request<RecordingInfo>("GET", `/v1/recordings/${encodeURIComponent(String(id))}`);
+  }
+
+  /** Download a finished recording as ZIP */
+  getRecordingArchive(id: string | number): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/recordings/${encodeURIComponent(String(id))}/archive`, undefined, undefined, true);
+  }
+
+  /** A recorded frame image */
+  getRecordingFrame(id: string | number, frame: string | number): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/recordings/${encodeURIComponent(String(id))}/frames/${encodeURIComponent(String(frame))}`, undefined, undefined, true);
+  }
+
+  /** Download a timelapse's assembled GIF or video */
+  getRecordingOutput(id: string | number): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/recordings/${encodeURIComponent(String(id))}/output`, undefined, undefined, true);
+  }
+
+  /** Stop a recording */
+  stopRecording(id: string | number): Promise<RecordingInfo> {
+    return this.request<RecordingInfo>("POST", `/v1/recordings/${encodeURIComponent(String(id))}/stop`);
+  }
+
+  /** Metadata timeline of a recording */
+  getRecordingTimeline(id: string | number): Promise<TimelineResponse> {
+    return this.request<TimelineResponse>("GET", `/v1/recordings/${encodeURIComponent(String(id))}/timeline`);
+  }
+
+  /** Capture a window with query parameters. Responses carry an ETag; with If-None-Match naming it, an unchanged capture is answered with 304 Not Modified */
+  takeScreenshotGET(query: { method?: string; target?: string; format?: "png" | "jpeg" | "bmp"; quality?: number; cursor?: boolean; match?: string; top_level?: boolean; region?: string; region_relative_to?: "window" | "client" | "screen"; capture_method?: string; fallback_methods?: string; retry_count?: number; retry_backoff?: string; reject_black_frames?: boolean; capture_other_desktops?: boolean; capture_ghost?: boolean; sync_to_present?: boolean; include_owned_windows?: boolean; max_response_bytes?: number; pipeline?: string; wait_for_stable?: string; color_management?: "none" | "embed" | "srgb" } = {}): Promise<ScreenshotResponse> {
+    return this.request<ScreenshotResponse>("GET", `/v1/screenshot`, query);
+  }
+
+  /** Capture a window, shell surface or the desktop. Responses carry an ETag of the request and what the capture looks like. With If-None-Match (or if_none_match) naming it, an unchanged capture is answered with 304 Not Modified */
+  takeScreenshot(body: ScreenshotRequest): Promise<ScreenshotResponse> {
+    return this.request<ScreenshotResponse>("POST", `/v1/screenshot`, undefined, body);
+  }
+
+  /** Capture several windows, possibly on several machines. Requests run concurrently; targets of the form "machine:window" run on a federated target. A failed request fails only its own result */
+  takeScreenshotBatch(body: BatchScreenshotRequest): Promise<BatchScreenshotResponse> {
+    return this.request<BatchScreenshotResponse>("POST", `/v1/screenshot/batch`, undefined, body);
+  }
+
+  /** Capture a window's scrollable content into one tall image. Scrolls the window down with the mouse wheel or Page Down, capturing it after each scroll, and stitches the captures where they overlap. Requires SCREENSHOT_ALLOW_INPUT=true */
+  takeScrollingScreenshot(body: ScrollCaptureRequest): Promise<ScrollCaptureResponse> {
+    return this.request<ScrollCaptureResponse>("POST", `/v1/screenshot/scrolling`, undefined, body);
+  }
+
+  /** Capture the taskbar, tray overflow or latest notification. surface is taskbar, tray_overflow or notifications */
+  takeShellScreenshot(surface: string | number, query: { format?: "png" | "jpeg" | "bmp"; cursor?: boolean } = {}): Promise<ScreenshotResponse> {
+    return this.request<ScreenshotResponse>("GET", `/v1/shell/${encodeURIComponent(String(surface))}`, query);
+  }
+
+  /** Cumulative capture and stream usage */
+  getUsageStats(): Promise<UsageStats> {
+    return this.request<UsageStats>("GET", `/v1/stats`);
+  }
+
+  /** Streaming statistics */
+  getStreamStatus(): Promise<StreamStatusResponse> {
+    return this.request<StreamStatusResponse>("GET", `/v1/stream/status`);
+  }
+
+  /** List federated targets and target aliases. The servers of SCREENSHOT_TARGETS and the connected agents, which captures can be addressed to as "name:window", followed by the target aliases */
+  listTargets(query: { check?: boolean } = {}): Promise<TargetListResponse> {
+    return this.request<TargetListResponse>("GET", `/v1/targets`, query);
+  }
+
+  /** Get a target alias */
+  getTargetAlias(name: string | number): Promise<TargetAlias> {
+    return this.request<TargetAlias>("GET", `/v1/targets/${encodeURIComponent(String(name))}`);
+  }
+
+  /** Create or replace a target alias. Requests capture the alias with method "alias" and its name as target, and streams with the target alias:{name}. Its capture method, format and quality apply where a request sets none, and its pipeline, such as redactions, runs ahead of the request's own. Responds 201 for a new alias */
+  putTargetAlias(name: string | number, body: TargetAlias): Promise<TargetAlias> {
+    return this.request<TargetAlias>("PUT", `/v1/targets/${encodeURIComponent(String(name))}`, undefined, body);
+  }
+
+  /** Delete a target alias */
+  deleteTargetAlias(name: string | number): Promise<void> {
+    return this.request<void>("DELETE", `/v1/targets/${encodeURIComponent(String(name))}`);
+  }
+
+  /** Windows of processes with notification area icons */
+  listTrayApps(): Promise<WindowListResponse> {
+    return this.request<WindowListResponse>("GET", `/v1/tray`);
+  }
+
+  /** Get the virtual webcam's status */
+  getWebcam(): Promise<WebcamStatus> {
+    return this.request<WebcamStatus>("GET", `/v1/webcam`);
+  }
+
+  /** Show a window or monitor on the virtual webcam. Windows only; needs softcam's DirectShow filter registered with regsvr32. Frames are scaled to the camera's size with black bars. Responds 409 while the webcam runs and 503 when softcam can't be loaded */
+  startWebcam(body: WebcamRequest): Promise<WebcamStatus> {
+    return this.request<WebcamStatus>("POST", `/v1/webcam`, undefined, body);
+  }
+
+  /** Stop the virtual webcam */
+  stopWebcam(): Promise<WebcamStatus> {
+    return this.request<WebcamStatus>("DELETE", `/v1/webcam`);
+  }
+
+  /** List top-level windows */
+  listWindows(query: { title_contains?: string; visible_only?: boolean; exclude_system?: boolean; virtual_desktop?: string } = {}): Promise<WindowListResponse> {
+    return this.request<WindowListResponse>("GET", `/v1/windows`, query);
+  }
+
+  /** Window details (not yet implemented) */
+  getWindow(handle: string | number): Promise<void> {
+    return this.request<void>("GET", `/v1/windows/${encodeURIComponent(String(handle))}`);
+  }
+
+  /** Window icon as PNG */
+  getWindowIcon(handle: string | number, query: { size?: "small" | "large" } = {}): Promise<ArrayBuffer> {
+    return this.request<ArrayBuffer>("GET", `/v1/windows/${encodeURIComponent(String(handle))}/icon`, query, undefined, true);
+  }
+
+  /** List workflow scripts with their parameters. Workflows are <name>.lua scripts of the workflows directory (SCREENSHOT_WORKFLOW_DIR); scripts that don't load are listed in errors */
+  listWorkflows(): Promise<WorkflowListResponse> {
+    return this.request<WorkflowListResponse>("GET", `/v1/workflows`);
+  }
+
+  /** Run a workflow script. Calls the script's run function with the params, which focuses windows, waits and captures them with regions redacted, and returns its captures. A failed call fails the run with the script line in the error */
+  runWorkflow(name: string | number, body: WorkflowRunRequest): Promise<WorkflowRunResponse> {
+    return this.request<WorkflowRunResponse>("POST", `/v1/workflows/${encodeURIComponent(String(name))}`, undefined, body);
+  }
+}
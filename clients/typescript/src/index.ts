@@ -0,0 +1,9 @@
+// TypeScript client for the Screenshot MCP Server. The REST methods and schema types in
+// generated.ts come from the server's OpenAPI document (`make clients`); the transport,
+// MCP calls and frame streaming are written by hand.
+
+export * from "./generated.js";
+export { APIError, Client, RPCError } from "./client.js";
+export type { ClientOptions } from "./client.js";
+export { FrameStream } from "./stream.js";
+export type { Frame, StreamOptions } from "./stream.js";
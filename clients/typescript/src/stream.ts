@@ -0,0 +1,186 @@
+// Async iterator over the frames of a WebSocket stream.
+
+import type { CaptureTiming } from "./generated.js";
+
+export interface StreamOptions {
+  fps?: number;
+  quality?: number;
+  format?: "png" | "jpeg";
+  /** Make the server wait for each frame to be consumed before sending more */
+  ack?: boolean;
+  /** Frames in flight in ack mode, default 1 */
+  maxUnacked?: number;
+  /** Tee the delivered frames into a recording on the server */
+  record?: boolean;
+  recordOutput?: "frames" | "mp4";
+}
+
+/** A decoded stream frame */
+export interface Frame {
+  number: number;
+  width: number;
+  height: number;
+  format: string;
+  /** Encoded image */
+  data: Uint8Array;
+  timestamp: Date;
+  /** Full-quality PNG requested with captureNow */
+  keyframe: boolean;
+  /** Capture timestamps and server-side latencies (ns) */
+  timing?: CaptureTiming;
+}
+
+interface StreamMessage {
+  type: string;
+  session_id?: string;
+  resume_token?: string;
+  recording_id?: string;
+  error?: string;
+  data?: {
+    frame_number?: number;
+    width?: number;
+    height?: number;
+    format?: string;
+    data_url?: string;
+    timestamp?: string;
+    ack_required?: boolean;
+    keyframe?: boolean;
+    timing?: CaptureTiming;
+    state?: string;
+  };
+}
+
+/**
+ * Frames of a window, delivered as they are iterated:
+ *
+ *     const frames = await client.stream(handle, { fps: 5 });
+ *     for await (const frame of frames) { ... }
+ */
+export class FrameStream implements AsyncIterable<Frame> {
+  sessionID = "";
+  resumeToken = "";
+  /** Recording the stream is teed into, when opened with record */
+  recordingID = "";
+  desktopState = "available";
+
+  private readonly queue: StreamMessage[] = [];
+  private waiting?: () => void;
+  private closed = false;
+  private error?: Error;
+
+  private constructor(private readonly socket: WebSocket) {
+    socket.addEventListener("message", (event) => {
+      this.queue.push(JSON.parse(String(event.data)));
+      this.wake();
+    });
+    socket.addEventListener("close", () => {
+      this.closed = true;
+      this.wake();
+    });
+    socket.addEventListener("error", () => {
+      this.error ??= new Error("stream connection failed");
+      this.closed = true;
+      this.wake();
+    });
+  }
+
+  /** Connects and waits for the server to start the session */
+  static async open(url: string): Promise<FrameStream> {
+    const stream = new FrameStream(new WebSocket(url));
+    const first = await stream.next();
+    if (!first || first.type === "error") {
+      stream.socket.close();
+      throw new Error(first?.error ?? stream.error?.message ?? "stream refused");
+    }
+    stream.sessionID = first.session_id ?? "";
+    stream.resumeToken = first.resume_token ?? "";
+    stream.recordingID = first.recording_id ?? "";
+    return stream;
+  }
+
+  async *[Symbol.asyncIterator](): AsyncIterator<Frame> {
+    for (;;) {
+      const message = await this.next();
+      if (!message) {
+        if (this.error) {
+          throw this.error;
+        }
+        return;
+      }
+      const data = message.data ?? {};
+      switch (message.type) {
+        case "frame":
+        case "keyframe":
+          yield decodeFrame(data);
+          // The consumer has taken the frame; let the server send the next one
+          if (data.ack_required) {
+            this.send({ command: "ack", frame_number: data.frame_number ?? 0 });
+          }
+          break;
+        case "desktop_unavailable":
+        case "desktop_available":
+          this.desktopState = data.state ?? this.desktopState;
+          break;
+      }
+    }
+  }
+
+  /** Asks for an immediate full-quality PNG keyframe */
+  captureNow(): void {
+    this.send({ command: "capture_now" });
+  }
+
+  /** Changes the stream's fps, quality, format, max_width or max_height */
+  updateOptions(options: Record<string, unknown>): void {
+    this.send({ command: "update_options", options });
+  }
+
+  /** Stops the server session and closes the connection */
+  close(): void {
+    if (this.socket.readyState === WebSocket.OPEN) {
+      this.send({ command: "stop" });
+    }
+    this.socket.close();
+  }
+
+  private send(message: Record<string, unknown>): void {
+    if (this.socket.readyState === WebSocket.OPEN) {
+      this.socket.send(JSON.stringify(message));
+    }
+  }
+
+  private async next(): Promise<StreamMessage | undefined> {
+    while (this.queue.length === 0) {
+      if (this.closed) {
+        return undefined;
+      }
+      await new Promise<void>((resolve) => (this.waiting = resolve));
+    }
+    return this.queue.shift();
+  }
+
+  private wake(): void {
+    const waiting = this.waiting;
+    this.waiting = undefined;
+    waiting?.();
+  }
+}
+
+function decodeFrame(data: NonNullable<StreamMessage["data"]>): Frame {
+  const encoded = (data.data_url ?? "").split(";base64,")[1] ?? "";
+  const binary = atob(encoded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return {
+    number: data.frame_number ?? 0,
+    width: data.width ?? 0,
+    height: data.height ?? 0,
+    format: data.format ?? "",
+    data: bytes,
+    timestamp: new Date(data.timestamp ?? 0),
+    keyframe: data.keyframe ?? false,
+    timing: data.timing,
+  };
+}
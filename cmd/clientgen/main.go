@@ -0,0 +1,168 @@
+// Command clientgen generates the typed parts of the Python and TypeScript clients from
+// the server's OpenAPI document: a schema type per component and a method per REST
+// operation. The transports, MCP calls and the streaming frame iterator are written by
+// hand next to the generated files, which are regenerated with `make clients`.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/screenshot-mcp-server/internal/openapi"
+)
+
+// operation is a REST operation the clients get a method for
+type operation struct {
+	ID          string
+	Summary     string
+	Description string
+	Method      string
+	Path        string // OpenAPI syntax, e.g. /v1/windows/{handle}
+	PathParams  []openapi.Parameter
+	Query       []openapi.Parameter
+	Body        *openapi.Schema
+	Result      *openapi.Schema // JSON success body; nil if the body is binary or empty
+	Binary      bool
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/openapi.json", "OpenAPI document")
+	pythonPath := flag.String("python", "clients/python/screenshot_mcp/_generated.py", "Generated Python module")
+	typescriptPath := flag.String("typescript", "clients/typescript/src/generated.ts", "Generated TypeScript module")
+	flag.Parse()
+
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read OpenAPI document: %v", err)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		log.Fatalf("Failed to parse OpenAPI document: %v", err)
+	}
+
+	operations := collectOperations(&doc)
+	source := fmt.Sprintf("Code generated by clientgen from %s. DO NOT EDIT.", *specPath)
+
+	if err := os.WriteFile(*pythonPath, []byte(generatePython(&doc, operations, source)), 0o644); err != nil {
+		log.Fatalf("Failed to write Python client: %v", err)
+	}
+	if err := os.WriteFile(*typescriptPath, []byte(generateTypeScript(&doc, operations, source)), 0o644); err != nil {
+		log.Fatalf("Failed to write TypeScript client: %v", err)
+	}
+}
+
+// collectOperations lists the operations in path order. Deprecated aliases and routes
+// that upgrade to WebSocket or stream server-sent events are left to the hand-written code.
+func collectOperations(doc *openapi.Document) []operation {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var operations []operation
+	for _, path := range paths {
+		for _, method := range []string{"get", "post", "put", "delete"} {
+			op := doc.Paths[path][method]
+			if op == nil || op.Deprecated {
+				continue
+			}
+			if _, upgrade := op.Responses["101"]; upgrade {
+				continue
+			}
+
+			o := operation{
+				ID:          op.OperationID,
+				Summary:     op.Summary,
+				Description: op.Description,
+				Method:      strings.ToUpper(method),
+				Path:        path,
+			}
+			for _, param := range op.Parameters {
+				if param.In == "path" {
+					o.PathParams = append(o.PathParams, param)
+				} else {
+					o.Query = append(o.Query, param)
+				}
+			}
+			if op.RequestBody != nil {
+				o.Body = op.RequestBody.Content["application/json"].Schema
+			}
+
+			streaming := false
+			for status, resp := range op.Responses {
+				if status == "default" {
+					continue
+				}
+				for contentType, media := range resp.Content {
+					switch {
+					case contentType == "text/event-stream":
+						streaming = true
+					case contentType == "application/json":
+						o.Result = media.Schema
+					default:
+						o.Binary = true
+					}
+				}
+			}
+			if !streaming {
+				operations = append(operations, o)
+			}
+		}
+	}
+	return operations
+}
+
+// schemaNames returns the component schema names in order
+func schemaNames(doc *openapi.Document) []string {
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedProperties returns a schema's property names in order
+func sortedProperties(schema *openapi.Schema) []string {
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	return props
+}
+
+// refName returns the component name a $ref points at
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// pathSegments splits an OpenAPI path into literal text and parameter names, e.g.
+// /v1/windows/{handle}/icon into "/v1/windows/", "handle", "/icon"; odd elements are
+// parameters
+func pathSegments(path string) []string {
+	var segments []string
+	for {
+		open := strings.IndexByte(path, '{')
+		if open < 0 {
+			return append(segments, path)
+		}
+		end := strings.IndexByte(path[open:], '}') + open
+		segments = append(segments, path[:open], path[open+1:end])
+		path = path[end+1:]
+	}
+}
+
+// summaryLine returns the doc text of an operation
+func (o *operation) summaryLine() string {
+	if o.Description == "" {
+		return o.Summary
+	}
+	return o.Summary + ". " + o.Description
+}
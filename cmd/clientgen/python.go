@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/screenshot-mcp-server/internal/openapi"
+)
+
+// pythonKeywords are names that can't be used as parameter names
+var pythonKeywords = map[string]bool{
+	"and": true, "as": true, "class": true, "def": true, "del": true, "from": true,
+	"global": true, "import": true, "in": true, "is": true, "lambda": true, "not": true,
+	"or": true, "pass": true, "return": true, "with": true, "yield": true,
+}
+
+// generatePython emits TypedDicts for the schemas and a GeneratedClient base class whose
+// methods call the hand-written _request
+func generatePython(doc *openapi.Document, operations []operation, source string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", source)
+	b.WriteString("from __future__ import annotations\n\n")
+	b.WriteString("from typing import Any, Dict, List, Mapping, Optional, TypedDict, Union\n\n\n")
+
+	for _, name := range schemaNames(doc) {
+		schema := doc.Components.Schemas[name]
+		fmt.Fprintf(&b, "%s = TypedDict(\n    %q,\n    {\n", name, name)
+		for _, prop := range sortedProperties(schema) {
+			fmt.Fprintf(&b, "        %q: %s,\n", prop, pythonType(schema.Properties[prop], true))
+		}
+		b.WriteString("    },\n    total=False,\n)\n\n")
+	}
+
+	b.WriteString("\nclass GeneratedClient:\n")
+	b.WriteString("    \"\"\"REST methods of the screenshot server; Client supplies the transport.\"\"\"\n\n")
+	b.WriteString("    def _request(\n        self,\n        method: str,\n        path: str,\n")
+	b.WriteString("        query: Optional[Mapping[str, Any]] = None,\n        body: Any = None,\n        binary: bool = False,\n    ) -> Any:\n")
+	b.WriteString("        raise NotImplementedError\n")
+
+	for _, op := range operations {
+		b.WriteString("\n")
+		args := []string{"self"}
+		for _, param := range op.PathParams {
+			args = append(args, pythonName(param.Name)+": Union[str, int]")
+		}
+		if op.Body != nil {
+			args = append(args, "body: "+pythonType(op.Body, false))
+		}
+		if len(op.Query) > 0 {
+			args = append(args, "*")
+			for _, param := range op.Query {
+				if param.Required {
+					args = append(args, fmt.Sprintf("%s: %s", pythonName(param.Name), pythonType(param.Schema, false)))
+				} else {
+					args = append(args, fmt.Sprintf("%s: Optional[%s] = None", pythonName(param.Name), pythonType(param.Schema, false)))
+				}
+			}
+		}
+
+		result := "None"
+		switch {
+		case op.Binary:
+			result = "bytes"
+		case op.Result != nil:
+			result = pythonType(op.Result, false)
+		}
+
+		fmt.Fprintf(&b, "    def %s(\n", snakeCase(op.ID))
+		for _, arg := range args {
+			fmt.Fprintf(&b, "        %s,\n", arg)
+		}
+		fmt.Fprintf(&b, "    ) -> %s:\n", result)
+		fmt.Fprintf(&b, "        %s\n", pythonDocstring(op.summaryLine()))
+
+		var path strings.Builder
+		for i, segment := range pathSegments(op.Path) {
+			if i%2 == 0 {
+				path.WriteString(strings.NewReplacer("{", "{{", "}", "}}").Replace(segment))
+			} else {
+				fmt.Fprintf(&path, "{_path(%s)}", pythonName(segment))
+			}
+		}
+		call := []string{fmt.Sprintf("%q", op.Method)}
+		if len(op.PathParams) > 0 {
+			call = append(call, fmt.Sprintf("f%q", path.String()))
+		} else {
+			call = append(call, fmt.Sprintf("%q", path.String()))
+		}
+		if len(op.Query) > 0 {
+			var query []string
+			for _, param := range op.Query {
+				query = append(query, fmt.Sprintf("%q: %s", param.Name, pythonName(param.Name)))
+			}
+			call = append(call, "query={"+strings.Join(query, ", ")+"}")
+		}
+		if op.Body != nil {
+			call = append(call, "body=body")
+		}
+		if op.Binary {
+			call = append(call, "binary=True")
+		}
+		fmt.Fprintf(&b, "        return self._request(%s)\n", strings.Join(call, ", "))
+	}
+
+	b.WriteString("\n\ndef _path(value: Union[str, int]) -> str:\n")
+	b.WriteString("    from urllib.parse import quote\n\n")
+	b.WriteString("    return quote(str(value), safe=\"\")\n")
+	return b.String()
+}
+
+// pythonType returns the annotation of a schema; quoted refers to components by string
+// so TypedDicts can refer to classes defined after them
+func pythonType(schema *openapi.Schema, quoted bool) string {
+	if schema == nil {
+		return "Any"
+	}
+	var t string
+	switch {
+	case schema.Ref != "":
+		t = refName(schema.Ref)
+		if quoted {
+			t = fmt.Sprintf("%q", t)
+		}
+	case schema.Type == "string" && schema.Format == "binary":
+		t = "bytes"
+	case schema.Type == "string":
+		t = "str"
+	case schema.Type == "integer":
+		t = "int"
+	case schema.Type == "number":
+		t = "float"
+	case schema.Type == "boolean":
+		t = "bool"
+	case schema.Type == "array":
+		t = "List[" + pythonType(schema.Items, quoted) + "]"
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		t = "Dict[str, " + pythonType(schema.AdditionalProperties, quoted) + "]"
+	case schema.Type == "object":
+		t = "Dict[str, Any]"
+	default:
+		t = "Any"
+	}
+	if schema.Nullable && t != "Any" {
+		t = "Optional[" + t + "]"
+	}
+	return t
+}
+
+// pythonName makes a parameter name a valid identifier
+func pythonName(name string) string {
+	name = snakeCase(name)
+	if pythonKeywords[name] {
+		name += "_"
+	}
+	return name
+}
+
+// snakeCase converts camelCase identifiers such as listWindows or sessionId
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Break before an upper-case letter that starts a word: after a lower-case
+			// letter, or inside an acronym before a lower-case letter (MCPMessage)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func pythonDocstring(text string) string {
+	return `"""` + strings.ReplaceAll(text, `"""`, `\"\"\"`) + `"""`
+}
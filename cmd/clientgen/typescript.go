@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/screenshot-mcp-server/internal/openapi"
+)
+
+// identifier matches property names that need no quotes in TypeScript
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// generateTypeScript emits interfaces for the schemas and an abstract GeneratedClient
+// whose methods call the hand-written request
+func generateTypeScript(doc *openapi.Document, operations []operation, source string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\n", source)
+
+	for _, name := range schemaNames(doc) {
+		schema := doc.Components.Schemas[name]
+		fmt.Fprintf(&b, "export interface %s {\n", name)
+		for _, prop := range sortedProperties(schema) {
+			fmt.Fprintf(&b, "  %s?: %s;\n", typescriptKey(prop), typescriptType(schema.Properties[prop]))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString("export type QueryValue = string | number | boolean | undefined;\n\n")
+	b.WriteString("/** REST methods of the screenshot server; Client supplies the transport. */\n")
+	b.WriteString("export abstract class GeneratedClient {\n")
+	b.WriteString("  protected abstract request<T>(\n    method: string,\n    path: string,\n")
+	b.WriteString("    query?: Record<string, QueryValue>,\n    body?: unknown,\n    binary?: boolean,\n  ): Promise<T>;\n")
+
+	for _, op := range operations {
+		b.WriteString("\n")
+		var args []string
+		for _, param := range op.PathParams {
+			args = append(args, param.Name+": string | number")
+		}
+		if op.Body != nil {
+			args = append(args, "body: "+typescriptType(op.Body))
+		}
+		if len(op.Query) > 0 {
+			var fields []string
+			required := false
+			for _, param := range op.Query {
+				optional := "?"
+				if param.Required {
+					optional = ""
+					required = true
+				}
+				fields = append(fields, fmt.Sprintf("%s%s: %s", typescriptKey(param.Name), optional, typescriptType(param.Schema)))
+			}
+			arg := "query: { " + strings.Join(fields, "; ") + " }"
+			if !required {
+				arg += " = {}"
+			}
+			args = append(args, arg)
+		}
+
+		result := "void"
+		switch {
+		case op.Binary:
+			result = "ArrayBuffer"
+		case op.Result != nil:
+			result = typescriptType(op.Result)
+		}
+
+		var path strings.Builder
+		for i, segment := range pathSegments(op.Path) {
+			if i%2 == 0 {
+				path.WriteString(strings.NewReplacer("`", "\\`", "$", "\\$").Replace(segment))
+			} else {
+				fmt.Fprintf(&path, "${encodeURIComponent(String(%s))}", segment)
+			}
+		}
+
+		call := []string{fmt.Sprintf("%q", op.Method), "`" + path.String() + "`"}
+		switch {
+		case len(op.Query) > 0:
+			call = append(call, "query")
+		case op.Body != nil || op.Binary:
+			call = append(call, "undefined")
+		}
+		switch {
+		case op.Body != nil:
+			call = append(call, "body")
+		case op.Binary:
+			call = append(call, "undefined")
+		}
+		if op.Binary {
+			call = append(call, "true")
+		}
+
+		fmt.Fprintf(&b, "  /** %s */\n", strings.ReplaceAll(op.summaryLine(), "*/", "*\\/"))
+		fmt.Fprintf(&b, "  %s(%s): Promise<%s> {\n", op.ID, strings.Join(args, ", "), result)
+		fmt.Fprintf(&b, "    return this.request<%s>(%s);\n", result, strings.Join(call, ", "))
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// typescriptType returns the TypeScript type of a schema
+func typescriptType(schema *openapi.Schema) string {
+	if schema == nil {
+		return "unknown"
+	}
+	var t string
+	switch {
+	case schema.Ref != "":
+		t = refName(schema.Ref)
+	case schema.Type == "string" && len(schema.Enum) > 0:
+		quoted := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			quoted[i] = fmt.Sprintf("%q", value)
+		}
+		t = strings.Join(quoted, " | ")
+	case schema.Type == "string":
+		t = "string"
+	case schema.Type == "integer", schema.Type == "number":
+		t = "number"
+	case schema.Type == "boolean":
+		t = "boolean"
+	case schema.Type == "array":
+		t = typescriptType(schema.Items)
+		if strings.Contains(t, " ") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		t = "Record<string, " + typescriptType(schema.AdditionalProperties) + ">"
+	case schema.Type == "object":
+		t = "Record<string, unknown>"
+	default:
+		t = "unknown"
+	}
+	if schema.Nullable && t != "unknown" {
+		t += " | null"
+	}
+	return t
+}
+
+func typescriptKey(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
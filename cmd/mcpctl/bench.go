@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/client"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// benchMethodAliases are the short names bench accepts for capture methods
+var benchMethodAliases = map[string]types.CaptureMethod{
+	"dwm":  types.CaptureDWMThumbnail,
+	"full": types.CaptureRenderFullContent,
+}
+
+// benchResult summarizes the captures of one method
+type benchResult struct {
+	Method     types.CaptureMethod `json:"method"`
+	Iterations int                 `json:"iterations"`
+	Succeeded  int                 `json:"succeeded"`
+	Failed     int                 `json:"failed"`
+	FellBack   int                 `json:"fell_back"` // Captures the server completed with another method
+	Min        time.Duration       `json:"min"`
+	P50        time.Duration       `json:"p50"`
+	P95        time.Duration       `json:"p95"`
+	Max        time.Duration       `json:"max"`
+	Mean       time.Duration       `json:"mean"`
+	PerSecond  float64             `json:"per_second"` // Successful captures per second of wall time
+	AvgBytes   int64               `json:"avg_bytes"`  // Encoded size, or raw pixel size for local captures
+	LastError  string              `json:"last_error,omitempty"`
+}
+
+// benchCapture performs one capture and returns the method that produced it and its size
+type benchCapture func(method types.CaptureMethod) (types.CaptureMethod, int64, error)
+
+// parseBenchMethods resolves the --methods list, warning about and skipping names that
+// aren't capture methods
+func parseBenchMethods(list string) []types.CaptureMethod {
+	var methods []types.CaptureMethod
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if alias, ok := benchMethodAliases[name]; ok {
+			methods = append(methods, alias)
+			continue
+		}
+		method, err := types.ParseCaptureMethod(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", name, err)
+			continue
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// localBenchCapture captures in process. The Windows engine captures with exactly the
+// method asked for; other engines have a single method.
+func localBenchCapture(window string) benchCapture {
+	handle, err := strconv.ParseUint(window, 0, 64)
+	if err != nil {
+		log.Fatalf("Invalid window handle %q: %v", window, err)
+	}
+	engine, err := screenshot.NewEngine()
+	if err != nil {
+		log.Fatalf("Failed to create screenshot engine: %v", err)
+	}
+
+	return func(method types.CaptureMethod) (types.CaptureMethod, int64, error) {
+		options := types.DefaultCaptureOptions()
+		options.PreferredMethod = method
+		options.FallbackMethods = nil
+
+		var buffer *types.ScreenshotBuffer
+		var err error
+		if exact, ok := interface{}(engine).(interface {
+			CaptureWithMethod(uintptr, types.CaptureMethod, *types.CaptureOptions) (*types.ScreenshotBuffer, error)
+		}); ok {
+			buffer, err = exact.CaptureWithMethod(uintptr(handle), method, options)
+		} else {
+			buffer, err = engine.CaptureByHandle(uintptr(handle), options)
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		return buffer.CaptureMethod, int64(len(buffer.Data)), nil
+	}
+}
+
+// serverBenchCapture captures through the server at --server, timing the whole request
+// including encoding and transfer
+func serverBenchCapture(window string) benchCapture {
+	c, err := client.New(serverURL)
+	if err != nil {
+		log.Fatalf("Invalid server URL: %v", err)
+	}
+
+	return func(method types.CaptureMethod) (types.CaptureMethod, int64, error) {
+		shot, err := c.Screenshot(context.Background(), &types.ScreenshotRequest{
+			Method:        "handle",
+			Target:        window,
+			Format:        types.ImageFormat(format),
+			Quality:       quality,
+			CaptureMethod: method,
+		})
+		if err != nil {
+			return "", 0, err
+		}
+		return types.CaptureMethod(shot.Metadata.CaptureMethod), shot.Size, nil
+	}
+}
+
+// benchMethod captures warmup times untimed, then iterations times
+func benchMethod(capture benchCapture, method types.CaptureMethod, iterations, warmup int) benchResult {
+	for i := 0; i < warmup; i++ {
+		capture(method)
+	}
+
+	result := benchResult{Method: method, Iterations: iterations}
+	latencies := make([]time.Duration, 0, iterations)
+	var totalBytes int64
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		begin := time.Now()
+		used, size, err := capture(method)
+		elapsed := time.Since(begin)
+		if err != nil {
+			result.Failed++
+			result.LastError = err.Error()
+			continue
+		}
+		result.Succeeded++
+		if method != types.CaptureAuto && used != "" && used != method {
+			result.FellBack++
+		}
+		latencies = append(latencies, elapsed)
+		totalBytes += size
+	}
+	wall := time.Since(start)
+
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.Min = latencies[0]
+	result.P50 = percentile(latencies, 50)
+	result.P95 = percentile(latencies, 95)
+	result.Max = latencies[len(latencies)-1]
+	result.Mean = total / time.Duration(len(latencies))
+	result.PerSecond = float64(result.Succeeded) / wall.Seconds()
+	result.AvgBytes = totalBytes / int64(result.Succeeded)
+	return result
+}
+
+// percentile returns the p-th percentile of sorted latencies, by nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func runBench() {
+	if benchWindow == "" {
+		log.Fatalf("--window is required")
+	}
+	if benchIterations < 1 {
+		log.Fatalf("--iterations must be at least 1")
+	}
+	methods := parseBenchMethods(benchMethods)
+	if len(methods) == 0 {
+		log.Fatalf("No capture methods to benchmark")
+	}
+
+	capture := serverBenchCapture(benchWindow)
+	where := serverURL
+	if benchLocal {
+		capture = localBenchCapture(benchWindow)
+		where = "in process"
+	}
+
+	results := make([]benchResult, 0, len(methods))
+	for _, method := range methods {
+		if !benchJSON {
+			fmt.Fprintf(os.Stderr, "Benchmarking %s (%d iterations, %s)...\n", method, benchIterations, where)
+		}
+		results = append(results, benchMethod(capture, method, benchIterations, benchWarmup))
+	}
+
+	if benchJSON {
+		printJSON(results)
+		return
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "METHOD\tOK\tFAILED\tFELL BACK\tMIN\tP50\tP95\tMAX\tCAPTURES/S\tAVG SIZE\t")
+	for _, result := range results {
+		if result.Succeeded == 0 {
+			fmt.Fprintf(table, "%s\t%d\t%d\t%d\t-\t-\t-\t-\t-\t-\t\n", result.Method, result.Succeeded, result.Failed, result.FellBack)
+			continue
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f\t%s\t\n",
+			result.Method, result.Succeeded, result.Failed, result.FellBack,
+			formatLatency(result.Min), formatLatency(result.P50), formatLatency(result.P95), formatLatency(result.Max),
+			result.PerSecond, formatBytes(result.AvgBytes))
+	}
+	table.Flush()
+
+	for _, result := range results {
+		if result.LastError != "" {
+			fmt.Printf("%s: %d failed, last error: %s\n", result.Method, result.Failed, result.LastError)
+		}
+	}
+}
+
+// formatLatency rounds a latency for the table
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// formatBytes formats a size with a binary unit
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/screenshot-mcp-server/internal/chrome"
+	"github.com/screenshot-mcp-server/internal/discovery"
 	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/client"
 	"github.com/screenshot-mcp-server/pkg/types"
 )
 
@@ -17,6 +23,32 @@ var (
 	format    string
 	quality   int
 	output    string
+
+	discoverTimeout time.Duration
+	discoverJSON    bool
+
+	targetsCheck bool
+	targetsJSON  bool
+
+	aliasDescription     string
+	aliasMethod          string
+	aliasTarget          string
+	aliasMatch           string
+	aliasCaptureMethod   string
+	aliasFallbackMethods string
+	aliasPipeline        string
+	aliasRedact          []string
+	aliasRedactMode      string
+
+	batchMethod    string
+	batchOutputDir string
+
+	benchWindow     string
+	benchMethods    string
+	benchIterations int
+	benchWarmup     int
+	benchLocal      bool
+	benchJSON       bool
 )
 
 // rootCmd represents the base command
@@ -76,6 +108,15 @@ var captureByClassCmd = &cobra.Command{
 	},
 }
 
+var captureByProcessCmd = &cobra.Command{
+	Use:   "process [executable-name]",
+	Short: "Capture screenshot of a process's main window by executable name",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		captureScreenshot("process", args[0])
+	},
+}
+
 // Window commands
 var listWindowsCmd = &cobra.Command{
 	Use:   "list",
@@ -111,6 +152,97 @@ var captureTabCmd = &cobra.Command{
 	},
 }
 
+// discoverCmd finds servers advertised over mDNS
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find screenshot servers on the local network",
+	Long: `Find screenshot servers that advertise themselves over mDNS (started with
+SCREENSHOT_MDNS=true) on the local network.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		discoverServers()
+	},
+}
+
+// targetsCmd lists the servers the server federates captures to
+var targetsCmd = &cobra.Command{
+	Use:   "targets",
+	Short: "List the machines the server can capture on",
+	Long: `List the screenshot servers (SCREENSHOT_TARGETS) and relay agents the server at
+--server federates captures to, and its target aliases. Captures are addressed to the
+machines as "machine:window" and to aliases with method "alias".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listTargets()
+	},
+}
+
+// targetCmd manages the target aliases of the server
+var targetCmd = &cobra.Command{
+	Use:   "target",
+	Short: "Manage target aliases",
+	Long: `Manage the target aliases of the server at --server: names standing for a window
+lookup with its preferred capture method, redactions and default format. Requests capture
+an alias with method "alias" and its name as target; "mcpctl targets" lists them.`,
+}
+
+var targetGetCmd = &cobra.Command{
+	Use:   "get [name]",
+	Short: "Show a target alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		showTargetAlias(args[0])
+	},
+}
+
+var targetSetCmd = &cobra.Command{
+	Use:   "set [name]",
+	Short: "Create or replace a target alias",
+	Long: `Create or replace a target alias. --format and --quality, when given, are the
+alias's default format and quality; each --redact region is hidden in every capture of
+the alias, ahead of any --pipeline stages and the request's own pipeline.`,
+	Example: `  mcpctl target set crm-app --method title_regex --target "^CRM - " --capture-method dxgi \
+    --redact 0,0,400,80 --format jpeg --quality 80`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setTargetAlias(cmd, args[0])
+	},
+}
+
+var targetDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a target alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		deleteTargetAlias(args[0])
+	},
+}
+
+// batchCmd captures windows on one or more machines through the server
+var batchCmd = &cobra.Command{
+	Use:   "batch [machine:window]...",
+	Short: "Capture several windows, possibly on several machines, at once",
+	Long: `Capture windows through the server at --server in one batch. Each argument is a
+window on the server itself or, as "machine:window", on one of its targets.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		batchCapture(args)
+	},
+}
+
+// benchCmd compares the latency and throughput of capture methods
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark capture methods on a window",
+	Long: `Capture a window repeatedly with each capture method and compare their latency
+and throughput, to help pick stream settings. Captures go through the server at --server,
+timing whole requests including encoding, or with --local run in process with exactly the
+method given. The server falls back to other methods when one fails; FELL BACK counts
+those captures.`,
+	Example: `  mcpctl bench --window 0x1A2B --methods bitblt,printwindow,dwm --iterations 50`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBench()
+	},
+}
+
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&serverURL, "server", "http://localhost:8080", "Screenshot server URL")
@@ -122,11 +254,44 @@ func init() {
 	rootCmd.AddCommand(screenshotCmd)
 	rootCmd.AddCommand(windowsCmd)
 	rootCmd.AddCommand(chromeCmd)
+	rootCmd.AddCommand(discoverCmd)
+	rootCmd.AddCommand(targetsCmd)
+	rootCmd.AddCommand(targetCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(benchCmd)
+
+	// Discover flags
+	discoverCmd.Flags().DurationVar(&discoverTimeout, "timeout", 3*time.Second, "How long to wait for answers")
+	discoverCmd.Flags().BoolVar(&discoverJSON, "json", false, "Print the servers as JSON")
+
+	// Federation flags
+	targetsCmd.Flags().BoolVar(&targetsCheck, "check", false, "Check each target's health")
+	targetsCmd.Flags().BoolVar(&targetsJSON, "json", false, "Print the targets as JSON")
+	targetSetCmd.Flags().StringVar(&aliasDescription, "description", "", "What the alias captures")
+	targetSetCmd.Flags().StringVar(&aliasMethod, "method", "title", "Window lookup method (title, title_regex, process, ...)")
+	targetSetCmd.Flags().StringVar(&aliasTarget, "target", "", "Target of the lookup method, e.g. a title regex")
+	targetSetCmd.Flags().StringVar(&aliasMatch, "match", "", "\"best\" picks the top-ranked window of ambiguous title lookups")
+	targetSetCmd.Flags().StringVar(&aliasCaptureMethod, "capture-method", "", "Preferred capture method")
+	targetSetCmd.Flags().StringVar(&aliasFallbackMethods, "fallback-methods", "", "Comma-separated methods tried after --capture-method")
+	targetSetCmd.Flags().StringVar(&aliasPipeline, "pipeline", "", "Post-processing stages as a JSON array")
+	targetSetCmd.Flags().StringArrayVar(&aliasRedact, "redact", nil, "Region x,y,width,height to redact; repeatable")
+	targetSetCmd.Flags().StringVar(&aliasRedactMode, "redact-mode", "fill", "How --redact hides regions: fill, pixelate or blur")
+	batchCmd.Flags().StringVar(&batchMethod, "method", "title", "Window lookup method (title, handle, process, ...)")
+	batchCmd.Flags().StringVar(&batchOutputDir, "output-dir", "", "Directory to save the images in")
+
+	// Bench flags
+	benchCmd.Flags().StringVar(&benchWindow, "window", "", "Handle of the window to capture, decimal or 0x hex")
+	benchCmd.Flags().StringVar(&benchMethods, "methods", "bitblt,printwindow,dwm", "Comma-separated capture methods (dwm = dwmthumbnail)")
+	benchCmd.Flags().IntVar(&benchIterations, "iterations", 50, "Timed captures per method")
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup", 2, "Untimed captures per method before timing")
+	benchCmd.Flags().BoolVar(&benchLocal, "local", false, "Capture in process instead of through the server")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "Print the results as JSON")
 
 	// Screenshot subcommands
 	screenshotCmd.AddCommand(captureByTitleCmd)
 	screenshotCmd.AddCommand(captureByPIDCmd)
 	screenshotCmd.AddCommand(captureByClassCmd)
+	screenshotCmd.AddCommand(captureByProcessCmd)
 
 	// Windows subcommands
 	windowsCmd.AddCommand(listWindowsCmd)
@@ -135,6 +300,11 @@ func init() {
 	chromeCmd.AddCommand(listInstancesCmd)
 	chromeCmd.AddCommand(listTabsCmd)
 	chromeCmd.AddCommand(captureTabCmd)
+
+	// Target alias subcommands
+	targetCmd.AddCommand(targetGetCmd)
+	targetCmd.AddCommand(targetSetCmd)
+	targetCmd.AddCommand(targetDeleteCmd)
 }
 
 func main() {
@@ -165,6 +335,8 @@ func captureScreenshot(method, target string) {
 		log.Fatalf("PID capture not implemented in CLI demo")
 	case "class":
 		buffer, err = engine.CaptureByClassName(target, options)
+	case "process":
+		buffer, err = engine.CaptureByProcessName(target, options)
 	default:
 		log.Fatalf("Unknown method: %s", method)
 	}
@@ -305,6 +477,132 @@ func captureChromeTab(tabID string) {
 	}
 }
 
+func discoverServers() {
+	ctx, cancel := context.WithTimeout(context.Background(), discoverTimeout)
+	defer cancel()
+
+	services, err := discovery.Browse(ctx)
+	if err != nil {
+		log.Fatalf("Failed to discover servers: %v", err)
+	}
+
+	if discoverJSON {
+		printJSON(services)
+		return
+	}
+
+	if len(services) == 0 {
+		fmt.Println("No screenshot servers found")
+		return
+	}
+
+	fmt.Printf("Found %d screenshot server(s):\n", len(services))
+	for i, service := range services {
+		fmt.Printf("  [%d] %s\n", i+1, service.Instance)
+		fmt.Printf("      URL: %s\n", service.URL())
+		fmt.Printf("      Host: %s\n", service.Host)
+		if version := service.Text[discovery.TextVersion]; version != "" {
+			fmt.Printf("      Version: %s\n", version)
+		}
+		if auth := service.Text[discovery.TextAuth]; auth != "" {
+			fmt.Printf("      Auth: %s\n", auth)
+		}
+		if grpcPort := service.Text[discovery.TextGRPCPort]; grpcPort != "" {
+			fmt.Printf("      gRPC port: %s\n", grpcPort)
+		}
+	}
+}
+
+func listTargets() {
+	c, err := client.New(serverURL)
+	if err != nil {
+		log.Fatalf("Invalid server URL: %v", err)
+	}
+
+	targets, err := c.Targets(context.Background(), targetsCheck)
+	if err != nil {
+		log.Fatalf("Failed to list targets: %v", err)
+	}
+
+	if targetsJSON {
+		printJSON(targets)
+		return
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No targets configured")
+		return
+	}
+
+	fmt.Printf("Found %d target(s):\n", len(targets))
+	for i, target := range targets {
+		fmt.Printf("  [%d] %s (%s)\n", i+1, target.Name, target.Kind)
+		if target.URL != "" {
+			fmt.Printf("      URL: %s\n", target.URL)
+		}
+		if target.Alias != nil {
+			fmt.Printf("      Window: %s\n", describeTargetAlias(target.Alias))
+		}
+		if target.Status != "" {
+			fmt.Printf("      Status: %s\n", target.Status)
+		}
+		if target.Error != "" {
+			fmt.Printf("      Error: %s\n", target.Error)
+		}
+	}
+}
+
+func batchCapture(windows []string) {
+	c, err := client.New(serverURL)
+	if err != nil {
+		log.Fatalf("Invalid server URL: %v", err)
+	}
+
+	requests := make([]types.ScreenshotRequest, len(windows))
+	for i, window := range windows {
+		requests[i] = types.ScreenshotRequest{
+			Method:  batchMethod,
+			Target:  window,
+			Format:  types.ImageFormat(format),
+			Quality: quality,
+		}
+	}
+
+	response, err := c.BatchScreenshot(context.Background(), requests)
+	if err != nil {
+		log.Fatalf("Batch capture failed: %v", err)
+	}
+
+	for i, result := range response.Results {
+		name := result.Target
+		if result.Machine != "" {
+			name = result.Machine + ":" + result.Target
+		}
+		if result.Response == nil {
+			fmt.Printf("  [%d] %s: failed: %s\n", i+1, name, result.Error)
+			continue
+		}
+		fmt.Printf("  [%d] %s: %dx%d, %d bytes\n", i+1, name, result.Response.Width, result.Response.Height, result.Response.Size)
+
+		if batchOutputDir != "" {
+			data, err := base64.StdEncoding.DecodeString(result.Response.Data)
+			if err != nil {
+				log.Fatalf("Failed to decode image: %v", err)
+			}
+			machine := result.Machine
+			if machine == "" {
+				machine = "local"
+			}
+			path := filepath.Join(batchOutputDir, fmt.Sprintf("%d-%s.%s", i+1, machine, result.Response.Format))
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				log.Fatalf("Failed to save image: %v", err)
+			}
+			fmt.Printf("      Saved to %s\n", path)
+		}
+	}
+	fmt.Printf("Captured %d of %d window(s)\n", response.Succeeded, len(response.Results))
+}
+
 // Utility function to pretty print JSON
 func printJSON(v interface{}) {
 	data, err := json.MarshalIndent(v, "", "  ")
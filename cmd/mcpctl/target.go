@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/client"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// serverClient returns a client of the server at --server
+func serverClient() *client.Client {
+	c, err := client.New(serverURL)
+	if err != nil {
+		log.Fatalf("Invalid server URL: %v", err)
+	}
+	return c
+}
+
+func showTargetAlias(name string) {
+	alias, err := serverClient().TargetAlias(context.Background(), name)
+	if err != nil {
+		log.Fatalf("Failed to get target alias: %v", err)
+	}
+	printJSON(alias)
+}
+
+func setTargetAlias(cmd *cobra.Command, name string) {
+	alias := &types.TargetAlias{
+		Name:          name,
+		Description:   aliasDescription,
+		Method:        aliasMethod,
+		Target:        aliasTarget,
+		Match:         aliasMatch,
+		CaptureMethod: types.CaptureMethod(aliasCaptureMethod),
+	}
+	for _, method := range strings.Split(aliasFallbackMethods, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			alias.FallbackMethods = append(alias.FallbackMethods, types.CaptureMethod(method))
+		}
+	}
+	// The global --format and --quality are the alias's defaults only when given
+	if cmd.Flags().Changed("format") {
+		alias.Format = types.ImageFormat(format)
+	}
+	if cmd.Flags().Changed("quality") {
+		alias.Quality = quality
+	}
+
+	if aliasPipeline != "" {
+		if err := json.Unmarshal([]byte(aliasPipeline), &alias.Pipeline); err != nil {
+			log.Fatalf("Invalid --pipeline: %v", err)
+		}
+	}
+	if len(aliasRedact) > 0 {
+		regions := make([]types.Rectangle, len(aliasRedact))
+		for i, value := range aliasRedact {
+			region, err := parseRectangle(value)
+			if err != nil {
+				log.Fatalf("Invalid --redact: %v", err)
+			}
+			regions[i] = region
+		}
+		params, err := json.Marshal(map[string]interface{}{"regions": regions, "mode": aliasRedactMode})
+		if err != nil {
+			log.Fatalf("Invalid --redact: %v", err)
+		}
+		alias.Pipeline = append([]types.PipelineStage{{Stage: "redact", Params: params}}, alias.Pipeline...)
+	}
+
+	saved, err := serverClient().PutTargetAlias(context.Background(), alias)
+	if err != nil {
+		log.Fatalf("Failed to save target alias: %v", err)
+	}
+	fmt.Printf("Saved target alias %s: %s\n", saved.Name, describeTargetAlias(saved))
+}
+
+func deleteTargetAlias(name string) {
+	if err := serverClient().DeleteTargetAlias(context.Background(), name); err != nil {
+		log.Fatalf("Failed to delete target alias: %v", err)
+	}
+	fmt.Printf("Deleted target alias %s\n", name)
+}
+
+// describeTargetAlias summarizes the window lookup an alias stands for
+func describeTargetAlias(alias *types.TargetAlias) string {
+	lookup := alias.Method
+	if alias.Target != "" {
+		lookup += " " + strconv.Quote(alias.Target)
+	}
+	if alias.CaptureMethod != "" {
+		lookup += ", captured with " + string(alias.CaptureMethod)
+	}
+	if len(alias.Pipeline) > 0 {
+		lookup += fmt.Sprintf(", %d pipeline stage(s)", len(alias.Pipeline))
+	}
+	return lookup
+}
+
+// parseRectangle parses an "x,y,width,height" region
+func parseRectangle(value string) (types.Rectangle, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return types.Rectangle{}, fmt.Errorf("%q is not x,y,width,height", value)
+	}
+	var numbers [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return types.Rectangle{}, fmt.Errorf("%q is not x,y,width,height", value)
+		}
+		numbers[i] = n
+	}
+	return types.Rectangle{X: numbers[0], Y: numbers[1], Width: numbers[2], Height: numbers[3]}, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// parseActivityConfig validates the activity gate settings
+func parseActivityConfig(config *Config) (types.ActivityGate, time.Duration, error) {
+	gate, err := types.ParseActivityGate(config.ActivityGate)
+	if err != nil {
+		return "", 0, err
+	}
+	idleAfter, err := time.ParseDuration(config.IdleAfter)
+	if err != nil || idleAfter <= 0 {
+		return "", 0, fmt.Errorf("invalid idle_after %q: must be a positive duration", config.IdleAfter)
+	}
+	return gate, idleAfter, nil
+}
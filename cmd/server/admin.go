@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultCloseReason is sent in the close frame of a session closed without a reason
+const defaultCloseReason = "session closed by administrator"
+
+// adminSessionsResponse lists the stream sessions for operators
+type adminSessionsResponse struct {
+	Sessions []types.StreamSessionStats `json:"sessions"`
+	Count    int                        `json:"count"`
+}
+
+// logLevelRequest sets the log level at runtime
+type logLevelRequest struct {
+	Level string `json:"level" binding:"required"` // debug, info, warn or error
+}
+
+// logLevelResponse reports the current log level
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// adminAuth admits requests bearing the admin token. Without a configured token the
+// admin API is disabled.
+func (s *Server) adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.config.Load().AdminToken == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin API is disabled; set SCREENSHOT_ADMIN_TOKEN to enable it"})
+			return
+		}
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Load().AdminToken)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// listAdminSessions lists every stream session with its client and statistics
+func (s *Server) listAdminSessions(c *gin.Context) {
+	sessions := s.streamManager.GetStats().Sessions
+	c.JSON(http.StatusOK, adminSessionsResponse{Sessions: sessions, Count: len(sessions)})
+}
+
+// getAdminSession returns one stream session
+func (s *Server) getAdminSession(c *gin.Context) {
+	session, err := s.streamManager.GetSessionDetail(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// closeAdminSession force-closes a stream session. The client receives a close frame
+// carrying the reason query parameter and cannot resume the session.
+func (s *Server) closeAdminSession(c *gin.Context) {
+	sessionID := c.Param("id")
+	session, err := s.streamManager.GetSessionDetail(sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := c.DefaultQuery("reason", defaultCloseReason)
+	if err := s.streamManager.CloseSession(sessionID, reason); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.logger.Warn("Streaming session closed by administrator",
+		zap.String("session_id", sessionID),
+		zap.Uintptr("window_id", session.WindowID),
+		zap.String("client_addr", session.ClientAddr),
+		zap.String("reason", reason),
+		zap.String("admin_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, session)
+}
+
+// getLogLevel returns the current log level
+func (s *Server) getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, logLevelResponse{Level: s.logLevel.String()})
+}
+
+// setLogLevel changes the log level of every sink until the server restarts
+func (s *Server) setLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	level, err := zapcore.ParseLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := s.logLevel.Level()
+	s.logLevel.SetLevel(level)
+	s.logger.Warn("Log level changed by administrator",
+		zap.Stringer("from", previous),
+		zap.Stringer("to", level),
+		zap.String("admin_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, logLevelResponse{Level: level.String()})
+}
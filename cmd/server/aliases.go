@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// aliasMethod is the window lookup method of requests naming a target alias
+const aliasMethod = "alias"
+
+// errAliasNotFound is returned for an alias that isn't defined
+var errAliasNotFound = errors.New("target alias not found")
+
+// targetAliases holds the saved target aliases, persisted to a JSON file
+type targetAliases struct {
+	mutex   sync.RWMutex
+	path    string // File the aliases are saved to; "" keeps them in memory only
+	aliases map[string]types.TargetAlias
+}
+
+// loadTargetAliases reads the aliases saved to path; a missing file has none
+func loadTargetAliases(path string) (*targetAliases, error) {
+	a := &targetAliases{path: path, aliases: make(map[string]types.TargetAlias)}
+	if path == "" {
+		return a, nil
+	}
+	var aliases []types.TargetAlias
+	if _, err := readJSONFile(path, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to load target aliases: %w", err)
+	}
+	for _, alias := range aliases {
+		if err := validateTargetAlias(&alias); err != nil {
+			return nil, fmt.Errorf("invalid target alias %q in %s: %w", alias.Name, path, err)
+		}
+		a.aliases[alias.Name] = alias
+	}
+	return a, nil
+}
+
+// get returns an alias by name
+func (a *targetAliases) get(name string) (types.TargetAlias, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	alias, ok := a.aliases[name]
+	return alias, ok
+}
+
+// list returns the aliases sorted by name
+func (a *targetAliases) list() []types.TargetAlias {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	aliases := make([]types.TargetAlias, 0, len(a.aliases))
+	for _, alias := range a.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases
+}
+
+// put creates or replaces an alias and saves the aliases, reporting whether it's new
+func (a *targetAliases) put(alias types.TargetAlias) (bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	previous, existed := a.aliases[alias.Name]
+	a.aliases[alias.Name] = alias
+	if err := a.save(); err != nil {
+		if existed {
+			a.aliases[alias.Name] = previous
+		} else {
+			delete(a.aliases, alias.Name)
+		}
+		return false, err
+	}
+	return !existed, nil
+}
+
+// delete removes an alias and saves the aliases
+func (a *targetAliases) delete(name string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	alias, ok := a.aliases[name]
+	if !ok {
+		return errAliasNotFound
+	}
+	delete(a.aliases, name)
+	if err := a.save(); err != nil {
+		a.aliases[name] = alias
+		return err
+	}
+	return nil
+}
+
+// save writes the aliases to their file. The caller holds the lock.
+func (a *targetAliases) save() error {
+	if a.path == "" {
+		return nil
+	}
+	aliases := make([]types.TargetAlias, 0, len(a.aliases))
+	for _, alias := range a.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return writeJSONFile(a.path, aliases)
+}
+
+// validateTargetAlias checks an alias names a window lookup the server can perform and
+// capture settings it accepts
+func validateTargetAlias(alias *types.TargetAlias) error {
+	if !targetNamePattern.MatchString(alias.Name) {
+		return fmt.Errorf("invalid name %q: use 1-64 letters, digits, '.', '_' or '-'", alias.Name)
+	}
+	if !windowLookupMethod(alias.Method) {
+		return fmt.Errorf("invalid method %q", alias.Method)
+	}
+	if alias.Target == "" && methodRequiresTarget(alias.Method) {
+		return fmt.Errorf("method %s needs a target", alias.Method)
+	}
+	if alias.Method == "title_regex" {
+		if _, err := regexp.Compile(alias.Target); err != nil {
+			return fmt.Errorf("invalid title regex: %w", err)
+		}
+	}
+	if alias.Match != "" && alias.Match != "best" {
+		return fmt.Errorf("invalid match %q (valid: best)", alias.Match)
+	}
+	if err := applyCaptureMethods(&types.CaptureOptions{}, alias.CaptureMethod, alias.FallbackMethods); err != nil {
+		return err
+	}
+	if err := validateImageFormat(alias.Format); err != nil {
+		return err
+	}
+	if alias.Quality < 0 || alias.Quality > 100 {
+		return fmt.Errorf("invalid quality %d: must be 1-100", alias.Quality)
+	}
+	if err := validatePipeline(alias.Pipeline); err != nil {
+		return validationCause(err)
+	}
+	return nil
+}
+
+// validationCause returns what an error tagged by invalidRequest wraps, to report it
+// as part of a larger validation error
+func validationCause(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+	return err
+}
+
+// windowLookupMethod reports whether method is a lookup method a target alias can use
+func windowLookupMethod(method string) bool {
+	switch method {
+	case "title", "title_contains", "title_regex", "title_fuzzy", "pid", "process", "handle", "class", "foreground", "under_cursor":
+		return true
+	}
+	return types.IsShellSurface(method)
+}
+
+// expandTargetAlias replaces a request's alias with the lookup it stands for. The alias's
+// capture settings apply where the request has none of its own, and its pipeline runs
+// ahead of the request's, so its redactions can't be left out. Aliases of requests
+// addressed to a federated target are left for that target to expand.
+func (s *Server) expandTargetAlias(req *types.ScreenshotRequest) error {
+	if req.Method != aliasMethod || req.Machine != "" {
+		return nil
+	}
+	alias, ok := s.aliases.get(req.Target)
+	if !ok {
+		if prefix, _, found := strings.Cut(req.Target, ":"); found && s.isTarget(prefix) {
+			return nil
+		}
+		return types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("target alias %q not found", req.Target), nil)
+	}
+
+	req.Method, req.Target = alias.Method, alias.Target
+	if req.Match == "" {
+		req.Match = alias.Match
+	}
+	if req.CaptureMethod == "" {
+		req.CaptureMethod = alias.CaptureMethod
+		if req.FallbackMethods == nil {
+			req.FallbackMethods = alias.FallbackMethods
+		}
+	}
+	if req.Format == "" {
+		req.Format = alias.Format
+	}
+	if req.Quality == 0 {
+		req.Quality = alias.Quality
+	}
+	if alias.Pipeline != nil {
+		req.Pipeline = append(append([]types.PipelineStage(nil), alias.Pipeline...), req.Pipeline...)
+	}
+	return nil
+}
+
+// prepareScreenshotRequest expands a request's target alias, then fills in the server's
+// default format and quality
+func (s *Server) prepareScreenshotRequest(req *types.ScreenshotRequest) error {
+	if err := s.expandTargetAlias(req); err != nil {
+		return err
+	}
+	config := s.config.Load()
+	if req.Format == "" {
+		req.Format = types.ImageFormat(config.DefaultFormat)
+	}
+	if req.Quality == 0 {
+		req.Quality = config.Quality
+	}
+	return nil
+}
+
+// aliasInfo lists an alias among the targets
+func aliasInfo(alias types.TargetAlias) types.TargetInfo {
+	return types.TargetInfo{Name: alias.Name, Kind: "alias", Alias: &alias}
+}
+
+// getTargetAlias returns a target alias
+func (s *Server) getTargetAlias(c *gin.Context) {
+	alias, ok := s.aliases.get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": errAliasNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alias)
+}
+
+// putTargetAlias creates or replaces a target alias
+func (s *Server) putTargetAlias(c *gin.Context) {
+	var alias types.TargetAlias
+	if err := c.ShouldBindJSON(&alias); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	alias.Name = c.Param("name")
+	if err := validateTargetAlias(&alias); err != nil {
+		sendCaptureError(c, invalidRequest(err))
+		return
+	}
+
+	created, err := s.aliases.put(alias)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save target alias: %v", err)})
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, alias)
+}
+
+// deleteTargetAlias removes a target alias
+func (s *Server) deleteTargetAlias(c *gin.Context) {
+	err := s.aliases.delete(c.Param("name"))
+	switch {
+	case errors.Is(err, errAliasNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save target aliases: %v", err)})
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Response size budgeting (max_response_bytes): when a result with images would exceed
+// the budget, the images are re-encoded, as JPEG at falling quality if needed, and then
+// downscaled until the result fits. MCP hosts reject oversized tool results, so a
+// smaller image beats a failed call.
+const (
+	// budgetEnvelopeBytes covers the JSON-RPC envelope around a result
+	budgetEnvelopeBytes = 64
+	// budgetReductionBytes covers the reduction metadata added to each shrunk image
+	budgetReductionBytes = 200
+	// budgetMinDimension is the smallest width or height budgeting scales down to
+	budgetMinDimension = 16
+)
+
+// budgetQualities are the JPEG qualities tried at full size before downscaling
+var budgetQualities = []int{85, 70, 55, 40}
+
+// budgetedImage is an image of a response that budgeting may replace: the capture it was
+// made from and the response fields holding it
+type budgetedImage struct {
+	source    *types.ScreenshotBuffer
+	data      *string // Base64 image data
+	format    *string
+	width     *int
+	height    *int
+	size      *int64 // Optional
+	reduction **types.ResponseReduction
+}
+
+// screenshotImage returns the budgeted image of a screenshot response
+func screenshotImage(resp *types.ScreenshotResponse, source *types.ScreenshotBuffer) budgetedImage {
+	return budgetedImage{
+		source:    source,
+		data:      &resp.Data,
+		format:    &resp.Format,
+		width:     &resp.Width,
+		height:    &resp.Height,
+		size:      &resp.Size,
+		reduction: &resp.Metadata.Reduction,
+	}
+}
+
+// popupImage returns the budgeted image of a popup
+func popupImage(popup *types.PopupImage, source *types.ScreenshotBuffer) budgetedImage {
+	return budgetedImage{
+		source:    source,
+		data:      &popup.Data,
+		format:    &popup.Format,
+		width:     &popup.Width,
+		height:    &popup.Height,
+		reduction: &popup.Reduction,
+	}
+}
+
+// validateResponseBudget rejects a negative max_response_bytes; 0 means no budget
+func validateResponseBudget(maxBytes int) error {
+	if maxBytes < 0 {
+		return invalidRequest(fmt.Errorf("max_response_bytes must not be negative"))
+	}
+	return nil
+}
+
+// fitResponseBudget shrinks the images of result until its JSON encoding, inside a
+// JSON-RPC envelope, is at most maxBytes. Images share the space left by the rest of
+// the result in proportion to their pixel counts. format and quality are the requested
+// encoding, tried first. It does nothing when maxBytes is 0 or the result already fits.
+func fitResponseBudget(result any, images []budgetedImage, maxBytes int, format types.ImageFormat, quality int) error {
+	if maxBytes <= 0 || len(images) == 0 {
+		return nil
+	}
+	size, err := jsonSize(result)
+	if err != nil {
+		return err
+	}
+	if size+budgetEnvelopeBytes <= maxBytes {
+		return nil
+	}
+
+	// Measure the result without its images
+	for _, image := range images {
+		*image.data = ""
+	}
+	overhead, err := jsonSize(result)
+	if err != nil {
+		return err
+	}
+	available := maxBytes - overhead - budgetEnvelopeBytes - budgetReductionBytes*len(images)
+	if available <= 0 {
+		return types.NewCaptureError(types.ErrResponseTooLarge,
+			fmt.Sprintf("max_response_bytes %d leaves no room for images (the rest of the result takes %d bytes)", maxBytes, overhead), nil)
+	}
+
+	totalPixels := 0
+	for _, image := range images {
+		totalPixels += image.source.Width * image.source.Height
+	}
+
+	if format == "" || format == types.FormatBMP {
+		format = types.FormatPNG
+	}
+	processor := screenshot.NewImageProcessor()
+	for _, image := range images {
+		share := available
+		if totalPixels > 0 {
+			share = int(int64(available) * int64(image.source.Width*image.source.Height) / int64(totalPixels))
+		}
+		// Base64 turns 3 bytes into 4
+		if err := shrinkImage(processor, image, share/4*3, maxBytes, format, quality); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shrinkImage encodes an image in at most limit bytes and stores it in the response
+func shrinkImage(processor *screenshot.ImageProcessor, image budgetedImage, limit, maxBytes int, format types.ImageFormat, quality int) error {
+	source := image.source
+	reduction := &types.ResponseReduction{
+		MaxResponseBytes: maxBytes,
+		OriginalFormat:   source.Format,
+		OriginalWidth:    source.Width,
+		OriginalHeight:   source.Height,
+		OriginalSize:     int64(len(source.Data)),
+		Scale:            1,
+	}
+
+	store := func(buffer *types.ScreenshotBuffer, data []byte, format types.ImageFormat, quality int) {
+		if format == types.FormatJPEG {
+			reduction.Quality = quality
+		}
+		*image.data = base64.StdEncoding.EncodeToString(data)
+		*image.format = string(format)
+		*image.width = buffer.Width
+		*image.height = buffer.Height
+		if image.size != nil {
+			*image.size = int64(len(data))
+		}
+		*image.reduction = reduction
+	}
+
+	// The requested encoding at full size
+	data, err := processor.Encode(source, format, quality)
+	if err != nil {
+		return err
+	}
+	if len(data) <= limit {
+		store(source, data, format, quality)
+		return nil
+	}
+
+	// JPEG at falling quality
+	if quality <= 0 || quality > 100 {
+		quality = budgetQualities[0]
+	}
+	tried := 0
+	for _, q := range budgetQualities {
+		if q = min(q, quality); q == tried {
+			continue
+		}
+		tried = q
+		if data, err = processor.Encode(source, types.FormatJPEG, q); err != nil {
+			return err
+		}
+		if len(data) <= limit {
+			store(source, data, types.FormatJPEG, q)
+			return nil
+		}
+	}
+
+	// Downscale at the lowest quality; encoded size roughly follows the pixel count
+	q := min(budgetQualities[len(budgetQualities)-1], quality)
+	scale := math.Sqrt(float64(limit)/float64(len(data))) * 0.95
+	for {
+		width := int(float64(source.Width) * scale)
+		height := int(float64(source.Height) * scale)
+		if width < budgetMinDimension || height < budgetMinDimension {
+			return types.NewCaptureError(types.ErrResponseTooLarge,
+				fmt.Sprintf("image does not fit in max_response_bytes %d", maxBytes), nil)
+		}
+		resized, err := processor.Resize(source, width, height)
+		if err != nil {
+			return err
+		}
+		if data, err = processor.Encode(resized, types.FormatJPEG, q); err != nil {
+			return err
+		}
+		if len(data) <= limit {
+			reduction.Scale = math.Round(scale*1000) / 1000
+			store(resized, data, types.FormatJPEG, q)
+			return nil
+		}
+		scale *= 0.8
+	}
+}
+
+// jsonSize returns the length of a value's JSON encoding
+func jsonSize(v any) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure response: %w", err)
+	}
+	return len(data), nil
+}
+
+// fitScreenshotBudget applies a screenshot request's max_response_bytes to its response
+// and popups
+func fitScreenshotBudget(resp *types.ScreenshotResponse, buffer *types.ScreenshotBuffer, popupBuffers []*types.ScreenshotBuffer, req *types.ScreenshotRequest) error {
+	images := []budgetedImage{screenshotImage(resp, buffer)}
+	for i := range resp.Popups {
+		images = append(images, popupImage(&resp.Popups[i], popupBuffers[i]))
+	}
+	return fitResponseBudget(resp, images, req.MaxResponseBytes, req.Format, req.Quality)
+}
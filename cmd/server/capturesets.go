@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/scheduler"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// maxCaptureSetMembers is the most targets a capture set holds; they're all captured at once
+const maxCaptureSetMembers = 32
+
+// errCaptureSetNotFound is returned for a capture set that isn't defined
+var errCaptureSetNotFound = errors.New("capture set not found")
+
+// captureSets holds the saved capture sets, persisted to a JSON file
+type captureSets struct {
+	mutex sync.RWMutex
+	path  string // File the sets are saved to; "" keeps them in memory only
+	sets  map[string]types.CaptureSet
+}
+
+// captureSetListResponse lists the capture sets
+type captureSetListResponse struct {
+	Sets  []types.CaptureSet `json:"sets"`
+	Count int                `json:"count"`
+}
+
+// loadCaptureSets reads the capture sets saved to path; a missing file has none
+func loadCaptureSets(path string) (*captureSets, error) {
+	c := &captureSets{path: path, sets: make(map[string]types.CaptureSet)}
+	if path == "" {
+		return c, nil
+	}
+	var sets []types.CaptureSet
+	if _, err := readJSONFile(path, &sets); err != nil {
+		return nil, fmt.Errorf("failed to load capture sets: %w", err)
+	}
+	for _, set := range sets {
+		if err := validateCaptureSet(&set); err != nil {
+			return nil, fmt.Errorf("invalid capture set %q in %s: %w", set.Name, path, err)
+		}
+		c.sets[set.Name] = set
+	}
+	return c, nil
+}
+
+// get returns a capture set by name
+func (c *captureSets) get(name string) (types.CaptureSet, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	set, ok := c.sets[name]
+	return set, ok
+}
+
+// list returns the capture sets sorted by name
+func (c *captureSets) list() []types.CaptureSet {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.sorted()
+}
+
+// put creates or replaces a capture set and saves the sets, reporting whether it's new
+func (c *captureSets) put(set types.CaptureSet) (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	previous, existed := c.sets[set.Name]
+	c.sets[set.Name] = set
+	if err := c.save(); err != nil {
+		if existed {
+			c.sets[set.Name] = previous
+		} else {
+			delete(c.sets, set.Name)
+		}
+		return false, err
+	}
+	return !existed, nil
+}
+
+// delete removes a capture set and saves the sets
+func (c *captureSets) delete(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	set, ok := c.sets[name]
+	if !ok {
+		return errCaptureSetNotFound
+	}
+	delete(c.sets, name)
+	if err := c.save(); err != nil {
+		c.sets[name] = set
+		return err
+	}
+	return nil
+}
+
+// sorted returns the sets sorted by name. The caller holds the lock.
+func (c *captureSets) sorted() []types.CaptureSet {
+	sets := make([]types.CaptureSet, 0, len(c.sets))
+	for _, set := range c.sets {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets
+}
+
+// save writes the sets to their file. The caller holds the lock.
+func (c *captureSets) save() error {
+	if c.path == "" {
+		return nil
+	}
+	return writeJSONFile(c.path, c.sorted())
+}
+
+// validateCaptureSet checks a capture set's members are requests the server accepts
+// and that capture right away. Waiting for a condition or for popups would pull a
+// member's capture away from the others'.
+func validateCaptureSet(set *types.CaptureSet) error {
+	if !targetNamePattern.MatchString(set.Name) {
+		return fmt.Errorf("invalid name %q: use 1-64 letters, digits, '.', '_' or '-'", set.Name)
+	}
+	if len(set.Requests) == 0 {
+		return errors.New("requests is empty")
+	}
+	if len(set.Requests) > maxCaptureSetMembers {
+		return fmt.Errorf("a capture set holds at most %d requests", maxCaptureSetMembers)
+	}
+	for i, req := range set.Requests {
+		if req.Target == "" && methodRequiresTarget(req.Method) {
+			return fmt.Errorf("requests[%d]: window method %q needs a target", i, req.Method)
+		}
+		if req.WaitFor != nil || req.WaitForStable != nil || req.Popups != nil {
+			return fmt.Errorf("requests[%d]: capture set members can't wait (wait_for, wait_for_stable, popups)", i)
+		}
+		if req.IfNoneMatch != "" {
+			return fmt.Errorf("requests[%d]: if_none_match isn't supported in capture sets", i)
+		}
+		if _, err := applyScreenshotRequest(&req, &types.CaptureOptions{}); err != nil {
+			return fmt.Errorf("requests[%d]: %w", i, validationCause(err))
+		}
+	}
+	return nil
+}
+
+// captureSet captures a set's members at once and bundles their results. The members
+// share one capture slot, taken for the whole set, so they all start together once
+// their requests are resolved instead of queuing for slots of their own.
+func (s *Server) captureSet(ctx context.Context, set types.CaptureSet) (*types.CaptureSetResponse, error) {
+	release, err := s.scheduler.Acquire(ctx, scheduler.PriorityOf(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx = scheduler.WithHeldSlot(ctx)
+
+	results := make([]types.BatchScreenshotResult, len(set.Requests))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range set.Requests {
+		wg.Add(1)
+		go func(req types.ScreenshotRequest, result *types.BatchScreenshotResult) {
+			defer wg.Done()
+			<-start
+			s.captureResult(ctx, "capture_set", req, result)
+		}(set.Requests[i], &results[i])
+	}
+	timestamp := time.Now()
+	close(start)
+	wg.Wait()
+
+	response := &types.CaptureSetResponse{
+		Name:                    set.Name,
+		Timestamp:               timestamp,
+		BatchScreenshotResponse: *newBatchResponse(results),
+	}
+	var first, last time.Time
+	for _, result := range results {
+		if result.Response == nil {
+			continue
+		}
+		if at := result.Response.Timestamp; first.IsZero() || at.Before(first) {
+			first = at
+		}
+		if at := result.Response.Timestamp; at.After(last) {
+			last = at
+		}
+	}
+	if !first.IsZero() {
+		response.Spread = last.Sub(first)
+	}
+
+	s.logger.Info("Capture set captured",
+		zap.String("set", set.Name),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+		zap.Duration("spread", response.Spread),
+	)
+	return response, nil
+}
+
+// listCaptureSets lists the capture sets
+func (s *Server) listCaptureSets(c *gin.Context) {
+	sets := s.captureSets.list()
+	c.JSON(http.StatusOK, captureSetListResponse{Sets: sets, Count: len(sets)})
+}
+
+// getCaptureSet returns a capture set
+func (s *Server) getCaptureSet(c *gin.Context) {
+	set, ok := s.captureSets.get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCaptureSetNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+// putCaptureSet creates or replaces a capture set
+func (s *Server) putCaptureSet(c *gin.Context) {
+	var set types.CaptureSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	set.Name = c.Param("name")
+	if err := validateCaptureSet(&set); err != nil {
+		sendCaptureError(c, invalidRequest(err))
+		return
+	}
+
+	created, err := s.captureSets.put(set)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save capture set: %v", err)})
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, set)
+}
+
+// deleteCaptureSet removes a capture set
+func (s *Server) deleteCaptureSet(c *gin.Context) {
+	err := s.captureSets.delete(c.Param("name"))
+	switch {
+	case errors.Is(err, errCaptureSetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save capture sets: %v", err)})
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// postCaptureSetCapture captures a capture set
+func (s *Server) postCaptureSetCapture(c *gin.Context) {
+	set, ok := s.captureSets.get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": errCaptureSetNotFound.Error()})
+		return
+	}
+	response, err := s.captureSet(c.Request.Context(), set)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleMCPCaptureSetList handles MCP capture set list requests
+func (s *Server) handleMCPCaptureSetList(c *gin.Context, req *types.MCPRequest) {
+	sets := s.captureSets.list()
+	s.sendMCPResult(c, req.ID, captureSetListResponse{Sets: sets, Count: len(sets)})
+}
+
+// handleMCPCaptureSetCapture handles MCP capture set requests: a saved set's name, or
+// an unsaved set's requests
+func (s *Server) handleMCPCaptureSetCapture(c *gin.Context, req *types.MCPRequest) {
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	var set types.CaptureSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	if set.Requests == nil {
+		saved, ok := s.captureSets.get(set.Name)
+		if !ok {
+			s.sendMCPError(c, req.ID, -32602, "Invalid params", errCaptureSetNotFound.Error())
+			return
+		}
+		set = saved
+	} else {
+		if set.Name == "" {
+			set.Name = "unsaved"
+		}
+		if err := validateCaptureSet(&set); err != nil {
+			s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+			return
+		}
+	}
+
+	response, err := s.captureSet(c.Request.Context(), set)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	s.sendMCPResult(c, req.ID, response)
+}
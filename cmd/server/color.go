@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// manageColor applies a request's color management, or the server's when the request
+// has none, using the ICC profile of the monitor the capture came from
+func (s *Server) manageColor(buffer *types.ScreenshotBuffer, mode types.ColorManagement) (*types.ScreenshotBuffer, *types.ColorProfileInfo, error) {
+	if mode == "" {
+		mode = types.ColorManagement(s.config.Load().ColorManagement)
+	}
+	if mode == types.ColorManagementNone {
+		return buffer, nil, nil
+	}
+
+	rect := buffer.WindowInfo.Rect
+	if rect.Width <= 0 || rect.Height <= 0 {
+		rect = buffer.MonitorInfo.Rect
+	}
+	profile, source, err := screenshot.MonitorColorProfile(rect)
+	if err != nil && !errors.Is(err, screenshot.ErrNoColorProfile) {
+		s.logger.Warn("Failed to read monitor color profile", zap.Error(err))
+		managed, info, _ := screenshot.ApplyColorManagement(buffer, mode, nil, source)
+		info.Warning = err.Error()
+		return managed, info, nil
+	}
+	return screenshot.ApplyColorManagement(buffer, mode, profile, source)
+}
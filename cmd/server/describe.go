@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/ocr"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// screen.describe defaults: a screenshot small enough for a model's context and a
+// window list short enough to skim
+const (
+	describeMaxSize     = 1280
+	describeQuality     = 80
+	describeWindowLimit = 25
+	describeOCRTimeout  = 15 * time.Second
+)
+
+// describedWindow is a window list entry of screen.describe
+type describedWindow struct {
+	Handle       uintptr `json:"handle"`
+	Title        string  `json:"title"`
+	ClassName    string  `json:"class_name"`
+	ProcessID    uint32  `json:"process_id"`
+	State        string  `json:"state"`
+	IsForeground bool    `json:"is_foreground"`
+}
+
+// describedScreenshot is the foreground window screenshot of screen.describe
+type describedScreenshot struct {
+	Data           string                   `json:"data"` // Base64 encoded image data
+	Format         string                   `json:"format"`
+	Width          int                      `json:"width"`
+	Height         int                      `json:"height"`
+	OriginalWidth  int                      `json:"original_width"`
+	OriginalHeight int                      `json:"original_height"`
+	Size           int64                    `json:"size"`
+	Timestamp      time.Time                `json:"timestamp"`
+	Reduction      *types.ResponseReduction `json:"reduction,omitempty"`
+
+	source *types.ScreenshotBuffer // Downscaled capture the image was encoded from
+}
+
+// handleMCPScreenDescribe returns what an agent needs to orient itself in one call: the
+// foreground window, a summary of the visible windows, a downscaled screenshot of the
+// foreground window and the text OCR finds in it. Only failing to find the foreground
+// window fails the call; the screenshot and OCR report their failures in "errors".
+func (s *Server) handleMCPScreenDescribe(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	maxSize := getInt(params, "max_size", describeMaxSize)
+	format := types.ImageFormat(getString(params, "format", string(types.FormatJPEG)))
+	quality := getInt(params, "quality", describeQuality)
+	windowLimit := getInt(params, "window_limit", describeWindowLimit)
+	withOCR := getBool(params, "ocr", true)
+	ocrLanguage := getString(params, "ocr_language", ocr.DefaultLanguage)
+	maxResponseBytes := getInt(params, "max_response_bytes", 0)
+
+	if err := validateImageFormat(format); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	if err := validateResponseBudget(maxResponseBytes); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	handle, err := s.windowManager.GetForegroundWindow()
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	active, err := s.windowManager.GetWindowInfo(handle)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	result := gin.H{"active_window": active}
+	failures := gin.H{}
+	var shot *describedScreenshot
+
+	// Window list summary
+	windows, err := s.windowManager.EnumerateWindows(&types.WindowFilter{VisibleOnly: true, ExcludeSystem: true})
+	if err != nil {
+		failures["windows"] = captureErrorBody(err)
+	} else {
+		summary := make([]describedWindow, 0, min(len(windows), max(windowLimit, 0)))
+		for i, info := range windows {
+			if windowLimit > 0 && i >= windowLimit {
+				break
+			}
+			summary = append(summary, describedWindow{
+				Handle:       info.Handle,
+				Title:        info.Title,
+				ClassName:    info.ClassName,
+				ProcessID:    info.ProcessID,
+				State:        info.State,
+				IsForeground: info.Handle == handle,
+			})
+		}
+		result["windows"] = gin.H{
+			"items":     summary,
+			"count":     len(windows),
+			"truncated": len(summary) < len(windows),
+		}
+	}
+
+	// Screenshot of the foreground window, and OCR of it at full resolution
+	captureReq := &types.ScreenshotRequest{Method: "handle", Target: strconv.FormatUint(uint64(handle), 10)}
+	options := &types.CaptureOptions{
+		IncludeFrame:      true,
+		ScaleFactor:       1.0,
+		AllowMinimized:    true,
+		WaitForVisible:    2 * time.Second,
+		RetryCount:        3,
+		RetryBackoff:      100 * time.Millisecond,
+		RetryMaxBackoff:   2 * time.Second,
+		DetectBlackFrames: true,
+		Context:           c.Request.Context(),
+		CustomProperties:  make(map[string]string),
+	}
+	buffer, err := s.describeCapture(captureReq, options)
+	if err == nil {
+		buffer, _, err = s.manageColor(buffer, "")
+	}
+	if err == nil {
+		buffer, err = s.postProcess(buffer, nil)
+	}
+	if err != nil {
+		failures["screenshot"] = captureErrorBody(err)
+	} else {
+		processor := screenshot.NewImageProcessor()
+		if shot, err = describeImage(processor, buffer, maxSize, format, quality); err != nil {
+			failures["screenshot"] = captureErrorBody(err)
+		} else {
+			result["screenshot"] = shot
+		}
+
+		if withOCR {
+			if text, err := s.describeText(c.Request.Context(), processor, buffer, ocrLanguage); err != nil {
+				failures["ocr"] = gin.H{"error": err.Error(), "available": !errors.Is(err, ocr.ErrUnavailable)}
+			} else {
+				result["text"] = text
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		result["errors"] = failures
+	}
+
+	if shot != nil {
+		image := budgetedImage{
+			source:    shot.source,
+			data:      &shot.Data,
+			format:    &shot.Format,
+			width:     &shot.Width,
+			height:    &shot.Height,
+			size:      &shot.Size,
+			reduction: &shot.Reduction,
+		}
+		if err := fitResponseBudget(result, []budgetedImage{image}, maxResponseBytes, format, quality); err != nil {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// describeCapture captures the foreground window with the default capture methods
+func (s *Server) describeCapture(req *types.ScreenshotRequest, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if err := applyCaptureMethods(options, "", nil); err != nil {
+		return nil, invalidRequest(err)
+	}
+	return s.captureTarget(req, options)
+}
+
+// describeImage downscales a capture to fit maxSize and encodes it
+func describeImage(processor *screenshot.ImageProcessor, buffer *types.ScreenshotBuffer, maxSize int, format types.ImageFormat, quality int) (*describedScreenshot, error) {
+	fitted, err := fitBuffer(processor, buffer, maxSize, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	data, err := processor.Encode(fitted, format, quality)
+	if err != nil {
+		return nil, err
+	}
+	return &describedScreenshot{
+		Data:           base64.StdEncoding.EncodeToString(data),
+		Format:         string(format),
+		Width:          fitted.Width,
+		Height:         fitted.Height,
+		OriginalWidth:  buffer.Width,
+		OriginalHeight: buffer.Height,
+		Size:           int64(len(data)),
+		Timestamp:      buffer.Timestamp,
+		source:         fitted,
+	}, nil
+}
+
+// describeText runs OCR on a capture
+func (s *Server) describeText(ctx context.Context, processor *screenshot.ImageProcessor, buffer *types.ScreenshotBuffer, language string) (string, error) {
+	encoded, err := processor.Encode(buffer, types.FormatPNG, 100)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, describeOCRTimeout)
+	defer cancel()
+	text, err := ocr.Recognize(ctx, encoded, language)
+	if err != nil && !errors.Is(err, ocr.ErrUnavailable) {
+		s.logger.Warn("OCR failed", zap.Error(err))
+	}
+	return text, err
+}
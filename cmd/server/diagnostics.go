@@ -0,0 +1,363 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/crash"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Diagnostics settings
+const (
+	recentErrorLimit = 100                    // Capture errors kept for diagnostics bundles
+	testWindowSettle = 300 * time.Millisecond // Time the self-test window gets to be composed
+	colorTolerance   = 16                     // Per-channel difference a verified capture may show
+	testWindowX      = 64
+	testWindowY      = 64
+	testWindowWidth  = 320
+	testWindowHeight = 240
+)
+
+// diagnosticMethods are the capture methods the self-test tries one at a time
+var diagnosticMethods = []types.CaptureMethod{
+	types.CaptureBitBlt,
+	types.CapturePrintWindow,
+	types.CaptureRenderFullContent,
+	types.CaptureWMPrint,
+	types.CaptureDWMThumbnail,
+	types.CaptureDXGI,
+	types.CaptureStealthRestore,
+}
+
+// recentError is a failed capture kept for diagnostics
+type recentError struct {
+	Time   time.Time       `json:"time"`
+	Method string          `json:"method"`
+	Target string          `json:"target"`
+	Code   types.ErrorCode `json:"code,omitempty"`
+	Error  string          `json:"error"`
+}
+
+// errorLog keeps the most recent capture errors
+type errorLog struct {
+	mutex   sync.Mutex
+	entries []recentError
+	next    int // Slot the next entry overwrites once the log is full
+	limit   int
+}
+
+func newErrorLog(limit int) *errorLog {
+	return &errorLog{limit: limit}
+}
+
+// add records an error, dropping the oldest once the log is full
+func (l *errorLog) add(entry recentError) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.entries) < l.limit {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % l.limit
+}
+
+// recent returns the recorded errors, oldest first
+func (l *errorLog) recent() []recentError {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entries := make([]recentError, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	return append(entries, l.entries[:l.next]...)
+}
+
+// systemInfo describes the machine a diagnostics bundle comes from
+type systemInfo struct {
+	GeneratedAt     time.Time              `json:"generated_at"`
+	Version         string                 `json:"version"`
+	GoVersion       string                 `json:"go_version"`
+	Platform        string                 `json:"platform"`
+	OSVersion       string                 `json:"os_version,omitempty"`
+	DPIAwareness    string                 `json:"dpi_awareness,omitempty"`
+	DesktopState    types.DesktopState     `json:"desktop_state"`
+	Monitors        []types.MonitorInfo    `json:"monitors,omitempty"`
+	DisplayAdapters []types.DisplayAdapter `json:"display_adapters,omitempty"`
+	Errors          map[string]string      `json:"errors,omitempty"` // Information that couldn't be collected, by field
+	Config          *Config                `json:"config"`           // Secrets are never included
+}
+
+// methodResult is the outcome of capturing the self-test window with one method
+type methodResult struct {
+	Method   types.CaptureMethod `json:"method"`
+	Success  bool                `json:"success"`
+	Verified bool                `json:"verified"` // The capture shows the test window's color
+	Width    int                 `json:"width,omitempty"`
+	Height   int                 `json:"height,omitempty"`
+	Duration time.Duration       `json:"duration"`
+	Error    string              `json:"error,omitempty"`
+	Image    string              `json:"image,omitempty"` // Path of the capture in the bundle
+}
+
+// fallbackMatrix is the result of the capture method self-test
+type fallbackMatrix struct {
+	Window    *types.WindowInfo `json:"window,omitempty"`
+	Synthetic bool              `json:"synthetic"` // The window is the server's own test window
+	Note      string            `json:"note,omitempty"`
+	Results   []methodResult    `json:"results"`
+}
+
+// methodCapturer is an engine that can capture with a single method, which the Windows
+// engine can
+type methodCapturer interface {
+	CaptureWithMethod(handle uintptr, method types.CaptureMethod, options *types.CaptureOptions) (*types.ScreenshotBuffer, error)
+}
+
+// recordError keeps a failed capture for diagnostics bundles
+func (s *Server) recordError(req *types.ScreenshotRequest, err error) {
+	s.recentErrors.add(recentError{
+		Time:   time.Now(),
+		Method: req.Method,
+		Target: req.Target,
+		Code:   types.ErrorCodeOf(err),
+		Error:  err.Error(),
+	})
+}
+
+// collectSystemInfo gathers the OS, display and configuration details of the server
+func (s *Server) collectSystemInfo() *systemInfo {
+	info := &systemInfo{
+		GeneratedAt:  time.Now(),
+		Version:      "1.0.0",
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		OSVersion:    screenshot.OSVersion(),
+		DPIAwareness: screenshot.DPIAwareness(),
+		DesktopState: screenshot.QueryDesktopState(),
+		Errors:       make(map[string]string),
+		Config:       s.config.Load(),
+	}
+
+	if engine, ok := s.nativeEngine().(interface {
+		Monitors() ([]types.MonitorInfo, error)
+	}); ok {
+		monitors, err := engine.Monitors()
+		if err != nil {
+			info.Errors["monitors"] = err.Error()
+		}
+		info.Monitors = monitors
+	}
+	adapters, err := screenshot.DisplayAdapters()
+	if err != nil {
+		info.Errors["display_adapters"] = err.Error()
+	}
+	info.DisplayAdapters = adapters
+	return info
+}
+
+// runFallbackMatrix captures a window with each capture method in turn, adding the
+// captures to the bundle. Windows engines capture a test window the server creates;
+// other engines have a single method, tried on the first visible window.
+func (s *Server) runFallbackMatrix(bundle *zip.Writer) (*fallbackMatrix, error) {
+	matrix := &fallbackMatrix{Results: make([]methodResult, 0, len(diagnosticMethods))}
+	options := types.DefaultCaptureOptions()
+	options.DetectBlackFrames = false
+	options.RetryCount = 0
+
+	engine, ok := s.nativeEngine().(methodCapturer)
+	if !ok {
+		windows, err := s.windowManager.EnumerateWindows(nil)
+		if err != nil {
+			return nil, err
+		}
+		for i := range windows {
+			if windows[i].IsVisible && windows[i].State != "minimized" {
+				matrix.Window = &windows[i]
+				break
+			}
+		}
+		if matrix.Window == nil {
+			matrix.Note = "no visible window to capture"
+			return matrix, nil
+		}
+		matrix.Note = "this engine has a single capture method; it was tried on the first visible window"
+		result := s.captureMatrixEntry(bundle, types.CaptureAuto, false, func() (*types.ScreenshotBuffer, error) {
+			return s.engine.CaptureWithFallbacks(matrix.Window.Handle, options)
+		})
+		matrix.Results = append(matrix.Results, result)
+		return matrix, nil
+	}
+
+	window, err := screenshot.NewTestWindow(testWindowX, testWindowY, testWindowWidth, testWindowHeight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test window: %w", err)
+	}
+	defer window.Close()
+	time.Sleep(testWindowSettle)
+
+	matrix.Synthetic = true
+	if info, err := s.windowManager.GetWindowInfo(window.Handle); err == nil {
+		matrix.Window = info
+	}
+	options.IncludeFrame = false
+	for _, method := range diagnosticMethods {
+		result := s.captureMatrixEntry(bundle, method, true, func() (*types.ScreenshotBuffer, error) {
+			return engine.CaptureWithMethod(window.Handle, method, options)
+		})
+		matrix.Results = append(matrix.Results, result)
+	}
+	return matrix, nil
+}
+
+// captureMatrixEntry performs one capture of the matrix, verifying the test window's
+// color when verify is set, and adds the image to the bundle
+func (s *Server) captureMatrixEntry(bundle *zip.Writer, method types.CaptureMethod, verify bool, capture func() (*types.ScreenshotBuffer, error)) methodResult {
+	result := methodResult{Method: method}
+	start := time.Now()
+	buffer, err := capture()
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	result.Width, result.Height = buffer.Width, buffer.Height
+	if buffer.CaptureMethod != "" {
+		result.Method = buffer.CaptureMethod
+	}
+	if verify {
+		result.Verified = showsTestColor(buffer)
+	}
+
+	data, err := screenshot.NewImageProcessor().Encode(buffer, types.FormatPNG, 0)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to encode capture: %v", err)
+		return result
+	}
+	result.Image = fmt.Sprintf("captures/%s.png", method)
+	if w, err := bundle.Create(result.Image); err == nil {
+		w.Write(data)
+	}
+	return result
+}
+
+// showsTestColor reports whether the center pixel of a capture is the test window's color
+func showsTestColor(buffer *types.ScreenshotBuffer) bool {
+	if buffer.Width == 0 || buffer.Height == 0 {
+		return false
+	}
+	offset := (buffer.Height/2)*buffer.Stride + (buffer.Width/2)*4
+	if offset+3 > len(buffer.Data) {
+		return false
+	}
+	b, g, r := buffer.Data[offset], buffer.Data[offset+1], buffer.Data[offset+2]
+	want := screenshot.TestWindowColor
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
+
+func near(a, b uint8) bool {
+	diff := int(a) - int(b)
+	return diff >= -colorTolerance && diff <= colorTolerance
+}
+
+// buildDiagnostics assembles the diagnostics bundle: system information, readiness
+// probes, the capture method matrix and its captures, and the recent capture errors and
+// panics
+func (s *Server) buildDiagnostics(ctx context.Context) ([]byte, error) {
+	var archive bytes.Buffer
+	bundle := zip.NewWriter(&archive)
+
+	matrix, err := s.runFallbackMatrix(bundle)
+	if err != nil {
+		matrix = &fallbackMatrix{Note: err.Error(), Results: []methodResult{}}
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"system.json", s.collectSystemInfo()},
+		{"readiness.json", s.checkReadiness(ctx)},
+		{"fallback_matrix.json", matrix},
+		{"recent_errors.json", s.recentErrors.recent()},
+		{"panics.json", s.crashes.Recent()},
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		w, err := bundle.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bundle.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
+
+// errorsResponse lists the recent capture errors and recovered panics
+type errorsResponse struct {
+	Errors []recentError  `json:"errors"`
+	Panics []crash.Report `json:"panics"` // Including those of earlier runs, from the crash log
+}
+
+// getErrors lists the recent capture errors and recovered panics, oldest first
+func (s *Server) getErrors(c *gin.Context) {
+	c.JSON(http.StatusOK, errorsResponse{
+		Errors: s.recentErrors.recent(),
+		Panics: s.crashes.Recent(),
+	})
+}
+
+// rankingsResponse lists the capture method order learned for each window class
+type rankingsResponse struct {
+	Adaptive bool                  `json:"adaptive"` // The engine orders its fallbacks by the rankings
+	Rankings []types.MethodRanking `json:"rankings"`
+}
+
+// getMethodRankings lists how each capture method fared on each window class and the
+// order fallbacks are tried in as a result. Only the Windows engine, which has fallbacks,
+// learns rankings.
+func (s *Server) getMethodRankings(c *gin.Context) {
+	response := rankingsResponse{Rankings: []types.MethodRanking{}}
+	if engine, ok := s.nativeEngine().(interface {
+		MethodRankings() []types.MethodRanking
+	}); ok {
+		response.Adaptive = true
+		response.Rankings = engine.MethodRankings()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getDiagnostics runs the self-test and returns the diagnostics bundle as a ZIP archive
+// to attach to bug reports
+func (s *Server) getDiagnostics(c *gin.Context) {
+	data, err := s.buildDiagnostics(c.Request.Context())
+	if err != nil {
+		s.logger.Error("Failed to build diagnostics bundle", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("diagnostics-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/zip", data)
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/client"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Federation limits
+const (
+	maxBatchRequests   = 64              // Largest batch accepted
+	batchConcurrency   = 8               // Captures of a batch run at once
+	targetCheckTimeout = 3 * time.Second // Longest a target's health check may take
+)
+
+// targetNamePattern limits target names to what can prefix a window as "name:window"
+var targetNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// targetConfig is an entry of SCREENSHOT_TARGETS
+type targetConfig struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Token string `json:"token"` // Bearer token sent to the target, if it needs one
+}
+
+// federatedTarget is a configured screenshot server
+type federatedTarget struct {
+	info   types.TargetInfo
+	client *client.Client
+}
+
+// targetListResponse lists the federated targets
+type targetListResponse struct {
+	Targets []types.TargetInfo `json:"targets"`
+	Count   int                `json:"count"`
+}
+
+// parseTargets parses SCREENSHOT_TARGETS, a JSON array of {"name", "url", "token"}
+func parseTargets(config string) (map[string]*federatedTarget, error) {
+	targets := make(map[string]*federatedTarget)
+	if config == "" {
+		return targets, nil
+	}
+
+	var entries []targetConfig
+	if err := json.Unmarshal([]byte(config), &entries); err != nil {
+		return nil, fmt.Errorf("invalid SCREENSHOT_TARGETS: %w", err)
+	}
+	for _, entry := range entries {
+		if !targetNamePattern.MatchString(entry.Name) {
+			return nil, fmt.Errorf("invalid SCREENSHOT_TARGETS name %q: use 1-64 letters, digits, '.', '_' or '-'", entry.Name)
+		}
+		if _, exists := targets[entry.Name]; exists {
+			return nil, fmt.Errorf("invalid SCREENSHOT_TARGETS: duplicate name %q", entry.Name)
+		}
+
+		var opts []client.Option
+		if entry.Token != "" {
+			opts = append(opts, client.WithBearerToken(entry.Token))
+		}
+		remote, err := client.New(entry.URL, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCREENSHOT_TARGETS entry %q: %w", entry.Name, err)
+		}
+		targets[entry.Name] = &federatedTarget{
+			info:   types.TargetInfo{Name: entry.Name, Kind: "server", URL: entry.URL},
+			client: remote,
+		}
+	}
+	return targets, nil
+}
+
+// targetClient returns a client for a configured target or, failing that, a relay
+// agent of that name
+func (s *Server) targetClient(name string) (*client.Client, error) {
+	if target, ok := s.targets[name]; ok {
+		return target.client, nil
+	}
+	if _, err := s.agents.Get(name); err == nil {
+		return client.New("http://"+name, client.WithHTTPClient(&http.Client{
+			Transport: s.agents.Transport(name),
+			Timeout:   60 * time.Second,
+		}))
+	}
+	return nil, fmt.Errorf("unknown target: %s", name)
+}
+
+// isTarget reports whether name is a configured target or a connected agent
+func (s *Server) isTarget(name string) bool {
+	if _, ok := s.targets[name]; ok {
+		return true
+	}
+	_, err := s.agents.Get(name)
+	return err == nil
+}
+
+// remoteScreenshot is a screenshot request addressed to a federated target
+type remoteScreenshot struct {
+	name    string
+	client  *client.Client
+	request types.ScreenshotRequest // The request as the target sees it
+}
+
+// resolveTarget returns the federated target a request is addressed to, by its machine
+// field or a "machine:" prefix of its target naming a known target, or nil for captures
+// on this server
+func (s *Server) resolveTarget(req *types.ScreenshotRequest) (*remoteScreenshot, error) {
+	name, window := req.Machine, req.Target
+	if name == "" {
+		prefix, rest, found := strings.Cut(req.Target, ":")
+		if !found || !s.isTarget(prefix) {
+			return nil, nil
+		}
+		name, window = prefix, rest
+	}
+
+	remote, err := s.targetClient(name)
+	if err != nil {
+		return nil, invalidRequest(err)
+	}
+	forwarded := *req
+	forwarded.Machine = ""
+	forwarded.Target = window
+	return &remoteScreenshot{name: name, client: remote, request: forwarded}, nil
+}
+
+// capture performs the request on its target
+func (r *remoteScreenshot) capture(ctx context.Context) (*types.ScreenshotResponse, error) {
+	shot, err := r.client.Screenshot(ctx, &r.request)
+	if err != nil {
+		return nil, targetError(r.name, err)
+	}
+	return &shot.ScreenshotResponse, nil
+}
+
+// targetError keeps the error code of a capture the target failed, and reports any
+// other failure as TARGET_UNREACHABLE
+func targetError(name string, err error) error {
+	var remoteErr *client.Error
+	if errors.As(err, &remoteErr) && remoteErr.Code != "" {
+		return fmt.Errorf("target %s: %w", name, err)
+	}
+	return types.NewCaptureError(types.ErrTargetUnreachable, fmt.Sprintf("target %s unreachable", name), err)
+}
+
+// listTargets returns the configured targets and connected agents, sorted by name,
+// checking their health when check is set, followed by the target aliases
+func (s *Server) listTargets(ctx context.Context, check bool) []types.TargetInfo {
+	targets := make([]types.TargetInfo, 0, len(s.targets))
+	for _, target := range s.targets {
+		targets = append(targets, target.info)
+	}
+	for _, agent := range s.agents.List() {
+		if _, shadowed := s.targets[agent.ID]; !shadowed {
+			targets = append(targets, types.TargetInfo{Name: agent.ID, Kind: "agent"})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+
+	if check {
+		var wg sync.WaitGroup
+		for i := range targets {
+			wg.Add(1)
+			go func(target *types.TargetInfo) {
+				defer wg.Done()
+				s.checkTarget(ctx, target)
+			}(&targets[i])
+		}
+		wg.Wait()
+	}
+	for _, alias := range s.aliases.list() {
+		targets = append(targets, aliasInfo(alias))
+	}
+	return targets
+}
+
+// checkTarget fills in a target's status from its health check
+func (s *Server) checkTarget(ctx context.Context, target *types.TargetInfo) {
+	ctx, cancel := context.WithTimeout(ctx, targetCheckTimeout)
+	defer cancel()
+
+	remote, err := s.targetClient(target.Name)
+	if err == nil {
+		var health *client.Health
+		if health, err = remote.Health(ctx); err == nil {
+			target.Status = "online"
+			target.Version = health.Version
+			return
+		}
+	}
+	target.Status = "offline"
+	target.Error = err.Error()
+}
+
+// getTargets lists the federated targets and target aliases
+func (s *Server) getTargets(c *gin.Context) {
+	targets := s.listTargets(c.Request.Context(), c.Query("check") == "true")
+	c.JSON(http.StatusOK, targetListResponse{Targets: targets, Count: len(targets)})
+}
+
+// batchScreenshot captures several windows, possibly on several machines, at once
+func (s *Server) batchScreenshot(c *gin.Context) {
+	var req types.BatchScreenshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := s.screenshotBatch(c.Request.Context(), req.Requests)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// screenshotBatch performs a batch's requests concurrently. A failed request fails only
+// its own result.
+func (s *Server) screenshotBatch(ctx context.Context, requests []types.ScreenshotRequest) (*types.BatchScreenshotResponse, error) {
+	if len(requests) == 0 {
+		return nil, invalidRequest(errors.New("requests is empty"))
+	}
+	if len(requests) > maxBatchRequests {
+		return nil, invalidRequest(fmt.Errorf("a batch holds at most %d requests", maxBatchRequests))
+	}
+
+	results := make([]types.BatchScreenshotResult, len(requests))
+	semaphore := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(req types.ScreenshotRequest, result *types.BatchScreenshotResult) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			s.captureResult(ctx, "batch", req, result)
+		}(requests[i], &results[i])
+	}
+	wg.Wait()
+
+	response := newBatchResponse(results)
+
+	s.logger.Info("Batch screenshot completed",
+		zap.Int("requests", len(requests)),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+	return response, nil
+}
+
+// captureResult performs one request of a batch or capture set into its result,
+// recovering a panic of the capture as the request's failure
+func (s *Server) captureResult(ctx context.Context, source string, req types.ScreenshotRequest, result *types.BatchScreenshotResult) {
+	defer func() {
+		if p := recover(); p != nil {
+			report := s.crashes.Capture(source, p, map[string]string{
+				"method":  req.Method,
+				"target":  req.Target,
+				"machine": req.Machine,
+			})
+			s.logger.Error("Capture panicked", zap.String("source", source), zap.String("crash_id", report.ID), zap.Any("error", p))
+			result.Response = nil
+			result.Error = fmt.Sprintf("capture panicked (crash %s)", report.ID)
+			result.Code = ""
+		}
+	}()
+
+	result.Machine, result.Target = req.Machine, req.Target
+	if remote, err := s.resolveTarget(&req); err == nil && remote != nil {
+		result.Machine, result.Target = remote.name, remote.request.Target
+	}
+	response, err := s.screenshot(ctx, &req)
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = types.ErrorCodeOf(err)
+		return
+	}
+	result.Response = response
+}
+
+// newBatchResponse counts the results that succeeded and failed
+func newBatchResponse(results []types.BatchScreenshotResult) *types.BatchScreenshotResponse {
+	response := &types.BatchScreenshotResponse{Results: results}
+	for _, result := range results {
+		if result.Response != nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response
+}
+
+// handleMCPScreenshotBatch handles MCP batch screenshot requests: {"requests": [...]}
+// with the fields of POST /v1/screenshot/batch
+func (s *Server) handleMCPScreenshotBatch(c *gin.Context, req *types.MCPRequest) {
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	var batch types.BatchScreenshotRequest
+	if err := json.Unmarshal(data, &batch); err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	response, err := s.screenshotBatch(c.Request.Context(), batch.Requests)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	s.sendMCPResult(c, req.ID, response)
+}
+
+// handleMCPTargetsList handles MCP federated target list requests
+func (s *Server) handleMCPTargetsList(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	targets := s.listTargets(c.Request.Context(), getBool(params, "check", false))
+	s.sendMCPResult(c, req.ID, targetListResponse{Targets: targets, Count: len(targets)})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// screen.findImage defaults and limits
+const (
+	findImageThreshold  = 0.8
+	findImageScaleStep  = 0.1
+	findImageMaxScales  = 21
+	findImageMaxResults = 10
+)
+
+// foundImage is a screen.findImage match
+type foundImage struct {
+	Box        types.Rectangle `json:"box"`       // Screen coordinates
+	ImageBox   types.Rectangle `json:"image_box"` // Relative to the captured window or screen
+	Center     types.Point     `json:"center"`    // Screen coordinates, for clicking
+	Confidence float64         `json:"confidence"`
+	Scale      float64         `json:"scale"`
+}
+
+// handleMCPScreenFindImage locates a template image on the screen or in a window
+func (s *Server) handleMCPScreenFindImage(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	template, err := decodeImageParam(params, "template")
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	threshold := getFloat64(params, "threshold", findImageThreshold)
+	if threshold <= 0 || threshold > 1 {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(fmt.Errorf("threshold must be greater than 0 and at most 1")))
+		return
+	}
+	scales, err := templateScales(getFloat64(params, "min_scale", 1), getFloat64(params, "max_scale", 1), getFloat64(params, "scale_step", findImageScaleStep))
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	region, err := getRegion(params, "region")
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+
+	buffer, err := s.sampleCapture(c.Request.Context(), params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	// Search only the region, if given, and report boxes relative to the whole capture
+	haystack := buffer
+	var offset types.Point
+	if region != nil {
+		if haystack, err = screenshot.NewImageProcessor().Crop(buffer, *region); err != nil {
+			s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+			return
+		}
+		offset = types.Point{X: max(region.X, 0), Y: max(region.Y, 0)}
+	}
+
+	matches, err := screenshot.FindTemplate(haystack, template, screenshot.TemplateOptions{
+		Threshold:  threshold,
+		Scales:     scales,
+		MaxResults: getInt(params, "max_results", findImageMaxResults),
+	})
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+
+	origin := buffer.SourceRect
+	found := make([]foundImage, 0, len(matches))
+	for _, match := range matches {
+		imageBox := match.Box
+		imageBox.X += offset.X
+		imageBox.Y += offset.Y
+		box := imageBox
+		box.X += origin.X
+		box.Y += origin.Y
+		found = append(found, foundImage{
+			Box:        box,
+			ImageBox:   imageBox,
+			Center:     types.Point{X: box.X + box.Width/2, Y: box.Y + box.Height/2},
+			Confidence: match.Confidence,
+			Scale:      match.Scale,
+		})
+	}
+
+	s.sendMCPResult(c, req.ID, gin.H{
+		"matches":     found,
+		"count":       len(found),
+		"source_rect": origin,
+		"timestamp":   buffer.Timestamp,
+	})
+}
+
+// decodeImageParam decodes a base64 PNG or JPEG parameter, which may be a data URL
+func decodeImageParam(params map[string]interface{}, key string) (*types.ScreenshotBuffer, error) {
+	value := getString(params, key, "")
+	if value == "" {
+		return nil, fmt.Errorf("%s is required", key)
+	}
+	if strings.HasPrefix(value, "data:") {
+		if _, data, ok := strings.Cut(value, ","); ok {
+			value = data
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", key, err)
+	}
+	image, err := screenshot.NewImageProcessor().Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return image, nil
+}
+
+// templateScales lists the template scales from min to max in steps
+func templateScales(minScale, maxScale, step float64) ([]float64, error) {
+	if minScale <= 0 || maxScale < minScale {
+		return nil, fmt.Errorf("min_scale must be positive and at most max_scale")
+	}
+	if minScale == maxScale {
+		return []float64{minScale}, nil
+	}
+	if step <= 0 || (maxScale-minScale)/step+1 > findImageMaxScales {
+		return nil, fmt.Errorf("scale_step must be positive and give at most %d scales", findImageMaxScales)
+	}
+	var scales []float64
+	for scale := minScale; scale <= maxScale+1e-9; scale += step {
+		scales = append(scales, math.Round(scale*1000)/1000)
+	}
+	return scales, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/ocr"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// screen.findText defaults. Screen text is small for Tesseract, which reads best at
+// print resolutions, so captures are upscaled before OCR.
+const (
+	findTextScale      = 2
+	maxFindTextScale   = 4
+	findTextMaxResults = 50
+	findTextOCRTimeout = 30 * time.Second
+)
+
+// foundText is a screen.findText match
+type foundText struct {
+	Text       string          `json:"text"`
+	Line       string          `json:"line"`
+	Box        types.Rectangle `json:"box"`       // Screen coordinates
+	ImageBox   types.Rectangle `json:"image_box"` // Relative to the captured window or screen
+	Center     types.Point     `json:"center"`    // Screen coordinates, for clicking
+	Confidence float64         `json:"confidence"`
+}
+
+// handleMCPScreenFindText finds text on the screen or in a window with OCR and returns
+// where it is in screen coordinates, for when UI Automation can't locate an element
+func (s *Server) handleMCPScreenFindText(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	pattern, err := findTextPattern(params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	scale := getInt(params, "scale", findTextScale)
+	if scale < 1 || scale > maxFindTextScale {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(fmt.Errorf("scale must be between 1 and %d", maxFindTextScale)))
+		return
+	}
+	minConfidence := getFloat64(params, "min_confidence", 0)
+	maxResults := getInt(params, "max_results", findTextMaxResults)
+	language := getString(params, "ocr_language", ocr.DefaultLanguage)
+
+	buffer, err := s.sampleCapture(c.Request.Context(), params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	words, err := recognizeWords(c.Request.Context(), buffer, scale, language)
+	if err != nil {
+		if errors.Is(err, ocr.ErrUnavailable) {
+			s.sendMCPError(c, req.ID, -32603, "OCR unavailable", err.Error())
+			return
+		}
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	origin := buffer.SourceRect
+	found := []foundText{}
+	truncated := false
+	for _, match := range ocr.Find(words, pattern) {
+		if match.Confidence < minConfidence {
+			continue
+		}
+		if maxResults > 0 && len(found) == maxResults {
+			truncated = true
+			break
+		}
+		box := types.Rectangle{X: origin.X + match.Box.X, Y: origin.Y + match.Box.Y, Width: match.Box.Width, Height: match.Box.Height}
+		found = append(found, foundText{
+			Text:       match.Text,
+			Line:       match.Line,
+			Box:        box,
+			ImageBox:   match.Box,
+			Center:     types.Point{X: box.X + box.Width/2, Y: box.Y + box.Height/2},
+			Confidence: match.Confidence,
+		})
+	}
+
+	s.sendMCPResult(c, req.ID, gin.H{
+		"matches":     found,
+		"count":       len(found),
+		"truncated":   truncated,
+		"words":       len(words),
+		"source_rect": origin,
+		"timestamp":   buffer.Timestamp,
+	})
+}
+
+// findTextPattern compiles the text or regex of a screen.findText request; text
+// matches literally and both ignore case unless case_sensitive is set
+func findTextPattern(params map[string]interface{}) (*regexp.Regexp, error) {
+	text := getString(params, "text", "")
+	expr := getString(params, "regex", "")
+	switch {
+	case text == "" && expr == "":
+		return nil, fmt.Errorf("text or regex is required")
+	case text != "" && expr != "":
+		return nil, fmt.Errorf("text and regex are mutually exclusive")
+	case text != "":
+		expr = regexp.QuoteMeta(text)
+	}
+	if !getBool(params, "case_sensitive", false) {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return pattern, nil
+}
+
+// recognizeWords runs OCR on a capture upscaled by scale and returns the words with
+// their boxes in capture pixels
+func recognizeWords(ctx context.Context, buffer *types.ScreenshotBuffer, scale int, language string) ([]ocr.Word, error) {
+	processor := screenshot.NewImageProcessor()
+	source := buffer
+	if scale > 1 {
+		var err error
+		if source, err = processor.Resize(buffer, buffer.Width*scale, buffer.Height*scale); err != nil {
+			return nil, err
+		}
+	}
+	encoded, err := processor.Encode(source, types.FormatPNG, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, findTextOCRTimeout)
+	defer cancel()
+	words, err := ocr.Words(ctx, encoded, language)
+	if err != nil {
+		return nil, err
+	}
+	for i := range words {
+		box := &words[i].Box
+		box.X, box.Y = box.X/scale, box.Y/scale
+		box.Width, box.Height = (box.Width+scale-1)/scale, (box.Height+scale-1)/scale
+	}
+	return words, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// frameCache keeps the last response of each distinct screenshot request, so that a poll
+// whose capture looks the same as last time is answered without encoding it again.
+// Responses are evicted least recently used first once they take more than maxBytes.
+type frameCache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List // Of *frameCacheEntry, most recently used first
+}
+
+type frameCacheEntry struct {
+	key      string
+	response types.ScreenshotResponse
+	size     int64
+}
+
+func newFrameCache(maxBytes int64) *frameCache {
+	return &frameCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns a copy of the response cached for key if it has the ETag etag
+func (f *frameCache) get(key, etag string) *types.ScreenshotResponse {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	element, ok := f.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*frameCacheEntry)
+	if entry.response.ETag != etag {
+		return nil
+	}
+	f.order.MoveToFront(element)
+	response := entry.response
+	response.Cached = true
+	return &response
+}
+
+// put caches a copy of the response to the request of key
+func (f *frameCache) put(key string, response *types.ScreenshotResponse) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.remove(key)
+	size := int64(len(response.Data))
+	if size > f.maxBytes {
+		return
+	}
+	entry := &frameCacheEntry{key: key, response: *response, size: size}
+	f.entries[key] = f.order.PushFront(entry)
+	f.size += size
+	f.evict()
+}
+
+// resize changes how many bytes of responses are kept; 0 empties and disables the cache
+func (f *frameCache) resize(maxBytes int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.maxBytes = maxBytes
+	f.evict()
+}
+
+func (f *frameCache) evict() {
+	for f.size > f.maxBytes {
+		f.remove(f.order.Back().Value.(*frameCacheEntry).key)
+	}
+}
+
+func (f *frameCache) remove(key string) {
+	if element, ok := f.entries[key]; ok {
+		f.size -= element.Value.(*frameCacheEntry).size
+		f.order.Remove(element)
+		delete(f.entries, key)
+	}
+}
+
+// checkFrameCache tags a capture for the frame cache. When the client already has a
+// capture that looks the same, or the cache has one, it also returns the response to
+// send instead of encoding the capture. Captures with popups aren't cached.
+func (s *Server) checkFrameCache(req *types.ScreenshotRequest, buffer *types.ScreenshotBuffer, popups int) (key, etag string, response *types.ScreenshotResponse) {
+	if popups > 0 {
+		return "", "", nil
+	}
+	key, etag = frameTag(req, buffer)
+	if etagMatches(req.IfNoneMatch, etag) {
+		return key, etag, &types.ScreenshotResponse{
+			Success:   true,
+			Format:    buffer.Format,
+			Width:     buffer.Width,
+			Height:    buffer.Height,
+			Timestamp: buffer.Timestamp,
+			ETag:      etag,
+			Unchanged: true,
+		}
+	}
+	// A cached response keeps the time of the capture it was encoded from, so clients can
+	// tell it from a fresh one
+	return key, etag, s.frames.get(key, etag)
+}
+
+// cacheFrame tags a response with the ETag of its capture and caches it
+func (s *Server) cacheFrame(key, etag string, response *types.ScreenshotResponse) {
+	if etag == "" {
+		return
+	}
+	response.ETag = etag
+	s.frames.put(key, response)
+}
+
+// frameTag returns the frame cache key of a request and the ETag of its capture: a hash
+// of the request, which decides how the capture is encoded, and the capture's perceptual
+// hash. It returns empty strings for captures that can't be hashed.
+func frameTag(req *types.ScreenshotRequest, buffer *types.ScreenshotBuffer) (key, etag string) {
+	perceptual, err := screenshot.PerceptualHash(buffer)
+	if err != nil {
+		return "", ""
+	}
+	keyed := *req
+	keyed.IfNoneMatch = ""
+	data, err := json.Marshal(keyed)
+	if err != nil {
+		return "", ""
+	}
+	hash := fnv.New64a()
+	hash.Write(data)
+	key = fmt.Sprintf("%016x", hash.Sum64())
+	return key, fmt.Sprintf("%q", key+"-"+perceptual)
+}
+
+// etagMatches reports whether an If-None-Match value lists etag; weak tags compare by
+// their value and "*" matches any
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
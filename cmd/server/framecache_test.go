@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gradientCapture returns a BGRA capture that brightens from left to right, or from right
+// to left when reversed
+func gradientCapture(reversed bool, timestamp time.Time) *types.ScreenshotBuffer {
+	const width, height = 64, 32
+	data := make([]byte, width*height*4)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := byte(x * 4)
+			if reversed {
+				value = byte((width - 1 - x) * 4)
+			}
+			p := data[(y*width+x)*4:]
+			p[0], p[1], p[2], p[3] = value, value, value, 255
+		}
+	}
+	return &types.ScreenshotBuffer{Data: data, Width: width, Height: height, Stride: width * 4, Format: "BGRA32", Timestamp: timestamp}
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"0123456789abcdef-ffff"`
+	tests := []struct {
+		ifNoneMatch string
+		etag        string
+		match       bool
+	}{
+		{ifNoneMatch: etag, etag: etag, match: true},
+		{ifNoneMatch: "W/" + etag, etag: etag, match: true},
+		{ifNoneMatch: `"other", ` + etag, etag: etag, match: true},
+		{ifNoneMatch: ` "other" ,W/` + etag + ` `, etag: etag, match: true},
+		{ifNoneMatch: "*", etag: etag, match: true},
+		{ifNoneMatch: `"other"`, etag: etag},
+		{ifNoneMatch: strings.Trim(etag, `"`), etag: etag},
+		{ifNoneMatch: "", etag: etag},
+		{ifNoneMatch: etag, etag: ""},
+		{ifNoneMatch: "*", etag: ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, etagMatches(tt.ifNoneMatch, tt.etag), "If-None-Match %q, ETag %q", tt.ifNoneMatch, tt.etag)
+	}
+}
+
+func TestFrameTag(t *testing.T) {
+	req := &types.ScreenshotRequest{Method: "title", Target: "Notepad", Format: types.FormatPNG}
+	key, etag := frameTag(req, gradientCapture(false, time.Now()))
+	require.NotEmpty(t, key)
+	assert.True(t, strings.HasPrefix(etag, `"`+key+"-"), etag)
+	assert.True(t, strings.HasSuffix(etag, `"`), etag)
+
+	// Another capture that looks the same, asked for conditionally, has the same tags
+	conditional := *req
+	conditional.IfNoneMatch = etag
+	sameKey, sameTag := frameTag(&conditional, gradientCapture(false, time.Now().Add(time.Second)))
+	assert.Equal(t, key, sameKey)
+	assert.Equal(t, etag, sameTag)
+
+	// A capture that looks different keeps the key but not the ETag
+	changedKey, changedTag := frameTag(req, gradientCapture(true, time.Now()))
+	assert.Equal(t, key, changedKey)
+	assert.NotEqual(t, etag, changedTag)
+
+	// Requests that encode differently have different keys
+	jpeg := *req
+	jpeg.Format = types.FormatJPEG
+	otherKey, otherTag := frameTag(&jpeg, gradientCapture(false, time.Now()))
+	assert.NotEqual(t, key, otherKey)
+	assert.NotEqual(t, etag, otherTag)
+
+	// Captures that can't be hashed aren't tagged
+	empty := gradientCapture(false, time.Now())
+	empty.Width, empty.Height = 0, 0
+	key, etag = frameTag(req, empty)
+	assert.Empty(t, key)
+	assert.Empty(t, etag)
+}
+
+func TestFrameCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	response := func(etag string, size int) *types.ScreenshotResponse {
+		return &types.ScreenshotResponse{Success: true, ETag: etag, Data: strings.Repeat("x", size)}
+	}
+	cache := newFrameCache(100)
+	cache.put("a", response("ta", 40))
+	cache.put("b", response("tb", 40))
+	require.NotNil(t, cache.get("a", "ta"), "a is now the most recently used")
+
+	cache.put("c", response("tc", 40))
+	assert.Nil(t, cache.get("b", "tb"), "b was the least recently used")
+	assert.NotNil(t, cache.get("a", "ta"))
+	assert.NotNil(t, cache.get("c", "tc"))
+	assert.Equal(t, int64(80), cache.size)
+
+	// Replacing an entry releases the old response's bytes
+	cache.put("a", response("ta2", 10))
+	assert.Nil(t, cache.get("a", "ta"), "the ETag no longer matches")
+	assert.NotNil(t, cache.get("a", "ta2"))
+	assert.Equal(t, int64(50), cache.size)
+
+	// Responses larger than the whole cache aren't kept
+	cache.put("d", response("td", 101))
+	assert.Nil(t, cache.get("d", "td"))
+	assert.Equal(t, 2, cache.order.Len())
+
+	cache.resize(40)
+	assert.Nil(t, cache.get("c", "tc"), "c was used before a")
+	assert.NotNil(t, cache.get("a", "ta2"))
+	assert.Equal(t, int64(10), cache.size)
+
+	cache.resize(0)
+	assert.Zero(t, cache.size)
+	assert.Empty(t, cache.entries)
+	assert.Zero(t, cache.order.Len())
+}
+
+func TestFrameCacheGetReturnsCopy(t *testing.T) {
+	cache := newFrameCache(100)
+	cache.put("a", &types.ScreenshotResponse{ETag: "ta", Data: "data"})
+	first := cache.get("a", "ta")
+	require.NotNil(t, first)
+	assert.True(t, first.Cached)
+	first.Data = "changed"
+	assert.Equal(t, "data", cache.get("a", "ta").Data)
+}
+
+func TestCheckFrameCache(t *testing.T) {
+	s := &Server{frames: newFrameCache(1 << 20)}
+	req := &types.ScreenshotRequest{Method: "title", Target: "Notepad"}
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	key, etag, response := s.checkFrameCache(req, gradientCapture(false, first), 0)
+	require.NotEmpty(t, etag)
+	assert.Nil(t, response, "nothing cached yet")
+	s.cacheFrame(key, etag, &types.ScreenshotResponse{Success: true, Data: "encoded", Timestamp: first})
+
+	// A capture that looks the same is answered from the cache with its original time
+	later := first.Add(time.Minute)
+	_, _, response = s.checkFrameCache(req, gradientCapture(false, later), 0)
+	require.NotNil(t, response)
+	assert.True(t, response.Cached)
+	assert.Equal(t, "encoded", response.Data)
+	assert.True(t, response.Timestamp.Equal(first), "cached at %s", response.Timestamp)
+
+	// A conditional request for an unchanged capture gets no data
+	conditional := *req
+	conditional.IfNoneMatch = etag
+	_, _, response = s.checkFrameCache(&conditional, gradientCapture(false, later), 0)
+	require.NotNil(t, response)
+	assert.True(t, response.Unchanged)
+	assert.Empty(t, response.Data)
+
+	// Changed captures and captures with popups are encoded again
+	_, _, response = s.checkFrameCache(req, gradientCapture(true, later), 0)
+	assert.Nil(t, response)
+	key, etag, response = s.checkFrameCache(req, gradientCapture(false, later), 1)
+	assert.Empty(t, key)
+	assert.Empty(t, etag)
+	assert.Nil(t, response)
+
+	// A disabled cache answers only conditional requests
+	s.frames.resize(0)
+	_, _, response = s.checkFrameCache(req, gradientCapture(false, later), 0)
+	assert.Nil(t, response)
+	_, _, response = s.checkFrameCache(&conditional, gradientCapture(false, later), 0)
+	assert.NotNil(t, response)
+}
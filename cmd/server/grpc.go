@@ -0,0 +1,557 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/budget"
+	"github.com/screenshot-mcp-server/internal/power"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/screenshotpb"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcShutdownTimeout bounds how long a graceful stop waits for open streams
+const grpcShutdownTimeout = 5 * time.Second
+
+// grpcService implements screenshot.v1.ScreenshotService on top of the same capture
+// paths as the HTTP and MCP handlers
+type grpcService struct {
+	screenshotpb.UnimplementedScreenshotServiceServer
+	server    *Server
+	processor *screenshot.ImageProcessor
+}
+
+// startGRPC serves the gRPC API on the configured port; it returns nil when the port is 0
+func (s *Server) startGRPC() (*grpc.Server, error) {
+	if s.config.Load().GRPCPort == 0 {
+		return nil, nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.config.Load().Host, s.config.Load().GRPCPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.recoverUnary),
+		grpc.ChainStreamInterceptor(s.recoverStream),
+	)
+	screenshotpb.RegisterScreenshotServiceServer(grpcServer, &grpcService{
+		server:    s,
+		processor: screenshot.NewImageProcessor(),
+	})
+	reflection.Register(grpcServer)
+
+	s.logger.Info("Starting gRPC server", zap.String("address", listener.Addr().String()))
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			s.logger.Error("gRPC server stopped", zap.Error(err))
+		}
+	}()
+	return grpcServer, nil
+}
+
+// recoverUnary fails a unary call that panicked with Internal instead of crashing the
+// server
+func (s *Server) recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = s.grpcPanic(info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoverStream fails a streaming call that panicked with Internal instead of crashing
+// the server
+func (s *Server) recoverStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = s.grpcPanic(info.FullMethod, p)
+		}
+	}()
+	return handler(srv, stream)
+}
+
+// grpcPanic records a panic of a gRPC call and returns its status
+func (s *Server) grpcPanic(method string, p interface{}) error {
+	report := s.crashes.Capture("grpc", p, map[string]string{"method": method})
+	s.logger.Error("gRPC handler panicked",
+		zap.String("method", method),
+		zap.String("crash_id", report.ID),
+		zap.Any("error", p),
+	)
+	return status.Errorf(codes.Internal, "internal error (crash %s)", report.ID)
+}
+
+// stopGRPC lets in-flight calls finish, cancelling streams that outlast the timeout
+func stopGRPC(grpcServer *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(grpcShutdownTimeout):
+		grpcServer.Stop()
+	}
+}
+
+// Capture takes a screenshot and returns it encoded in the requested format
+func (g *grpcService) Capture(ctx context.Context, in *screenshotpb.CaptureRequest) (*screenshotpb.CaptureResponse, error) {
+	s := g.server
+	startTime := time.Now()
+
+	req := captureRequestFromProto(in)
+	if req.Target == "" && methodRequiresTarget(req.Method) {
+		return nil, grpcError(invalidRequest(fmt.Errorf("missing required field: target")))
+	}
+	if err := s.prepareScreenshotRequest(req); err != nil {
+		return nil, grpcError(err)
+	}
+
+	options := &types.CaptureOptions{
+		IncludeCursor:     req.IncludeCursor,
+		IncludeFrame:      in.IncludeFrame == nil || *in.IncludeFrame,
+		ScaleFactor:       1.0,
+		AllowMinimized:    in.AllowMinimized == nil || *in.AllowMinimized,
+		RestoreWindow:     in.RestoreWindow,
+		WaitForVisible:    2 * time.Second,
+		RetryCount:        3,
+		RetryBackoff:      100 * time.Millisecond,
+		RetryMaxBackoff:   2 * time.Second,
+		DetectBlackFrames: true,
+		Context:           ctx,
+		CustomProperties:  make(map[string]string),
+	}
+
+	plan, err := applyScreenshotRequest(req, options)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	buffer, err := s.captureWhenReady(req, plan, options)
+	if err != nil {
+		s.logger.Error("Screenshot capture failed",
+			zap.String("method", req.Method),
+			zap.String("target", req.Target),
+			zap.String("code", string(types.ErrorCodeOf(err))),
+			zap.Error(err),
+		)
+		return nil, grpcError(err)
+	}
+	if buffer, _, err = s.manageColor(buffer, req.ColorManagement); err != nil {
+		return nil, grpcError(err)
+	}
+	if buffer, err = s.postProcess(buffer, req.Pipeline); err != nil {
+		return nil, grpcError(err)
+	}
+
+	popups, err := s.capturePopupBuffers(req, buffer, options)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	image, err := g.encode(buffer, req.Format, req.Quality)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	s.rememberCapture(req, &types.ScreenshotResponse{
+		Data:      base64.StdEncoding.EncodeToString(image.Data),
+		Format:    image.Format,
+		Width:     buffer.Width,
+		Height:    buffer.Height,
+		Timestamp: buffer.Timestamp,
+	})
+
+	response := &screenshotpb.CaptureResponse{
+		Image:  image,
+		Window: windowInfoToProto(&buffer.WindowInfo),
+		Metadata: &screenshotpb.Metadata{
+			CaptureMethod:      captureMethodName(buffer, req.Method),
+			ProcessingTimeUs:   time.Since(startTime).Microseconds(),
+			WindowVisible:      buffer.WindowInfo.IsVisible,
+			WindowMinimized:    buffer.WindowInfo.State == "minimized",
+			DpiScaling:         float64(buffer.DPI) / 96.0,
+			BlackFrameDetected: buffer.BlackFrameDetected,
+			Retries:            int32(buffer.Retries),
+			OccludedPercent:    buffer.WindowInfo.OccludedPercent,
+			OccludedBy:         handlesToProto(buffer.WindowInfo.OccludedBy),
+		},
+	}
+	for _, attempt := range buffer.Attempts {
+		response.Metadata.Attempts = append(response.Metadata.Attempts, &screenshotpb.CaptureAttempt{
+			Method:     string(attempt.Method),
+			DurationUs: attempt.Duration.Microseconds(),
+			Success:    attempt.Success,
+			Error:      attempt.Error,
+			Retry:      int32(attempt.Retry),
+		})
+	}
+	for i := range buffer.OwnedWindows {
+		response.Metadata.OwnedWindows = append(response.Metadata.OwnedWindows, windowInfoToProto(&buffer.OwnedWindows[i]))
+	}
+	for _, popup := range popups {
+		popupImage, err := g.encode(popup, req.Format, req.Quality)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		response.Popups = append(response.Popups, &screenshotpb.Popup{
+			Window: windowInfoToProto(&popup.WindowInfo),
+			Image:  popupImage,
+		})
+	}
+
+	s.logger.Info("Screenshot captured successfully",
+		zap.String("method", req.Method),
+		zap.String("capture_method", string(buffer.CaptureMethod)),
+		zap.String("target", req.Target),
+		zap.Int("width", buffer.Width),
+		zap.Int("height", buffer.Height),
+		zap.String("transport", "grpc"),
+	)
+
+	return response, nil
+}
+
+// ListWindows lists windows matching the request's filter
+func (g *grpcService) ListWindows(ctx context.Context, in *screenshotpb.ListWindowsRequest) (*screenshotpb.ListWindowsResponse, error) {
+	filter := &types.WindowFilter{
+		TitleContains:  in.TitleContains,
+		VisibleOnly:    in.VisibleOnly,
+		ExcludeSystem:  in.ExcludeSystem,
+		VirtualDesktop: in.VirtualDesktop,
+	}
+
+	windows, err := g.server.windowManager.EnumerateWindows(filter)
+	if err != nil {
+		g.server.logger.Error("Failed to enumerate windows", zap.Error(err))
+		return nil, grpcError(err)
+	}
+
+	response := &screenshotpb.ListWindowsResponse{}
+	for i := range windows {
+		response.Windows = append(response.Windows, windowInfoToProto(&windows[i]))
+	}
+	return response, nil
+}
+
+// StreamFrames captures a window at the requested rate until the client cancels. Like
+// the WebSocket stream it skips frames that fail to capture and pauses with a single
+// desktop state event while the desktop is locked.
+func (g *grpcService) StreamFrames(in *screenshotpb.StreamFramesRequest, stream screenshotpb.ScreenshotService_StreamFramesServer) error {
+	s := g.server
+	options := &types.StreamOptions{
+		FPS:       s.config.Load().StreamDefaultFPS,
+		Quality:   s.config.Load().Quality,
+		Format:    types.ImageFormat(s.config.Load().DefaultFormat),
+		MaxWidth:  int(in.MaxWidth),
+		MaxHeight: int(in.MaxHeight),
+	}
+	if in.Fps != 0 {
+		if in.Fps < 0 || in.Fps > 60 {
+			return grpcError(invalidRequest(fmt.Errorf("fps must be between 1 and 60")))
+		}
+		options.FPS = int(in.Fps)
+	}
+	if in.Quality != 0 {
+		if in.Quality < 0 || in.Quality > 100 {
+			return grpcError(invalidRequest(fmt.Errorf("quality must be between 1 and 100")))
+		}
+		options.Quality = int(in.Quality)
+	}
+	if in.Format != "" {
+		options.Format = types.ImageFormat(in.Format)
+	}
+	if err := validateImageFormat(options.Format); err != nil {
+		return grpcError(err)
+	}
+
+	handle := uintptr(in.Handle)
+	s.logger.Info("Starting gRPC stream",
+		zap.Uintptr("window_id", handle),
+		zap.Int("fps", options.FPS),
+		zap.String("format", string(options.Format)),
+	)
+
+	captureOptions := types.DefaultCaptureOptions()
+	captureOptions.AllowMinimized = true
+	captureOptions.RestoreWindow = false
+	captureOptions.Priority = types.PriorityStream
+	captureOptions.Context = stream.Context()
+
+	interval := time.Second / time.Duration(options.FPS)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Set while the desktop is locked or otherwise uncapturable
+	var desktopState types.DesktopState
+	var frameNumber int64
+	governor := budget.NewGovernor()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			s.logger.Info("gRPC stream closed",
+				zap.Uintptr("window_id", handle),
+				zap.Int64("frames", frameNumber),
+			)
+			return nil
+		case <-ticker.C:
+		}
+
+		// Frames that fall due while the user's activity pauses streaming are skipped
+		if s.activity.Paused() {
+			continue
+		}
+		// On battery and in battery saver the power policy caps the frame rate and quality
+		limits, _ := s.power.Limits()
+		fps, quality, _ := power.Apply(limits, options.FPS, options.Quality)
+		// and the CPU budget the frame rate further
+		fps, _ = governor.Limit(fps, s.config.Load().StreamCPUBudget, time.Now())
+		if next := time.Second / time.Duration(fps); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+
+		var buffer *types.ScreenshotBuffer
+		var err error
+		cpu := budget.Measure(func() {
+			buffer, err = s.engine.CaptureByHandle(handle, captureOptions)
+		})
+		var unavailable *types.DesktopUnavailableError
+		if errors.As(err, &unavailable) {
+			if unavailable.State != desktopState {
+				desktopState = unavailable.State
+				if err := stream.Send(desktopStateEvent(desktopState)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err != nil {
+			s.logger.Warn("Failed to capture frame", zap.Uintptr("window_id", handle), zap.Error(err))
+			governor.Add(cpu)
+			continue
+		}
+
+		if desktopState != "" {
+			desktopState = ""
+			if err := stream.Send(desktopStateEvent(types.DesktopAvailable)); err != nil {
+				return err
+			}
+		}
+
+		var image *screenshotpb.Image
+		cpu += budget.Measure(func() {
+			if buffer, err = g.fit(buffer, options.MaxWidth, options.MaxHeight); err == nil {
+				image, err = g.encode(buffer, options.Format, quality)
+			}
+		})
+		governor.Add(cpu)
+		if err != nil {
+			return grpcError(err)
+		}
+
+		frameNumber++
+		err = stream.Send(&screenshotpb.StreamEvent{
+			Event: &screenshotpb.StreamEvent_Frame{
+				Frame: &screenshotpb.Frame{FrameNumber: frameNumber, Image: image},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// encode encodes a capture for a response
+func (g *grpcService) encode(buffer *types.ScreenshotBuffer, format types.ImageFormat, quality int) (*screenshotpb.Image, error) {
+	data, err := g.processor.Encode(buffer, format, quality)
+	if err != nil {
+		return nil, err
+	}
+	return &screenshotpb.Image{
+		Data:      data,
+		Format:    string(format),
+		Width:     int32(buffer.Width),
+		Height:    int32(buffer.Height),
+		Timestamp: timestamppb.New(buffer.Timestamp),
+	}, nil
+}
+
+// fit scales a frame down to the stream's maximum size, keeping its aspect ratio
+func (g *grpcService) fit(buffer *types.ScreenshotBuffer, maxWidth, maxHeight int) (*types.ScreenshotBuffer, error) {
+	return fitBuffer(g.processor, buffer, maxWidth, maxHeight)
+}
+
+// fitBuffer scales a capture down to at most maxWidth by maxHeight, keeping its aspect
+// ratio; 0 leaves a dimension unbounded
+func fitBuffer(processor *screenshot.ImageProcessor, buffer *types.ScreenshotBuffer, maxWidth, maxHeight int) (*types.ScreenshotBuffer, error) {
+	width, height := buffer.Width, buffer.Height
+	if maxWidth > 0 && width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width = width * maxHeight / height
+		height = maxHeight
+	}
+	if width == buffer.Width && height == buffer.Height {
+		return buffer, nil
+	}
+	return processor.Resize(buffer, max(width, 1), max(height, 1))
+}
+
+// desktopStateEvent reports the desktop becoming unavailable or available again
+func desktopStateEvent(state types.DesktopState) *screenshotpb.StreamEvent {
+	return &screenshotpb.StreamEvent{
+		Event: &screenshotpb.StreamEvent_DesktopState{
+			DesktopState: &screenshotpb.DesktopStateChange{
+				Available: state == types.DesktopAvailable,
+				State:     string(state),
+			},
+		},
+	}
+}
+
+// captureRequestFromProto converts a gRPC capture request, applying the same default
+// method as the MCP screenshot method
+func captureRequestFromProto(in *screenshotpb.CaptureRequest) *types.ScreenshotRequest {
+	req := &types.ScreenshotRequest{
+		Method:               in.Method,
+		Target:               in.Target,
+		Format:               types.ImageFormat(in.Format),
+		Quality:              int(in.Quality),
+		IncludeCursor:        in.IncludeCursor,
+		RegionRelativeTo:     types.RegionOrigin(in.RegionRelativeTo),
+		CaptureMethod:        types.CaptureMethod(in.CaptureMethod),
+		Match:                in.Match,
+		TopLevel:             in.TopLevel,
+		RetryBackoff:         in.RetryBackoff,
+		RejectBlackFrames:    in.RejectBlackFrames,
+		CaptureOtherDesktops: in.CaptureOtherDesktops,
+		IncludeOwnedWindows:  in.IncludeOwnedWindows,
+	}
+	if req.Method == "" {
+		req.Method = "title"
+	}
+	for _, method := range in.FallbackMethods {
+		req.FallbackMethods = append(req.FallbackMethods, types.CaptureMethod(method))
+	}
+	if in.RetryCount != nil {
+		retryCount := int(*in.RetryCount)
+		req.RetryCount = &retryCount
+	}
+	if r := in.Region; r != nil {
+		req.Region = &types.Rectangle{X: int(r.X), Y: int(r.Y), Width: int(r.Width), Height: int(r.Height)}
+	}
+	if w := in.WaitFor; w != nil {
+		req.WaitFor = &types.WaitCondition{
+			TitleRegex:   w.TitleRegex,
+			Element:      w.Element,
+			StableFrames: int(w.StableFrames),
+			Timeout:      w.Timeout,
+			Interval:     w.Interval,
+		}
+		if p := w.Pixel; p != nil {
+			req.WaitFor.Pixel = &types.PixelCondition{X: int(p.X), Y: int(p.Y), Color: p.Color, Tolerance: int(p.Tolerance)}
+		}
+	}
+	if p := in.Popups; p != nil {
+		req.Popups = &types.PopupCapture{Within: p.Within, MaxPopups: int(p.MaxPopups), Classes: p.Classes}
+	}
+	return req
+}
+
+// windowInfoToProto converts window information for a gRPC response
+func windowInfoToProto(info *types.WindowInfo) *screenshotpb.WindowInfo {
+	return &screenshotpb.WindowInfo{
+		Handle:           uint64(info.Handle),
+		Title:            info.Title,
+		ClassName:        info.ClassName,
+		ProcessId:        info.ProcessID,
+		ThreadId:         info.ThreadID,
+		Rect:             rectangleToProto(info.Rect),
+		ClientRect:       rectangleToProto(info.ClientRect),
+		State:            info.State,
+		ZOrder:           int32(info.ZOrder),
+		IsVisible:        info.IsVisible,
+		IsTopmost:        info.IsTopMost,
+		Monitor:          int32(info.Monitor),
+		IntegrityLevel:   info.IntegrityLevel,
+		DisplayAffinity:  info.DisplayAffinity,
+		VirtualDesktopId: info.VirtualDesktopID,
+		OnCurrentDesktop: info.OnCurrentDesktop,
+		OccludedPercent:  info.OccludedPercent,
+		OccludedBy:       handlesToProto(info.OccludedBy),
+	}
+}
+
+func rectangleToProto(r types.Rectangle) *screenshotpb.Rectangle {
+	return &screenshotpb.Rectangle{X: int32(r.X), Y: int32(r.Y), Width: int32(r.Width), Height: int32(r.Height)}
+}
+
+func handlesToProto(handles []uintptr) []uint64 {
+	if len(handles) == 0 {
+		return nil
+	}
+	converted := make([]uint64, len(handles))
+	for i, handle := range handles {
+		converted[i] = uint64(handle)
+	}
+	return converted
+}
+
+// grpcCodes maps error codes to gRPC status codes, following captureErrorStatus
+var grpcCodes = map[types.ErrorCode]codes.Code{
+	types.ErrInvalidRequest:     codes.InvalidArgument,
+	types.ErrUnsupportedFormat:  codes.InvalidArgument,
+	types.ErrAccessDenied:       codes.PermissionDenied,
+	types.ErrElevationRequired:  codes.PermissionDenied,
+	types.ErrCaptureExcluded:    codes.PermissionDenied,
+	types.ErrWindowNotFound:     codes.NotFound,
+	types.ErrAmbiguousWindow:    codes.FailedPrecondition,
+	types.ErrOtherDesktop:       codes.FailedPrecondition,
+	types.ErrBlackFrame:         codes.FailedPrecondition,
+	types.ErrDWMUnavailable:     codes.Unavailable,
+	types.ErrDesktopUnavailable: codes.Unavailable,
+	types.ErrTimeout:            codes.DeadlineExceeded,
+	types.ErrTargetUnreachable:  codes.Unavailable,
+	types.ErrCancelled:          codes.Canceled,
+}
+
+// grpcError converts a failed capture to a gRPC status. The error code is attached as
+// the reason of an ErrorInfo detail, along with the desktop state when it applies.
+func grpcError(err error) error {
+	code := types.ErrorCodeOf(err)
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+
+	info := &errdetails.ErrorInfo{Reason: string(code), Domain: "screenshot-mcp-server"}
+	var unavailable *types.DesktopUnavailableError
+	if errors.As(err, &unavailable) {
+		info.Metadata = map[string]string{"desktop_state": string(unavailable.State)}
+	}
+
+	st, detailErr := status.New(grpcCode, err.Error()).WithDetails(info)
+	if detailErr != nil {
+		return status.Error(grpcCode, err.Error())
+	}
+	return st.Err()
+}
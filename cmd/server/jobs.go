@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// jobIDHeader names a REST call's job, so it can be cancelled while it runs
+const jobIDHeader = "X-Job-ID"
+
+// Errors of calls cancelled while they ran
+const (
+	mcpRequestCancelled = -32800 // JSON-RPC error code
+	statusCancelled     = 499    // HTTP status, nginx's "Client Closed Request"; there's no standard one
+)
+
+// jobRegistry tracks the operations in flight, so they can be cancelled by ID.
+// Cancelling a job cancels its context, which captures, waits and OCR give up on.
+type jobRegistry struct {
+	mutex  sync.Mutex
+	jobs   map[string]*job
+	lastID uint64
+}
+
+// jobListResponse lists the running jobs
+type jobListResponse struct {
+	Jobs  []types.JobInfo `json:"jobs"`
+	Count int             `json:"count"`
+}
+
+type job struct {
+	info   types.JobInfo
+	cancel context.CancelFunc
+}
+
+func newJobRegistry() *jobRegistry {
+	return &jobRegistry{jobs: make(map[string]*job)}
+}
+
+// start registers a job with the ID the caller chose, or a generated one when id is
+// empty. It returns the job's context, its ID and the function that ends it, or false
+// when another running job has the ID.
+func (r *jobRegistry) start(ctx context.Context, id, method, source string) (context.Context, string, func(), bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if id == "" {
+		r.lastID++
+		id = fmt.Sprintf("job-%d", r.lastID)
+	}
+	if _, taken := r.jobs[id]; taken {
+		return nil, "", nil, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	j := &job{
+		info:   types.JobInfo{ID: id, Method: method, Source: source, StartedAt: time.Now()},
+		cancel: cancel,
+	}
+	r.jobs[id] = j
+	done := func() {
+		cancel()
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.jobs[id] == j {
+			delete(r.jobs, id)
+		}
+	}
+	return ctx, id, done, true
+}
+
+// cancel cancels a running job, reporting whether there was one
+func (r *jobRegistry) cancel(id string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return false
+	}
+	j.info.Cancelled = true
+	j.cancel()
+	return true
+}
+
+// list returns the running jobs, oldest first
+func (r *jobRegistry) list() []types.JobInfo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	jobs := make([]types.JobInfo, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		jobs = append(jobs, j.info)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.Before(jobs[j].StartedAt) })
+	return jobs
+}
+
+// trackJob runs a REST call as a job. The client can name it with the X-Job-ID header
+// to cancel it later; otherwise it's given an ID, sent back in the same header.
+func (s *Server) trackJob(c *gin.Context) {
+	ctx, id, done, ok := s.jobs.start(c.Request.Context(), c.GetHeader(jobIDHeader), c.Request.Method+" "+c.FullPath(), "http")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("job %s is already running", c.GetHeader(jobIDHeader))})
+		return
+	}
+	defer done()
+	c.Header(jobIDHeader, id)
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+// startMCPJob runs an MCP call as a job with the call's ID, or a generated one if a
+// running job already has it. Notifications and the job methods themselves aren't jobs.
+func (s *Server) startMCPJob(c *gin.Context, req *types.MCPRequest) func() {
+	if req.ID == nil || strings.HasPrefix(req.Method, "job.") {
+		return func() {}
+	}
+	ctx, _, done, ok := s.jobs.start(c.Request.Context(), fmt.Sprint(req.ID), req.Method, "mcp")
+	if !ok {
+		ctx, _, done, _ = s.jobs.start(c.Request.Context(), "", req.Method, "mcp")
+	}
+	c.Request = c.Request.WithContext(ctx)
+	return done
+}
+
+// listJobs returns the running jobs, with the running recordings, which cancelling stops
+func (s *Server) listJobs() []types.JobInfo {
+	jobs := s.jobs.list()
+	for _, info := range s.recorder.List() {
+		if info.Status == "recording" {
+			jobs = append(jobs, types.JobInfo{ID: info.ID, Method: "recording", Source: "recording", StartedAt: info.StartTime})
+		}
+	}
+	return jobs
+}
+
+// cancelJob cancels a running job, or stops a running recording, reporting whether
+// there was one
+func (s *Server) cancelJob(id, reason string) bool {
+	cancelled := s.jobs.cancel(id)
+	if !cancelled {
+		if info, err := s.recorder.Get(id); err == nil && info.Status == "recording" {
+			_, err := s.recorder.Stop(id)
+			cancelled = err == nil
+		}
+	}
+	if cancelled {
+		s.logger.Info("Job cancelled", zap.String("job_id", id), zap.String("reason", reason))
+	}
+	return cancelled
+}
+
+// getJobs lists the running jobs
+func (s *Server) getJobs(c *gin.Context) {
+	jobs := s.listJobs()
+	c.JSON(http.StatusOK, jobListResponse{Jobs: jobs, Count: len(jobs)})
+}
+
+// deleteJob cancels a running job; the cancelled call fails with CANCELLED
+func (s *Server) deleteJob(c *gin.Context) {
+	if !s.cancelJob(c.Param("id"), "cancelled through the API") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// handleMCPJobList handles MCP job list requests
+func (s *Server) handleMCPJobList(c *gin.Context, req *types.MCPRequest) {
+	jobs := s.listJobs()
+	s.sendMCPResult(c, req.ID, jobListResponse{Jobs: jobs, Count: len(jobs)})
+}
+
+// handleMCPJobCancel cancels a running job by ID: an MCP call's ID or a REST call's job ID
+func (s *Server) handleMCPJobCancel(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	id, ok := params["id"]
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: id", nil)
+		return
+	}
+	if !s.cancelJob(fmt.Sprint(id), getString(params, "reason", "cancelled through MCP")) {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", "job not found")
+		return
+	}
+	s.sendMCPResult(c, req.ID, gin.H{"cancelled": true})
+}
+
+// handleMCPCancelledNotification cancels the call a notifications/cancelled message names
+func (s *Server) handleMCPCancelledNotification(req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	if id, ok := params["requestId"]; ok {
+		s.cancelJob(fmt.Sprint(id), getString(params, "reason", "cancelled by the MCP host"))
+	}
+}
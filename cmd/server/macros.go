@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/macro"
+	"github.com/screenshot-mcp-server/internal/scheduler"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// macroStatusResponse reports a macro being recorded
+type macroStatusResponse struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "recording"
+}
+
+var (
+	errMacroRecording = errors.New("a macro is already being recorded")
+	errNoMacro        = errors.New("no macro of that name is being recorded")
+)
+
+// startMacro starts recording the user's input into a workflow of the workflows directory
+func (s *Server) startMacro(req *types.MacroRecordRequest) error {
+	driver, err := s.inputDriver()
+	if err != nil {
+		return err
+	}
+	options := macro.Options{Method: req.Method, Target: req.Target, Overwrite: req.Overwrite}
+	if req.Interval != "" {
+		if options.Interval, err = time.ParseDuration(req.Interval); err != nil || options.Interval < 0 {
+			return invalidRequest(fmt.Errorf("invalid interval: %s", req.Interval))
+		}
+	}
+	if options.Method == "" {
+		options.Method = "foreground"
+	}
+	if options.Target == "" && methodRequiresTarget(options.Method) {
+		return invalidRequest(fmt.Errorf("window method %s needs a target", options.Method))
+	}
+
+	s.macroMutex.Lock()
+	defer s.macroMutex.Unlock()
+	if s.macro != nil {
+		return errMacroRecording
+	}
+	recording, err := macro.Start(driver, s.config.Load().WorkflowDir, req.Name, options, s.captureMacroFrame, s.logger)
+	if errors.Is(err, macro.ErrExists) {
+		return invalidRequest(fmt.Errorf("workflow %s exists; set overwrite to replace it", req.Name))
+	}
+	if err != nil {
+		return err
+	}
+	s.macro = recording
+	s.logger.Info("Macro recording started", zap.String("macro", req.Name))
+	return nil
+}
+
+// stopMacro stops recording a macro and saves its workflow
+func (s *Server) stopMacro(name string) (*types.MacroResult, error) {
+	s.macroMutex.Lock()
+	defer s.macroMutex.Unlock()
+	if s.macro == nil || s.macro.Name() != name {
+		return nil, errNoMacro
+	}
+	result, err := s.macro.Stop()
+	s.macro = nil
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Macro recorded",
+		zap.String("macro", name),
+		zap.Int("steps", result.Steps),
+		zap.Int("frames", result.Frames),
+		zap.Duration("duration", result.Duration),
+	)
+	return result, nil
+}
+
+// saveMacro stops the macro being recorded, if any, on shutdown
+func (s *Server) saveMacro() {
+	s.macroMutex.Lock()
+	recording := s.macro
+	s.macroMutex.Unlock()
+	if recording == nil {
+		return
+	}
+	if _, err := s.stopMacro(recording.Name()); err != nil {
+		s.logger.Error("Failed to save macro", zap.String("macro", recording.Name()), zap.Error(err))
+	}
+}
+
+// captureMacroFrame takes a screenshot of a macro's window as PNG
+func (s *Server) captureMacroFrame(ctx context.Context, method, target string) ([]byte, error) {
+	ctx = scheduler.WithPriority(ctx, types.PriorityScheduled)
+	response, err := s.screenshot(ctx, &types.ScreenshotRequest{Method: method, Target: target, Format: types.FormatPNG})
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := responseBuffer(response)
+	if err != nil {
+		return nil, err
+	}
+	img, err := screenshot.NewImageProcessor().ToImage(buffer)
+	if err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// postMacro starts recording a macro
+func (s *Server) postMacro(c *gin.Context) {
+	var req types.MacroRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	err := s.startMacro(&req)
+	if errors.Is(err, errMacroRecording) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, macroStatusResponse{Name: req.Name, Status: "recording"})
+}
+
+// postMacroStop stops recording a macro and returns its workflow
+func (s *Server) postMacroStop(c *gin.Context) {
+	result, err := s.stopMacro(c.Param("name"))
+	if errors.Is(err, errNoMacro) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// postMacroPlay replays a macro, comparing its screenshots with the recorded ones
+func (s *Server) postMacroPlay(c *gin.Context) {
+	s.postWorkflow(c)
+}
+
+func (s *Server) handleMCPMacroRecord(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	name := getString(params, "name", "")
+	if name == "" {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", "name is required")
+		return
+	}
+
+	switch action := getString(params, "action", "start"); action {
+	case "start":
+		record := types.MacroRecordRequest{
+			Name:      name,
+			Method:    getString(params, "method", ""),
+			Target:    getString(params, "target", ""),
+			Interval:  getString(params, "interval", ""),
+			Overwrite: getBool(params, "overwrite", false),
+		}
+		err := s.startMacro(&record)
+		if errors.Is(err, errMacroRecording) {
+			s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+			return
+		}
+		if err != nil {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPResult(c, req.ID, macroStatusResponse{Name: name, Status: "recording"})
+	case "stop":
+		result, err := s.stopMacro(name)
+		if errors.Is(err, errNoMacro) {
+			s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+			return
+		}
+		if err != nil {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPResult(c, req.ID, result)
+	default:
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", fmt.Sprintf("unknown action %q; use start or stop", action))
+	}
+}
+
+func (s *Server) handleMCPMacroPlay(c *gin.Context, req *types.MCPRequest) {
+	s.handleMCPWorkflowRun(c, req)
+}
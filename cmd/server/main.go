@@ -3,20 +3,45 @@ package main
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/internal/activity"
 	"github.com/screenshot-mcp-server/internal/chrome"
+	"github.com/screenshot-mcp-server/internal/crash"
+	"github.com/screenshot-mcp-server/internal/input"
+	"github.com/screenshot-mcp-server/internal/logging"
+	"github.com/screenshot-mcp-server/internal/macro"
+	"github.com/screenshot-mcp-server/internal/openapi"
+	"github.com/screenshot-mcp-server/internal/plugin"
+	"github.com/screenshot-mcp-server/internal/power"
+	"github.com/screenshot-mcp-server/internal/recording"
+	"github.com/screenshot-mcp-server/internal/scheduler"
+	"github.com/screenshot-mcp-server/internal/tracing"
+	"github.com/screenshot-mcp-server/internal/publish"
+	"github.com/screenshot-mcp-server/internal/relay"
 	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/window"
+	"github.com/screenshot-mcp-server/internal/webcam"
 	"github.com/screenshot-mcp-server/internal/ws"
+	"github.com/screenshot-mcp-server/pkg/client"
+	"github.com/screenshot-mcp-server/pkg/framecrypt"
 	"github.com/screenshot-mcp-server/pkg/types"
 	"go.uber.org/zap"
 )
@@ -26,49 +51,316 @@ type Server struct {
 	engine         types.ScreenshotEngine
 	chromeManager  types.ChromeManager
 	streamManager  *ws.StreamManager
+	events         *ws.EventHub
+	mcpSessions    *mcpSSEHub
+	windowManager  types.WindowManager
+	recorder       *recording.Recorder
+	webcam         *webcam.Camera // Virtual webcam fed captures of a window
+	outputs        *publish.Publisher // Windows and displays published to RTMP servers
+	agents         *relay.Hub
+	targets        map[string]*federatedTarget
+	aliases        *targetAliases // Saved targets requests name with method "alias"
+	usage          *usageCounters // Captures and stream frames counted across restarts
+	captureSets    *captureSets // Saved groups of targets captured together
+	recentErrors   *errorLog // Recent capture errors, for diagnostics bundles
+	frames         *frameCache // Last response of each screenshot request
+	history        *captureHistory // Recent captures, readable as MCP resources
+	jobs           *jobRegistry // REST and MCP calls in flight, which can be cancelled
+	scheduler      *scheduler.Scheduler // Slots captures run in
+	crashes        *crash.Reporter
+	elevatedHelper *screenshot.ElevatedHelper
 	logger         *zap.Logger
+	logLevel       zap.AtomicLevel // Level of logger, changed through the admin API
+	logSinks       *logging.Logger
+	rpcDebug       *rpcLog // MCP exchanges, kept while RPC debugging is enabled
+	plugins        *plugin.Manager
+	input          input.Driver // nil unless input is allowed
+	macroMutex     sync.Mutex
+	macro          *macro.Recording // Macro being recorded
+	activity       *activity.Monitor // User activity, which can pause streams and recordings
+	power          *power.Monitor    // Power state, which can cap streams
 	router         *gin.Engine
 	httpServer     *http.Server
-	config         *Config
+	pipeline       atomic.Pointer[screenshot.Pipeline] // Default post-processing pipeline
+	watermarkPipeline *screenshot.Pipeline // Enforced watermark, applied after the pipeline
+	config         atomic.Pointer[Config] // Replaced when the config file is reloaded
 	upgrader       websocket.Upgrader
+	compression    ws.Compression // permessage-deflate level of stream connections
+	openAPIOnce    sync.Once
+	openAPISpec    *openapi.Document
 }
 
 // Config holds server configuration
 type Config struct {
 	Port           int    `json:"port"`
+	GRPCPort       int    `json:"grpc_port"` // 0 disables the gRPC API
 	Host           string `json:"host"`
 	DefaultFormat  string `json:"default_format"`
 	Quality        int    `json:"quality"`
 	IncludeCursor  bool   `json:"include_cursor"`
 	LogLevel       string `json:"log_level"`
 	ChromeTimeout  string `json:"chrome_timeout"`
+	// Megabytes of screenshot responses kept to answer repeated requests for unchanged
+	// captures; 0, the default, disables the cache, while ETags and If-None-Match still work
+	FrameCacheMB int `json:"frame_cache_mb"`
+	// Recent screenshots kept for MCP hosts to read as screenshot://history/{id}
+	// resources; 0 keeps none
+	CaptureHistory int `json:"capture_history"`
 	// WebSocket streaming configuration
 	StreamMaxSessions int `json:"stream_max_sessions"`
 	StreamDefaultFPS  int `json:"stream_default_fps"`
+	StreamResumeGrace string `json:"stream_resume_grace"`
+	// Longest a stream session may run, and may go without its client connected, before
+	// it's closed; "" for no limit
+	StreamMaxDuration string `json:"stream_max_duration"`
+	StreamIdleTimeout string `json:"stream_idle_timeout"`
+	// Percent of a CPU core each stream may spend capturing and encoding before its frame
+	// rate is lowered; 0 for no limit
+	StreamCPUBudget int `json:"stream_cpu_budget"`
+	// permessage-deflate level negotiated on the stream and events WebSockets: 0 turns
+	// compression off, 1 (fastest) to 9 (smallest)
+	StreamCompression int `json:"stream_compression"`
+	// Directory of the shared-memory frame rings of streams with transport=shm; "" disables
+	// the shm transport
+	StreamSharedMemoryDir string `json:"stream_shared_memory_dir"`
+	// softcam DLL the virtual webcam sends frames through; it must also be registered
+	// with regsvr32 for applications to see the camera
+	WebcamDLL string `json:"webcam_dll"`
+	// Captures run at once; more wait, interactive requests first
+	CaptureConcurrency int `json:"capture_concurrency"`
+	// Recording configuration
+	RecordingDir string `json:"recording_dir"`
+	// Launch an elevated helper (after a UAC prompt) to capture windows of elevated processes
+	ElevatedHelper bool `json:"elevated_helper"`
+	// Handling of windows excluded from capture: "fail" or "dwm_thumbnail"
+	ExcludedWindowPolicy string `json:"excluded_window_policy"`
+	// Named pipe serving MCP JSON-RPC on Windows; "" disables it
+	PipeName string `json:"pipe_name"`
+	// mDNS/DNS-SD advertisement on the local network
+	MDNS     bool   `json:"mdns"`
+	MDNSName string `json:"mdns_name"` // Instance name, default the machine name
+	MDNSAuth string `json:"mdns_auth"` // Authentication hint for clients: "none", "bearer" or "basic"
+	// Default post-processing pipeline, a JSON array of stages applied to captures whose
+	// request has no pipeline of its own
+	Pipeline string `json:"pipeline"`
+	// Watermark applied to every capture and stream frame, after any pipeline: its text,
+	// or watermark stage params as a JSON object, and the path of a PNG logo
+	Watermark     string `json:"watermark"`
+	WatermarkLogo string `json:"watermark_logo"`
+	// How captures from monitors with an ICC profile are treated: "none", "embed" or "srgb"
+	ColorManagement string `json:"color_management"`
+	// Bearer token of the /v1/admin API; "" disables it
+	AdminToken string `json:"-"`
+	// Pre-shared key that signs and encrypts stream frames, and the security level every
+	// stream gets at least: "none", "sign" or "encrypt"
+	StreamKey      string `json:"-"`
+	StreamSecurity string `json:"stream_security"`
+	// Relay: the token agents present to this server, and as an agent, the central
+	// server to connect to and the ID to register under (default the hostname)
+	RelayToken string `json:"-"`
+	RelayURL   string `json:"relay_url"`
+	AgentName  string `json:"agent_name"`
+	// Federation: other screenshot servers captures can be addressed to as "name:window",
+	// a JSON array of {"name", "url", "token"}
+	Targets string `json:"-"`
+	// Crash reporting: the file recovered panics are appended to ("" keeps them in memory
+	// only), and the DSN of a Sentry-compatible server to report them to
+	CrashLog  string `json:"crash_log"`
+	SentryDSN string `json:"-"`
+	// Logging: "json" or "console" encoding, a file written in addition to stderr and
+	// rotated at LogMaxSize MB keeping LogMaxBackups old files, and the Windows Event Log:
+	// "true", "false" or "auto" (when running as a service)
+	LogFormat     string `json:"log_format"`
+	LogFile       string `json:"log_file"`
+	LogMaxSize    int    `json:"log_max_size"`
+	LogMaxBackups int    `json:"log_max_backups"`
+	LogEventLog   string `json:"log_event_log"`
+	// Keep sanitized copies of MCP requests and responses for /v1/admin/debug/rpc
+	DebugRPC bool `json:"debug_rpc"`
+	// JSON file of settings overriding the defaults and environment, watched for changes
+	ConfigFile string `json:"-"`
+	// Directory of plugins adding MCP tools and pipeline stages, started with the server
+	PluginDir string `json:"plugin_dir"`
+	// Directory of workflow scripts run through /v1/workflows/{name} and workflow.run
+	WorkflowDir string `json:"workflow_dir"`
+	// JSON file the target aliases managed through /v1/targets/{name} are saved to; ""
+	// keeps them in memory only
+	TargetAliasFile string `json:"target_alias_file"`
+	// JSON file the usage counters served at /v1/stats are saved to, so they survive
+	// restarts; "" keeps them in memory only
+	StatsFile string `json:"stats_file"`
+	// JSON file the capture sets managed through /v1/capture-sets/{name} are saved to;
+	// "" keeps them in memory only
+	CaptureSetFile string `json:"capture_set_file"`
+	// Let workflows click and type, record macros of the user's input and scroll windows
+	// for scrolling captures
+	AllowInput bool `json:"allow_input"`
+	// Pause streams and recordings while the user is active ("pause_when_active") or idle
+	// ("pause_when_idle"), the user being idle after IdleAfter without input
+	ActivityGate string `json:"activity_gate"`
+	IdleAfter    string `json:"idle_after"`
+	// Caps of stream frame rate and quality on battery and in battery saver: empty for
+	// the defaults, "off" for none, or JSON such as {"battery":{"max_fps":15}}
+	PowerPolicy string `json:"power_policy"`
 }
 
 // DefaultConfig returns default server configuration
 func DefaultConfig() *Config {
-	return &Config{
+	config := &Config{
 		Port:              8080,
+		GRPCPort:          9090,
 		Host:              "localhost",
 		DefaultFormat:     "png",
 		Quality:           95,
 		IncludeCursor:     false,
 		LogLevel:          "info",
 		ChromeTimeout:     "30s",
+		FrameCacheMB:      envInt("SCREENSHOT_FRAME_CACHE_MB", 0),
+		CaptureHistory:    envInt("SCREENSHOT_CAPTURE_HISTORY", 20),
 		StreamMaxSessions: 10,
 		StreamDefaultFPS:  10,
+		StreamResumeGrace: "30s",
+		StreamMaxDuration: os.Getenv("SCREENSHOT_STREAM_MAX_DURATION"),
+		StreamIdleTimeout: os.Getenv("SCREENSHOT_STREAM_IDLE_TIMEOUT"),
+		StreamCPUBudget:   envInt("SCREENSHOT_STREAM_CPU_BUDGET", 0),
+		StreamCompression: envInt("SCREENSHOT_STREAM_COMPRESSION", int(ws.DefaultCompression)),
+		StreamSharedMemoryDir: filepath.Join(os.TempDir(), "screenshot-mcp-shm"),
+		WebcamDLL:         "softcam.dll",
+		CaptureConcurrency: envInt("SCREENSHOT_CAPTURE_CONCURRENCY", 4),
+		RecordingDir:      "recordings",
+		ElevatedHelper:    os.Getenv("SCREENSHOT_ELEVATED_HELPER") == "true",
+		ExcludedWindowPolicy: os.Getenv("SCREENSHOT_EXCLUDED_WINDOW_POLICY"),
+		PipeName:          configuredPipeName(),
+		MDNS:              os.Getenv("SCREENSHOT_MDNS") == "true",
+		MDNSName:          os.Getenv("SCREENSHOT_MDNS_NAME"),
+		MDNSAuth:          "none",
+		Pipeline:          os.Getenv("SCREENSHOT_PIPELINE"),
+		Watermark:         os.Getenv("SCREENSHOT_WATERMARK"),
+		WatermarkLogo:     os.Getenv("SCREENSHOT_WATERMARK_LOGO"),
+		ColorManagement:   os.Getenv("SCREENSHOT_COLOR_MANAGEMENT"),
+		AdminToken:        os.Getenv("SCREENSHOT_ADMIN_TOKEN"),
+		StreamKey:         os.Getenv("SCREENSHOT_STREAM_KEY"),
+		StreamSecurity:    os.Getenv("SCREENSHOT_STREAM_SECURITY"),
+		RelayToken:        os.Getenv("SCREENSHOT_RELAY_TOKEN"),
+		RelayURL:          os.Getenv("SCREENSHOT_RELAY_URL"),
+		AgentName:         os.Getenv("SCREENSHOT_AGENT_NAME"),
+		Targets:           os.Getenv("SCREENSHOT_TARGETS"),
+		CrashLog:          "crash.log",
+		SentryDSN:         os.Getenv("SCREENSHOT_SENTRY_DSN"),
+		LogFormat:         logging.FormatJSON,
+		LogFile:           os.Getenv("SCREENSHOT_LOG_FILE"),
+		LogMaxSize:        envInt("SCREENSHOT_LOG_MAX_SIZE", logging.DefaultMaxSizeMB),
+		LogMaxBackups:     envInt("SCREENSHOT_LOG_MAX_BACKUPS", logging.DefaultMaxBackups),
+		LogEventLog:       "auto",
+		DebugRPC:          os.Getenv("SCREENSHOT_DEBUG_RPC") == "true",
+		ConfigFile:        os.Getenv("SCREENSHOT_CONFIG"),
+		PluginDir:         "plugins",
+		WorkflowDir:       "workflows",
+		TargetAliasFile:   "target-aliases.json",
+		StatsFile:         "stats.json",
+		CaptureSetFile:    "capture-sets.json",
+		AllowInput:        os.Getenv("SCREENSHOT_ALLOW_INPUT") == "true",
+		ActivityGate:      os.Getenv("SCREENSHOT_ACTIVITY_GATE"),
+		IdleAfter:         "5m",
+		PowerPolicy:       os.Getenv("SCREENSHOT_POWER_POLICY"),
+	}
+	if level := os.Getenv("SCREENSHOT_LOG_LEVEL"); level != "" {
+		config.LogLevel = level
+	}
+	if format := os.Getenv("SCREENSHOT_LOG_FORMAT"); format != "" {
+		config.LogFormat = format
+	}
+	if eventLog := os.Getenv("SCREENSHOT_LOG_EVENTLOG"); eventLog != "" {
+		config.LogEventLog = eventLog
+	}
+	if crashLog, ok := os.LookupEnv("SCREENSHOT_CRASH_LOG"); ok {
+		config.CrashLog = crashLog
+	}
+	if pluginDir, ok := os.LookupEnv("SCREENSHOT_PLUGIN_DIR"); ok {
+		config.PluginDir = pluginDir
+	}
+	if workflowDir, ok := os.LookupEnv("SCREENSHOT_WORKFLOW_DIR"); ok {
+		config.WorkflowDir = workflowDir
+	}
+	if aliasFile, ok := os.LookupEnv("SCREENSHOT_TARGET_ALIAS_FILE"); ok {
+		config.TargetAliasFile = aliasFile
+	}
+	if statsFile, ok := os.LookupEnv("SCREENSHOT_STATS_FILE"); ok {
+		config.StatsFile = statsFile
+	}
+	if setFile, ok := os.LookupEnv("SCREENSHOT_CAPTURE_SET_FILE"); ok {
+		config.CaptureSetFile = setFile
+	}
+	if shmDir, ok := os.LookupEnv("SCREENSHOT_STREAM_SHM_DIR"); ok {
+		config.StreamSharedMemoryDir = shmDir
+	}
+	if webcamDLL, ok := os.LookupEnv("SCREENSHOT_WEBCAM_DLL"); ok {
+		config.WebcamDLL = webcamDLL
+	}
+	if idleAfter := os.Getenv("SCREENSHOT_IDLE_AFTER"); idleAfter != "" {
+		config.IdleAfter = idleAfter
+	}
+	if auth := os.Getenv("SCREENSHOT_MDNS_AUTH"); auth != "" {
+		config.MDNSAuth = auth
+	}
+	// Serve only the named pipe, without opening any TCP port
+	if os.Getenv("SCREENSHOT_PIPE_ONLY") == "true" {
+		config.Port = 0
+		config.GRPCPort = 0
+	}
+	// Serve only the relay connection, as a capture agent behind NAT
+	if config.RelayURL != "" && os.Getenv("SCREENSHOT_AGENT_ONLY") == "true" {
+		config.Port = 0
+		config.GRPCPort = 0
+	}
+	return config
+}
+
+// envInt returns the integer value of an environment variable, or fallback when it is
+// unset or not a number
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// newLogger builds the logger the configuration describes
+func newLogger(config *Config) (*logging.Logger, error) {
+	options := logging.Options{
+		Level:      config.LogLevel,
+		Format:     config.LogFormat,
+		File:       config.LogFile,
+		MaxSizeMB:  config.LogMaxSize,
+		MaxBackups: config.LogMaxBackups,
+	}
+	switch config.LogEventLog {
+	case "true":
+		options.EventLog = true
+	case "false":
+	case "auto", "":
+		options.EventLog = logging.RunningAsService()
+	default:
+		return nil, fmt.Errorf("invalid SCREENSHOT_LOG_EVENTLOG %q: must be true, false or auto", config.LogEventLog)
 	}
+	return logging.New(options)
 }
 
 // NewServer creates a new screenshot server
 func NewServer() (*Server, error) {
+	config := DefaultConfig()
+	if config.ConfigFile != "" {
+		if err := loadConfigFile(config, config.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize logger
-	logger, err := zap.NewProduction()
+	serverLogger, err := newLogger(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
+	logger := serverLogger.Logger
 
 	// Initialize screenshot engine
 	engine, err := screenshot.NewEngine()
@@ -83,6 +375,148 @@ func NewServer() (*Server, error) {
 	// Initialize stream manager
 	streamManager := ws.NewStreamManager(logger)
 
+	if grace, err := time.ParseDuration(config.StreamResumeGrace); err == nil {
+		streamManager.SetResumeGracePeriod(grace)
+	}
+	if err := validateCPUBudget(config.StreamCPUBudget); err != nil {
+		return nil, err
+	}
+	if config.FrameCacheMB < 0 {
+		return nil, fmt.Errorf("invalid frame_cache_mb %d: must not be negative", config.FrameCacheMB)
+	}
+	if config.CaptureHistory < 0 {
+		return nil, fmt.Errorf("invalid capture_history %d: must not be negative", config.CaptureHistory)
+	}
+	if config.CaptureConcurrency < 1 {
+		return nil, fmt.Errorf("invalid capture_concurrency %d: must be at least 1", config.CaptureConcurrency)
+	}
+	streamManager.SetCPUBudget(config.StreamCPUBudget)
+	maxDuration, idleTimeout, err := parseSessionLimits(config)
+	if err != nil {
+		return nil, err
+	}
+	streamManager.SetSessionLimits(config.StreamMaxSessions, maxDuration, idleTimeout)
+	compression, err := ws.ParseCompression(config.StreamCompression)
+	if err != nil {
+		return nil, err
+	}
+	streamManager.SetCompression(compression)
+	streamManager.SetSharedMemoryDir(config.StreamSharedMemoryDir)
+
+	excludedPolicy, err := types.ParseExcludedWindowPolicy(config.ExcludedWindowPolicy)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetExcludedWindowPolicy(excludedPolicy)
+
+	colorManagement, err := types.ParseColorManagement(config.ColorManagement)
+	if err != nil {
+		return nil, err
+	}
+	config.ColorManagement = string(colorManagement)
+
+	activityGate, idleAfter, err := parseActivityConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	powerPolicy, err := parsePowerPolicy(config.PowerPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plugins come first, so the pipeline can use their stages
+	plugins := plugin.NewManager(logger)
+	if err := plugins.Load(config.PluginDir, fmt.Sprintf("http://%s:%d", config.Host, config.Port)); err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+
+	pipeline, err := parsePipelineConfig(config.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var inputDriver input.Driver
+	if config.AllowInput {
+		if inputDriver, err = input.New(); err != nil {
+			return nil, fmt.Errorf("SCREENSHOT_ALLOW_INPUT: %w", err)
+		}
+		logger.Warn("Input is allowed; workflows can click and type on the desktop")
+	}
+	watermarkPipeline, err := parseWatermarkConfig(config.Watermark, config.WatermarkLogo)
+	if err != nil {
+		return nil, err
+	}
+	streamManager.SetWatermark(watermarkPipeline)
+
+	streamSecurity, err := types.ParseStreamSecurity(config.StreamSecurity)
+	if err != nil {
+		return nil, err
+	}
+	if config.StreamKey != "" {
+		frameKey, err := framecrypt.NewKey(config.StreamKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCREENSHOT_STREAM_KEY: %w", err)
+		}
+		streamManager.SetFrameKey(frameKey)
+	} else if streamSecurity != types.StreamSecurityNone {
+		return nil, fmt.Errorf("stream security %q requires SCREENSHOT_STREAM_KEY", streamSecurity)
+	}
+
+	targets, err := parseTargets(config.Targets)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := loadTargetAliases(config.TargetAliasFile)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := loadUsageCounters(config.StatsFile)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := loadCaptureSets(config.CaptureSetFile)
+	if err != nil {
+		return nil, err
+	}
+
+	crashes := crash.NewReporter(config.CrashLog, "1.0.0", logger)
+	if config.SentryDSN != "" {
+		hook, err := crash.SentryHook(config.SentryDSN, logger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCREENSHOT_SENTRY_DSN: %w", err)
+		}
+		crashes.AddHook(hook)
+	}
+	streamManager.SetCrashReporter(crashes)
+
+	var elevatedHelper *screenshot.ElevatedHelper
+	if config.ElevatedHelper {
+		elevatedHelper = screenshot.NewElevatedHelper()
+		engine.SetElevatedHelper(elevatedHelper)
+	}
+
+	// Captures share a bounded number of slots, interactive requests first
+	captureScheduler := scheduler.New(config.CaptureConcurrency)
+	scheduledEngine := scheduler.NewEngine(engine, captureScheduler)
+	scheduledEngine.SetObserver(usage.recordCapture)
+	streamManager.SetUsageRecorder(usage)
+
+	// Initialize window manager and recorder
+	windowManager := window.NewManager()
+	recorder := recording.NewRecorder(scheduledEngine, windowManager, config.RecordingDir, logger)
+	recorder.SetWatermark(watermarkPipeline)
+	recorder.SetCrashReporter(crashes)
+	streamManager.SetRecorder(recorder)
+
+	// User activity gates streams and recordings
+	activityMonitor := activity.NewMonitor(windowManager.GetLastInputTime, activityGate, idleAfter)
+	recorder.SetActivityMonitor(activityMonitor)
+	streamManager.SetActivityMonitor(activityMonitor)
+	streamManager.SetWindowManager(windowManager)
+
+	// Streams are capped on battery and in battery saver
+	powerMonitor := power.NewMonitor(powerPolicy)
+	streamManager.SetPowerMonitor(powerMonitor)
+
 	// Create WebSocket upgrader
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -91,15 +525,50 @@ func NewServer() (*Server, error) {
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 	}
+	compression.Configure(&upgrader)
 
 	// Create server instance
 	server := &Server{
-		engine:        engine,
+		engine:        scheduledEngine,
+		scheduler:     captureScheduler,
 		chromeManager: chromeManager,
 		streamManager: streamManager,
+		events:        ws.NewEventHub(scheduledEngine, logger),
+		mcpSessions:   newMCPSSEHub(),
+		windowManager: windowManager,
+		recorder:      recorder,
+		webcam:        webcam.NewCamera(config.WebcamDLL, logger),
+		outputs:       publish.NewPublisher(logger),
+		agents:        relay.NewHub(config.RelayToken, logger),
+		targets:       targets,
+		aliases:       aliases,
+		usage:         usage,
+		captureSets:   sets,
+		recentErrors:  newErrorLog(recentErrorLimit),
+		frames:        newFrameCache(int64(config.FrameCacheMB) << 20),
+		history:       newCaptureHistory(config.CaptureHistory),
+		jobs:          newJobRegistry(),
+		crashes:       crashes,
+		elevatedHelper: elevatedHelper,
+		watermarkPipeline: watermarkPipeline,
 		logger:        logger,
-		config:        DefaultConfig(),
+		logLevel:      serverLogger.Level,
+		logSinks:      serverLogger,
+		plugins:       plugins,
+		input:         inputDriver,
+		activity:      activityMonitor,
+		power:         powerMonitor,
 		upgrader:      upgrader,
+		compression:   compression,
+	}
+	server.config.Store(config)
+	server.pipeline.Store(pipeline)
+	server.events.SetWatermark(watermarkPipeline)
+	server.events.SetCrashReporter(crashes)
+	server.events.SetCompression(compression)
+	if config.DebugRPC {
+		server.rpcDebug = &rpcLog{}
+		logger.Warn("RPC debugging is enabled; MCP requests and responses are kept in memory")
 	}
 
 	// Setup HTTP router
@@ -116,32 +585,132 @@ func (s *Server) setupRouter() {
 	s.router = gin.New()
 	
 	// Middleware
-	s.router.Use(gin.Recovery())
+	s.router.Use(s.recoveryMiddleware())
 	s.router.Use(s.loggingMiddleware())
 	s.router.Use(s.corsMiddleware())
 
 	// Health check
 	s.router.GET("/health", s.healthCheck)
+	s.router.GET("/health/ready", s.readinessCheck)
+
+	// Resource counters for Prometheus
+	s.router.GET("/metrics", s.getMetrics)
+
+	// OpenAPI document of the REST API; the UI is docs/api
+	s.router.GET("/openapi.json", s.getOpenAPI)
 
 	// API v1 routes
 	v1 := s.router.Group("/v1")
 	{
 		// Screenshot endpoints
-		v1.POST("/screenshot", s.takeScreenshot)
-		v1.GET("/screenshot", s.takeScreenshotGET)
+		v1.POST("/screenshot", s.trackJob, s.takeScreenshot)
+		v1.GET("/screenshot", s.trackJob, s.takeScreenshotGET)
+		v1.POST("/screenshot/batch", s.trackJob, s.batchScreenshot)
+		v1.POST("/screenshot/scrolling", s.trackJob, s.scrollingScreenshot)
 		
 		// Window management
 		v1.GET("/windows", s.listWindows)
 		v1.GET("/windows/:handle", s.getWindow)
+		v1.GET("/windows/:handle/icon", s.getWindowIcon)
+		v1.GET("/processes/:pid/icon", s.getProcessIcon)
+		v1.GET("/tray", s.listTrayApps)
+		v1.GET("/shell/:surface", s.takeShellScreenshot)
 		
 		// Chrome integration
 		v1.GET("/chrome/instances", s.listChromeInstances)
 		v1.GET("/chrome/tabs", s.listChromeTabs)
 		v1.POST("/chrome/tabs/:id/screenshot", s.takeChromeTabScreenshot)
+		v1.GET("/chrome/tabs/:id/frames", s.listChromeTabFrames)
 		
 		// WebSocket streaming
 		v1.GET("/stream/:windowId", s.handleWebSocketStream)
 		v1.GET("/stream/status", s.getStreamStatus)
+		v1.GET("/stats", s.getUsageStats)
+		v1.GET("/events", s.events.HandleWebSocket)
+
+		// Recordings
+		v1.POST("/recordings", s.startRecording)
+		v1.GET("/recordings", s.listRecordings)
+		v1.GET("/recordings/:id", s.getRecording)
+		v1.POST("/recordings/:id/stop", s.stopRecording)
+		v1.GET("/recordings/:id/timeline", s.getRecordingTimeline)
+		v1.GET("/recordings/:id/frames/:frame", s.getRecordingFrame)
+		v1.GET("/recordings/:id/archive", s.getRecordingArchive)
+		v1.GET("/recordings/:id/output", s.getRecordingOutput)
+
+		// Administration of stream sessions
+		admin := v1.Group("/admin", s.adminAuth())
+		admin.GET("/sessions", s.listAdminSessions)
+		admin.GET("/sessions/:id", s.getAdminSession)
+		admin.DELETE("/sessions/:id", s.closeAdminSession)
+		admin.GET("/loglevel", s.getLogLevel)
+		admin.PUT("/loglevel", s.setLogLevel)
+
+		// Profiles of the running server, and capture stage totals for flame graphs
+		admin.GET("/debug/pprof", redirectPprofIndex)
+		admin.GET("/debug/pprof/", s.getPprofIndex)
+		admin.GET("/debug/pprof/:profile", s.getPprofProfile)
+		admin.GET("/debug/flame", s.getFlame)
+		admin.DELETE("/debug/flame", s.deleteFlame)
+
+		// MCP requests and responses, which carry window titles and screen contents
+		admin.GET("/debug/rpc", s.getRPCDebug)
+		admin.DELETE("/debug/rpc", s.clearRPCDebug)
+
+		// Recent capture errors and recovered panics with their stacks
+		admin.GET("/errors", s.getErrors)
+
+		// Capture agents connected through the relay
+		v1.GET("/agents/connect", s.agents.HandleConnect)
+		v1.GET("/agents", s.listAgents)
+		v1.GET("/agents/:id", s.getAgent)
+		v1.GET("/agents/:id/api/*path", s.proxyAgent)
+		v1.POST("/agents/:id/api/*path", s.proxyAgent)
+		v1.DELETE("/agents/:id/api/*path", s.proxyAgent)
+
+		// Servers and agents captures can be addressed to as "name:window"
+		v1.GET("/targets", s.getTargets)
+		v1.GET("/targets/:name", s.getTargetAlias)
+		v1.PUT("/targets/:name", s.putTargetAlias)
+		v1.DELETE("/targets/:name", s.deleteTargetAlias)
+
+		// Groups of targets captured at the same instant
+		v1.GET("/capture-sets", s.listCaptureSets)
+		v1.GET("/capture-sets/:name", s.getCaptureSet)
+		v1.PUT("/capture-sets/:name", s.putCaptureSet)
+		v1.DELETE("/capture-sets/:name", s.deleteCaptureSet)
+		v1.POST("/capture-sets/:name/capture", s.trackJob, s.postCaptureSetCapture)
+
+		// Virtual webcam showing a window to video-conferencing applications
+		v1.GET("/webcam", s.getWebcam)
+		v1.POST("/webcam", s.postWebcam)
+		v1.DELETE("/webcam", s.deleteWebcam)
+
+		// RTMP outputs publishing a window to broadcast tooling, through ffmpeg
+		v1.GET("/outputs", s.getOutputs)
+		v1.POST("/outputs", s.postOutput)
+		v1.GET("/outputs/:id", s.getOutput)
+		v1.DELETE("/outputs/:id", s.deleteOutput)
+
+		// Captures, OCR and recordings in flight, which can be cancelled
+		v1.GET("/jobs", s.getJobs)
+		v1.DELETE("/jobs/:id", s.deleteJob)
+
+		// Self-test and diagnostics bundle for bug reports
+		v1.GET("/diagnostics", s.getDiagnostics)
+		v1.GET("/capture/rankings", s.getMethodRankings)
+
+		// Plugins from the plugins directory, with their tools and pipeline stages
+		v1.GET("/plugins", s.getPlugins)
+
+		// Workflow scripts that prepare windows and capture them
+		v1.GET("/workflows", s.getWorkflows)
+		v1.POST("/workflows/:name", s.trackJob, s.postWorkflow)
+
+		// Macros of the user's input, recorded as workflows
+		v1.POST("/macros", s.postMacro)
+		v1.POST("/macros/:name/stop", s.postMacroStop)
+		v1.POST("/macros/:name/play", s.postMacroPlay)
 	}
 
 	// API routes (for compatibility)
@@ -149,7 +718,7 @@ func (s *Server) setupRouter() {
 	{
 		api.GET("/health", s.healthCheck)
 		api.GET("/windows", s.listWindows)
-		api.GET("/screenshot", s.takeScreenshotGET)
+		api.GET("/screenshot", s.trackJob, s.takeScreenshotGET)
 	}
 
 	// WebSocket streaming routes (top level for simplicity)
@@ -158,6 +727,10 @@ func (s *Server) setupRouter() {
 	// MCP JSON-RPC 2.0 endpoint
 	s.router.POST("/rpc", s.handleMCPRequest)
 
+	// MCP over Server-Sent Events
+	s.router.GET("/sse", s.handleMCPSSE)
+	s.router.POST("/messages", s.handleMCPMessage)
+
 	// Documentation
 	s.router.Static("/docs", "./docs")
 	s.router.GET("/", func(c *gin.Context) {
@@ -165,24 +738,60 @@ func (s *Server) setupRouter() {
 	})
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, unless its port is 0, and the other transports
 func (s *Server) Start() error {
-	s.httpServer = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.config.Host, s.config.Port),
-		Handler: s.router,
+	if s.config.Load().Port != 0 {
+		s.httpServer = &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", s.config.Load().Host, s.config.Load().Port),
+			Handler: s.router,
+		}
+
+		s.logger.Info("Starting screenshot MCP server",
+			zap.String("address", s.httpServer.Addr),
+			zap.String("version", "1.0.0"),
+		)
+
+		// Start server in a goroutine
+		go func() {
+			if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Fatal("Failed to start server", zap.Error(err))
+			}
+		}()
 	}
 
-	s.logger.Info("Starting screenshot MCP server",
-		zap.String("address", s.httpServer.Addr),
-		zap.String("version", "1.0.0"),
-	)
+	grpcServer, err := s.startGRPC()
+	if err != nil {
+		return err
+	}
 
-	// Start server in a goroutine
-	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Fatal("Failed to start server", zap.Error(err))
-		}
-	}()
+	pipeServer, err := s.startPipe()
+	if err != nil {
+		return err
+	}
+
+	advertiser := s.startMDNS()
+
+	relayAgent, err := s.startRelay()
+	if err != nil {
+		return err
+	}
+
+	// Save the usage counters as they change
+	stopUsage := make(chan struct{})
+	defer close(stopUsage)
+	go s.usage.run(stopUsage, s.logger)
+
+	// Close stream sessions past their duration and idle limits
+	stopReaper := make(chan struct{})
+	defer close(stopReaper)
+	go s.streamManager.ReapSessions(stopReaper)
+
+	// Apply edits of the config file while running
+	stopWatch := make(chan struct{})
+	defer close(stopWatch)
+	if path := s.config.Load().ConfigFile; path != "" {
+		go s.watchConfig(path, stopWatch)
+	}
 
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
@@ -191,6 +800,62 @@ func (s *Server) Start() error {
 
 	s.logger.Info("Shutting down server...")
 
+	// Withdraw the mDNS advertisement first, so clients stop discovering a server that's going away
+	if advertiser != nil {
+		advertiser.Close()
+	}
+
+	// Leave the central server, and disconnect the agents of this one
+	if relayAgent != nil {
+		relayAgent.Close()
+	}
+	s.agents.Close()
+
+	// Finalize active recordings so their manifests are written
+	s.recorder.Cleanup()
+
+	// Release the virtual webcam; ErrNotRunning just means it wasn't started
+	s.webcam.Stop()
+
+	// End RTMP streams so servers see them finish rather than time out
+	s.outputs.Close()
+
+	// Disconnect event subscribers and MCP SSE clients
+	s.events.Close()
+	s.mcpSessions.Close()
+
+	// Stop the elevated helper, if one was started
+	if s.elevatedHelper != nil {
+		s.elevatedHelper.Close()
+	}
+
+	// Let open gRPC calls and streams finish
+	if grpcServer != nil {
+		stopGRPC(grpcServer)
+	}
+
+	// Disconnect named pipe clients
+	if pipeServer != nil {
+		pipeServer.Close()
+	}
+
+	// Stop the plugins once no request can call them
+	defer s.plugins.Close()
+
+	// Save a macro left recording
+	s.saveMacro()
+
+	// Save the usage counters, streams and recordings included
+	if err := s.usage.save(); err != nil {
+		s.logger.Error("Failed to save usage stats", zap.Error(err))
+	}
+
+	if s.httpServer == nil {
+		s.logger.Info("Server exited")
+		s.logSinks.Close()
+		return nil
+	}
+
 	// Shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -201,6 +866,7 @@ func (s *Server) Start() error {
 	}
 
 	s.logger.Info("Server exited")
+	s.logSinks.Close()
 	return nil
 }
 
@@ -209,9 +875,12 @@ func (s *Server) Start() error {
 // healthCheck returns server health status
 func (s *Server) healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "healthy",
-		"timestamp": time.Now(),
-		"version":   "1.0.0",
+		"status":        "healthy",
+		"timestamp":     time.Now(),
+		"version":       "1.0.0",
+		"desktop_state": screenshot.QueryDesktopState(),
+		"activity":      s.activity.State(),
+		"power":         s.power.State(),
 	})
 }
 
@@ -229,10 +898,9 @@ func (s *Server) takeScreenshot(c *gin.Context) {
 // takeScreenshotGET handles GET screenshot requests
 func (s *Server) takeScreenshotGET(c *gin.Context) {
 	req := types.ScreenshotRequest{
-		Method:  c.DefaultQuery("method", "title"),
-		Target:  c.Query("target"),
-		Format:  types.ImageFormat(c.DefaultQuery("format", s.config.DefaultFormat)),
-		Quality: s.config.Quality,
+		Method: c.DefaultQuery("method", "title"),
+		Target: c.Query("target"),
+		Format: types.ImageFormat(c.Query("format")),
 	}
 
 	if qualityStr := c.Query("quality"); qualityStr != "" {
@@ -242,9 +910,61 @@ func (s *Server) takeScreenshotGET(c *gin.Context) {
 	}
 
 	req.IncludeCursor = c.Query("cursor") == "true"
+	req.Match = c.Query("match")
+	req.TopLevel = c.Query("top_level") == "true"
+	req.RejectBlackFrames = c.Query("reject_black_frames") == "true"
+	req.CaptureOtherDesktops = c.Query("capture_other_desktops") == "true"
+	req.CaptureGhost = c.Query("capture_ghost") == "true"
+	req.SyncToPresent = c.Query("sync_to_present") == "true"
+	req.IncludeOwnedWindows = c.Query("include_owned_windows") == "true"
+	req.RetryBackoff = c.Query("retry_backoff")
+	if retriesStr := c.Query("retry_count"); retriesStr != "" {
+		retries, err := strconv.Atoi(retriesStr)
+		if err != nil {
+			sendCaptureError(c, invalidRequest(fmt.Errorf("invalid retry_count: %s", retriesStr)))
+			return
+		}
+		req.RetryCount = &retries
+	}
+	if maxBytesStr := c.Query("max_response_bytes"); maxBytesStr != "" {
+		maxBytes, err := strconv.Atoi(maxBytesStr)
+		if err != nil {
+			sendCaptureError(c, invalidRequest(fmt.Errorf("invalid max_response_bytes: %s", maxBytesStr)))
+			return
+		}
+		req.MaxResponseBytes = maxBytes
+	}
+	if pipelineStr := c.Query("pipeline"); pipelineStr != "" {
+		if err := json.Unmarshal([]byte(pipelineStr), &req.Pipeline); err != nil {
+			sendCaptureError(c, invalidRequest(fmt.Errorf("invalid pipeline: %w", err)))
+			return
+		}
+	}
+	if stableStr := c.Query("wait_for_stable"); stableStr != "" {
+		condition, err := parseStabilityQuery(stableStr)
+		if err != nil {
+			sendCaptureError(c, invalidRequest(err))
+			return
+		}
+		req.WaitForStable = condition
+	}
+	req.RegionRelativeTo = types.RegionOrigin(c.Query("region_relative_to"))
+	if regionStr := c.Query("region"); regionStr != "" {
+		region, err := parseRegion(regionStr)
+		if err != nil {
+			sendCaptureError(c, invalidRequest(err))
+			return
+		}
+		req.Region = region
+	}
+	req.CaptureMethod = types.CaptureMethod(c.Query("capture_method"))
+	req.ColorManagement = types.ColorManagement(c.Query("color_management"))
+	for _, method := range splitList(c.Query("fallback_methods")) {
+		req.FallbackMethods = append(req.FallbackMethods, types.CaptureMethod(method))
+	}
 
-	if req.Target == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "target parameter is required"})
+	if req.Target == "" && methodRequiresTarget(req.Method) {
+		sendCaptureError(c, invalidRequest(fmt.Errorf("target parameter is required")))
 		return
 	}
 
@@ -253,6 +973,41 @@ func (s *Server) takeScreenshotGET(c *gin.Context) {
 
 // processScreenshotRequest processes a screenshot request
 func (s *Server) processScreenshotRequest(c *gin.Context, req *types.ScreenshotRequest) {
+	if req.IfNoneMatch == "" {
+		req.IfNoneMatch = c.GetHeader("If-None-Match")
+	}
+	response, err := s.screenshot(c.Request.Context(), req)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	if response.ETag != "" {
+		c.Header("ETag", response.ETag)
+	}
+	if response.Unchanged {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// screenshot performs a screenshot request, on this machine or, for requests addressed
+// to "machine:window", on a federated target
+func (s *Server) screenshot(ctx context.Context, req *types.ScreenshotRequest) (*types.ScreenshotResponse, error) {
+	if err := s.prepareScreenshotRequest(req); err != nil {
+		return nil, err
+	}
+	remote, err := s.resolveTarget(req)
+	if err != nil {
+		return nil, err
+	}
+	if remote != nil {
+		return remote.capture(ctx)
+	}
+
+	// The capture's stages are traced as spans
+	ctx, span := tracing.Start(ctx, "screenshot")
+	defer span.End()
 	startTime := time.Now()
 
 	options := &types.CaptureOptions{
@@ -263,49 +1018,67 @@ func (s *Server) processScreenshotRequest(c *gin.Context, req *types.ScreenshotR
 		RestoreWindow:    false,
 		WaitForVisible:   2 * time.Second,
 		RetryCount:       3,
+		RetryBackoff:     100 * time.Millisecond,
+		RetryMaxBackoff:  2 * time.Second,
+		DetectBlackFrames: true,
+		Priority:         scheduler.PriorityOf(ctx),
+		SlotHeld:         scheduler.SlotHeld(ctx),
+		Context:          ctx,
 		CustomProperties: make(map[string]string),
 	}
 
-	if req.Region != nil {
-		options.Region = req.Region
+	plan, err := applyScreenshotRequest(req, options)
+	if err != nil {
+		return nil, err
 	}
 
-	var buffer *types.ScreenshotBuffer
-	var err error
-
-	// Capture based on method
-	switch req.Method {
-	case "title":
-		buffer, err = s.engine.CaptureByTitle(req.Target, options)
-	case "pid":
-		if pid, parseErr := strconv.ParseUint(req.Target, 10, 32); parseErr == nil {
-			buffer, err = s.engine.CaptureByPID(uint32(pid), options)
-		} else {
-			err = fmt.Errorf("invalid PID: %s", req.Target)
-		}
-	case "handle":
-		if handle, parseErr := strconv.ParseUint(req.Target, 10, 64); parseErr == nil {
-			buffer, err = s.engine.CaptureByHandle(uintptr(handle), options)
-		} else {
-			err = fmt.Errorf("invalid handle: %s", req.Target)
-		}
-	case "class":
-		buffer, err = s.engine.CaptureByClassName(req.Target, options)
-	default:
-		err = fmt.Errorf("unsupported method: %s", req.Method)
+	_, captureSpan := tracing.Start(ctx, "capture")
+	buffer, err := s.captureWhenReady(req, plan, options)
+	captureSpan.End()
+	if err != nil {
+		s.logger.Error("Screenshot capture failed",
+			zap.String("method", req.Method),
+			zap.String("target", req.Target),
+			zap.String("code", string(types.ErrorCodeOf(err))),
+			zap.Error(err),
+		)
+		s.recordError(req, err)
+		return nil, err
+	}
+	captured := time.Now()
+	timing := types.NewCaptureTiming(startTime, captured)
+	_, processSpan := tracing.Start(ctx, "process")
+	buffer, colorProfile, err := s.manageColor(buffer, req.ColorManagement)
+	if err == nil {
+		buffer, err = s.postProcess(buffer, req.Pipeline)
 	}
+	processSpan.End()
+	if err != nil {
+		return nil, err
+	}
+	timing.Process = time.Since(captured)
 
+	popups, popupBuffers, err := s.capturePopups(req, buffer, options)
 	if err != nil {
-		s.logger.Error("Screenshot capture failed",
+		s.logger.Error("Popup capture failed",
 			zap.String("method", req.Method),
 			zap.String("target", req.Target),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
+	}
+
+	// A capture that looks the same as the one the client has, or as the last one of
+	// the same request, isn't encoded again
+	cacheKey, etag, unchanged := s.checkFrameCache(req, buffer, len(popupBuffers))
+	if unchanged != nil {
+		s.rememberCapture(req, unchanged)
+		return unchanged, nil
 	}
 
 	// Encode the image data as base64
+	encodeStart := time.Now()
+	_, encodeSpan := tracing.Start(ctx, "encode")
 	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
 
 	response := types.ScreenshotResponse{
@@ -317,372 +1090,875 @@ func (s *Server) processScreenshotRequest(c *gin.Context, req *types.ScreenshotR
 		Size:      int64(len(buffer.Data)),
 		Timestamp: buffer.Timestamp,
 		Metadata: types.Metadata{
-			CaptureMethod:  req.Method,
+			CaptureMethod:  captureMethodName(buffer, req.Method),
 			ProcessingTime: time.Since(startTime),
 			WindowVisible:  buffer.WindowInfo.IsVisible,
 			WindowMinimized: buffer.WindowInfo.State == "minimized",
+			WindowHung:     buffer.WindowInfo.State == "hung",
+			GhostWindow:    buffer.GhostWindow,
 			DPIScaling:     float64(buffer.DPI) / 96.0,
 			ColorDepth:     32,
 			Properties:     options.CustomProperties,
+			BlackFrameDetected: buffer.BlackFrameDetected,
+			Attempts:       buffer.Attempts,
+			Retries:        buffer.Retries,
+			OccludedPercent: buffer.WindowInfo.OccludedPercent,
+			OccludedBy:     buffer.WindowInfo.OccludedBy,
+			OwnedWindows:   buffer.OwnedWindows,
+			ColorProfile:   colorProfile,
+			Stability:      buffer.Stability,
+			Timing:         timing,
 		},
+		Popups: popups,
+	}
+
+	err = fitScreenshotBudget(&response, buffer, popupBuffers, req)
+	encodeSpan.End()
+	if err != nil {
+		return nil, err
 	}
+	timing.Encode = time.Since(encodeStart)
+	s.cacheFrame(cacheKey, etag, &response)
+	s.rememberCapture(req, &response)
 
 	s.logger.Info("Screenshot captured successfully",
 		zap.String("method", req.Method),
+		zap.String("capture_method", string(buffer.CaptureMethod)),
 		zap.String("target", req.Target),
 		zap.Int("width", buffer.Width),
 		zap.Int("height", buffer.Height),
 		zap.Duration("processing_time", response.Metadata.ProcessingTime),
 	)
 
-	c.JSON(http.StatusOK, response)
+	return &response, nil
 }
 
-// listWindows lists all available windows
-func (s *Server) listWindows(c *gin.Context) {
-	// For now return a placeholder - window enumeration can be implemented later
-	c.JSON(http.StatusOK, gin.H{
-		"windows": []interface{}{},
-		"message": "Window enumeration will be implemented in a future version",
-	})
+// captureTarget resolves a screenshot target by lookup method and captures it
+func (s *Server) captureTarget(req *types.ScreenshotRequest, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	target := req.Target
+	pickBest := req.Match == "best"
+
+	switch req.Method {
+	case "title":
+		return s.engine.CaptureByTitle(target, options)
+	case "title_contains":
+		return s.captureByTitleMatch(target, window.TitleMatchContains, pickBest, options)
+	case "title_regex":
+		return s.captureByTitleMatch(target, window.TitleMatchRegex, pickBest, options)
+	case "title_fuzzy":
+		return s.captureByTitleMatch(target, window.TitleMatchFuzzy, pickBest, options)
+	case "pid":
+		pid, err := strconv.ParseUint(target, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PID: %s", target)
+		}
+		return s.engine.CaptureByPID(uint32(pid), options)
+	case "process":
+		return s.engine.CaptureByProcessName(target, options)
+	case "handle":
+		handle, err := strconv.ParseUint(target, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid handle: %s", target)
+		}
+		return s.engine.CaptureByHandle(uintptr(handle), options)
+	case "class":
+		return s.engine.CaptureByClassName(target, options)
+	case "foreground":
+		handle, err := s.windowManager.GetForegroundWindow()
+		if err != nil {
+			return nil, err
+		}
+		return s.engine.CaptureByHandle(handle, options)
+	case "under_cursor":
+		pt, err := s.windowManager.GetCursorPosition()
+		if err != nil {
+			return nil, err
+		}
+		handle, err := s.windowManager.WindowFromPoint(pt, req.TopLevel)
+		if err != nil {
+			return nil, err
+		}
+		return s.engine.CaptureByHandle(handle, options)
+	case string(types.ShellTaskbar), string(types.ShellTrayOverflow), string(types.ShellNotifications):
+		return s.engine.CaptureShellSurface(types.ShellSurface(req.Method), options)
+	default:
+		return nil, fmt.Errorf("unsupported method: %s", req.Method)
+	}
 }
 
-// getWindow gets information about a specific window
-func (s *Server) getWindow(c *gin.Context) {
-	handle := c.Param("handle")
-	c.JSON(http.StatusOK, gin.H{
-		"handle":  handle,
-		"message": "Window details not yet implemented",
-	})
+// Wait-for defaults
+const (
+	defaultWaitTimeout  = 10 * time.Second
+	defaultWaitInterval = 250 * time.Millisecond
+)
+
+// waitPlan is a validated wait_for condition
+type waitPlan struct {
+	condition *types.WaitCondition
+	title     *regexp.Regexp
+	color     [3]int
+	timeout   time.Duration
+	interval  time.Duration
+	stable    *stabilityPlan // wait_for_stable, checked once the condition holds
 }
 
-// listChromeInstances lists all Chrome instances
-func (s *Server) listChromeInstances(c *gin.Context) {
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		s.logger.Error("Failed to discover Chrome instances", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// parseWaitCondition validates a wait_for block; a nil condition yields a nil plan
+func parseWaitCondition(condition *types.WaitCondition) (*waitPlan, error) {
+	if condition == nil {
+		return nil, nil
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"instances": instances,
-		"count":     len(instances),
-	})
-}
+	plan := &waitPlan{
+		condition: condition,
+		timeout:   defaultWaitTimeout,
+		interval:  defaultWaitInterval,
+	}
 
-// listChromeTabs lists tabs for all or specific Chrome instances
-func (s *Server) listChromeTabs(c *gin.Context) {
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		s.logger.Error("Failed to discover Chrome instances", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if condition.Timeout != "" {
+		timeout, err := time.ParseDuration(condition.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid wait_for.timeout: %s", condition.Timeout)
+		}
+		plan.timeout = timeout
+	}
+	if condition.Interval != "" {
+		interval, err := time.ParseDuration(condition.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid wait_for.interval: %s", condition.Interval)
+		}
+		plan.interval = interval
 	}
 
-	var allTabs []types.ChromeTab
-	for _, instance := range instances {
-		tabs, err := s.chromeManager.GetTabs(&instance)
+	if condition.TitleRegex != "" {
+		title, err := regexp.Compile(condition.TitleRegex)
 		if err != nil {
-			s.logger.Warn("Failed to get tabs for Chrome instance",
-				zap.Uint32("pid", instance.PID),
-				zap.Error(err),
-			)
-			continue
+			return nil, fmt.Errorf("invalid wait_for.title_regex: %w", err)
 		}
-		allTabs = append(allTabs, tabs...)
+		plan.title = title
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"tabs":  allTabs,
-		"count": len(allTabs),
-	})
-}
+	if condition.Pixel != nil {
+		color := strings.TrimPrefix(condition.Pixel.Color, "#")
+		value, err := strconv.ParseUint(color, 16, 32)
+		if err != nil || len(color) != 6 {
+			return nil, fmt.Errorf("invalid wait_for.pixel.color %q (expected #RRGGBB)", condition.Pixel.Color)
+		}
+		plan.color = [3]int{int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)}
+	}
 
-// takeChromeTabScreenshot takes a screenshot of a specific Chrome tab
-func (s *Server) takeChromeTabScreenshot(c *gin.Context) {
-	tabID := c.Param("id")
+	if condition.StableFrames < 0 {
+		return nil, fmt.Errorf("wait_for.stable_frames cannot be negative")
+	}
 
-	// Find the tab
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	return plan, nil
+}
+
+// captureWhenReady captures the target once every wait_for condition holds and, with
+// wait_for_stable, once it has stopped changing
+func (s *Server) captureWhenReady(req *types.ScreenshotRequest, plan *waitPlan, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if plan == nil {
+		return s.captureTarget(req, options)
 	}
 
-	var targetTab *types.ChromeTab
-	for _, instance := range instances {
-		tabs, err := s.chromeManager.GetTabs(&instance)
-		if err != nil {
-			continue
+	var buffer *types.ScreenshotBuffer
+	var err error
+	if plan.condition == nil {
+		buffer, err = s.captureTarget(req, options)
+	} else {
+		buffer, err = s.awaitConditions(req, plan, options)
+	}
+	if err != nil || plan.stable == nil {
+		return buffer, err
+	}
+	return s.waitForStable(buffer, plan.stable, options)
+}
+
+// awaitConditions captures the target once its wait_for condition holds. The target
+// window is waited for first; title and UI Automation conditions are then polled on
+// its handle, and pixel and stability conditions on successive captures.
+func (s *Server) awaitConditions(req *types.ScreenshotRequest, plan *waitPlan, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	deadline := time.Now().Add(plan.timeout)
+	pause := func(what string, lastErr error) error {
+		if time.Now().Add(plan.interval).After(deadline) {
+			return types.NewCaptureError(types.ErrTimeout, fmt.Sprintf("timed out after %s waiting for %s", plan.timeout, what), lastErr)
 		}
-		
-		for _, tab := range tabs {
-			if tab.ID == tabID {
-				targetTab = &tab
+		return types.Sleep(options.Context, plan.interval)
+	}
+
+	// The first successful capture also resolves the window handle
+	var buffer *types.ScreenshotBuffer
+	for {
+		var err error
+		if buffer, err = s.captureTarget(req, options); err == nil {
+			break
+		}
+		if err := pause("the target window", err); err != nil {
+			return nil, err
+		}
+	}
+	handle := buffer.WindowInfo.Handle
+	current := true // buffer still reflects the window's state
+
+	if plan.title != nil {
+		for {
+			title, err := s.windowManager.GetWindowTitle(handle)
+			if err != nil {
+				return nil, err
+			}
+			if plan.title.MatchString(title) {
 				break
 			}
+			current = false
+			if err := pause(fmt.Sprintf("a title matching %q (last %q)", plan.condition.TitleRegex, title), nil); err != nil {
+				return nil, err
+			}
 		}
-		if targetTab != nil {
-			break
+	}
+
+	if element := plan.condition.Element; element != "" {
+		for {
+			visible, err := s.windowManager.IsElementVisible(handle, element)
+			if err != nil {
+				return nil, err
+			}
+			if visible {
+				break
+			}
+			current = false
+			if err := pause(fmt.Sprintf("element %q", element), nil); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	if targetTab == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tab not found"})
-		return
+	var previous uint64
+	stable := 0
+	for {
+		if !current {
+			var err error
+			if buffer, err = s.engine.CaptureByHandle(handle, options); err != nil {
+				return nil, err
+			}
+		}
+		current = false
+
+		pixelMatches := plan.condition.Pixel == nil || plan.pixelMatches(buffer)
+
+		frameStable := true
+		if plan.condition.StableFrames > 1 {
+			hash := fnv.New64a()
+			hash.Write(buffer.Data)
+			if sum := hash.Sum64(); stable > 0 && sum == previous {
+				stable++
+			} else {
+				previous = sum
+				stable = 1
+			}
+			frameStable = stable >= plan.condition.StableFrames
+		}
+
+		if pixelMatches && frameStable {
+			return buffer, nil
+		}
+
+		what := "a matching pixel"
+		if pixelMatches {
+			what = fmt.Sprintf("%d stable frames", plan.condition.StableFrames)
+		}
+		if err := pause(what, nil); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	// Capture screenshot
-	options := types.DefaultCaptureOptions()
-	buffer, err := s.chromeManager.CaptureTab(targetTab, options)
-	if err != nil {
-		s.logger.Error("Failed to capture Chrome tab screenshot",
-			zap.String("tab_id", tabID),
-			zap.Error(err),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// pixelMatches checks the wait_for pixel against a BGRA capture
+func (p *waitPlan) pixelMatches(buffer *types.ScreenshotBuffer) bool {
+	pixel := p.condition.Pixel
+	if pixel.X < 0 || pixel.Y < 0 || pixel.X >= buffer.Width || pixel.Y >= buffer.Height {
+		return false
 	}
 
-	// Encode as base64
-	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+	offset := pixel.Y*stride + pixel.X*4
+	if offset+2 >= len(buffer.Data) {
+		return false
+	}
 
-	response := types.ScreenshotResponse{
-		Success:   true,
-		Data:      imageData,
-		Format:    buffer.Format,
-		Width:     buffer.Width,
-		Height:    buffer.Height,
-		Size:      int64(len(buffer.Data)),
-		Timestamp: buffer.Timestamp,
-		Metadata: types.Metadata{
-			CaptureMethod: "chrome_tab",
-			Properties: map[string]string{
-				"tab_id":    tabID,
-				"tab_title": targetTab.Title,
-				"tab_url":   targetTab.URL,
-			},
-		},
+	actual := [3]int{int(buffer.Data[offset+2]), int(buffer.Data[offset+1]), int(buffer.Data[offset])}
+	for i := range actual {
+		diff := actual[i] - p.color[i]
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > pixel.Tolerance {
+			return false
+		}
 	}
+	return true
+}
 
-	c.JSON(http.StatusOK, response)
+// Popup capture limits
+const (
+	defaultPopupTimeout = 5 * time.Second
+	maxPopupTimeout     = 60 * time.Second
+)
+
+// popupTimeout validates a popups block and returns how long to wait for popups
+func popupTimeout(popups *types.PopupCapture) (time.Duration, error) {
+	if popups == nil {
+		return 0, nil
+	}
+	if popups.MaxPopups < 0 {
+		return 0, fmt.Errorf("popups.max_popups cannot be negative")
+	}
+	if popups.Within == "" {
+		return defaultPopupTimeout, nil
+	}
+	within, err := time.ParseDuration(popups.Within)
+	if err != nil || within <= 0 || within > maxPopupTimeout {
+		return 0, fmt.Errorf("invalid popups.within %q (must be between 0 and %s)", popups.Within, maxPopupTimeout)
+	}
+	return within, nil
 }
 
-// handleMCPRequest handles MCP JSON-RPC 2.0 requests
-func (s *Server) handleMCPRequest(c *gin.Context) {
-	var req types.MCPRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		s.sendMCPError(c, nil, -32700, "Parse error", nil)
-		return
+// capturePopups captures the popups a request armed for in the captured window's
+// process, returning them for the response along with their captures; it returns nil
+// when the request didn't ask for popups
+func (s *Server) capturePopups(req *types.ScreenshotRequest, buffer *types.ScreenshotBuffer, options *types.CaptureOptions) ([]types.PopupImage, []*types.ScreenshotBuffer, error) {
+	if req.Popups == nil {
+		return nil, nil, nil
+	}
+	buffers, err := s.capturePopupBuffers(req, buffer, options)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	s.logger.Debug("Received MCP request",
-		zap.String("method", req.Method),
-		zap.Any("id", req.ID),
-	)
+	popups := make([]types.PopupImage, 0, len(buffers))
+	for _, popup := range buffers {
+		popups = append(popups, types.PopupImage{
+			Window:    popup.WindowInfo,
+			Data:      base64.StdEncoding.EncodeToString(popup.Data),
+			Format:    popup.Format,
+			Width:     popup.Width,
+			Height:    popup.Height,
+			Timestamp: popup.Timestamp,
+		})
+	}
+	return popups, buffers, nil
+}
 
-	switch req.Method {
-	case "screenshot.capture":
-		s.handleMCPScreenshot(c, &req)
-	case "window.list":
-		s.handleMCPWindowList(c, &req)
-	case "chrome.instances":
-		s.handleMCPChromeInstances(c, &req)
-	case "chrome.tabs":
-		s.handleMCPChromeTabs(c, &req)
-	case "chrome.tabCapture":
-		s.handleMCPChromeTabCapture(c, &req)
-	case "stream.status":
-		s.handleMCPStreamStatus(c, &req)
-	default:
-		s.sendMCPError(c, req.ID, -32601, "Method not found", nil)
+// capturePopupBuffers waits for the popups a request armed for and returns their
+// unencoded captures
+func (s *Server) capturePopupBuffers(req *types.ScreenshotRequest, buffer *types.ScreenshotBuffer, options *types.CaptureOptions) ([]*types.ScreenshotBuffer, error) {
+	if req.Popups == nil {
+		return nil, nil
+	}
+	within, err := popupTimeout(req.Popups)
+	if err != nil {
+		return nil, invalidRequest(err)
 	}
+
+	// The region applies to the main window, and restoring would dismiss the popup
+	popupOptions := *options
+	popupOptions.Region = nil
+	popupOptions.RestoreWindow = false
+
+	return s.engine.CapturePopups(buffer.WindowInfo.ProcessID, req.Popups.Classes, within, req.Popups.MaxPopups, &popupOptions)
 }
 
-// handleMCPScreenshot handles MCP screenshot requests
-func (s *Server) handleMCPScreenshot(c *gin.Context, req *types.MCPRequest) {
-	// Parse parameters
-	params, ok := req.Params.(map[string]interface{})
-	if !ok {
-		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
-		return
+// methodRequiresTarget reports whether a lookup method needs a target value
+func methodRequiresTarget(method string) bool {
+	return method != "foreground" && method != "under_cursor" && !types.IsShellSurface(method)
+}
+
+// captureByTitleMatch enumerates top-level windows and captures the one whose title matches pattern
+func (s *Server) captureByTitleMatch(pattern string, mode window.TitleMatchMode, pickBest bool, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	windows, err := s.windowManager.EnumerateWindows(&types.WindowFilter{ExcludeSystem: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate windows: %w", err)
 	}
 
-	// Build screenshot request
-	screenshotReq := types.ScreenshotRequest{
-		Method:        getString(params, "method", "title"),
-		Target:        getString(params, "target", ""),
-		Format:        types.ImageFormat(getString(params, "format", s.config.DefaultFormat)),
-		Quality:       getInt(params, "quality", s.config.Quality),
-		IncludeCursor: getBool(params, "include_cursor", s.config.IncludeCursor),
+	target, err := window.SelectWindowByTitle(windows, pattern, mode, pickBest)
+	if err != nil {
+		return nil, err
 	}
 
-	if screenshotReq.Target == "" {
-		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: target", nil)
-		return
+	return s.engine.CaptureByHandle(target.Handle, options)
+}
+
+// applyScreenshotRequest validates a request's capture settings, applies them to
+// options and returns its wait plan. Validation failures are tagged ErrInvalidRequest
+// or ErrUnsupportedFormat.
+func applyScreenshotRequest(req *types.ScreenshotRequest, options *types.CaptureOptions) (*waitPlan, error) {
+	if err := validateImageFormat(req.Format); err != nil {
+		return nil, err
 	}
 
-	// Process the request (reuse existing logic)
-	options := &types.CaptureOptions{
-		IncludeCursor:    screenshotReq.IncludeCursor,
-		IncludeFrame:     getBool(params, "include_frame", true),
-		ScaleFactor:      getFloat64(params, "scale_factor", 1.0),
-		AllowMinimized:   getBool(params, "allow_minimized", true),
-		RestoreWindow:    getBool(params, "restore_window", false),
-		WaitForVisible:   2 * time.Second,
-		RetryCount:       3,
-		CustomProperties: make(map[string]string),
+	options.Region = req.Region
+	origin, err := types.ParseRegionOrigin(string(req.RegionRelativeTo))
+	if err != nil {
+		return nil, invalidRequest(err)
 	}
+	options.RegionRelativeTo = origin
 
-	var buffer *types.ScreenshotBuffer
-	var err error
+	if err := applyCaptureMethods(options, req.CaptureMethod, req.FallbackMethods); err != nil {
+		return nil, invalidRequest(err)
+	}
+	if err := applyRetryPolicy(options, req.RetryCount, req.RetryBackoff); err != nil {
+		return nil, invalidRequest(err)
+	}
+	options.RejectBlackFrames = req.RejectBlackFrames
+	options.CaptureOtherDesktops = req.CaptureOtherDesktops
+	options.CaptureGhost = req.CaptureGhost
+	options.SyncToPresent = req.SyncToPresent
+	options.IncludeOwnedWindows = req.IncludeOwnedWindows
 
-	switch screenshotReq.Method {
-	case "title":
-		buffer, err = s.engine.CaptureByTitle(screenshotReq.Target, options)
-	case "pid":
-		if pid, parseErr := strconv.ParseUint(screenshotReq.Target, 10, 32); parseErr == nil {
-			buffer, err = s.engine.CaptureByPID(uint32(pid), options)
-		} else {
-			err = fmt.Errorf("invalid PID: %s", screenshotReq.Target)
-		}
-	case "handle":
-		if handle, parseErr := strconv.ParseUint(screenshotReq.Target, 10, 64); parseErr == nil {
-			buffer, err = s.engine.CaptureByHandle(uintptr(handle), options)
-		} else {
-			err = fmt.Errorf("invalid handle: %s", screenshotReq.Target)
+	if err := validateResponseBudget(req.MaxResponseBytes); err != nil {
+		return nil, err
+	}
+	if err := validatePipeline(req.Pipeline); err != nil {
+		return nil, err
+	}
+	if req.ColorManagement != "" {
+		if _, err := types.ParseColorManagement(string(req.ColorManagement)); err != nil {
+			return nil, invalidRequest(err)
 		}
-	case "class":
-		buffer, err = s.engine.CaptureByClassName(screenshotReq.Target, options)
-	default:
-		err = fmt.Errorf("unsupported method: %s", screenshotReq.Method)
 	}
 
-	if err != nil {
-		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
-		return
+	if _, err := popupTimeout(req.Popups); err != nil {
+		return nil, invalidRequest(err)
 	}
 
-	// Encode and send response
-	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
-	result := types.ScreenshotResponse{
-		Success:   true,
-		Data:      imageData,
-		Format:    buffer.Format,
-		Width:     buffer.Width,
-		Height:    buffer.Height,
-		Size:      int64(len(buffer.Data)),
-		Timestamp: buffer.Timestamp,
+	plan, err := parseWaitCondition(req.WaitFor)
+	if err != nil {
+		return nil, invalidRequest(err)
 	}
-
-	s.sendMCPResult(c, req.ID, result)
+	stable, err := parseStabilityCondition(req.WaitForStable)
+	if err != nil {
+		return nil, invalidRequest(err)
+	}
+	if stable != nil {
+		if plan == nil {
+			plan = &waitPlan{}
+		}
+		plan.stable = stable
+	}
+	return plan, nil
 }
 
-// handleMCPWindowList handles MCP window list requests
-func (s *Server) handleMCPWindowList(c *gin.Context, req *types.MCPRequest) {
-	// Placeholder implementation
-	result := map[string]interface{}{
-		"windows": []interface{}{},
-		"message": "Window enumeration not yet implemented",
+// nativeEngine returns the engine under the capture scheduler, for its methods beyond
+// types.ScreenshotEngine
+func (s *Server) nativeEngine() types.ScreenshotEngine {
+	if scheduled, ok := s.engine.(*scheduler.Engine); ok {
+		return scheduled.Unwrap()
 	}
-	s.sendMCPResult(c, req.ID, result)
+	return s.engine
 }
 
-// handleMCPChromeInstances handles MCP Chrome instances requests
-func (s *Server) handleMCPChromeInstances(c *gin.Context, req *types.MCPRequest) {
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
-		return
+// validateImageFormat rejects output formats the encoder can't produce; empty selects the default
+func validateImageFormat(format types.ImageFormat) error {
+	switch format {
+	case "", types.FormatPNG, types.FormatJPEG, types.FormatBMP:
+		return nil
 	}
+	return types.NewCaptureError(types.ErrUnsupportedFormat, fmt.Sprintf("unsupported format %q (valid: png, jpeg, bmp)", format), nil)
+}
 
-	result := map[string]interface{}{
-		"instances": instances,
-		"count":     len(instances),
+// validateCPUBudget checks a stream CPU budget, in percent of a core
+func validateCPUBudget(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid stream_cpu_budget %d: must be 0-100", percent)
 	}
-	s.sendMCPResult(c, req.ID, result)
+	return nil
 }
 
-// handleMCPChromeTabs handles MCP Chrome tabs requests
-func (s *Server) handleMCPChromeTabs(c *gin.Context, req *types.MCPRequest) {
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
-		return
+// parseSessionLimits checks the stream session limits of a config and parses its durations
+func parseSessionLimits(config *Config) (maxDuration, idleTimeout time.Duration, err error) {
+	if config.StreamMaxSessions < 0 {
+		return 0, 0, fmt.Errorf("invalid stream_max_sessions %d: must not be negative", config.StreamMaxSessions)
 	}
-
-	var allTabs []types.ChromeTab
-	for _, instance := range instances {
-		tabs, err := s.chromeManager.GetTabs(&instance)
-		if err != nil {
-			continue
+	if config.StreamMaxDuration != "" {
+		if maxDuration, err = time.ParseDuration(config.StreamMaxDuration); err != nil || maxDuration < 0 {
+			return 0, 0, fmt.Errorf("invalid stream_max_duration %q: must be a duration such as \"2h\"", config.StreamMaxDuration)
 		}
-		allTabs = append(allTabs, tabs...)
 	}
-
-	result := map[string]interface{}{
-		"tabs":  allTabs,
-		"count": len(allTabs),
+	if config.StreamIdleTimeout != "" {
+		if idleTimeout, err = time.ParseDuration(config.StreamIdleTimeout); err != nil || idleTimeout < 0 {
+			return 0, 0, fmt.Errorf("invalid stream_idle_timeout %q: must be a duration such as \"5m\"", config.StreamIdleTimeout)
+		}
 	}
-	s.sendMCPResult(c, req.ID, result)
+	return maxDuration, idleTimeout, nil
 }
 
-// handleMCPChromeTabCapture handles MCP Chrome tab capture requests
-func (s *Server) handleMCPChromeTabCapture(c *gin.Context, req *types.MCPRequest) {
-	params, ok := req.Params.(map[string]interface{})
-	if !ok {
-		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
-		return
+// invalidRequest tags a validation error with ErrInvalidRequest
+func invalidRequest(err error) error {
+	return types.NewCaptureError(types.ErrInvalidRequest, "invalid request", err)
+}
+
+// captureErrorStatus maps an error code to an HTTP status
+func captureErrorStatus(code types.ErrorCode) int {
+	switch code {
+	case types.ErrInvalidRequest, types.ErrUnsupportedFormat:
+		return http.StatusBadRequest
+	case types.ErrAccessDenied, types.ErrElevationRequired, types.ErrCaptureExcluded:
+		return http.StatusForbidden
+	case types.ErrWindowNotFound:
+		return http.StatusNotFound
+	case types.ErrAmbiguousWindow, types.ErrOtherDesktop:
+		return http.StatusConflict
+	case types.ErrBlackFrame, types.ErrResponseTooLarge:
+		return http.StatusUnprocessableEntity
+	case types.ErrDWMUnavailable, types.ErrDesktopUnavailable:
+		return http.StatusServiceUnavailable
+	case types.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case types.ErrTargetUnreachable:
+		return http.StatusBadGateway
+	case types.ErrCancelled:
+		return statusCancelled
+	default:
+		return http.StatusInternalServerError
 	}
+}
 
-	tabID := getString(params, "tab_id", "")
-	if tabID == "" {
-		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: tab_id", nil)
-		return
+// captureErrorBody builds the error body for a failed capture: the message, its
+// code and, for title matching failures, the candidate windows or, when the desktop is
+// unavailable, its state
+func captureErrorBody(err error) gin.H {
+	body := gin.H{
+		"error": err.Error(),
+		"code":  types.ErrorCodeOf(err),
+	}
+	var matchErr *window.TitleMatchError
+	if errors.As(err, &matchErr) {
+		body["candidates"] = matchErr.Candidates
+	}
+	var unavailable *types.DesktopUnavailableError
+	if errors.As(err, &unavailable) {
+		body["desktop_state"] = unavailable.State
+	}
+	// Details of a capture a federated target failed
+	var remoteErr *client.Error
+	if errors.As(err, &remoteErr) {
+		if len(remoteErr.Candidates) > 0 {
+			body["candidates"] = remoteErr.Candidates
+		}
+		if remoteErr.DesktopState != "" {
+			body["desktop_state"] = remoteErr.DesktopState
+		}
 	}
+	return body
+}
 
-	// Find the tab (reuse existing logic)
-	instances, err := s.chromeManager.DiscoverInstances()
-	if err != nil {
-		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
-		return
+// sendCaptureError writes a failed capture as an HTTP error response
+func sendCaptureError(c *gin.Context, err error) {
+	c.JSON(captureErrorStatus(types.ErrorCodeOf(err)), captureErrorBody(err))
+}
+
+// sendMCPCaptureError writes a failed capture as an MCP error with the code in error.data
+func (s *Server) sendMCPCaptureError(c *gin.Context, id interface{}, err error) {
+	switch types.ErrorCodeOf(err) {
+	case types.ErrInvalidRequest, types.ErrUnsupportedFormat, types.ErrAmbiguousWindow:
+		s.sendMCPError(c, id, -32602, "Invalid params", captureErrorBody(err))
+	case types.ErrCancelled:
+		s.sendMCPError(c, id, mcpRequestCancelled, "Request cancelled", captureErrorBody(err))
+	default:
+		s.sendMCPError(c, id, -32603, "Internal error", captureErrorBody(err))
 	}
+}
 
-	var targetTab *types.ChromeTab
-	for _, instance := range instances {
-		tabs, err := s.chromeManager.GetTabs(&instance)
+// applyCaptureMethods validates client-selected capture methods and applies them to options
+func applyCaptureMethods(options *types.CaptureOptions, preferred types.CaptureMethod, fallbacks []types.CaptureMethod) error {
+	options.PreferredMethod = types.CaptureAuto
+	if preferred != "" {
+		method, err := types.ParseCaptureMethod(string(preferred))
 		if err != nil {
-			continue
+			return fmt.Errorf("invalid capture_method: %w", err)
 		}
-		
-		for _, tab := range tabs {
-			if tab.ID == tabID {
-				targetTab = &tab
-				break
-			}
+		options.PreferredMethod = method
+	}
+
+	options.FallbackMethods = nil
+	for _, fallback := range fallbacks {
+		method, err := types.ParseCaptureMethod(string(fallback))
+		if err != nil {
+			return fmt.Errorf("invalid fallback_methods: %w", err)
 		}
-		if targetTab != nil {
-			break
+		if method == types.CaptureAuto {
+			return fmt.Errorf("invalid fallback_methods: auto cannot be used as a fallback")
 		}
+		options.FallbackMethods = append(options.FallbackMethods, method)
 	}
 
-	if targetTab == nil {
-		s.sendMCPError(c, req.ID, -32603, "Tab not found", nil)
-		return
+	if len(options.FallbackMethods) > 0 && options.PreferredMethod == types.CaptureAuto {
+		return fmt.Errorf("fallback_methods requires capture_method")
 	}
 
-	// Capture screenshot
-	options := types.DefaultCaptureOptions()
-	buffer, err := s.chromeManager.CaptureTab(targetTab, options)
-	if err != nil {
-		s.sendMCPError(c, req.ID, -32603, "Screenshot failed", err.Error())
+	return nil
+}
+
+// Retry policy limits
+const (
+	maxRetryCount   = 10
+	maxRetryBackoff = 5 * time.Second
+)
+
+// applyRetryPolicy validates client retry settings and applies them to options
+func applyRetryPolicy(options *types.CaptureOptions, retryCount *int, retryBackoff string) error {
+	if retryCount != nil {
+		if *retryCount < 0 || *retryCount > maxRetryCount {
+			return fmt.Errorf("retry_count must be between 0 and %d", maxRetryCount)
+		}
+		options.RetryCount = *retryCount
+	}
+
+	if retryBackoff != "" {
+		backoff, err := time.ParseDuration(retryBackoff)
+		if err != nil || backoff < 0 || backoff > maxRetryBackoff {
+			return fmt.Errorf("retry_backoff must be a duration between 0 and %s", maxRetryBackoff)
+		}
+		options.RetryBackoff = backoff
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// captureMethodName reports the engine method that produced a buffer, falling back
+// to the request's lookup method when the engine didn't record one
+func captureMethodName(buffer *types.ScreenshotBuffer, fallback string) string {
+	if buffer.CaptureMethod != "" {
+		return string(buffer.CaptureMethod)
+	}
+	return fallback
+}
+
+// listWindows lists all available windows
+func (s *Server) listWindows(c *gin.Context) {
+	filter := &types.WindowFilter{
+		TitleContains:  c.Query("title_contains"),
+		VisibleOnly:    c.Query("visible_only") == "true",
+		ExcludeSystem:  c.Query("exclude_system") == "true",
+		VirtualDesktop: c.Query("virtual_desktop"),
+	}
+
+	windows, err := s.windowManager.EnumerateWindows(filter)
+	if err != nil {
+		s.logger.Error("Failed to enumerate windows", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Encode and send response
+	c.JSON(http.StatusOK, gin.H{
+		"windows": windows,
+		"count":   len(windows),
+	})
+}
+
+// listTrayApps lists the windows of processes with notification area icons
+func (s *Server) listTrayApps(c *gin.Context) {
+	windows, err := s.engine.FindSystemTrayApps()
+	if err != nil {
+		s.logger.Error("Failed to find tray applications", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"windows": windows,
+		"count":   len(windows),
+	})
+}
+
+// takeShellScreenshot captures the taskbar, tray overflow flyout or latest toast notification
+func (s *Server) takeShellScreenshot(c *gin.Context) {
+	surface := c.Param("surface")
+	if !types.IsShellSurface(surface) {
+		sendCaptureError(c, invalidRequest(fmt.Errorf("unknown shell surface %q (valid: taskbar, tray_overflow, notifications)", surface)))
+		return
+	}
+
+	req := types.ScreenshotRequest{
+		Method:        surface,
+		Format:        types.ImageFormat(c.DefaultQuery("format", s.config.Load().DefaultFormat)),
+		Quality:       s.config.Load().Quality,
+		IncludeCursor: c.Query("cursor") == "true",
+	}
+	s.processScreenshotRequest(c, &req)
+}
+
+// getWindow gets information about a specific window
+func (s *Server) getWindow(c *gin.Context) {
+	handle := c.Param("handle")
+	c.JSON(http.StatusOK, gin.H{
+		"handle":  handle,
+		"message": "Window details not yet implemented",
+	})
+}
+
+// getWindowIcon returns a window's icon as a PNG
+func (s *Server) getWindowIcon(c *gin.Context) {
+	handle, err := strconv.ParseUint(c.Param("handle"), 10, 64)
+	if err != nil {
+		sendCaptureError(c, invalidRequest(fmt.Errorf("invalid handle: %s", c.Param("handle"))))
+		return
+	}
+	large, err := iconSizeLarge(c.DefaultQuery("size", "large"))
+	if err != nil {
+		sendCaptureError(c, invalidRequest(err))
+		return
+	}
+
+	buffer, err := s.engine.GetWindowIcon(uintptr(handle), large)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	sendIcon(c, buffer)
+}
+
+// getProcessIcon returns the icon of a process's executable as a PNG
+func (s *Server) getProcessIcon(c *gin.Context) {
+	pid, err := strconv.ParseUint(c.Param("pid"), 10, 32)
+	if err != nil {
+		sendCaptureError(c, invalidRequest(fmt.Errorf("invalid PID: %s", c.Param("pid"))))
+		return
+	}
+	large, err := iconSizeLarge(c.DefaultQuery("size", "large"))
+	if err != nil {
+		sendCaptureError(c, invalidRequest(err))
+		return
+	}
+
+	buffer, err := s.engine.GetProcessIcon(uint32(pid), large)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	sendIcon(c, buffer)
+}
+
+// iconSizeLarge parses the icon size query parameter: "large" (32px) or "small" (16px)
+func iconSizeLarge(size string) (bool, error) {
+	switch size {
+	case "large":
+		return true, nil
+	case "small":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid icon size %q (valid: small, large)", size)
+}
+
+// sendIcon writes an icon buffer as a PNG response
+func sendIcon(c *gin.Context, buffer *types.ScreenshotBuffer) {
+	data, err := screenshot.NewImageProcessor().Encode(buffer, types.FormatPNG, 100)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "max-age=60")
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// listChromeInstances lists all Chrome instances
+func (s *Server) listChromeInstances(c *gin.Context) {
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		s.logger.Error("Failed to discover Chrome instances", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instances": instances,
+		"count":     len(instances),
+	})
+}
+
+// listChromeTabs lists tabs for all or specific Chrome instances
+func (s *Server) listChromeTabs(c *gin.Context) {
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		s.logger.Error("Failed to discover Chrome instances", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var allTabs []types.ChromeTab
+	for _, instance := range instances {
+		tabs, err := s.chromeManager.GetTabs(&instance)
+		if err != nil {
+			s.logger.Warn("Failed to get tabs for Chrome instance",
+				zap.Uint32("pid", instance.PID),
+				zap.Error(err),
+			)
+			continue
+		}
+		allTabs = append(allTabs, tabs...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tabs":  allTabs,
+		"count": len(allTabs),
+	})
+}
+
+// takeChromeTabScreenshot takes a screenshot of a specific Chrome tab
+func (s *Server) takeChromeTabScreenshot(c *gin.Context) {
+	tabID := c.Param("id")
+
+	targetTab, err := s.findChromeTab(tabID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if targetTab == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tab not found"})
+		return
+	}
+
+	// Out-of-process iframe handling: "composite" or "separate"
+	framesMode := c.Query("frames")
+	if framesMode != "" && framesMode != "composite" && framesMode != "separate" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frames must be 'composite' or 'separate'"})
+		return
+	}
+
+	// Capture screenshot
+	options := types.DefaultCaptureOptions()
+	options.CompositeFrames = framesMode == "composite"
+	buffer, err := s.chromeManager.CaptureTab(targetTab, options)
+	if err != nil {
+		s.logger.Error("Failed to capture Chrome tab screenshot",
+			zap.String("tab_id", tabID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if buffer, err = s.watermark(buffer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Encode as base64
 	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
-	result := types.ScreenshotResponse{
+
+	response := types.ScreenshotResponse{
 		Success:   true,
 		Data:      imageData,
 		Format:    buffer.Format,
@@ -690,259 +1966,1704 @@ func (s *Server) handleMCPChromeTabCapture(c *gin.Context, req *types.MCPRequest
 		Height:    buffer.Height,
 		Size:      int64(len(buffer.Data)),
 		Timestamp: buffer.Timestamp,
+		Metadata: types.Metadata{
+			CaptureMethod: "chrome_tab",
+			Properties: map[string]string{
+				"tab_id":    tabID,
+				"tab_title": targetTab.Title,
+				"tab_url":   targetTab.URL,
+			},
+		},
 	}
 
-	s.sendMCPResult(c, req.ID, result)
+	if framesMode != "separate" {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	captures, err := s.chromeManager.CaptureFrames(targetTab, options)
+	if err != nil {
+		s.logger.Error("Failed to capture Chrome tab frames",
+			zap.String("tab_id", tabID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.watermarkFrames(captures); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"screenshot": response,
+		"frames":     frameCaptureResponses(captures),
+	})
 }
 
-// MCP helper functions
+// listChromeTabFrames lists the out-of-process iframes of a Chrome tab
+func (s *Server) listChromeTabFrames(c *gin.Context) {
+	tabID := c.Param("id")
 
-func (s *Server) sendMCPResult(c *gin.Context, id interface{}, result interface{}) {
-	response := types.MCPResponse{
-		JSONRPC: "2.0",
-		Result:  result,
-		ID:      id,
+	targetTab, err := s.findChromeTab(tabID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	c.JSON(http.StatusOK, response)
+
+	if targetTab == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tab not found"})
+		return
+	}
+
+	frames, err := s.chromeManager.GetFrames(targetTab)
+	if err != nil {
+		s.logger.Error("Failed to enumerate Chrome tab frames",
+			zap.String("tab_id", tabID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"frames": frames,
+		"count":  len(frames),
+	})
 }
 
-func (s *Server) sendMCPError(c *gin.Context, id interface{}, code int, message string, data interface{}) {
-	response := types.MCPResponse{
-		JSONRPC: "2.0",
-		Error: &types.MCPError{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
-		ID: id,
+// findChromeTab searches all discovered Chrome instances for a tab by ID.
+// It returns a nil tab without error when no instance has the tab.
+func (s *Server) findChromeTab(tabID string) (*types.ChromeTab, error) {
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		return nil, err
 	}
-	c.JSON(http.StatusOK, response) // MCP errors are still HTTP 200
+
+	for _, instance := range instances {
+		tabs, err := s.chromeManager.GetTabs(&instance)
+		if err != nil {
+			continue
+		}
+
+		for _, tab := range tabs {
+			if tab.ID == tabID {
+				return &tab, nil
+			}
+		}
+	}
+
+	return nil, nil
 }
 
-// Parameter parsing helpers
-func getString(params map[string]interface{}, key string, defaultValue string) string {
-	if val, exists := params[key]; exists {
-		if str, ok := val.(string); ok {
-			return str
+// frameCaptureResponses converts per-frame captures to screenshot responses
+func frameCaptureResponses(captures []types.ChromeFrameCapture) []gin.H {
+	results := make([]gin.H, 0, len(captures))
+	for _, capture := range captures {
+		results = append(results, gin.H{
+			"frame": capture.Frame,
+			"screenshot": &types.ScreenshotResponse{
+				Success:   true,
+				Data:      base64.StdEncoding.EncodeToString(capture.Buffer.Data),
+				Format:    capture.Buffer.Format,
+				Width:     capture.Buffer.Width,
+				Height:    capture.Buffer.Height,
+				Size:      int64(len(capture.Buffer.Data)),
+				Timestamp: capture.Buffer.Timestamp,
+			},
+		})
+	}
+	return results
+}
+
+// handleMCPRequest handles MCP JSON-RPC 2.0 requests
+func (s *Server) handleMCPRequest(c *gin.Context) {
+	if s.rpcDebug != nil {
+		defer s.traceMCP(c)()
+	}
+
+	var req types.MCPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.sendMCPError(c, nil, -32700, "Parse error", nil)
+		return
+	}
+
+	s.logger.Debug("Received MCP request",
+		zap.String("method", req.Method),
+		zap.Any("id", req.ID),
+	)
+
+	// Notifications such as notifications/initialized carry no ID and get no response
+	if req.ID == nil && strings.HasPrefix(req.Method, "notifications/") {
+		if req.Method == "notifications/cancelled" {
+			s.handleMCPCancelledNotification(&req)
+		}
+		c.Status(http.StatusAccepted)
+		return
+	}
+	defer s.startMCPJob(c, &req)()
+
+	switch req.Method {
+	case "initialize":
+		s.handleMCPInitialize(c, &req)
+	case "ping":
+		s.sendMCPResult(c, req.ID, map[string]interface{}{})
+	case "resources/list":
+		s.handleMCPResourcesList(c, &req)
+	case "resources/templates/list":
+		s.handleMCPResourceTemplatesList(c, &req)
+	case "resources/read":
+		s.handleMCPResourcesRead(c, &req)
+	case "prompts/list":
+		s.handleMCPPromptsList(c, &req)
+	case "prompts/get":
+		s.handleMCPPromptsGet(c, &req)
+	case "screenshot.capture":
+		s.handleMCPScreenshot(c, &req)
+	case "screenshot.active":
+		s.handleMCPScreenshotActive(c, &req)
+	case "window.list":
+		s.handleMCPWindowList(c, &req)
+	case "screen.describe":
+		s.handleMCPScreenDescribe(c, &req)
+	case "screen.getPixel":
+		s.handleMCPScreenGetPixel(c, &req)
+	case "screen.histogram":
+		s.handleMCPScreenHistogram(c, &req)
+	case "screen.findText":
+		s.handleMCPScreenFindText(c, &req)
+	case "screen.findImage":
+		s.handleMCPScreenFindImage(c, &req)
+	case "chrome.instances":
+		s.handleMCPChromeInstances(c, &req)
+	case "chrome.tabs":
+		s.handleMCPChromeTabs(c, &req)
+	case "chrome.tabCapture":
+		s.handleMCPChromeTabCapture(c, &req)
+	case "chrome.frames":
+		s.handleMCPChromeFrames(c, &req)
+	case "stream.status":
+		s.handleMCPStreamStatus(c, &req)
+	case "recording.start":
+		s.handleMCPRecordingStart(c, &req)
+	case "recording.stop":
+		s.handleMCPRecordingStop(c, &req)
+	case "recording.list":
+		s.handleMCPRecordingList(c, &req)
+	case "recording.get":
+		s.handleMCPRecordingGet(c, &req)
+	case "screenshot.batch":
+		s.handleMCPScreenshotBatch(c, &req)
+	case "screenshot.scrolling":
+		s.handleMCPScreenshotScrolling(c, &req)
+	case "targets.list":
+		s.handleMCPTargetsList(c, &req)
+	case "captureSet.list":
+		s.handleMCPCaptureSetList(c, &req)
+	case "captureSet.capture":
+		s.handleMCPCaptureSetCapture(c, &req)
+	case "webcam.start":
+		s.handleMCPWebcamStart(c, &req)
+	case "webcam.stop":
+		s.handleMCPWebcamStop(c, &req)
+	case "webcam.status":
+		s.handleMCPWebcamStatus(c, &req)
+	case "output.start":
+		s.handleMCPOutputStart(c, &req)
+	case "output.stop":
+		s.handleMCPOutputStop(c, &req)
+	case "output.list":
+		s.handleMCPOutputList(c, &req)
+	case "job.list":
+		s.handleMCPJobList(c, &req)
+	case "job.cancel":
+		s.handleMCPJobCancel(c, &req)
+	case "plugins.list":
+		s.handleMCPPluginsList(c, &req)
+	case "workflow.list":
+		s.handleMCPWorkflowList(c, &req)
+	case "workflow.run":
+		s.handleMCPWorkflowRun(c, &req)
+	case "macro.record":
+		s.handleMCPMacroRecord(c, &req)
+	case "macro.play":
+		s.handleMCPMacroPlay(c, &req)
+	default:
+		if s.plugins.HasTool(req.Method) {
+			s.handleMCPPluginTool(c, &req)
+			return
+		}
+		s.sendMCPError(c, req.ID, -32601, "Method not found", nil)
+	}
+}
+
+// mcpProtocolVersion is the MCP revision the server speaks, the one defining the SSE transport
+const mcpProtocolVersion = "2024-11-05"
+
+// handleMCPInitialize answers the MCP handshake a host sends before any other request
+func (s *Server) handleMCPInitialize(c *gin.Context, req *types.MCPRequest) {
+	s.sendMCPResult(c, req.ID, map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities": map[string]interface{}{
+			"resources": map[string]interface{}{},
+			"prompts":   map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "screenshot-mcp-server",
+			"version": "1.0.0",
+		},
+	})
+}
+
+// handleMCPScreenshot handles MCP screenshot requests
+func (s *Server) handleMCPScreenshot(c *gin.Context, req *types.MCPRequest) {
+	// Parse parameters
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+
+	// Build screenshot request
+	screenshotReq := types.ScreenshotRequest{
+		Method:        getString(params, "method", "title"),
+		Target:        getString(params, "target", ""),
+		Format:        types.ImageFormat(getString(params, "format", "")),
+		Quality:       getInt(params, "quality", 0),
+		IncludeCursor: getBool(params, "include_cursor", s.config.Load().IncludeCursor),
+		Match:         getString(params, "match", ""),
+		TopLevel:      getBool(params, "top_level", false),
+		RegionRelativeTo: types.RegionOrigin(getString(params, "region_relative_to", "")),
+		CaptureMethod: types.CaptureMethod(getString(params, "capture_method", "")),
+		RetryBackoff:  getString(params, "retry_backoff", ""),
+		RejectBlackFrames: getBool(params, "reject_black_frames", false),
+		CaptureOtherDesktops: getBool(params, "capture_other_desktops", false),
+		CaptureGhost: getBool(params, "capture_ghost", false),
+		SyncToPresent: getBool(params, "sync_to_present", false),
+		IncludeOwnedWindows: getBool(params, "include_owned_windows", false),
+		MaxResponseBytes: getInt(params, "max_response_bytes", 0),
+		Machine:       getString(params, "machine", ""),
+		IfNoneMatch:   getString(params, "if_none_match", ""),
+	}
+
+	for _, method := range getStringList(params, "fallback_methods") {
+		screenshotReq.FallbackMethods = append(screenshotReq.FallbackMethods, types.CaptureMethod(method))
+	}
+	if _, exists := params["retry_count"]; exists {
+		retryCount := getInt(params, "retry_count", 0)
+		screenshotReq.RetryCount = &retryCount
+	}
+
+	region, err := getRegion(params, "region")
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	screenshotReq.Region = region
+
+	if screenshotReq.WaitFor, err = getWaitCondition(params, "wait_for"); err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	if screenshotReq.Popups, err = getPopupCapture(params, "popups"); err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	if screenshotReq.WaitForStable, err = getStabilityCondition(params, "wait_for_stable"); err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	if screenshotReq.Pipeline, err = getPipeline(params, "pipeline"); err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	screenshotReq.ColorManagement = types.ColorManagement(getString(params, "color_management", ""))
+
+	if screenshotReq.Target == "" && methodRequiresTarget(screenshotReq.Method) {
+		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: target", nil)
+		return
+	}
+
+	if err := s.prepareScreenshotRequest(&screenshotReq); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	// Captures addressed to another machine run there
+	remote, err := s.resolveTarget(&screenshotReq)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	if remote != nil {
+		result, err := remote.capture(c.Request.Context())
+		if err != nil {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPResult(c, req.ID, result)
+		return
+	}
+
+	// Process the request (reuse existing logic)
+	options := &types.CaptureOptions{
+		IncludeCursor:    screenshotReq.IncludeCursor,
+		IncludeFrame:     getBool(params, "include_frame", true),
+		ScaleFactor:      getFloat64(params, "scale_factor", 1.0),
+		AllowMinimized:   getBool(params, "allow_minimized", true),
+		RestoreWindow:    getBool(params, "restore_window", false),
+		WaitForVisible:   2 * time.Second,
+		RetryCount:       3,
+		RetryBackoff:     100 * time.Millisecond,
+		RetryMaxBackoff:  2 * time.Second,
+		DetectBlackFrames: getBool(params, "detect_black_frames", true),
+		Context:          c.Request.Context(),
+		CustomProperties: make(map[string]string),
+	}
+
+	plan, err := applyScreenshotRequest(&screenshotReq, options)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	startTime := time.Now()
+	buffer, err := s.captureWhenReady(&screenshotReq, plan, options)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	captured := time.Now()
+	timing := types.NewCaptureTiming(startTime, captured)
+	buffer, colorProfile, err := s.manageColor(buffer, screenshotReq.ColorManagement)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	if buffer, err = s.postProcess(buffer, screenshotReq.Pipeline); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	timing.Process = time.Since(captured)
+
+	popups, popupBuffers, err := s.capturePopups(&screenshotReq, buffer, options)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	cacheKey, etag, unchanged := s.checkFrameCache(&screenshotReq, buffer, len(popupBuffers))
+	if unchanged != nil {
+		s.rememberCapture(&screenshotReq, unchanged)
+		s.sendMCPResult(c, req.ID, unchanged)
+		return
+	}
+
+	// Encode and send response
+	encodeStart := time.Now()
+	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
+	result := types.ScreenshotResponse{
+		Success:   true,
+		Data:      imageData,
+		Format:    buffer.Format,
+		Width:     buffer.Width,
+		Height:    buffer.Height,
+		Size:      int64(len(buffer.Data)),
+		Timestamp: buffer.Timestamp,
+		Metadata: types.Metadata{
+			CaptureMethod:      captureMethodName(buffer, screenshotReq.Method),
+			WindowHung:         buffer.WindowInfo.State == "hung",
+			GhostWindow:        buffer.GhostWindow,
+			BlackFrameDetected: buffer.BlackFrameDetected,
+			Attempts:           buffer.Attempts,
+			Retries:            buffer.Retries,
+			OccludedPercent:    buffer.WindowInfo.OccludedPercent,
+			OccludedBy:         buffer.WindowInfo.OccludedBy,
+			OwnedWindows:       buffer.OwnedWindows,
+			ColorProfile:       colorProfile,
+			Stability:          buffer.Stability,
+			Timing:             timing,
+		},
+		Popups: popups,
+	}
+
+	if err := fitScreenshotBudget(&result, buffer, popupBuffers, &screenshotReq); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	timing.Encode = time.Since(encodeStart)
+	s.cacheFrame(cacheKey, etag, &result)
+	s.rememberCapture(&screenshotReq, &result)
+
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// handleMCPScreenshotActive captures the foreground window; it accepts the same
+// optional params as screenshot.capture apart from method and target
+func (s *Server) handleMCPScreenshotActive(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	active := make(map[string]interface{}, len(params)+1)
+	for key, value := range params {
+		active[key] = value
+	}
+	active["method"] = "foreground"
+	delete(active, "target")
+
+	activeReq := *req
+	activeReq.Params = active
+	s.handleMCPScreenshot(c, &activeReq)
+}
+
+// handleMCPWindowList handles MCP window list requests
+func (s *Server) handleMCPWindowList(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	filter := &types.WindowFilter{
+		TitleContains:  getString(params, "title_contains", ""),
+		VisibleOnly:    getBool(params, "visible_only", false),
+		ExcludeSystem:  getBool(params, "exclude_system", false),
+		VirtualDesktop: getString(params, "virtual_desktop", ""),
+	}
+
+	windows, err := s.windowManager.EnumerateWindows(filter)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	result := map[string]interface{}{
+		"windows": windows,
+		"count":   len(windows),
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// handleMCPChromeInstances handles MCP Chrome instances requests
+func (s *Server) handleMCPChromeInstances(c *gin.Context, req *types.MCPRequest) {
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	result := map[string]interface{}{
+		"instances": instances,
+		"count":     len(instances),
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// handleMCPChromeTabs handles MCP Chrome tabs requests
+func (s *Server) handleMCPChromeTabs(c *gin.Context, req *types.MCPRequest) {
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	var allTabs []types.ChromeTab
+	for _, instance := range instances {
+		tabs, err := s.chromeManager.GetTabs(&instance)
+		if err != nil {
+			continue
+		}
+		allTabs = append(allTabs, tabs...)
+	}
+
+	result := map[string]interface{}{
+		"tabs":  allTabs,
+		"count": len(allTabs),
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// handleMCPChromeTabCapture handles MCP Chrome tab capture requests
+func (s *Server) handleMCPChromeTabCapture(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+
+	tabID := getString(params, "tab_id", "")
+	if tabID == "" {
+		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: tab_id", nil)
+		return
+	}
+
+	framesMode := getString(params, "frames", "")
+	if framesMode != "" && framesMode != "composite" && framesMode != "separate" {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", "frames must be 'composite' or 'separate'")
+		return
+	}
+
+	maxResponseBytes := getInt(params, "max_response_bytes", 0)
+	if err := validateResponseBudget(maxResponseBytes); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	targetTab, err := s.findChromeTab(tabID)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	if targetTab == nil {
+		s.sendMCPError(c, req.ID, -32603, "Tab not found", nil)
+		return
+	}
+
+	// Capture screenshot
+	options := types.DefaultCaptureOptions()
+	options.CompositeFrames = framesMode == "composite"
+	buffer, err := s.chromeManager.CaptureTab(targetTab, options)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Screenshot failed", err.Error())
+		return
+	}
+	if buffer, err = s.watermark(buffer); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	// Encode and send response
+	imageData := base64.StdEncoding.EncodeToString(buffer.Data)
+	result := types.ScreenshotResponse{
+		Success:   true,
+		Data:      imageData,
+		Format:    buffer.Format,
+		Width:     buffer.Width,
+		Height:    buffer.Height,
+		Size:      int64(len(buffer.Data)),
+		Timestamp: buffer.Timestamp,
+	}
+
+	if framesMode != "separate" {
+		if err := fitResponseBudget(&result, []budgetedImage{screenshotImage(&result, buffer)}, maxResponseBytes, types.FormatPNG, 0); err != nil {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPResult(c, req.ID, result)
+		return
+	}
+
+	captures, err := s.chromeManager.CaptureFrames(targetTab, options)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Frame capture failed", err.Error())
+		return
+	}
+	if err := s.watermarkFrames(captures); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	frames := frameCaptureResponses(captures)
+	response := map[string]interface{}{
+		"screenshot": &result,
+		"frames":     frames,
+	}
+	images := []budgetedImage{screenshotImage(&result, buffer)}
+	for i, frame := range frames {
+		images = append(images, screenshotImage(frame["screenshot"].(*types.ScreenshotResponse), captures[i].Buffer))
+	}
+	if err := fitResponseBudget(response, images, maxResponseBytes, types.FormatPNG, 0); err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	s.sendMCPResult(c, req.ID, response)
+}
+
+// handleMCPChromeFrames handles MCP Chrome out-of-process iframe listing requests
+func (s *Server) handleMCPChromeFrames(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+
+	tabID := getString(params, "tab_id", "")
+	if tabID == "" {
+		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: tab_id", nil)
+		return
+	}
+
+	targetTab, err := s.findChromeTab(tabID)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	if targetTab == nil {
+		s.sendMCPError(c, req.ID, -32603, "Tab not found", nil)
+		return
+	}
+
+	frames, err := s.chromeManager.GetFrames(targetTab)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+
+	result := map[string]interface{}{
+		"frames": frames,
+		"count":  len(frames),
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// MCP helper functions
+
+func (s *Server) sendMCPResult(c *gin.Context, id interface{}, result interface{}) {
+	response := types.MCPResponse{
+		JSONRPC: "2.0",
+		Result:  result,
+		ID:      id,
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) sendMCPError(c *gin.Context, id interface{}, code int, message string, data interface{}) {
+	response := types.MCPResponse{
+		JSONRPC: "2.0",
+		Error: &types.MCPError{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+		ID: id,
+	}
+	c.JSON(http.StatusOK, response) // MCP errors are still HTTP 200
+}
+
+// Parameter parsing helpers
+func getString(params map[string]interface{}, key string, defaultValue string) string {
+	if val, exists := params[key]; exists {
+		if str, ok := val.(string); ok {
+			return str
+		}
+	}
+	return defaultValue
+}
+
+func getInt(params map[string]interface{}, key string, defaultValue int) int {
+	if val, exists := params[key]; exists {
+		switch v := val.(type) {
+		case int:
+			return v
+		case float64:
+			return int(v)
+		case string:
+			if i, err := strconv.Atoi(v); err == nil {
+				return i
+			}
+		}
+	}
+	return defaultValue
+}
+
+func getBool(params map[string]interface{}, key string, defaultValue bool) bool {
+	if val, exists := params[key]; exists {
+		if b, ok := val.(bool); ok {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getFloat64(params map[string]interface{}, key string, defaultValue float64) float64 {
+	if val, exists := params[key]; exists {
+		switch v := val.(type) {
+		case float64:
+			return v
+		case int:
+			return float64(v)
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	}
+	return defaultValue
+}
+
+// getStringList reads a parameter given either as a JSON array of strings or a comma-separated string
+func getStringList(params map[string]interface{}, key string) []string {
+	switch v := params[key].(type) {
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				items = append(items, str)
+			}
+		}
+		return items
+	case string:
+		return splitList(v)
+	}
+	return nil
+}
+
+// getRegion reads a region given either as an {x, y, width, height} object or an "x,y,width,height" string
+func getRegion(params map[string]interface{}, key string) (*types.Rectangle, error) {
+	switch v := params[key].(type) {
+	case map[string]interface{}:
+		region := &types.Rectangle{
+			X:      getInt(v, "x", 0),
+			Y:      getInt(v, "y", 0),
+			Width:  getInt(v, "width", 0),
+			Height: getInt(v, "height", 0),
+		}
+		if region.Width <= 0 || region.Height <= 0 {
+			return nil, fmt.Errorf("region width and height must be positive")
+		}
+		return region, nil
+	case string:
+		return parseRegion(v)
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("region must be an object or an \"x,y,width,height\" string")
+}
+
+// getWaitCondition reads a wait_for object parameter
+func getWaitCondition(params map[string]interface{}, key string) (*types.WaitCondition, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	var condition types.WaitCondition
+	if err := json.Unmarshal(data, &condition); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return &condition, nil
+}
+
+// getStabilityCondition reads a wait_for_stable parameter: an object, or true for the defaults
+func getStabilityCondition(params map[string]interface{}, key string) (*types.StabilityCondition, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	if enabled, ok := value.(bool); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return &types.StabilityCondition{}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	var condition types.StabilityCondition
+	if err := json.Unmarshal(data, &condition); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return &condition, nil
+}
+
+// getPopupCapture reads a popups object parameter
+func getPopupCapture(params map[string]interface{}, key string) (*types.PopupCapture, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	var popups types.PopupCapture
+	if err := json.Unmarshal(data, &popups); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return &popups, nil
+}
+
+// getPipeline reads a pipeline array parameter; an absent or null pipeline is nil, so
+// the server default applies
+func getPipeline(params map[string]interface{}, key string) ([]types.PipelineStage, error) {
+	value, exists := params[key]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	pipeline := []types.PipelineStage{}
+	if err := json.Unmarshal(data, &pipeline); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return pipeline, nil
+}
+
+// parseRegion parses an "x,y,width,height" region
+func parseRegion(value string) (*types.Rectangle, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("region must be \"x,y,width,height\"")
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid region value %q", part)
+		}
+		values[i] = n
+	}
+
+	if values[2] <= 0 || values[3] <= 0 {
+		return nil, fmt.Errorf("region width and height must be positive")
+	}
+	return &types.Rectangle{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
+
+// Middleware
+
+func (s *Server) loggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		raw := c.Request.URL.RawQuery
+
+		// Process request
+		c.Next()
+
+		// Log request
+		latency := time.Since(start)
+		clientIP := c.ClientIP()
+		method := c.Request.Method
+		statusCode := c.Writer.Status()
+
+		if raw != "" {
+			path = path + "?" + raw
+		}
+
+		s.logger.Info("HTTP Request",
+			zap.String("client_ip", clientIP),
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Int("status", statusCode),
+			zap.Duration("latency", latency),
+		)
+	}
+}
+
+// recoveryMiddleware recovers panics of handlers, records them and fails the request
+// with 500
+func (s *Server) recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// Handlers abort responses mid-stream this way; it is not a crash
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			report := s.crashes.Capture("http", p, map[string]string{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+			})
+			s.logger.Error("HTTP handler panicked",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("crash_id", report.ID),
+				zap.Any("error", p),
+			)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":    "Internal server error",
+				"crash_id": report.ID,
+			})
+		}()
+		c.Next()
+	}
+}
+
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// handleMCPStreamStatus handles MCP stream status requests
+func (s *Server) handleMCPStreamStatus(c *gin.Context, req *types.MCPRequest) {
+	stats := s.streamManager.GetStats()
+	result := map[string]interface{}{
+		"active_sessions": stats.ActiveSessions,
+		"total_sessions":  stats.TotalSessions,
+		"total_frames":    stats.TotalFrames,
+		"uptime":          stats.Uptime.String(),
+		"max_sessions":    s.config.Load().StreamMaxSessions,
+		"sessions":        stats.Sessions,
+		"activity":        s.activity.State(),
+		"power":           s.power.State(),
+		"websocket_url":   fmt.Sprintf("ws://%s:%d/stream/{windowId}", s.config.Load().Host, s.config.Load().Port),
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
+
+// WebSocket streaming handlers
+
+// handleWebSocketStream handles WebSocket streaming connections
+func (s *Server) handleWebSocketStream(c *gin.Context) {
+	// The "active" target follows the foreground window, starting with the current one
+	follow := c.Param("windowId") == activeStreamTarget
+	var windowID int
+	var monitor *int
+	var err error
+	if follow {
+		var handle uintptr
+		handle, err = s.windowManager.GetForegroundWindow()
+		windowID = int(handle)
+	} else {
+		windowID, monitor, err = s.parseStreamTarget(c.Param("windowId"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Upgrade HTTP connection to WebSocket
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		s.logger.Error("WebSocket upgrade failed", zap.Error(err))
+		return
+	}
+	s.compression.Apply(conn)
+	defer conn.Close()
+
+	// Resume a detached session when the client presents its session ID and token
+	if resumeID := c.Query("session_id"); resumeID != "" {
+		s.resumeWebSocketStream(c, conn, uintptr(windowID), monitor, follow, resumeID)
+		return
+	}
+
+	// Join another client's session as a view-only viewer
+	if watchID := c.Query("watch"); watchID != "" {
+		s.watchWebSocketStream(c, conn, uintptr(windowID), monitor, follow, watchID)
+		return
+	}
+
+	// Parse query parameters for initial options
+	fps := s.config.Load().StreamDefaultFPS
+	quality := s.config.Load().Quality
+	format := s.config.Load().DefaultFormat
+
+	if fpsStr := c.Query("fps"); fpsStr != "" {
+		if f, err := strconv.Atoi(fpsStr); err == nil && f > 0 && f <= 60 {
+			fps = f
+		}
+	}
+
+	if qualityStr := c.Query("quality"); qualityStr != "" {
+		if q, err := strconv.Atoi(qualityStr); err == nil && q > 0 && q <= 100 {
+			quality = q
+		}
+	}
+
+	if formatStr := c.Query("format"); formatStr != "" {
+		format = formatStr
+	}
+
+	options := &types.StreamOptions{
+		FPS:      fps,
+		Quality:  quality,
+		Format:   types.ImageFormat(format),
+		Monitor:  monitor,
+		Follow:   follow,
+	}
+
+	// Optional client-ack flow control
+	if ack, err := strconv.ParseBool(c.Query("ack")); err == nil && ack {
+		options.AckMode = true
+		options.MaxUnacked = 1
+		if n, err := strconv.Atoi(c.Query("max_unacked")); err == nil && n > 0 {
+			options.MaxUnacked = n
+		}
+	}
+
+	// Quality ladder: occasional detail frames between lower-quality ones
+	if n, err := strconv.Atoi(c.Query("detail_interval")); err == nil && n > 1 {
+		options.DetailInterval = n
+		if q, err := strconv.Atoi(c.Query("detail_quality")); err == nil && q > 0 && q <= 100 {
+			options.DetailQuality = q
+		}
+	}
+
+	// Region of interest, which set_region moves mid-session
+	if regionStr := c.Query("region"); regionStr != "" {
+		region, err := parseRegion(regionStr)
+		if err != nil {
+			conn.WriteJSON(ws.StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+			})
+			return
+		}
+		options.Region = region
+	}
+
+	// Region of a fixed size that follows the cursor
+	if zoomStr := c.Query("cursor_zoom"); zoomStr != "" {
+		zoom, smoothing, err := parseCursorZoom(zoomStr, c.Query("cursor_smoothing"))
+		if err == nil && options.Region != nil {
+			err = fmt.Errorf("region and cursor_zoom can't be combined")
+		}
+		if err != nil {
+			conn.WriteJSON(ws.StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+			})
+			return
+		}
+		options.CursorZoom, options.CursorSmoothing = zoom, smoothing
+	}
+
+	// Other windows composited picture-in-picture
+	if pip := c.Query("pip"); pip != "" {
+		insets, err := parseInsets(pip)
+		if err != nil {
+			conn.WriteJSON(ws.StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+			})
+			return
+		}
+		options.Insets = insets
+	}
+
+	// Every window of a desktop or monitor stream but the allowed ones hidden
+	if privacy := c.Query("privacy"); privacy != "" {
+		allow, err := parsePrivacy(privacy, c.Query("allow"))
+		if err == nil && (follow || (monitor == nil && windowID != 0)) {
+			err = fmt.Errorf("privacy only applies to desktop and monitor streams")
+		}
+		if err == nil && (options.Region != nil || options.CursorZoom != nil) {
+			err = fmt.Errorf("privacy streams show the whole display")
+		}
+		if err != nil {
+			conn.WriteJSON(ws.StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				Error:     err.Error(),
+			})
+			return
+		}
+		options.Privacy, options.PrivacyAllow = privacy, allow
+	}
+
+	// Capture time, window title, session and frame rate burned into the frames
+	options.Overlay = c.Query("overlay") == "true"
+
+	// Desktop duplication of a full-screen game, synced to its frames
+	options.Game = c.Query("game") == "true"
+
+	// Frame signing or encryption, never weaker than the server's minimum
+	security, err := types.ParseStreamSecurity(c.Query("security"))
+	if err != nil {
+		conn.WriteJSON(ws.StreamMessage{
+			Type:      "error",
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		})
+		return
+	}
+	options.Security = security.Stronger(types.StreamSecurity(s.config.Load().StreamSecurity))
+
+	// Raw BGRA frames for local viewers instead of encoded images
+	transport, err := types.ParseStreamTransport(c.Query("transport"))
+	if err != nil {
+		conn.WriteJSON(ws.StreamMessage{
+			Type:      "error",
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		})
+		return
+	}
+	options.Transport = transport
+
+	// Optional recording of the delivered frames
+	if record, err := strconv.ParseBool(c.Query("record")); err == nil && record {
+		options.Record = true
+		options.RecordOutput = c.Query("record_output")
+	}
+
+	// Set up the screenshot engine in the stream manager
+	s.streamManager.SetEngine(s.engine)
+
+	s.logger.Info("Starting WebSocket stream session",
+		zap.Int("window_id", windowID),
+		zap.Int("fps", fps),
+		zap.Int("quality", quality),
+		zap.String("format", format),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	// Special handling: if windowID is 0, capture full desktop
+	if monitor != nil {
+		s.logger.Info("Using monitor capture mode", zap.Int("monitor", *monitor))
+	} else if windowID == 0 {
+		s.logger.Info("Using desktop capture mode for window ID 0")
+	}
+
+	// Start streaming session, bound to the connection from the outset, with the
+	// session started message sent before frames start flowing
+	session, err := s.streamManager.ConnectSession(uintptr(windowID), options, conn, ws.NewClientInfo(c, conn), func(session *ws.StreamSession) interface{} {
+		started := map[string]interface{}{
+			"type":         "session_started",
+			"session_id":   session.ID,
+			"resume_token": session.ResumeToken,
+			"timestamp":    time.Now(),
+		}
+		if recordingID := session.RecordingID(); recordingID != "" {
+			started["recording_id"] = recordingID
+		}
+		if options.Security != types.StreamSecurityNone {
+			started["security"] = options.Security
+		}
+		if options.Transport != types.StreamTransportJSON {
+			started["transport"] = options.Transport
+		}
+		if options.Follow {
+			started["window_id"] = session.WindowID
+		}
+		return started
+	})
+	if err != nil {
+		s.logger.Error("Stream session failed",
+			zap.Int("window_id", windowID),
+			zap.Error(err),
+		)
+		conn.WriteJSON(ws.StreamMessage{
+			Type:      "error",
+			Timestamp: time.Now(),
+			Error:     err.Error(),
+		})
+		return
+	}
+
+	// Handle WebSocket messages until the connection drops or the session ends.
+	// A dropped connection leaves the session resumable for the grace period.
+	s.streamManager.HandleClientMessages(session)
+
+	s.logger.Info("WebSocket stream connection closed",
+		zap.Int("window_id", windowID),
+		zap.String("session_id", session.ID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+}
+
+// parseStreamTarget reads the target of a stream route: a window handle, 0 for the
+// desktop, "monitor:N" for the display of that index, or "alias:name" for the window of
+// a target alias
+func (s *Server) parseStreamTarget(target string) (int, *int, error) {
+	if index, ok := strings.CutPrefix(target, "monitor:"); ok {
+		monitor, err := strconv.Atoi(index)
+		if err != nil || monitor < 0 {
+			return 0, nil, fmt.Errorf("invalid monitor %q", index)
+		}
+		if engine, ok := s.nativeEngine().(interface {
+			Monitors() ([]types.MonitorInfo, error)
+		}); ok {
+			if monitors, err := engine.Monitors(); err == nil && monitor >= len(monitors) {
+				return 0, nil, fmt.Errorf("monitor %d not found (%d monitors)", monitor, len(monitors))
+			}
+		}
+		return 0, &monitor, nil
+	}
+	if name, ok := strings.CutPrefix(target, aliasMethod+":"); ok {
+		handle, err := s.findWindowHandle(aliasMethod, name)
+		if err != nil {
+			return 0, nil, err
 		}
+		return int(handle), nil, nil
 	}
-	return defaultValue
+	windowID, err := strconv.Atoi(target)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid window ID %q", target)
+	}
+	return windowID, nil, nil
 }
 
-func getInt(params map[string]interface{}, key string, defaultValue int) int {
-	if val, exists := params[key]; exists {
-		switch v := val.(type) {
-		case int:
-			return v
-		case float64:
-			return int(v)
-		case string:
-			if i, err := strconv.Atoi(v); err == nil {
-				return i
-			}
+// parseCursorZoom parses the "widthxheight" size of a cursor zoom region and its
+// smoothing, which defaults to ws.DefaultCursorSmoothing
+func parseCursorZoom(size, smoothing string) (*types.Size, float64, error) {
+	width, height, ok := strings.Cut(size, "x")
+	w, werr := strconv.Atoi(width)
+	h, herr := strconv.Atoi(height)
+	if !ok || werr != nil || herr != nil || w < 16 || h < 16 {
+		return nil, 0, fmt.Errorf("cursor_zoom must be \"widthxheight\", at least 16x16")
+	}
+	factor := ws.DefaultCursorSmoothing
+	if smoothing != "" {
+		var err error
+		factor, err = strconv.ParseFloat(smoothing, 64)
+		if err != nil || factor < 0 || factor > ws.MaxCursorSmoothing {
+			return nil, 0, fmt.Errorf("cursor_smoothing must be between 0 and %g", ws.MaxCursorSmoothing)
 		}
 	}
-	return defaultValue
+	return &types.Size{Width: w, Height: h}, factor, nil
 }
 
-func getBool(params map[string]interface{}, key string, defaultValue bool) bool {
-	if val, exists := params[key]; exists {
-		if b, ok := val.(bool); ok {
-			return b
-		}
+// parseInsets parses the picture-in-picture windows of a stream, a comma-separated list of
+// "window[:position[:scale]]"
+func parseInsets(value string) ([]types.StreamInset, error) {
+	specs := strings.Split(value, ",")
+	if len(specs) > ws.MaxInsets {
+		return nil, fmt.Errorf("at most %d pip windows", ws.MaxInsets)
 	}
-	return defaultValue
-}
 
-func getFloat64(params map[string]interface{}, key string, defaultValue float64) float64 {
-	if val, exists := params[key]; exists {
-		switch v := val.(type) {
-		case float64:
-			return v
-		case int:
-			return float64(v)
-		case string:
-			if f, err := strconv.ParseFloat(v, 64); err == nil {
-				return f
+	insets := make([]types.StreamInset, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(strings.TrimSpace(spec), ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("pip window must be \"window[:position[:scale]]\": %q", spec)
+		}
+		handle, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pip window ID %q", parts[0])
+		}
+		inset := types.StreamInset{WindowID: uintptr(handle), Position: ws.DefaultInsetPosition, Scale: ws.DefaultInsetScale}
+		if len(parts) > 1 && parts[1] != "" {
+			switch parts[1] {
+			case "top_left", "top_right", "bottom_left", "bottom_right":
+				inset.Position = parts[1]
+			default:
+				return nil, fmt.Errorf("pip position must be top_left, top_right, bottom_left or bottom_right")
+			}
+		}
+		if len(parts) > 2 {
+			scale, err := strconv.ParseFloat(parts[2], 64)
+			if err != nil || scale < ws.MinInsetScale || scale > ws.MaxInsetScale {
+				return nil, fmt.Errorf("pip scale must be between %g and %g", ws.MinInsetScale, ws.MaxInsetScale)
 			}
+			inset.Scale = scale
 		}
+		insets = append(insets, inset)
 	}
-	return defaultValue
+	return insets, nil
 }
 
-// Middleware
+// parsePrivacy checks the privacy mode of a stream and parses the windows it leaves
+// visible, a comma-separated list of window IDs
+func parsePrivacy(mode, allow string) ([]uintptr, error) {
+	if mode != screenshot.PrivacyBlur && mode != screenshot.PrivacyBlack {
+		return nil, fmt.Errorf("privacy must be %s or %s", screenshot.PrivacyBlur, screenshot.PrivacyBlack)
+	}
+	if allow == "" {
+		return nil, nil
+	}
 
-func (s *Server) loggingMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+	var handles []uintptr
+	for _, id := range strings.Split(allow, ",") {
+		handle, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed window ID %q", id)
+		}
+		handles = append(handles, uintptr(handle))
+	}
+	return handles, nil
+}
 
-		// Process request
-		c.Next()
+// activeStreamTarget is the stream target following the foreground window
+const activeStreamTarget = "active"
 
-		// Log request
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
+// sameStreamTarget reports whether a session streams the given window or monitor, or
+// follows the foreground window like the "active" target
+func sameStreamTarget(stats *ws.StatusMessage, windowID uintptr, monitor *int, follow bool) bool {
+	if follow || stats.Options.Follow {
+		return follow && stats.Options.Follow
+	}
+	if monitor != nil || stats.Options.Monitor != nil {
+		return monitor != nil && stats.Options.Monitor != nil && *monitor == *stats.Options.Monitor
+	}
+	return stats.WindowID == windowID
+}
 
-		if raw != "" {
-			path = path + "?" + raw
-		}
+// resumeWebSocketStream re-attaches a reconnecting client to its detached stream session
+func (s *Server) resumeWebSocketStream(c *gin.Context, conn *websocket.Conn, windowID uintptr, monitor *int, follow bool, sessionID string) {
+	if stats, err := s.streamManager.GetSessionStats(sessionID); err == nil && !sameStreamTarget(stats, windowID, monitor, follow) {
+		conn.WriteJSON(map[string]interface{}{
+			"type":      "error",
+			"error":     "session belongs to a different window",
+			"timestamp": time.Now(),
+		})
+		return
+	}
 
-		s.logger.Info("HTTP Request",
-			zap.String("client_ip", clientIP),
-			zap.String("method", method),
-			zap.String("path", path),
-			zap.Int("status", statusCode),
-			zap.Duration("latency", latency),
+	session, err := s.streamManager.ResumeSession(sessionID, c.Query("resume_token"), conn)
+	if err != nil {
+		s.logger.Warn("Stream session resume rejected",
+			zap.String("session_id", sessionID),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Error(err),
 		)
+		conn.WriteJSON(map[string]interface{}{
+			"type":      "error",
+			"error":     err.Error(),
+			"timestamp": time.Now(),
+		})
+		return
 	}
-}
 
-func (s *Server) corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+	session.SetClientInfo(ws.NewClientInfo(c, conn))
+	s.streamManager.HandleClientMessages(session)
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	s.logger.Info("WebSocket stream connection closed",
+		zap.Uintptr("window_id", windowID),
+		zap.String("session_id", session.ID),
+		zap.String("client_ip", c.ClientIP()),
+	)
+}
 
-		c.Next()
+// watchWebSocketStream joins a connection to a stream session as a view-only viewer
+func (s *Server) watchWebSocketStream(c *gin.Context, conn *websocket.Conn, windowID uintptr, monitor *int, follow bool, sessionID string) {
+	if stats, err := s.streamManager.GetSessionStats(sessionID); err == nil && !sameStreamTarget(stats, windowID, monitor, follow) {
+		conn.WriteJSON(map[string]interface{}{
+			"type":      "error",
+			"error":     "session belongs to a different window",
+			"timestamp": time.Now(),
+		})
+		return
+	}
+
+	if err := s.streamManager.WatchSession(sessionID, conn, ws.NewClientInfo(c, conn)); err != nil {
+		conn.WriteJSON(map[string]interface{}{
+			"type":      "error",
+			"error":     err.Error(),
+			"timestamp": time.Now(),
+		})
 	}
 }
 
-// handleMCPStreamStatus handles MCP stream status requests
-func (s *Server) handleMCPStreamStatus(c *gin.Context, req *types.MCPRequest) {
+// getStreamStatus returns the current streaming status
+func (s *Server) getStreamStatus(c *gin.Context) {
 	stats := s.streamManager.GetStats()
-	result := map[string]interface{}{
+	c.JSON(http.StatusOK, gin.H{
 		"active_sessions": stats.ActiveSessions,
 		"total_sessions":  stats.TotalSessions,
 		"total_frames":    stats.TotalFrames,
 		"uptime":          stats.Uptime.String(),
-		"max_sessions":    s.config.StreamMaxSessions,
-		"websocket_url":   fmt.Sprintf("ws://%s:%d/stream/{windowId}", s.config.Host, s.config.Port),
+		"max_sessions":    s.config.Load().StreamMaxSessions,
+		"sessions":        stats.Sessions,
+		"activity":        s.activity.State(),
+		"power":           s.power.State(),
+	})
+}
+
+// Recording handlers
+
+// recordingOptionsFromRequest applies a recording request over the default options
+func recordingOptionsFromRequest(req *types.RecordingRequest) (*types.RecordingOptions, error) {
+	options := types.DefaultRecordingOptions()
+	if req.Mode != "" {
+		options.Mode = req.Mode
 	}
-	s.sendMCPResult(c, req.ID, result)
+
+	// Timelapses sample once per second and run until stopped or size-capped
+	if options.Mode == types.RecordingTimelapse {
+		options.FPS = 1
+		options.MaxDuration = 0
+	}
+
+	options.WindowID = req.WindowID
+	if req.FPS > 0 {
+		options.FPS = req.FPS
+	}
+	if req.Format != "" {
+		options.Format = req.Format
+	}
+	if req.Quality > 0 {
+		options.Quality = req.Quality
+	}
+	if req.MaxWidth > 0 {
+		options.MaxWidth = req.MaxWidth
+	}
+	options.IncludeInput = req.IncludeInput
+	options.Overlay = req.Overlay
+	if req.PlaybackFPS > 0 {
+		options.PlaybackFPS = req.PlaybackFPS
+	}
+	if req.MaxSize > 0 {
+		options.MaxSize = req.MaxSize
+	}
+	if req.Output != "" {
+		options.Output = req.Output
+	}
+
+	if req.MaxDuration != "" {
+		duration, err := time.ParseDuration(req.MaxDuration)
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid max_duration: %s", req.MaxDuration)
+		}
+		options.MaxDuration = duration
+	}
+
+	return options, nil
 }
 
-// WebSocket streaming handlers
+// startRecording starts a new recording
+func (s *Server) startRecording(c *gin.Context) {
+	var req types.RecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
 
-// handleWebSocketStream handles WebSocket streaming connections
-func (s *Server) handleWebSocketStream(c *gin.Context) {
-	windowIDStr := c.Param("windowId")
-	windowID, err := strconv.Atoi(windowIDStr)
+	options, err := recordingOptionsFromRequest(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Upgrade HTTP connection to WebSocket
-	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	info, err := s.recorder.Start(options)
 	if err != nil {
-		s.logger.Error("WebSocket upgrade failed", zap.Error(err))
+		s.logger.Error("Failed to start recording", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer conn.Close()
 
-	// Parse query parameters for initial options
-	fps := s.config.StreamDefaultFPS
-	quality := s.config.Quality
-	format := s.config.DefaultFormat
+	c.JSON(http.StatusCreated, info)
+}
 
-	if fpsStr := c.Query("fps"); fpsStr != "" {
-		if f, err := strconv.Atoi(fpsStr); err == nil && f > 0 && f <= 60 {
-			fps = f
-		}
-	}
+// listRecordings lists all recordings
+func (s *Server) listRecordings(c *gin.Context) {
+	recordings := s.recorder.List()
+	c.JSON(http.StatusOK, gin.H{
+		"recordings": recordings,
+		"count":      len(recordings),
+	})
+}
 
-	if qualityStr := c.Query("quality"); qualityStr != "" {
-		if q, err := strconv.Atoi(qualityStr); err == nil && q > 0 && q <= 100 {
-			quality = q
-		}
+// getRecording returns information about a recording
+func (s *Server) getRecording(c *gin.Context) {
+	info, err := s.recorder.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
 
-	if formatStr := c.Query("format"); formatStr != "" {
-		format = formatStr
+	c.JSON(http.StatusOK, info)
+}
+
+// stopRecording stops a recording and finalizes its artifact
+func (s *Server) stopRecording(c *gin.Context) {
+	info, err := s.recorder.Stop(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
 
-	options := &types.StreamOptions{
-		FPS:      fps,
-		Quality:  quality,
-		Format:   types.ImageFormat(format),
+	c.JSON(http.StatusOK, info)
+}
+
+// getRecordingTimeline returns the metadata timeline of a recording
+func (s *Server) getRecordingTimeline(c *gin.Context) {
+	events, err := s.recorder.Timeline(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Set up the screenshot engine in the stream manager
-	s.streamManager.SetEngine(s.engine)
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
 
-	s.logger.Info("Starting WebSocket stream session",
-		zap.Int("window_id", windowID),
-		zap.Int("fps", fps),
-		zap.Int("quality", quality),
-		zap.String("format", format),
-		zap.String("client_ip", c.ClientIP()),
-	)
+// getRecordingFrame serves a single recorded frame image
+func (s *Server) getRecordingFrame(c *gin.Context) {
+	frame, err := strconv.ParseInt(c.Param("frame"), 10, 64)
+	if err != nil || frame <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid frame number"})
+		return
+	}
 
-	// Special handling: if windowID is 0, capture full desktop
-	if windowID == 0 {
-		s.logger.Info("Using desktop capture mode for window ID 0")
+	path, err := s.recorder.FramePath(c.Param("id"), frame)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Start streaming session
-	session, err := s.streamManager.StartSession(uintptr(windowID), options)
+	c.File(path)
+}
+
+// getRecordingArchive downloads a finished recording as a ZIP archive
+func (s *Server) getRecordingArchive(c *gin.Context) {
+	id := c.Param("id")
+
+	info, err := s.recorder.Get(id)
 	if err != nil {
-		s.logger.Error("Stream session failed",
-			zap.Int("window_id", windowID),
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if info.EndTime == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recording is still in progress"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", id))
+
+	if err := s.recorder.WriteArchive(id, c.Writer); err != nil {
+		s.logger.Error("Failed to write recording archive",
+			zap.String("recording_id", id),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// getRecordingOutput downloads the assembled output of a finished timelapse
+func (s *Server) getRecordingOutput(c *gin.Context) {
+	info, err := s.recorder.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if info.Output == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recording has no assembled output"})
 		return
 	}
 
-	// Set the WebSocket connection
-	session.Conn = conn
+	c.FileAttachment(info.Output, fmt.Sprintf("%s%s", info.ID, filepath.Ext(info.Output)))
+}
 
-	// Send session started message
-	err = conn.WriteJSON(map[string]interface{}{
-		"type":       "session_started",
-		"session_id": session.ID,
-		"timestamp":  time.Now(),
-	})
+// handleMCPRecordingStart handles MCP recording start requests
+func (s *Server) handleMCPRecordingStart(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		params = map[string]interface{}{}
+	}
+
+	recordingReq := types.RecordingRequest{
+		Mode:         types.RecordingMode(getString(params, "mode", "")),
+		WindowID:     uintptr(getInt(params, "window_id", 0)),
+		FPS:          getFloat64(params, "fps", 0),
+		Format:       types.ImageFormat(getString(params, "format", "")),
+		Quality:      getInt(params, "quality", 0),
+		MaxWidth:     getInt(params, "max_width", 0),
+		IncludeInput: getBool(params, "include_input", false),
+		MaxDuration:  getString(params, "max_duration", ""),
+		PlaybackFPS:  getFloat64(params, "playback_fps", 0),
+		MaxSize:      int64(getInt(params, "max_size", 0)),
+		Output:       getString(params, "output", ""),
+		Overlay:      getBool(params, "overlay", false),
+	}
+
+	options, err := recordingOptionsFromRequest(&recordingReq)
 	if err != nil {
-		s.logger.Error("Failed to send session started message", zap.Error(err))
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
 		return
 	}
 
-	// Handle WebSocket messages in a goroutine
-	go s.streamManager.HandleClientMessages(session)
+	info, err := s.recorder.Start(options)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32603, "Failed to start recording", err.Error())
+		return
+	}
 
-	// Wait for session to complete
-	<-session.Context.Done()
+	s.sendMCPResult(c, req.ID, info)
+}
 
-	s.logger.Info("WebSocket stream session ended",
-		zap.Int("window_id", windowID),
-		zap.String("client_ip", c.ClientIP()),
-	)
+// handleMCPRecordingStop handles MCP recording stop requests
+func (s *Server) handleMCPRecordingStop(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+
+	info, err := s.recorder.Stop(getString(params, "id", ""))
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	s.sendMCPResult(c, req.ID, info)
 }
 
-// getStreamStatus returns the current streaming status
-func (s *Server) getStreamStatus(c *gin.Context) {
-	stats := s.streamManager.GetStats()
-	c.JSON(http.StatusOK, gin.H{
-		"active_sessions": stats.ActiveSessions,
-		"total_sessions":  stats.TotalSessions,
-		"total_frames":    stats.TotalFrames,
-		"uptime":          stats.Uptime.String(),
-		"max_sessions":    s.config.StreamMaxSessions,
+// handleMCPRecordingList handles MCP recording list requests
+func (s *Server) handleMCPRecordingList(c *gin.Context, req *types.MCPRequest) {
+	recordings := s.recorder.List()
+	s.sendMCPResult(c, req.ID, map[string]interface{}{
+		"recordings": recordings,
+		"count":      len(recordings),
 	})
 }
 
+// handleMCPRecordingGet handles MCP requests for a recording and its timeline
+func (s *Server) handleMCPRecordingGet(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+
+	id := getString(params, "id", "")
+	info, err := s.recorder.Get(id)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	result := map[string]interface{}{
+		"recording": info,
+	}
+
+	if getBool(params, "include_timeline", false) {
+		events, err := s.recorder.Timeline(id)
+		if err != nil {
+			s.sendMCPError(c, req.ID, -32603, "Failed to read timeline", err.Error())
+			return
+		}
+		result["timeline"] = events
+	}
+
+	s.sendMCPResult(c, req.ID, result)
+}
+
 // main function
 func main() {
+	// The elevated helper is this binary relaunched with administrator rights
+	if len(os.Args) == 3 && os.Args[1] == screenshot.ElevatedHelperArg {
+		if err := screenshot.RunElevatedHelper(os.Args[2]); err != nil {
+			log.Fatal("Elevated helper failed:", err)
+		}
+		return
+	}
+
+	if len(os.Args) == 3 && os.Args[1] == openAPIArg {
+		if err := writeOpenAPI(os.Args[2]); err != nil {
+			log.Fatal("Failed to write OpenAPI document:", err)
+		}
+		return
+	}
+
 	server, err := NewServer()
 	if err != nil {
 		log.Fatal("Failed to create server:", err)
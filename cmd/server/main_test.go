@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/window"
+	"github.com/screenshot-mcp-server/internal/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newStreamTestServer serves the stream endpoint of a server over the fake desktop
+func newStreamTestServer(t *testing.T) (*Server, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	windows := window.NewFakeManager(window.FakeWindows())
+	engine := screenshot.NewFakeEngine(windows)
+
+	s := &Server{
+		engine:        engine,
+		windowManager: windows,
+		streamManager: ws.NewStreamManager(zap.NewNop()),
+		logger:        zap.NewNop(),
+	}
+	s.config.Store(DefaultConfig())
+	s.streamManager.SetEngine(engine)
+	s.streamManager.SetWindowManager(windows)
+
+	router := gin.New()
+	router.GET("/v1/stream/:windowId", s.handleWebSocketStream)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		server.Close()
+		s.streamManager.Cleanup()
+	})
+	return s, server
+}
+
+func dialStream(t *testing.T, server *httptest.Server, target string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/stream/"+target, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestWebSocketStreamBindsConnection(t *testing.T) {
+	s, server := newStreamTestServer(t)
+	conn := dialStream(t, server, "65552?fps=10")
+
+	var started map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&started))
+	require.Equal(t, "session_started", started["type"])
+	sessionID, _ := started["session_id"].(string)
+	require.NotEmpty(t, sessionID)
+
+	// The session was registered with the connection already bound
+	detail, err := s.streamManager.GetSessionDetail(sessionID)
+	require.NoError(t, err)
+	assert.True(t, detail.Connected)
+	assert.NotNil(t, detail.ConnectedAt)
+
+	for {
+		var msg ws.StreamMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg.Type == "frame" {
+			assert.Equal(t, sessionID, msg.SessionID)
+			break
+		}
+	}
+	assert.Equal(t, 1, s.streamManager.GetStats().ActiveSessions)
+}
+
+func TestWebSocketStreamRejectedOptionsLeaveNoSession(t *testing.T) {
+	s, server := newStreamTestServer(t)
+	conn := dialStream(t, server, "65552?transport=raw&security=sign")
+
+	var msg ws.StreamMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, "error", msg.Type)
+	assert.Equal(t, 0, s.streamManager.GetStats().ActiveSessions)
+}
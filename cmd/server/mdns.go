@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/screenshot-mcp-server/internal/discovery"
+	"go.uber.org/zap"
+)
+
+// startMDNS advertises the server on the local network when enabled. Failing to
+// advertise is logged rather than fatal, since the server works without it.
+func (s *Server) startMDNS() *discovery.Advertiser {
+	if !s.config.Load().MDNS {
+		return nil
+	}
+	if s.config.Load().Port == 0 {
+		s.logger.Warn("mDNS advertisement needs the HTTP server; not advertising")
+		return nil
+	}
+
+	service := discovery.Service{
+		Instance: s.config.Load().MDNSName,
+		Port:     s.config.Load().Port,
+		Text: map[string]string{
+			discovery.TextVersion: "1.0.0",
+			discovery.TextAuth:    s.config.Load().MDNSAuth,
+			discovery.TextRPCPath: "/rpc",
+		},
+	}
+	if s.config.Load().GRPCPort != 0 {
+		service.Text[discovery.TextGRPCPort] = strconv.Itoa(s.config.Load().GRPCPort)
+	}
+
+	// A server bound to one address is advertised on that address only
+	switch ip := net.ParseIP(s.config.Load().Host); {
+	case s.config.Load().Host == "localhost" || ip != nil && ip.IsLoopback():
+		s.logger.Warn("Server only listens on the loopback interface; advertised clients won't be able to connect",
+			zap.String("host", s.config.Load().Host))
+	case ip != nil && !ip.IsUnspecified():
+		service.Addrs = []net.IP{ip}
+	}
+
+	advertiser, err := discovery.Advertise(service)
+	if err != nil {
+		s.logger.Warn("Failed to start mDNS advertisement", zap.Error(err))
+		return nil
+	}
+
+	advertised := advertiser.Service()
+	s.logger.Info("Advertising server over mDNS",
+		zap.String("service", discovery.ServiceType),
+		zap.String("instance", advertised.Instance),
+		zap.String("host", advertised.Host),
+		zap.Int("port", advertised.Port),
+	)
+	return advertiser
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// getMetrics serves the server's resource counters in the Prometheus text format
+func (s *Server) getMetrics(c *gin.Context) {
+	var out bytes.Buffer
+	usage := screenshot.GDIHandleUsage()
+
+	counters := []struct {
+		name, help, kind string
+		value            func(screenshot.GDIUsage) int64
+	}{
+		{"screenshot_gdi_handles_created_total", "GDI handles created by the capture engine", "counter",
+			func(u screenshot.GDIUsage) int64 { return int64(u.Created) }},
+		{"screenshot_gdi_handles_released_total", "GDI handles released by the capture engine", "counter",
+			func(u screenshot.GDIUsage) int64 { return int64(u.Released) }},
+		{"screenshot_gdi_handles_outstanding", "GDI handles created but not released yet", "gauge",
+			screenshot.GDIUsage.Outstanding},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n", counter.name, counter.help, counter.name, counter.kind)
+		for _, u := range usage {
+			fmt.Fprintf(&out, "%s{subsystem=%q,kind=%q} %d\n", counter.name, u.Subsystem, u.Kind, counter.value(u))
+		}
+	}
+
+	if objects, err := screenshot.ProcessGDIObjects(); err == nil {
+		fmt.Fprintf(&out, "# HELP screenshot_process_gdi_objects GDI objects held by the server process\n")
+		fmt.Fprintf(&out, "# TYPE screenshot_process_gdi_objects gauge\nscreenshot_process_gdi_objects %d\n", objects)
+	}
+
+	stats := s.streamManager.GetStats()
+	fmt.Fprintf(&out, "# HELP screenshot_stream_sessions_active Active streaming sessions\n")
+	fmt.Fprintf(&out, "# TYPE screenshot_stream_sessions_active gauge\nscreenshot_stream_sessions_active %d\n", stats.ActiveSessions)
+
+	scheduled := s.scheduler.Stats()
+	fmt.Fprintf(&out, "# HELP screenshot_capture_slots Captures that may run at once\n")
+	fmt.Fprintf(&out, "# TYPE screenshot_capture_slots gauge\nscreenshot_capture_slots %d\n", scheduled.Limit)
+	fmt.Fprintf(&out, "# HELP screenshot_captures_running Captures running\n")
+	fmt.Fprintf(&out, "# TYPE screenshot_captures_running gauge\nscreenshot_captures_running %d\n", scheduled.Running)
+	fmt.Fprintf(&out, "# HELP screenshot_captures_waiting Captures waiting for a slot\n# TYPE screenshot_captures_waiting gauge\n")
+	for _, priority := range []types.CapturePriority{types.PriorityInteractive, types.PriorityScheduled, types.PriorityStream} {
+		fmt.Fprintf(&out, "screenshot_captures_waiting{priority=%q} %d\n", priority, scheduled.Waiting[priority.String()])
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", out.Bytes())
+}
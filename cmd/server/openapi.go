@@ -0,0 +1,370 @@
+package main
+
+//go:generate go run . --openapi ../../docs/openapi.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/openapi"
+	"github.com/screenshot-mcp-server/internal/ws"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// openAPIArg makes the server write its OpenAPI document to a file and exit; `go
+// generate` uses it to refresh docs/openapi.json for client generators
+const openAPIArg = "--openapi"
+
+// apiError is the body of a failed REST call
+type apiError struct {
+	Error        string             `json:"error"`
+	Code         types.ErrorCode    `json:"code,omitempty"`
+	Candidates   []types.WindowInfo `json:"candidates,omitempty"`    // Windows an ambiguous title matched
+	DesktopState types.DesktopState `json:"desktop_state,omitempty"` // Why the desktop can't be captured
+}
+
+// Response bodies of handlers that reply with gin.H
+type (
+	healthResponse struct {
+		Status       string              `json:"status"`
+		Timestamp    time.Time           `json:"timestamp"`
+		Version      string              `json:"version"`
+		DesktopState types.DesktopState  `json:"desktop_state"`
+		Activity     types.ActivityState `json:"activity"`
+		Power        types.PowerState    `json:"power"`
+	}
+	windowListResponse struct {
+		Windows []types.WindowInfo `json:"windows"`
+		Count   int                `json:"count"`
+	}
+	chromeInstancesResponse struct {
+		Instances []types.ChromeInstance `json:"instances"`
+		Count     int                    `json:"count"`
+	}
+	chromeTabsResponse struct {
+		Tabs  []types.ChromeTab `json:"tabs"`
+		Count int               `json:"count"`
+	}
+	chromeFramesResponse struct {
+		Frames []types.ChromeFrame `json:"frames"`
+		Count  int                 `json:"count"`
+	}
+	streamStatusResponse struct {
+		ActiveSessions int                        `json:"active_sessions"`
+		TotalSessions  int                        `json:"total_sessions"`
+		TotalFrames    int64                      `json:"total_frames"`
+		Uptime         string                     `json:"uptime"`
+		MaxSessions    int                        `json:"max_sessions"`
+		Sessions       []types.StreamSessionStats `json:"sessions"`
+		Activity       types.ActivityState        `json:"activity"`
+		Power          types.PowerState           `json:"power"`
+	}
+	recordingListResponse struct {
+		Recordings []types.RecordingInfo `json:"recordings"`
+		Count      int                   `json:"count"`
+	}
+	timelineResponse struct {
+		Events []types.TimelineEvent `json:"events"`
+		Count  int                   `json:"count"`
+	}
+)
+
+// Query parameters shared by GET screenshot routes
+var screenshotQuery = []openapi.Param{
+	{Name: "method", Description: "Window lookup method (default title); alias for a target alias"},
+	{Name: "target", Description: "Title, PID, process name, handle, class or alias name; not needed for foreground and under_cursor"},
+	{Name: "format", Enum: []string{"png", "jpeg", "bmp"}},
+	{Name: "quality", Type: "integer", Description: "JPEG quality (1-100)"},
+	{Name: "cursor", Type: "boolean", Description: "Include the mouse cursor"},
+	{Name: "match", Description: "\"best\" picks the top-ranked window for ambiguous title lookups"},
+	{Name: "top_level", Type: "boolean", Description: "under_cursor: capture the top-level window"},
+	{Name: "region", Description: "x,y,width,height"},
+	{Name: "region_relative_to", Enum: []string{"window", "client", "screen"}},
+	{Name: "capture_method", Description: "Force a capture method"},
+	{Name: "fallback_methods", Description: "Comma-separated methods tried after capture_method"},
+	{Name: "retry_count", Type: "integer"},
+	{Name: "retry_backoff", Description: "Duration of the first retry delay, e.g. 100ms"},
+	{Name: "reject_black_frames", Type: "boolean"},
+	{Name: "capture_other_desktops", Type: "boolean"},
+	{Name: "capture_ghost", Type: "boolean"},
+	{Name: "sync_to_present", Type: "boolean"},
+	{Name: "include_owned_windows", Type: "boolean"},
+	{Name: "max_response_bytes", Type: "integer", Description: "Shrink the image until the response fits in this many bytes"},
+	{Name: "pipeline", Description: "Post-processing stages as a JSON array, replacing the server default"},
+	{Name: "wait_for_stable", Description: "\"true\" or a JSON object: recapture until the window stops changing"},
+	{Name: "color_management", Enum: []string{"none", "embed", "srgb"}, Description: "How to treat the color profile of the capture's monitor"},
+}
+
+// Query parameters of the window list routes
+var windowListQuery = []openapi.Param{
+	{Name: "title_contains"},
+	{Name: "visible_only", Type: "boolean"},
+	{Name: "exclude_system", Type: "boolean"},
+	{Name: "virtual_desktop", Description: "\"current\", \"other\" or a virtual desktop ID"},
+}
+
+var iconSizeQuery = []openapi.Param{{Name: "size", Enum: []string{"small", "large"}}}
+
+// apiRoutes documents the REST routes, keyed by method and router path
+var apiRoutes = []openapi.Route{
+	{Method: "GET", Path: "/health", OperationID: "getHealth", Tag: "System", Summary: "Server health", Response: healthResponse{}},
+	{Method: "GET", Path: "/health/ready", OperationID: "getReadiness", Tag: "System", Summary: "Server readiness",
+		Description: "Probes capture, window enumeration, monitors, DWM composition and Chrome discovery. Responds 503 with the same body when a required capability fails.",
+		Response:    types.ReadinessReport{}},
+	{Method: "GET", Path: "/v1/diagnostics", OperationID: "getDiagnostics", Tag: "System", Summary: "Self-test and download a diagnostics bundle",
+		Description: "Captures a test window with each capture method and returns a ZIP of the results and captures, system and display information, readiness probes and recent capture errors, to attach to bug reports",
+		ContentType: "application/zip"},
+	{Method: "GET", Path: "/v1/capture/rankings", OperationID: "listMethodRankings", Tag: "System", Summary: "Capture method order learned per window class",
+		Description: "Each capture method's success rate and average latency over its last 50 attempts on windows of each class. Fallbacks are tried best first once a method has 5 attempts on a class; adaptive is false for engines without fallbacks",
+		Response:    rankingsResponse{}},
+	{Method: "GET", Path: "/v1/plugins", OperationID: "listPlugins", Tag: "System", Summary: "Plugins with their MCP tools and pipeline stages",
+		Description: "Plugins are started from the plugins directory (SCREENSHOT_PLUGIN_DIR) at startup. A plugin that failed to start or has exited is listed with its error",
+		Response:    pluginListResponse{}},
+	{Method: "GET", Path: "/metrics", OperationID: "getMetrics", Tag: "System", Summary: "Resource counters in the Prometheus text format",
+		Description: "GDI handles created, released and outstanding per capture subsystem and kind, the GDI objects of the process on Windows, and the active streaming sessions",
+		ContentType: "text/plain"},
+	{Method: "GET", Path: "/openapi.json", OperationID: "getOpenAPI", Tag: "System", Summary: "This OpenAPI document", ContentType: "application/json"},
+
+	{Method: "POST", Path: "/v1/screenshot", OperationID: "takeScreenshot", Tag: "Screenshots", Summary: "Capture a window, shell surface or the desktop",
+		Description: "Responses carry an ETag of the request and what the capture looks like. With If-None-Match (or if_none_match) naming it, an unchanged capture is answered with 304 Not Modified", Request: types.ScreenshotRequest{}, Response: types.ScreenshotResponse{}},
+	{Method: "GET", Path: "/v1/screenshot", OperationID: "takeScreenshotGET", Tag: "Screenshots", Summary: "Capture a window with query parameters",
+		Description: "Responses carry an ETag; with If-None-Match naming it, an unchanged capture is answered with 304 Not Modified", Query: screenshotQuery, Response: types.ScreenshotResponse{}},
+	{Method: "POST", Path: "/v1/screenshot/batch", OperationID: "takeScreenshotBatch", Tag: "Screenshots", Summary: "Capture several windows, possibly on several machines",
+		Description: "Requests run concurrently; targets of the form \"machine:window\" run on a federated target. A failed request fails only its own result",
+		Request:     types.BatchScreenshotRequest{}, Response: types.BatchScreenshotResponse{}},
+	{Method: "POST", Path: "/v1/screenshot/scrolling", OperationID: "takeScrollingScreenshot", Tag: "Screenshots", Summary: "Capture a window's scrollable content into one tall image",
+		Description: "Scrolls the window down with the mouse wheel or Page Down, capturing it after each scroll, and stitches the captures where they overlap. Requires SCREENSHOT_ALLOW_INPUT=true",
+		Request:     types.ScrollCaptureRequest{}, Response: types.ScrollCaptureResponse{}},
+	{Method: "GET", Path: "/v1/capture-sets", OperationID: "listCaptureSets", Tag: "Screenshots", Summary: "List capture sets", Response: captureSetListResponse{}},
+	{Method: "GET", Path: "/v1/capture-sets/:name", OperationID: "getCaptureSet", Tag: "Screenshots", Summary: "Get a capture set", Response: types.CaptureSet{}},
+	{Method: "PUT", Path: "/v1/capture-sets/:name", OperationID: "putCaptureSet", Tag: "Screenshots", Summary: "Create or replace a capture set",
+		Description: "Members are screenshot requests that capture right away: wait_for, wait_for_stable, popups and if_none_match aren't allowed. Responds 201 for a new set",
+		Request:     types.CaptureSet{}, Response: types.CaptureSet{}},
+	{Method: "DELETE", Path: "/v1/capture-sets/:name", OperationID: "deleteCaptureSet", Tag: "Screenshots", Summary: "Delete a capture set", Status: http.StatusNoContent},
+	{Method: "POST", Path: "/v1/capture-sets/:name/capture", OperationID: "captureCaptureSet", Tag: "Screenshots", Summary: "Capture a capture set's targets at the same instant",
+		Description: "The members share one capture slot and start together; the response carries the instant they started and the spread between the earliest and latest capture. A failed member fails only its own result",
+		Response:    types.CaptureSetResponse{}},
+	{Method: "GET", Path: "/v1/webcam", OperationID: "getWebcam", Tag: "Streaming", Summary: "Get the virtual webcam's status", Response: types.WebcamStatus{}},
+	{Method: "POST", Path: "/v1/webcam", OperationID: "startWebcam", Tag: "Streaming", Summary: "Show a window or monitor on the virtual webcam",
+		Description: "Windows only; needs softcam's DirectShow filter registered with regsvr32. Frames are scaled to the camera's size with black bars. Responds 409 while the webcam runs and 503 when softcam can't be loaded",
+		Request:     types.WebcamRequest{}, Response: types.WebcamStatus{}, Status: http.StatusCreated},
+	{Method: "DELETE", Path: "/v1/webcam", OperationID: "stopWebcam", Tag: "Streaming", Summary: "Stop the virtual webcam", Response: types.WebcamStatus{}},
+	{Method: "GET", Path: "/v1/outputs", OperationID: "listOutputs", Tag: "Streaming", Summary: "List RTMP outputs", Response: outputListResponse{}},
+	{Method: "POST", Path: "/v1/outputs", OperationID: "startOutput", Tag: "Streaming", Summary: "Publish a window or monitor to an RTMP server",
+		Description: "Needs ffmpeg on PATH. Frames are scaled to the output's size with black bars and encoded as H.264 with a silent audio track. The status masks the stream key. Responds 503 when ffmpeg can't be started",
+		Request:     types.OutputRequest{}, Response: types.OutputStatus{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/outputs/:id", OperationID: "getOutput", Tag: "Streaming", Summary: "Get an RTMP output's status", Response: types.OutputStatus{}},
+	{Method: "DELETE", Path: "/v1/outputs/:id", OperationID: "stopOutput", Tag: "Streaming", Summary: "Stop an RTMP output and remove it from the list", Response: types.OutputStatus{}},
+	{Method: "GET", Path: "/v1/jobs", OperationID: "listJobs", Tag: "Screenshots", Summary: "List the captures, OCR calls and recordings in flight", Response: jobListResponse{}},
+	{Method: "DELETE", Path: "/v1/jobs/:id", OperationID: "cancelJob", Tag: "Screenshots", Summary: "Cancel a job",
+		Description: "The cancelled call fails with code CANCELLED (HTTP 499, MCP error -32800). Screenshot, batch, scrolling, capture set and workflow calls are jobs, named by the X-Job-ID request header or given an ID sent back in it; MCP calls are jobs named by their JSON-RPC ID. Cancelling a recording stops it",
+		Status:      http.StatusNoContent},
+	{Method: "GET", Path: "/v1/workflows", OperationID: "listWorkflows", Tag: "Screenshots", Summary: "List workflow scripts with their parameters",
+		Description: "Workflows are <name>.lua scripts of the workflows directory (SCREENSHOT_WORKFLOW_DIR); scripts that don't load are listed in errors",
+		Response:    workflowListResponse{}},
+	{Method: "POST", Path: "/v1/workflows/:name", OperationID: "runWorkflow", Tag: "Screenshots", Summary: "Run a workflow script",
+		Description: "Calls the script's run function with the params, which focuses windows, waits and captures them with regions redacted, and returns its captures. A failed call fails the run with the script line in the error",
+		Request:     types.WorkflowRunRequest{}, Response: types.WorkflowRunResponse{}},
+	{Method: "POST", Path: "/v1/macros", OperationID: "recordMacro", Tag: "Screenshots", Summary: "Start recording a macro of the user's input",
+		Description: "Records clicks, key presses and typing, with screenshots of the window at the start, every interval and at the end, until stopped. Requires SCREENSHOT_ALLOW_INPUT=true; one macro is recorded at a time",
+		Request:     types.MacroRecordRequest{}, Response: macroStatusResponse{}, Status: http.StatusAccepted},
+	{Method: "POST", Path: "/v1/macros/:name/stop", OperationID: "stopMacro", Tag: "Screenshots", Summary: "Stop recording a macro and save it as a workflow",
+		Description: "Writes <name>.lua and its screenshots, in <name>.frames, to the workflows directory",
+		Response:    types.MacroResult{}},
+	{Method: "POST", Path: "/v1/macros/:name/play", OperationID: "playMacro", Tag: "Screenshots", Summary: "Replay a macro",
+		Description: "Runs the macro's workflow, replaying its input; each capture reports the share of pixels that differ from the recorded screenshot",
+		Request:     types.WorkflowRunRequest{}, Response: types.WorkflowRunResponse{}},
+	{Method: "GET", Path: "/v1/shell/:surface", OperationID: "takeShellScreenshot", Tag: "Screenshots", Summary: "Capture the taskbar, tray overflow or latest notification",
+		Description: "surface is taskbar, tray_overflow or notifications",
+		Query:       []openapi.Param{{Name: "format", Enum: []string{"png", "jpeg", "bmp"}}, {Name: "cursor", Type: "boolean"}},
+		Response:    types.ScreenshotResponse{}},
+
+	{Method: "GET", Path: "/v1/windows", OperationID: "listWindows", Tag: "Windows", Summary: "List top-level windows", Query: windowListQuery, Response: windowListResponse{}},
+	{Method: "GET", Path: "/v1/windows/:handle", OperationID: "getWindow", Tag: "Windows", Summary: "Window details (not yet implemented)"},
+	{Method: "GET", Path: "/v1/windows/:handle/icon", OperationID: "getWindowIcon", Tag: "Windows", Summary: "Window icon as PNG", Query: iconSizeQuery, ContentType: "image/png"},
+	{Method: "GET", Path: "/v1/processes/:pid/icon", OperationID: "getProcessIcon", Tag: "Windows", Summary: "Process icon as PNG", Query: iconSizeQuery, ContentType: "image/png"},
+	{Method: "GET", Path: "/v1/tray", OperationID: "listTrayApps", Tag: "Windows", Summary: "Windows of processes with notification area icons", Response: windowListResponse{}},
+
+	{Method: "GET", Path: "/v1/chrome/instances", OperationID: "listChromeInstances", Tag: "Chrome", Summary: "List Chrome instances with remote debugging", Response: chromeInstancesResponse{}},
+	{Method: "GET", Path: "/v1/chrome/tabs", OperationID: "listChromeTabs", Tag: "Chrome", Summary: "List tabs of all Chrome instances", Response: chromeTabsResponse{}},
+	{Method: "POST", Path: "/v1/chrome/tabs/:id/screenshot", OperationID: "takeChromeTabScreenshot", Tag: "Chrome", Summary: "Capture a Chrome tab",
+		Description: "With frames=separate the response holds the tab screenshot and one per out-of-process iframe",
+		Query:       []openapi.Param{{Name: "frames", Enum: []string{"composite", "separate"}}},
+		Response:    types.ScreenshotResponse{}},
+	{Method: "GET", Path: "/v1/chrome/tabs/:id/frames", OperationID: "listChromeTabFrames", Tag: "Chrome", Summary: "List out-of-process iframes of a tab", Response: chromeFramesResponse{}},
+
+	{Method: "GET", Path: "/v1/stream/:windowId", OperationID: "streamWindow", Tag: "Streaming", Summary: "Stream a window over WebSocket",
+		Description: "Upgrades to a WebSocket carrying JSON frame messages; window 0 streams the desktop, monitor:N one display, active whichever window is in the foreground and alias:{name} the window of a target alias",
+		Query: []openapi.Param{
+			{Name: "fps", Type: "integer"}, {Name: "quality", Type: "integer"}, {Name: "format", Enum: []string{"png", "jpeg"}},
+			{Name: "ack", Type: "boolean"}, {Name: "max_unacked", Type: "integer"},
+			{Name: "detail_interval", Type: "integer", Description: "Encode every Nth frame at detail_quality and the others at quality"},
+			{Name: "detail_quality", Type: "integer", Description: "Quality of detail frames (default 95)"},
+			{Name: "region", Description: "Stream only the x,y,width,height part of the window or display; move it with set_region"},
+			{Name: "cursor_zoom", Description: "Stream a widthxheight region that follows the cursor"},
+			{Name: "cursor_smoothing", Type: "number", Description: "How slowly the cursor zoom follows the cursor, 0 (instantly) to 0.95 (default 0.5)"},
+			{Name: "pip", Description: "Comma-separated window[:position[:scale]] list of windows composited picture-in-picture into the frames"},
+			{Name: "overlay", Type: "boolean", Description: "Burn the capture time, window title, session ID and achieved FPS into each frame"},
+			{Name: "privacy", Description: "Desktop and monitor streams: blur or black to hide every window not in allow"},
+			{Name: "allow", Description: "Comma-separated IDs of the windows privacy leaves visible"},
+			{Name: "record", Type: "boolean", Description: "Tee the delivered frames into a recording"},
+			{Name: "record_output", Enum: []string{"frames", "mp4"}},
+			{Name: "session_id", Description: "Resume a detached session"}, {Name: "resume_token"},
+			{Name: "watch", Description: "Join a session as a view-only viewer"},
+			{Name: "security", Enum: []string{"none", "sign", "encrypt"}, Description: "Sign or encrypt frames with the server's stream key"},
+			{Name: "game", Type: "boolean", Description: "Duplicate the full-screen game's monitor, synced to its frames"},
+			{Name: "transport", Enum: []string{"json", "raw", "shm"}, Description: "raw sends frames as unencoded BGRA pixels in binary messages, shm writes them to a shared-memory ring"},
+		},
+		Status: http.StatusSwitchingProtocols, Response: ws.StreamMessage{}},
+	{Method: "GET", Path: "/v1/stream/status", OperationID: "getStreamStatus", Tag: "Streaming", Summary: "Streaming statistics", Response: streamStatusResponse{}},
+	{Method: "GET", Path: "/v1/stats", OperationID: "getUsageStats", Tag: "Streaming", Summary: "Cumulative capture and stream usage", Response: types.UsageStats{}},
+	{Method: "GET", Path: "/v1/events", OperationID: "subscribeEvents", Tag: "Streaming", Summary: "Subscribe to server events over WebSocket", Status: http.StatusSwitchingProtocols, Response: ws.StreamMessage{}},
+
+	{Method: "POST", Path: "/v1/recordings", OperationID: "startRecording", Tag: "Recordings", Summary: "Start a recording", Request: types.RecordingRequest{}, Response: types.RecordingInfo{}, Status: http.StatusCreated},
+	{Method: "GET", Path: "/v1/recordings", OperationID: "listRecordings", Tag: "Recordings", Summary: "List recordings", Response: recordingListResponse{}},
+	{Method: "GET", Path: "/v1/recordings/:id", OperationID: "getRecording", Tag: "Recordings", Summary: "Get a recording", Response: types.RecordingInfo{}},
+	{Method: "POST", Path: "/v1/recordings/:id/stop", OperationID: "stopRecording", Tag: "Recordings", Summary: "Stop a recording", Response: types.RecordingInfo{}},
+	{Method: "GET", Path: "/v1/recordings/:id/timeline", OperationID: "getRecordingTimeline", Tag: "Recordings", Summary: "Metadata timeline of a recording", Response: timelineResponse{}},
+	{Method: "GET", Path: "/v1/recordings/:id/frames/:frame", OperationID: "getRecordingFrame", Tag: "Recordings", Summary: "A recorded frame image", ContentType: "application/octet-stream"},
+	{Method: "GET", Path: "/v1/recordings/:id/archive", OperationID: "getRecordingArchive", Tag: "Recordings", Summary: "Download a finished recording as ZIP", ContentType: "application/zip"},
+	{Method: "GET", Path: "/v1/recordings/:id/output", OperationID: "getRecordingOutput", Tag: "Recordings", Summary: "Download a timelapse's assembled GIF or video", ContentType: "application/octet-stream"},
+
+	{Method: "GET", Path: "/v1/admin/sessions", OperationID: "listAdminSessions", Tag: "Admin", Summary: "List stream sessions with their clients",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)", Response: adminSessionsResponse{}},
+	{Method: "GET", Path: "/v1/admin/sessions/:id", OperationID: "getAdminSession", Tag: "Admin", Summary: "Inspect a stream session",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)", Response: types.StreamSessionStats{}},
+	{Method: "DELETE", Path: "/v1/admin/sessions/:id", OperationID: "closeAdminSession", Tag: "Admin", Summary: "Force-close a stream session",
+		Description: "Sends the client a WebSocket close frame (1008) with the reason; the session can't be resumed. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		Query:       []openapi.Param{{Name: "reason", Description: "Close frame reason shown to the client"}},
+		Response:    types.StreamSessionStats{}},
+	{Method: "GET", Path: "/v1/admin/loglevel", OperationID: "getLogLevel", Tag: "Admin", Summary: "Current log level",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)", Response: logLevelResponse{}},
+	{Method: "PUT", Path: "/v1/admin/loglevel", OperationID: "setLogLevel", Tag: "Admin", Summary: "Change the log level at runtime",
+		Description: "Applies to every log sink until the server restarts. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		Request:     logLevelRequest{}, Response: logLevelResponse{}},
+	{Method: "GET", Path: "/v1/admin/debug/pprof/", OperationID: "listProfiles", Tag: "Admin", Summary: "List the pprof profiles of the running server",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)", ContentType: "text/html"},
+	{Method: "GET", Path: "/v1/admin/debug/pprof/:profile", OperationID: "getProfile", Tag: "Admin", Summary: "Take a pprof profile or execution trace",
+		Description: "profile is a net/http/pprof name: profile (CPU), trace, heap, allocs, goroutine, block, mutex, threadcreate, cmdline or symbol. CPU samples carry a stage label, such as stream;encode;convert, and capture stages are regions of execution traces. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		Query: []openapi.Param{
+			{Name: "seconds", Type: "integer", Description: "Duration of CPU profiles and traces, or of a delta profile"},
+			{Name: "debug", Type: "integer", Description: "1 or 2 for a text profile"},
+			{Name: "gc", Type: "integer", Description: "1 runs a garbage collection before a heap profile"},
+		},
+		ContentType: "application/octet-stream"},
+	{Method: "GET", Path: "/v1/admin/debug/flame", OperationID: "getFlame", Tag: "Admin", Summary: "Time spent in each capture stage as folded stacks",
+		Description: "One \"stack microseconds\" line per stack of capture, process, encode (convert, compress) and send stages of screenshots and streams, counting its own time, for flamegraph.pl, speedscope or inferno. Totals accumulate since startup or the last DELETE. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		ContentType: "text/plain"},
+	{Method: "DELETE", Path: "/v1/admin/debug/flame", OperationID: "resetFlame", Tag: "Admin", Summary: "Clear the capture stage totals",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/v1/admin/debug/rpc", OperationID: "listRPCExchanges", Tag: "Admin", Summary: "Recent MCP requests and responses",
+		Description: "Available when the server runs with SCREENSHOT_DEBUG_RPC=true. Keeps the last 200 exchanges of every MCP transport with long strings such as image data truncated and tokens, passwords and secrets redacted. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		Response:    rpcDebugResponse{}},
+	{Method: "DELETE", Path: "/v1/admin/debug/rpc", OperationID: "clearRPCExchanges", Tag: "Admin", Summary: "Drop the recorded MCP requests and responses",
+		Description: "Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)"},
+	{Method: "GET", Path: "/v1/admin/errors", OperationID: "listErrors", Tag: "Admin", Summary: "Recent capture errors and recovered panics",
+		Description: "Panics of capture goroutines and handlers are recovered and listed with their stack and context, including those of earlier runs read from the crash log. Requires the admin bearer token (SCREENSHOT_ADMIN_TOKEN)",
+		Response:    errorsResponse{}},
+
+	{Method: "GET", Path: "/v1/agents/connect", OperationID: "connectAgent", Tag: "Agents", Summary: "Connect a capture agent",
+		Description: "Upgrades to the WebSocket a capture agent (SCREENSHOT_RELAY_URL) registers and serves forwarded requests over. Requires the relay bearer token (SCREENSHOT_RELAY_TOKEN)",
+		Status:      http.StatusSwitchingProtocols},
+	{Method: "GET", Path: "/v1/agents", OperationID: "listAgents", Tag: "Agents", Summary: "List connected capture agents", Response: agentListResponse{}},
+	{Method: "GET", Path: "/v1/agents/:id", OperationID: "getAgent", Tag: "Agents", Summary: "Get a connected capture agent",
+		Description: "The agent's API, streams included, is served under /v1/agents/{id}/api, e.g. GET /v1/agents/{id}/api/v1/windows",
+		Response:    types.AgentInfo{}},
+	{Method: "GET", Path: "/v1/targets", OperationID: "listTargets", Tag: "Agents", Summary: "List federated targets and target aliases",
+		Description: "The servers of SCREENSHOT_TARGETS and the connected agents, which captures can be addressed to as \"name:window\", followed by the target aliases",
+		Query:       []openapi.Param{{Name: "check", Type: "boolean", Description: "Check each server's and agent's health"}},
+		Response:    targetListResponse{}},
+	{Method: "GET", Path: "/v1/targets/:name", OperationID: "getTargetAlias", Tag: "Agents", Summary: "Get a target alias", Response: types.TargetAlias{}},
+	{Method: "PUT", Path: "/v1/targets/:name", OperationID: "putTargetAlias", Tag: "Agents", Summary: "Create or replace a target alias",
+		Description: "Requests capture the alias with method \"alias\" and its name as target, and streams with the target alias:{name}. Its capture method, format and quality apply where a request sets none, and its pipeline, such as redactions, runs ahead of the request's own. Responds 201 for a new alias",
+		Request:     types.TargetAlias{}, Response: types.TargetAlias{}},
+	{Method: "DELETE", Path: "/v1/targets/:name", OperationID: "deleteTargetAlias", Tag: "Agents", Summary: "Delete a target alias", Status: http.StatusNoContent},
+
+	{Method: "POST", Path: "/rpc", OperationID: "callMCP", Tag: "MCP", Summary: "MCP JSON-RPC 2.0 request", Request: types.MCPRequest{}, Response: types.MCPResponse{}},
+	{Method: "GET", Path: "/sse", OperationID: "openMCPSession", Tag: "MCP", Summary: "Open an MCP Server-Sent Events session", ContentType: "text/event-stream"},
+	{Method: "POST", Path: "/messages", OperationID: "postMCPMessage", Tag: "MCP", Summary: "Post a JSON-RPC message to an SSE session",
+		Query: []openapi.Param{{Name: "sessionId", Required: true}}, Request: types.MCPRequest{}, Status: http.StatusAccepted},
+}
+
+// Routes left out of the document
+var undocumentedRoutes = map[string]bool{
+	"GET /":                 true,
+	"GET /docs/*filepath":   true,
+	"HEAD /docs/*filepath":  true,
+	"GET /stream/:windowId": true, // Alias of /v1/stream/:windowId
+
+	// Redirects to /v1/admin/debug/pprof/
+	"GET /v1/admin/debug/pprof": true,
+
+	// Forwarded to an agent's own API
+	"GET /v1/agents/:id/api/*path":    true,
+	"POST /v1/agents/:id/api/*path":   true,
+	"DELETE /v1/agents/:id/api/*path": true,
+}
+
+// openAPIDocument describes the routes registered on the router
+func (s *Server) openAPIDocument() *openapi.Document {
+	generator := openapi.New(openapi.Info{
+		Title:       "Screenshot MCP Server",
+		Description: "Window and desktop capture, Chrome tab capture, streaming and recordings",
+		Version:     "1.0.0",
+	}, openapi.Server{URL: fmt.Sprintf("http://%s:%d", s.config.Load().Host, s.config.Load().Port)})
+
+	docs := make(map[string]openapi.Route, len(apiRoutes))
+	for _, route := range apiRoutes {
+		docs[route.Method+" "+route.Path] = route
+		generator.AddTag(openapi.Tag{Name: route.Tag})
+	}
+
+	for _, info := range s.router.Routes() {
+		key := info.Method + " " + info.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+		route, ok := docs[key]
+		if !ok && strings.HasPrefix(info.Path, "/api/") {
+			// The /api routes are older aliases of /v1 routes and /health
+			rest := strings.TrimPrefix(info.Path, "/api")
+			if route, ok = docs[info.Method+" /v1"+rest]; !ok {
+				route, ok = docs[info.Method+" "+rest]
+			}
+			route.Deprecated = ok
+			route.OperationID = "" // IDs must be unique; aliases get path-derived ones
+		}
+		if !ok {
+			route = openapi.Route{Method: info.Method}
+		}
+		route.Path = info.Path
+		generator.Add(route, apiError{})
+	}
+	return generator.Document()
+}
+
+// getOpenAPI serves the OpenAPI document of the REST API
+func (s *Server) getOpenAPI(c *gin.Context) {
+	s.openAPIOnce.Do(func() {
+		s.openAPISpec = s.openAPIDocument()
+	})
+	c.JSON(http.StatusOK, s.openAPISpec)
+}
+
+// writeOpenAPI writes the OpenAPI document of a default-configured server to a file
+func writeOpenAPI(path string) error {
+	server := &Server{}
+	server.config.Store(DefaultConfig())
+	server.setupRouter()
+
+	data, err := json.MarshalIndent(server.openAPIDocument(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
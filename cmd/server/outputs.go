@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/publish"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// outputListResponse is the response of GET /v1/outputs
+type outputListResponse struct {
+	Outputs []types.OutputStatus `json:"outputs"`
+}
+
+// startOutput validates a request and starts publishing its target to an RTMP server
+func (s *Server) startOutput(req types.OutputRequest) (types.OutputStatus, error) {
+	if err := publish.ValidateRequest(&req); err != nil {
+		return types.OutputStatus{}, invalidRequest(err)
+	}
+	capture, err := s.targetCapture(req.Target)
+	if err != nil {
+		return types.OutputStatus{}, err
+	}
+	return s.outputs.Start(req, capture)
+}
+
+// getOutputs lists the RTMP outputs
+func (s *Server) getOutputs(c *gin.Context) {
+	c.JSON(http.StatusOK, outputListResponse{Outputs: s.outputs.List()})
+}
+
+// getOutput returns an RTMP output's status
+func (s *Server) getOutput(c *gin.Context) {
+	status, err := s.outputs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// postOutput starts an RTMP output
+func (s *Server) postOutput(c *gin.Context) {
+	var req types.OutputRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	status, err := s.startOutput(req)
+	var captureErr *types.CaptureError
+	switch {
+	case errors.As(err, &captureErr):
+		sendCaptureError(c, err)
+		return
+	case err != nil:
+		// ffmpeg isn't on PATH or failed to start
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, status)
+}
+
+// deleteOutput stops an RTMP output and removes it from the list
+func (s *Server) deleteOutput(c *gin.Context) {
+	status, err := s.outputs.Stop(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// handleMCPOutputStart handles MCP RTMP output start requests
+func (s *Server) handleMCPOutputStart(c *gin.Context, req *types.MCPRequest) {
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	var outputReq types.OutputRequest
+	if err := json.Unmarshal(data, &outputReq); err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	status, err := s.startOutput(outputReq)
+	if err != nil {
+		var captureErr *types.CaptureError
+		if errors.As(err, &captureErr) {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPError(c, req.ID, -32603, "Failed to start RTMP output", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, status)
+}
+
+// handleMCPOutputStop handles MCP RTMP output stop requests
+func (s *Server) handleMCPOutputStop(c *gin.Context, req *types.MCPRequest) {
+	params, ok := req.Params.(map[string]interface{})
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", nil)
+		return
+	}
+	status, err := s.outputs.Stop(getString(params, "id", ""))
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, status)
+}
+
+// handleMCPOutputList handles MCP RTMP output list requests
+func (s *Server) handleMCPOutputList(c *gin.Context, req *types.MCPRequest) {
+	s.sendMCPResult(c, req.ID, outputListResponse{Outputs: s.outputs.List()})
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MCP over a named pipe: a local MCP host connects to the pipe and exchanges
+// newline-delimited JSON-RPC messages, each handled exactly like POST /rpc, so the
+// server can be used without any TCP port.
+
+const (
+	// maxPipeMessage bounds a JSON-RPC message read from the pipe
+	maxPipeMessage = 1 << 20
+	// pipeAcceptRetryDelay spaces out retries when accepting a connection fails
+	pipeAcceptRetryDelay = time.Second
+)
+
+// pipeListener accepts connections on a named pipe
+type pipeListener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// mcpPipeServer serves MCP on a pipe listener and tracks its connections for shutdown
+type mcpPipeServer struct {
+	listener pipeListener
+	conns    map[io.ReadWriteCloser]struct{}
+	mu       sync.Mutex
+	closed   bool
+}
+
+// startPipe serves MCP on the configured named pipe; it returns nil when the name is empty
+func (s *Server) startPipe() (*mcpPipeServer, error) {
+	if s.config.Load().PipeName == "" {
+		return nil, nil
+	}
+
+	listener, err := listenPipe(s.config.Load().PipeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.config.Load().PipeName, err)
+	}
+
+	pipe := &mcpPipeServer{listener: listener, conns: make(map[io.ReadWriteCloser]struct{})}
+	s.logger.Info("Starting MCP named pipe transport", zap.String("pipe", s.config.Load().PipeName))
+	go s.servePipe(pipe)
+	return pipe, nil
+}
+
+// servePipe accepts pipe connections until the listener is closed
+func (s *Server) servePipe(pipe *mcpPipeServer) {
+	for {
+		conn, err := pipe.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to accept named pipe connection", zap.Error(err))
+			time.Sleep(pipeAcceptRetryDelay)
+			continue
+		}
+
+		if !pipe.track(conn) {
+			conn.Close()
+			return
+		}
+		go func() {
+			defer pipe.untrack(conn)
+			s.servePipeConn(conn)
+		}()
+	}
+}
+
+// servePipeConn answers the JSON-RPC messages of one connection in order
+func (s *Server) servePipeConn(conn io.ReadWriteCloser) {
+	s.logger.Info("MCP named pipe client connected")
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPipeMessage)
+	for scanner.Scan() {
+		message := bytes.TrimSpace(scanner.Bytes())
+		if len(message) == 0 {
+			continue
+		}
+
+		response := s.dispatchMCP(message)
+		if len(response) == 0 {
+			continue // Notification
+		}
+		if _, err := conn.Write(append(response, '\n')); err != nil {
+			s.logger.Warn("Failed to write named pipe response", zap.Error(err))
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		s.logger.Warn("Named pipe connection failed", zap.Error(err))
+	}
+
+	s.logger.Info("MCP named pipe client disconnected")
+}
+
+// dispatchMCP handles a JSON-RPC message as a POST /rpc request and returns the
+// response body, which is empty for notifications
+func (s *Server) dispatchMCP(message []byte) []byte {
+	req, err := http.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(message))
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "pipe"
+
+	writer := &pipeResponseWriter{header: make(http.Header)}
+	s.router.ServeHTTP(writer, req)
+	return bytes.TrimSpace(writer.body.Bytes())
+}
+
+// track registers a connection; it returns false once the server is closed
+func (p *mcpPipeServer) track(conn io.ReadWriteCloser) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	return true
+}
+
+func (p *mcpPipeServer) untrack(conn io.ReadWriteCloser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, conn)
+	conn.Close()
+}
+
+// Close stops accepting connections and disconnects the connected clients
+func (p *mcpPipeServer) Close() {
+	p.listener.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+// pipeResponseWriter collects the response of a dispatched request
+type pipeResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *pipeResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// configuredPipeName returns SCREENSHOT_PIPE_NAME if set, which may be empty to
+// disable the pipe, or the platform default
+func configuredPipeName() string {
+	if name, ok := os.LookupEnv("SCREENSHOT_PIPE_NAME"); ok {
+		return name
+	}
+	return defaultPipeName
+}
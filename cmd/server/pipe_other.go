@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "fmt"
+
+// defaultPipeName is empty: named pipes are a Windows transport
+const defaultPipeName = ""
+
+// listenPipe fails: named pipes are only supported on Windows
+func listenPipe(name string) (pipeListener, error) {
+	return nil, fmt.Errorf("named pipes are only supported on Windows")
+}
@@ -0,0 +1,128 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultPipeName is the named pipe MCP is served on unless configured otherwise
+const defaultPipeName = `\\.\pipe\screenshot-mcp`
+
+// pipeBufferSize is the in and out buffer size of each pipe instance
+const pipeBufferSize = 64 * 1024
+
+// namedPipeListener accepts clients on a named pipe, creating an instance per client.
+// Remote clients are rejected, and the default pipe security only lets the server's
+// user, administrators and SYSTEM write to it.
+type namedPipeListener struct {
+	name    string
+	namePtr *uint16
+	pending windows.Handle // Instance waiting for a client, 0 if none
+	closed  bool
+	mu      sync.Mutex
+}
+
+// listenPipe creates the first instance of the pipe, failing if another process
+// already owns the name
+func listenPipe(name string) (pipeListener, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &namedPipeListener{name: name, namePtr: namePtr}
+	if l.pending, err = l.create(true); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// create creates a pipe instance
+func (l *namedPipeListener) create(first bool) (windows.Handle, error) {
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	pipe, err := windows.CreateNamedPipe(l.namePtr, flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, pipeBufferSize, pipeBufferSize, 0, nil)
+	if err != nil {
+		return 0, fmt.Errorf("CreateNamedPipe failed: %w", err)
+	}
+	return pipe, nil
+}
+
+// Accept waits for a client to connect to a pipe instance
+func (l *namedPipeListener) Accept() (io.ReadWriteCloser, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.closePending()
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	if l.pending == 0 {
+		pipe, err := l.create(false)
+		if err != nil {
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.pending = pipe
+	}
+	pipe := l.pending
+	l.mu.Unlock()
+
+	err := windows.ConnectNamedPipe(pipe, nil)
+	if errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		err = nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		l.closePending()
+		return nil, net.ErrClosed
+	}
+	l.pending = 0
+	if err != nil {
+		windows.CloseHandle(pipe)
+		return nil, fmt.Errorf("ConnectNamedPipe failed: %w", err)
+	}
+	// os.File cancels a blocked read when the connection is closed
+	return os.NewFile(uintptr(pipe), l.name), nil
+}
+
+// Close stops accepting clients; connections already accepted stay open
+func (l *namedPipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	waiting := l.pending != 0
+	l.mu.Unlock()
+
+	// Unblock a pending ConnectNamedPipe by connecting to the pipe ourselves
+	if waiting {
+		if f, err := os.OpenFile(l.name, os.O_RDWR, 0); err == nil {
+			f.Close()
+		}
+	}
+	return nil
+}
+
+// closePending closes the instance waiting for a client; l.mu must be held
+func (l *namedPipeListener) closePending() {
+	if l.pending != 0 {
+		windows.CloseHandle(l.pending)
+		l.pending = 0
+	}
+}
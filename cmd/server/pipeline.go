@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// parsePipelineConfig parses the server's default pipeline, a JSON array of stages
+func parsePipelineConfig(raw string) (*screenshot.Pipeline, error) {
+	var specs []types.PipelineStage
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+			return nil, fmt.Errorf("invalid default pipeline: %w", err)
+		}
+	}
+	pipeline, err := screenshot.NewPipeline(specs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default pipeline: %w", err)
+	}
+	return pipeline, nil
+}
+
+// validatePipeline rejects a request pipeline with unknown stages or bad parameters
+func validatePipeline(specs []types.PipelineStage) error {
+	if _, err := screenshot.NewPipeline(specs); err != nil {
+		return invalidRequest(err)
+	}
+	return nil
+}
+
+// parseWatermarkConfig builds the server's watermark from its text, or watermark stage
+// params as a JSON object, and the path of a PNG logo. It returns an empty pipeline when
+// neither is set.
+func parseWatermarkConfig(watermark, logoPath string) (*screenshot.Pipeline, error) {
+	if watermark == "" && logoPath == "" {
+		return screenshot.NewPipeline(nil)
+	}
+
+	params := map[string]interface{}{}
+	if strings.HasPrefix(strings.TrimSpace(watermark), "{") {
+		if err := json.Unmarshal([]byte(watermark), &params); err != nil {
+			return nil, fmt.Errorf("invalid watermark: %w", err)
+		}
+	} else if watermark != "" {
+		params["text"] = watermark
+	}
+	if logoPath != "" {
+		logo, err := os.ReadFile(logoPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read watermark logo: %w", err)
+		}
+		params["logo"] = base64.StdEncoding.EncodeToString(logo)
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watermark: %w", err)
+	}
+	pipeline, err := screenshot.NewPipeline([]types.PipelineStage{{Stage: "watermark", Params: raw}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid watermark: %w", err)
+	}
+	return pipeline, nil
+}
+
+// watermark applies the server's watermark to a capture
+func (s *Server) watermark(buffer *types.ScreenshotBuffer) (*types.ScreenshotBuffer, error) {
+	processed, err := s.watermarkPipeline.Process(buffer)
+	if err != nil {
+		return nil, types.NewCaptureError(types.ErrCaptureFailed, "failed to watermark capture", err)
+	}
+	return processed, nil
+}
+
+// watermarkFrames applies the server's watermark to separately captured frames
+func (s *Server) watermarkFrames(captures []types.ChromeFrameCapture) error {
+	for i := range captures {
+		buffer, err := s.watermark(captures[i].Buffer)
+		if err != nil {
+			return err
+		}
+		captures[i].Buffer = buffer
+	}
+	return nil
+}
+
+// postProcess runs a capture through the request's pipeline or, when the request has
+// none, the server's default pipeline, then applies the server's watermark, which
+// requests can't turn off
+func (s *Server) postProcess(buffer *types.ScreenshotBuffer, specs []types.PipelineStage) (*types.ScreenshotBuffer, error) {
+	pipeline := s.pipeline.Load()
+	if specs != nil {
+		var err error
+		if pipeline, err = screenshot.NewPipeline(specs); err != nil {
+			return nil, invalidRequest(err)
+		}
+	}
+	processed, err := pipeline.Process(buffer)
+	if err != nil {
+		return nil, invalidRequest(err)
+	}
+	return s.watermark(processed)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Pixel sampling limits
+const (
+	maxSamplePoints        = 256
+	defaultHistogramColors = 5
+	maxHistogramColors     = 64
+	defaultHistogramBits   = 4
+)
+
+// samplePoint is a point of screen.getPixel
+type samplePoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// handleMCPScreenGetPixel returns the colors of pixels of a window or the screen, so an
+// agent can check UI state without transferring an image. Coordinates are relative to
+// the captured image: the window including its frame, or the primary screen when no
+// target is given.
+func (s *Server) handleMCPScreenGetPixel(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	points, err := getSamplePoints(params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+
+	buffer, err := s.sampleCapture(c.Request.Context(), params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	pixels := make([]screenshot.PixelColor, 0, len(points))
+	for _, point := range points {
+		pixel, err := screenshot.SamplePixel(buffer, point.X, point.Y)
+		if err != nil {
+			s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+			return
+		}
+		pixels = append(pixels, pixel)
+	}
+
+	s.sendMCPResult(c, req.ID, gin.H{
+		"pixels":    pixels,
+		"width":     buffer.Width,
+		"height":    buffer.Height,
+		"timestamp": buffer.Timestamp,
+	})
+}
+
+// handleMCPScreenHistogram returns the average and dominant colors of a region of a
+// window or the screen
+func (s *Server) handleMCPScreenHistogram(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	region, err := getRegion(params, "region")
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+	colors := getInt(params, "colors", defaultHistogramColors)
+	if colors < 1 || colors > maxHistogramColors {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(fmt.Errorf("colors must be between 1 and %d", maxHistogramColors)))
+		return
+	}
+	bits := getInt(params, "bits", defaultHistogramBits)
+
+	buffer, err := s.sampleCapture(c.Request.Context(), params)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+
+	if region == nil {
+		region = &types.Rectangle{Width: buffer.Width, Height: buffer.Height}
+	}
+	histogram, err := screenshot.Histogram(buffer, *region, bits, colors)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, invalidRequest(err))
+		return
+	}
+
+	s.sendMCPResult(c, req.ID, gin.H{
+		"region":      histogram.Region,
+		"pixel_count": histogram.PixelCount,
+		"average":     histogram.Average,
+		"dominant":    histogram.Dominant,
+		"width":       buffer.Width,
+		"height":      buffer.Height,
+		"timestamp":   buffer.Timestamp,
+	})
+}
+
+// sampleCapture captures the window a sampling request names with method and target,
+// or the screen when method is "screen" (the default), with the request's color
+// management applied
+func (s *Server) sampleCapture(ctx context.Context, params map[string]interface{}) (*types.ScreenshotBuffer, error) {
+	req := &types.ScreenshotRequest{
+		Method:          getString(params, "method", "screen"),
+		Target:          getString(params, "target", ""),
+		Match:           getString(params, "match", ""),
+		TopLevel:        getBool(params, "top_level", false),
+		CaptureMethod:   types.CaptureMethod(getString(params, "capture_method", "")),
+		ColorManagement: types.ColorManagement(getString(params, "color_management", "")),
+	}
+	options := &types.CaptureOptions{
+		IncludeFrame:      getBool(params, "include_frame", true),
+		ScaleFactor:       1.0,
+		AllowMinimized:    true,
+		WaitForVisible:    2 * time.Second,
+		RetryCount:        3,
+		RetryBackoff:      100 * time.Millisecond,
+		RetryMaxBackoff:   2 * time.Second,
+		DetectBlackFrames: true,
+		Context:           ctx,
+		CustomProperties:  make(map[string]string),
+	}
+
+	var buffer *types.ScreenshotBuffer
+	if req.Method == "screen" {
+		if _, err := types.ParseColorManagement(string(req.ColorManagement)); err != nil {
+			return nil, invalidRequest(err)
+		}
+		var err error
+		if buffer, err = s.engine.CaptureFullScreen(getInt(params, "monitor", 0), options); err != nil {
+			return nil, err
+		}
+	} else {
+		if req.Target == "" && methodRequiresTarget(req.Method) {
+			return nil, invalidRequest(fmt.Errorf("target is required for method %q", req.Method))
+		}
+		plan, err := applyScreenshotRequest(req, options)
+		if err != nil {
+			return nil, err
+		}
+		if buffer, err = s.captureWhenReady(req, plan, options); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, _, err := s.manageColor(buffer, req.ColorManagement)
+	return buffer, err
+}
+
+// getSamplePoints reads the points of screen.getPixel: a points array, or x and y
+func getSamplePoints(params map[string]interface{}) ([]samplePoint, error) {
+	if value, exists := params["points"]; exists && value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid points: %w", err)
+		}
+		var points []samplePoint
+		if err := json.Unmarshal(data, &points); err != nil {
+			return nil, fmt.Errorf("invalid points: %w", err)
+		}
+		if len(points) == 0 || len(points) > maxSamplePoints {
+			return nil, fmt.Errorf("points must have between 1 and %d entries", maxSamplePoints)
+		}
+		return points, nil
+	}
+
+	_, hasX := params["x"]
+	_, hasY := params["y"]
+	if !hasX || !hasY {
+		return nil, fmt.Errorf("x and y, or points, are required")
+	}
+	return []samplePoint{{X: getInt(params, "x", 0), Y: getInt(params, "y", 0)}}, nil
+}
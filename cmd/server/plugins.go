@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/plugin"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// pluginListResponse lists the plugins found in the plugins directory
+type pluginListResponse struct {
+	Plugins []types.PluginInfo `json:"plugins"`
+	Count   int                `json:"count"`
+}
+
+// getPlugins lists the plugins with their tools and pipeline stages
+func (s *Server) getPlugins(c *gin.Context) {
+	plugins := s.plugins.List()
+	c.JSON(http.StatusOK, pluginListResponse{Plugins: plugins, Count: len(plugins)})
+}
+
+func (s *Server) handleMCPPluginsList(c *gin.Context, req *types.MCPRequest) {
+	plugins := s.plugins.List()
+	s.sendMCPResult(c, req.ID, pluginListResponse{Plugins: plugins, Count: len(plugins)})
+}
+
+// handleMCPPluginTool calls the plugin tool named by the request's method. Errors the
+// plugin returns are passed on as they are.
+func (s *Server) handleMCPPluginTool(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	result, err := s.plugins.CallTool(c.Request.Context(), req.Method, params)
+	if err != nil {
+		var pluginErr *plugin.Error
+		if errors.As(err, &pluginErr) {
+			var data interface{}
+			if len(pluginErr.Data) > 0 {
+				data = pluginErr.Data
+			}
+			s.sendMCPError(c, req.ID, pluginErr.Code, pluginErr.Message, data)
+			return
+		}
+		s.logger.Error("Plugin tool failed", zap.String("method", req.Method), zap.Error(err))
+		s.sendMCPError(c, req.ID, -32603, "Internal error", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, result)
+}
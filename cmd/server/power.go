@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/screenshot-mcp-server/internal/power"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// parsePowerPolicy parses the power policy setting: empty for the default caps, "off"
+// for none, or the caps as JSON
+func parsePowerPolicy(setting string) (types.PowerPolicy, error) {
+	switch setting {
+	case "":
+		return power.DefaultPolicy(), nil
+	case "off":
+		return types.PowerPolicy{}, nil
+	}
+	var policy types.PowerPolicy
+	if err := json.Unmarshal([]byte(setting), &policy); err != nil {
+		return policy, fmt.Errorf("invalid power_policy: %w", err)
+	}
+	for _, limits := range []*types.PowerLimits{policy.Battery, policy.Saver} {
+		if limits == nil {
+			continue
+		}
+		if limits.MaxFPS < 0 || limits.MaxQuality < 0 || limits.MaxQuality > 100 {
+			return policy, fmt.Errorf("invalid power_policy: max_fps must not be negative and max_quality must be between 0 and 100")
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/tracing"
+)
+
+// pprofHandlers are the profiles net/http/pprof serves with their own handler; the
+// others, such as heap and goroutine, are runtime/pprof profiles
+var pprofHandlers = map[string]http.HandlerFunc{
+	"cmdline": pprof.Cmdline,
+	"profile": pprof.Profile,
+	"symbol":  pprof.Symbol,
+	"trace":   pprof.Trace,
+}
+
+// getPprofIndex lists the pprof profiles. Its links are relative to the directory, so
+// it's served with a trailing slash.
+func (s *Server) getPprofIndex(c *gin.Context) {
+	pprof.Index(c.Writer, c.Request)
+}
+
+// redirectPprofIndex sends the index's path without the trailing slash to the index
+func redirectPprofIndex(c *gin.Context) {
+	target := c.Request.URL.Path + "/"
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+}
+
+// getPprofProfile serves a pprof profile, CPU profile or execution trace. Samples and
+// trace regions of captures carry the stage they were taken in.
+func (s *Server) getPprofProfile(c *gin.Context) {
+	name := c.Param("profile")
+	if handler, ok := pprofHandlers[name]; ok {
+		handler(c.Writer, c.Request)
+		return
+	}
+	pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+}
+
+// getFlame serves the time spent in each capture stage as folded stacks for flame graphs
+func (s *Server) getFlame(c *gin.Context) {
+	var folded bytes.Buffer
+	if err := tracing.WriteFolded(&folded); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", folded.Bytes())
+}
+
+// deleteFlame clears the capture stage totals, to profile from a clean slate
+func (s *Server) deleteFlame(c *gin.Context) {
+	tracing.Reset()
+	c.Status(http.StatusNoContent)
+}
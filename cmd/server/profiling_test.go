@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPprofIndexLinksResolve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+	router := gin.New()
+	admin := router.Group("/v1/admin")
+	admin.GET("/debug/pprof", redirectPprofIndex)
+	admin.GET("/debug/pprof/", s.getPprofIndex)
+	admin.GET("/debug/pprof/:profile", s.getPprofProfile)
+	get := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	redirect := get("/v1/admin/debug/pprof?debug=1")
+	assert.Equal(t, http.StatusMovedPermanently, redirect.Code)
+	assert.Equal(t, "/v1/admin/debug/pprof/?debug=1", redirect.Header().Get("Location"))
+
+	index := get("/v1/admin/debug/pprof/")
+	require.Equal(t, http.StatusOK, index.Code)
+	link := regexp.MustCompile(`href=['"](heap\?debug=1)['"]`).FindStringSubmatch(index.Body.String())
+	require.NotNil(t, link, "the index links to the heap profile")
+
+	base, err := url.Parse("/v1/admin/debug/pprof/")
+	require.NoError(t, err)
+	target, err := base.Parse(link[1])
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/admin/debug/pprof/heap?debug=1", target.String())
+	heap := get(target.String())
+	assert.Equal(t, http.StatusOK, heap.Code)
+	assert.Contains(t, heap.Body.String(), "heap profile")
+}
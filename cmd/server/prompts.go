@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// mcpPrompt is a ready-made prompt and how it's filled in from its arguments, which
+// have been checked to include the required ones
+type mcpPrompt struct {
+	types.MCPPrompt
+	build func(s *Server, args map[string]string) ([]types.MCPPromptMessage, error)
+}
+
+// mcpPrompts are the prompts listed by prompts/list, in order
+var mcpPrompts = []mcpPrompt{
+	{
+		MCPPrompt: types.MCPPrompt{
+			Name:        "analyze-screen",
+			Description: "Look at what's on the screen and describe it",
+			Arguments: []types.MCPPromptArgument{
+				{Name: "focus", Description: "What to look for, such as error dialogs or the state of a form"},
+			},
+		},
+		build: func(s *Server, args map[string]string) ([]types.MCPPromptMessage, error) {
+			text := "Call screen.describe to get the foreground window, the visible windows, a screenshot " +
+				"and its text. Describe what the user is looking at: the application, what it shows and " +
+				"anything that needs attention, such as dialogs, errors or progress."
+			if focus := args["focus"]; focus != "" {
+				text += " Focus on: " + focus + "."
+			}
+			text += " If a detail is too small to read, capture the window again with screenshot.capture " +
+				"and a region around it."
+			return []types.MCPPromptMessage{textMessage(text)}, nil
+		},
+	},
+	{
+		MCPPrompt: types.MCPPrompt{
+			Name:        "compare-captures",
+			Description: "Compare two recent screenshots and list what changed",
+			Arguments: []types.MCPPromptArgument{
+				{Name: "before", Description: "Earlier screenshot: a history ID or screenshot://history/{id} URI", Required: true},
+				{Name: "after", Description: "Later screenshot: a history ID or screenshot://history/{id} URI", Required: true},
+			},
+		},
+		build: func(s *Server, args map[string]string) ([]types.MCPPromptMessage, error) {
+			before, err := s.promptResource(historyURI(args["before"]))
+			if err != nil {
+				return nil, err
+			}
+			after, err := s.promptResource(historyURI(args["after"]))
+			if err != nil {
+				return nil, err
+			}
+			return []types.MCPPromptMessage{
+				textMessage("Here are two screenshots of the same screen, the first taken before the second."),
+				resourceMessage(before),
+				resourceMessage(after),
+				textMessage("List what changed between them: windows opened or closed, text, values and " +
+					"controls that differ, and anything that appeared or went away. Ignore differences " +
+					"that are only noise, such as a blinking caret or the clock."),
+			}, nil
+		},
+	},
+	{
+		MCPPrompt: types.MCPPrompt{
+			Name:        "capture-window",
+			Description: "Find a window by its title and capture it",
+			Arguments: []types.MCPPromptArgument{
+				{Name: "window", Description: "Part of the window's title", Required: true},
+			},
+		},
+		build: func(s *Server, args map[string]string) ([]types.MCPPromptMessage, error) {
+			windows, err := s.promptResource(windowsResourceURI)
+			if err != nil {
+				return nil, err
+			}
+			return []types.MCPPromptMessage{
+				textMessage("These are the open windows."),
+				resourceMessage(windows),
+				textMessage(fmt.Sprintf("Find the window whose title contains %q and capture it with "+
+					"screenshot.capture, using method handle and its handle as target. If several "+
+					"match, pick the visible one in front. If none does, say which windows come closest.",
+					args["window"])),
+			}, nil
+		},
+	},
+	{
+		MCPPrompt: types.MCPPrompt{
+			Name:        "summarize-recording",
+			Description: "Summarize what happened during a recording",
+			Arguments: []types.MCPPromptArgument{
+				{Name: "id", Description: "Recording ID", Required: true},
+			},
+		},
+		build: func(s *Server, args map[string]string) ([]types.MCPPromptMessage, error) {
+			id := args["id"]
+			recording, err := s.promptResource(resourceScheme + recordingsResource + id)
+			if err != nil {
+				return nil, err
+			}
+			return []types.MCPPromptMessage{
+				textMessage("This is a screen recording."),
+				resourceMessage(recording),
+				textMessage(fmt.Sprintf("Call recording.get with id %q and include_timeline to see when the "+
+					"foreground window changed and the user was active, then read the frames around those "+
+					"moments as screenshot://recordings/%s/frames/{frame}. Summarize step by step what the "+
+					"user did and what the applications showed.", id, id)),
+			}, nil
+		},
+	},
+}
+
+// findPrompt returns a prompt by name
+func findPrompt(name string) (*mcpPrompt, bool) {
+	for i := range mcpPrompts {
+		if mcpPrompts[i].Name == name {
+			return &mcpPrompts[i], true
+		}
+	}
+	return nil, false
+}
+
+// historyURI returns the URI of a screenshot in the capture history given by its ID or URI
+func historyURI(screenshot string) string {
+	if strings.HasPrefix(screenshot, resourceScheme) {
+		return screenshot
+	}
+	return resourceScheme + historyResource + screenshot
+}
+
+// promptResource reads a resource a prompt embeds
+func (s *Server) promptResource(uri string) (*types.MCPResourceContents, error) {
+	contents, err := s.readResource(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uri, err)
+	}
+	return contents, nil
+}
+
+func textMessage(text string) types.MCPPromptMessage {
+	return types.MCPPromptMessage{Role: "user", Content: types.MCPContent{Type: "text", Text: text}}
+}
+
+func resourceMessage(contents *types.MCPResourceContents) types.MCPPromptMessage {
+	return types.MCPPromptMessage{Role: "user", Content: types.MCPContent{Type: "resource", Resource: contents}}
+}
+
+// handleMCPPromptsList lists the ready-made prompts
+func (s *Server) handleMCPPromptsList(c *gin.Context, req *types.MCPRequest) {
+	prompts := make([]types.MCPPrompt, len(mcpPrompts))
+	for i, prompt := range mcpPrompts {
+		prompts[i] = prompt.MCPPrompt
+	}
+	s.sendMCPResult(c, req.ID, map[string]interface{}{"prompts": prompts})
+}
+
+// handleMCPPromptsGet fills in a prompt with its arguments
+func (s *Server) handleMCPPromptsGet(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	name := getString(params, "name", "")
+	prompt, ok := findPrompt(name)
+	if !ok {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", fmt.Sprintf("unknown prompt %q", name))
+		return
+	}
+
+	args := make(map[string]string)
+	if values, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range values {
+			args[key] = fmt.Sprint(value)
+		}
+	}
+	for _, argument := range prompt.Arguments {
+		if argument.Required && args[argument.Name] == "" {
+			s.sendMCPError(c, req.ID, -32602, "Invalid params", fmt.Sprintf("missing required argument %q", argument.Name))
+			return
+		}
+	}
+
+	messages, err := prompt.build(s, args)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, map[string]interface{}{
+		"description": prompt.Description,
+		"messages":    messages,
+	})
+}
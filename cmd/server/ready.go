@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout is the longest the readiness probes may take together. Probes still
+// running then are reported as timed out.
+const readinessTimeout = 5 * time.Second
+
+// capabilityProbe checks one capability. It returns a detail and version for the
+// report, or an error if the capability is unavailable.
+type capabilityProbe struct {
+	name     string
+	required bool
+	probe    func(report *types.ReadinessReport) (detail, version string, err error)
+}
+
+// errUnsupported marks a capability the platform doesn't have
+var errUnsupported = fmt.Errorf("not supported on %s", runtime.GOOS)
+
+// capabilityProbes returns the probes /health/ready runs
+func (s *Server) capabilityProbes() []capabilityProbe {
+	return []capabilityProbe{
+		{name: "capture", required: true, probe: s.probeCapture},
+		{name: "window_enumeration", required: true, probe: s.probeWindows},
+		{name: "monitors", required: true, probe: s.probeMonitors},
+		{name: "dwm_composition", probe: probeComposition},
+		{name: "chrome_discovery", probe: s.probeChrome},
+	}
+}
+
+// probeCapture captures a single pixel of the primary monitor
+func (s *Server) probeCapture(*types.ReadinessReport) (string, string, error) {
+	buffer, err := s.engine.CaptureFullScreen(0, &types.CaptureOptions{
+		Region: &types.Rectangle{X: 0, Y: 0, Width: 1, Height: 1},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(buffer.Data) == 0 {
+		return "", "", fmt.Errorf("capture returned no pixels")
+	}
+	detail := fmt.Sprintf("%dx%d %s", buffer.Width, buffer.Height, buffer.Format)
+	if buffer.CaptureMethod != "" {
+		detail += " via " + string(buffer.CaptureMethod)
+	}
+	return detail, "", nil
+}
+
+// probeWindows enumerates the top-level windows
+func (s *Server) probeWindows(*types.ReadinessReport) (string, string, error) {
+	windows, err := s.windowManager.EnumerateWindows(nil)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("%d windows", len(windows)), "", nil
+}
+
+// probeMonitors enumerates the monitors and adds them to the report
+func (s *Server) probeMonitors(report *types.ReadinessReport) (string, string, error) {
+	engine, ok := s.nativeEngine().(interface {
+		Monitors() ([]types.MonitorInfo, error)
+	})
+	if !ok {
+		return "", "", errUnsupported
+	}
+	monitors, err := engine.Monitors()
+	if err != nil {
+		return "", "", err
+	}
+	if len(monitors) == 0 {
+		return "", "", fmt.Errorf("no monitors found")
+	}
+	report.Monitors = monitors
+	return fmt.Sprintf("%d monitors", len(monitors)), "", nil
+}
+
+// probeComposition checks that DWM composition is enabled, which the DWM capture methods
+// need
+func probeComposition(*types.ReadinessReport) (string, string, error) {
+	if runtime.GOOS != "windows" {
+		return "", "", errUnsupported
+	}
+	enabled, err := screenshot.CompositionEnabled()
+	if err != nil {
+		return "", "", err
+	}
+	if !enabled {
+		return "", "", fmt.Errorf("desktop composition is disabled")
+	}
+	return "enabled", "", nil
+}
+
+// probeChrome discovers Chrome instances with remote debugging enabled. Finding none is
+// fine; failing to look is not.
+func (s *Server) probeChrome(*types.ReadinessReport) (string, string, error) {
+	if runtime.GOOS != "windows" {
+		return "", "", errUnsupported
+	}
+	instances, err := s.chromeManager.DiscoverInstances()
+	if err != nil {
+		return "", "", err
+	}
+	if len(instances) == 0 {
+		return "no debuggable instances", "", nil
+	}
+	return fmt.Sprintf("%d instances", len(instances)), instances[0].Version, nil
+}
+
+// runProbe runs a probe, failing it if it panics
+func (s *Server) runProbe(probe capabilityProbe, report *types.ReadinessReport) (detail, version string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			crashReport := s.crashes.Capture("readiness", p, map[string]string{"capability": probe.name})
+			s.logger.Error("Readiness probe panicked",
+				zap.String("capability", probe.name),
+				zap.String("crash_id", crashReport.ID),
+				zap.Any("error", p),
+			)
+			err = fmt.Errorf("probe panicked (crash %s): %v", crashReport.ID, p)
+		}
+	}()
+	return probe.probe(report)
+}
+
+// checkReadiness runs the capability probes concurrently and reports whether every
+// required capability works
+func (s *Server) checkReadiness(ctx context.Context) *types.ReadinessReport {
+	start := time.Now()
+	report := &types.ReadinessReport{
+		Status:       "ready",
+		Timestamp:    start,
+		Version:      "1.0.0",
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		OSVersion:    screenshot.OSVersion(),
+		DesktopState: screenshot.QueryDesktopState(),
+		Capabilities: make(map[string]types.CapabilityStatus),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	type result struct {
+		name   string
+		status types.CapabilityStatus
+		// monitors is kept apart from report, which probes still running after the
+		// timeout must not touch
+		monitors []types.MonitorInfo
+	}
+	probes := s.capabilityProbes()
+	results := make(chan result, len(probes))
+	for _, probe := range probes {
+		go func(probe capabilityProbe) {
+			begin := time.Now()
+			var scratch types.ReadinessReport
+			detail, version, err := s.runProbe(probe, &scratch)
+			status := types.CapabilityStatus{
+				Status:   "ok",
+				Required: probe.required,
+				Detail:   detail,
+				Version:  version,
+				Duration: time.Since(begin),
+			}
+			switch {
+			case errors.Is(err, errUnsupported):
+				status.Status = "unsupported"
+			case err != nil:
+				status.Status = "unavailable"
+				status.Error = err.Error()
+			}
+			results <- result{name: probe.name, status: status, monitors: scratch.Monitors}
+		}(probe)
+	}
+
+collect:
+	for range probes {
+		select {
+		case r := <-results:
+			report.Capabilities[r.name] = r.status
+			if r.monitors != nil {
+				report.Monitors = r.monitors
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+	for _, probe := range probes {
+		if _, done := report.Capabilities[probe.name]; !done {
+			report.Capabilities[probe.name] = types.CapabilityStatus{
+				Status:   "timeout",
+				Required: probe.required,
+				Error:    ctx.Err().Error(),
+				Duration: time.Since(start),
+			}
+		}
+	}
+
+	for _, status := range report.Capabilities {
+		if status.Required && status.Status != "ok" {
+			report.Status = "not_ready"
+		}
+	}
+	report.ProbeTime = time.Since(start)
+	return report
+}
+
+// readinessCheck reports whether the server can really capture: 200 when every required
+// capability works, 503 otherwise
+func (s *Server) readinessCheck(c *gin.Context) {
+	report := s.checkReadiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+		failed := make([]string, 0)
+		for name, capability := range report.Capabilities {
+			if capability.Required && capability.Status != "ok" {
+				failed = append(failed, name)
+			}
+		}
+		s.logger.Warn("Readiness check failed", zap.Strings("capabilities", failed))
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/relay"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// agentListResponse lists the agents connected through the relay
+type agentListResponse struct {
+	Agents []types.AgentInfo `json:"agents"`
+	Count  int               `json:"count"`
+}
+
+// listAgents lists the capture agents connected to this server
+func (s *Server) listAgents(c *gin.Context) {
+	agents := s.agents.List()
+	c.JSON(http.StatusOK, agentListResponse{Agents: agents, Count: len(agents)})
+}
+
+// getAgent returns one connected agent
+func (s *Server) getAgent(c *gin.Context) {
+	agent, err := s.agents.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+// proxyAgent forwards a request, or a stream, to an agent's API
+func (s *Server) proxyAgent(c *gin.Context) {
+	s.agents.Proxy(c, c.Param("id"), c.Param("path"))
+}
+
+// startRelay connects to the central server as a capture agent when a relay URL is
+// configured. Forwarded requests are served by this server's own routes.
+func (s *Server) startRelay() (*relay.Agent, error) {
+	if s.config.Load().RelayURL == "" {
+		return nil, nil
+	}
+	if s.config.Load().RelayToken == "" {
+		return nil, errors.New("SCREENSHOT_RELAY_URL requires SCREENSHOT_RELAY_TOKEN")
+	}
+
+	hostname, _ := os.Hostname()
+	info := types.AgentInfo{
+		ID:       s.config.Load().AgentName,
+		Hostname: hostname,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Version:  "1.0.0",
+	}
+	if info.ID == "" {
+		info.ID = hostname
+	}
+
+	agent, err := relay.NewAgent(s.config.Load().RelayURL, s.config.Load().RelayToken, info, s.router, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	agent.Start()
+
+	s.logger.Info("Relaying captures to central server",
+		zap.String("server", s.config.Load().RelayURL),
+		zap.String("agent_id", info.ID),
+	)
+	return agent, nil
+}
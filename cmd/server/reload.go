@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 2 * time.Second
+
+// reloadableSettings are the settings, by JSON name, a changed config file applies
+// without a restart. The others take effect at the next start.
+var reloadableSettings = map[string]bool{
+	"default_format":         true,
+	"quality":                true,
+	"stream_default_fps":     true,
+	"stream_security":        true,
+	"log_level":              true,
+	"excluded_window_policy": true,
+	"color_management":       true,
+	"pipeline":               true,
+	"workflow_dir":           true,
+	"activity_gate":          true,
+	"idle_after":             true,
+	"power_policy":           true,
+	"stream_cpu_budget":      true,
+	"stream_max_sessions":    true,
+	"stream_max_duration":    true,
+	"stream_idle_timeout":    true,
+	"frame_cache_mb":         true,
+	"capture_concurrency":    true,
+}
+
+// loadConfigFile overlays the settings of a JSON config file on config. Unknown keys,
+// including those of secrets, which are only read from the environment, are rejected.
+func loadConfigFile(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// watchConfig reloads the config file whenever it changes, until stop is closed
+func (s *Server) watchConfig(path string, stop <-chan struct{}) {
+	modified := func() (time.Time, int64) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+	lastTime, lastSize := modified()
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			modTime, size := modified()
+			if size < 0 || (modTime.Equal(lastTime) && size == lastSize) {
+				continue
+			}
+			lastTime, lastSize = modTime, size
+			if err := s.reloadConfig(path); err != nil {
+				s.logger.Error("Config file not reloaded; keeping the current settings",
+					zap.String("path", path), zap.Error(err))
+			}
+		}
+	}
+}
+
+// reloadConfig applies the reloadable settings of the config file. Nothing is applied
+// unless all of them are valid; changed settings that need a restart are logged.
+func (s *Server) reloadConfig(path string) error {
+	current := s.config.Load()
+	next := DefaultConfig()
+	if err := loadConfigFile(next, path); err != nil {
+		return err
+	}
+
+	// Keep the running value of every setting that can't change without a restart
+	var restart []string
+	currentValue, nextValue := reflect.ValueOf(current).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < nextValue.NumField(); i++ {
+		name, _, _ := strings.Cut(nextValue.Type().Field(i).Tag.Get("json"), ",")
+		if reloadableSettings[name] {
+			continue
+		}
+		if name != "-" && !reflect.DeepEqual(currentValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			restart = append(restart, name)
+		}
+		nextValue.Field(i).Set(currentValue.Field(i))
+	}
+
+	// Validate everything before applying anything
+	switch types.ImageFormat(next.DefaultFormat) {
+	case types.FormatPNG, types.FormatJPEG, types.FormatBMP, types.FormatWebP:
+	default:
+		return fmt.Errorf("invalid default_format %q", next.DefaultFormat)
+	}
+	if next.Quality < 1 || next.Quality > 100 {
+		return fmt.Errorf("invalid quality %d: must be 1-100", next.Quality)
+	}
+	if next.StreamDefaultFPS < 1 || next.StreamDefaultFPS > 60 {
+		return fmt.Errorf("invalid stream_default_fps %d: must be 1-60", next.StreamDefaultFPS)
+	}
+	if err := validateCPUBudget(next.StreamCPUBudget); err != nil {
+		return err
+	}
+	maxDuration, idleTimeout, err := parseSessionLimits(next)
+	if err != nil {
+		return err
+	}
+	if next.FrameCacheMB < 0 {
+		return fmt.Errorf("invalid frame_cache_mb %d: must not be negative", next.FrameCacheMB)
+	}
+	if next.CaptureConcurrency < 1 {
+		return fmt.Errorf("invalid capture_concurrency %d: must be at least 1", next.CaptureConcurrency)
+	}
+	security, err := types.ParseStreamSecurity(next.StreamSecurity)
+	if err != nil {
+		return err
+	}
+	if security != types.StreamSecurityNone && next.StreamKey == "" {
+		return fmt.Errorf("stream security %q requires SCREENSHOT_STREAM_KEY", security)
+	}
+	level, err := zapcore.ParseLevel(next.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log_level: %w", err)
+	}
+	excludedPolicy, err := types.ParseExcludedWindowPolicy(next.ExcludedWindowPolicy)
+	if err != nil {
+		return err
+	}
+	colorManagement, err := types.ParseColorManagement(next.ColorManagement)
+	if err != nil {
+		return err
+	}
+	next.ColorManagement = string(colorManagement)
+	activityGate, idleAfter, err := parseActivityConfig(next)
+	if err != nil {
+		return err
+	}
+	powerPolicy, err := parsePowerPolicy(next.PowerPolicy)
+	if err != nil {
+		return err
+	}
+	var pipeline *screenshot.Pipeline
+	if next.Pipeline != current.Pipeline {
+		if pipeline, err = parsePipelineConfig(next.Pipeline); err != nil {
+			return err
+		}
+	}
+
+	// The admin API may have changed the level since; only a changed file setting overrides it
+	if next.LogLevel != current.LogLevel {
+		s.logLevel.SetLevel(level)
+	}
+	if engine, ok := s.nativeEngine().(interface {
+		SetExcludedWindowPolicy(types.ExcludedWindowPolicy)
+	}); ok {
+		engine.SetExcludedWindowPolicy(excludedPolicy)
+	}
+	if pipeline != nil {
+		s.pipeline.Store(pipeline)
+	}
+	s.activity.Configure(activityGate, idleAfter)
+	s.power.Configure(powerPolicy)
+	s.streamManager.SetCPUBudget(next.StreamCPUBudget)
+	s.streamManager.SetSessionLimits(next.StreamMaxSessions, maxDuration, idleTimeout)
+	s.frames.resize(int64(next.FrameCacheMB) << 20)
+	s.scheduler.SetLimit(next.CaptureConcurrency)
+	s.config.Store(next)
+
+	s.logger.Info("Config file reloaded", zap.String("path", path))
+	if len(restart) > 0 {
+		s.logger.Warn("Changed settings take effect after a restart",
+			zap.String("path", path), zap.Strings("settings", restart))
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// MCP resource URIs
+const (
+	resourceScheme     = "screenshot://"
+	windowsResourceURI = resourceScheme + "windows"
+	historyResource    = "history/"
+	recordingsResource = "recordings/"
+)
+
+// mcpResourceNotFound is the JSON-RPC error code of a resource that doesn't exist
+const mcpResourceNotFound = -32002
+
+// captureHistory keeps the most recent screenshot responses, newest last, so MCP hosts
+// can read them back as screenshot://history/{id} resources
+type captureHistory struct {
+	mutex   sync.Mutex
+	entries []historyEntry
+	next    int    // Slot the next entry overwrites once the history is full
+	lastID  uint64 // ID of the latest entry; IDs aren't reused
+	limit   int
+}
+
+// historyEntry is a capture kept in the history
+type historyEntry struct {
+	ID        uint64
+	Method    string
+	Target    string
+	Format    string
+	Width     int
+	Height    int
+	Timestamp time.Time
+	Data      string // Base64-encoded image
+}
+
+func newCaptureHistory(limit int) *captureHistory {
+	return &captureHistory{limit: limit}
+}
+
+// add keeps a capture, dropping the oldest once the history is full
+func (h *captureHistory) add(entry historyEntry) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.limit == 0 {
+		return
+	}
+	h.lastID++
+	entry.ID = h.lastID
+	if len(h.entries) < h.limit {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % h.limit
+}
+
+// get returns a kept capture by ID
+func (h *captureHistory) get(id uint64) (historyEntry, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, entry := range h.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return historyEntry{}, false
+}
+
+// recent returns the kept captures, newest first
+func (h *captureHistory) recent() []historyEntry {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entries := make([]historyEntry, 0, len(h.entries))
+	entries = append(entries, h.entries[h.next:]...)
+	entries = append(entries, h.entries[:h.next]...)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// rememberCapture adds a screenshot response to the capture history. Responses without
+// image data, such as those of unchanged captures, aren't kept.
+func (s *Server) rememberCapture(req *types.ScreenshotRequest, response *types.ScreenshotResponse) {
+	if response.Data == "" || response.Unchanged {
+		return
+	}
+	s.history.add(historyEntry{
+		Method:    req.Method,
+		Target:    req.Target,
+		Format:    response.Format,
+		Width:     response.Width,
+		Height:    response.Height,
+		Timestamp: response.Timestamp,
+		Data:      response.Data,
+	})
+}
+
+// resource describes a kept capture as an MCP resource
+func (entry historyEntry) resource() types.MCPResource {
+	name := entry.Method
+	if entry.Target != "" {
+		name += " " + strconv.Quote(entry.Target)
+	}
+	return types.MCPResource{
+		URI:         fmt.Sprintf("%s%s%d", resourceScheme, historyResource, entry.ID),
+		Name:        fmt.Sprintf("Screenshot %d: %s", entry.ID, name),
+		Description: fmt.Sprintf("%dx%d %s captured %s", entry.Width, entry.Height, entry.Format, entry.Timestamp.Format(time.RFC3339)),
+		MimeType:    imageMIMEType(entry.Format),
+	}
+}
+
+// imageMIMEType returns the MIME type of an image format
+func imageMIMEType(format string) string {
+	switch types.ImageFormat(format) {
+	case types.FormatPNG:
+		return "image/png"
+	case types.FormatJPEG:
+		return "image/jpeg"
+	case types.FormatBMP:
+		return "image/bmp"
+	case types.FormatWebP:
+		return "image/webp"
+	}
+	return "application/octet-stream"
+}
+
+// resourceTemplates describes the resources addressed by ID
+var resourceTemplates = []types.MCPResourceTemplate{
+	{URITemplate: resourceScheme + historyResource + "{id}", Name: "Recent screenshot", Description: "A screenshot the server captured recently"},
+	{URITemplate: resourceScheme + recordingsResource + "{id}", Name: "Recording", Description: "A recording's status and options", MimeType: "application/json"},
+	{URITemplate: resourceScheme + recordingsResource + "{id}/frames/{frame}", Name: "Recording frame", Description: "A recorded frame, numbered from 1"},
+}
+
+// handleMCPResourcesList lists the windows, the recent captures, newest first, and the recordings
+func (s *Server) handleMCPResourcesList(c *gin.Context, req *types.MCPRequest) {
+	resources := []types.MCPResource{{
+		URI:         windowsResourceURI,
+		Name:        "Windows",
+		Description: "The top-level windows, as listed by window.list",
+		MimeType:    "application/json",
+	}}
+	for _, entry := range s.history.recent() {
+		resources = append(resources, entry.resource())
+	}
+	for _, info := range s.recorder.List() {
+		resources = append(resources, types.MCPResource{
+			URI:         resourceScheme + recordingsResource + info.ID,
+			Name:        "Recording " + info.ID,
+			Description: fmt.Sprintf("%s recording, %s, %d frames", info.Mode, info.Status, info.FrameCount),
+			MimeType:    "application/json",
+		})
+	}
+	s.sendMCPResult(c, req.ID, map[string]interface{}{"resources": resources})
+}
+
+// handleMCPResourceTemplatesList lists the templates of the resources addressed by ID
+func (s *Server) handleMCPResourceTemplatesList(c *gin.Context, req *types.MCPRequest) {
+	s.sendMCPResult(c, req.ID, map[string]interface{}{"resourceTemplates": resourceTemplates})
+}
+
+// handleMCPResourcesRead reads a resource by URI
+func (s *Server) handleMCPResourcesRead(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	uri := getString(params, "uri", "")
+	if uri == "" {
+		s.sendMCPError(c, req.ID, -32602, "Missing required parameter: uri", nil)
+		return
+	}
+
+	contents, err := s.readResource(uri)
+	if err != nil {
+		s.sendMCPError(c, req.ID, mcpResourceNotFound, "Resource not found", map[string]interface{}{"uri": uri, "error": err.Error()})
+		return
+	}
+	s.sendMCPResult(c, req.ID, map[string]interface{}{"contents": []types.MCPResourceContents{*contents}})
+}
+
+// readResource returns the content of the resource at uri
+func (s *Server) readResource(uri string) (*types.MCPResourceContents, error) {
+	path, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %s", uri)
+	}
+
+	switch {
+	case uri == windowsResourceURI:
+		windows, err := s.windowManager.EnumerateWindows(&types.WindowFilter{ExcludeSystem: true})
+		if err != nil {
+			return nil, err
+		}
+		return jsonResource(uri, map[string]interface{}{"windows": windows, "count": len(windows)})
+
+	case strings.HasPrefix(path, historyResource):
+		id, err := strconv.ParseUint(strings.TrimPrefix(path, historyResource), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid screenshot ID in %s", uri)
+		}
+		entry, ok := s.history.get(id)
+		if !ok {
+			return nil, fmt.Errorf("screenshot %d is no longer kept", id)
+		}
+		return &types.MCPResourceContents{URI: uri, MimeType: imageMIMEType(entry.Format), Blob: entry.Data}, nil
+
+	case strings.HasPrefix(path, recordingsResource):
+		id, frame, isFrame := strings.Cut(strings.TrimPrefix(path, recordingsResource), "/frames/")
+		if !isFrame {
+			info, err := s.recorder.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			return jsonResource(uri, info)
+		}
+		number, err := strconv.ParseInt(frame, 10, 64)
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("invalid frame number in %s", uri)
+		}
+		framePath, err := s.recorder.FramePath(id, number)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(framePath)
+		if err != nil {
+			return nil, err
+		}
+		mimeType := mime.TypeByExtension(filepath.Ext(framePath))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		return &types.MCPResourceContents{URI: uri, MimeType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}, nil
+	}
+	return nil, fmt.Errorf("unknown resource %s", uri)
+}
+
+// jsonResource returns a resource holding v as JSON
+func jsonResource(uri string, v interface{}) (*types.MCPResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &types.MCPResourceContents{URI: uri, MimeType: "application/json", Text: string(data)}, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// RPC debug settings
+const (
+	rpcDebugLimit       = 200 // Exchanges kept
+	rpcDebugStringLimit = 256 // Longest string kept whole, such as base64 image data
+	rpcDebugStringKeep  = 64  // Leading bytes kept of longer strings
+)
+
+// rpcDebugSecrets are substrings of the keys whose values are never kept
+var rpcDebugSecrets = []string{"token", "password", "secret", "authorization", "dsn"}
+
+// rpcExchange is a sanitized MCP request and the response the server sent
+type rpcExchange struct {
+	Seq       int64           `json:"seq"`
+	Time      time.Time       `json:"time"`
+	Transport string          `json:"transport"` // "http", "sse" or "pipe"
+	ClientIP  string          `json:"client_ip,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	ID        interface{}     `json:"id,omitempty"`
+	Duration  time.Duration   `json:"duration"`
+	ErrorCode int             `json:"error_code,omitempty"` // JSON-RPC error code of the response
+	Error     string          `json:"error,omitempty"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response,omitempty"` // Absent for notifications
+}
+
+// rpcDebugResponse lists the recorded exchanges
+type rpcDebugResponse struct {
+	Exchanges []rpcExchange `json:"exchanges"`
+	Count     int           `json:"count"`
+}
+
+// rpcLog keeps the most recent MCP exchanges while RPC debugging is enabled
+type rpcLog struct {
+	mutex     sync.Mutex
+	exchanges []rpcExchange
+	next      int // Slot the next exchange overwrites once the log is full
+	seq       int64
+}
+
+// add records an exchange, dropping the oldest once the log is full
+func (l *rpcLog) add(exchange rpcExchange) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.seq++
+	exchange.Seq = l.seq
+	if len(l.exchanges) < rpcDebugLimit {
+		l.exchanges = append(l.exchanges, exchange)
+		return
+	}
+	l.exchanges[l.next] = exchange
+	l.next = (l.next + 1) % rpcDebugLimit
+}
+
+// recent returns the recorded exchanges, oldest first
+func (l *rpcLog) recent() []rpcExchange {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	exchanges := make([]rpcExchange, 0, len(l.exchanges))
+	exchanges = append(exchanges, l.exchanges[l.next:]...)
+	return append(exchanges, l.exchanges[:l.next]...)
+}
+
+func (l *rpcLog) clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.exchanges, l.next = nil, 0
+}
+
+// teeResponseWriter keeps a copy of the body written through it
+type teeResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *teeResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *teeResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// traceMCP starts recording the MCP request of c, returning the function that records
+// it with its response once handled
+func (s *Server) traceMCP(c *gin.Context) func() {
+	start := time.Now()
+	body, _ := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	writer := &teeResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	return func() {
+		c.Writer = writer.ResponseWriter
+
+		exchange := rpcExchange{
+			Time:      start,
+			Transport: "http",
+			ClientIP:  c.ClientIP(),
+			Duration:  time.Since(start),
+			Request:   sanitizeRPC(body),
+		}
+		switch {
+		case c.Request.RemoteAddr == "pipe":
+			exchange.Transport, exchange.ClientIP = "pipe", ""
+		case c.Request.URL.Path == "/messages":
+			exchange.Transport = "sse"
+		}
+
+		var req types.MCPRequest
+		if json.Unmarshal(body, &req) == nil {
+			exchange.Method, exchange.ID = req.Method, req.ID
+		}
+		if writer.body.Len() > 0 {
+			exchange.Response = sanitizeRPC(writer.body.Bytes())
+			var resp types.MCPResponse
+			if json.Unmarshal(writer.body.Bytes(), &resp) == nil && resp.Error != nil {
+				exchange.ErrorCode, exchange.Error = resp.Error.Code, resp.Error.Message
+			}
+		}
+		s.rpcDebug.add(exchange)
+	}
+}
+
+// sanitizeRPC returns a copy of a JSON message with long strings truncated and secrets
+// redacted. A body that isn't JSON is kept, truncated, as a string.
+func sanitizeRPC(data []byte) json.RawMessage {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		value = truncateString(string(data))
+	} else {
+		value = sanitizeValue(value)
+	}
+	sanitized, err := json.Marshal(value)
+	if err != nil {
+		return json.RawMessage(`null`)
+	}
+	return sanitized
+}
+
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSecretKey(key) {
+				v[key] = "[redacted]"
+				continue
+			}
+			v[key] = sanitizeValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeValue(item)
+		}
+	case string:
+		return truncateString(v)
+	}
+	return value
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range rpcDebugSecrets {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateString shortens strings such as base64 image data to their first bytes and a
+// note of the length dropped
+func truncateString(s string) string {
+	if len(s) <= rpcDebugStringLimit {
+		return s
+	}
+	keep := rpcDebugStringKeep
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+	return fmt.Sprintf("%s... [%d bytes truncated]", s[:keep], len(s)-keep)
+}
+
+// rpcDebugEnabled rejects requests while RPC debugging is disabled
+func (s *Server) rpcDebugEnabled(c *gin.Context) bool {
+	if s.rpcDebug == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "RPC debugging is disabled; set SCREENSHOT_DEBUG_RPC=true to enable it"})
+		return false
+	}
+	return true
+}
+
+// getRPCDebug lists the recorded MCP exchanges, oldest first
+func (s *Server) getRPCDebug(c *gin.Context) {
+	if !s.rpcDebugEnabled(c) {
+		return
+	}
+	exchanges := s.rpcDebug.recent()
+	c.JSON(http.StatusOK, rpcDebugResponse{Exchanges: exchanges, Count: len(exchanges)})
+}
+
+// clearRPCDebug drops the recorded MCP exchanges
+func (s *Server) clearRPCDebug(c *gin.Context) {
+	if !s.rpcDebugEnabled(c) {
+		return
+	}
+	s.rpcDebug.clear()
+	c.Status(http.StatusNoContent)
+}
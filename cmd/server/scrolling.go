@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Scrolling capture limits and defaults
+const (
+	defaultScrollNotches = 5
+	defaultScrollPages   = 20
+	maxScrollPages       = 100
+	defaultScrollSettle  = 300 * time.Millisecond
+	maxScrollSettle      = 5 * time.Second
+	maxStitchedHeight    = 32000 // Rows; scrolling stops once the image is this tall
+	scrollBarMargin      = 24    // Columns at 96 DPI ignored when matching captures
+)
+
+// scrollCapture scrolls a window's content down, capturing it after each scroll, and
+// stitches the captures into one image
+func (s *Server) scrollCapture(ctx context.Context, req *types.ScrollCaptureRequest) (*types.ScrollCaptureResponse, error) {
+	driver, err := s.inputDriver()
+	if err != nil {
+		return nil, err
+	}
+	if req.Method == "" {
+		req.Method = "foreground"
+	}
+	if req.Target == "" && methodRequiresTarget(req.Method) {
+		return nil, invalidRequest(fmt.Errorf("window method %s needs a target", req.Method))
+	}
+	if req.Scroll == "" {
+		req.Scroll = "wheel"
+	}
+	if req.Scroll != "wheel" && req.Scroll != "page" {
+		return nil, invalidRequest(fmt.Errorf("invalid scroll %q (valid: wheel, page)", req.Scroll))
+	}
+	notches := req.WheelNotches
+	if notches == 0 {
+		notches = defaultScrollNotches
+	}
+	pages := req.MaxPages
+	if pages == 0 {
+		pages = defaultScrollPages
+	}
+	if notches < 0 || pages < 0 || pages > maxScrollPages {
+		return nil, invalidRequest(fmt.Errorf("wheel_notches must be positive and max_pages at most %d", maxScrollPages))
+	}
+	settle := defaultScrollSettle
+	if req.Settle != "" {
+		if settle, err = time.ParseDuration(req.Settle); err != nil || settle < 0 || settle > maxScrollSettle {
+			return nil, invalidRequest(fmt.Errorf("invalid settle %q (at most %s)", req.Settle, maxScrollSettle))
+		}
+	}
+	if err := validateResponseBudget(req.MaxResponseBytes); err != nil {
+		return nil, invalidRequest(err)
+	}
+
+	handle, err := s.findWindowHandle(req.Method, req.Target)
+	if err != nil {
+		return nil, err
+	}
+	// The wheel scrolls the window under the pointer and Page Down the focused one
+	if err := s.windowManager.BringToForeground(handle); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	options := &types.CaptureOptions{
+		IncludeFrame:     true,
+		ScaleFactor:      1.0,
+		WaitForVisible:   2 * time.Second,
+		RetryCount:       3,
+		RetryBackoff:     100 * time.Millisecond,
+		RetryMaxBackoff:  2 * time.Second,
+		Context:          ctx,
+		CustomProperties: make(map[string]string),
+	}
+	first, err := s.engine.CaptureByHandle(handle, options)
+	if err != nil {
+		return nil, err
+	}
+	margin := scrollBarMargin
+	if first.DPI > 0 {
+		margin = scrollBarMargin * first.DPI / 96
+	}
+	stitcher, err := screenshot.NewStitcher(first, margin)
+	if err != nil {
+		return nil, err
+	}
+
+	rect := first.WindowInfo.Rect
+	x, y := rect.X+rect.Width/2, rect.Y+rect.Height/2
+	response := &types.ScrollCaptureResponse{Pages: 1}
+	for page := 0; page < pages && stitcher.Height() < maxStitchedHeight; page++ {
+		if req.Scroll == "page" {
+			err = driver.Press("pagedown")
+		} else {
+			err = driver.Scroll(x, y, -notches)
+		}
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(settle):
+		}
+
+		next, err := s.engine.CaptureByHandle(handle, options)
+		if err != nil {
+			return nil, err
+		}
+		added, _, err := stitcher.Add(next)
+		if err != nil {
+			return nil, err
+		}
+		if added == 0 {
+			response.Complete = true
+			break
+		}
+		response.Pages++
+	}
+	response.Unmatched = stitcher.Unmatched()
+
+	buffer, err := s.postProcess(stitcher.Image(), req.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	response.Screenshot = &types.ScreenshotResponse{
+		Success:   true,
+		Data:      base64.StdEncoding.EncodeToString(buffer.Data),
+		Format:    buffer.Format,
+		Width:     buffer.Width,
+		Height:    buffer.Height,
+		Size:      int64(len(buffer.Data)),
+		Timestamp: buffer.Timestamp,
+		Metadata: types.Metadata{
+			CaptureMethod:  captureMethodName(buffer, req.Method),
+			ProcessingTime: time.Since(start),
+			WindowVisible:  buffer.WindowInfo.IsVisible,
+			DPIScaling:     float64(buffer.DPI) / 96.0,
+			ColorDepth:     32,
+			Properties:     options.CustomProperties,
+		},
+	}
+	budget := &types.ScreenshotRequest{Format: req.Format, Quality: req.Quality, MaxResponseBytes: req.MaxResponseBytes}
+	if err := fitScreenshotBudget(response.Screenshot, buffer, nil, budget); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Scrolling capture completed",
+		zap.String("method", req.Method),
+		zap.String("target", req.Target),
+		zap.Int("pages", response.Pages),
+		zap.Int("height", buffer.Height),
+		zap.Bool("complete", response.Complete),
+	)
+	return response, nil
+}
+
+// scrollingScreenshot captures a window's scrollable content into one tall image
+func (s *Server) scrollingScreenshot(c *gin.Context) {
+	var req types.ScrollCaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	response, err := s.scrollCapture(c.Request.Context(), &req)
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) handleMCPScreenshotScrolling(c *gin.Context, req *types.MCPRequest) {
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	var scroll types.ScrollCaptureRequest
+	if err := json.Unmarshal(data, &scroll); err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+
+	response, err := s.scrollCapture(c.Request.Context(), &scroll)
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	s.sendMCPResult(c, req.ID, response)
+}
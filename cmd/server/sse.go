@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MCP-over-SSE transport (MCP 2024-11-05): a client opens GET /sse, receives an
+// "endpoint" event naming its POST /messages URL, and gets every JSON-RPC response as a
+// "message" event on the stream. The messages are handled exactly like POST /rpc.
+
+// sseKeepAlive is how often an idle SSE stream sends a comment to keep proxies from
+// closing it
+const sseKeepAlive = 15 * time.Second
+
+// mcpSSESession is one connected SSE client
+type mcpSSESession struct {
+	id       string
+	messages chan []byte
+	done     chan struct{}
+}
+
+// mcpSSEHub tracks the open SSE sessions
+type mcpSSEHub struct {
+	sessions map[string]*mcpSSESession
+	mu       sync.Mutex
+	closed   bool
+}
+
+func newMCPSSEHub() *mcpSSEHub {
+	return &mcpSSEHub{sessions: make(map[string]*mcpSSESession)}
+}
+
+// open registers a new session; it returns nil once the hub is closed
+func (h *mcpSSEHub) open() (*mcpSSESession, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	session := &mcpSSESession{
+		id:       hex.EncodeToString(id),
+		messages: make(chan []byte, 16),
+		done:     make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil
+	}
+	h.sessions[session.id] = session
+	return session, nil
+}
+
+// get returns an open session
+func (h *mcpSSEHub) get(id string) *mcpSSESession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessions[id]
+}
+
+// remove forgets a session and ends its stream
+func (h *mcpSSEHub) remove(session *mcpSSESession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.sessions[session.id] == session {
+		delete(h.sessions, session.id)
+		close(session.done)
+	}
+}
+
+// Close ends every stream so server shutdown doesn't wait on them
+func (h *mcpSSEHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for id, session := range h.sessions {
+		delete(h.sessions, id)
+		close(session.done)
+	}
+}
+
+// handleMCPSSE opens an MCP SSE stream and relays the session's responses until the
+// client disconnects
+func (s *Server) handleMCPSSE(c *gin.Context) {
+	session, err := s.mcpSessions.open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+	defer s.mcpSessions.remove(session)
+
+	s.logger.Info("MCP SSE session opened",
+		zap.String("session_id", session.id),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent("endpoint", "/messages?sessionId="+session.id)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			s.logger.Info("MCP SSE session closed", zap.String("session_id", session.id))
+			return
+		case <-session.done:
+			return
+		case message := <-session.messages:
+			c.SSEvent("message", string(message))
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// handleMCPMessage accepts a JSON-RPC message for an SSE session. The message is
+// dispatched like POST /rpc and its response, if any, is sent on the session's stream.
+func (s *Server) handleMCPMessage(c *gin.Context) {
+	session := s.mcpSessions.get(c.Query("sessionId"))
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown or closed session"})
+		return
+	}
+
+	// Capture the response the MCP handlers write
+	writer := &bufferedResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	s.handleMCPRequest(c)
+	c.Writer = writer.ResponseWriter
+
+	if writer.body.Len() > 0 {
+		select {
+		case session.messages <- writer.body.Bytes():
+		case <-session.done:
+			c.JSON(http.StatusGone, gin.H{"error": "session closed"})
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+
+	c.String(http.StatusAccepted, "Accepted")
+}
+
+// bufferedResponseWriter holds back a handler's response headers, body and status
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.body.Len()
+}
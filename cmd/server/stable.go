@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// wait_for_stable defaults: a blinking caret or a small spinner stays under the threshold,
+// a page still laying out or a dialog fading in does not
+const (
+	defaultStableThreshold = 0.001
+	defaultStableTolerance = 8
+	defaultStableFrames    = 3
+	defaultStableTimeout   = 5 * time.Second
+	defaultStableInterval  = 100 * time.Millisecond
+)
+
+// stabilityPlan is a validated wait_for_stable condition
+type stabilityPlan struct {
+	threshold     float64
+	tolerance     int
+	frames        int
+	timeout       time.Duration
+	interval      time.Duration
+	failOnTimeout bool
+}
+
+// parseStabilityCondition validates a wait_for_stable block; a nil condition yields a nil plan
+func parseStabilityCondition(condition *types.StabilityCondition) (*stabilityPlan, error) {
+	if condition == nil {
+		return nil, nil
+	}
+
+	plan := &stabilityPlan{
+		threshold:     defaultStableThreshold,
+		tolerance:     defaultStableTolerance,
+		frames:        defaultStableFrames,
+		timeout:       defaultStableTimeout,
+		interval:      defaultStableInterval,
+		failOnTimeout: condition.FailOnTimeout,
+	}
+
+	if condition.Threshold != 0 {
+		if condition.Threshold < 0 || condition.Threshold > 1 {
+			return nil, fmt.Errorf("wait_for_stable.threshold must be between 0 and 1")
+		}
+		plan.threshold = condition.Threshold
+	}
+	if condition.Tolerance != 0 {
+		if condition.Tolerance < 0 || condition.Tolerance > 255 {
+			return nil, fmt.Errorf("wait_for_stable.tolerance must be between 0 and 255")
+		}
+		plan.tolerance = condition.Tolerance
+	}
+	if condition.Frames != 0 {
+		if condition.Frames < 2 {
+			return nil, fmt.Errorf("wait_for_stable.frames must be at least 2")
+		}
+		plan.frames = condition.Frames
+	}
+	if condition.Timeout != "" {
+		timeout, err := time.ParseDuration(condition.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid wait_for_stable.timeout: %s", condition.Timeout)
+		}
+		plan.timeout = timeout
+	}
+	if condition.Interval != "" {
+		interval, err := time.ParseDuration(condition.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid wait_for_stable.interval: %s", condition.Interval)
+		}
+		plan.interval = interval
+	}
+
+	return plan, nil
+}
+
+// parseStabilityQuery reads the wait_for_stable query parameter: "true" for the
+// defaults or a JSON object
+func parseStabilityQuery(value string) (*types.StabilityCondition, error) {
+	switch value {
+	case "true":
+		return &types.StabilityCondition{}, nil
+	case "false":
+		return nil, nil
+	}
+	var condition types.StabilityCondition
+	if err := json.Unmarshal([]byte(value), &condition); err != nil {
+		return nil, fmt.Errorf("invalid wait_for_stable: %w", err)
+	}
+	return &condition, nil
+}
+
+// waitForStable recaptures the window of a capture until plan.frames consecutive
+// captures differ by at most plan.threshold and returns the last one. On timeout the
+// last capture is returned with Stability.Stable false, unless the plan fails instead.
+func (s *Server) waitForStable(buffer *types.ScreenshotBuffer, plan *stabilityPlan, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	start := time.Now()
+	deadline := start.Add(plan.timeout)
+	handle := buffer.WindowInfo.Handle
+
+	info := &types.StabilityInfo{Frames: 1}
+	settled := 1
+	for settled < plan.frames {
+		if time.Now().Add(plan.interval).After(deadline) {
+			if plan.failOnTimeout {
+				return nil, types.NewCaptureError(types.ErrTimeout, fmt.Sprintf("timed out after %s waiting for the window to stop changing (%.2f%% of pixels changed last)", plan.timeout, info.Difference*100), nil)
+			}
+			break
+		}
+		if err := types.Sleep(options.Context, plan.interval); err != nil {
+			return nil, err
+		}
+
+		next, err := s.engine.CaptureByHandle(handle, options)
+		if err != nil {
+			return nil, err
+		}
+		info.Frames++
+		if info.Difference, err = screenshot.FrameDifference(buffer, next, plan.tolerance); err != nil {
+			return nil, err
+		}
+		if info.Difference <= plan.threshold {
+			settled++
+		} else {
+			settled = 1
+		}
+		buffer = next
+	}
+
+	info.Stable = settled >= plan.frames
+	info.Waited = time.Since(start)
+	buffer.Stability = info
+	return buffer, nil
+}
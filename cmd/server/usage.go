@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// usageSaveInterval is how often changed usage counters are saved
+const usageSaveInterval = 30 * time.Second
+
+// usageCounters counts captures, stream frames and capture method outcomes for as long as
+// the server has been used, persisted to a JSON file so they survive restarts
+type usageCounters struct {
+	mutex sync.Mutex
+	path  string // File the counters are saved to; "" keeps them in memory only
+	stats types.UsageStats
+	dirty bool // Changed since last saved
+}
+
+// loadUsageCounters reads the counters saved to path; without a file, counting starts now
+func loadUsageCounters(path string) (*usageCounters, error) {
+	u := &usageCounters{path: path, stats: types.UsageStats{Since: time.Now()}}
+	if path != "" {
+		if _, err := readJSONFile(path, &u.stats); err != nil {
+			return nil, fmt.Errorf("failed to load usage stats: %w", err)
+		}
+	}
+	if u.stats.Methods == nil {
+		u.stats.Methods = make(map[string]types.MethodUsage)
+	}
+	return u, nil
+}
+
+// recordCapture counts a capture and each method it tried, including the methods of
+// failed captures. Observes the capture engine.
+func (u *usageCounters) recordCapture(buffer *types.ScreenshotBuffer, err error) {
+	if buffer == nil && err == nil {
+		return
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.stats.Captures++
+	u.dirty = true
+	attempts := types.AttemptsOf(err)
+	if err != nil {
+		u.stats.FailedCaptures++
+	} else {
+		attempts = buffer.Attempts
+		if len(attempts) == 0 && buffer.CaptureMethod != "" {
+			u.countMethod(string(buffer.CaptureMethod), true)
+		}
+	}
+	for _, attempt := range attempts {
+		u.countMethod(string(attempt.Method), attempt.Success)
+	}
+}
+
+func (u *usageCounters) countMethod(method string, success bool) {
+	usage := u.stats.Methods[method]
+	usage.Attempts++
+	if success {
+		usage.Successes++
+	}
+	usage.SuccessRate = float64(usage.Successes) / float64(usage.Attempts)
+	u.stats.Methods[method] = usage
+}
+
+// RecordSession counts a stream session, implementing ws.UsageRecorder
+func (u *usageCounters) RecordSession() {
+	u.mutex.Lock()
+	u.stats.StreamSessions++
+	u.dirty = true
+	u.mutex.Unlock()
+}
+
+// RecordFrame counts a frame delivered to a stream client, implementing ws.UsageRecorder
+func (u *usageCounters) RecordFrame(bytes int) {
+	u.mutex.Lock()
+	u.stats.StreamFrames++
+	u.stats.StreamBytes += int64(bytes)
+	u.dirty = true
+	u.mutex.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (u *usageCounters) snapshot() types.UsageStats {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	stats := u.stats
+	stats.Methods = make(map[string]types.MethodUsage, len(u.stats.Methods))
+	for method, usage := range u.stats.Methods {
+		stats.Methods[method] = usage
+	}
+	return stats
+}
+
+// save writes the counters to their file if they changed since last saved
+func (u *usageCounters) save() error {
+	if u.path == "" {
+		return nil
+	}
+	u.mutex.Lock()
+	if !u.dirty {
+		u.mutex.Unlock()
+		return nil
+	}
+	u.dirty = false
+	u.mutex.Unlock()
+
+	if err := writeJSONFile(u.path, u.snapshot()); err != nil {
+		u.mutex.Lock()
+		u.dirty = true
+		u.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// run saves the counters every usageSaveInterval until stop is closed
+func (u *usageCounters) run(stop <-chan struct{}, logger *zap.Logger) {
+	ticker := time.NewTicker(usageSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := u.save(); err != nil {
+				logger.Warn("Failed to save usage stats", zap.String("path", u.path), zap.Error(err))
+			}
+		}
+	}
+}
+
+// getUsageStats returns the usage counters kept across restarts
+func (s *Server) getUsageStats(c *gin.Context) {
+	c.JSON(http.StatusOK, s.usage.snapshot())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageCountersSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	usage, err := loadUsageCounters(path)
+	require.NoError(t, err)
+
+	usage.recordCapture(&types.ScreenshotBuffer{
+		CaptureMethod: types.CaptureMethod("bitblt"),
+		Attempts: []types.CaptureAttempt{
+			{Method: types.CaptureMethod("wgc"), Success: false},
+			{Method: types.CaptureMethod("bitblt"), Success: true},
+		},
+	}, nil)
+	usage.recordCapture(nil, errors.New("window not found"))
+	usage.RecordSession()
+	usage.RecordFrame(1000)
+	require.NoError(t, usage.save())
+
+	restored, err := loadUsageCounters(path)
+	require.NoError(t, err)
+	stats := restored.snapshot()
+	assert.Equal(t, int64(2), stats.Captures)
+	assert.Equal(t, int64(1), stats.FailedCaptures)
+	assert.Equal(t, int64(1), stats.StreamSessions)
+	assert.Equal(t, int64(1), stats.StreamFrames)
+	assert.Equal(t, int64(1000), stats.StreamBytes)
+	assert.Equal(t, types.MethodUsage{Attempts: 1, Successes: 0, SuccessRate: 0}, stats.Methods["wgc"])
+	assert.Equal(t, types.MethodUsage{Attempts: 1, Successes: 1, SuccessRate: 1}, stats.Methods["bitblt"])
+	assert.True(t, stats.Since.Equal(usage.snapshot().Since))
+}
+
+func TestUsageCountersCountAttemptsOfFailedCaptures(t *testing.T) {
+	usage, err := loadUsageCounters("")
+	require.NoError(t, err)
+
+	failure := &types.AttemptsError{
+		Attempts: []types.CaptureAttempt{
+			{Method: types.CaptureMethod("bitblt"), Error: "access denied"},
+			{Method: types.CaptureMethod("print_window"), Error: "blank bitmap"},
+			{Method: types.CaptureMethod("bitblt"), Error: "access denied", Retry: 1},
+		},
+		Err: errors.New("all capture methods failed"),
+	}
+	usage.recordCapture(nil, fmt.Errorf("failed to capture window: %w", failure))
+	usage.recordCapture(&types.ScreenshotBuffer{
+		Attempts: []types.CaptureAttempt{{Method: types.CaptureMethod("bitblt"), Success: true}},
+	}, nil)
+
+	stats := usage.snapshot()
+	assert.Equal(t, int64(2), stats.Captures)
+	assert.Equal(t, int64(1), stats.FailedCaptures)
+	assert.Equal(t, types.MethodUsage{Attempts: 3, Successes: 1, SuccessRate: 1.0 / 3}, stats.Methods["bitblt"])
+	assert.Equal(t, types.MethodUsage{Attempts: 1}, stats.Methods["print_window"])
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/webcam"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// targetCapture returns the function capturing the target of the virtual webcam or an
+// RTMP output: a window handle, 0 for the desktop, "monitor:N" or "alias:name", as stream
+// routes take them. Frames get the server's watermark, like stream frames.
+func (s *Server) targetCapture(target string) (func(ctx context.Context) (*types.ScreenshotBuffer, error), error) {
+	windowID, monitor, err := s.parseStreamTarget(target)
+	if err != nil {
+		return nil, invalidRequest(err)
+	}
+	return func(ctx context.Context) (*types.ScreenshotBuffer, error) {
+		options := types.DefaultCaptureOptions()
+		options.AllowMinimized = true
+		options.RestoreWindow = false
+		options.Priority = types.PriorityStream
+		options.Context = ctx
+		var buffer *types.ScreenshotBuffer
+		var err error
+		if monitor != nil {
+			buffer, err = s.engine.CaptureFullScreen(*monitor, options)
+		} else {
+			buffer, err = s.engine.CaptureByHandle(uintptr(windowID), options)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.watermark(buffer)
+	}, nil
+}
+
+// startWebcam validates a request and starts the virtual webcam on its target
+func (s *Server) startWebcam(req types.WebcamRequest) (types.WebcamStatus, error) {
+	if err := webcam.ValidateRequest(&req); err != nil {
+		return types.WebcamStatus{}, invalidRequest(err)
+	}
+	capture, err := s.targetCapture(req.Target)
+	if err != nil {
+		return types.WebcamStatus{}, err
+	}
+	return s.webcam.Start(req, capture)
+}
+
+// getWebcam returns the virtual webcam's status
+func (s *Server) getWebcam(c *gin.Context) {
+	c.JSON(http.StatusOK, s.webcam.Status())
+}
+
+// postWebcam starts the virtual webcam
+func (s *Server) postWebcam(c *gin.Context) {
+	var req types.WebcamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	status, err := s.startWebcam(req)
+	var captureErr *types.CaptureError
+	switch {
+	case errors.As(err, &captureErr):
+		sendCaptureError(c, err)
+		return
+	case errors.Is(err, webcam.ErrRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		// softcam isn't installed, or this isn't Windows
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, status)
+}
+
+// deleteWebcam stops the virtual webcam
+func (s *Server) deleteWebcam(c *gin.Context) {
+	status, err := s.webcam.Stop()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// handleMCPWebcamStart handles MCP virtual webcam start requests
+func (s *Server) handleMCPWebcamStart(c *gin.Context, req *types.MCPRequest) {
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	var webcamReq types.WebcamRequest
+	if err := json.Unmarshal(data, &webcamReq); err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	status, err := s.startWebcam(webcamReq)
+	if err != nil {
+		var captureErr *types.CaptureError
+		if errors.As(err, &captureErr) {
+			s.sendMCPCaptureError(c, req.ID, err)
+			return
+		}
+		s.sendMCPError(c, req.ID, -32603, "Failed to start virtual webcam", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, status)
+}
+
+// handleMCPWebcamStop handles MCP virtual webcam stop requests
+func (s *Server) handleMCPWebcamStop(c *gin.Context, req *types.MCPRequest) {
+	status, err := s.webcam.Stop()
+	if err != nil {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	s.sendMCPResult(c, req.ID, status)
+}
+
+// handleMCPWebcamStatus handles MCP virtual webcam status requests
+func (s *Server) handleMCPWebcamStatus(c *gin.Context, req *types.MCPRequest) {
+	s.sendMCPResult(c, req.ID, s.webcam.Status())
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/screenshot-mcp-server/internal/input"
+	"github.com/screenshot-mcp-server/internal/scheduler"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/window"
+	"github.com/screenshot-mcp-server/internal/workflow"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// workflowListResponse lists the workflows of the workflows directory
+type workflowListResponse struct {
+	Workflows []types.WorkflowInfo `json:"workflows"`
+	Count     int                  `json:"count"`
+	Errors    []string             `json:"errors,omitempty"` // Scripts that don't load
+}
+
+// compareTolerance is the channel difference below which capture's expect counts pixels
+// as unchanged, absorbing font smoothing and compression noise
+const compareTolerance = 16
+
+// workflowHost performs workflow commands on this server's windows
+type workflowHost struct {
+	server *Server
+}
+
+// Focus brings the selected window to the front
+func (h workflowHost) Focus(ctx context.Context, method, target string) error {
+	handle, err := h.server.findWindowHandle(method, target)
+	if err != nil {
+		return err
+	}
+	return h.server.windowManager.BringToForeground(handle)
+}
+
+// Capture takes a screenshot with the server's default format and quality
+func (h workflowHost) Capture(ctx context.Context, req *types.ScreenshotRequest) (*types.ScreenshotResponse, error) {
+	if req.Target == "" && methodRequiresTarget(req.Method) {
+		return nil, invalidRequest(fmt.Errorf("window method %s needs a target", req.Method))
+	}
+	return h.server.screenshot(ctx, req)
+}
+
+// Compare returns the share of pixels of a screenshot that differ from an image file
+func (h workflowHost) Compare(ctx context.Context, response *types.ScreenshotResponse, reference string) (float64, error) {
+	data, err := os.ReadFile(reference)
+	if err != nil {
+		return 0, err
+	}
+	expected, err := screenshot.NewImageProcessor().Decode(data)
+	if err != nil {
+		return 0, err
+	}
+	actual, err := responseBuffer(response)
+	if err != nil {
+		return 0, err
+	}
+	return screenshot.FrameDifference(actual, expected, compareTolerance)
+}
+
+// Click clicks a mouse button at screen coordinates
+func (h workflowHost) Click(ctx context.Context, x, y int, button string) error {
+	driver, err := h.server.inputDriver()
+	if err != nil {
+		return err
+	}
+	return driver.Click(x, y, button)
+}
+
+// Press presses a key combination
+func (h workflowHost) Press(ctx context.Context, combo string) error {
+	driver, err := h.server.inputDriver()
+	if err != nil {
+		return err
+	}
+	return driver.Press(combo)
+}
+
+// Type types text
+func (h workflowHost) Type(ctx context.Context, text string) error {
+	driver, err := h.server.inputDriver()
+	if err != nil {
+		return err
+	}
+	return driver.Type(text)
+}
+
+// inputDriver returns the input driver, unless input isn't allowed
+func (s *Server) inputDriver() (input.Driver, error) {
+	if s.input == nil {
+		return nil, types.NewCaptureError(types.ErrAccessDenied, "input is not allowed; set SCREENSHOT_ALLOW_INPUT=true", nil)
+	}
+	return s.input, nil
+}
+
+// responseBuffer decodes the image of a screenshot response
+func responseBuffer(response *types.ScreenshotResponse) (*types.ScreenshotBuffer, error) {
+	data, err := base64.StdEncoding.DecodeString(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch response.Format {
+	case "BGRA32", "RGBA32":
+		return &types.ScreenshotBuffer{
+			Data:   data,
+			Width:  response.Width,
+			Height: response.Height,
+			Stride: response.Width * 4,
+			Format: response.Format,
+		}, nil
+	}
+	return screenshot.NewImageProcessor().Decode(data)
+}
+
+// findWindowHandle resolves a window as a screenshot request's method and target would
+func (s *Server) findWindowHandle(method, target string) (uintptr, error) {
+	var filter types.WindowFilter
+	switch method {
+	case aliasMethod:
+		alias, ok := s.aliases.get(target)
+		if !ok {
+			return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("target alias %q not found", target), nil)
+		}
+		return s.findWindowHandle(alias.Method, alias.Target)
+	case "handle":
+		handle, err := strconv.ParseUint(target, 10, 64)
+		if err != nil {
+			return 0, invalidRequest(fmt.Errorf("invalid handle: %s", target))
+		}
+		return uintptr(handle), nil
+	case "foreground":
+		return s.windowManager.GetForegroundWindow()
+	case "title", "title_contains", "title_regex", "title_fuzzy":
+		windows, err := s.windowManager.EnumerateWindows(&types.WindowFilter{ExcludeSystem: true})
+		if err != nil {
+			return 0, fmt.Errorf("failed to enumerate windows: %w", err)
+		}
+		mode := map[string]window.TitleMatchMode{
+			"title":          window.TitleMatchExact,
+			"title_contains": window.TitleMatchContains,
+			"title_regex":    window.TitleMatchRegex,
+			"title_fuzzy":    window.TitleMatchFuzzy,
+		}[method]
+		match, err := window.SelectWindowByTitle(windows, target, mode, false)
+		if err != nil {
+			return 0, err
+		}
+		return match.Handle, nil
+	case "pid":
+		pid, err := strconv.ParseUint(target, 10, 32)
+		if err != nil {
+			return 0, invalidRequest(fmt.Errorf("invalid PID: %s", target))
+		}
+		filter.ProcessIDs = []uint32{uint32(pid)}
+	case "class":
+		filter.ClassNames = []string{target}
+	default:
+		return 0, invalidRequest(fmt.Errorf("focus doesn't support window method %s", method))
+	}
+
+	filter.VisibleOnly = true
+	windows, err := s.windowManager.EnumerateWindows(&filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enumerate windows: %w", err)
+	}
+	if len(windows) == 0 {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "window not found", nil)
+	}
+	return windows[0].Handle, nil
+}
+
+// runWorkflow loads a workflow and runs it
+func (s *Server) runWorkflow(ctx context.Context, name string, params map[string]string) (*types.WorkflowRunResponse, error) {
+	script, err := workflow.Load(s.config.Load().WorkflowDir, name)
+	if err != nil {
+		return nil, err
+	}
+	// A workflow's captures wait behind interactive requests
+	ctx = scheduler.WithPriority(ctx, types.PriorityScheduled)
+	response, err := workflow.Run(ctx, script, workflowHost{server: s}, params)
+	if err != nil {
+		s.logger.Error("Workflow failed", zap.String("workflow", name), zap.Error(err))
+		if errors.Is(err, workflow.ErrInvalidParams) {
+			return nil, invalidRequest(err)
+		}
+		return nil, err
+	}
+	s.logger.Info("Workflow completed",
+		zap.String("workflow", name),
+		zap.Int("captures", len(response.Captures)),
+		zap.Duration("duration", response.Duration),
+	)
+	return response, nil
+}
+
+// listWorkflows lists the workflows with their parameters
+func (s *Server) listWorkflows() workflowListResponse {
+	scripts, errs := workflow.List(s.config.Load().WorkflowDir)
+	response := workflowListResponse{Workflows: make([]types.WorkflowInfo, 0, len(scripts))}
+	for _, script := range scripts {
+		response.Workflows = append(response.Workflows, script.Info())
+	}
+	for _, err := range errs {
+		response.Errors = append(response.Errors, err.Error())
+	}
+	response.Count = len(response.Workflows)
+	return response
+}
+
+// getWorkflows lists the workflows of the workflows directory
+func (s *Server) getWorkflows(c *gin.Context) {
+	c.JSON(http.StatusOK, s.listWorkflows())
+}
+
+// postWorkflow runs a workflow
+func (s *Server) postWorkflow(c *gin.Context) {
+	var req types.WorkflowRunRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	response, err := s.runWorkflow(c.Request.Context(), c.Param("name"), req.Params)
+	if errors.Is(err, workflow.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		sendCaptureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) handleMCPWorkflowList(c *gin.Context, req *types.MCPRequest) {
+	s.sendMCPResult(c, req.ID, s.listWorkflows())
+}
+
+func (s *Server) handleMCPWorkflowRun(c *gin.Context, req *types.MCPRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	name := getString(params, "name", "")
+	if name == "" {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", "name is required")
+		return
+	}
+	values := make(map[string]string)
+	if raw, ok := params["params"].(map[string]interface{}); ok {
+		for key, value := range raw {
+			if str, ok := value.(string); ok {
+				values[key] = str
+			} else {
+				values[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	response, err := s.runWorkflow(c.Request.Context(), name, values)
+	if errors.Is(err, workflow.ErrNotFound) {
+		s.sendMCPError(c, req.ID, -32602, "Invalid params", err.Error())
+		return
+	}
+	if err != nil {
+		s.sendMCPCaptureError(c, req.ID, err)
+		return
+	}
+	s.sendMCPResult(c, req.ID, response)
+}
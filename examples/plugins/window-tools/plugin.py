@@ -0,0 +1,84 @@
+"""Example plugin for the screenshot MCP server.
+
+Adds the MCP tool window_tools.find, which looks up windows through the server's REST API,
+and the pipeline stage window_tools.log_size, which logs the size of each image it is given
+and passes it on unchanged. Copy this directory into the server's plugins directory to try it.
+"""
+
+import json
+import signal
+import sys
+import urllib.parse
+import urllib.request
+
+server_url = None
+
+
+def initialize(params):
+    global server_url
+    server_url = params["server_url"]
+    return {
+        "version": "1.0.0",
+        "description": "Window lookup and image size logging",
+        "tools": [
+            {
+                "name": "find",
+                "description": "Windows whose title contains the given text",
+                "params": {
+                    "type": "object",
+                    "properties": {"title": {"type": "string"}},
+                    "required": ["title"],
+                },
+            }
+        ],
+        "stages": [{"name": "log_size", "description": "Logs the PNG size of each image"}],
+    }
+
+
+def call_tool(params):
+    if params["tool"] != "find":
+        raise RpcError(-32601, "Unknown tool")
+    title = (params.get("params") or {}).get("title")
+    if not title:
+        raise RpcError(-32602, "title is required")
+    with urllib.request.urlopen(server_url + "/api/windows") as response:
+        windows = json.load(response)["windows"] or []
+    matches = [w for w in windows if title.lower() in w["title"].lower()]
+    return {"windows": [{"handle": w["handle"], "title": w["title"]} for w in matches]}
+
+
+def apply_stage(params):
+    # Logs go to stderr, which the server writes to its own log
+    print(f"{params['stage']}: {len(params['image']) * 3 // 4} bytes", file=sys.stderr, flush=True)
+    return {"image": params["image"]}
+
+
+class RpcError(Exception):
+    def __init__(self, code, message):
+        super().__init__(message)
+        self.code = code
+        self.message = message
+
+
+methods = {"initialize": initialize, "tool.call": call_tool, "stage.apply": apply_stage}
+
+# The server stops its plugins when it shuts down; leave Ctrl+C to it
+signal.signal(signal.SIGINT, signal.SIG_IGN)
+
+for line in sys.stdin:
+    message = json.loads(line)
+    if "id" not in message:
+        if message["method"] == "shutdown":
+            break
+        continue
+    reply = {"jsonrpc": "2.0", "id": message["id"]}
+    try:
+        handler = methods.get(message["method"])
+        if handler is None:
+            raise RpcError(-32601, "Method not found")
+        reply["result"] = handler(message.get("params") or {})
+    except RpcError as error:
+        reply["error"] = {"code": error.code, "message": error.message}
+    except Exception as error:
+        reply["error"] = {"code": -32603, "message": str(error)}
+    print(json.dumps(reply), flush=True)
@@ -0,0 +1,88 @@
+// Package activity tells whether the user is using the machine, from the time of the
+// last keyboard or mouse input, and decides whether streams and recordings pause for it.
+package activity
+
+import (
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Activity states
+const (
+	StateActive  = "active"
+	StateIdle    = "idle"
+	StateUnknown = "unknown"
+)
+
+// DefaultIdleAfter is the time without input after which the user is idle
+const DefaultIdleAfter = 5 * time.Minute
+
+// Monitor reads the last input time when asked; reading it is cheap enough to do for
+// every frame
+type Monitor struct {
+	lastInput func() (time.Time, error)
+
+	mutex     sync.RWMutex
+	gate      types.ActivityGate
+	idleAfter time.Duration
+}
+
+// NewMonitor creates a monitor reading the last input time from lastInput
+func NewMonitor(lastInput func() (time.Time, error), gate types.ActivityGate, idleAfter time.Duration) *Monitor {
+	m := &Monitor{lastInput: lastInput}
+	m.Configure(gate, idleAfter)
+	return m
+}
+
+// Configure changes the gate and the time without input after which the user is idle
+func (m *Monitor) Configure(gate types.ActivityGate, idleAfter time.Duration) {
+	if gate == "" {
+		gate = types.ActivityGateNone
+	}
+	if idleAfter <= 0 {
+		idleAfter = DefaultIdleAfter
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.gate, m.idleAfter = gate, idleAfter
+}
+
+// State reports the user's activity and whether the gate pauses captures
+func (m *Monitor) State() types.ActivityState {
+	m.mutex.RLock()
+	gate, idleAfter := m.gate, m.idleAfter
+	m.mutex.RUnlock()
+
+	state := types.ActivityState{State: StateUnknown, IdleAfter: idleAfter, Gate: gate}
+	last, err := m.lastInput()
+	if err != nil || last.IsZero() {
+		// Without input times nothing is paused
+		return state
+	}
+	state.LastInput = &last
+	state.IdleFor = max(time.Since(last), 0)
+	state.State = StateActive
+	if state.IdleFor >= idleAfter {
+		state.State = StateIdle
+	}
+	state.CapturePaused = (gate == types.ActivityGateActive && state.State == StateActive) ||
+		(gate == types.ActivityGateIdle && state.State == StateIdle)
+	return state
+}
+
+// Paused reports whether streams and recordings are paused now. A nil monitor never
+// pauses them.
+func (m *Monitor) Paused() bool {
+	if m == nil {
+		return false
+	}
+	m.mutex.RLock()
+	gate := m.gate
+	m.mutex.RUnlock()
+	if gate == types.ActivityGateNone {
+		return false
+	}
+	return m.State().CapturePaused
+}
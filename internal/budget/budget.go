@@ -0,0 +1,103 @@
+// Package budget measures the CPU time streams spend capturing and encoding frames and
+// lowers their frame rate to keep each within a share of a CPU core.
+package budget
+
+import (
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+const (
+	sampleWindow  = time.Second // Usage is averaged over this long before the cap changes
+	raiseHeadroom = 0.8         // Share of the budget a raised frame rate is expected to use
+)
+
+// Measure runs fn on the calling goroutine's thread and returns the CPU time it used,
+// or the time it took where the thread's CPU time can't be read. Work fn hands to other
+// goroutines isn't counted.
+func Measure(fn func()) time.Duration {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	began := time.Now()
+	start, ok := threadTime()
+	fn()
+	if end, ended := threadTime(); ok && ended {
+		return end - start
+	}
+	return time.Since(began)
+}
+
+// Governor tracks the CPU time of one stream and caps its frame rate while the stream
+// uses more than its budget. It isn't safe for concurrent use.
+type Governor struct {
+	start  time.Time
+	cpu    time.Duration // Used since start
+	frames int           // Captured since start
+	usage  float64       // Percent of a core used over the last window
+	cap    int           // Frame rate cap; 0 while uncapped
+}
+
+// NewGovernor creates a governor for a stream starting now
+func NewGovernor() *Governor {
+	return &Governor{start: time.Now()}
+}
+
+// Add records the CPU time one frame took to capture and encode
+func (g *Governor) Add(cpu time.Duration) {
+	g.cpu += cpu
+	g.frames++
+}
+
+// Limit returns the frame rate to stream at instead of requested under a budget of
+// percent of a core, 0 for none, and whether it changed. The cap is revised once per
+// window: lowered to what the measured cost per frame allows while over budget, and
+// raised back, up to requested, once the raised rate fits the budget with headroom.
+func (g *Governor) Limit(requested, percent int, now time.Time) (int, bool) {
+	previous := g.fps(requested)
+	if percent <= 0 {
+		g.cap = 0
+	} else if elapsed := now.Sub(g.start); elapsed >= sampleWindow && g.frames > 0 {
+		budget := float64(percent) / 100
+		perFrame := g.cpu.Seconds() / float64(g.frames)
+		g.usage = math.Round(g.cpu.Seconds()/elapsed.Seconds()*1000) / 10
+		current := previous
+		switch {
+		case g.usage > float64(percent) && perFrame > 0:
+			g.cap = max(1, min(current-1, int(budget/perFrame)))
+		case g.cap > 0 && perFrame > 0:
+			if raised := int(budget * raiseHeadroom / perFrame); raised > current {
+				g.cap = raised
+			}
+		}
+		g.start, g.cpu, g.frames = now, 0, 0
+	}
+	if g.cap >= requested {
+		g.cap = 0
+	}
+	fps := g.fps(requested)
+	return fps, fps != previous
+}
+
+func (g *Governor) fps(requested int) int {
+	if g.cap > 0 && g.cap < requested {
+		return g.cap
+	}
+	return requested
+}
+
+// Usage returns the percent of a core the stream used over the last window
+func (g *Governor) Usage() float64 {
+	return g.usage
+}
+
+// Throttle describes the cap under a budget of percent of a core, or nil while uncapped
+func (g *Governor) Throttle(percent int) *types.CPUThrottle {
+	if g.cap == 0 {
+		return nil
+	}
+	return &types.CPUThrottle{Budget: percent, Usage: g.usage, FPS: g.cap}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package budget
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"golang.org/x/sys/windows"
+)
+
+var getThreadTimes = win32.Kernel32.NewProc("GetThreadTimes")
+
+// threadTime returns the kernel and user time of the calling thread
+func threadTime() (time.Duration, bool) {
+	var creation, exit, kernel, user windows.Filetime
+	ret, _, _ := getThreadTimes.Call(
+		uintptr(windows.CurrentThread()),
+		uintptr(unsafe.Pointer(&creation)),
+		uintptr(unsafe.Pointer(&exit)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+	if ret == 0 {
+		return 0, false
+	}
+	return filetimeDuration(kernel) + filetimeDuration(user), true
+}
+
+// filetimeDuration converts a FILETIME span, counted in 100ns intervals
+func filetimeDuration(t windows.Filetime) time.Duration {
+	return time.Duration(int64(t.HighDateTime)<<32|int64(t.LowDateTime)) * 100
+}
@@ -0,0 +1,477 @@
+package chrome
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// frameSettleDelay is how long to keep collecting attach events after Target.setAutoAttach returns
+const frameSettleDelay = 250 * time.Millisecond
+
+// devtoolsSession wraps a tab's DevTools connection for multi-command exchanges
+// using the flattened session protocol, so OOPIF targets can be addressed by sessionId
+type devtoolsSession struct {
+	cm        *ChromeManager
+	conn      *websocket.Conn
+	responses chan map[string]interface{}
+	nextID    int
+	frames    []types.ChromeFrame
+	dpr       float64 // Device pixels per CSS pixel, known after attachFrames
+}
+
+// cssRect is a rectangle in CSS pixels
+type cssRect struct {
+	X, Y, Width, Height float64
+}
+
+// scaled converts the rectangle to whole pixels at scale pixels per CSS pixel
+func (r cssRect) scaled(scale float64) types.Rectangle {
+	return types.Rectangle{
+		X:      int(math.Round(r.X * scale)),
+		Y:      int(math.Round(r.Y * scale)),
+		Width:  int(math.Round(r.Width * scale)),
+		Height: int(math.Round(r.Height * scale)),
+	}
+}
+
+// frameBox is an out-of-process iframe with the content box of its owner element, in CSS
+// pixels relative to the viewport. Frames that couldn't be located have an empty box.
+type frameBox struct {
+	frame types.ChromeFrame
+	box   cssRect
+}
+
+func (f frameBox) visible() bool {
+	return f.box.Width > 0 && f.box.Height > 0
+}
+
+// openSession connects to a tab's DevTools WebSocket
+func (cm *ChromeManager) openSession(tab *types.ChromeTab) (*devtoolsSession, error) {
+	if tab == nil {
+		return nil, fmt.Errorf("tab cannot be nil")
+	}
+
+	if tab.WebSocketURL == "" {
+		return nil, fmt.Errorf("tab does not have WebSocket URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cm.timeout)
+	defer cancel()
+
+	conn, _, err := cm.wsDialer.DialContext(ctx, tab.WebSocketURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to tab WebSocket: %w", err)
+	}
+
+	session := &devtoolsSession{
+		cm:        cm,
+		conn:      conn,
+		responses: make(chan map[string]interface{}, 64),
+		nextID:    100,
+	}
+
+	errors := make(chan error, 1)
+	go cm.handleWebSocketMessages(conn, session.responses, errors)
+
+	return session, nil
+}
+
+// Close closes the underlying DevTools connection
+func (s *devtoolsSession) Close() error {
+	return s.conn.Close()
+}
+
+// call sends a DevTools command (optionally on a child session) and waits for its result.
+// Events received while waiting are dispatched to handleEvent.
+func (s *devtoolsSession) call(sessionID, method string, params map[string]interface{}) (map[string]interface{}, error) {
+	s.nextID++
+	id := s.nextID
+
+	command := map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": params,
+	}
+	if sessionID != "" {
+		command["sessionId"] = sessionID
+	}
+
+	if err := s.conn.WriteJSON(command); err != nil {
+		return nil, fmt.Errorf("failed to send %s command: %w", method, err)
+	}
+
+	timeout := time.After(s.cm.timeout)
+	for {
+		select {
+		case response, ok := <-s.responses:
+			if !ok {
+				return nil, fmt.Errorf("connection closed while waiting for %s", method)
+			}
+
+			rawID, exists := response["id"]
+			if !exists {
+				s.handleEvent(response)
+				continue
+			}
+
+			if responseID, ok := rawID.(float64); !ok || int(responseID) != id {
+				continue
+			}
+
+			if errorObj, exists := response["error"]; exists {
+				return nil, fmt.Errorf("Chrome DevTools error: %v", errorObj)
+			}
+
+			result, _ := response["result"].(map[string]interface{})
+			return result, nil
+
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for %s response", method)
+		}
+	}
+}
+
+// drainEvents processes events that arrive within the given window
+func (s *devtoolsSession) drainEvents(window time.Duration) {
+	deadline := time.After(window)
+	for {
+		select {
+		case event, ok := <-s.responses:
+			if !ok {
+				return
+			}
+			if _, isResponse := event["id"]; !isResponse {
+				s.handleEvent(event)
+			}
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// handleEvent records iframe targets announced via Target.attachedToTarget
+func (s *devtoolsSession) handleEvent(event map[string]interface{}) {
+	if method, _ := event["method"].(string); method != "Target.attachedToTarget" {
+		return
+	}
+
+	params, _ := event["params"].(map[string]interface{})
+	targetInfo, _ := params["targetInfo"].(map[string]interface{})
+	if targetType, _ := targetInfo["type"].(string); targetType != "iframe" {
+		return
+	}
+
+	frame := types.ChromeFrame{}
+	frame.SessionID, _ = params["sessionId"].(string)
+	frame.TargetID, _ = targetInfo["targetId"].(string)
+	frame.URL, _ = targetInfo["url"].(string)
+	frame.Title, _ = targetInfo["title"].(string)
+
+	s.frames = append(s.frames, frame)
+}
+
+// attachFrames enables auto-attach and locates the owner element of every OOPIF
+func (s *devtoolsSession) attachFrames() ([]frameBox, error) {
+	_, err := s.call("", "Target.setAutoAttach", map[string]interface{}{
+		"autoAttach":             true,
+		"waitForDebuggerOnStart": false,
+		"flatten":                true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable auto-attach: %w", err)
+	}
+
+	s.drainEvents(frameSettleDelay)
+
+	s.dpr = s.devicePixelRatio()
+	boxes := make([]frameBox, len(s.frames))
+	for i, frame := range s.frames {
+		boxes[i].frame = frame
+		box, err := s.frameBox(frame.TargetID)
+		if err != nil {
+			// Detached or invisible frames keep an empty rectangle
+			continue
+		}
+		boxes[i].box = box
+		boxes[i].frame.Rect = box.scaled(s.dpr)
+	}
+
+	return boxes, nil
+}
+
+// devicePixelRatio returns the tab's device pixel ratio, defaulting to 1
+func (s *devtoolsSession) devicePixelRatio() float64 {
+	result, err := s.call("", "Runtime.evaluate", map[string]interface{}{
+		"expression":    "window.devicePixelRatio",
+		"returnByValue": true,
+	})
+	if err != nil {
+		return 1.0
+	}
+
+	value, _ := result["result"].(map[string]interface{})
+	if ratio, ok := value["value"].(float64); ok && ratio > 0 {
+		return ratio
+	}
+	return 1.0
+}
+
+// scrollOffset returns the page position of the viewport in CSS pixels, which screenshot
+// clips are relative to
+func (s *devtoolsSession) scrollOffset() (float64, float64, error) {
+	result, err := s.call("", "Page.getLayoutMetrics", map[string]interface{}{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get layout metrics: %w", err)
+	}
+
+	// cssLayoutViewport replaced layoutViewport, which is in device pixels on newer versions
+	viewport, ok := result["cssLayoutViewport"].(map[string]interface{})
+	if !ok {
+		viewport, _ = result["layoutViewport"].(map[string]interface{})
+	}
+	x, _ := viewport["pageX"].(float64)
+	y, _ := viewport["pageY"].(float64)
+	return x, y, nil
+}
+
+// frameBox locates the owner <iframe> element of a frame and returns its content box
+func (s *devtoolsSession) frameBox(frameID string) (cssRect, error) {
+	owner, err := s.call("", "DOM.getFrameOwner", map[string]interface{}{
+		"frameId": frameID,
+	})
+	if err != nil {
+		return cssRect{}, err
+	}
+
+	backendNodeID, ok := owner["backendNodeId"].(float64)
+	if !ok {
+		return cssRect{}, fmt.Errorf("frame owner has no backend node")
+	}
+
+	boxResult, err := s.call("", "DOM.getBoxModel", map[string]interface{}{
+		"backendNodeId": int(backendNodeID),
+	})
+	if err != nil {
+		return cssRect{}, err
+	}
+
+	model, _ := boxResult["model"].(map[string]interface{})
+	quad, _ := model["content"].([]interface{})
+	if len(quad) != 8 {
+		return cssRect{}, fmt.Errorf("invalid box model")
+	}
+
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for i := 0; i < len(quad); i += 2 {
+		x, _ := quad[i].(float64)
+		y, _ := quad[i+1].(float64)
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	return cssRect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}, nil
+}
+
+// captureScreenshot captures the page as PNG. Chrome only captures top-level targets, so
+// frames are captured from the page too, clipped to their box.
+func (s *devtoolsSession) captureScreenshot(params map[string]interface{}) (*types.ScreenshotBuffer, error) {
+	result, err := s.call("", "Page.captureScreenshot", params)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result["data"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid screenshot response format")
+	}
+
+	imageData, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot data: %w", err)
+	}
+
+	return newPNGBuffer(imageData), nil
+}
+
+// captureFrame captures the part of the page a frame covers, at scale times the device
+// pixel ratio. scrollX and scrollY are the viewport's page position.
+func (s *devtoolsSession) captureFrame(frame frameBox, scrollX, scrollY, scale float64) (*types.ScreenshotBuffer, error) {
+	if !frame.visible() {
+		return nil, fmt.Errorf("frame %s has no visible owner element", frame.frame.URL)
+	}
+	buffer, err := s.captureScreenshot(map[string]interface{}{
+		"format": "png",
+		// Painting the page's own renderer, as background tab captures do, leaves
+		// out-of-process frames blank; the compositor surface has them
+		"fromSurface": true,
+		"clip": map[string]interface{}{
+			"x":      frame.box.X + scrollX,
+			"y":      frame.box.Y + scrollY,
+			"width":  frame.box.Width,
+			"height": frame.box.Height,
+			"scale":  scale,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("frame %s: %w", frame.frame.URL, err)
+	}
+	return buffer, nil
+}
+
+// GetFrames lists the out-of-process iframes attached to a tab
+func (cm *ChromeManager) GetFrames(tab *types.ChromeTab) ([]types.ChromeFrame, error) {
+	session, err := cm.openSession(tab)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	boxes, err := session.attachFrames()
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]types.ChromeFrame, len(boxes))
+	for i, box := range boxes {
+		frames[i] = box.frame
+	}
+	return frames, nil
+}
+
+// CaptureFrames captures every out-of-process iframe of a tab individually, in device
+// pixels like their rectangles. Frames that can't be captured, such as hidden ones, are
+// left out; it fails when none can.
+func (cm *ChromeManager) CaptureFrames(tab *types.ChromeTab, options *types.CaptureOptions) ([]types.ChromeFrameCapture, error) {
+	session, err := cm.openSession(tab)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	frames, err := session.attachFrames()
+	if err != nil {
+		return nil, err
+	}
+	captures := make([]types.ChromeFrameCapture, 0, len(frames))
+	if len(frames) == 0 {
+		return captures, nil
+	}
+
+	scrollX, scrollY, err := session.scrollOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	for _, frame := range frames {
+		buffer, err := session.captureFrame(frame, scrollX, scrollY, 1)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		captures = append(captures, types.ChromeFrameCapture{Frame: frame.frame, Buffer: buffer})
+	}
+	if len(captures) == 0 {
+		return nil, fmt.Errorf("none of the tab's %d frames could be captured: %w", len(frames), firstErr)
+	}
+
+	return captures, nil
+}
+
+// captureComposited captures the main page and draws each OOPIF capture over its owner element
+func (cm *ChromeManager) captureComposited(tab *types.ChromeTab, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	session, err := cm.openSession(tab)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	frames, err := session.attachFrames()
+	if err != nil {
+		return nil, err
+	}
+
+	main, err := session.captureScreenshot(screenshotParams(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to take screenshot: %w", err)
+	}
+
+	if len(frames) == 0 {
+		return main, nil
+	}
+
+	scrollX, scrollY, err := session.scrollOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := png.Decode(bytes.NewReader(main.Data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tab screenshot: %w", err)
+	}
+
+	canvas := image.NewRGBA(base.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), base, image.Point{}, draw.Src)
+
+	// The main capture starts at the viewport, or at the region, in page CSS pixels, and
+	// is scaled by the region's scale factor and the device pixel ratio. Frames are
+	// captured at the same scale and placed relative to that origin.
+	originX, originY, scale := scrollX, scrollY, 1.0
+	if options.Region != nil {
+		originX, originY = float64(options.Region.X), float64(options.Region.Y)
+		if options.ScaleFactor > 0 {
+			scale = options.ScaleFactor
+		}
+	}
+	pixels := scale * session.dpr
+
+	drawn := 0
+	var firstErr error
+	for _, frame := range frames {
+		buffer, err := session.captureFrame(frame, scrollX, scrollY, scale)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		frameImage, err := png.Decode(bytes.NewReader(buffer.Data))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("frame %s: failed to decode screenshot: %w", frame.frame.URL, err)
+			}
+			continue
+		}
+
+		at := image.Pt(
+			int(math.Round((frame.box.X+scrollX-originX)*pixels)),
+			int(math.Round((frame.box.Y+scrollY-originY)*pixels)),
+		)
+		bounds := frameImage.Bounds()
+		draw.Draw(canvas, bounds.Sub(bounds.Min).Add(at), frameImage, bounds.Min, draw.Over)
+		drawn++
+	}
+	if drawn == 0 {
+		return nil, fmt.Errorf("none of the tab's %d frames could be captured: %w", len(frames), firstErr)
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode composited screenshot: %w", err)
+	}
+
+	return newPNGBuffer(encoded.Bytes()), nil
+}
@@ -0,0 +1,191 @@
+package chrome
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pageColor  = color.RGBA{255, 255, 255, 255}
+	frameColor = color.RGBA{255, 0, 0, 255}
+)
+
+// fakeDevTools answers the DevTools commands of frame captures for a page scrolled 100 CSS
+// pixels down at a device pixel ratio of 2, with a 100x75 viewport. Frame A's owner
+// element is at (10, 20) in the viewport and 30x15; frame B's owner can't be found.
+type fakeDevTools struct {
+	failCaptures bool // Fail clipped captures as Chrome does for a frame it can't paint
+
+	mu    sync.Mutex
+	clips []map[string]interface{}
+}
+
+func (f *fakeDevTools) serve(t *testing.T) *types.ChromeTab {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var command map[string]interface{}
+			if err := conn.ReadJSON(&command); err != nil {
+				return
+			}
+			result, failure := f.answer(conn, command)
+			response := map[string]interface{}{"id": command["id"], "result": result}
+			if failure != "" {
+				response = map[string]interface{}{"id": command["id"], "error": map[string]interface{}{"code": -32000, "message": failure}}
+			}
+			if err := conn.WriteJSON(response); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &types.ChromeTab{ID: "page", WebSocketURL: "ws" + strings.TrimPrefix(server.URL, "http")}
+}
+
+func (f *fakeDevTools) answer(conn *websocket.Conn, command map[string]interface{}) (map[string]interface{}, string) {
+	params, _ := command["params"].(map[string]interface{})
+	switch command["method"] {
+	case "Target.setAutoAttach":
+		for _, target := range []string{"A", "B"} {
+			conn.WriteJSON(map[string]interface{}{
+				"method": "Target.attachedToTarget",
+				"params": map[string]interface{}{
+					"sessionId":  "session-" + target,
+					"targetInfo": map[string]interface{}{"targetId": target, "type": "iframe", "url": "https://" + target + ".example/"},
+				},
+			})
+		}
+		return map[string]interface{}{}, ""
+	case "Runtime.evaluate":
+		return map[string]interface{}{"result": map[string]interface{}{"value": 2}}, ""
+	case "Page.getLayoutMetrics":
+		return map[string]interface{}{"cssLayoutViewport": map[string]interface{}{"pageX": 0, "pageY": 100}}, ""
+	case "DOM.getFrameOwner":
+		if params["frameId"] != "A" {
+			return nil, "Frame with the given id was not found."
+		}
+		return map[string]interface{}{"backendNodeId": 7}, ""
+	case "DOM.getBoxModel":
+		return map[string]interface{}{"model": map[string]interface{}{"content": []float64{10, 20, 40, 20, 40, 35, 10, 35}}}, ""
+	case "Page.captureScreenshot":
+		if command["sessionId"] != nil {
+			return nil, "Command can only be executed on top level targets"
+		}
+		clip, ok := params["clip"].(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{"data": encodePNG(200, 150, pageColor)}, ""
+		}
+		f.mu.Lock()
+		f.clips = append(f.clips, params)
+		f.mu.Unlock()
+		fill := pageColor
+		if params["fromSurface"] == true {
+			if f.failCaptures {
+				return nil, "Unable to capture screenshot"
+			}
+			fill = frameColor
+		}
+		size := func(key string) int { return int(math.Round(clip[key].(float64) * clip["scale"].(float64) * 2)) }
+		return map[string]interface{}{"data": encodePNG(size("width"), size("height"), fill)}, ""
+	}
+	return map[string]interface{}{}, ""
+}
+
+func encodePNG(width, height int, fill color.RGBA) string {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = fill.R, fill.G, fill.B, fill.A
+	}
+	var encoded bytes.Buffer
+	png.Encode(&encoded, img)
+	return base64.StdEncoding.EncodeToString(encoded.Bytes())
+}
+
+func decodePNG(t *testing.T, buffer *types.ScreenshotBuffer) image.Image {
+	img, err := png.Decode(bytes.NewReader(buffer.Data))
+	require.NoError(t, err)
+	return img
+}
+
+func TestCaptureFramesClipsThePage(t *testing.T) {
+	devtools := &fakeDevTools{}
+	captures, err := NewManager().CaptureFrames(devtools.serve(t), nil)
+	require.NoError(t, err)
+
+	require.Len(t, captures, 1, "frame B has no owner element to clip to")
+	assert.Equal(t, "A", captures[0].Frame.TargetID)
+	assert.Equal(t, types.Rectangle{X: 20, Y: 40, Width: 60, Height: 30}, captures[0].Frame.Rect)
+	assert.Equal(t, 60, captures[0].Buffer.Width, "frames are captured in device pixels like their rectangles")
+	assert.Equal(t, 30, captures[0].Buffer.Height)
+
+	require.Len(t, devtools.clips, 1)
+	assert.Equal(t, map[string]interface{}{"x": 10.0, "y": 120.0, "width": 30.0, "height": 15.0, "scale": 1.0}, devtools.clips[0]["clip"],
+		"clips are CSS pixels of the page, not the viewport")
+}
+
+func TestCaptureCompositedDrawsFramesAtTheirBox(t *testing.T) {
+	devtools := &fakeDevTools{}
+	buffer, err := NewManager().CaptureTab(devtools.serve(t), &types.CaptureOptions{CompositeFrames: true})
+	require.NoError(t, err)
+
+	img := decodePNG(t, buffer)
+	assert.Equal(t, image.Rect(0, 0, 200, 150), img.Bounds())
+	assert.Equal(t, frameColor, color.RGBAModel.Convert(img.At(20, 40)))
+	assert.Equal(t, frameColor, color.RGBAModel.Convert(img.At(79, 69)))
+	assert.Equal(t, pageColor, color.RGBAModel.Convert(img.At(19, 40)))
+	assert.Equal(t, pageColor, color.RGBAModel.Convert(img.At(80, 70)))
+}
+
+func TestCaptureCompositedRegion(t *testing.T) {
+	devtools := &fakeDevTools{}
+	options := &types.CaptureOptions{
+		CompositeFrames: true,
+		Region:          &types.Rectangle{X: 5, Y: 110, Width: 50, Height: 40},
+		ScaleFactor:     0.5,
+	}
+	buffer, err := NewManager().CaptureTab(devtools.serve(t), options)
+	require.NoError(t, err)
+
+	// The region is 50x40 CSS pixels at half scale and a device pixel ratio of 2, and the
+	// frame is at (10, 120) on the page
+	img := decodePNG(t, buffer)
+	assert.Equal(t, image.Rect(0, 0, 50, 40), img.Bounds())
+	assert.Equal(t, frameColor, color.RGBAModel.Convert(img.At(5, 10)))
+	assert.Equal(t, frameColor, color.RGBAModel.Convert(img.At(34, 24)))
+	assert.Equal(t, pageColor, color.RGBAModel.Convert(img.At(4, 10)))
+	assert.Equal(t, pageColor, color.RGBAModel.Convert(img.At(35, 25)))
+
+	require.Len(t, devtools.clips, 2)
+	assert.Equal(t, 0.5, devtools.clips[1]["clip"].(map[string]interface{})["scale"], "frames are captured at the region's scale")
+}
+
+func TestFrameCapturesFailWhenNoFrameCanBeCaptured(t *testing.T) {
+	devtools := &fakeDevTools{failCaptures: true}
+	tab := devtools.serve(t)
+
+	_, err := NewManager().CaptureFrames(tab, nil)
+	assert.ErrorContains(t, err, "none of the tab's 2 frames could be captured")
+	assert.ErrorContains(t, err, "Unable to capture screenshot")
+
+	_, err = NewManager().CaptureTab(tab, &types.CaptureOptions{CompositeFrames: true})
+	assert.ErrorContains(t, err, "none of the tab's 2 frames could be captured")
+}
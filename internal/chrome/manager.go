@@ -11,30 +11,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
-	"unsafe"
 
 	"github.com/gorilla/websocket"
 	"github.com/screenshot-mcp-server/pkg/types"
-	"golang.org/x/sys/windows"
-)
-
-var (
-	// Windows API for Chrome process discovery
-	user32                   = windows.NewLazyDLL("user32.dll")
-	kernel32                 = windows.NewLazyDLL("kernel32.dll")
-	enumWindows              = user32.NewProc("EnumWindows")
-	getWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
-	getClassName             = user32.NewProc("GetClassNameW")
-	openProcess              = kernel32.NewProc("OpenProcess")
-	closeHandle              = kernel32.NewProc("CloseHandle")
-	queryFullProcessImageName = kernel32.NewProc("QueryFullProcessImageNameW")
-)
-
-const (
-	PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
-	MAX_PATH                          = 260
 )
 
 // ChromeManager implements Chrome DevTools Protocol integration
@@ -128,6 +108,11 @@ func (cm *ChromeManager) CaptureTab(tab *types.ChromeTab, options *types.Capture
 		return nil, fmt.Errorf("tab does not have WebSocket URL")
 	}
 	
+	// Out-of-process iframes are rendered by separate targets and need compositing
+	if options != nil && options.CompositeFrames {
+		return cm.captureComposited(tab, options)
+	}
+	
 	// Connect to tab's WebSocket
 	ctx, cancel := context.WithTimeout(context.Background(), cm.timeout)
 	defer cancel()
@@ -157,7 +142,11 @@ func (cm *ChromeManager) CaptureTab(tab *types.ChromeTab, options *types.Capture
 		return nil, fmt.Errorf("failed to decode screenshot data: %w", err)
 	}
 	
-	// Convert to our screenshot buffer format
+	return newPNGBuffer(imageData), nil
+}
+
+// newPNGBuffer wraps PNG data returned by Chrome in a screenshot buffer
+func newPNGBuffer(imageData []byte) *types.ScreenshotBuffer {
 	buffer := &types.ScreenshotBuffer{
 		Data:      imageData,
 		Format:    "PNG", // Chrome always returns PNG
@@ -170,7 +159,7 @@ func (cm *ChromeManager) CaptureTab(tab *types.ChromeTab, options *types.Capture
 		buffer.Height = int(uint32(imageData[20])<<24 | uint32(imageData[21])<<16 | uint32(imageData[22])<<8 | uint32(imageData[23]))
 	}
 	
-	return buffer, nil
+	return buffer
 }
 
 // ExecuteScript executes JavaScript in a tab
@@ -203,70 +192,6 @@ func (cm *ChromeManager) ExecuteScript(tab *types.ChromeTab, script string) (int
 	return cm.executeScript(conn, responses, script)
 }
 
-// findChromeProcesses finds all Chrome process IDs
-func (cm *ChromeManager) findChromeProcesses() ([]uint32, error) {
-	var pids []uint32
-	
-	// Callback for EnumWindows to find Chrome windows
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		var pid uint32
-		getWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
-		
-		// Check if window class is Chrome
-		classBuf := make([]uint16, 256)
-		getClassName.Call(hwnd, uintptr(unsafe.Pointer(&classBuf[0])), 256)
-		className := syscall.UTF16ToString(classBuf)
-		
-		// Chrome window classes
-		if strings.Contains(className, "Chrome_WidgetWin") {
-			// Check if this PID is already in our list
-			found := false
-			for _, existingPID := range pids {
-				if existingPID == pid {
-					found = true
-					break
-				}
-			}
-			if !found {
-				// Verify it's actually Chrome by checking process name
-				if cm.isChromePID(pid) {
-					pids = append(pids, pid)
-				}
-			}
-		}
-		
-		return 1 // Continue enumeration
-	})
-	
-	enumWindows.Call(callback, 0)
-	
-	if len(pids) == 0 {
-		return nil, fmt.Errorf("no Chrome processes found")
-	}
-	
-	return pids, nil
-}
-
-// isChromePID verifies if a PID belongs to Chrome
-func (cm *ChromeManager) isChromePID(pid uint32) bool {
-	handle, _, _ := openProcess.Call(PROCESS_QUERY_LIMITED_INFORMATION, 0, uintptr(pid))
-	if handle == 0 {
-		return false
-	}
-	defer closeHandle.Call(handle)
-	
-	var pathBuf [MAX_PATH]uint16
-	var size uint32 = MAX_PATH
-	
-	ret, _, _ := queryFullProcessImageName.Call(handle, 0, uintptr(unsafe.Pointer(&pathBuf[0])), uintptr(unsafe.Pointer(&size)))
-	if ret == 0 {
-		return false
-	}
-	
-	processPath := syscall.UTF16ToString(pathBuf[:size])
-	return strings.Contains(strings.ToLower(processPath), "chrome.exe")
-}
-
 // discoverInstance discovers Chrome instance information for a PID
 func (cm *ChromeManager) discoverInstance(pid uint32) (*types.ChromeInstance, error) {
 	// Check cache first
@@ -435,27 +360,11 @@ func (cm *ChromeManager) handleWebSocketMessages(conn *websocket.Conn, responses
 
 // takeScreenshot takes a screenshot using Chrome DevTools Protocol
 func (cm *ChromeManager) takeScreenshot(conn *websocket.Conn, responses <-chan map[string]interface{}, options *types.CaptureOptions) (string, error) {
-	// Prepare screenshot parameters
-	params := map[string]interface{}{
-		"format": "png",
-		"fromSurface": false, // Allows capturing background tabs
-	}
-	
-	if options != nil && options.Region != nil {
-		params["clip"] = map[string]interface{}{
-			"x":      options.Region.X,
-			"y":      options.Region.Y,
-			"width":  options.Region.Width,
-			"height": options.Region.Height,
-			"scale":  options.ScaleFactor,
-		}
-	}
-	
 	// Send screenshot command
 	command := map[string]interface{}{
 		"id":     1,
 		"method": "Page.captureScreenshot",
-		"params": params,
+		"params": screenshotParams(options),
 	}
 	
 	if err := conn.WriteJSON(command); err != nil {
@@ -471,8 +380,8 @@ func (cm *ChromeManager) takeScreenshot(conn *websocket.Conn, responses <-chan m
 				return "", fmt.Errorf("connection closed while waiting for screenshot")
 			}
 			
-			// Check if this is our screenshot response
-			if id, exists := response["id"]; exists && id == 1 {
+			// Check if this is our screenshot response (JSON numbers decode as float64)
+			if id, ok := response["id"].(float64); ok && id == 1 {
 				if errorObj, exists := response["error"]; exists {
 					return "", fmt.Errorf("Chrome DevTools error: %v", errorObj)
 				}
@@ -496,6 +405,30 @@ func (cm *ChromeManager) takeScreenshot(conn *websocket.Conn, responses <-chan m
 	}
 }
 
+// screenshotParams builds Page.captureScreenshot parameters from capture options
+func screenshotParams(options *types.CaptureOptions) map[string]interface{} {
+	params := map[string]interface{}{
+		"format": "png",
+		"fromSurface": false, // Allows capturing background tabs
+	}
+	
+	if options != nil && options.Region != nil {
+		scale := options.ScaleFactor
+		if scale <= 0 {
+			scale = 1.0
+		}
+		params["clip"] = map[string]interface{}{
+			"x":      options.Region.X,
+			"y":      options.Region.Y,
+			"width":  options.Region.Width,
+			"height": options.Region.Height,
+			"scale":  scale,
+		}
+	}
+	
+	return params
+}
+
 // executeScript executes JavaScript using Chrome DevTools Protocol
 func (cm *ChromeManager) executeScript(conn *websocket.Conn, responses <-chan map[string]interface{}, script string) (interface{}, error) {
 	// Send script execution command
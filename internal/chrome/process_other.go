@@ -0,0 +1,10 @@
+//go:build !windows
+
+package chrome
+
+import "fmt"
+
+// findChromeProcesses finds Chrome by its top-level windows, which needs Windows
+func (cm *ChromeManager) findChromeProcesses() ([]uint32, error) {
+	return nil, fmt.Errorf("Chrome discovery is only supported on Windows")
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package chrome
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+)
+
+// findChromeProcesses finds all Chrome process IDs
+func (cm *ChromeManager) findChromeProcesses() ([]uint32, error) {
+	var pids []uint32
+
+	// Find Chrome windows
+	win32.EnumWindows(func(hwnd uintptr) bool {
+		pid := win32.WindowProcessID(hwnd)
+
+		// Check if window class is Chrome
+		className := win32.GetClassName(hwnd)
+
+		// Chrome window classes
+		if strings.Contains(className, "Chrome_WidgetWin") {
+			// Check if this PID is already in our list
+			found := false
+			for _, existingPID := range pids {
+				if existingPID == pid {
+					found = true
+					break
+				}
+			}
+			if !found {
+				// Verify it's actually Chrome by checking process name
+				if cm.isChromePID(pid) {
+					pids = append(pids, pid)
+				}
+			}
+		}
+
+		return true // Continue enumeration
+	})
+
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("no Chrome processes found")
+	}
+
+	return pids, nil
+}
+
+// isChromePID verifies if a PID belongs to Chrome
+func (cm *ChromeManager) isChromePID(pid uint32) bool {
+	processPath, err := win32.ProcessImagePath(pid)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(processPath), "chrome.exe")
+}
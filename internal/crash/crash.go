@@ -0,0 +1,218 @@
+// Package crash records panics recovered in the server's goroutines. Each panic is kept
+// in memory for the errors API, appended to a crash log that survives restarts, and
+// passed to reporting hooks such as Sentry.
+package crash
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recentLimit is how many reports the reporter keeps in memory
+const recentLimit = 50
+
+// Report describes a recovered panic
+type Report struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Component string            `json:"component"` // Goroutine that panicked, such as "stream" or "http"
+	Panic     string            `json:"panic"`
+	Stack     string            `json:"stack"`
+	Context   map[string]string `json:"context,omitempty"` // Request or session the goroutine was serving
+	Version   string            `json:"version"`
+	Platform  string            `json:"platform"`
+
+	frames []runtime.Frame // Panicking call stack, innermost first
+}
+
+// Frames returns the call stack of the panic, innermost first. Reports read back from
+// the crash log have none.
+func (r *Report) Frames() []runtime.Frame {
+	return r.frames
+}
+
+// Hook receives every report, for example to forward it to an error tracker. Hooks run
+// on the goroutine that recovered the panic and must not block.
+type Hook func(report *Report)
+
+// Reporter records recovered panics. The methods of a nil Reporter do nothing, so
+// components can recover panics whether or not a reporter was set.
+type Reporter struct {
+	path    string
+	version string
+	logger  *zap.Logger
+
+	mutex  sync.Mutex
+	recent []Report
+	hooks  []Hook
+}
+
+// NewReporter creates a reporter that appends to the crash log at path ("" keeps reports
+// in memory only) and loads the latest reports already in it
+func NewReporter(path, version string, logger *zap.Logger) *Reporter {
+	r := &Reporter{path: path, version: version, logger: logger}
+	if path != "" {
+		if err := r.load(); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to read crash log", zap.String("path", path), zap.Error(err))
+		}
+	}
+	return r
+}
+
+// AddHook adds a hook that receives every report
+func (r *Reporter) AddHook(hook Hook) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Recover recovers a panic, logs it and records it. It must be deferred directly:
+//
+//	defer reporter.Recover("batch", map[string]string{"target": target})
+func (r *Reporter) Recover(component string, context map[string]string) {
+	if p := recover(); p != nil {
+		report := r.Capture(component, p, context)
+		if report != nil {
+			r.logger.Error("Goroutine panicked",
+				zap.String("component", component),
+				zap.String("crash_id", report.ID),
+				zap.Any("error", p),
+			)
+		}
+	}
+}
+
+// Capture records a panic value recovered by the caller, whose deferred function must
+// be running for the stack to show where the panic happened. Logging the panic is left
+// to the caller.
+func (r *Reporter) Capture(component string, p interface{}, context map[string]string) *Report {
+	if r == nil {
+		return nil
+	}
+
+	report := &Report{
+		ID:        newID(),
+		Time:      time.Now(),
+		Component: component,
+		Panic:     fmt.Sprint(p),
+		Stack:     string(debug.Stack()),
+		Context:   context,
+		Version:   r.version,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		frames:    panicFrames(),
+	}
+
+	r.mutex.Lock()
+	r.recent = append(r.recent, *report)
+	if len(r.recent) > recentLimit {
+		r.recent = r.recent[len(r.recent)-recentLimit:]
+	}
+	hooks := r.hooks
+	if r.path != "" {
+		if err := r.append(report); err != nil {
+			r.logger.Warn("Failed to write crash log", zap.String("path", r.path), zap.Error(err))
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, hook := range hooks {
+		hook(report)
+	}
+	return report
+}
+
+// Recent returns the latest reports, oldest first, including those of earlier runs
+// read from the crash log
+func (r *Reporter) Recent() []Report {
+	if r == nil {
+		return []Report{}
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Report{}, r.recent...)
+}
+
+// append writes a report to the crash log as a JSON line
+func (r *Reporter) append(report *Report) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// load reads the latest reports of the crash log
+func (r *Reporter) load() error {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var report Report
+		if json.Unmarshal(scanner.Bytes(), &report) != nil {
+			continue // A line cut short by a crash mid-write
+		}
+		r.recent = append(r.recent, report)
+		if len(r.recent) > recentLimit {
+			r.recent = r.recent[1:]
+		}
+	}
+	return scanner.Err()
+}
+
+// panicFrames returns the call stack below runtime.gopanic: the frames from where the
+// panic happened outwards
+func panicFrames() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	iterator := runtime.CallersFrames(pcs[:n])
+
+	var frames []runtime.Frame
+	for {
+		frame, more := iterator.Next()
+		frames = append(frames, frame)
+		if strings.HasPrefix(frame.Function, "runtime.gopanic") {
+			frames = frames[:0]
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,139 @@
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sentryTimeout is the longest sending a report to Sentry may take
+const sentryTimeout = 10 * time.Second
+
+// sentryEvent is the subset of the Sentry event payload the server sends
+type sentryEvent struct {
+	EventID    string            `json:"event_id"`
+	Timestamp  string            `json:"timestamp"`
+	Level      string            `json:"level"`
+	Platform   string            `json:"platform"`
+	Logger     string            `json:"logger"`
+	Release    string            `json:"release,omitempty"`
+	ServerName string            `json:"server_name,omitempty"`
+	Message    string            `json:"message"`
+	Tags       map[string]string `json:"tags"`
+	Extra      map[string]string `json:"extra,omitempty"`
+	Exception  struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+	Contexts map[string]map[string]string `json:"contexts"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Module     string `json:"module,omitempty"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// SentryHook returns a hook that sends reports to the Sentry-compatible store endpoint
+// of a DSN, such as https://key@sentry.example.com/42. GlitchTip and other servers
+// that implement the Sentry store API accept the same events.
+func SentryHook(dsn string, logger *zap.Logger) (Hook, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+	path := strings.TrimSuffix(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	if slash < 0 || path[slash+1:] == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, path[:slash], path[slash+1:])
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=screenshot-mcp/1.0, sentry_key=%s", parsed.User.Username())
+	if secret, ok := parsed.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	client := &http.Client{Timeout: sentryTimeout}
+	hostname, _ := os.Hostname()
+	return func(report *Report) {
+		body, err := json.Marshal(newSentryEvent(report, hostname))
+		if err != nil {
+			return
+		}
+		go func() {
+			req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Sentry-Auth", auth)
+			resp, err := client.Do(req)
+			if err != nil {
+				logger.Warn("Failed to send crash report to Sentry", zap.String("crash_id", report.ID), zap.Error(err))
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				logger.Warn("Sentry rejected crash report", zap.String("crash_id", report.ID), zap.Int("status", resp.StatusCode))
+			}
+		}()
+	}, nil
+}
+
+// newSentryEvent converts a report to a Sentry event
+func newSentryEvent(report *Report, hostname string) *sentryEvent {
+	event := &sentryEvent{
+		EventID:    report.ID,
+		Timestamp:  report.Time.UTC().Format(time.RFC3339),
+		Level:      "fatal",
+		Platform:   "go",
+		Logger:     report.Component,
+		Release:    report.Version,
+		ServerName: hostname,
+		Message:    "panic: " + report.Panic,
+		Tags:       map[string]string{"component": report.Component},
+		Extra:      report.Context,
+		Contexts: map[string]map[string]string{
+			"os":      {"name": runtime.GOOS},
+			"runtime": {"name": "go", "version": runtime.Version()},
+		},
+	}
+
+	exception := sentryException{Type: "panic", Value: report.Panic, Module: report.Component}
+	// Sentry lists frames outermost first
+	frames := report.Frames()
+	for i := len(frames) - 1; i >= 0; i-- {
+		frame := frames[i]
+		exception.Stacktrace.Frames = append(exception.Stacktrace.Frames, sentryFrame{
+			Function: frame.Function,
+			Filename: frame.File,
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    !strings.HasPrefix(frame.Function, "runtime.") && !strings.Contains(frame.Function, "/vendor/"),
+		})
+	}
+	event.Exception.Values = []sentryException{exception}
+	return event
+}
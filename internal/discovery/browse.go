@@ -0,0 +1,183 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// queryInterval spaces out repeated queries while browsing
+const queryInterval = time.Second
+
+// Browse queries the local network for screenshot servers until ctx is done and returns
+// the ones that answered, ordered by instance name. Queries are sent from an ephemeral
+// port, so responders answer by unicast (RFC 6762 section 6.7).
+func Browse(ctx context.Context) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	query, err := browseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(queryInterval)
+		defer ticker.Stop()
+		for {
+			conn.WriteToUDP(query, mdnsGroup)
+			select {
+			case <-queryCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	records := newRecordSet()
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return nil, fmt.Errorf("failed to read mDNS response: %w", err)
+		}
+		records.add(buf[:n])
+	}
+	return records.services(), nil
+}
+
+// browseQuery builds the PTR query for the service type
+func browseQuery() ([]byte, error) {
+	name, err := dnsmessage.NewName(ServiceType + "." + domain)
+	if err != nil {
+		return nil, err
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: uint16(time.Now().UnixNano())})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// recordSet collects the records of browse responses, keyed by lower-case name
+type recordSet struct {
+	instances map[string]string // Instance name as received
+	srv       map[string]dnsmessage.SRVResource
+	txt       map[string][]string
+	addrs     map[string][]net.IP
+}
+
+func newRecordSet() *recordSet {
+	return &recordSet{
+		instances: make(map[string]string),
+		srv:       make(map[string]dnsmessage.SRVResource),
+		txt:       make(map[string][]string),
+		addrs:     make(map[string][]net.IP),
+	}
+}
+
+// add collects the records of a response; malformed packets are ignored
+func (r *recordSet) add(packet []byte) {
+	var p dnsmessage.Parser
+	header, err := p.Start(packet)
+	if err != nil || !header.Response {
+		return
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	var resources []dnsmessage.Resource
+	for _, section := range []func() ([]dnsmessage.Resource, error){p.AllAnswers, p.AllAuthorities, p.AllAdditionals} {
+		records, err := section()
+		if err != nil {
+			break
+		}
+		resources = append(resources, records...)
+	}
+
+	serviceName := strings.ToLower(ServiceType + "." + domain)
+	for _, resource := range resources {
+		if resource.Header.TTL == 0 {
+			continue // Goodbye
+		}
+		name := strings.ToLower(resource.Header.Name.String())
+		switch body := resource.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == serviceName {
+				r.instances[strings.ToLower(body.PTR.String())] = body.PTR.String()
+			}
+		case *dnsmessage.SRVResource:
+			r.srv[name] = *body
+		case *dnsmessage.TXTResource:
+			r.txt[name] = body.TXT
+		case *dnsmessage.AResource:
+			ip := net.IP(body.A[:])
+			if !containsIP(r.addrs[name], ip) {
+				r.addrs[name] = append(r.addrs[name], ip)
+			}
+		}
+	}
+}
+
+// services resolves the instances that have an SRV record
+func (r *recordSet) services() []Service {
+	suffix := "." + ServiceType + "." + domain
+	services := []Service{}
+	for key, instance := range r.instances {
+		srv, ok := r.srv[key]
+		if !ok || !strings.HasSuffix(key, strings.ToLower(suffix)) {
+			continue
+		}
+		host := srv.Target.String()
+		service := Service{
+			Instance: instance[:len(instance)-len(suffix)],
+			Host:     host,
+			Port:     int(srv.Port),
+			Addrs:    r.addrs[strings.ToLower(host)],
+			Text:     make(map[string]string),
+		}
+		for _, entry := range r.txt[key] {
+			if entry == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(entry, "=")
+			service.Text[strings.ToLower(k)] = v
+		}
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Instance < services[j].Instance
+	})
+	return services
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,403 @@
+// Package discovery advertises screenshot servers on the local network with mDNS/DNS-SD
+// (RFC 6762 and 6763) and finds the servers other machines advertise.
+package discovery
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ServiceType is the DNS-SD service type screenshot servers are advertised as
+const ServiceType = "_screenshot-mcp._tcp"
+
+// TXT record keys of an advertised server
+const (
+	TextVersion  = "version"
+	TextAuth     = "auth"      // Authentication clients must use: "none", "bearer" or "basic"
+	TextRPCPath  = "rpc"       // MCP JSON-RPC path
+	TextGRPCPort = "grpc_port" // Absent when the gRPC API is disabled
+)
+
+const (
+	domain = "local."
+	// servicesName lists the service types of a host (RFC 6763 section 9)
+	servicesName = "_services._dns-sd._udp." + domain
+
+	// Record TTLs recommended by RFC 6762 section 10
+	hostTTL    = 120
+	serviceTTL = 4500
+	legacyTTL  = 10 // Cap for unicast answers to legacy queries (RFC 6762 section 6.7)
+
+	// cacheFlush marks unique records in multicast responses; unicastResponse marks
+	// questions whose answer is wanted by unicast. Both share the class's top bit.
+	cacheFlush      = 1 << 15
+	unicastResponse = 1 << 15
+
+	maxMessageSize = 9000
+)
+
+// mdnsGroup is the IPv4 mDNS multicast address
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is an advertised screenshot server
+type Service struct {
+	Instance string            `json:"instance"` // Display name, e.g. the machine name
+	Host     string            `json:"host"`     // mDNS host name, e.g. "desktop-1.local."
+	Port     int               `json:"port"`     // HTTP port
+	Addrs    []net.IP          `json:"addresses"`
+	Text     map[string]string `json:"txt,omitempty"`
+}
+
+// URL returns the HTTP base URL of the service, preferring its first address
+func (s *Service) URL() string {
+	host := strings.TrimSuffix(s.Host, ".")
+	if len(s.Addrs) > 0 {
+		host = s.Addrs[0].String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(s.Port))
+}
+
+// Advertiser answers mDNS queries for a service until it is closed
+type Advertiser struct {
+	service  Service
+	conn     *net.UDPConn
+	typeName dnsmessage.Name
+	instName dnsmessage.Name
+	hostName dnsmessage.Name
+	done     chan struct{}
+	closed   sync.Once
+}
+
+// Advertise starts answering queries for service and announces it. An empty Instance
+// defaults to the machine name, an empty Host to "<machine name>.local." and no Addrs
+// to the machine's IPv4 addresses.
+func Advertise(service Service) (*Advertiser, error) {
+	if service.Port <= 0 {
+		return nil, fmt.Errorf("invalid port %d", service.Port)
+	}
+	machine, err := os.Hostname()
+	if err != nil {
+		machine = "screenshot-server"
+	}
+	machine = label(strings.SplitN(machine, ".", 2)[0])
+	if service.Instance == "" {
+		service.Instance = machine
+	}
+	if service.Host == "" {
+		service.Host = machine + "." + domain
+	}
+	if len(service.Addrs) == 0 {
+		if service.Addrs, err = LocalAddrs(); err != nil {
+			return nil, err
+		}
+	}
+	if len(service.Addrs) == 0 {
+		return nil, fmt.Errorf("no network addresses to advertise")
+	}
+
+	a := &Advertiser{service: service, done: make(chan struct{})}
+	if a.typeName, err = dnsmessage.NewName(ServiceType + "." + domain); err != nil {
+		return nil, err
+	}
+	if a.instName, err = dnsmessage.NewName(label(service.Instance) + "." + ServiceType + "." + domain); err != nil {
+		return nil, fmt.Errorf("invalid instance name %q: %w", service.Instance, err)
+	}
+	if a.hostName, err = dnsmessage.NewName(service.Host); err != nil {
+		return nil, fmt.Errorf("invalid host name %q: %w", service.Host, err)
+	}
+
+	if a.conn, err = net.ListenMulticastUDP("udp4", nil, mdnsGroup); err != nil {
+		return nil, fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+
+	go a.serve()
+	go a.announce()
+	return a, nil
+}
+
+// Service returns the advertised service
+func (a *Advertiser) Service() Service {
+	return a.service
+}
+
+// Close withdraws the advertisement and stops answering queries
+func (a *Advertiser) Close() error {
+	var err error
+	a.closed.Do(func() {
+		close(a.done)
+		// Goodbye packet: the records with a TTL of zero (RFC 6762 section 10.1)
+		if msg, packErr := a.response(0, nil, questionAll, 0, true); packErr == nil {
+			a.conn.WriteToUDP(msg, mdnsGroup)
+		}
+		err = a.conn.Close()
+	})
+	return err
+}
+
+// announce sends the records unsolicited twice, a second apart (RFC 6762 section 8.3)
+func (a *Advertiser) announce() {
+	for i := 0; i < 2; i++ {
+		if msg, err := a.response(0, nil, questionAll, math.MaxUint32, true); err == nil {
+			a.conn.WriteToUDP(msg, mdnsGroup)
+		}
+		select {
+		case <-a.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// serve answers queries until the connection is closed
+func (a *Advertiser) serve() {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.done:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		a.answer(buf[:n], from)
+	}
+}
+
+// questionKind is the set of records a question asks for
+type questionKind int
+
+const (
+	questionNone questionKind = iota
+	questionServices
+	questionAll  // PTR, with SRV, TXT and addresses as additional records
+	questionSRV  // SRV, with the addresses
+	questionTXT  // TXT
+	questionHost // Addresses
+)
+
+// answer replies to a query. Queries from port 5353 are answered by multicast unless they
+// ask for a unicast response; others are legacy queries (RFC 6762 section 6.7), answered
+// by unicast with the query ID and question.
+func (a *Advertiser) answer(packet []byte, from *net.UDPAddr) {
+	var p dnsmessage.Parser
+	header, err := p.Start(packet)
+	if err != nil || header.Response {
+		return
+	}
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return
+	}
+
+	legacy := from.Port != mdnsGroup.Port
+	for _, q := range questions {
+		kind := a.match(q)
+		if kind == questionNone {
+			continue
+		}
+
+		var msg []byte
+		if legacy {
+			msg, err = a.response(header.ID, &q, kind, legacyTTL, false)
+		} else {
+			msg, err = a.response(0, nil, kind, math.MaxUint32, true)
+		}
+		if err != nil {
+			continue
+		}
+
+		if legacy || uint16(q.Class)&unicastResponse != 0 {
+			a.conn.WriteToUDP(msg, from)
+		} else {
+			a.conn.WriteToUDP(msg, mdnsGroup)
+		}
+	}
+}
+
+// match returns the records a question asks for
+func (a *Advertiser) match(q dnsmessage.Question) questionKind {
+	class := dnsmessage.Class(uint16(q.Class) &^ unicastResponse)
+	if class != dnsmessage.ClassINET && class != dnsmessage.ClassANY {
+		return questionNone
+	}
+	name := q.Name.String()
+	all := q.Type == dnsmessage.TypeALL
+	switch {
+	case strings.EqualFold(name, servicesName) && (all || q.Type == dnsmessage.TypePTR):
+		return questionServices
+	case strings.EqualFold(name, a.typeName.String()) && (all || q.Type == dnsmessage.TypePTR):
+		return questionAll
+	case strings.EqualFold(name, a.instName.String()) && all:
+		return questionAll
+	case strings.EqualFold(name, a.instName.String()) && q.Type == dnsmessage.TypeSRV:
+		return questionSRV
+	case strings.EqualFold(name, a.instName.String()) && q.Type == dnsmessage.TypeTXT:
+		return questionTXT
+	case strings.EqualFold(name, a.hostName.String()) && (all || q.Type == dnsmessage.TypeA):
+		return questionHost
+	}
+	return questionNone
+}
+
+// response builds a response with the records of kind, their TTLs capped at maxTTL.
+// question is repeated in legacy responses; multicast sets the cache-flush bit on
+// unique records.
+func (a *Advertiser) response(id uint16, question *dnsmessage.Question, kind questionKind, maxTTL uint32, multicast bool) ([]byte, error) {
+	ttl := func(t uint32) uint32 {
+		return min(t, maxTTL)
+	}
+	class := dnsmessage.ClassINET
+	uniqueClass := class
+	if multicast {
+		uniqueClass |= cacheFlush
+	}
+
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{ID: id, Response: true, Authoritative: true})
+	b.EnableCompression()
+	if question != nil {
+		if err := b.StartQuestions(); err != nil {
+			return nil, err
+		}
+		q := *question
+		q.Class = dnsmessage.Class(uint16(q.Class) &^ unicastResponse)
+		if err := b.Question(q); err != nil {
+			return nil, err
+		}
+	}
+
+	srv := func() error {
+		return b.SRVResource(dnsmessage.ResourceHeader{Name: a.instName, Class: uniqueClass, TTL: ttl(hostTTL)},
+			dnsmessage.SRVResource{Target: a.hostName, Port: uint16(a.service.Port)})
+	}
+	txt := func() error {
+		return b.TXTResource(dnsmessage.ResourceHeader{Name: a.instName, Class: uniqueClass, TTL: ttl(serviceTTL)},
+			dnsmessage.TXTResource{TXT: a.text()})
+	}
+	addrs := func() error {
+		for _, ip := range a.service.Addrs {
+			ip4 := ip.To4()
+			if ip4 == nil {
+				continue
+			}
+			var addr dnsmessage.AResource
+			copy(addr.A[:], ip4)
+			if err := b.AResource(dnsmessage.ResourceHeader{Name: a.hostName, Class: uniqueClass, TTL: ttl(hostTTL)}, addr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	var additional []func() error
+	switch kind {
+	case questionServices:
+		services, _ := dnsmessage.NewName(servicesName)
+		if err := b.PTRResource(dnsmessage.ResourceHeader{Name: services, Class: class, TTL: ttl(serviceTTL)},
+			dnsmessage.PTRResource{PTR: a.typeName}); err != nil {
+			return nil, err
+		}
+	case questionAll:
+		if err := b.PTRResource(dnsmessage.ResourceHeader{Name: a.typeName, Class: class, TTL: ttl(serviceTTL)},
+			dnsmessage.PTRResource{PTR: a.instName}); err != nil {
+			return nil, err
+		}
+		additional = []func() error{srv, txt, addrs}
+	case questionSRV:
+		if err := srv(); err != nil {
+			return nil, err
+		}
+		additional = []func() error{addrs}
+	case questionTXT:
+		if err := txt(); err != nil {
+			return nil, err
+		}
+	case questionHost:
+		if err := addrs(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	for _, add := range additional {
+		if err := add(); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// text returns the TXT strings in key order
+func (a *Advertiser) text() []string {
+	keys := make([]string, 0, len(a.service.Text))
+	for key := range a.service.Text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	text := make([]string, 0, len(keys))
+	for _, key := range keys {
+		text = append(text, key+"="+a.service.Text[key])
+	}
+	if len(text) == 0 {
+		text = append(text, "") // A TXT record holds at least one string
+	}
+	return text
+}
+
+// LocalAddrs returns the IPv4 addresses of the machine's multicast-capable interfaces
+func LocalAddrs() ([]net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+
+	var addrs []net.IP
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLinkLocalUnicast() {
+				addrs = append(addrs, ipNet.IP.To4())
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// label makes text usable as a single DNS label
+func label(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r == '.' || r == '\\' {
+			return '-'
+		}
+		return r
+	}, text)
+	if len(text) > 63 {
+		text = text[:63]
+	}
+	return text
+}
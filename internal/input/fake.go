@@ -0,0 +1,66 @@
+package input
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeDriver records the input it is sent instead of synthesizing it, for the simulated
+// desktop. It never sees user input.
+type FakeDriver struct {
+	mutex   sync.Mutex
+	actions []string
+}
+
+// NewFakeDriver creates a driver without recorded input
+func NewFakeDriver() *FakeDriver {
+	return &FakeDriver{}
+}
+
+// Actions returns the input sent so far, such as "click 10 20 left"
+func (d *FakeDriver) Actions() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.actions...)
+}
+
+func (d *FakeDriver) record(action string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.actions = append(d.actions, action)
+}
+
+// Click records a click
+func (d *FakeDriver) Click(x, y int, button string) error {
+	if !ValidButton(button) {
+		return fmt.Errorf("unknown mouse button %q", button)
+	}
+	d.record(fmt.Sprintf("click %d %d %s", x, y, button))
+	return nil
+}
+
+// Scroll records a turn of the mouse wheel
+func (d *FakeDriver) Scroll(x, y, notches int) error {
+	d.record(fmt.Sprintf("scroll %d %d %d", x, y, notches))
+	return nil
+}
+
+// Press records a key combination
+func (d *FakeDriver) Press(combo string) error {
+	if _, err := ParseCombo(combo); err != nil {
+		return err
+	}
+	d.record("key " + combo)
+	return nil
+}
+
+// Type records typed text
+func (d *FakeDriver) Type(text string) error {
+	d.record(fmt.Sprintf("type %q", text))
+	return nil
+}
+
+// Watch delivers nothing: nobody uses the simulated desktop
+func (d *FakeDriver) Watch(events chan<- Event) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,123 @@
+// Package input synthesizes mouse and keyboard input and watches the user's, for
+// workflows that click and type and for the macro recorder. Input is sent with SendInput
+// and watched with low-level hooks on Windows; elsewhere only the simulated desktop of
+// SCREENSHOT_BACKEND=fake has a driver, which records what it is sent.
+package input
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned where input can't be synthesized or watched
+var ErrUnsupported = errors.New("input is not supported on this platform")
+
+// Mouse buttons
+const (
+	ButtonLeft   = "left"
+	ButtonRight  = "right"
+	ButtonMiddle = "middle"
+)
+
+// Raw event kinds
+const (
+	MouseDown = "mouse_down"
+	MouseUp   = "mouse_up"
+	KeyDown   = "key_down"
+	KeyUp     = "key_up"
+)
+
+// Event is a mouse button or key the user pressed or released
+type Event struct {
+	Kind   string
+	Time   time.Time
+	X, Y   int    // Screen coordinates of mouse events
+	Button string // Mouse button
+	Key    string // Key name, as in Keys
+	Char   string // Text a key down types with the current modifiers, if any
+}
+
+// Driver synthesizes input and watches the user's
+type Driver interface {
+	// Click moves the pointer to screen coordinates and clicks a button
+	Click(x, y int, button string) error
+	// Scroll moves the pointer to screen coordinates and turns the mouse wheel by notches,
+	// positive away from the user (up) and negative toward the user (down)
+	Scroll(x, y, notches int) error
+	// Press presses a key combination such as "ctrl+s", releasing the keys in reverse
+	Press(combo string) error
+	// Type types text regardless of the keyboard layout
+	Type(text string) error
+	// Watch delivers the user's input, without synthesized input, until stop is called.
+	// Events are dropped when the channel is full.
+	Watch(events chan<- Event) (stop func(), err error)
+}
+
+// Keys maps key names to their Windows virtual-key codes
+var Keys = map[string]uint16{
+	"backspace": 0x08, "tab": 0x09, "enter": 0x0D, "pause": 0x13, "capslock": 0x14,
+	"escape": 0x1B, "space": 0x20, "pageup": 0x21, "pagedown": 0x22, "end": 0x23,
+	"home": 0x24, "left": 0x25, "up": 0x26, "right": 0x27, "down": 0x28,
+	"printscreen": 0x2C, "insert": 0x2D, "delete": 0x2E,
+	"shift": 0x10, "ctrl": 0x11, "alt": 0x12, "win": 0x5B, "apps": 0x5D,
+	"f1": 0x70, "f2": 0x71, "f3": 0x72, "f4": 0x73, "f5": 0x74, "f6": 0x75,
+	"f7": 0x76, "f8": 0x77, "f9": 0x78, "f10": 0x79, "f11": 0x7A, "f12": 0x7B,
+}
+
+func init() {
+	for c := '0'; c <= '9'; c++ {
+		Keys[string(c)] = uint16(c)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		Keys[string(c)] = uint16(c - 'a' + 'A')
+	}
+}
+
+// Modifier reports whether a key name is a modifier, held while other keys are pressed
+func Modifier(key string) bool {
+	return key == "shift" || key == "ctrl" || key == "alt" || key == "win"
+}
+
+// ParseCombo returns the virtual-key codes of a key combination such as "ctrl+shift+s"
+func ParseCombo(combo string) ([]uint16, error) {
+	if combo == "" {
+		return nil, errors.New("empty key combination")
+	}
+	var codes []uint16
+	for _, name := range strings.Split(strings.ToLower(combo), "+") {
+		code, ok := Keys[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", name)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// KeyName returns the name of a virtual-key code, or "" for keys without one
+func KeyName(code uint16) string {
+	// The left and right variants of modifiers share their names
+	switch code {
+	case 0xA0, 0xA1:
+		return "shift"
+	case 0xA2, 0xA3:
+		return "ctrl"
+	case 0xA4, 0xA5:
+		return "alt"
+	case 0x5C:
+		return "win"
+	}
+	for name, value := range Keys {
+		if value == code {
+			return name
+		}
+	}
+	return ""
+}
+
+// ValidButton reports whether a mouse button name is known
+func ValidButton(button string) bool {
+	return button == ButtonLeft || button == ButtonRight || button == ButtonMiddle
+}
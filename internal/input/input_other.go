@@ -0,0 +1,14 @@
+//go:build !windows
+
+package input
+
+import "os"
+
+// New returns the input driver of this platform. Only the simulated desktop of
+// SCREENSHOT_BACKEND=fake has one outside Windows.
+func New() (Driver, error) {
+	if os.Getenv("SCREENSHOT_BACKEND") == "fake" {
+		return NewFakeDriver(), nil
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,412 @@
+//go:build windows
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32 = win32.User32
+
+	sendInput           = user32.NewProc("SendInput")
+	setCursorPos        = user32.NewProc("SetCursorPos")
+	setWindowsHookExW   = user32.NewProc("SetWindowsHookExW")
+	unhookWindowsHookEx = user32.NewProc("UnhookWindowsHookEx")
+	callNextHookEx      = user32.NewProc("CallNextHookEx")
+	getMessageW         = user32.NewProc("GetMessageW")
+	peekMessageW        = user32.NewProc("PeekMessageW")
+	postThreadMessageW  = user32.NewProc("PostThreadMessageW")
+	getKeyState         = user32.NewProc("GetKeyState")
+	toUnicode           = user32.NewProc("ToUnicode")
+)
+
+// Windows API constants
+const (
+	INPUT_MOUSE    = 0
+	INPUT_KEYBOARD = 1
+
+	MOUSEEVENTF_LEFTDOWN   = 0x0002
+	MOUSEEVENTF_LEFTUP     = 0x0004
+	MOUSEEVENTF_RIGHTDOWN  = 0x0008
+	MOUSEEVENTF_RIGHTUP    = 0x0010
+	MOUSEEVENTF_MIDDLEDOWN = 0x0020
+	MOUSEEVENTF_MIDDLEUP   = 0x0040
+	MOUSEEVENTF_WHEEL      = 0x0800
+
+	WHEEL_DELTA = 120
+
+	KEYEVENTF_KEYUP   = 0x0002
+	KEYEVENTF_UNICODE = 0x0004
+
+	WH_KEYBOARD_LL = 13
+	WH_MOUSE_LL    = 14
+	HC_ACTION      = 0
+
+	PM_NOREMOVE = 0x0000
+
+	WM_QUIT        = 0x0012
+	WM_KEYDOWN     = 0x0100
+	WM_KEYUP       = 0x0101
+	WM_SYSKEYDOWN  = 0x0104
+	WM_SYSKEYUP    = 0x0105
+	WM_LBUTTONDOWN = 0x0201
+	WM_LBUTTONUP   = 0x0202
+	WM_RBUTTONDOWN = 0x0204
+	WM_RBUTTONUP   = 0x0205
+	WM_MBUTTONDOWN = 0x0207
+	WM_MBUTTONUP   = 0x0208
+
+	LLKHF_INJECTED = 0x10
+	LLMHF_INJECTED = 0x01
+
+	VK_SHIFT   = 0x10
+	VK_CONTROL = 0x11
+	VK_MENU    = 0x12
+	VK_CAPITAL = 0x14
+)
+
+// MOUSEINPUT is the mouse member of INPUT
+type MOUSEINPUT struct {
+	Dx, Dy      int32
+	MouseData   uint32
+	DwFlags     uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
+
+// KEYBDINPUT is the keyboard member of INPUT
+type KEYBDINPUT struct {
+	WVk         uint16
+	WScan       uint16
+	DwFlags     uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
+
+// mouseINPUT and keyboardINPUT are INPUT with their union member; the keyboard one is
+// padded to the size of the larger mouse member
+type mouseINPUT struct {
+	Type uint32
+	Mi   MOUSEINPUT
+}
+
+type keyboardINPUT struct {
+	Type uint32
+	Ki   KEYBDINPUT
+	_    [8]byte
+}
+
+// MSLLHOOKSTRUCT describes a low-level mouse event
+type MSLLHOOKSTRUCT struct {
+	Pt          win32.POINT
+	MouseData   uint32
+	Flags       uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
+
+// KBDLLHOOKSTRUCT describes a low-level keyboard event
+type KBDLLHOOKSTRUCT struct {
+	VkCode      uint32
+	ScanCode    uint32
+	Flags       uint32
+	Time        uint32
+	DwExtraInfo uintptr
+}
+
+// MSG is a thread message
+type MSG struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      win32.POINT
+}
+
+// windowsDriver synthesizes input with SendInput and watches it with low-level hooks
+type windowsDriver struct{}
+
+// New returns the input driver of this platform
+func New() (Driver, error) {
+	return windowsDriver{}, nil
+}
+
+// Click moves the pointer and clicks a button
+func (windowsDriver) Click(x, y int, button string) error {
+	var down, up uint32
+	switch button {
+	case ButtonLeft:
+		down, up = MOUSEEVENTF_LEFTDOWN, MOUSEEVENTF_LEFTUP
+	case ButtonRight:
+		down, up = MOUSEEVENTF_RIGHTDOWN, MOUSEEVENTF_RIGHTUP
+	case ButtonMiddle:
+		down, up = MOUSEEVENTF_MIDDLEDOWN, MOUSEEVENTF_MIDDLEUP
+	default:
+		return fmt.Errorf("unknown mouse button %q", button)
+	}
+
+	if ret, _, err := setCursorPos.Call(uintptr(x), uintptr(y)); ret == 0 {
+		return fmt.Errorf("SetCursorPos failed: %w", err)
+	}
+	inputs := []mouseINPUT{
+		{Type: INPUT_MOUSE, Mi: MOUSEINPUT{DwFlags: down}},
+		{Type: INPUT_MOUSE, Mi: MOUSEINPUT{DwFlags: up}},
+	}
+	return send(unsafe.Pointer(&inputs[0]), len(inputs), unsafe.Sizeof(inputs[0]))
+}
+
+// Scroll moves the pointer and turns the wheel, which scrolls the window under it
+func (windowsDriver) Scroll(x, y, notches int) error {
+	if ret, _, err := setCursorPos.Call(uintptr(x), uintptr(y)); ret == 0 {
+		return fmt.Errorf("SetCursorPos failed: %w", err)
+	}
+	inputs := []mouseINPUT{
+		{Type: INPUT_MOUSE, Mi: MOUSEINPUT{DwFlags: MOUSEEVENTF_WHEEL, MouseData: uint32(int32(notches * WHEEL_DELTA))}},
+	}
+	return send(unsafe.Pointer(&inputs[0]), len(inputs), unsafe.Sizeof(inputs[0]))
+}
+
+// Press presses the keys of a combination in order and releases them in reverse
+func (windowsDriver) Press(combo string) error {
+	codes, err := ParseCombo(combo)
+	if err != nil {
+		return err
+	}
+	inputs := make([]keyboardINPUT, 0, 2*len(codes))
+	for _, code := range codes {
+		inputs = append(inputs, keyboardINPUT{Type: INPUT_KEYBOARD, Ki: KEYBDINPUT{WVk: code}})
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		inputs = append(inputs, keyboardINPUT{Type: INPUT_KEYBOARD, Ki: KEYBDINPUT{WVk: codes[i], DwFlags: KEYEVENTF_KEYUP}})
+	}
+	return send(unsafe.Pointer(&inputs[0]), len(inputs), unsafe.Sizeof(inputs[0]))
+}
+
+// Type sends text as Unicode characters, independent of the keyboard layout
+func (windowsDriver) Type(text string) error {
+	units := utf16.Encode([]rune(text))
+	if len(units) == 0 {
+		return nil
+	}
+	inputs := make([]keyboardINPUT, 0, 2*len(units))
+	for _, unit := range units {
+		inputs = append(inputs,
+			keyboardINPUT{Type: INPUT_KEYBOARD, Ki: KEYBDINPUT{WScan: unit, DwFlags: KEYEVENTF_UNICODE}},
+			keyboardINPUT{Type: INPUT_KEYBOARD, Ki: KEYBDINPUT{WScan: unit, DwFlags: KEYEVENTF_UNICODE | KEYEVENTF_KEYUP}},
+		)
+	}
+	return send(unsafe.Pointer(&inputs[0]), len(inputs), unsafe.Sizeof(inputs[0]))
+}
+
+// send passes count INPUT structures to SendInput, which fails when another desktop,
+// such as the secure desktop of a UAC prompt, has the input
+func send(inputs unsafe.Pointer, count int, size uintptr) error {
+	sent, _, err := sendInput.Call(uintptr(count), uintptr(inputs), size)
+	if int(sent) != count {
+		return fmt.Errorf("SendInput failed: %w", err)
+	}
+	return nil
+}
+
+// Low-level hooks are global, so one watch runs at a time; its callbacks are created
+// once, since Windows callbacks are never freed
+var (
+	watchMutex       sync.Mutex
+	watchEvents      chan<- Event
+	modifiers        = make(map[uint16]bool) // Modifier keys held, by virtual-key code
+	mouseCallback    = sync.OnceValue(func() uintptr { return windows.NewCallback(mouseHook) })
+	keyboardCallback = sync.OnceValue(func() uintptr { return windows.NewCallback(keyboardHook) })
+)
+
+// Watch installs low-level mouse and keyboard hooks on a thread of their own, which
+// runs the message loop the hooks are called from
+func (windowsDriver) Watch(events chan<- Event) (func(), error) {
+	watchMutex.Lock()
+	if watchEvents != nil {
+		watchMutex.Unlock()
+		return nil, errors.New("input is already being watched")
+	}
+	watchEvents = events
+	clear(modifiers)
+	watchMutex.Unlock()
+
+	started := make(chan error, 1)
+	threadID := make(chan uint32, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		mouse, _, err := setWindowsHookExW.Call(WH_MOUSE_LL, mouseCallback(), 0, 0)
+		if mouse == 0 {
+			started <- fmt.Errorf("SetWindowsHookEx(WH_MOUSE_LL) failed: %w", err)
+			return
+		}
+		defer unhookWindowsHookEx.Call(mouse)
+		keyboard, _, err := setWindowsHookExW.Call(WH_KEYBOARD_LL, keyboardCallback(), 0, 0)
+		if keyboard == 0 {
+			started <- fmt.Errorf("SetWindowsHookEx(WH_KEYBOARD_LL) failed: %w", err)
+			return
+		}
+		defer unhookWindowsHookEx.Call(keyboard)
+
+		// Create the thread's message queue before anyone posts to it
+		var msg MSG
+		peekMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, PM_NOREMOVE)
+		threadID <- windows.GetCurrentThreadId()
+		started <- nil
+		for {
+			// GetMessage returns 0 for WM_QUIT and -1 on errors
+			ret, _, _ := getMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(ret) <= 0 {
+				return
+			}
+		}
+	}()
+
+	if err := <-started; err != nil {
+		<-done
+		watchMutex.Lock()
+		watchEvents = nil
+		watchMutex.Unlock()
+		return nil, err
+	}
+	tid := <-threadID
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			postThreadMessageW.Call(uintptr(tid), WM_QUIT, 0, 0)
+			<-done
+			watchMutex.Lock()
+			watchEvents = nil
+			watchMutex.Unlock()
+		})
+	}, nil
+}
+
+// deliver passes an event to the watch without blocking the hook
+func deliver(event Event) {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	if watchEvents == nil {
+		return
+	}
+	select {
+	case watchEvents <- event:
+	default:
+	}
+}
+
+func mouseHook(code int, wParam, lParam uintptr) uintptr {
+	if code == HC_ACTION {
+		info := (*MSLLHOOKSTRUCT)(unsafe.Pointer(lParam))
+		if info.Flags&LLMHF_INJECTED == 0 {
+			event := Event{Time: time.Now(), X: int(info.Pt.X), Y: int(info.Pt.Y)}
+			switch wParam {
+			case WM_LBUTTONDOWN:
+				event.Kind, event.Button = MouseDown, ButtonLeft
+			case WM_LBUTTONUP:
+				event.Kind, event.Button = MouseUp, ButtonLeft
+			case WM_RBUTTONDOWN:
+				event.Kind, event.Button = MouseDown, ButtonRight
+			case WM_RBUTTONUP:
+				event.Kind, event.Button = MouseUp, ButtonRight
+			case WM_MBUTTONDOWN:
+				event.Kind, event.Button = MouseDown, ButtonMiddle
+			case WM_MBUTTONUP:
+				event.Kind, event.Button = MouseUp, ButtonMiddle
+			}
+			if event.Kind != "" {
+				deliver(event)
+			}
+		}
+	}
+	ret, _, _ := callNextHookEx.Call(0, uintptr(code), wParam, lParam)
+	return ret
+}
+
+func keyboardHook(code int, wParam, lParam uintptr) uintptr {
+	if code == HC_ACTION {
+		info := (*KBDLLHOOKSTRUCT)(unsafe.Pointer(lParam))
+		vk := uint16(info.VkCode)
+		if info.Flags&LLKHF_INJECTED == 0 {
+			event := Event{Time: time.Now(), Key: KeyName(vk)}
+			switch wParam {
+			case WM_KEYDOWN, WM_SYSKEYDOWN:
+				event.Kind = KeyDown
+				event.Char = keyChar(vk, info.ScanCode)
+				trackModifier(event.Key, true)
+			case WM_KEYUP, WM_SYSKEYUP:
+				event.Kind = KeyUp
+				trackModifier(event.Key, false)
+			}
+			if event.Kind != "" && (event.Key != "" || event.Char != "") {
+				deliver(event)
+			}
+		}
+	}
+	ret, _, _ := callNextHookEx.Call(0, uintptr(code), wParam, lParam)
+	return ret
+}
+
+// trackModifier keeps the modifiers held, which the hook's thread can't query
+func trackModifier(key string, down bool) {
+	var vk uint16
+	switch key {
+	case "shift":
+		vk = VK_SHIFT
+	case "ctrl":
+		vk = VK_CONTROL
+	case "alt":
+		vk = VK_MENU
+	default:
+		return
+	}
+	watchMutex.Lock()
+	modifiers[vk] = down
+	watchMutex.Unlock()
+}
+
+// keyChar returns the text a key types with the modifiers held, using the current
+// keyboard layout without changing its dead-key state
+func keyChar(vk uint16, scan uint32) string {
+	var state [256]byte
+	watchMutex.Lock()
+	for code, down := range modifiers {
+		if down {
+			state[code] = 0x80
+		}
+	}
+	watchMutex.Unlock()
+	if capital, _, _ := getKeyState.Call(VK_CAPITAL); capital&1 != 0 {
+		state[VK_CAPITAL] = 0x01
+	}
+
+	var buffer [8]uint16
+	// Flag 0x4 leaves the keyboard state alone (Windows 10 1607 and later)
+	n, _, _ := toUnicode.Call(uintptr(vk), uintptr(scan), uintptr(unsafe.Pointer(&state[0])),
+		uintptr(unsafe.Pointer(&buffer[0])), uintptr(len(buffer)), 0x4)
+	if int32(n) <= 0 {
+		return ""
+	}
+	text := string(utf16.Decode(buffer[:n]))
+	for _, r := range text {
+		if r < 0x20 || r == 0x7F {
+			return "" // Control characters, such as those of Ctrl+letter
+		}
+	}
+	return text
+}
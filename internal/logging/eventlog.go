@@ -0,0 +1,90 @@
+//go:build windows
+
+package logging
+
+import (
+	"io"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the ID of every event the server logs
+const eventID = 1
+
+// RunningAsService reports whether the process was started by the service control manager
+func RunningAsService() bool {
+	service, err := svc.IsWindowsService()
+	return err == nil && service
+}
+
+// eventLogCore writes log entries to the Windows Event Log as information, warning or
+// error events
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	log     *eventlog.Log
+}
+
+// newEventLogCore opens the Event Log under source, registering the source first if this
+// process may (services run with the rights to)
+func newEventLogCore(source string, enabler zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	// Fails when the source exists already or the process isn't elevated; an unregistered
+	// source still logs, with a generic event description
+	eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The Event Log records the time and level itself
+	config := zapcore.EncoderConfig{
+		MessageKey:     "msg",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	return &eventLogCore{LevelEnabler: enabler, encoder: zapcore.NewConsoleEncoder(config), log: log}, log, nil
+}
+
+func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &eventLogCore{LevelEnabler: c.LevelEnabler, encoder: encoder, log: c.log}
+}
+
+func (c *eventLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *eventLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	message := buffer.String()
+	buffer.Free()
+
+	switch {
+	case entry.Level >= zapcore.ErrorLevel:
+		return c.log.Error(eventID, message)
+	case entry.Level == zapcore.WarnLevel:
+		return c.log.Warning(eventID, message)
+	default:
+		return c.log.Info(eventID, message)
+	}
+}
+
+func (c *eventLogCore) Sync() error {
+	return nil
+}
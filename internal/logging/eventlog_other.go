@@ -0,0 +1,20 @@
+//go:build !windows
+
+package logging
+
+import (
+	"errors"
+	"io"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RunningAsService reports false: only Windows services log to the Event Log
+func RunningAsService() bool {
+	return false
+}
+
+// newEventLogCore fails: the Event Log exists on Windows only
+func newEventLogCore(source string, enabler zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	return nil, nil, errors.New("the Event Log is only available on Windows")
+}
@@ -0,0 +1,110 @@
+// Package logging builds the server's logger from its configuration: the level, which
+// can be changed at runtime, console or JSON encoding, an optional rotated log file and,
+// on Windows, the Event Log.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log encodings
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// EventSource is the source name the server's Event Log entries appear under
+const EventSource = "ScreenshotMCP"
+
+// Options configures the logger
+type Options struct {
+	Level      string // debug, info, warn or error
+	Format     string // FormatJSON or FormatConsole
+	File       string // Log file written in addition to stderr; "" writes stderr only
+	MaxSizeMB  int    // Size at which the log file is rotated
+	MaxBackups int    // Rotated files kept, as File.1 (newest) to File.MaxBackups
+	EventLog   bool   // Also write info and above to the Windows Event Log
+}
+
+// Logger is a configured logger, its level, and the sinks to close on shutdown
+type Logger struct {
+	*zap.Logger
+	Level zap.AtomicLevel
+
+	closers []io.Closer
+}
+
+// New builds a logger from options
+func New(options Options) (*Logger, error) {
+	level, err := zap.ParseAtomicLevel(options.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", options.Level, err)
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	var encoder zapcore.Encoder
+	switch options.Format {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be %q or %q", options.Format, FormatJSON, FormatConsole)
+	}
+
+	logger := &Logger{Level: level}
+	cores := []zapcore.Core{zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), level)}
+
+	if options.File != "" {
+		file, err := OpenRotatingFile(options.File, int64(options.MaxSizeMB)<<20, options.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		logger.closers = append(logger.closers, file)
+		cores = append(cores, zapcore.NewCore(encoder.Clone(), file, level))
+	}
+
+	if options.EventLog {
+		core, closer, err := newEventLogCore(EventSource, zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= zapcore.InfoLevel && level.Enabled(l)
+		}))
+		if err != nil {
+			logger.Close()
+			return nil, fmt.Errorf("failed to open Event Log: %w", err)
+		}
+		logger.closers = append(logger.closers, closer)
+		cores = append(cores, core)
+	}
+
+	logger.Logger = zap.New(zapcore.NewTee(cores...),
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			// Sample repeated entries like zap's production logger
+			return zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+		}),
+	)
+	return logger, nil
+}
+
+// Close flushes the logger and closes its log file and Event Log handle
+func (l *Logger) Close() error {
+	if l.Logger != nil {
+		l.Logger.Sync()
+	}
+	var first error
+	for _, closer := range l.closers {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,311 @@
+// Package macro records the user's input and periodic screenshots into a workflow
+// script that replays the input and compares screenshots taken at the same points with
+// the recorded ones. A macro named name is the workflow name.lua, and its screenshots
+// are PNG files in the name.frames directory next to it.
+package macro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/screenshot-mcp-server/internal/input"
+	"github.com/screenshot-mcp-server/internal/workflow"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Recording limits
+const (
+	maxFrames      = 500         // Screenshots kept; later ticks are skipped
+	maxWait        = time.Minute // Longest wait call; longer pauses are split
+	minWait        = 50 * time.Millisecond
+	eventBuffer    = 1024 // Input events queued while a screenshot is taken
+	captureTimeout = 30 * time.Second
+)
+
+// ErrExists is returned when a workflow of the macro's name exists
+var ErrExists = errors.New("a workflow of that name exists")
+
+// CaptureFunc takes a screenshot of the recorded window as PNG
+type CaptureFunc func(ctx context.Context, method, target string) ([]byte, error)
+
+// Options configures a recording
+type Options struct {
+	Method    string // Window replayed against and captured; the foreground window by default
+	Target    string
+	Interval  time.Duration // Between screenshots; 0 takes them only at the start and end
+	Overwrite bool          // Replace a workflow of the same name
+}
+
+// step is a statement of the script's run function, at the time it happened
+type step struct {
+	time time.Time
+	line string
+}
+
+// Recording is a macro being recorded
+type Recording struct {
+	name      string
+	dir       string
+	options   Options
+	capture   CaptureFunc
+	logger    *zap.Logger
+	started   time.Time
+	stopWatch func()
+	stop      chan struct{}
+	done      chan struct{}
+
+	// Owned by the recording goroutine until done is closed
+	steps   []step
+	inputs  int
+	frames  int
+	pending map[string]input.Event // Mouse buttons down, by button
+	held    map[string]bool        // Modifiers down
+	text    strings.Builder        // Characters typed since the last step
+	textAt  time.Time
+}
+
+// Start begins recording the user's input into the workflow name of dir
+func Start(driver input.Driver, dir, name string, options Options, capture CaptureFunc, logger *zap.Logger) (*Recording, error) {
+	if !workflow.ValidName(name) {
+		return nil, fmt.Errorf("invalid macro name %q", name)
+	}
+	path := filepath.Join(dir, name+workflow.Extension)
+	if _, err := os.Stat(path); err == nil && !options.Overwrite {
+		return nil, ErrExists
+	}
+	if options.Method == "" {
+		options.Method = "foreground"
+	}
+	if err := os.MkdirAll(filepath.Join(dir, name+".frames"), 0755); err != nil {
+		return nil, err
+	}
+
+	r := &Recording{
+		name:    name,
+		dir:     dir,
+		options: options,
+		capture: capture,
+		logger:  logger,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		pending: make(map[string]input.Event),
+		held:    make(map[string]bool),
+	}
+	// The first screenshot checks the window can be captured
+	if err := r.takeFrame(r.started); err != nil {
+		os.Remove(filepath.Join(dir, name+".frames"))
+		return nil, err
+	}
+
+	events := make(chan input.Event, eventBuffer)
+	stopWatch, err := driver.Watch(events)
+	if err != nil {
+		return nil, err
+	}
+	r.stopWatch = stopWatch
+	go r.run(events)
+	return r, nil
+}
+
+// Name returns the macro's name
+func (r *Recording) Name() string {
+	return r.name
+}
+
+// Started returns when the recording started
+func (r *Recording) Started() time.Time {
+	return r.started
+}
+
+// run records events and screenshots until stopped
+func (r *Recording) run(events <-chan input.Event) {
+	defer close(r.done)
+	var tick <-chan time.Time
+	if r.options.Interval > 0 {
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case event := <-events:
+			r.record(event)
+		case now := <-tick:
+			r.logFrameError(r.takeFrame(now))
+		case <-r.stop:
+			r.stopWatch()
+			// Record what was queued before the hooks came off
+			for drained := false; !drained; {
+				select {
+				case event := <-events:
+					r.record(event)
+				default:
+					drained = true
+				}
+			}
+			r.flushText()
+			r.logFrameError(r.takeFrame(time.Now()))
+			return
+		}
+	}
+}
+
+// record turns an input event into script steps
+func (r *Recording) record(event input.Event) {
+	switch event.Kind {
+	case input.MouseDown:
+		r.pending[event.Button] = event
+	case input.MouseUp:
+		down, ok := r.pending[event.Button]
+		if !ok {
+			return
+		}
+		delete(r.pending, event.Button)
+		line := fmt.Sprintf("click(%d, %d)", down.X, down.Y)
+		if event.Button != input.ButtonLeft {
+			line = fmt.Sprintf("click(%d, %d, %s)", down.X, down.Y, workflow.Quote(event.Button))
+		}
+		r.addStep(down.Time, line)
+	case input.KeyDown:
+		if input.Modifier(event.Key) {
+			r.held[event.Key] = true
+			return
+		}
+		// Enter, tab and backspace type control characters, replayed as keys
+		if printable(event.Char) && !r.held["ctrl"] && !r.held["alt"] && !r.held["win"] {
+			if r.text.Len() == 0 {
+				r.textAt = event.Time
+			}
+			r.text.WriteString(event.Char)
+			return
+		}
+		if event.Key == "" {
+			return
+		}
+		combo := event.Key
+		for _, modifier := range []string{"win", "alt", "shift", "ctrl"} {
+			if r.held[modifier] {
+				combo = modifier + "+" + combo
+			}
+		}
+		r.addStep(event.Time, "key("+workflow.Quote(combo)+")")
+	case input.KeyUp:
+		if input.Modifier(event.Key) {
+			delete(r.held, event.Key)
+		}
+	}
+}
+
+// printable reports whether text is non-empty and has no control characters
+func printable(text string) bool {
+	for _, c := range text {
+		if !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// addStep records an input step, after any text typed before it
+func (r *Recording) addStep(at time.Time, line string) {
+	r.flushText()
+	r.steps = append(r.steps, step{time: at, line: line})
+	r.inputs++
+}
+
+func (r *Recording) flushText() {
+	if r.text.Len() == 0 {
+		return
+	}
+	r.steps = append(r.steps, step{time: r.textAt, line: "type_text(" + workflow.Quote(r.text.String()) + ")"})
+	r.inputs++
+	r.text.Reset()
+}
+
+// takeFrame saves a screenshot and the capture step comparing the replay with it
+func (r *Recording) takeFrame(at time.Time) error {
+	if r.frames >= maxFrames {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), captureTimeout)
+	defer cancel()
+	data, err := r.capture(ctx, r.options.Method, r.options.Target)
+	if err != nil {
+		return err
+	}
+
+	label := fmt.Sprintf("frame-%d", r.frames+1)
+	file := filepath.ToSlash(filepath.Join(r.name+".frames", label+".png"))
+	if err := os.WriteFile(filepath.Join(r.dir, file), data, 0644); err != nil {
+		return err
+	}
+	r.frames++
+	r.flushText()
+	r.steps = append(r.steps, step{time: at, line: fmt.Sprintf("capture{label = %s, expect = %s}", workflow.Quote(label), workflow.Quote(file))})
+	return nil
+}
+
+// logFrameError logs a screenshot that failed during the recording, which goes on
+func (r *Recording) logFrameError(err error) {
+	if err != nil {
+		r.logger.Warn("Macro screenshot failed", zap.String("macro", r.name), zap.Error(err))
+	}
+}
+
+// Stop ends the recording and writes its workflow
+func (r *Recording) Stop() (*types.MacroResult, error) {
+	close(r.stop)
+	<-r.done
+
+	script := r.script()
+	path := filepath.Join(r.dir, r.name+workflow.Extension)
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		return nil, err
+	}
+	return &types.MacroResult{
+		Name:     r.name,
+		Workflow: path,
+		Steps:    r.inputs,
+		Frames:   r.frames,
+		Duration: time.Since(r.started),
+		Script:   script,
+	}, nil
+}
+
+// script writes the steps as a workflow, with the pauses between them
+func (r *Recording) script() string {
+	var b strings.Builder
+	window := workflow.Quote(r.options.Method)
+	if r.options.Target != "" {
+		window += ", " + workflow.Quote(r.options.Target)
+	}
+	fmt.Fprintf(&b, "-- Macro recorded %s\n\nfunction run(p)\n\twindow(%s)\n", r.started.Format("2006-01-02 15:04:05"), window)
+	if r.options.Method != "foreground" {
+		b.WriteString("\tfocus()\n")
+	}
+
+	// Frames are taken between events, so sort by when each step happened
+	sort.SliceStable(r.steps, func(i, j int) bool { return r.steps[i].time.Before(r.steps[j].time) })
+	last := r.started
+	for _, s := range r.steps {
+		for pause := s.time.Sub(last); pause >= minWait; pause -= maxWait {
+			fmt.Fprintf(&b, "\twait(%s)\n", workflow.Quote(min(pause, maxWait).Round(10*time.Millisecond).String()))
+		}
+		if s.time.After(last) {
+			last = s.time
+		}
+		b.WriteString("\t" + s.line + "\n")
+	}
+	b.WriteString("end\n")
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package macro
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/input"
+	"github.com/screenshot-mcp-server/internal/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptIsAWorkflow(t *testing.T) {
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := &Recording{
+		name:    "save-note",
+		options: Options{Method: "title_contains", Target: `Notes "draft"`},
+		started: started,
+		pending: make(map[string]input.Event),
+		held:    make(map[string]bool),
+	}
+	r.steps = append(r.steps, step{time: started, line: `capture{label = "frame-1", expect = "save-note.frames/frame-1.png"}`})
+	r.record(input.Event{Kind: input.MouseDown, Button: input.ButtonRight, X: 10, Y: 20, Time: started.Add(time.Second)})
+	r.record(input.Event{Kind: input.MouseUp, Button: input.ButtonRight, Time: started.Add(time.Second)})
+	for i, char := range []string{"a", "\"", "\\", "ü"} {
+		r.record(input.Event{Kind: input.KeyDown, Key: "a", Char: char, Time: started.Add(2*time.Second + time.Duration(i)*time.Millisecond)})
+	}
+	r.record(input.Event{Kind: input.KeyDown, Key: "ctrl", Time: started.Add(3 * time.Second)})
+	r.record(input.Event{Kind: input.KeyDown, Key: "s", Char: "\x13", Time: started.Add(3 * time.Second)})
+	r.record(input.Event{Kind: input.KeyUp, Key: "ctrl", Time: started.Add(3 * time.Second)})
+	r.record(input.Event{Kind: input.KeyDown, Key: "enter", Char: "\r", Time: started.Add(2*time.Minute + 4*time.Second)})
+
+	script := r.script()
+	assert.Equal(t, `-- Macro recorded 2024-01-02 03:04:05
+
+function run(p)
+	window("title_contains", "Notes \"draft\"")
+	focus()
+	capture{label = "frame-1", expect = "save-note.frames/frame-1.png"}
+	wait("1s")
+	click(10, 20, "right")
+	wait("1s")
+	type_text("a\"\\ü")
+	wait("1s")
+	key("ctrl+s")
+	wait("1m0s")
+	wait("1m0s")
+	wait("1s")
+	key("enter")
+end
+`, script)
+
+	parsed, err := workflow.Parse("save-note", script)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(parsed.Description, "Macro recorded"))
+	assert.Empty(t, parsed.Params)
+}
@@ -0,0 +1,75 @@
+package ocr
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Match is text matching a search, located in the image
+type Match struct {
+	Text       string          `json:"text"`       // The matched text
+	Line       string          `json:"line"`       // The line of text it was found in
+	Box        types.Rectangle `json:"box"`        // Union of the boxes of the matched words
+	Confidence float64         `json:"confidence"` // Lowest confidence of the matched words
+}
+
+// Find searches the lines of text formed by words for pattern. A match can span several
+// words of a line, but not lines; its box covers every word it touches.
+func Find(words []Word, pattern *regexp.Regexp) []Match {
+	matches := []Match{}
+	for _, line := range groupLines(words) {
+		var text strings.Builder
+		starts := make([]int, len(line))
+		for i, word := range line {
+			if i > 0 {
+				text.WriteByte(' ')
+			}
+			starts[i] = text.Len()
+			text.WriteString(word.Text)
+		}
+
+		lineText := text.String()
+		for _, loc := range pattern.FindAllStringIndex(lineText, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			match := Match{Text: lineText[loc[0]:loc[1]], Line: lineText, Confidence: math.Inf(1)}
+			first := true
+			for i, word := range line {
+				if starts[i] >= loc[1] || starts[i]+len(word.Text) <= loc[0] {
+					continue
+				}
+				if first {
+					match.Box = word.Box
+					first = false
+				} else {
+					match.Box = union(match.Box, word.Box)
+				}
+				match.Confidence = math.Min(match.Confidence, word.Confidence)
+			}
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// groupLines splits words, in reading order, into lines
+func groupLines(words []Word) [][]Word {
+	var lines [][]Word
+	for i, word := range words {
+		if i == 0 || word.Block != words[i-1].Block || word.Paragraph != words[i-1].Paragraph || word.Line != words[i-1].Line {
+			lines = append(lines, nil)
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], word)
+	}
+	return lines
+}
+
+func union(a, b types.Rectangle) types.Rectangle {
+	left, top := min(a.X, b.X), min(a.Y, b.Y)
+	right, bottom := max(a.X+a.Width, b.X+b.Width), max(a.Y+a.Height, b.Y+b.Height)
+	return types.Rectangle{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
@@ -0,0 +1,122 @@
+// Package ocr extracts text from captures with the Tesseract command-line tool, which
+// must be installed separately and be on PATH.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// DefaultLanguage is the Tesseract language used when none is given
+const DefaultLanguage = "eng"
+
+// ErrUnavailable is returned when tesseract isn't installed
+var ErrUnavailable = errors.New("OCR requires tesseract on PATH")
+
+// Available reports whether tesseract can be run
+func Available() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// Word is a word Tesseract found, with its bounding box in image pixels
+type Word struct {
+	Text       string          `json:"text"`
+	Box        types.Rectangle `json:"box"`
+	Confidence float64         `json:"confidence"` // 0-100
+	Block      int             `json:"block"`
+	Paragraph  int             `json:"paragraph"`
+	Line       int             `json:"line"`
+}
+
+// Recognize returns the text in an encoded image (PNG or JPEG). language is a Tesseract
+// language such as "eng" or "eng+deu"; empty uses DefaultLanguage.
+func Recognize(ctx context.Context, image []byte, language string) (string, error) {
+	output, err := run(ctx, image, language)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Words returns the words in an encoded image with their bounding boxes, in reading order
+func Words(ctx context.Context, image []byte, language string) ([]Word, error) {
+	output, err := run(ctx, image, language, "tsv")
+	if err != nil {
+		return nil, err
+	}
+	return parseTSV(output)
+}
+
+// run runs tesseract on an image and returns its output
+func run(ctx context.Context, image []byte, language string, config ...string) (string, error) {
+	tesseract, err := exec.LookPath("tesseract")
+	if err != nil {
+		return "", ErrUnavailable
+	}
+	if language == "" {
+		language = DefaultLanguage
+	}
+
+	args := append([]string{"stdin", "stdout", "-l", language}, config...)
+	cmd := exec.CommandContext(ctx, tesseract, args...)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("tesseract timed out: %w", ctx.Err())
+		}
+		return "", fmt.Errorf("tesseract failed: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.String(), nil
+}
+
+// parseTSV reads the words of Tesseract's TSV output, whose columns are level,
+// page_num, block_num, par_num, line_num, word_num, left, top, width, height, conf and
+// text. Level 5 rows are words.
+func parseTSV(output string) ([]Word, error) {
+	words := []Word{}
+	for i, line := range strings.Split(output, "\n") {
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue // Header
+		}
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), "\t", 12)
+		if len(fields) < 12 {
+			return nil, fmt.Errorf("unexpected tesseract TSV line %d: %q", i+1, line)
+		}
+		if fields[0] != "5" || strings.TrimSpace(fields[11]) == "" {
+			continue
+		}
+
+		values := make([]int, 0, 10)
+		for _, field := range fields[:10] {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected tesseract TSV line %d: %q", i+1, line)
+			}
+			values = append(values, n)
+		}
+		confidence, err := strconv.ParseFloat(fields[10], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected tesseract TSV line %d: %q", i+1, line)
+		}
+		words = append(words, Word{
+			Text:       fields[11],
+			Box:        types.Rectangle{X: values[6], Y: values[7], Width: values[8], Height: values[9]},
+			Confidence: confidence,
+			Block:      values[2],
+			Paragraph:  values[3],
+			Line:       values[4],
+		})
+	}
+	return words, nil
+}
@@ -0,0 +1,382 @@
+// Package openapi describes the REST API as an OpenAPI 3.0 document. Paths come from
+// the router's registered routes and schemas are derived by reflection from the Go
+// types handlers bind and return, so the document follows the code it describes.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the API is served from
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+// Operation describes one route
+type Operation struct {
+	Tags        []string            `json:"tags,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a request body
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of OpenAPI schema objects the generator produces
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Components holds the named schemas operations refer to
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Route documents a route. Request and Response are values whose types describe the
+// JSON bodies; a nil Response documents a body of ContentType instead.
+type Route struct {
+	Method      string
+	Path        string // Router syntax, e.g. /v1/windows/:handle
+	OperationID string // Default derived from the method and path, e.g. getV1WindowsHandle
+	Tag         string
+	Summary     string
+	Description string
+	Query       []Param
+	Request     any
+	Response    any
+	ContentType string // Content type of a non-JSON response, e.g. "image/png"
+	Status      int    // Success status, default 200
+	Deprecated  bool
+}
+
+// Param documents a query or path parameter
+type Param struct {
+	Name        string
+	Description string
+	Type        string // "string" (default), "integer", "number" or "boolean"
+	Required    bool
+	Enum        []string
+}
+
+// Generator builds a Document route by route
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+	tags  map[string]bool
+}
+
+// New starts a document
+func New(info Info, servers ...Server) *Generator {
+	return &Generator{
+		doc: &Document{
+			OpenAPI:    "3.0.3",
+			Info:       info,
+			Servers:    servers,
+			Paths:      make(map[string]PathItem),
+			Components: Components{Schemas: make(map[string]*Schema)},
+		},
+		names: make(map[reflect.Type]string),
+		tags:  make(map[string]bool),
+	}
+}
+
+// routeParam matches :name and *name segments of router paths
+var routeParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Add documents a route; errorBody describes the body of failure responses
+func (g *Generator) Add(route Route, errorBody any) {
+	path := routeParam.ReplaceAllString(route.Path, "{$1}")
+	id := route.OperationID
+	if id == "" {
+		id = operationID(route.Method, path)
+	}
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: id,
+		Responses:   make(map[string]Response),
+		Deprecated:  route.Deprecated,
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+		g.AddTag(Tag{Name: route.Tag})
+	}
+
+	for _, match := range routeParam.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range route.Query {
+		schemaType := param.Type
+		if schemaType == "" {
+			schemaType = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      &Schema{Type: schemaType, Enum: param.Enum},
+		})
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.Schema(reflect.TypeOf(route.Request))}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case route.Response != nil:
+		success.Content = map[string]MediaType{"application/json": {Schema: g.Schema(reflect.TypeOf(route.Response))}}
+	case route.ContentType == "application/json":
+		success.Content = map[string]MediaType{route.ContentType: {Schema: &Schema{Type: "object"}}}
+	case route.ContentType != "":
+		success.Content = map[string]MediaType{route.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	if errorBody != nil {
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content:     map[string]MediaType{"application/json": {Schema: g.Schema(reflect.TypeOf(errorBody))}},
+		}
+	}
+
+	item := g.doc.Paths[path]
+	if item == nil {
+		item = make(PathItem)
+		g.doc.Paths[path] = item
+	}
+	item[strings.ToLower(route.Method)] = op
+}
+
+// AddTag declares a tag; tags are listed in the order they are declared or first used
+func (g *Generator) AddTag(tag Tag) {
+	if !g.tags[tag.Name] {
+		g.tags[tag.Name] = true
+		g.doc.Tags = append(g.doc.Tags, tag)
+	}
+}
+
+// Document returns the document built so far
+func (g *Generator) Document() *Document {
+	return g.doc
+}
+
+// Schema describes a Go type the way encoding/json marshals it. Named struct types
+// are added to the components and referenced.
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := g.schema(t)
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			return &Schema{}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	default:
+		// Interfaces and anything else can hold any JSON value
+		return &Schema{}
+	}
+}
+
+// ref registers a named struct type in the components and refers to it
+func (g *Generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = exportedName(t.Name())
+		if _, taken := g.doc.Components.Schemas[name]; taken {
+			name = exportedName(t.PkgPath()) + name
+		}
+		g.names[t] = name
+		g.doc.Components.Schemas[name] = &Schema{} // Placeholder for recursive types
+		g.doc.Components.Schemas[name] = g.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes a struct's JSON fields, inlining embedded structs
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, value := range g.structSchema(embedded).Properties {
+					schema.Properties[key] = value
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.Schema(field.Type)
+	}
+	return schema
+}
+
+// operationID derives an identifier such as getV1WindowsHandle from a method and path
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		id.WriteString(exportedName(part))
+	}
+	return id.String()
+}
+
+// exportedName capitalizes the last element of a path
+func exportedName(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	if path == "" {
+		return ""
+	}
+	return strings.ToUpper(path[:1]) + path[1:]
+}
@@ -0,0 +1,344 @@
+// Package plugin runs the server's plugins: executables found in the plugins directory
+// that add MCP tools and pipeline stages. A plugin is a subdirectory holding a
+// plugin.json manifest; its command is started with the server and spoken to in
+// JSON-RPC 2.0, one message per line on stdin and stdout, so plugins can be written in
+// any language, including Python for ML-based detectors.
+//
+// The server sends:
+//
+//	initialize   {"server_url", "protocol"}   -> {"version", "description", "tools": [{"name", "description", "params"}], "stages": [{"name", "description"}]}
+//	tool.call    {"tool", "params"}           -> any JSON result
+//	stage.apply  {"stage", "params", "image"} -> {"image"}
+//	shutdown     (notification)
+//
+// Images are base64 PNG. Tools and stages are namespaced by the plugin's name, so tool
+// "detect" of plugin "buttons" is the MCP method "buttons.detect".
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Protocol is the version of the plugin protocol the server speaks
+const Protocol = 1
+
+// ManifestName is the file that makes a directory a plugin
+const ManifestName = "plugin.json"
+
+// Plugin timeouts
+const (
+	startTimeout = 10 * time.Second // For a plugin to answer initialize
+	callTimeout  = 60 * time.Second // For a tool call or stage to finish
+	stopTimeout  = 5 * time.Second  // For a plugin to exit after shutdown
+)
+
+// validName matches plugin, tool and stage names
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Manifest is a plugin's plugin.json
+type Manifest struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"` // Executable, relative to the plugin's directory or on the PATH
+	Args    []string `json:"args"`
+}
+
+// initializeResult is a plugin's answer to initialize
+type initializeResult struct {
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Tools       []struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Params      json.RawMessage `json:"params"`
+	} `json:"tools"`
+	Stages []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"stages"`
+}
+
+// Plugin is a plugin found in the plugins directory
+type Plugin struct {
+	info    types.PluginInfo
+	process *process
+}
+
+// Info describes the plugin and whether it is still running
+func (p *Plugin) Info() types.PluginInfo {
+	info := p.info
+	if p.process != nil {
+		exited, err := p.process.exited()
+		info.Running = !exited
+		if exited {
+			info.Error = "plugin exited"
+			if err != nil {
+				info.Error = fmt.Sprintf("plugin exited: %v", err)
+			}
+		}
+	}
+	return info
+}
+
+// Manager starts the plugins of a directory and routes tool calls to them
+type Manager struct {
+	logger *zap.Logger
+
+	mutex   sync.RWMutex
+	plugins []*Plugin
+	tools   map[string]*Plugin // By method name
+}
+
+// NewManager creates a manager without plugins
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger, tools: make(map[string]*Plugin)}
+}
+
+// Load starts every plugin in dir and registers its tools and pipeline stages. A missing
+// directory has no plugins; a plugin that fails to start is listed with its error and
+// doesn't stop the others.
+func (m *Manager) Load(dir, serverURL string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pluginDir := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(pluginDir, ManifestName)); err != nil {
+			continue
+		}
+		plugin := m.start(pluginDir, serverURL)
+		m.mutex.Lock()
+		m.plugins = append(m.plugins, plugin)
+		m.mutex.Unlock()
+	}
+	return nil
+}
+
+// start starts the plugin in dir. Failures are recorded in the plugin's info.
+func (m *Manager) start(dir, serverURL string) *Plugin {
+	plugin := &Plugin{info: types.PluginInfo{
+		Name:   filepath.Base(dir),
+		Dir:    dir,
+		Tools:  []types.PluginTool{},
+		Stages: []types.PluginStage{},
+	}}
+	if err := m.startProcess(plugin, dir, serverURL); err != nil {
+		plugin.info.Error = err.Error()
+		m.logger.Error("Failed to start plugin", zap.String("dir", dir), zap.Error(err))
+		return plugin
+	}
+
+	m.logger.Info("Plugin started",
+		zap.String("plugin", plugin.info.Name),
+		zap.String("version", plugin.info.Version),
+		zap.Int("tools", len(plugin.info.Tools)),
+		zap.Int("stages", len(plugin.info.Stages)),
+	)
+	return plugin
+}
+
+func (m *Manager) startProcess(plugin *Plugin, dir, serverURL string) error {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid %s: %w", ManifestName, err)
+	}
+	if manifest.Name != "" {
+		plugin.info.Name = manifest.Name
+	}
+	if !validName.MatchString(plugin.info.Name) {
+		return fmt.Errorf("invalid plugin name %q", plugin.info.Name)
+	}
+	if manifest.Command == "" {
+		return fmt.Errorf("%s has no command", ManifestName)
+	}
+
+	command := manifest.Command
+	if local := filepath.Join(dir, command); fileExists(local) {
+		command = local
+	}
+	cmd := exec.Command(command, manifest.Args...)
+	cmd.Dir = dir
+	proc, err := start(cmd, m.logger.With(zap.String("plugin", plugin.info.Name)))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	var result initializeResult
+	err = proc.call(ctx, "initialize", map[string]interface{}{"server_url": serverURL, "protocol": Protocol}, &result)
+	if err != nil {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), stopTimeout)
+		defer stopCancel()
+		proc.stop(stopCtx)
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	plugin.process = proc
+	plugin.info.Version = result.Version
+	plugin.info.Description = result.Description
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, tool := range result.Tools {
+		name := plugin.info.Name + "." + tool.Name
+		if !validName.MatchString(tool.Name) || m.tools[name] != nil {
+			m.logger.Warn("Skipping plugin tool with an invalid or duplicate name", zap.String("tool", name))
+			continue
+		}
+		m.tools[name] = plugin
+		plugin.info.Tools = append(plugin.info.Tools, types.PluginTool{Name: name, Description: tool.Description, Params: tool.Params})
+	}
+	for _, stage := range result.Stages {
+		name := plugin.info.Name + "." + stage.Name
+		if !validName.MatchString(stage.Name) || stageRegistered(name) {
+			m.logger.Warn("Skipping plugin stage with an invalid or duplicate name", zap.String("stage", name))
+			continue
+		}
+		screenshot.RegisterStage(name, plugin.stageFactory(stage.Name))
+		plugin.info.Stages = append(plugin.info.Stages, types.PluginStage{Name: name, Description: stage.Description})
+	}
+	return nil
+}
+
+// HasTool reports whether a plugin provides the MCP method
+func (m *Manager) HasTool(method string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.tools[method] != nil
+}
+
+// CallTool calls a plugin's tool with the params of an MCP request
+func (m *Manager) CallTool(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	m.mutex.RLock()
+	plugin := m.tools[method]
+	m.mutex.RUnlock()
+	if plugin == nil {
+		return nil, fmt.Errorf("no plugin provides %s", method)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	var result json.RawMessage
+	tool := method[len(plugin.info.Name)+1:]
+	if err := plugin.process.call(ctx, "tool.call", map[string]interface{}{"tool": tool, "params": params}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// List describes every plugin, sorted by name
+func (m *Manager) List() []types.PluginInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	infos := make([]types.PluginInfo, 0, len(m.plugins))
+	for _, plugin := range m.plugins {
+		infos = append(infos, plugin.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Close stops every plugin
+func (m *Manager) Close() {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, plugin := range m.plugins {
+		if plugin.process == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(proc *process) {
+			defer wg.Done()
+			proc.stop(ctx)
+		}(plugin.process)
+	}
+	wg.Wait()
+}
+
+// stageFactory returns the factory of one of the plugin's stages. Params are passed to
+// the plugin with every image; the plugin validates them.
+func (p *Plugin) stageFactory(stage string) screenshot.StageFactory {
+	return func(params json.RawMessage) (screenshot.Stage, error) {
+		return screenshot.StageFunc(func(img image.Image) (image.Image, error) {
+			return p.applyStage(stage, params, img)
+		}), nil
+	}
+}
+
+// applyStage sends an image through one of the plugin's stages
+func (p *Plugin) applyStage(stage string, params json.RawMessage, img image.Image) (image.Image, error) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	var result struct {
+		Image string `json:"image"`
+	}
+	err := p.process.call(ctx, "stage.apply", map[string]interface{}{
+		"stage":  stage,
+		"params": params,
+		"image":  base64.StdEncoding.EncodeToString(encoded.Bytes()),
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.info.Name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Image)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s returned invalid image data: %w", p.info.Name, err)
+	}
+	processed, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s returned an invalid PNG: %w", p.info.Name, err)
+	}
+	return processed, nil
+}
+
+func stageRegistered(name string) bool {
+	for _, registered := range screenshot.StageNames() {
+		if registered == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,218 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// maxMessageSize is the longest line a plugin may write, enough for a base64 4K capture
+const maxMessageSize = 64 << 20
+
+// ErrStopped is returned by calls to a plugin whose process has exited
+var ErrStopped = errors.New("plugin is not running")
+
+// request is a JSON-RPC request sent to a plugin
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int64       `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response is a plugin's JSON-RPC response
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Error is a JSON-RPC error a plugin returned
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// process is a running plugin executable that is sent one JSON-RPC message per line on
+// stdin and answers on stdout. Its stderr goes to the server log.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	logger *zap.Logger
+
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	nextID     int64
+	pending    map[int64]chan *response
+	stderrDone chan struct{}
+	done       chan struct{}
+	err        error // Why the process exited, once done is closed
+}
+
+// start launches cmd and begins reading its responses
+func start(cmd *exec.Cmd, logger *zap.Logger) (*process, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{
+		cmd:        cmd,
+		stdin:      stdin,
+		logger:     logger,
+		pending:    make(map[int64]chan *response),
+		stderrDone: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go p.logStderr(stderr)
+	go p.readResponses(stdout)
+	return p, nil
+}
+
+// call sends a request and decodes the result into result, which may be nil
+func (p *process) call(ctx context.Context, method string, params, result interface{}) error {
+	p.mutex.Lock()
+	select {
+	case <-p.done:
+		p.mutex.Unlock()
+		return ErrStopped
+	default:
+	}
+	p.nextID++
+	id := p.nextID
+	reply := make(chan *response, 1)
+	p.pending[id] = reply
+	p.mutex.Unlock()
+
+	defer func() {
+		p.mutex.Lock()
+		delete(p.pending, id)
+		p.mutex.Unlock()
+	}()
+
+	if err := p.send(request{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		return nil
+	case <-p.done:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify sends a request that gets no response
+func (p *process) notify(method string) error {
+	return p.send(request{JSONRPC: "2.0", Method: method})
+}
+
+func (p *process) send(message request) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// readResponses hands each response line to the call waiting for it, until stdout closes
+func (p *process) readResponses(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			p.logger.Warn("Plugin wrote an invalid message", zap.Error(err))
+			continue
+		}
+		p.mutex.Lock()
+		reply, ok := p.pending[resp.ID]
+		p.mutex.Unlock()
+		if ok {
+			reply <- &resp
+		}
+	}
+
+	// Wait closes the pipes, so it must come after every read
+	if scanner.Err() != nil {
+		p.cmd.Process.Kill()
+	}
+	<-p.stderrDone
+	err := p.cmd.Wait()
+	if scanErr := scanner.Err(); scanErr != nil {
+		err = scanErr
+	}
+	p.mutex.Lock()
+	p.err = err
+	close(p.done)
+	p.mutex.Unlock()
+}
+
+func (p *process) logStderr(stderr io.Reader) {
+	defer close(p.stderrDone)
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		p.logger.Info("Plugin output", zap.String("line", scanner.Text()))
+	}
+}
+
+// exited returns whether the process has exited, and why
+func (p *process) exited() (bool, error) {
+	select {
+	case <-p.done:
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		return true, p.err
+	default:
+		return false, nil
+	}
+}
+
+// stop asks the plugin to exit, killing it if it hasn't by the time ctx is done
+func (p *process) stop(ctx context.Context) {
+	p.notify("shutdown")
+	p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
@@ -0,0 +1,122 @@
+// Package power reads whether the machine runs on battery or in battery saver mode and
+// applies the power policy capping streams accordingly.
+package power
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Power sources
+const (
+	SourceAC      = "ac"
+	SourceBattery = "battery"
+	SourceUnknown = "unknown"
+)
+
+// ErrUnsupported is returned where the power state can't be queried
+var ErrUnsupported = errors.New("power state is not available on this platform")
+
+// refreshInterval is how long a queried power state is reused
+const refreshInterval = 5 * time.Second
+
+// DefaultPolicy halves a typical stream on battery and caps it further in battery saver
+func DefaultPolicy() types.PowerPolicy {
+	return types.PowerPolicy{
+		Battery: &types.PowerLimits{MaxFPS: 15, MaxQuality: 75},
+		Saver:   &types.PowerLimits{MaxFPS: 5, MaxQuality: 60},
+	}
+}
+
+// Monitor caches the power state and applies the policy to streams
+type Monitor struct {
+	query func() (types.PowerState, error)
+
+	mutex   sync.Mutex
+	policy  types.PowerPolicy
+	state   types.PowerState
+	checked time.Time
+}
+
+// NewMonitor creates a monitor querying this platform's power state
+func NewMonitor(policy types.PowerPolicy) *Monitor {
+	return NewMonitorWith(Query, policy)
+}
+
+// NewMonitorWith creates a monitor reading the power state from query
+func NewMonitorWith(query func() (types.PowerState, error), policy types.PowerPolicy) *Monitor {
+	return &Monitor{query: query, policy: policy}
+}
+
+// Configure replaces the policy
+func (m *Monitor) Configure(policy types.PowerPolicy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.policy = policy
+}
+
+// State returns the power state, queried at most every few seconds
+func (m *Monitor) State() types.PowerState {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.stateLocked()
+}
+
+func (m *Monitor) stateLocked() types.PowerState {
+	if time.Since(m.checked) < refreshInterval {
+		return m.state
+	}
+	state, err := m.query()
+	if err != nil {
+		state = types.PowerState{Source: SourceUnknown}
+	}
+	m.state, m.checked = state, time.Now()
+	return state
+}
+
+// Limits returns the caps the policy sets in the current power state, with the state.
+// A nil monitor caps nothing.
+func (m *Monitor) Limits() (types.PowerLimits, types.PowerState) {
+	if m == nil {
+		return types.PowerLimits{}, types.PowerState{Source: SourceUnknown}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	state := m.stateLocked()
+
+	var limits types.PowerLimits
+	if state.Source == SourceBattery && m.policy.Battery != nil {
+		limits = lower(limits, *m.policy.Battery)
+	}
+	if state.BatterySaver && m.policy.Saver != nil {
+		limits = lower(limits, *m.policy.Saver)
+	}
+	return limits, state
+}
+
+// lower combines two sets of caps, keeping the lower of each
+func lower(a, b types.PowerLimits) types.PowerLimits {
+	return types.PowerLimits{MaxFPS: lowerCap(a.MaxFPS, b.MaxFPS), MaxQuality: lowerCap(a.MaxQuality, b.MaxQuality)}
+}
+
+func lowerCap(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Apply caps a frame rate and quality, returning whether either was lowered
+func Apply(limits types.PowerLimits, fps, quality int) (int, int, bool) {
+	capped := false
+	if limits.MaxFPS > 0 && fps > limits.MaxFPS {
+		fps, capped = limits.MaxFPS, true
+	}
+	if limits.MaxQuality > 0 && quality > limits.MaxQuality {
+		quality, capped = limits.MaxQuality, true
+	}
+	return fps, quality, capped
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package power
+
+import "github.com/screenshot-mcp-server/pkg/types"
+
+// Query reports that the power state is unknown, so no policy applies
+func Query() (types.PowerState, error) {
+	return types.PowerState{}, ErrUnsupported
+}
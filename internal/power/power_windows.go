@@ -0,0 +1,44 @@
+//go:build windows
+
+package power
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var getSystemPowerStatus = win32.Kernel32.NewProc("GetSystemPowerStatus")
+
+// SYSTEM_POWER_STATUS is the power state GetSystemPowerStatus fills in
+type SYSTEM_POWER_STATUS struct {
+	ACLineStatus        byte // 0 offline, 1 online, 255 unknown
+	BatteryFlag         byte // 128 no system battery, 255 unknown
+	BatteryLifePercent  byte // 255 unknown
+	SystemStatusFlag    byte // 1 while battery saver is on (Windows 10 and later)
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// Query reads the power state with GetSystemPowerStatus
+func Query() (types.PowerState, error) {
+	var status SYSTEM_POWER_STATUS
+	if ret, _, err := getSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status))); ret == 0 {
+		return types.PowerState{}, fmt.Errorf("GetSystemPowerStatus failed: %w", err)
+	}
+
+	state := types.PowerState{Source: SourceUnknown, BatterySaver: status.SystemStatusFlag == 1}
+	switch status.ACLineStatus {
+	case 0:
+		state.Source = SourceBattery
+	case 1:
+		state.Source = SourceAC
+	}
+	if status.BatteryFlag != 128 && status.BatteryFlag != 255 && status.BatteryLifePercent <= 100 {
+		percent := int(status.BatteryLifePercent)
+		state.BatteryPercent = &percent
+	}
+	return state, nil
+}
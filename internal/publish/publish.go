@@ -0,0 +1,354 @@
+// Package publish pushes captures of a window or display to an RTMP server, so broadcast
+// tooling such as OBS (through nginx-rtmp or its media source), vMix or a streaming
+// service takes them in without a custom client. Frames are encoded as H.264 in FLV by
+// ffmpeg, which must be on PATH.
+package publish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/rawframe"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Output size, rate and bitrate defaults and limits
+const (
+	DefaultWidth   = 1280
+	DefaultHeight  = 720
+	DefaultFPS     = 30
+	DefaultBitrate = 4000
+	maxDimension   = 3840
+	maxFPS         = 60
+	maxBitrate     = 50000
+)
+
+// stopTimeout is how long ffmpeg gets to end the stream once stopped
+const stopTimeout = 5 * time.Second
+
+// ErrNotFound is returned for an output ID that isn't listed
+var ErrNotFound = errors.New("output not found")
+
+// CaptureFunc captures an output's source once, giving up when ctx is cancelled
+type CaptureFunc func(ctx context.Context) (*types.ScreenshotBuffer, error)
+
+// output is one ffmpeg process publishing a source
+type output struct {
+	status types.OutputStatus
+	url    string // Unmasked
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Publisher runs the RTMP outputs
+type Publisher struct {
+	logger  *zap.Logger
+	mutex   sync.Mutex
+	outputs map[string]*output
+}
+
+// NewPublisher returns a publisher with no outputs
+func NewPublisher(logger *zap.Logger) *Publisher {
+	return &Publisher{logger: logger, outputs: make(map[string]*output)}
+}
+
+// ValidateRequest fills in the defaults of a request and checks its URL, size, frame rate
+// and bitrate
+func ValidateRequest(req *types.OutputRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "rtmp" && target.Scheme != "rtmps") || target.Host == "" {
+		return fmt.Errorf("url must be an rtmp:// or rtmps:// URL")
+	}
+	if req.Width == 0 {
+		req.Width = DefaultWidth
+	}
+	if req.Height == 0 {
+		req.Height = DefaultHeight
+	}
+	if req.FPS == 0 {
+		req.FPS = DefaultFPS
+	}
+	if req.Bitrate == 0 {
+		req.Bitrate = DefaultBitrate
+	}
+	// H.264 with 4:2:0 chroma needs even dimensions
+	if req.Width < 16 || req.Height < 16 || req.Width > maxDimension || req.Height > maxDimension ||
+		req.Width%2 != 0 || req.Height%2 != 0 {
+		return fmt.Errorf("invalid size %dx%d: width and height must be even and 16-%d", req.Width, req.Height, maxDimension)
+	}
+	if req.FPS < 1 || req.FPS > maxFPS {
+		return fmt.Errorf("invalid fps %d: must be 1-%d", req.FPS, maxFPS)
+	}
+	if req.Bitrate < 100 || req.Bitrate > maxBitrate {
+		return fmt.Errorf("invalid bitrate %d: must be 100-%d kbit/s", req.Bitrate, maxBitrate)
+	}
+	return nil
+}
+
+// Start launches ffmpeg and feeds it captures until the output is stopped or ffmpeg
+// exits. req has been validated.
+func (p *Publisher) Start(req types.OutputRequest, capture CaptureFunc) (types.OutputStatus, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return types.OutputStatus{}, fmt.Errorf("RTMP output requires ffmpeg on PATH")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, ffmpeg, ffmpegArgs(req)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return types.OutputStatus{}, err
+	}
+	stderr := &tailBuffer{}
+	cmd.Stderr = stderr
+	// Stopping closes stdin, interrupting a write blocked on a stalled connection and
+	// letting ffmpeg flush the end of the stream; it's killed if that takes too long
+	cmd.Cancel = stdin.Close
+	cmd.WaitDelay = stopTimeout
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return types.OutputStatus{}, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	out := &output{
+		status: types.OutputStatus{
+			ID:        "out_" + strconv.FormatInt(time.Now().UnixNano(), 10),
+			Running:   true,
+			Target:    req.Target,
+			URL:       maskStreamKey(req.URL),
+			Width:     req.Width,
+			Height:    req.Height,
+			FPS:       req.FPS,
+			Bitrate:   req.Bitrate,
+			StartedAt: time.Now(),
+		},
+		url:    req.URL,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	p.mutex.Lock()
+	p.outputs[out.status.ID] = out
+	status := out.status
+	p.mutex.Unlock()
+
+	go p.run(ctx, out, cmd, stdin, stderr, capture)
+
+	p.logger.Info("RTMP output started",
+		zap.String("output_id", status.ID),
+		zap.String("target", req.Target),
+		zap.String("url", status.URL),
+		zap.Int("width", req.Width),
+		zap.Int("height", req.Height),
+		zap.Int("fps", req.FPS),
+	)
+	return status, nil
+}
+
+// Stop stops an output, if it's still running, and removes it from the list, returning
+// its final status
+func (p *Publisher) Stop(id string) (types.OutputStatus, error) {
+	p.mutex.Lock()
+	out, ok := p.outputs[id]
+	if !ok {
+		p.mutex.Unlock()
+		return types.OutputStatus{}, ErrNotFound
+	}
+	delete(p.outputs, id)
+	p.mutex.Unlock()
+
+	out.cancel()
+	<-out.done
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return out.status, nil
+}
+
+// Get returns an output's status
+func (p *Publisher) Get(id string) (types.OutputStatus, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	out, ok := p.outputs[id]
+	if !ok {
+		return types.OutputStatus{}, ErrNotFound
+	}
+	return out.status, nil
+}
+
+// List returns the status of every output, oldest first
+func (p *Publisher) List() []types.OutputStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	list := make([]types.OutputStatus, 0, len(p.outputs))
+	for _, out := range p.outputs {
+		list = append(list, out.status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// Close stops every output
+func (p *Publisher) Close() {
+	for _, status := range p.List() {
+		p.Stop(status.ID)
+	}
+}
+
+// run captures the source at the output's frame rate and writes the frames to ffmpeg,
+// until ctx is cancelled or ffmpeg exits. A capture that fails repeats the previous frame,
+// keeping the constant rate ffmpeg was told to expect.
+func (p *Publisher) run(ctx context.Context, out *output, cmd *exec.Cmd, stdin io.WriteCloser, stderr *tailBuffer, capture CaptureFunc) {
+	defer close(out.done)
+
+	p.mutex.Lock()
+	id, width, height, fps := out.status.ID, out.status.Width, out.status.Height, out.status.FPS
+	p.mutex.Unlock()
+
+	frame := make([]byte, width*height*3)
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	var writeErr error
+	for writeErr == nil {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		buffer, err := capture(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if err == nil {
+			err = rawframe.FitBGR(frame, width, height, buffer)
+		}
+		_, writeErr = stdin.Write(frame)
+
+		p.mutex.Lock()
+		if err != nil {
+			if out.status.LastError != err.Error() {
+				p.logger.Warn("RTMP output frame failed", zap.String("output_id", id), zap.Error(err))
+			}
+			out.status.FailedFrames++
+			out.status.LastError = err.Error()
+		} else {
+			out.status.Frames++
+			out.status.LastError = ""
+		}
+		p.mutex.Unlock()
+	}
+
+	stdin.Close()
+	waitErr := cmd.Wait()
+
+	now := time.Now()
+	p.mutex.Lock()
+	out.status.Running = false
+	out.status.StoppedAt = &now
+	if ctx.Err() == nil {
+		// ffmpeg exited on its own, such as when the server refused the stream
+		// ffmpeg names the URL in its errors
+		log := strings.ReplaceAll(stderr.String(), out.url, out.status.URL)
+		out.status.LastError = ffmpegError(waitErr, log)
+		p.logger.Warn("RTMP output failed",
+			zap.String("output_id", id),
+			zap.String("error", out.status.LastError),
+		)
+	} else {
+		p.logger.Info("RTMP output stopped",
+			zap.String("output_id", id),
+			zap.Int64("frames", out.status.Frames),
+			zap.Int64("failed_frames", out.status.FailedFrames),
+		)
+	}
+	p.mutex.Unlock()
+	out.cancel()
+}
+
+// ffmpegArgs returns the arguments of an ffmpeg encoding raw BGR frames from stdin as
+// low-latency H.264 in FLV. A silent audio track is added, since most streaming services
+// reject streams without one.
+func ffmpegArgs(req types.OutputRequest) []string {
+	bitrate := strconv.Itoa(req.Bitrate) + "k"
+	return []string{
+		"-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "bgr24",
+		"-s", fmt.Sprintf("%dx%d", req.Width, req.Height),
+		"-framerate", strconv.Itoa(req.FPS),
+		"-i", "-",
+		"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=44100",
+		"-c:v", "libx264", "-preset", "veryfast", "-tune", "zerolatency", "-pix_fmt", "yuv420p",
+		"-b:v", bitrate, "-maxrate", bitrate, "-bufsize", strconv.Itoa(req.Bitrate*2) + "k",
+		"-g", strconv.Itoa(req.FPS * 2),
+		"-c:a", "aac", "-b:a", "128k",
+		"-shortest",
+		"-f", "flv", req.URL,
+	}
+}
+
+// ffmpegError describes why ffmpeg exited, from the last line it logged
+func ffmpegError(err error, log string) string {
+	lines := strings.Split(strings.TrimSpace(log), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return "ffmpeg: " + last
+	}
+	if err != nil {
+		return "ffmpeg: " + err.Error()
+	}
+	return "ffmpeg exited"
+}
+
+// maskStreamKey hides the stream key, the last path segment of an RTMP URL, and any
+// credentials, so statuses can be listed and logged
+func maskStreamKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	if i := strings.LastIndex(u.Path, "/"); i > 0 && i < len(u.Path)-1 {
+		u.Path = u.Path[:i+1]
+		return u.String() + "****"
+	}
+	return u.String()
+}
+
+// tailBuffer keeps the last few KB ffmpeg logged
+type tailBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+// tailLimit is how much of ffmpeg's log is kept
+const tailLimit = 4096
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.buf.Write(p)
+	if extra := t.buf.Len() - tailLimit; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.buf.String()
+}
@@ -0,0 +1,266 @@
+//go:build darwin && cgo
+
+// Package quartz binds the Quartz Window Services and display functions of CoreGraphics
+// the macOS capture backend needs. Reading other applications' window titles and
+// contents requires the Screen Recording permission; without it titles are empty and
+// captures show only the desktop.
+//
+// CGWindowListCreateImage is deprecated in favor of ScreenCaptureKit and is unavailable
+// in the macOS 15 SDK, so the package must be built against an earlier SDK.
+package quartz
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics -framework CoreFoundation
+#include <stdlib.h>
+#include <CoreGraphics/CoreGraphics.h>
+#include <CoreFoundation/CoreFoundation.h>
+
+typedef struct {
+	uint32_t id;
+	int32_t  pid;
+	int32_t  layer;
+	int      onscreen;
+	double   x, y, width, height;
+	char     *title;
+	char     *owner;
+} qzWindow;
+
+// qzCopyString returns a malloc'd UTF-8 copy of a CFString, or NULL
+static char *qzCopyString(CFStringRef s) {
+	if (s == NULL) {
+		return NULL;
+	}
+	CFIndex size = CFStringGetMaximumSizeForEncoding(CFStringGetLength(s), kCFStringEncodingUTF8) + 1;
+	char *buf = malloc(size);
+	if (!CFStringGetCString(s, buf, size, kCFStringEncodingUTF8)) {
+		free(buf);
+		return NULL;
+	}
+	return buf;
+}
+
+// qzListWindows describes every window, frontmost first
+static int qzListWindows(qzWindow **out) {
+	CFArrayRef list = CGWindowListCopyWindowInfo(kCGWindowListOptionAll | kCGWindowListExcludeDesktopElements, kCGNullWindowID);
+	if (list == NULL) {
+		return -1;
+	}
+	CFIndex n = CFArrayGetCount(list);
+	qzWindow *windows = calloc(n > 0 ? n : 1, sizeof(qzWindow));
+	for (CFIndex i = 0; i < n; i++) {
+		CFDictionaryRef d = CFArrayGetValueAtIndex(list, i);
+		qzWindow *w = &windows[i];
+		CFNumberRef num;
+		if ((num = CFDictionaryGetValue(d, kCGWindowNumber)) != NULL) {
+			CFNumberGetValue(num, kCFNumberSInt32Type, &w->id);
+		}
+		if ((num = CFDictionaryGetValue(d, kCGWindowOwnerPID)) != NULL) {
+			CFNumberGetValue(num, kCFNumberSInt32Type, &w->pid);
+		}
+		if ((num = CFDictionaryGetValue(d, kCGWindowLayer)) != NULL) {
+			CFNumberGetValue(num, kCFNumberSInt32Type, &w->layer);
+		}
+		CFBooleanRef onscreen = CFDictionaryGetValue(d, kCGWindowIsOnscreen);
+		w->onscreen = onscreen != NULL && CFBooleanGetValue(onscreen);
+		CFDictionaryRef bounds = CFDictionaryGetValue(d, kCGWindowBounds);
+		CGRect r;
+		if (bounds != NULL && CGRectMakeWithDictionaryRepresentation(bounds, &r)) {
+			w->x = r.origin.x;
+			w->y = r.origin.y;
+			w->width = r.size.width;
+			w->height = r.size.height;
+		}
+		w->title = qzCopyString(CFDictionaryGetValue(d, kCGWindowName));
+		w->owner = qzCopyString(CFDictionaryGetValue(d, kCGWindowOwnerName));
+	}
+	CFRelease(list);
+	*out = windows;
+	return (int)n;
+}
+
+static void qzFreeWindows(qzWindow *windows, int n) {
+	for (int i = 0; i < n; i++) {
+		free(windows[i].title);
+		free(windows[i].owner);
+	}
+	free(windows);
+}
+
+// qzDraw renders an image into a malloc'd BGRA buffer and releases it
+static uint8_t *qzDraw(CGImageRef image, int *width, int *height) {
+	if (image == NULL) {
+		return NULL;
+	}
+	size_t w = CGImageGetWidth(image), h = CGImageGetHeight(image);
+	if (w == 0 || h == 0) {
+		CGImageRelease(image);
+		return NULL;
+	}
+	uint8_t *data = calloc(w * h * 4, 1);
+	CGColorSpaceRef space = CGColorSpaceCreateDeviceRGB();
+	CGContextRef ctx = CGBitmapContextCreate(data, w, h, 8, w * 4, space,
+		kCGImageAlphaPremultipliedFirst | kCGBitmapByteOrder32Little);
+	CGContextDrawImage(ctx, CGRectMake(0, 0, w, h), image);
+	CGContextRelease(ctx);
+	CGColorSpaceRelease(space);
+	CGImageRelease(image);
+	*width = (int)w;
+	*height = (int)h;
+	return data;
+}
+
+// qzCaptureWindow captures a window at one pixel per point, without its shadow
+static uint8_t *qzCaptureWindow(uint32_t id, int *width, int *height) {
+	CGImageRef image = CGWindowListCreateImage(CGRectNull, kCGWindowListOptionIncludingWindow, id,
+		kCGWindowImageBoundsIgnoreFraming | kCGWindowImageNominalResolution);
+	return qzDraw(image, width, height);
+}
+
+// qzCaptureRect captures the on-screen windows within a rectangle of the global display space
+static uint8_t *qzCaptureRect(double x, double y, double w, double h, int *width, int *height) {
+	CGImageRef image = CGWindowListCreateImage(CGRectMake(x, y, w, h), kCGWindowListOptionOnScreenOnly,
+		kCGNullWindowID, kCGWindowImageNominalResolution);
+	return qzDraw(image, width, height);
+}
+
+static void qzCursor(double *x, double *y) {
+	CGEventRef event = CGEventCreate(NULL);
+	CGPoint p = CGEventGetLocation(event);
+	CFRelease(event);
+	*x = p.x;
+	*y = p.y;
+}
+
+static double qzIdleSeconds(void) {
+	return CGEventSourceSecondsSinceLastEventType(kCGEventSourceStateCombinedSessionState, kCGAnyInputEventType);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+)
+
+// maxDisplays bounds the displays Displays reports
+const maxDisplays = 16
+
+// Window describes a window from CGWindowListCopyWindowInfo. Coordinates are in points,
+// with the origin at the top left of the main display.
+type Window struct {
+	ID       uint32
+	PID      uint32
+	Layer    int // 0 for normal application windows
+	OnScreen bool
+	X, Y     int
+	Width    int
+	Height   int
+	Title    string
+	Owner    string // Name of the owning application
+}
+
+// Display describes an active display
+type Display struct {
+	ID     uint32
+	Main   bool
+	X, Y   int
+	Width  int
+	Height int
+}
+
+// Windows lists every window except desktop elements, frontmost first
+func Windows() ([]Window, error) {
+	var list *C.qzWindow
+	n := int(C.qzListWindows(&list))
+	if n < 0 {
+		return nil, errors.New("CGWindowListCopyWindowInfo failed")
+	}
+	defer C.qzFreeWindows(list, C.int(n))
+
+	windows := make([]Window, n)
+	for i, w := range unsafe.Slice(list, n) {
+		windows[i] = Window{
+			ID:       uint32(w.id),
+			PID:      uint32(w.pid),
+			Layer:    int(w.layer),
+			OnScreen: w.onscreen != 0,
+			X:        int(w.x),
+			Y:        int(w.y),
+			Width:    int(w.width),
+			Height:   int(w.height),
+		}
+		if w.title != nil {
+			windows[i].Title = C.GoString(w.title)
+		}
+		if w.owner != nil {
+			windows[i].Owner = C.GoString(w.owner)
+		}
+	}
+	return windows, nil
+}
+
+// CaptureWindow captures a window as BGRA pixels
+func CaptureWindow(id uint32) (int, int, []byte, error) {
+	var width, height C.int
+	data := C.qzCaptureWindow(C.uint32_t(id), &width, &height)
+	return copyImage(data, width, height)
+}
+
+// CaptureRect captures what is on screen within a rectangle of the global display space
+func CaptureRect(x, y, width, height int) (int, int, []byte, error) {
+	var w, h C.int
+	data := C.qzCaptureRect(C.double(x), C.double(y), C.double(width), C.double(height), &w, &h)
+	return copyImage(data, w, h)
+}
+
+// copyImage moves a buffer from qzDraw into Go memory
+func copyImage(data *C.uint8_t, width, height C.int) (int, int, []byte, error) {
+	if data == nil {
+		return 0, 0, nil, errors.New("CGWindowListCreateImage returned no image")
+	}
+	defer C.free(unsafe.Pointer(data))
+	pixels := C.GoBytes(unsafe.Pointer(data), width*height*4)
+	return int(width), int(height), pixels, nil
+}
+
+// Displays lists the active displays, the main display first
+func Displays() ([]Display, error) {
+	var ids [maxDisplays]C.CGDirectDisplayID
+	var count C.uint32_t
+	if err := C.CGGetActiveDisplayList(maxDisplays, &ids[0], &count); err != C.kCGErrorSuccess {
+		return nil, errors.New("CGGetActiveDisplayList failed")
+	}
+
+	mainID := C.CGMainDisplayID()
+	displays := make([]Display, 0, int(count))
+	for _, id := range ids[:count] {
+		bounds := C.CGDisplayBounds(id)
+		display := Display{
+			ID:     uint32(id),
+			Main:   id == mainID,
+			X:      int(bounds.origin.x),
+			Y:      int(bounds.origin.y),
+			Width:  int(bounds.size.width),
+			Height: int(bounds.size.height),
+		}
+		if display.Main {
+			displays = append([]Display{display}, displays...)
+		} else {
+			displays = append(displays, display)
+		}
+	}
+	return displays, nil
+}
+
+// CursorPosition returns the mouse position in the global display space
+func CursorPosition() (int, int) {
+	var x, y C.double
+	C.qzCursor(&x, &y)
+	return int(x), int(y)
+}
+
+// IdleTime returns the time since the last keyboard or mouse input
+func IdleTime() time.Duration {
+	return time.Duration(float64(C.qzIdleSeconds()) * float64(time.Second))
+}
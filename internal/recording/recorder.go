@@ -0,0 +1,670 @@
+package recording
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/activity"
+	"github.com/screenshot-mcp-server/internal/crash"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Recording status values
+const (
+	StatusRecording  = "recording"
+	StatusAssembling = "assembling"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Artifact layout inside a recording directory
+const (
+	framesDirName    = "frames"
+	timelineFileName = "timeline.jsonl"
+	manifestFileName = "manifest.json"
+)
+
+// Recorder manages recordings that persist frames and a metadata timeline to disk
+type Recorder struct {
+	recordings    map[string]*Recording
+	recordingsMux sync.RWMutex
+	engine        types.ScreenshotEngine
+	windows       types.WindowManager
+	processor     types.ImageProcessor
+	baseDir       string
+	logger        *zap.Logger
+	watermark     *screenshot.Pipeline // Applied to every frame before encoding
+	crashes       *crash.Reporter      // Records panics of recording goroutines
+	activity      *activity.Monitor    // Pauses recordings depending on the user's activity
+}
+
+// Recording represents an active or finished recording
+type Recording struct {
+	info     types.RecordingInfo
+	options  *types.RecordingOptions
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	timeline *bufio.Writer
+	file     *os.File
+	mutex    sync.RWMutex
+
+	// Last observed desktop state, used to emit change events
+	lastForeground uintptr
+	lastWindow     *types.WindowInfo
+	lastCursor     *types.Point
+	lastInput      time.Time
+
+	// Timelapse spooling state
+	stride  int64
+	samples int64
+	spool   []spooledFrame
+
+	// Stream frames written so far, for assembling them at their real pace
+	teed []teedFrame
+}
+
+// NewRecorder creates a recorder storing artifacts under baseDir
+func NewRecorder(engine types.ScreenshotEngine, windows types.WindowManager, baseDir string, logger *zap.Logger) *Recorder {
+	return &Recorder{
+		recordings: make(map[string]*Recording),
+		engine:     engine,
+		windows:    windows,
+		processor:  screenshot.NewImageProcessor(),
+		baseDir:    baseDir,
+		logger:     logger,
+	}
+}
+
+// SetWatermark sets a pipeline applied to every recorded frame
+func (r *Recorder) SetWatermark(watermark *screenshot.Pipeline) {
+	r.watermark = watermark
+}
+
+// SetCrashReporter sets the reporter that records panics of recording goroutines
+func (r *Recorder) SetCrashReporter(crashes *crash.Reporter) {
+	r.crashes = crashes
+}
+
+// SetActivityMonitor pauses recordings while the monitor's gate holds
+func (r *Recorder) SetActivityMonitor(monitor *activity.Monitor) {
+	r.activity = monitor
+}
+
+// Start begins a new recording
+func (r *Recorder) Start(options *types.RecordingOptions) (*types.RecordingInfo, error) {
+	if options == nil {
+		options = types.DefaultRecordingOptions()
+	}
+	if options.Mode == "" {
+		options.Mode = types.RecordingSession
+	}
+	if options.Mode != types.RecordingSession && options.Mode != types.RecordingTimelapse {
+		return nil, fmt.Errorf("unsupported recording mode: %s", options.Mode)
+	}
+	if options.FPS <= 0 || options.FPS > 30 {
+		return nil, fmt.Errorf("fps must be between 0 and 30")
+	}
+	if options.Mode == types.RecordingTimelapse {
+		if err := validateTimelapseOptions(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.Format == "" {
+		options.Format = types.FormatJPEG
+	}
+
+	recording, err := r.create(options)
+	if err != nil {
+		return nil, err
+	}
+
+	go r.run(recording)
+
+	r.logger.Info("Recording started",
+		zap.String("recording_id", recording.info.ID),
+		zap.String("mode", string(options.Mode)),
+		zap.Uintptr("window_id", options.WindowID),
+		zap.Float64("fps", options.FPS),
+	)
+
+	return recording.Info(), nil
+}
+
+// create sets up a recording's directory and timeline and registers it
+func (r *Recorder) create(options *types.RecordingOptions) (*Recording, error) {
+	id := fmt.Sprintf("rec_%d_%d", options.WindowID, time.Now().UnixNano())
+	dir := filepath.Join(r.baseDir, id)
+
+	if err := os.MkdirAll(filepath.Join(dir, framesDirName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, timelineFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create timeline: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if options.MaxDuration > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), options.MaxDuration)
+	}
+
+	recording := &Recording{
+		info: types.RecordingInfo{
+			ID:        id,
+			Mode:      options.Mode,
+			Status:    StatusRecording,
+			WindowID:  options.WindowID,
+			Options:   options,
+			Directory: dir,
+			StartTime: time.Now(),
+		},
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		timeline: bufio.NewWriter(file),
+		file:     file,
+		stride:   1,
+	}
+	if options.Mode == types.RecordingTimelapse {
+		recording.info.Decimation = 1
+	}
+
+	r.recordingsMux.Lock()
+	r.recordings[id] = recording
+	r.recordingsMux.Unlock()
+
+	return recording, nil
+}
+
+// Stop stops a recording and waits for its artifact to be finalized
+func (r *Recorder) Stop(id string) (*types.RecordingInfo, error) {
+	recording, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	recording.cancel()
+	<-recording.done
+
+	return recording.Info(), nil
+}
+
+// Get returns information about a recording
+func (r *Recorder) Get(id string) (*types.RecordingInfo, error) {
+	recording, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return recording.Info(), nil
+}
+
+// List returns all known recordings, newest first
+func (r *Recorder) List() []*types.RecordingInfo {
+	r.recordingsMux.RLock()
+	defer r.recordingsMux.RUnlock()
+
+	infos := make([]*types.RecordingInfo, 0, len(r.recordings))
+	for _, recording := range r.recordings {
+		infos = append(infos, recording.Info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.After(infos[j].StartTime)
+	})
+
+	return infos
+}
+
+// Timeline reads the recorded timeline events
+func (r *Recorder) Timeline(id string) ([]types.TimelineEvent, error) {
+	recording, err := r.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flush buffered events so in-progress recordings return an up-to-date timeline
+	recording.mutex.Lock()
+	if recording.info.EndTime == nil {
+		recording.timeline.Flush()
+	}
+	recording.mutex.Unlock()
+
+	file, err := os.Open(filepath.Join(recording.info.Directory, timelineFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open timeline: %w", err)
+	}
+	defer file.Close()
+
+	var events []types.TimelineEvent
+	decoder := json.NewDecoder(file)
+	for {
+		var event types.TimelineEvent
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode timeline: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// FramePath returns the on-disk path of a recorded frame
+func (r *Recorder) FramePath(id string, frame int64) (string, error) {
+	recording, err := r.get(id)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(recording.info.Directory, framesDirName, frameFileName(frame, recording.options.Format))
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("frame %d not found", frame)
+	}
+
+	return path, nil
+}
+
+// WriteArchive writes a completed recording directory as a ZIP archive
+func (r *Recorder) WriteArchive(id string, w io.Writer) error {
+	recording, err := r.get(id)
+	if err != nil {
+		return err
+	}
+
+	if recording.Info().EndTime == nil {
+		return fmt.Errorf("recording %s is still in progress", id)
+	}
+
+	archive := zip.NewWriter(w)
+	root := recording.info.Directory
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		entry, err := archive.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(entry, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive recording: %w", err)
+	}
+
+	return archive.Close()
+}
+
+// Cleanup stops all active recordings
+func (r *Recorder) Cleanup() {
+	r.recordingsMux.RLock()
+	active := make([]*Recording, 0, len(r.recordings))
+	for _, recording := range r.recordings {
+		active = append(active, recording)
+	}
+	r.recordingsMux.RUnlock()
+
+	for _, recording := range active {
+		recording.cancel()
+		<-recording.done
+	}
+}
+
+// Info returns a snapshot of the recording's information
+func (rec *Recording) Info() *types.RecordingInfo {
+	rec.mutex.RLock()
+	defer rec.mutex.RUnlock()
+
+	info := rec.info
+	return &info
+}
+
+func (r *Recorder) get(id string) (*Recording, error) {
+	r.recordingsMux.RLock()
+	defer r.recordingsMux.RUnlock()
+
+	recording, exists := r.recordings[id]
+	if !exists {
+		return nil, fmt.Errorf("recording not found: %s", id)
+	}
+	return recording, nil
+}
+
+// run captures frames at the configured rate until the recording is stopped
+func (r *Recorder) run(rec *Recording) {
+	defer close(rec.done)
+	defer func() {
+		if p := recover(); p != nil {
+			r.logger.Error("Recording goroutine panicked",
+				zap.String("recording_id", rec.info.ID),
+				zap.Any("error", p),
+			)
+			r.crashes.Capture("recording", p, map[string]string{
+				"recording_id": rec.info.ID,
+				"window_id":    fmt.Sprintf("%d", rec.info.WindowID),
+			})
+			r.finish(rec, fmt.Errorf("recording panicked: %v", p))
+		}
+	}()
+
+	captureOptions := types.DefaultCaptureOptions()
+	captureOptions.AllowMinimized = true
+	captureOptions.RestoreWindow = false
+	captureOptions.Priority = types.PriorityScheduled
+	captureOptions.Context = rec.ctx
+
+	interval := time.Duration(float64(time.Second) / rec.options.FPS)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	capture := r.captureFrame
+	if rec.options.Mode == types.RecordingTimelapse {
+		capture = r.captureTimelapseFrame
+	}
+
+	// Frames that fall due while the user's activity pauses recording are skipped, with
+	// a timeline event on each change
+	gated := false
+	tick := func() {
+		if paused := r.activity.Paused(); paused != gated {
+			gated = paused
+			event := types.TimelineEvent{Type: "capture_resumed"}
+			if paused {
+				event.Type = "capture_paused"
+			}
+			r.recordEvent(rec, event)
+		}
+		if !gated {
+			capture(rec, captureOptions)
+		}
+	}
+
+	r.recordEvent(rec, types.TimelineEvent{Type: "recording_started"})
+	tick()
+
+	for {
+		select {
+		case <-rec.ctx.Done():
+			r.finish(rec, nil)
+			return
+		case <-ticker.C:
+			tick()
+		}
+	}
+}
+
+// captureFrame samples desktop state, then captures and stores one frame
+func (r *Recorder) captureFrame(rec *Recording, captureOptions *types.CaptureOptions) {
+	r.sampleDesktopState(rec)
+
+	buffer, encoded, err := r.grabFrame(rec, captureOptions)
+	if err != nil {
+		// A capture cut short by the recording stopping isn't a failure
+		if rec.ctx.Err() == nil {
+			r.recordEvent(rec, types.TimelineEvent{
+				Type: "capture_failed",
+				Data: map[string]interface{}{"error": err.Error()},
+			})
+		}
+		return
+	}
+
+	rec.mutex.Lock()
+	rec.info.FrameCount++
+	frame := rec.info.FrameCount
+	rec.mutex.Unlock()
+
+	name := frameFileName(frame, rec.options.Format)
+	if err := os.WriteFile(filepath.Join(rec.info.Directory, framesDirName, name), encoded, 0644); err != nil {
+		r.logger.Warn("Failed to write recording frame",
+			zap.String("recording_id", rec.info.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	rec.mutex.Lock()
+	rec.info.BytesWritten += int64(len(encoded))
+	rec.mutex.Unlock()
+
+	r.recordEvent(rec, types.TimelineEvent{
+		Type:  "frame",
+		Frame: frame,
+		Data: map[string]interface{}{
+			"file":   filepath.ToSlash(filepath.Join(framesDirName, name)),
+			"width":  buffer.Width,
+			"height": buffer.Height,
+			"size":   len(encoded),
+		},
+	})
+}
+
+// grabFrame captures the recording target, downscales it, and encodes it
+func (r *Recorder) grabFrame(rec *Recording, captureOptions *types.CaptureOptions) (*types.ScreenshotBuffer, []byte, error) {
+	var buffer *types.ScreenshotBuffer
+	var err error
+	if rec.options.WindowID == 0 {
+		buffer, err = r.engine.CaptureFullScreen(0, captureOptions)
+	} else {
+		buffer, err = r.engine.CaptureByHandle(rec.options.WindowID, captureOptions)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rec.options.MaxWidth > 0 && buffer.Width > rec.options.MaxWidth {
+		height := buffer.Height * rec.options.MaxWidth / buffer.Width
+		if resized, err := r.processor.Resize(buffer, rec.options.MaxWidth, height); err == nil {
+			buffer = resized
+		}
+	}
+	if r.watermark != nil {
+		if buffer, err = r.watermark.Process(buffer); err != nil {
+			return nil, nil, fmt.Errorf("failed to watermark frame: %w", err)
+		}
+	}
+	if rec.options.Overlay {
+		if buffer, err = r.drawInfoStrip(rec, buffer); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	encoded, err := r.processor.Encode(buffer, rec.options.Format, rec.options.Quality)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buffer, encoded, nil
+}
+
+// drawInfoStrip burns when a frame was captured, the window's title, the recording and
+// the frame rate it has averaged into the bottom of a frame of a recording with Overlay
+func (r *Recorder) drawInfoStrip(rec *Recording, buffer *types.ScreenshotBuffer) (*types.ScreenshotBuffer, error) {
+	rec.mutex.RLock()
+	frames, elapsed := rec.info.FrameCount, time.Since(rec.info.StartTime)
+	rec.mutex.RUnlock()
+
+	fields := []string{buffer.Timestamp.Format("2006-01-02 15:04:05.000")}
+	if title := buffer.WindowInfo.Title; title != "" {
+		fields = append(fields, title)
+	}
+	fields = append(fields, rec.info.ID)
+	if elapsed > 0 {
+		fields = append(fields, fmt.Sprintf("%.1f fps", float64(frames)/elapsed.Seconds()))
+	}
+
+	drawn, err := screenshot.InfoStrip(buffer, fields...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw overlay: %w", err)
+	}
+	return drawn, nil
+}
+
+// sampleDesktopState emits timeline events for foreground, window, and input changes
+func (r *Recorder) sampleDesktopState(rec *Recording) {
+	if r.windows == nil {
+		return
+	}
+
+	if foreground, err := r.windows.GetForegroundWindow(); err == nil && foreground != rec.lastForeground {
+		rec.lastForeground = foreground
+		event := types.TimelineEvent{Type: "foreground_changed"}
+		if info, err := r.windows.GetWindowInfo(foreground); err == nil {
+			event.Window = info
+		}
+		r.recordEvent(rec, event)
+	}
+
+	if rec.options.WindowID != 0 {
+		if info, err := r.windows.GetWindowInfo(rec.options.WindowID); err == nil && windowChanged(rec.lastWindow, info) {
+			rec.lastWindow = info
+			r.recordEvent(rec, types.TimelineEvent{Type: "window_changed", Window: info})
+		}
+	}
+
+	if !rec.options.IncludeInput {
+		return
+	}
+
+	if cursor, err := r.windows.GetCursorPosition(); err == nil && (rec.lastCursor == nil || *rec.lastCursor != cursor) {
+		rec.lastCursor = &cursor
+		r.recordEvent(rec, types.TimelineEvent{Type: "cursor_moved", Cursor: &cursor})
+	}
+
+	if lastInput, err := r.windows.GetLastInputTime(); err == nil && lastInput.Sub(rec.lastInput) > time.Millisecond {
+		rec.lastInput = lastInput
+		r.recordEvent(rec, types.TimelineEvent{
+			Type: "input_activity",
+			Data: map[string]interface{}{"last_input": lastInput},
+		})
+	}
+}
+
+// recordEvent appends an event to the recording's timeline file
+func (r *Recorder) recordEvent(rec *Recording, event types.TimelineEvent) {
+	event.Timestamp = time.Now()
+
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	event.Offset = event.Timestamp.Sub(rec.info.StartTime)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	rec.timeline.Write(data)
+	rec.timeline.WriteByte('\n')
+	rec.info.EventCount++
+}
+
+// finish flushes the timeline and writes the recording manifest
+func (r *Recorder) finish(rec *Recording, failure error) {
+	r.recordEvent(rec, types.TimelineEvent{Type: "recording_stopped"})
+
+	var assemble func(*Recording) (string, error)
+	switch {
+	case rec.options.Mode == types.RecordingTimelapse:
+		assemble = r.assembleTimelapse
+	case rec.options.Mode == types.RecordingStream && rec.options.Output == "mp4":
+		assemble = r.assembleStream
+	}
+	if failure == nil && assemble != nil {
+		rec.mutex.Lock()
+		rec.info.Status = StatusAssembling
+		rec.mutex.Unlock()
+
+		output, err := assemble(rec)
+		if err != nil {
+			failure = fmt.Errorf("failed to assemble %s: %w", rec.options.Mode, err)
+		} else {
+			rec.mutex.Lock()
+			rec.info.Output = output
+			rec.mutex.Unlock()
+		}
+	}
+
+	rec.mutex.Lock()
+	now := time.Now()
+	rec.info.EndTime = &now
+	rec.info.Status = StatusCompleted
+	if failure != nil {
+		rec.info.Status = StatusFailed
+		rec.info.Error = failure.Error()
+	}
+	rec.timeline.Flush()
+	rec.file.Close()
+	info := rec.info
+	rec.mutex.Unlock()
+
+	manifest, err := json.MarshalIndent(info, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(info.Directory, manifestFileName), manifest, 0644)
+	}
+	if err != nil {
+		r.logger.Warn("Failed to write recording manifest",
+			zap.String("recording_id", info.ID),
+			zap.Error(err),
+		)
+	}
+
+	r.logger.Info("Recording stopped",
+		zap.String("recording_id", info.ID),
+		zap.String("status", info.Status),
+		zap.Int64("frames", info.FrameCount),
+		zap.Int64("events", info.EventCount),
+	)
+}
+
+// windowChanged reports whether a window moved, resized, or changed title or state
+func windowChanged(previous, current *types.WindowInfo) bool {
+	if previous == nil {
+		return true
+	}
+	return previous.Rect != current.Rect ||
+		previous.Title != current.Title ||
+		previous.State != current.State
+}
+
+// frameFileName returns the zero-padded file name of a frame
+func frameFileName(frame int64, format types.ImageFormat) string {
+	ext := "png"
+	switch format {
+	case types.FormatJPEG:
+		ext = "jpg"
+	case types.FormatBMP:
+		ext = "bmp"
+	}
+	return fmt.Sprintf("%06d.%s", frame, ext)
+}
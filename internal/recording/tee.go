@@ -0,0 +1,158 @@
+package recording
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// teedFrame is a stream frame stored on disk
+type teedFrame struct {
+	frame      int64
+	capturedAt time.Time
+}
+
+// StartStream begins a recording fed by a live stream session through WriteFrame
+// instead of capturing on its own, so it holds exactly the frames the client received
+func (r *Recorder) StartStream(sessionID string, options *types.RecordingOptions) (*types.RecordingInfo, error) {
+	options.Mode = types.RecordingStream
+	switch options.Output {
+	case "":
+		options.Output = "frames"
+	case "frames":
+	case "mp4":
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			return nil, fmt.Errorf("mp4 output requires ffmpeg on PATH")
+		}
+	default:
+		return nil, fmt.Errorf("record_output must be 'frames' or 'mp4'")
+	}
+
+	recording, err := r.create(options)
+	if err != nil {
+		return nil, err
+	}
+	recording.info.SessionID = sessionID
+
+	go r.tee(recording)
+
+	r.logger.Info("Recording started",
+		zap.String("recording_id", recording.info.ID),
+		zap.String("mode", string(options.Mode)),
+		zap.String("session_id", sessionID),
+		zap.Uintptr("window_id", options.WindowID),
+	)
+
+	return recording.Info(), nil
+}
+
+// tee waits for a stream recording to be stopped and finalizes it
+func (r *Recorder) tee(rec *Recording) {
+	defer close(rec.done)
+
+	r.recordEvent(rec, types.TimelineEvent{
+		Type: "recording_started",
+		Data: map[string]interface{}{"session_id": rec.info.SessionID},
+	})
+	<-rec.ctx.Done()
+	r.finish(rec, nil)
+}
+
+// WriteFrame stores an encoded frame a stream delivered. streamFrame is the frame
+// number the client saw and capturedAt when the frame was captured.
+func (r *Recorder) WriteFrame(id string, encoded []byte, width, height int, streamFrame int64, capturedAt time.Time) error {
+	rec, err := r.get(id)
+	if err != nil {
+		return err
+	}
+
+	rec.mutex.Lock()
+	if rec.info.EndTime != nil || rec.ctx.Err() != nil {
+		rec.mutex.Unlock()
+		return fmt.Errorf("recording %s has stopped", id)
+	}
+	rec.info.FrameCount++
+	frame := rec.info.FrameCount
+	rec.mutex.Unlock()
+
+	name := frameFileName(frame, rec.options.Format)
+	if err := os.WriteFile(filepath.Join(rec.info.Directory, framesDirName, name), encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+
+	rec.mutex.Lock()
+	rec.info.BytesWritten += int64(len(encoded))
+	rec.teed = append(rec.teed, teedFrame{frame: frame, capturedAt: capturedAt})
+	rec.mutex.Unlock()
+
+	r.recordEvent(rec, types.TimelineEvent{
+		Type:  "frame",
+		Frame: frame,
+		Data: map[string]interface{}{
+			"file":         filepath.ToSlash(filepath.Join(framesDirName, name)),
+			"width":        width,
+			"height":       height,
+			"size":         len(encoded),
+			"stream_frame": streamFrame,
+			"captured_at":  capturedAt,
+		},
+	})
+	return nil
+}
+
+// assembleStream encodes the stream's frames as H.264 MP4, each shown until the next
+// one was captured so the video plays back at the pace the client received it
+func (r *Recorder) assembleStream(rec *Recording) (string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg not found on PATH")
+	}
+	if len(rec.teed) == 0 {
+		return "", fmt.Errorf("no frames were recorded")
+	}
+
+	// The last frame is shown for one frame interval at the stream's rate
+	last := time.Second / 30
+	if rec.options.FPS > 0 {
+		last = time.Duration(float64(time.Second) / rec.options.FPS)
+	}
+
+	var list bytes.Buffer
+	for i, frame := range rec.teed {
+		duration := last
+		if i+1 < len(rec.teed) {
+			duration = rec.teed[i+1].capturedAt.Sub(frame.capturedAt)
+		}
+		fmt.Fprintf(&list, "file '%s/%s'\nduration %f\n", framesDirName, frameFileName(frame.frame, rec.options.Format), duration.Seconds())
+	}
+	// The demuxer ignores the duration of the final entry unless it is repeated
+	final := rec.teed[len(rec.teed)-1]
+	fmt.Fprintf(&list, "file '%s/%s'\n", framesDirName, frameFileName(final.frame, rec.options.Format))
+
+	listPath := filepath.Join(rec.info.Directory, "frames.txt")
+	if err := os.WriteFile(listPath, list.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write frame list: %w", err)
+	}
+	defer os.Remove(listPath)
+
+	output := filepath.Join(rec.info.Directory, "stream.mp4")
+	cmd := exec.Command(ffmpeg,
+		"-y", "-loglevel", "error",
+		"-f", "concat", "-safe", "0", "-i", listPath,
+		"-vsync", "vfr",
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+		"-c:v", "libx264", "-pix_fmt", "yuv420p",
+		output,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed: %v: %s", err, bytes.TrimSpace(out))
+	}
+
+	return output, nil
+}
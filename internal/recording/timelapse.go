@@ -0,0 +1,227 @@
+package recording
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// GIF assembly limits keep the in-memory frame set bounded for long timelapses
+const (
+	gifMaxWidth  = 640
+	gifMaxFrames = 600
+)
+
+// spooledFrame is a timelapse frame stored on disk awaiting assembly
+type spooledFrame struct {
+	sample int64
+	size   int64
+}
+
+// validateTimelapseOptions checks the options specific to timelapse recordings
+func validateTimelapseOptions(options *types.RecordingOptions) error {
+	if options.PlaybackFPS <= 0 || options.PlaybackFPS > 60 {
+		return fmt.Errorf("playback_fps must be between 0 and 60")
+	}
+	if options.MaxSize < 0 {
+		return fmt.Errorf("max_size cannot be negative")
+	}
+	if options.Output != "gif" && options.Output != "mp4" {
+		return fmt.Errorf("output must be 'gif' or 'mp4'")
+	}
+	if options.Output == "mp4" {
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			return fmt.Errorf("mp4 output requires ffmpeg on PATH")
+		}
+	}
+	return nil
+}
+
+// captureTimelapseFrame spools one frame to disk, skipping samples dropped by decimation
+func (r *Recorder) captureTimelapseFrame(rec *Recording, captureOptions *types.CaptureOptions) {
+	rec.samples++
+	sample := rec.samples
+
+	// Only every stride-th sample is kept once the spool has been decimated
+	if (sample-1)%rec.stride != 0 {
+		return
+	}
+
+	_, encoded, err := r.grabFrame(rec, captureOptions)
+	if err != nil {
+		// A capture cut short by the recording stopping isn't a failure
+		if rec.ctx.Err() == nil {
+			r.recordEvent(rec, types.TimelineEvent{
+				Type: "capture_failed",
+				Data: map[string]interface{}{"error": err.Error()},
+			})
+		}
+		return
+	}
+
+	name := frameFileName(sample, rec.options.Format)
+	if err := os.WriteFile(filepath.Join(rec.info.Directory, framesDirName, name), encoded, 0644); err != nil {
+		r.logger.Warn("Failed to write timelapse frame",
+			zap.String("recording_id", rec.info.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	rec.spool = append(rec.spool, spooledFrame{sample: sample, size: int64(len(encoded))})
+
+	rec.mutex.Lock()
+	rec.info.FrameCount = int64(len(rec.spool))
+	rec.info.BytesWritten += int64(len(encoded))
+	overLimit := rec.options.MaxSize > 0 && rec.info.BytesWritten > rec.options.MaxSize
+	rec.mutex.Unlock()
+
+	if overLimit && len(rec.spool) > 1 {
+		r.decimate(rec)
+	}
+}
+
+// decimate drops every other spooled frame and halves the effective capture rate
+func (r *Recorder) decimate(rec *Recording) {
+	kept := rec.spool[:0]
+	var bytesKept int64
+	for i, frame := range rec.spool {
+		if i%2 == 0 {
+			kept = append(kept, frame)
+			bytesKept += frame.size
+			continue
+		}
+		os.Remove(filepath.Join(rec.info.Directory, framesDirName, frameFileName(frame.sample, rec.options.Format)))
+	}
+	rec.spool = kept
+	rec.stride *= 2
+
+	rec.mutex.Lock()
+	rec.info.FrameCount = int64(len(rec.spool))
+	rec.info.BytesWritten = bytesKept
+	rec.info.Decimation = rec.stride
+	rec.mutex.Unlock()
+
+	r.recordEvent(rec, types.TimelineEvent{
+		Type: "frames_decimated",
+		Data: map[string]interface{}{
+			"decimation": rec.stride,
+			"frames":     len(rec.spool),
+			"bytes":      bytesKept,
+		},
+	})
+}
+
+// assembleTimelapse renders the spooled frames into the configured output and returns its path
+func (r *Recorder) assembleTimelapse(rec *Recording) (string, error) {
+	if len(rec.spool) == 0 {
+		return "", fmt.Errorf("no frames were captured")
+	}
+
+	if rec.options.Output == "mp4" {
+		return r.assembleVideo(rec)
+	}
+	return r.assembleGIF(rec)
+}
+
+// assembleGIF encodes the spooled frames as an animated GIF
+func (r *Recorder) assembleGIF(rec *Recording) (string, error) {
+	step := (len(rec.spool) + gifMaxFrames - 1) / gifMaxFrames
+
+	// GIF delays are in hundredths of a second; most viewers clamp anything below 2
+	delay := int(math.Round(100 / rec.options.PlaybackFPS))
+	if delay < 2 {
+		delay = 2
+	}
+
+	animation := &gif.GIF{}
+	for i := 0; i < len(rec.spool); i += step {
+		path := filepath.Join(rec.info.Directory, framesDirName, frameFileName(rec.spool[i].sample, rec.options.Format))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read frame: %w", err)
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode frame: %w", err)
+		}
+
+		if img.Bounds().Dx() > gifMaxWidth {
+			img = imaging.Resize(img, gifMaxWidth, 0, imaging.Box)
+		}
+
+		bounds := img.Bounds()
+		paletted := image.NewPaletted(bounds, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, delay)
+	}
+
+	output := filepath.Join(rec.info.Directory, "timelapse.gif")
+	file, err := os.Create(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output: %w", err)
+	}
+	defer file.Close()
+
+	if err := gif.EncodeAll(file, animation); err != nil {
+		return "", fmt.Errorf("failed to encode GIF: %w", err)
+	}
+
+	return output, nil
+}
+
+// assembleVideo encodes the spooled frames as H.264 MP4 using ffmpeg's concat demuxer
+func (r *Recorder) assembleVideo(rec *Recording) (string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg not found on PATH")
+	}
+
+	// The concat list gives every frame the same duration at the playback rate
+	var list bytes.Buffer
+	duration := 1 / rec.options.PlaybackFPS
+	for _, frame := range rec.spool {
+		fmt.Fprintf(&list, "file '%s/%s'\nduration %f\n", framesDirName, frameFileName(frame.sample, rec.options.Format), duration)
+	}
+	// The demuxer ignores the duration of the final entry unless it is repeated
+	last := rec.spool[len(rec.spool)-1]
+	fmt.Fprintf(&list, "file '%s/%s'\n", framesDirName, frameFileName(last.sample, rec.options.Format))
+
+	listPath := filepath.Join(rec.info.Directory, "frames.txt")
+	if err := os.WriteFile(listPath, list.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write frame list: %w", err)
+	}
+	defer os.Remove(listPath)
+
+	output := filepath.Join(rec.info.Directory, "timelapse.mp4")
+	cmd := exec.Command(ffmpeg,
+		"-y", "-loglevel", "error",
+		"-f", "concat", "-safe", "0", "-i", listPath,
+		"-r", strconv.FormatFloat(rec.options.PlaybackFPS, 'f', -1, 64),
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",
+		"-c:v", "libx264", "-pix_fmt", "yuv420p",
+		output,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed: %v: %s", err, bytes.TrimSpace(out))
+	}
+
+	return output, nil
+}
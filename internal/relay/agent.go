@@ -0,0 +1,290 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Reconnect backoff of agents
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Agent is the capture machine's side of the relay: it keeps a connection to the
+// central server open and serves the requests and streams forwarded over it
+type Agent struct {
+	endpoint string
+	token    string
+	info     types.AgentInfo
+	handler  http.Handler
+	logger   *zap.Logger
+
+	listener   *memListener
+	httpClient *http.Client
+	dialer     *websocket.Dialer
+
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	streams map[string]*websocket.Conn // Local stream connections by relay stream ID
+	mutex   sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewAgent creates an agent that connects to the server at serverURL (http, https, ws
+// or wss) and serves forwarded requests with handler
+func NewAgent(serverURL, token string, info types.AgentInfo, handler http.Handler, logger *zap.Logger) (*Agent, error) {
+	endpoint, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid relay URL: %w", err)
+	}
+	switch endpoint.Scheme {
+	case "http", "ws":
+		endpoint.Scheme = "ws"
+	case "https", "wss":
+		endpoint.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("invalid relay URL %q: scheme must be ws, wss, http or https", serverURL)
+	}
+	if strings.Trim(endpoint.Path, "/") == "" {
+		endpoint.Path = ConnectPath
+	}
+	if !agentIDPattern.MatchString(info.ID) {
+		return nil, fmt.Errorf("invalid agent ID %q: use 1-64 letters, digits, '.', '_' or '-'", info.ID)
+	}
+
+	listener := newMemListener()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Agent{
+		endpoint: endpoint.String(),
+		token:    token,
+		info:     info,
+		handler:  handler,
+		logger:   logger,
+		listener: listener,
+		httpClient: &http.Client{
+			Transport: &http.Transport{DialContext: listener.Dial},
+			// Redirects are the caller's to follow
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		dialer:  &websocket.Dialer{NetDialContext: listener.Dial, HandshakeTimeout: 10 * time.Second},
+		streams: make(map[string]*websocket.Conn),
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Start serves the handler in memory and connects to the central server, reconnecting
+// with backoff whenever the connection drops
+func (a *Agent) Start() {
+	go http.Serve(a.listener, a.handler)
+	go a.run()
+}
+
+// Close disconnects from the central server
+func (a *Agent) Close() {
+	a.cancel()
+	a.mutex.Lock()
+	if a.conn != nil {
+		a.conn.Close()
+	}
+	a.mutex.Unlock()
+	a.listener.Close()
+}
+
+// run keeps the agent connected until it is closed
+func (a *Agent) run() {
+	delay := minReconnectDelay
+	for a.ctx.Err() == nil {
+		connected, err := a.session()
+		if a.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+		a.logger.Warn("Relay connection lost, reconnecting",
+			zap.String("server", a.endpoint),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// session connects, registers and serves the connection until it fails. It reports
+// whether the agent was registered.
+func (a *Agent) session() (bool, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+a.token)
+	conn, resp, err := websocket.DefaultDialer.DialContext(a.ctx, a.endpoint, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return false, fmt.Errorf("%w: %s: %s", err, resp.Status, bytes.TrimSpace(body))
+		}
+		return false, err
+	}
+	defer a.closeStreams()
+	defer conn.Close()
+
+	a.mutex.Lock()
+	a.conn = conn
+	a.mutex.Unlock()
+
+	if err := a.send(&message{Type: msgRegister, Agent: &a.info}); err != nil {
+		return false, err
+	}
+	var reply message
+	if err := conn.ReadJSON(&reply); err != nil {
+		return false, err
+	}
+	if reply.Type != msgRegistered {
+		return false, fmt.Errorf("registration refused: %s", reply.Error)
+	}
+	a.logger.Info("Registered with relay server",
+		zap.String("server", a.endpoint),
+		zap.String("agent_id", a.info.ID),
+	)
+
+	// The server pings regularly; silence means the connection is dead
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(10*time.Second))
+	})
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return true, err
+		}
+		switch msg.Type {
+		case msgRequest:
+			go a.serveRequest(&msg)
+		case msgStreamOpen:
+			go a.openStream(&msg)
+		case msgStreamData:
+			a.mutex.Lock()
+			stream := a.streams[msg.ID]
+			a.mutex.Unlock()
+			if stream != nil {
+				stream.WriteMessage(websocket.TextMessage, msg.Data)
+			}
+		case msgStreamClose:
+			a.mutex.Lock()
+			stream := a.streams[msg.ID]
+			delete(a.streams, msg.ID)
+			a.mutex.Unlock()
+			if stream != nil {
+				stream.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				stream.Close()
+			}
+		}
+	}
+}
+
+// send writes a message to the central server
+func (a *Agent) send(msg *message) error {
+	a.mutex.Lock()
+	conn := a.conn
+	a.mutex.Unlock()
+
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+	return conn.WriteJSON(msg)
+}
+
+// serveRequest performs a forwarded request against the agent's handler
+func (a *Agent) serveRequest(request *message) {
+	response := &message{Type: msgResponse, ID: request.ID}
+
+	ctx, cancel := context.WithTimeout(a.ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, request.Method, "http://agent"+request.Path, bytes.NewReader(request.Body))
+	if err == nil {
+		req.Header = request.Header
+		var resp *http.Response
+		if resp, err = a.httpClient.Do(req); err == nil {
+			response.Status = resp.StatusCode
+			response.Header = resp.Header
+			response.Body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	if err := a.send(response); err != nil {
+		a.logger.Warn("Failed to return relayed response", zap.String("path", request.Path), zap.Error(err))
+	}
+}
+
+// openStream opens a forwarded stream against the agent's handler and relays its
+// messages until either side closes it
+func (a *Agent) openStream(open *message) {
+	conn, resp, err := a.dialer.DialContext(a.ctx, "ws://agent"+open.Path, open.Header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+		}
+		a.send(&message{Type: msgStreamClose, ID: open.ID, Error: err.Error()})
+		return
+	}
+
+	a.mutex.Lock()
+	a.streams[open.ID] = conn
+	a.mutex.Unlock()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			a.mutex.Lock()
+			_, tracked := a.streams[open.ID]
+			delete(a.streams, open.ID)
+			a.mutex.Unlock()
+			conn.Close()
+			// Tell the server unless it closed the stream itself
+			if tracked {
+				a.send(&message{Type: msgStreamClose, ID: open.ID})
+			}
+			return
+		}
+		if err := a.send(&message{Type: msgStreamData, ID: open.ID, Data: data}); err != nil {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// closeStreams closes the local streams of a lost connection
+func (a *Agent) closeStreams() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for id, stream := range a.streams {
+		stream.Close()
+		delete(a.streams, id)
+	}
+}
@@ -0,0 +1,477 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// ErrAgentNotFound is returned for agents that aren't connected
+var ErrAgentNotFound = errors.New("agent not found")
+
+// Hub is the central server's side of the relay: it accepts agent connections and
+// forwards requests and streams to them
+type Hub struct {
+	token    string
+	agents   map[string]*agentConn
+	mutex    sync.RWMutex
+	upgrader websocket.Upgrader
+	logger   *zap.Logger
+}
+
+// agentConn is a connected agent
+type agentConn struct {
+	info     types.AgentInfo
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	mutex    sync.Mutex
+	pending  map[string]chan *message // Requests awaiting a response
+	streams  map[string]chan *message // Open streams
+	requests atomic.Int64
+	nextID   atomic.Int64
+	done     chan struct{}
+}
+
+// NewHub creates a hub admitting agents that present token; an empty token refuses all agents
+func NewHub(token string, logger *zap.Logger) *Hub {
+	return &Hub{
+		token:  token,
+		agents: make(map[string]*agentConn),
+		upgrader: websocket.Upgrader{
+			CheckOrigin:     func(r *http.Request) bool { return true },
+			WriteBufferSize: 1024 * 1024,
+		},
+		logger: logger,
+	}
+}
+
+// HandleConnect accepts an agent connection and serves it until it drops. An agent
+// reconnecting under the ID of a connected one replaces it.
+func (h *Hub) HandleConnect(c *gin.Context) {
+	if h.token == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "relay is disabled; set SCREENSHOT_RELAY_TOKEN to accept agents"})
+		return
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="relay"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "relay token required"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Warn("Agent connection upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	var register message
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.ReadJSON(&register); err != nil || register.Type != msgRegister || register.Agent == nil {
+		conn.WriteJSON(message{Type: msgError, Error: "expected a register message"})
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	if !agentIDPattern.MatchString(register.Agent.ID) {
+		conn.WriteJSON(message{Type: msgError, Error: "agent ID must be 1-64 letters, digits, '.', '_' or '-'"})
+		return
+	}
+
+	agent := &agentConn{
+		info:    *register.Agent,
+		conn:    conn,
+		pending: make(map[string]chan *message),
+		streams: make(map[string]chan *message),
+		done:    make(chan struct{}),
+	}
+	agent.info.RemoteAddr = c.ClientIP()
+	agent.info.ConnectedAt = time.Now()
+
+	h.mutex.Lock()
+	previous := h.agents[agent.info.ID]
+	h.agents[agent.info.ID] = agent
+	h.mutex.Unlock()
+	if previous != nil {
+		previous.conn.Close()
+	}
+
+	if err := agent.send(&message{Type: msgRegistered, ID: agent.info.ID}); err != nil {
+		h.unregister(agent)
+		return
+	}
+	h.logger.Info("Agent connected",
+		zap.String("agent_id", agent.info.ID),
+		zap.String("hostname", agent.info.Hostname),
+		zap.String("platform", agent.info.Platform),
+		zap.String("remote_addr", agent.info.RemoteAddr),
+	)
+
+	go agent.ping()
+	err = agent.read()
+	h.unregister(agent)
+
+	h.logger.Info("Agent disconnected",
+		zap.String("agent_id", agent.info.ID),
+		zap.Error(err),
+	)
+}
+
+// unregister removes an agent, unless it was already replaced, and fails its requests
+func (h *Hub) unregister(agent *agentConn) {
+	h.mutex.Lock()
+	if h.agents[agent.info.ID] == agent {
+		delete(h.agents, agent.info.ID)
+	}
+	h.mutex.Unlock()
+
+	agent.mutex.Lock()
+	close(agent.done)
+	for id, stream := range agent.streams {
+		close(stream)
+		delete(agent.streams, id)
+	}
+	agent.mutex.Unlock()
+}
+
+// List returns the connected agents ordered by ID
+func (h *Hub) List() []types.AgentInfo {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	agents := make([]types.AgentInfo, 0, len(h.agents))
+	for _, agent := range h.agents {
+		agents = append(agents, agent.snapshot())
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents
+}
+
+// Get returns a connected agent
+func (h *Hub) Get(id string) (types.AgentInfo, error) {
+	agent, err := h.agent(id)
+	if err != nil {
+		return types.AgentInfo{}, err
+	}
+	return agent.snapshot(), nil
+}
+
+// Close disconnects every agent
+func (h *Hub) Close() {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, agent := range h.agents {
+		agent.conn.Close()
+	}
+}
+
+func (h *Hub) agent(id string) (*agentConn, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	agent, ok := h.agents[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAgentNotFound, id)
+	}
+	return agent, nil
+}
+
+// Proxy forwards a request to an agent's API at path, relaying WebSocket upgrades as
+// streams
+func (h *Hub) Proxy(c *gin.Context, id, path string) {
+	agent, err := h.agent(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Request.URL.RawQuery != "" {
+		path += "?" + c.Request.URL.RawQuery
+	}
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.proxyStream(c, agent, path)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBody))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+
+	response, err := agent.do(ctx, &message{
+		Type:   msgRequest,
+		Method: c.Request.Method,
+		Path:   path,
+		Header: forwardHeader(c.Request.Header),
+		Body:   body,
+	})
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "agent did not respond in time"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	for name, values := range forwardHeader(response.Header) {
+		if name == "Content-Length" {
+			continue
+		}
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Writer.Header().Set("Content-Length", strconv.Itoa(len(response.Body)))
+	c.Writer.WriteHeader(response.Status)
+	c.Writer.Write(response.Body)
+}
+
+// proxyStream relays a WebSocket connection to a stream opened on the agent
+func (h *Hub) proxyStream(c *gin.Context, agent *agentConn, path string) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Warn("Relayed stream upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	id := agent.newID()
+	incoming := make(chan *message, streamQueue)
+	agent.mutex.Lock()
+	select {
+	case <-agent.done:
+		agent.mutex.Unlock()
+		conn.WriteJSON(gin.H{"type": "error", "error": "agent disconnected", "timestamp": time.Now()})
+		return
+	default:
+	}
+	agent.streams[id] = incoming
+	agent.mutex.Unlock()
+	defer agent.closeStream(id)
+
+	header := http.Header{}
+	if userAgent := c.GetHeader("User-Agent"); userAgent != "" {
+		header.Set("User-Agent", userAgent)
+	}
+	if err := agent.send(&message{Type: msgStreamOpen, ID: id, Path: path, Header: header}); err != nil {
+		return
+	}
+
+	// Client to agent
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				agent.send(&message{Type: msgStreamClose, ID: id})
+				agent.closeStream(id)
+				return
+			}
+			if agent.send(&message{Type: msgStreamData, ID: id, Data: data}) != nil {
+				return
+			}
+		}
+	}()
+
+	// Agent to client
+	for msg := range incoming {
+		if msg.Type == msgStreamClose {
+			if msg.Error != "" {
+				conn.WriteJSON(gin.H{"type": "error", "error": msg.Error, "timestamp": time.Now()})
+			}
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, msg.Data); err != nil {
+			agent.send(&message{Type: msgStreamClose, ID: id})
+			return
+		}
+	}
+}
+
+// snapshot returns the agent's info with its current counters
+func (a *agentConn) snapshot() types.AgentInfo {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	info := a.info
+	info.Requests = a.requests.Load()
+	info.Streams = len(a.streams)
+	return info
+}
+
+func (a *agentConn) newID() string {
+	return strconv.FormatInt(a.nextID.Add(1), 10)
+}
+
+// send writes a message to the agent
+func (a *agentConn) send(msg *message) error {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	a.conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+	return a.conn.WriteJSON(msg)
+}
+
+// do sends a request to the agent and waits for its response
+func (a *agentConn) do(ctx context.Context, request *message) (*message, error) {
+	request.ID = a.newID()
+	reply := make(chan *message, 1)
+
+	a.mutex.Lock()
+	a.pending[request.ID] = reply
+	a.mutex.Unlock()
+	defer func() {
+		a.mutex.Lock()
+		delete(a.pending, request.ID)
+		a.mutex.Unlock()
+	}()
+
+	a.requests.Add(1)
+	if err := a.send(request); err != nil {
+		return nil, fmt.Errorf("failed to forward request to agent: %w", err)
+	}
+
+	select {
+	case response := <-reply:
+		if response.Error != "" {
+			return nil, fmt.Errorf("agent failed the request: %s", response.Error)
+		}
+		return response, nil
+	case <-a.done:
+		return nil, errors.New("agent disconnected")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// closeStream stops relaying a stream
+func (a *agentConn) closeStream(id string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if stream, ok := a.streams[id]; ok {
+		close(stream)
+		delete(a.streams, id)
+	}
+}
+
+// read dispatches the agent's messages until the connection fails
+func (a *agentConn) read() error {
+	for {
+		var msg message
+		if err := a.conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+
+		a.mutex.Lock()
+		switch msg.Type {
+		case msgResponse:
+			if reply, ok := a.pending[msg.ID]; ok {
+				reply <- &msg
+			}
+		case msgStreamData, msgStreamClose:
+			if stream, ok := a.streams[msg.ID]; ok {
+				select {
+				case stream <- &msg:
+				default:
+					// The client can't keep up; dropping its messages keeps the agent's
+					// other requests and streams flowing. A close that doesn't fit ends
+					// the relay directly.
+					if msg.Type == msgStreamClose {
+						close(stream)
+						delete(a.streams, msg.ID)
+					}
+				}
+			}
+		}
+		a.mutex.Unlock()
+	}
+}
+
+// ping keeps the connection alive until the agent disconnects
+func (a *agentConn) ping() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			if err := a.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				a.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// Transport returns an http.RoundTripper that sends requests to an agent's API over its
+// relay connection, for clients of the agent running inside the central server. The
+// request URL's host is ignored.
+func (h *Hub) Transport(id string) http.RoundTripper {
+	return &agentTransport{hub: h, id: id}
+}
+
+type agentTransport struct {
+	hub *Hub
+	id  string
+}
+
+// RoundTrip forwards one request to the agent
+func (t *agentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	agent, err := t.hub.agent(t.id)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(req.Body, maxRequestBody+1))
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxRequestBody {
+			return nil, fmt.Errorf("request body exceeds %d bytes", maxRequestBody)
+		}
+	}
+
+	response, err := agent.do(req.Context(), &message{
+		Type:   msgRequest,
+		Method: req.Method,
+		Path:   req.URL.RequestURI(),
+		Header: forwardHeader(req.Header),
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	header := forwardHeader(response.Header)
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(response.Body)),
+		ContentLength: int64(len(response.Body)),
+		Request:       req,
+	}, nil
+}
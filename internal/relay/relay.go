@@ -0,0 +1,124 @@
+// Package relay lets capture agents behind NAT serve a central server. An agent dials
+// out to the central server over WebSocket and registers; the central server then
+// forwards API requests and streams to it over that one connection. The agent serves
+// forwarded requests with its own HTTP handler, in process, so it needs no open port.
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// ConnectPath is where agents connect on the central server
+const ConnectPath = "/v1/agents/connect"
+
+// Relay timing
+const (
+	pingInterval   = 20 * time.Second // Keeps NAT mappings open and detects dead agents
+	pongTimeout    = 60 * time.Second // Agents drop a connection not pinged for this long
+	requestTimeout = 2 * time.Minute  // Longest a forwarded request may take
+	maxRequestBody = 32 << 20         // Largest forwarded request body
+	streamQueue    = 32               // Stream messages buffered per relayed stream
+)
+
+// Message types
+const (
+	msgRegister    = "register"     // Agent to server: the agent's info
+	msgRegistered  = "registered"   // Server to agent: registration accepted
+	msgRequest     = "request"      // Server to agent: an HTTP request
+	msgResponse    = "response"     // Agent to server: the response to a request
+	msgStreamOpen  = "stream_open"  // Server to agent: open a WebSocket stream
+	msgStreamData  = "stream_data"  // Either way: a message of an open stream
+	msgStreamClose = "stream_close" // Either way: a stream ended
+	msgError       = "error"        // Server to agent: registration refused
+)
+
+// message is the envelope of everything sent over an agent connection
+type message struct {
+	Type   string           `json:"type"`
+	ID     string           `json:"id,omitempty"` // Request or stream the message belongs to
+	Agent  *types.AgentInfo `json:"agent,omitempty"`
+	Method string           `json:"method,omitempty"`
+	Path   string           `json:"path,omitempty"` // Path and query of a request or stream
+	Header http.Header      `json:"header,omitempty"`
+	Body   []byte           `json:"body,omitempty"`
+	Status int              `json:"status,omitempty"`
+	Data   json.RawMessage  `json:"data,omitempty"` // Stream message
+	Error  string           `json:"error,omitempty"`
+}
+
+// agentIDPattern limits agent IDs to what can appear in a URL path segment unescaped
+var agentIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// hopHeaders are connection-specific headers that are not forwarded
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te",
+	"Trailer", "Transfer-Encoding", "Upgrade",
+	"Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Accept",
+}
+
+// forwardHeader copies a header without its hop-by-hop fields
+func forwardHeader(header http.Header) http.Header {
+	forwarded := header.Clone()
+	for _, name := range hopHeaders {
+		forwarded.Del(name)
+	}
+	return forwarded
+}
+
+// memListener is a net.Listener whose connections are in-memory pipes opened by Dial
+type memListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMemListener() *memListener {
+	return &memListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+// Accept waits for the next connection
+func (l *memListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting connections
+func (l *memListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr returns a placeholder address
+func (l *memListener) Addr() net.Addr {
+	return memAddr{}
+}
+
+// Dial opens a connection to the listener
+func (l *memListener) Dial(ctx context.Context, _, _ string) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type memAddr struct{}
+
+func (memAddr) Network() string { return "memory" }
+func (memAddr) String() string  { return "agent" }
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Engine runs the captures of an engine in the scheduler's slots, at the priority of
+// their options. Window discovery and icons aren't scheduled.
+type Engine struct {
+	types.ScreenshotEngine
+	scheduler *Scheduler
+	observe   func(*types.ScreenshotBuffer, error) // Told the outcome of every capture
+}
+
+// NewEngine schedules the captures of engine
+func NewEngine(engine types.ScreenshotEngine, scheduler *Scheduler) *Engine {
+	return &Engine{ScreenshotEngine: engine, scheduler: scheduler}
+}
+
+// Unwrap returns the scheduled engine, for its methods beyond types.ScreenshotEngine
+func (e *Engine) Unwrap() types.ScreenshotEngine {
+	return e.ScreenshotEngine
+}
+
+// SetObserver has observe told the outcome of every capture, such as to count them. It
+// must be set before captures run.
+func (e *Engine) SetObserver(observe func(*types.ScreenshotBuffer, error)) {
+	e.observe = observe
+}
+
+// schedule runs a capture once it has a slot, or right away in the slot its caller holds.
+// Cancelling the options' context gives up waiting for a slot.
+func (e *Engine) schedule(options *types.CaptureOptions, capture func() (*types.ScreenshotBuffer, error)) (*types.ScreenshotBuffer, error) {
+	if e.observe != nil {
+		run := capture
+		capture = func() (*types.ScreenshotBuffer, error) {
+			buffer, err := run()
+			e.observe(buffer, err)
+			return buffer, err
+		}
+	}
+
+	ctx, priority := context.Background(), types.PriorityInteractive
+	if options != nil {
+		if options.SlotHeld {
+			return capture()
+		}
+		if options.Context != nil {
+			ctx = options.Context
+		}
+		priority = options.Priority
+	}
+	release, err := e.scheduler.Acquire(ctx, priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return capture()
+}
+
+func (e *Engine) CaptureByHandle(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureByHandle(handle, options)
+	})
+}
+
+func (e *Engine) CaptureByTitle(title string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureByTitle(title, options)
+	})
+}
+
+func (e *Engine) CaptureByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureByPID(pid, options)
+	})
+}
+
+func (e *Engine) CaptureByClassName(className string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureByClassName(className, options)
+	})
+}
+
+func (e *Engine) CaptureByProcessName(name string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureByProcessName(name, options)
+	})
+}
+
+func (e *Engine) CaptureFullScreen(monitor int, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureFullScreen(monitor, options)
+	})
+}
+
+func (e *Engine) CaptureHiddenByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureHiddenByPID(pid, options)
+	})
+}
+
+func (e *Engine) CaptureTrayApp(processName string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureTrayApp(processName, options)
+	})
+}
+
+func (e *Engine) CaptureWithFallbacks(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureWithFallbacks(handle, options)
+	})
+}
+
+func (e *Engine) CaptureShellSurface(surface types.ShellSurface, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		return e.ScreenshotEngine.CaptureShellSurface(surface, options)
+	})
+}
+
+func (e *Engine) CapturePopups(pid uint32, classes []string, within time.Duration, maxPopups int, options *types.CaptureOptions) ([]*types.ScreenshotBuffer, error) {
+	var popups []*types.ScreenshotBuffer
+	_, err := e.schedule(options, func() (*types.ScreenshotBuffer, error) {
+		var err error
+		popups, err = e.ScreenshotEngine.CapturePopups(pid, classes, within, maxPopups, options)
+		return nil, err
+	})
+	return popups, err
+}
+
+var _ types.ScreenshotEngine = (*Engine)(nil)
@@ -0,0 +1,192 @@
+// Package scheduler bounds how many captures run at once and orders those waiting by
+// priority. Captures can block for long, such as while DWM renders a restored window or
+// WM_PRINT waits on a hung application, so they are spread over a fixed number of slots
+// rather than serialized behind each other or run without limit. Interactive requests
+// are served before scheduled jobs, which are served before stream ticks, and one slot
+// is kept for interactive requests so background work can't take them all.
+package scheduler
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// MaxWait is how long a capture waits for a slot before failing with TIMEOUT
+const MaxWait = 30 * time.Second
+
+// priorities lists the capture priorities, the most urgent first
+var priorities = []types.CapturePriority{types.PriorityInteractive, types.PriorityScheduled, types.PriorityStream}
+
+// Stats reports the scheduler's slots and queues
+type Stats struct {
+	Limit   int            `json:"limit"`
+	Running int            `json:"running"`
+	Waiting map[string]int `json:"waiting"` // By priority
+}
+
+// Scheduler hands out capture slots
+type Scheduler struct {
+	mutex   sync.Mutex
+	limit   int
+	running int
+	queues  map[types.CapturePriority]*list.List // Of *waiter, first come first
+	maxWait time.Duration                        // MaxWait, shorter in tests
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// New creates a scheduler running at most limit captures at once
+func New(limit int) *Scheduler {
+	s := &Scheduler{limit: max(limit, 1), queues: make(map[types.CapturePriority]*list.List), maxWait: MaxWait}
+	for _, priority := range priorities {
+		s.queues[priority] = list.New()
+	}
+	return s
+}
+
+// SetLimit changes how many captures run at once. Captures running beyond a lowered
+// limit finish first.
+func (s *Scheduler) SetLimit(limit int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limit = max(limit, 1)
+	s.dispatch()
+}
+
+// Acquire waits for a slot for a capture of priority and returns the function that
+// frees it; calling that more than once frees the slot once. It fails when ctx ends or
+// no slot freed up within MaxWait.
+func (s *Scheduler) Acquire(ctx context.Context, priority types.CapturePriority) (func(), error) {
+	if _, ok := s.queues[priority]; !ok {
+		priority = types.PriorityInteractive
+	}
+
+	s.mutex.Lock()
+	if !s.queued(priority) && s.running < s.capacity(priority) {
+		s.running++
+		s.mutex.Unlock()
+		return sync.OnceFunc(s.release), nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	element := s.queues[priority].PushBack(w)
+	s.mutex.Unlock()
+
+	timer := time.NewTimer(s.maxWait)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.ready:
+		return sync.OnceFunc(s.release), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = types.NewCaptureError(types.ErrTimeout,
+			fmt.Sprintf("no capture slot freed up within %s", s.maxWait), nil)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if w.granted {
+		// The slot came as the wait ended; pass it on
+		s.running--
+		s.dispatch()
+	} else {
+		s.queues[priority].Remove(element)
+	}
+	return nil, err
+}
+
+// Stats returns the slots in use and the captures waiting
+func (s *Scheduler) Stats() Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats := Stats{Limit: s.limit, Running: s.running, Waiting: make(map[string]int, len(priorities))}
+	for _, priority := range priorities {
+		stats.Waiting[priority.String()] = s.queues[priority].Len()
+	}
+	return stats
+}
+
+func (s *Scheduler) release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running--
+	s.dispatch()
+}
+
+// capacity returns the slots captures of priority may fill: all of them for interactive
+// requests, and all but one for the rest
+func (s *Scheduler) capacity(priority types.CapturePriority) int {
+	if priority == types.PriorityInteractive || s.limit == 1 {
+		return s.limit
+	}
+	return s.limit - 1
+}
+
+// queued reports whether captures of priority or a more urgent one are waiting
+func (s *Scheduler) queued(priority types.CapturePriority) bool {
+	for _, p := range priorities {
+		if p > priority {
+			break
+		}
+		if s.queues[p].Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch hands free slots to the waiting captures, most urgent first
+func (s *Scheduler) dispatch() {
+	for _, priority := range priorities {
+		queue := s.queues[priority]
+		for queue.Len() > 0 && s.running < s.capacity(priority) {
+			w := queue.Remove(queue.Front()).(*waiter)
+			w.granted = true
+			s.running++
+			close(w.ready)
+		}
+		if queue.Len() > 0 {
+			// Less urgent captures fit in fewer slots
+			return
+		}
+	}
+}
+
+type (
+	contextKey     struct{}
+	slotContextKey struct{}
+)
+
+// WithPriority returns a context whose captures run at priority
+func WithPriority(ctx context.Context, priority types.CapturePriority) context.Context {
+	return context.WithValue(ctx, contextKey{}, priority)
+}
+
+// PriorityOf returns the priority of captures made for ctx, interactive by default
+func PriorityOf(ctx context.Context) types.CapturePriority {
+	if priority, ok := ctx.Value(contextKey{}).(types.CapturePriority); ok {
+		return priority
+	}
+	return types.PriorityInteractive
+}
+
+// WithHeldSlot returns a context whose captures run in a slot the caller holds, so that
+// captures meant to happen together don't wait for each other's slots
+func WithHeldSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, slotContextKey{}, true)
+}
+
+// SlotHeld reports whether captures made for ctx run in a slot the caller holds
+func SlotHeld(ctx context.Context) bool {
+	held, _ := ctx.Value(slotContextKey{}).(bool)
+	return held
+}
@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type acquired struct {
+	release func()
+	err     error
+}
+
+// acquire asks for a slot in the background and waits until it is granted or queued
+func acquire(t *testing.T, s *Scheduler, ctx context.Context, priority types.CapturePriority) <-chan acquired {
+	t.Helper()
+	before := s.Stats().Waiting[priority.String()]
+	result := make(chan acquired, 1)
+	go func() {
+		release, err := s.Acquire(ctx, priority)
+		result <- acquired{release, err}
+	}()
+	require.Eventually(t, func() bool {
+		return len(result) > 0 || s.Stats().Waiting[priority.String()] > before
+	}, time.Second, time.Millisecond)
+	return result
+}
+
+func mustAcquire(t *testing.T, s *Scheduler, priority types.CapturePriority) func() {
+	t.Helper()
+	release, err := s.Acquire(context.Background(), priority)
+	require.NoError(t, err)
+	return release
+}
+
+func granted(t *testing.T, result <-chan acquired) func() {
+	t.Helper()
+	select {
+	case r := <-result:
+		require.NoError(t, r.err)
+		return r.release
+	case <-time.After(time.Second):
+		require.FailNow(t, "the capture wasn't granted a slot")
+		return nil
+	}
+}
+
+func assertWaiting(t *testing.T, result <-chan acquired) {
+	t.Helper()
+	select {
+	case <-result:
+		assert.Fail(t, "the capture was granted a slot beyond the limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestAcquireRunsUpToTheLimit(t *testing.T) {
+	s := New(2)
+	first := mustAcquire(t, s, types.PriorityInteractive)
+	mustAcquire(t, s, types.PriorityInteractive)
+	assert.Equal(t, 2, s.Stats().Running)
+
+	third := acquire(t, s, context.Background(), types.PriorityInteractive)
+	assertWaiting(t, third)
+	first()
+	granted(t, third)
+	assert.Equal(t, 2, s.Stats().Running)
+}
+
+func TestWaitersAreServedByPriority(t *testing.T) {
+	s := New(1)
+	release := mustAcquire(t, s, types.PriorityInteractive)
+
+	order := make(chan types.CapturePriority, 3)
+	for _, priority := range []types.CapturePriority{types.PriorityStream, types.PriorityScheduled, types.PriorityInteractive} {
+		result := acquire(t, s, context.Background(), priority)
+		go func(priority types.CapturePriority) {
+			r := <-result
+			order <- priority
+			r.release()
+		}(priority)
+	}
+	assert.Equal(t, map[string]int{"interactive": 1, "scheduled": 1, "stream": 1}, s.Stats().Waiting)
+
+	release()
+	for _, want := range priorities {
+		select {
+		case got := <-order:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			require.FailNow(t, "a waiting capture wasn't granted a slot")
+		}
+	}
+	assert.Eventually(t, func() bool { return s.Stats().Running == 0 }, time.Second, time.Millisecond)
+}
+
+func TestASlotIsKeptForInteractiveRequests(t *testing.T) {
+	s := New(2)
+	mustAcquire(t, s, types.PriorityScheduled)
+	scheduled := acquire(t, s, context.Background(), types.PriorityScheduled)
+	stream := acquire(t, s, context.Background(), types.PriorityStream)
+	assertWaiting(t, scheduled)
+	assertWaiting(t, stream)
+
+	interactive := acquire(t, s, context.Background(), types.PriorityInteractive)
+	release := granted(t, interactive)
+	assert.Equal(t, 2, s.Stats().Running)
+
+	// The interactive request's slot stays kept once it is freed
+	release()
+	assertWaiting(t, scheduled)
+	assert.Equal(t, 1, s.Stats().Running)
+}
+
+func TestInteractiveRequestsPassWaitingBackgroundWork(t *testing.T) {
+	s := New(2)
+	release := mustAcquire(t, s, types.PriorityInteractive)
+	mustAcquire(t, s, types.PriorityInteractive)
+	stream := acquire(t, s, context.Background(), types.PriorityStream)
+	interactive := acquire(t, s, context.Background(), types.PriorityInteractive)
+
+	release()
+	granted(t, interactive)
+	assertWaiting(t, stream)
+}
+
+func TestSetLimit(t *testing.T) {
+	s := New(3)
+	releases := []func(){
+		mustAcquire(t, s, types.PriorityInteractive),
+		mustAcquire(t, s, types.PriorityInteractive),
+		mustAcquire(t, s, types.PriorityInteractive),
+	}
+
+	s.SetLimit(1)
+	waiting := acquire(t, s, context.Background(), types.PriorityInteractive)
+	releases[0]()
+	releases[1]()
+	assertWaiting(t, waiting)
+	assert.Equal(t, 1, s.Stats().Running, "captures running beyond the lowered limit finish first")
+	releases[2]()
+	granted(t, waiting)
+
+	next := acquire(t, s, context.Background(), types.PriorityInteractive)
+	assertWaiting(t, next)
+	s.SetLimit(2)
+	granted(t, next)
+	assert.Equal(t, Stats{Limit: 2, Running: 2, Waiting: map[string]int{"interactive": 0, "scheduled": 0, "stream": 0}}, s.Stats())
+}
+
+func TestAcquireTimesOut(t *testing.T) {
+	s := New(1)
+	s.maxWait = 10 * time.Millisecond
+	mustAcquire(t, s, types.PriorityInteractive)
+
+	_, err := s.Acquire(context.Background(), types.PriorityScheduled)
+	assert.Equal(t, types.ErrTimeout, types.ErrorCodeOf(err))
+	assert.Equal(t, 0, s.Stats().Waiting["scheduled"])
+	assert.Equal(t, 1, s.Stats().Running)
+}
+
+func TestAcquireGivesUpWhenTheContextEnds(t *testing.T) {
+	s := New(1)
+	mustAcquire(t, s, types.PriorityInteractive)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := acquire(t, s, ctx, types.PriorityStream)
+	cancel()
+	select {
+	case r := <-result:
+		assert.ErrorIs(t, r.err, context.Canceled)
+	case <-time.After(time.Second):
+		require.FailNow(t, "the capture kept waiting")
+	}
+	assert.Equal(t, 0, s.Stats().Waiting["stream"])
+	assert.Equal(t, 1, s.Stats().Running)
+}
+
+func TestSlotGrantedAsTheWaitEndsIsPassedOn(t *testing.T) {
+	s := New(1)
+	mustAcquire(t, s, types.PriorityInteractive)
+	ctx, cancel := context.WithCancel(context.Background())
+	first := acquire(t, s, ctx, types.PriorityInteractive)
+	second := acquire(t, s, context.Background(), types.PriorityInteractive)
+
+	// The first waiter's context ends, and the held slot is freed and granted to it
+	// before the waiter gets to leave the queue
+	s.mutex.Lock()
+	cancel()
+	s.running--
+	s.dispatch()
+	s.mutex.Unlock()
+
+	r := <-first
+	assert.ErrorIs(t, r.err, context.Canceled)
+	granted(t, second)()
+	assert.Equal(t, 0, s.Stats().Running)
+}
+
+func TestReleasingTwiceFreesOneSlot(t *testing.T) {
+	s := New(2)
+	release := mustAcquire(t, s, types.PriorityInteractive)
+	mustAcquire(t, s, types.PriorityInteractive)
+
+	release()
+	release()
+	assert.Equal(t, 1, s.Stats().Running)
+}
+
+func TestEngineWaitsWithTheOptionsContext(t *testing.T) {
+	s := New(1)
+	mustAcquire(t, s, types.PriorityInteractive)
+	engine := NewEngine(nil, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := engine.schedule(&types.CaptureOptions{Context: ctx, Priority: types.PriorityStream}, func() (*types.ScreenshotBuffer, error) {
+		require.FailNow(t, "the capture ran without a slot")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
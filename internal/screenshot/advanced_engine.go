@@ -1,11 +1,16 @@
+//go:build windows
+
 package screenshot
 
 import (
 	"fmt"
+	"strings"
 	"syscall"
 	"time"
 	"unsafe"
 
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/internal/window"
 	"github.com/screenshot-mcp-server/pkg/types"
 	"golang.org/x/sys/windows"
 )
@@ -19,15 +24,11 @@ var (
 	dwmQueryThumbnailSourceSize   = dwmapi.NewProc("DwmQueryThumbnailSourceSize")
 	
 	// Shell functions for system tray
-	shell32                       = windows.NewLazyDLL("shell32.dll")
 	shell_NotifyIconGetRect       = shell32.NewProc("Shell_NotifyIconGetRect")
 	
 	// Additional User32 functions
 	sendMessage                   = user32.NewProc("SendMessageW")
 	postMessage                   = user32.NewProc("PostMessageW")
-	enumChildWindows              = user32.NewProc("EnumChildWindows")
-	enumThreadWindows             = user32.NewProc("EnumThreadWindows")
-	getWindowThreadProcessId      = user32.NewProc("GetWindowThreadProcessId")
 	
 	// Process and thread functions
 	createToolhelp32Snapshot      = kernel32.NewProc("CreateToolhelp32Snapshot")
@@ -35,6 +36,8 @@ var (
 	process32Next                = kernel32.NewProc("Process32NextW")
 	thread32First                = kernel32.NewProc("Thread32First")
 	thread32Next                 = kernel32.NewProc("Thread32Next")
+	virtualAllocEx               = kernel32.NewProc("VirtualAllocEx")
+	virtualFreeEx                = kernel32.NewProc("VirtualFreeEx")
 )
 
 // Windows API constants for advanced features
@@ -56,8 +59,7 @@ const (
 	PRF_CHILDREN      = 0x00000010
 	PRF_OWNED         = 0x00000020
 	
-	// Cloaking constants
-	DWMWA_CLOAKED = 14
+	// Cloaking reasons
 	DWM_CLOAKED_APP = 0x0000001
 	DWM_CLOAKED_SHELL = 0x0000002  
 	DWM_CLOAKED_INHERITED = 0x0000004
@@ -72,13 +74,23 @@ const (
 	NIM_DELETE = 0x00000002
 	NIM_SETFOCUS = 0x00000003
 	NIM_SETVERSION = 0x00000004
+	
+	// Toolbar messages for reading tray icons
+	TB_GETBUTTON   = 0x0417
+	TB_BUTTONCOUNT = 0x0418
+	
+	// Remote memory allocation
+	MEM_COMMIT     = 0x00001000
+	MEM_RESERVE    = 0x00002000
+	MEM_RELEASE    = 0x00008000
+	PAGE_READWRITE = 0x04
 )
 
 // DWM Thumbnail structures
 type DWM_THUMBNAIL_PROPERTIES struct {
 	dwFlags               uint32
-	rcDestination         RECT
-	rcSource              RECT
+	rcDestination         win32.RECT
+	rcSource              win32.RECT
 	opacity               byte
 	fVisible              int32  // BOOL
 	fSourceClientAreaOnly int32  // BOOL
@@ -88,6 +100,17 @@ type SIZE struct {
 	Width, Height int32
 }
 
+// TBBUTTON structure (x64 layout)
+type TBBUTTON struct {
+	Bitmap    int32
+	Command   int32
+	State     byte
+	Style     byte
+	reserved  [6]byte
+	Data      uintptr
+	String    uintptr
+}
+
 // Process and thread structures
 type PROCESSENTRY32 struct {
 	dwSize              uint32
@@ -132,20 +155,15 @@ func (e *WindowsScreenshotEngine) EnumerateAllProcessWindows(pid uint32) ([]type
 	}
 	
 	// Also try standard EnumWindows with PID filtering
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		var windowPID uint32
-		getWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&windowPID)))
-		
-		if windowPID == pid {
+	win32.EnumWindows(func(hwnd uintptr) bool {
+		if win32.WindowProcessID(hwnd) == pid {
 			if info, err := e.getWindowInfo(hwnd); err == nil {
 				windows = append(windows, *info)
 			}
 		}
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
 	
-	enumWindows.Call(callback, 0)
-	
 	return e.deduplicateWindows(windows), nil
 }
 
@@ -159,22 +177,34 @@ func (e *WindowsScreenshotEngine) FindSystemTrayApps() ([]types.WindowInfo, erro
 		return nil, fmt.Errorf("failed to find system tray: %w", err)
 	}
 	
-	// Find notification area
+	// Icons sit on the notification area toolbar and, when hidden, on the overflow flyout's
+	var toolbars []uintptr
 	notifyWnd, _ := e.findChildWindow(trayWnd, "TrayNotifyWnd", "")
 	if notifyWnd != 0 {
 		sysPager, _ := e.findChildWindow(notifyWnd, "SysPager", "")
 		if sysPager != 0 {
-			toolbarWnd, _ := e.findChildWindow(sysPager, "ToolbarWindow32", "")
-			if toolbarWnd != 0 {
-				// Get processes with tray icons
-				trayProcesses := e.getTrayProcesses(toolbarWnd)
-				
-				for _, pid := range trayProcesses {
-					processWindows, err := e.EnumerateAllProcessWindows(pid)
-					if err == nil {
-						trayApps = append(trayApps, processWindows...)
-					}
-				}
+			if toolbarWnd, _ := e.findChildWindow(sysPager, "ToolbarWindow32", ""); toolbarWnd != 0 {
+				toolbars = append(toolbars, toolbarWnd)
+			}
+		}
+	}
+	if overflowWnd, err := e.findWindow("NotifyIconOverflowWindow", ""); err == nil {
+		if toolbarWnd, _ := e.findChildWindow(overflowWnd, "ToolbarWindow32", ""); toolbarWnd != 0 {
+			toolbars = append(toolbars, toolbarWnd)
+		}
+	}
+	
+	// Get processes with tray icons
+	seen := make(map[uint32]bool)
+	for _, toolbarWnd := range toolbars {
+		for _, pid := range e.getTrayProcesses(toolbarWnd) {
+			if seen[pid] {
+				continue
+			}
+			seen[pid] = true
+			processWindows, err := e.EnumerateAllProcessWindows(pid)
+			if err == nil {
+				trayApps = append(trayApps, processWindows...)
 			}
 		}
 	}
@@ -186,12 +216,9 @@ func (e *WindowsScreenshotEngine) FindSystemTrayApps() ([]types.WindowInfo, erro
 func (e *WindowsScreenshotEngine) FindHiddenWindows() ([]types.WindowInfo, error) {
 	var hiddenWindows []types.WindowInfo
 	
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		visible, _, _ := isWindowVisible.Call(hwnd)
-		iconic, _, _ := isIconic.Call(hwnd)
-		
+	win32.EnumWindows(func(hwnd uintptr) bool {
 		// Window exists but is not visible and not minimized
-		if visible == 0 && iconic == 0 {
+		if !win32.IsWindowVisible(hwnd) && !win32.IsIconic(hwnd) {
 			if info, err := e.getWindowInfo(hwnd); err == nil {
 				// Filter out system windows with no title
 				if info.Title != "" || len(info.ClassName) > 0 {
@@ -199,11 +226,9 @@ func (e *WindowsScreenshotEngine) FindHiddenWindows() ([]types.WindowInfo, error
 				}
 			}
 		}
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
 	
-	enumWindows.Call(callback, 0)
-	
 	return hiddenWindows, nil
 }
 
@@ -211,27 +236,17 @@ func (e *WindowsScreenshotEngine) FindHiddenWindows() ([]types.WindowInfo, error
 func (e *WindowsScreenshotEngine) FindCloakedWindows() ([]types.WindowInfo, error) {
 	var cloakedWindows []types.WindowInfo
 	
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		var cloaked uint32
-		ret, _, _ := dwmGetWindowAttribute.Call(
-			hwnd,
-			DWMWA_CLOAKED,
-			uintptr(unsafe.Pointer(&cloaked)),
-			unsafe.Sizeof(cloaked),
-		)
-		
+	win32.EnumWindows(func(hwnd uintptr) bool {
 		// Window is cloaked by DWM
-		if ret == 0 && cloaked != 0 {
+		if win32.IsCloaked(hwnd) {
 			if info, err := e.getWindowInfo(hwnd); err == nil {
 				info.State = "cloaked"
 				cloakedWindows = append(cloakedWindows, *info)
 			}
 		}
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
 	
-	enumWindows.Call(callback, 0)
-	
 	return cloakedWindows, nil
 }
 
@@ -286,6 +301,68 @@ func (e *WindowsScreenshotEngine) CaptureTrayApp(processName string, options *ty
 	return e.CaptureHiddenByPID(pid, options)
 }
 
+// CaptureByProcessName captures the main window of a process identified by its
+// executable name, e.g. "notepad.exe" or "slack"
+func (e *WindowsScreenshotEngine) CaptureByProcessName(name string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	pids, err := e.findProcessesByName(name)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Multi-process apps (browsers, Electron) spread windows across processes
+	var windows []types.WindowInfo
+	for _, pid := range pids {
+		processWindows, err := e.EnumerateAllProcessWindows(pid)
+		if err != nil {
+			continue
+		}
+		windows = append(windows, processWindows...)
+	}
+	
+	target := e.selectMainWindow(windows)
+	if target == nil {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no capturable windows found for process %s (%d instances)", name, len(pids)), nil)
+	}
+	
+	return e.CaptureByHandle(target.Handle, options)
+}
+
+// selectMainWindow picks the window most likely to be an application's main window:
+// an unowned, titled, visible top-level window, preferring the largest
+func (e *WindowsScreenshotEngine) selectMainWindow(windows []types.WindowInfo) *types.WindowInfo {
+	var best *types.WindowInfo
+	bestScore := 0
+	
+	for i := range windows {
+		window := &windows[i]
+		if window.Rect.Width <= 0 || window.Rect.Height <= 0 {
+			continue
+		}
+		
+		score := 1
+		if win32.GetWindow(window.Handle, win32.GW_OWNER) == 0 {
+			score += 8
+		}
+		if window.IsVisible {
+			score += 4
+		}
+		if window.Title != "" {
+			score += 2
+		}
+		if window.State != "minimized" && window.Rect.Width > 100 && window.Rect.Height > 100 {
+			score++
+		}
+		
+		if best == nil || score > bestScore ||
+			(score == bestScore && window.Rect.Width*window.Rect.Height > best.Rect.Width*best.Rect.Height) {
+			best = window
+			bestScore = score
+		}
+	}
+	
+	return best
+}
+
 // CaptureWithFallbacks uses multiple capture methods with intelligent fallback
 func (e *WindowsScreenshotEngine) CaptureWithFallbacks(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
 	if options == nil {
@@ -300,12 +377,59 @@ func (e *WindowsScreenshotEngine) CaptureWithFallbacks(handle uintptr, options *
 	// Determine capture methods to try
 	methods := e.selectCaptureMethods(windowInfo, options)
 	
+	return e.captureWithMethods(handle, windowInfo, methods, options)
+}
+
+// CaptureWithMethod captures a window with exactly one method and no fallbacks
+func (e *WindowsScreenshotEngine) CaptureWithMethod(handle uintptr, method types.CaptureMethod, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if options == nil {
+		options = types.DefaultCaptureOptions()
+	}
+
+	windowInfo, err := e.getWindowInfo(handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get window info: %w", err)
+	}
+
+	return e.captureWithMethods(handle, windowInfo, []types.CaptureMethod{method}, options)
+}
+
+// captureWithMethods tries each method in order and records every attempt on the result.
+// When black frame detection is enabled, black results count as failures; if every
+// method fails that way the first black frame is returned flagged as such.
+func (e *WindowsScreenshotEngine) captureWithMethods(handle uintptr, windowInfo *types.WindowInfo, methods []types.CaptureMethod, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	var attempts []types.CaptureAttempt
+	var blackFrame *types.ScreenshotBuffer
 	var lastErr error
+	
 	for i, method := range methods {
+		start := time.Now()
 		buffer, err := e.captureWithMethod(handle, windowInfo, method, options)
+		attempt := types.CaptureAttempt{Method: method, Duration: time.Since(start)}
+		
+		if err == nil && options.DetectBlackFrames && isBlackFrame(buffer) {
+			if blackFrame == nil {
+				blackFrame = buffer
+				blackFrame.CaptureMethod = method
+			}
+			err = fmt.Errorf("%s returned a black frame", method)
+		}
+		
+		// Methods skipped for a hung window say nothing about how they fare on its class
+		attempt.Success = err == nil
+		if windowInfo.State != "hung" || !sendsWindowMessages(method) {
+			e.ranking.Record(windowInfo.ClassName, attempt)
+		}
+		
 		if err == nil {
+			buffer.CaptureMethod = method
+			buffer.Attempts = append(attempts, attempt)
+			buffer.BlackFrameDetected = blackFrame != nil
 			return buffer, nil
 		}
+		
+		attempt.Error = err.Error()
+		attempts = append(attempts, attempt)
 		lastErr = err
 		
 		// Add delay between attempts
@@ -314,7 +438,16 @@ func (e *WindowsScreenshotEngine) CaptureWithFallbacks(handle uintptr, options *
 		}
 	}
 	
-	return nil, fmt.Errorf("all capture methods failed, last error: %w", lastErr)
+	if blackFrame != nil {
+		blackFrame.Attempts = attempts
+		blackFrame.BlackFrameDetected = true
+		return blackFrame, nil
+	}
+	
+	if lastErr == nil {
+		return nil, fmt.Errorf("no capture methods to try")
+	}
+	return nil, &methodsError{attempts: attempts, last: lastErr}
 }
 
 // selectCaptureMethods intelligently selects the best capture methods for a window
@@ -322,21 +455,30 @@ func (e *WindowsScreenshotEngine) selectCaptureMethods(windowInfo *types.WindowI
 	methods := make([]types.CaptureMethod, 0, 6)
 	
 	// If user specified a preferred method, try it first
-	if options.PreferredMethod != types.CaptureAuto {
+	if options.PreferredMethod != "" && options.PreferredMethod != types.CaptureAuto {
 		methods = append(methods, options.PreferredMethod)
 	}
 	
-	// Add fallback methods based on window state
+	// Exclusive full-screen games are only seen by duplicating their monitor
+	if windowInfo.Fullscreen == types.FullscreenExclusive {
+		methods = append(methods, types.CaptureGame)
+	}
+	
+	// Add fallback methods based on window state, best first for the window's class
+	var fallbacks []types.CaptureMethod
 	switch windowInfo.State {
 	case "visible":
-		methods = append(methods, types.CaptureBitBlt, types.CapturePrintWindow, types.CaptureDWMThumbnail)
+		fallbacks = []types.CaptureMethod{types.CaptureBitBlt, types.CapturePrintWindow, types.CaptureDWMThumbnail}
 	case "minimized":
-		methods = append(methods, types.CaptureDWMThumbnail, types.CapturePrintWindow, types.CaptureWMPrint, types.CaptureStealthRestore)
+		fallbacks = []types.CaptureMethod{types.CaptureDWMThumbnail, types.CapturePrintWindow, types.CaptureWMPrint, types.CaptureStealthRestore}
 	case "hidden", "cloaked":
-		methods = append(methods, types.CaptureDWMThumbnail, types.CaptureWMPrint, types.CapturePrintWindow)
+		fallbacks = []types.CaptureMethod{types.CaptureDWMThumbnail, types.CaptureWMPrint, types.CapturePrintWindow}
+	case "hung":
+		fallbacks = []types.CaptureMethod{types.CaptureDWMThumbnail, types.CaptureBitBlt, types.CaptureDXGI}
 	default:
-		methods = append(methods, types.CaptureDWMThumbnail, types.CapturePrintWindow, types.CaptureWMPrint, types.CaptureBitBlt)
+		fallbacks = []types.CaptureMethod{types.CaptureDWMThumbnail, types.CapturePrintWindow, types.CaptureWMPrint, types.CaptureBitBlt}
 	}
+	methods = append(methods, e.ranking.Rank(windowInfo.ClassName, fallbacks)...)
 	
 	// Add user-specified fallback methods
 	if len(options.FallbackMethods) > 0 {
@@ -347,8 +489,18 @@ func (e *WindowsScreenshotEngine) selectCaptureMethods(windowInfo *types.WindowI
 	return e.deduplicateMethods(methods)
 }
 
+// MethodRankings returns the capture method order learned for each window class
+func (e *WindowsScreenshotEngine) MethodRankings() []types.MethodRanking {
+	return e.ranking.Rankings()
+}
+
 // captureWithMethod captures using a specific method
 func (e *WindowsScreenshotEngine) captureWithMethod(handle uintptr, windowInfo *types.WindowInfo, method types.CaptureMethod, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	// Methods that send the window messages would block until a hung window recovers
+	if windowInfo.State == "hung" && sendsWindowMessages(method) {
+		return nil, fmt.Errorf("%s skipped: window is not responding", method)
+	}
+	
 	switch method {
 	case types.CaptureBitBlt:
 		return e.captureVisibleWindow(handle, windowInfo, options)
@@ -360,107 +512,25 @@ func (e *WindowsScreenshotEngine) captureWithMethod(handle uintptr, windowInfo *
 		return e.captureWMPrint(handle, windowInfo, options)
 	case types.CaptureStealthRestore:
 		return e.captureStealthRestore(handle, windowInfo, options)
+	case types.CaptureRenderFullContent:
+		return e.captureRenderFullContent(handle, windowInfo, options)
+	case types.CaptureDXGI:
+		return e.captureDXGI(handle, windowInfo, options)
+	case types.CaptureGame:
+		return e.captureGame(handle, windowInfo, options)
 	default:
 		return nil, fmt.Errorf("unsupported capture method: %s", method)
 	}
 }
 
-// captureDWMThumbnail uses the DWM Thumbnail API to capture any window
-func (e *WindowsScreenshotEngine) captureDWMThumbnail(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
-	// Get desktop window as destination
-	desktopHandle, _, _ := getDesktopWindow.Call()
-	if desktopHandle == 0 {
-		return nil, fmt.Errorf("failed to get desktop window")
-	}
-	
-	// Register thumbnail
-	var thumbnail uintptr
-	ret, _, _ := dwmRegisterThumbnail.Call(desktopHandle, handle, uintptr(unsafe.Pointer(&thumbnail)))
-	if ret != 0 {
-		return nil, fmt.Errorf("DwmRegisterThumbnail failed: %x", ret)
-	}
-	defer dwmUnregisterThumbnail.Call(thumbnail)
-	
-	// Get source size
-	var sourceSize SIZE
-	ret, _, _ = dwmQueryThumbnailSourceSize.Call(thumbnail, uintptr(unsafe.Pointer(&sourceSize)))
-	if ret != 0 {
-		return nil, fmt.Errorf("DwmQueryThumbnailSourceSize failed: %x", ret)
-	}
-	
-	// Create off-screen bitmap for thumbnail
-	screenDC, _, _ := getDC.Call(0)
-	if screenDC == 0 {
-		return nil, fmt.Errorf("failed to get screen DC")
-	}
-	defer releaseDC.Call(0, screenDC)
-	
-	memDC, _, _ := createCompatibleDC.Call(screenDC)
-	if memDC == 0 {
-		return nil, fmt.Errorf("failed to create compatible DC")
-	}
-	defer deleteDC.Call(memDC)
-	
-	// Create DIB section
-	width := int(sourceSize.Width)
-	height := int(sourceSize.Height)
-	
-	var bmi BITMAPINFO
-	bmi.Header.Size = uint32(unsafe.Sizeof(bmi.Header))
-	bmi.Header.Width = int32(width)
-	bmi.Header.Height = -int32(height) // Top-down DIB
-	bmi.Header.Planes = 1
-	bmi.Header.BitCount = 32
-	bmi.Header.Compression = BI_RGB
-	
-	var pBits uintptr
-	bitmap, _, _ := createDIBSection.Call(memDC, uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(&pBits)), 0, 0)
-	if bitmap == 0 {
-		return nil, fmt.Errorf("failed to create DIB section")
-	}
-	defer deleteObject.Call(bitmap)
-	
-	oldBitmap, _, _ := selectObject.Call(memDC, bitmap)
-	defer selectObject.Call(memDC, oldBitmap)
-	
-	// Setup thumbnail properties
-	var props DWM_THUMBNAIL_PROPERTIES
-	props.dwFlags = DWM_TNP_RECTDESTINATION | DWM_TNP_RECTSOURCE | DWM_TNP_VISIBLE
-	props.rcDestination = RECT{0, 0, int32(width), int32(height)}
-	props.rcSource = RECT{0, 0, int32(sourceSize.Width), int32(sourceSize.Height)}
-	props.fVisible = 1
-	
-	// Update thumbnail
-	ret, _, _ = dwmUpdateThumbnailProperties.Call(thumbnail, uintptr(unsafe.Pointer(&props)))
-	if ret != 0 {
-		return nil, fmt.Errorf("DwmUpdateThumbnailProperties failed: %x", ret)
-	}
-	
-	// Give DWM time to render
-	time.Sleep(time.Millisecond * 100)
-	
-	// Copy pixel data
-	pixelCount := width * height * 4
-	pixelData := make([]byte, pixelCount)
-	
-	if pBits != 0 {
-		copy(pixelData, (*[1 << 30]byte)(unsafe.Pointer(pBits))[:pixelCount:pixelCount])
-	}
-	
-	// Create screenshot buffer
-	buffer := &types.ScreenshotBuffer{
-		Data:       pixelData,
-		Width:      width,
-		Height:     height,
-		Stride:     width * 4,
-		Format:     "BGRA32",
-		DPI:        96,
-		Timestamp:  time.Now(),
-		SourceRect: types.Rectangle{X: 0, Y: 0, Width: width, Height: height},
-		WindowInfo: *windowInfo,
+// sendsWindowMessages reports whether a method makes the window render itself by
+// sending it messages, which a hung window doesn't answer
+func sendsWindowMessages(method types.CaptureMethod) bool {
+	switch method {
+	case types.CapturePrintWindow, types.CaptureRenderFullContent, types.CaptureWMPrint, types.CaptureStealthRestore:
+		return true
 	}
-	
-	return buffer, nil
+	return false
 }
 
 // captureWMPrint uses WM_PRINT message to force window rendering
@@ -471,17 +541,17 @@ func (e *WindowsScreenshotEngine) captureWMPrint(handle uintptr, windowInfo *typ
 	}
 	
 	// Create device context
-	screenDC, _, _ := getDC.Call(0)
+	screenDC, _ := getTrackedDC(GDIWMPrint, 0, false)
 	if screenDC == 0 {
 		return nil, fmt.Errorf("failed to get screen DC")
 	}
-	defer releaseDC.Call(0, screenDC)
+	defer releaseTrackedDC(GDIWMPrint, 0, screenDC)
 	
-	memDC, _, _ := createCompatibleDC.Call(screenDC)
+	memDC := createTrackedDC(GDIWMPrint, screenDC)
 	if memDC == 0 {
 		return nil, fmt.Errorf("failed to create compatible DC")
 	}
-	defer deleteDC.Call(memDC)
+	defer deleteTrackedDC(GDIWMPrint, memDC)
 	
 	// Create DIB section
 	var bmi BITMAPINFO
@@ -493,11 +563,11 @@ func (e *WindowsScreenshotEngine) captureWMPrint(handle uintptr, windowInfo *typ
 	bmi.Header.Compression = BI_RGB
 	
 	var pBits uintptr
-	bitmap, _, _ := createDIBSection.Call(memDC, uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(&pBits)), 0, 0)
+	bitmap := createTrackedDIB(GDIWMPrint, memDC, &bmi, &pBits)
 	if bitmap == 0 {
 		return nil, fmt.Errorf("failed to create DIB section")
 	}
-	defer deleteObject.Call(bitmap)
+	defer deleteTrackedObject(GDIWMPrint, GDIBitmap, bitmap)
 	
 	oldBitmap, _, _ := selectObject.Call(memDC, bitmap)
 	defer selectObject.Call(memDC, oldBitmap)
@@ -544,17 +614,20 @@ func (e *WindowsScreenshotEngine) captureStealthRestore(handle uintptr, windowIn
 		return e.captureVisibleWindow(handle, windowInfo, options)
 	}
 	
-	// Store original window placement
-	placement, err := e.getWindowPlacement(handle)
+	// Store original window placement, and put it back however the capture ends
+	placement, err := window.GetPlacement(handle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get window placement: %w", err)
 	}
+	defer func() {
+		if err := window.RestorePlacement(handle, placement); err != nil {
+			win32.ShowWindow(handle, win32.SW_SHOWMINNOACTIVE)
+		}
+	}()
 	
-	// Restore window without activating
-	ret, _, _ := showWindow.Call(handle, SW_SHOWNOACTIVATE)
-	if ret == 0 {
-		return nil, fmt.Errorf("failed to restore window")
-	}
+	// Restore window without activating. ShowWindow returns the previous visibility,
+	// which is nonzero for a minimized window, so a zero return isn't an error.
+	win32.ShowWindow(handle, win32.SW_SHOWNOACTIVATE)
 	
 	// Wait for window to become visible
 	if options.WaitForVisible > 0 {
@@ -564,16 +637,7 @@ func (e *WindowsScreenshotEngine) captureStealthRestore(handle uintptr, windowIn
 	}
 	
 	// Capture the now-visible window
-	buffer, err := e.captureVisibleWindow(handle, windowInfo, options)
-	
-	// Restore original state
-	if placement != nil {
-		e.setWindowPlacement(handle, placement)
-	} else {
-		showWindow.Call(handle, SW_MINIMIZE)
-	}
-	
-	return buffer, err
+	return e.captureVisibleWindow(handle, windowInfo, options)
 }
 
 // Helper functions
@@ -585,7 +649,7 @@ func (e *WindowsScreenshotEngine) getProcessThreads(pid uint32) ([]uint32, error
 	if snapshot == ^uintptr(0) {
 		return nil, fmt.Errorf("failed to create snapshot")
 	}
-	defer closeHandle.Call(snapshot)
+	defer windows.CloseHandle(windows.Handle(snapshot))
 	
 	var te THREADENTRY32
 	te.dwSize = uint32(unsafe.Sizeof(te))
@@ -612,82 +676,39 @@ func (e *WindowsScreenshotEngine) getProcessThreads(pid uint32) ([]uint32, error
 func (e *WindowsScreenshotEngine) enumerateThreadWindows(threadID uint32) ([]types.WindowInfo, error) {
 	var windows []types.WindowInfo
 	
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
+	err := win32.EnumThreadWindows(threadID, func(hwnd uintptr) bool {
 		if info, err := e.getWindowInfo(hwnd); err == nil {
 			windows = append(windows, *info)
 		}
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
 	
-	enumThreadWindows.Call(uintptr(threadID), callback, 0)
-	
-	return windows, nil
+	return windows, err
 }
 
 func (e *WindowsScreenshotEngine) findWindow(className, windowName string) (uintptr, error) {
-	var classPtr, namePtr *uint16
-	
-	if className != "" {
-		var err error
-		classPtr, err = syscall.UTF16PtrFromString(className)
-		if err != nil {
-			return 0, err
-		}
-	}
-	
-	if windowName != "" {
-		var err error
-		namePtr, err = syscall.UTF16PtrFromString(windowName)
-		if err != nil {
-			return 0, err
-		}
-	}
-	
-	handle, _, _ := findWindowW.Call(
-		uintptr(unsafe.Pointer(classPtr)),
-		uintptr(unsafe.Pointer(namePtr)),
-	)
-	
-	if handle == 0 {
-		return 0, fmt.Errorf("window not found")
+	handle, err := win32.FindWindow(className, windowName)
+	if err != nil {
+		return 0, fmt.Errorf("window not found: %w", err)
 	}
-	
 	return handle, nil
 }
 
 func (e *WindowsScreenshotEngine) findChildWindow(parent uintptr, className, windowName string) (uintptr, error) {
 	var found uintptr
 	
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		if className != "" {
-			classBuf := make([]uint16, 256)
-			getClassName.Call(hwnd, uintptr(unsafe.Pointer(&classBuf[0])), 256)
-			actualClass := syscall.UTF16ToString(classBuf)
-			if actualClass != className {
-				return 1 // Continue
-			}
+	win32.EnumChildWindows(parent, func(hwnd uintptr) bool {
+		if className != "" && win32.GetClassName(hwnd) != className {
+			return true // Continue
 		}
-		
-		if windowName != "" {
-			titleLen, _, _ := getWindowTextLengthW.Call(hwnd)
-			if titleLen > 0 {
-				titleBuf := make([]uint16, titleLen+1)
-				getWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&titleBuf[0])), uintptr(len(titleBuf)))
-				actualName := syscall.UTF16ToString(titleBuf)
-				if actualName != windowName {
-					return 1 // Continue
-				}
-			} else if windowName != "" {
-				return 1 // Continue
-			}
+		if windowName != "" && win32.GetWindowText(hwnd) != windowName {
+			return true // Continue
 		}
 		
 		found = hwnd
-		return 0 // Stop enumeration
+		return false // Stop enumeration
 	})
 	
-	enumChildWindows.Call(parent, callback, 0)
-	
 	if found == 0 {
 		return 0, fmt.Errorf("child window not found")
 	}
@@ -695,35 +716,92 @@ func (e *WindowsScreenshotEngine) findChildWindow(parent uintptr, className, win
 	return found, nil
 }
 
+// getTrayProcesses returns the processes owning the icons on a notification area toolbar.
+// The buttons live in explorer's address space, so each TBBUTTON is fetched into a buffer
+// allocated there and read back; its dwData points to explorer's TRAYDATA record, whose
+// first field is the window that owns the icon. Assumes the server and explorer share
+// the same bitness.
 func (e *WindowsScreenshotEngine) getTrayProcesses(toolbarWnd uintptr) []uint32 {
 	var processes []uint32
 	
-	// This would require more complex implementation involving
-	// toolbar button enumeration and process identification
-	// For now, return empty slice as a placeholder
+	explorerPID := win32.WindowProcessID(toolbarWnd)
+	if explorerPID == 0 {
+		return processes
+	}
+	
+	process, err := windows.OpenProcess(windows.PROCESS_VM_OPERATION|windows.PROCESS_VM_READ, false, explorerPID)
+	if err != nil {
+		return processes
+	}
+	defer windows.CloseHandle(process)
+	
+	var button TBBUTTON
+	remote, _, _ := virtualAllocEx.Call(uintptr(process), 0, unsafe.Sizeof(button), MEM_COMMIT|MEM_RESERVE, PAGE_READWRITE)
+	if remote == 0 {
+		return processes
+	}
+	defer virtualFreeEx.Call(uintptr(process), remote, 0, MEM_RELEASE)
+	
+	seen := make(map[uint32]bool)
+	count, _, _ := sendMessage.Call(toolbarWnd, TB_BUTTONCOUNT, 0, 0)
+	for i := uintptr(0); i < count; i++ {
+		if ret, _, _ := sendMessage.Call(toolbarWnd, TB_GETBUTTON, i, remote); ret == 0 {
+			continue
+		}
+		if err := windows.ReadProcessMemory(process, remote, (*byte)(unsafe.Pointer(&button)), unsafe.Sizeof(button), nil); err != nil || button.Data == 0 {
+			continue
+		}
+		
+		var owner uintptr
+		if err := windows.ReadProcessMemory(process, button.Data, (*byte)(unsafe.Pointer(&owner)), unsafe.Sizeof(owner), nil); err != nil || owner == 0 {
+			continue
+		}
+		
+		pid := win32.WindowProcessID(owner)
+		if pid != 0 && !seen[pid] {
+			seen[pid] = true
+			processes = append(processes, pid)
+		}
+	}
 	
 	return processes
 }
 
 func (e *WindowsScreenshotEngine) findProcessByName(name string) (uint32, error) {
+	pids, err := e.findProcessesByName(name)
+	if err != nil {
+		return 0, err
+	}
+	return pids[0], nil
+}
+
+// findProcessesByName returns the IDs of all processes whose image name matches
+// name case-insensitively; the ".exe" extension is optional
+func (e *WindowsScreenshotEngine) findProcessesByName(name string) ([]uint32, error) {
+	target := strings.ToLower(name)
+	if !strings.HasSuffix(target, ".exe") {
+		target += ".exe"
+	}
+	
 	snapshot, _, _ := createToolhelp32Snapshot.Call(TH32CS_SNAPPROCESS, 0)
 	if snapshot == ^uintptr(0) {
-		return 0, fmt.Errorf("failed to create snapshot")
+		return nil, fmt.Errorf("failed to create snapshot")
 	}
-	defer closeHandle.Call(snapshot)
+	defer windows.CloseHandle(windows.Handle(snapshot))
 	
 	var pe PROCESSENTRY32
 	pe.dwSize = uint32(unsafe.Sizeof(pe))
 	
 	ret, _, _ := process32First.Call(snapshot, uintptr(unsafe.Pointer(&pe)))
 	if ret == 0 {
-		return 0, fmt.Errorf("no processes found")
+		return nil, fmt.Errorf("no processes found")
 	}
 	
+	var pids []uint32
 	for {
 		exeName := syscall.UTF16ToString(pe.szExeFile[:])
-		if exeName == name {
-			return pe.th32ProcessID, nil
+		if strings.ToLower(exeName) == target {
+			pids = append(pids, pe.th32ProcessID)
 		}
 		
 		ret, _, _ := process32Next.Call(snapshot, uintptr(unsafe.Pointer(&pe)))
@@ -732,17 +810,10 @@ func (e *WindowsScreenshotEngine) findProcessByName(name string) (uint32, error)
 		}
 	}
 	
-	return 0, fmt.Errorf("process not found: %s", name)
-}
-
-func (e *WindowsScreenshotEngine) getWindowPlacement(handle uintptr) (*windowPlacement, error) {
-	// Implementation would use GetWindowPlacement
-	return nil, nil
-}
-
-func (e *WindowsScreenshotEngine) setWindowPlacement(handle uintptr, placement *windowPlacement) error {
-	// Implementation would use SetWindowPlacement
-	return nil
+	if len(pids) == 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("process not found: %s", name), nil)
+	}
+	return pids, nil
 }
 
 func (e *WindowsScreenshotEngine) deduplicateWindows(windows []types.WindowInfo) []types.WindowInfo {
@@ -772,7 +843,3 @@ func (e *WindowsScreenshotEngine) deduplicateMethods(methods []types.CaptureMeth
 	
 	return result
 }
-
-type windowPlacement struct {
-	// Placeholder for actual WINDOWPLACEMENT structure
-}
\ No newline at end of file
@@ -0,0 +1,71 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// SetWindowDisplayAffinity values
+const (
+	WDA_NONE               = 0x00
+	WDA_MONITOR            = 0x01
+	WDA_EXCLUDEFROMCAPTURE = 0x11
+)
+
+// displayAffinity reports the display affinity a window set to block capture, or ""
+// if it allows capture
+func displayAffinity(handle uintptr) string {
+	affinity, err := win32.GetWindowDisplayAffinity(handle)
+	if err != nil {
+		return ""
+	}
+
+	switch affinity {
+	case WDA_MONITOR:
+		return types.DisplayAffinityMonitor
+	case WDA_EXCLUDEFROMCAPTURE:
+		return types.DisplayAffinityExcludeFromCapture
+	default:
+		return ""
+	}
+}
+
+// SetExcludedWindowPolicy sets how windows that block capture via their display affinity
+// are handled; the default is to fail without trying. It may change while captures run.
+func (e *WindowsScreenshotEngine) SetExcludedWindowPolicy(policy types.ExcludedWindowPolicy) {
+	e.excludedPolicy.Store(policy)
+}
+
+// captureExcludedWindow captures a window whose display affinity blocks capture. Every
+// regular method returns a black image for such windows, so unless the policy allows
+// the DWM thumbnail workaround the capture fails with ErrCaptureExcluded.
+func (e *WindowsScreenshotEngine) captureExcludedWindow(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	excluded := types.NewCaptureError(types.ErrCaptureExcluded,
+		fmt.Sprintf("window %d is excluded from capture (display affinity %s)", handle, windowInfo.DisplayAffinity), nil)
+	if policy, _ := e.excludedPolicy.Load().(types.ExcludedWindowPolicy); policy != types.ExcludedWindowDWMThumbnail {
+		return nil, excluded
+	}
+
+	start := time.Now()
+	buffer, err := e.captureDWMThumbnail(handle, windowInfo, options)
+	if err == nil && isBlackFrame(buffer) {
+		err = fmt.Errorf("DWM thumbnail was blacked out")
+	}
+	if err != nil {
+		excluded.Err = err
+		return nil, excluded
+	}
+
+	buffer.CaptureMethod = types.CaptureDWMThumbnail
+	buffer.Attempts = []types.CaptureAttempt{{
+		Method:   types.CaptureDWMThumbnail,
+		Duration: time.Since(start),
+		Success:  true,
+	}}
+	return buffer, nil
+}
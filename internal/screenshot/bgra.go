@@ -0,0 +1,71 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// BGRA is an image over a capture's BGRA pixels in place, rows Stride bytes apart as
+// GDI and DXGI leave them, so the JPEG encoder reads the capture without copying it into
+// an image.RGBA first
+type BGRA struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+}
+
+// NewBGRA wraps the pixels of a BGRA32 capture without copying them
+func NewBGRA(buffer *types.ScreenshotBuffer) (*BGRA, error) {
+	if buffer.Format != "BGRA32" {
+		return nil, fmt.Errorf("not a BGRA32 capture: %s", buffer.Format)
+	}
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+	if buffer.Width <= 0 || buffer.Height <= 0 || stride < buffer.Width*4 ||
+		len(buffer.Data) < stride*(buffer.Height-1)+buffer.Width*4 {
+		return nil, fmt.Errorf("capture data doesn't match its %dx%d size", buffer.Width, buffer.Height)
+	}
+	return &BGRA{Pix: buffer.Data, Stride: stride, Rect: image.Rect(0, 0, buffer.Width, buffer.Height)}, nil
+}
+
+// ColorModel implements image.Image
+func (b *BGRA) ColorModel() color.Model { return color.RGBAModel }
+
+// Bounds implements image.Image
+func (b *BGRA) Bounds() image.Rectangle { return b.Rect }
+
+// At implements image.Image
+func (b *BGRA) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(b.Rect)) {
+		return color.RGBA{}
+	}
+	p := b.Pix[b.PixOffset(x, y):]
+	return color.RGBA{R: p[2], G: p[1], B: p[0], A: p[3]}
+}
+
+// BGRAPix returns the pixels and the distance between rows, for the JPEG encoder
+func (b *BGRA) BGRAPix() ([]byte, int) { return b.Pix, b.Stride }
+
+// PixOffset returns the index of the first byte of the pixel at (x, y)
+func (b *BGRA) PixOffset(x, y int) int {
+	return (y-b.Rect.Min.Y)*b.Stride + (x-b.Rect.Min.X)*4
+}
+
+// RGBA copies the pixels into an image.RGBA, for encoders and filters that need one
+func (b *BGRA) RGBA() *image.RGBA {
+	rgba := image.NewRGBA(b.Rect)
+	width := b.Rect.Dx() * 4
+	for y := 0; y < b.Rect.Dy(); y++ {
+		src := b.Pix[y*b.Stride : y*b.Stride+width]
+		dst := rgba.Pix[y*rgba.Stride : y*rgba.Stride+width]
+		for i := 0; i < width; i += 4 {
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = src[i+2], src[i+1], src[i], src[i+3]
+		}
+	}
+	return rgba
+}
@@ -0,0 +1,82 @@
+package screenshot
+
+import (
+	"bytes"
+	stdjpeg "image/jpeg"
+	"math/rand"
+	"testing"
+
+	"github.com/screenshot-mcp-server/internal/jpeg"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomCapture returns a BGRA32 capture of random pixels, with padding bytes after each
+// row when stride is wider than the row
+func randomCapture(rng *rand.Rand, width, height, stride int) *types.ScreenshotBuffer {
+	data := make([]byte, stride*height)
+	rng.Read(data)
+	return &types.ScreenshotBuffer{Data: data, Width: width, Height: height, Stride: stride, Format: "BGRA32"}
+}
+
+func TestBGRAJPEGMatchesStandardEncoder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sizes := []struct {
+		name          string
+		width, height int
+		padding       int // Bytes after each row
+	}{
+		{name: "1x1", width: 1, height: 1},
+		{name: "block", width: 8, height: 8},
+		{name: "odd", width: 7, height: 13},
+		{name: "odd wide", width: 33, height: 17},
+		{name: "partial macroblock", width: 17, height: 9},
+		{name: "padded", width: 30, height: 20, padding: 8},
+		{name: "odd padded", width: 31, height: 15, padding: 4},
+		{name: "large padded", width: 129, height: 67, padding: 64},
+	}
+	for _, size := range sizes {
+		capture := randomCapture(rng, size.width, size.height, size.width*4+size.padding)
+		bgra, err := NewBGRA(capture)
+		require.NoError(t, err)
+		rgba := bgra.RGBA()
+
+		for _, quality := range []int{1, 25, 50, 75, 90, 100} {
+			var fork, std bytes.Buffer
+			require.NoError(t, jpeg.Encode(&fork, bgra, &jpeg.Options{Quality: quality}))
+			require.NoError(t, stdjpeg.Encode(&std, rgba, &stdjpeg.Options{Quality: quality}))
+			assert.True(t, bytes.Equal(std.Bytes(), fork.Bytes()), "%s at quality %d differs from image/jpeg", size.name, quality)
+		}
+	}
+}
+
+func TestBGRARGBAHonoursStride(t *testing.T) {
+	capture := &types.ScreenshotBuffer{
+		Data: []byte{
+			1, 2, 3, 4, 5, 6, 7, 8, 0xee, 0xee,
+			9, 10, 11, 12, 13, 14, 15, 16, 0xee, 0xee,
+		},
+		Width: 2, Height: 2, Stride: 10, Format: "BGRA32",
+	}
+	bgra, err := NewBGRA(capture)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{3, 2, 1, 4, 7, 6, 5, 8, 11, 10, 9, 12, 15, 14, 13, 16}, bgra.RGBA().Pix)
+}
+
+func TestNewBGRARejectsMismatchedCaptures(t *testing.T) {
+	tests := map[string]*types.ScreenshotBuffer{
+		"format":       {Data: make([]byte, 16), Width: 2, Height: 2, Format: "RGBA32"},
+		"empty":        {Format: "BGRA32"},
+		"short data":   {Data: make([]byte, 15), Width: 2, Height: 2, Format: "BGRA32"},
+		"short stride": {Data: make([]byte, 16), Width: 2, Height: 2, Stride: 4, Format: "BGRA32"},
+	}
+	for name, capture := range tests {
+		_, err := NewBGRA(capture)
+		assert.Error(t, err, name)
+	}
+
+	// The last row needs no padding
+	_, err := NewBGRA(&types.ScreenshotBuffer{Data: make([]byte, 18), Width: 2, Height: 2, Stride: 10, Format: "BGRA32"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,60 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Black frame heuristic parameters
+const (
+	blackFrameSamples   = 64 // Samples per axis
+	blackFrameThreshold = 16 // Maximum channel value still considered black
+)
+
+// isBlackFrame reports whether a BGRA buffer is uniformly black, which is what
+// GDI capture returns for windows rendered through DirectComposition or the GPU
+// (Electron, Chromium, some UWP apps). Alpha is ignored because such frames are
+// often fully transparent as well.
+func isBlackFrame(buffer *types.ScreenshotBuffer) bool {
+	if buffer == nil || buffer.Width <= 0 || buffer.Height <= 0 {
+		return false
+	}
+
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+
+	stepX := max(buffer.Width/blackFrameSamples, 1)
+	stepY := max(buffer.Height/blackFrameSamples, 1)
+
+	for y := 0; y < buffer.Height; y += stepY {
+		row := y * stride
+		for x := 0; x < buffer.Width; x += stepX {
+			offset := row + x*4
+			if offset+2 >= len(buffer.Data) {
+				return false
+			}
+			if buffer.Data[offset] > blackFrameThreshold ||
+				buffer.Data[offset+1] > blackFrameThreshold ||
+				buffer.Data[offset+2] > blackFrameThreshold {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// escalateBlackFrame retries a capture that came back black with methods that
+// read composed GPU content. The result is flagged BlackFrameDetected if every
+// method still produced a black frame.
+func (e *WindowsScreenshotEngine) escalateBlackFrame(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	methods := []types.CaptureMethod{types.CaptureRenderFullContent}
+	if windowInfo.State != "minimized" && windowInfo.IsVisible {
+		methods = append(methods, types.CaptureDXGI)
+	}
+
+	return e.captureWithMethods(handle, windowInfo, methods, options)
+}
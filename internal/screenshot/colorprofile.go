@@ -0,0 +1,77 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	monitorFromRect = user32.NewProc("MonitorFromRect")
+	getMonitorInfoW = user32.NewProc("GetMonitorInfoW")
+	createDCW       = gdi32.NewProc("CreateDCW")
+	getICMProfileW  = gdi32.NewProc("GetICMProfileW")
+)
+
+const MONITOR_DEFAULTTONEAREST = 2
+
+// MONITORINFOEXW structure
+type MONITORINFOEXW struct {
+	Size    uint32
+	Monitor win32.RECT
+	Work    win32.RECT
+	Flags   uint32
+	Device  [32]uint16
+}
+
+// MonitorColorProfile reads the ICC profile of the monitor showing most of rect, in
+// screen coordinates, and returns it with the path it was read from. It fails with
+// ErrNoColorProfile when the monitor has none.
+func MonitorColorProfile(rect types.Rectangle) ([]byte, string, error) {
+	r := win32.RECT{
+		Left:   int32(rect.X),
+		Top:    int32(rect.Y),
+		Right:  int32(rect.X + rect.Width),
+		Bottom: int32(rect.Y + rect.Height),
+	}
+	monitor, _, _ := monitorFromRect.Call(uintptr(unsafe.Pointer(&r)), MONITOR_DEFAULTTONEAREST)
+	if monitor == 0 {
+		return nil, "", fmt.Errorf("no monitor for %dx%d at %d,%d", rect.Width, rect.Height, rect.X, rect.Y)
+	}
+	info := MONITORINFOEXW{Size: uint32(unsafe.Sizeof(MONITORINFOEXW{}))}
+	if ok, _, callErr := getMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return nil, "", fmt.Errorf("GetMonitorInfo failed: %w", callErr)
+	}
+
+	display, _ := windows.UTF16PtrFromString("DISPLAY")
+	hdc, _, callErr := createDCW.Call(uintptr(unsafe.Pointer(display)), uintptr(unsafe.Pointer(&info.Device[0])), 0, 0)
+	if hdc == 0 {
+		return nil, "", fmt.Errorf("CreateDC failed for %s: %w", windows.UTF16ToString(info.Device[:]), callErr)
+	}
+	adoptTrackedObject(GDIColorProfile, GDIDeviceContext)
+	defer deleteTrackedDC(GDIColorProfile, hdc)
+
+	size := uint32(windows.MAX_PATH)
+	for {
+		path := make([]uint16, size)
+		requested := size
+		ok, _, _ := getICMProfileW.Call(hdc, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&path[0])))
+		if ok != 0 {
+			name := windows.UTF16ToString(path)
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, name, fmt.Errorf("failed to read color profile: %w", err)
+			}
+			return data, name, nil
+		}
+		if size <= requested {
+			return nil, "", ErrNoColorProfile
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package screenshot
+
+import "github.com/screenshot-mcp-server/pkg/types"
+
+// MonitorColorProfile fails: display profiles are only read on Windows, so captures
+// elsewhere are taken to be sRGB
+func MonitorColorProfile(rect types.Rectangle) ([]byte, string, error) {
+	return nil, "", ErrNoColorProfile
+}
@@ -0,0 +1,95 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Inset margins and border
+const (
+	insetMargin = 12 // Distance from the edges of the frame and between insets
+	insetBorder = 2
+)
+
+// insetBorderColor frames insets so they stand out from the window behind them
+var insetBorderColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+// Inset is a capture drawn scaled down into a corner of another, for picture-in-picture
+type Inset struct {
+	Buffer   *types.ScreenshotBuffer
+	Position string  // "top_left", "top_right", "bottom_left" or "bottom_right"
+	Scale    float64 // Width of the inset as a share of the base's width, up to half its height
+}
+
+// ComposeInsets draws insets over base, each in a thin border; insets in the same
+// corner are stacked away from it. The result keeps base's metadata with the composite
+// pixels as RGBA32; base itself is left unchanged.
+func ComposeInsets(base *types.ScreenshotBuffer, insets []Inset) (*types.ScreenshotBuffer, error) {
+	canvas, err := newCanvas(base)
+	if err != nil {
+		return nil, err
+	}
+	processor := NewImageProcessor()
+
+	stacked := make(map[string]int) // Height taken up in each corner
+	for _, inset := range insets {
+		insetImg, err := processor.ToImage(inset.Buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert inset to image: %w", err)
+		}
+		// Tall windows are limited to half the frame's height instead
+		width := int(float64(base.Width) * inset.Scale)
+		height := width * inset.Buffer.Height / max(inset.Buffer.Width, 1)
+		if height > base.Height/2 {
+			width, height = width*(base.Height/2)/height, base.Height/2
+		}
+		if width < 1 || height < 1 {
+			continue
+		}
+		scaled := imaging.Resize(insetImg, width, height, imaging.Linear)
+		framed := scaled.Bounds().Inset(-insetBorder)
+
+		at, err := placement(inset.Position, canvas.Bounds(), framed, insetMargin)
+		if err != nil {
+			return nil, err
+		}
+		switch inset.Position {
+		case "top_left", "top_right":
+			at.Y += stacked[inset.Position]
+		default:
+			at.Y -= stacked[inset.Position]
+		}
+		stacked[inset.Position] += framed.Dy() + insetMargin
+
+		frame := framed.Sub(framed.Min).Add(at)
+		draw.Draw(canvas, frame, image.NewUniform(insetBorderColor), image.Point{}, draw.Src)
+		draw.Draw(canvas, frame.Inset(insetBorder), scaled, image.Point{}, draw.Src)
+	}
+
+	return fromCanvas(base, canvas), nil
+}
+
+// newCanvas copies a capture into an image to draw on
+func newCanvas(buffer *types.ScreenshotBuffer) (*image.RGBA, error) {
+	img, err := NewImageProcessor().ToImage(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert capture to image: %w", err)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, buffer.Width, buffer.Height))
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Src)
+	return canvas, nil
+}
+
+// fromCanvas returns a capture's metadata with the pixels of the canvas drawn on it
+func fromCanvas(buffer *types.ScreenshotBuffer, canvas *image.RGBA) *types.ScreenshotBuffer {
+	drawn := *buffer
+	drawn.Data = canvas.Pix
+	drawn.Stride = canvas.Stride
+	drawn.Format = "RGBA32"
+	return &drawn
+}
@@ -0,0 +1,111 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var (
+	wtsapi32 = win32.Wtsapi32
+
+	wtsQuerySessionInformationW = wtsapi32.NewProc("WTSQuerySessionInformationW")
+	wtsFreeMemory               = wtsapi32.NewProc("WTSFreeMemory")
+	openInputDesktop            = user32.NewProc("OpenInputDesktop")
+	closeDesktop                = user32.NewProc("CloseDesktop")
+	getUserObjectInformationW   = user32.NewProc("GetUserObjectInformationW")
+)
+
+// Session and desktop constants
+const (
+	WTS_CURRENT_SERVER_HANDLE = 0
+	WTS_CURRENT_SESSION       = 0xFFFFFFFF
+
+	// WTS_INFO_CLASS values
+	WTSConnectState  = 8
+	WTSSessionInfoEx = 25
+
+	// WTS_CONNECTSTATE_CLASS values
+	WTSDisconnected = 4
+
+	WTS_SESSIONSTATE_LOCK = 0
+
+	UOI_NAME            = 2
+	DESKTOP_READOBJECTS = 0x0001
+)
+
+// wtsInfoEx is the start of WTSINFOEX with a level 1 payload
+type wtsInfoEx struct {
+	Level        uint32
+	_            uint32 // The level union is 8-byte aligned
+	SessionID    uint32
+	SessionState int32
+	SessionFlags int32
+}
+
+// QueryDesktopState reports whether the interactive desktop of the server's session
+// can be captured. While the session is locked, a UAC prompt is up or a remote
+// session is disconnected, captures only produce black or stale frames.
+func QueryDesktopState() types.DesktopState {
+	var connectState int32
+	if querySessionInformation(WTSConnectState, unsafe.Pointer(&connectState), unsafe.Sizeof(connectState)) &&
+		connectState == WTSDisconnected {
+		return types.DesktopDisconnected
+	}
+
+	var info wtsInfoEx
+	if querySessionInformation(WTSSessionInfoEx, unsafe.Pointer(&info), unsafe.Sizeof(info)) &&
+		info.Level == 1 && info.SessionFlags == WTS_SESSIONSTATE_LOCK {
+		return types.DesktopLocked
+	}
+
+	// The lock screen and UAC prompts switch input to the Winlogon desktop, which
+	// the server isn't allowed to open
+	desktop, _, _ := openInputDesktop.Call(0, 0, DESKTOP_READOBJECTS)
+	if desktop == 0 {
+		return types.DesktopSecure
+	}
+	defer closeDesktop.Call(desktop)
+
+	name := make([]uint16, 64)
+	var needed uint32
+	ret, _, _ := getUserObjectInformationW.Call(desktop, UOI_NAME,
+		uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)*2), uintptr(unsafe.Pointer(&needed)))
+	if ret != 0 && !strings.EqualFold(syscall.UTF16ToString(name), "Default") {
+		return types.DesktopSecure
+	}
+
+	return types.DesktopAvailable
+}
+
+// querySessionInformation copies up to size bytes of a WTS_INFO_CLASS value for the
+// current session into out
+func querySessionInformation(class uintptr, out unsafe.Pointer, size uintptr) bool {
+	var buf uintptr
+	var length uint32
+	ret, _, _ := wtsQuerySessionInformationW.Call(WTS_CURRENT_SERVER_HANDLE, WTS_CURRENT_SESSION, class,
+		uintptr(unsafe.Pointer(&buf)), uintptr(unsafe.Pointer(&length)))
+	if ret == 0 || buf == 0 {
+		return false
+	}
+	defer wtsFreeMemory.Call(buf)
+
+	if uintptr(length) < size {
+		return false
+	}
+	copy(unsafe.Slice((*byte)(out), size), unsafe.Slice((*byte)(unsafe.Pointer(buf)), size))
+	return true
+}
+
+// checkDesktopAvailable fails with a DesktopUnavailableError when captures can't succeed
+func checkDesktopAvailable() error {
+	if state := QueryDesktopState(); state != types.DesktopAvailable {
+		return &types.DesktopUnavailableError{State: state}
+	}
+	return nil
+}
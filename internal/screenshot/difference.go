@@ -0,0 +1,51 @@
+package screenshot
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// FrameDifference returns the share of pixels, 0-1, whose red, green or blue channel
+// differs by more than tolerance between two raw captures. Captures of different sizes
+// differ completely.
+func FrameDifference(a, b *types.ScreenshotBuffer, tolerance int) (float64, error) {
+	if a.Width != b.Width || a.Height != b.Height {
+		return 1, nil
+	}
+	if a.Width == 0 || a.Height == 0 {
+		return 0, nil
+	}
+	ar, ag, ab, err := channelOffsets(a)
+	if err != nil {
+		return 0, err
+	}
+	br, bg, bb, err := channelOffsets(b)
+	if err != nil {
+		return 0, err
+	}
+
+	strideA, strideB := bufferStride(a), bufferStride(b)
+	if len(a.Data) < strideA*(a.Height-1)+a.Width*4 || len(b.Data) < strideB*(b.Height-1)+b.Width*4 {
+		return 0, fmt.Errorf("capture data is shorter than its %dx%d size", a.Width, a.Height)
+	}
+	changed := 0
+	for y := 0; y < a.Height; y++ {
+		rowA, rowB := a.Data[y*strideA:], b.Data[y*strideB:]
+		for x := 0; x < a.Width; x++ {
+			pa, pb := rowA[x*4:], rowB[x*4:]
+			if channelDiff(pa[ar], pb[br]) > tolerance || channelDiff(pa[ag], pb[bg]) > tolerance || channelDiff(pa[ab], pb[bb]) > tolerance {
+				changed++
+			}
+		}
+	}
+	return float64(changed) / float64(a.Width*a.Height), nil
+}
+
+// channelDiff returns the absolute difference of two channel values
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,149 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var (
+	dwmFlush         = dwmapi.NewProc("DwmFlush")
+	getSystemMetrics = user32.NewProc("GetSystemMetrics")
+	setWindowPos     = user32.NewProc("SetWindowPos")
+)
+
+// GetSystemMetrics indexes of the virtual screen, the bounds of every monitor
+const (
+	SM_XVIRTUALSCREEN  = 76
+	SM_CXVIRTUALSCREEN = 78
+
+	SWP_NOMOVE     = 0x0002
+	SWP_NOZORDER   = 0x0004
+	SWP_NOACTIVATE = 0x0010
+)
+
+const (
+	thumbnailHostClass = "ScreenshotMCPThumbnailHost"
+	thumbnailHostTitle = "Screenshot MCP thumbnail host"
+
+	// thumbnailHostMargin is how far past the right edge of the virtual screen the host
+	// window is placed, so it never shows on a monitor
+	thumbnailHostMargin = 100
+
+	// thumbnailPasses is how many compositions a thumbnail gets to show up in its host
+	thumbnailPasses = 3
+)
+
+// thumbnailHostBackground fills the host window, so a host the thumbnail wasn't drawn
+// into is told apart from a window that is black
+var thumbnailHostBackground = color.RGBA{R: 0xFF, G: 0x00, B: 0xFF, A: 0xFF}
+
+// captureDWMThumbnail captures a window through its live DWM thumbnail, which DWM keeps
+// for windows that are minimized, cloaked or covered. Thumbnails are only drawn into
+// windows DWM composes, so the thumbnail is registered onto a layered host window of the
+// window's size, placed off every monitor, and the host is captured with
+// PrintWindow(PW_RENDERFULLCONTENT) once DWM has composed it. A host that still shows only
+// its background fails the capture instead of returning an empty image.
+//
+// The thumbnail has the window's restored size even while it's minimized; a minimized
+// window has no client area to crop to, so it's captured with its frame.
+func (e *WindowsScreenshotEngine) captureDWMThumbnail(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	left, _, _ := getSystemMetrics.Call(SM_XVIRTUALSCREEN)
+	width, _, _ := getSystemMetrics.Call(SM_CXVIRTUALSCREEN)
+	host, err := newToolWindow(toolWindowSpec{
+		class:      thumbnailHostClass,
+		title:      thumbnailHostTitle,
+		exStyle:    WS_EX_LAYERED | WS_EX_TOOLWINDOW | WS_EX_NOACTIVATE | WS_EX_TRANSPARENT,
+		x:          int(int32(left)) + int(int32(width)) + thumbnailHostMargin,
+		y:          0,
+		width:      1,
+		height:     1,
+		background: &thumbnailHostBackground,
+		subsystem:  GDIDWMThumbnail,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail host window: %w", err)
+	}
+	defer host.Close()
+
+	var thumbnail uintptr
+	ret, _, _ := dwmRegisterThumbnail.Call(host.Handle, handle, uintptr(unsafe.Pointer(&thumbnail)))
+	if ret != 0 {
+		return nil, types.NewCaptureError(types.ErrDWMUnavailable, fmt.Sprintf("DwmRegisterThumbnail failed: %x", ret), nil)
+	}
+	defer dwmUnregisterThumbnail.Call(thumbnail)
+
+	var sourceSize SIZE
+	ret, _, _ = dwmQueryThumbnailSourceSize.Call(thumbnail, uintptr(unsafe.Pointer(&sourceSize)))
+	if ret != 0 {
+		return nil, fmt.Errorf("DwmQueryThumbnailSourceSize failed: %x", ret)
+	}
+	source := types.Rectangle{X: windowInfo.Rect.X, Y: windowInfo.Rect.Y, Width: int(sourceSize.Width), Height: int(sourceSize.Height)}
+	clientOnly := !options.IncludeFrame && windowInfo.ClientRect.Width > 0 && windowInfo.ClientRect.Height > 0
+	if clientOnly {
+		source = windowInfo.ClientRect
+	}
+	if source.Width <= 0 || source.Height <= 0 {
+		return nil, fmt.Errorf("invalid window dimensions: %dx%d", source.Width, source.Height)
+	}
+	ret, _, callErr := setWindowPos.Call(host.Handle, 0, 0, 0, uintptr(source.Width), uintptr(source.Height), SWP_NOMOVE|SWP_NOZORDER|SWP_NOACTIVATE)
+	if ret == 0 {
+		return nil, fmt.Errorf("failed to size thumbnail host window: %v", callErr)
+	}
+
+	var props DWM_THUMBNAIL_PROPERTIES
+	props.dwFlags = DWM_TNP_RECTDESTINATION | DWM_TNP_OPACITY | DWM_TNP_VISIBLE | DWM_TNP_SOURCECLIENTAREAONLY
+	props.rcDestination = win32.RECT{Right: int32(source.Width), Bottom: int32(source.Height)}
+	props.opacity = 255
+	props.fVisible = 1
+	if clientOnly {
+		props.fSourceClientAreaOnly = 1
+	}
+	ret, _, _ = dwmUpdateThumbnailProperties.Call(thumbnail, uintptr(unsafe.Pointer(&props)))
+	if ret != 0 {
+		return nil, fmt.Errorf("DwmUpdateThumbnailProperties failed: %x", ret)
+	}
+
+	hostInfo := &types.WindowInfo{Rect: types.Rectangle{Width: source.Width, Height: source.Height}}
+	var buffer *types.ScreenshotBuffer
+	for pass := 0; pass < thumbnailPasses; pass++ {
+		// Wait for DWM to compose the host with the thumbnail in it
+		dwmFlush.Call()
+		buffer, err = e.printWindowWithFlags(host.Handle, hostInfo, PW_RENDERFULLCONTENT|PW_CLIENTONLY)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture thumbnail host window: %w", err)
+		}
+		if !showsOnly(buffer, thumbnailHostBackground) {
+			buffer.Timestamp = time.Now()
+			buffer.SourceRect = source
+			buffer.WindowInfo = *windowInfo
+			return buffer, nil
+		}
+	}
+	return nil, types.NewCaptureError(types.ErrDWMUnavailable, "DWM did not draw the window's thumbnail", nil)
+}
+
+// showsOnly reports whether a BGRA capture is a single color throughout, sampling a
+// grid of pixels as isBlackFrame does
+func showsOnly(buffer *types.ScreenshotBuffer, c color.RGBA) bool {
+	stepX := max(buffer.Width/blackFrameSamples, 1)
+	stepY := max(buffer.Height/blackFrameSamples, 1)
+	for y := 0; y < buffer.Height; y += stepY {
+		for x := 0; x < buffer.Width; x += stepX {
+			offset := y*buffer.Stride + x*4
+			if offset+2 >= len(buffer.Data) {
+				return false
+			}
+			if buffer.Data[offset] != c.B || buffer.Data[offset+1] != c.G || buffer.Data[offset+2] != c.R {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWindowSettle is the time a new window gets to be composed
+const testWindowSettle = 300 * time.Millisecond
+
+// captureTestWindow captures a test window with the DWM thumbnail method alone
+func captureTestWindow(t *testing.T, window *TestWindow) *types.ScreenshotBuffer {
+	engine, err := NewEngine()
+	require.NoError(t, err)
+
+	options := types.DefaultCaptureOptions()
+	options.IncludeFrame = false
+	buffer, err := engine.CaptureWithMethod(window.Handle, types.CaptureDWMThumbnail, options)
+	require.NoError(t, err)
+	return buffer
+}
+
+// assertShowsColor checks the center pixel of a BGRA capture, within a small tolerance
+func assertShowsColor(t *testing.T, buffer *types.ScreenshotBuffer, want color.RGBA) {
+	offset := (buffer.Height/2)*buffer.Stride + (buffer.Width/2)*4
+	require.Less(t, offset+2, len(buffer.Data))
+	got := color.RGBA{R: buffer.Data[offset+2], G: buffer.Data[offset+1], B: buffer.Data[offset]}
+	assert.InDelta(t, want.R, got.R, 8, "red of %v", got)
+	assert.InDelta(t, want.G, got.G, 8, "green of %v", got)
+	assert.InDelta(t, want.B, got.B, 8, "blue of %v", got)
+}
+
+func TestCaptureDWMThumbnailReadsWindowPixels(t *testing.T) {
+	window, err := NewTestWindow(200, 200, 240, 160)
+	require.NoError(t, err)
+	defer window.Close()
+	time.Sleep(testWindowSettle)
+
+	buffer := captureTestWindow(t, window)
+	assert.Equal(t, 240, buffer.Width)
+	assert.Equal(t, 160, buffer.Height)
+	assertShowsColor(t, buffer, TestWindowColor)
+
+	// The host window is gone once the capture returns
+	_, err = win32.FindWindow(thumbnailHostClass, "")
+	assert.Error(t, err)
+}
+
+func TestCaptureDWMThumbnailOfCoveredWindow(t *testing.T) {
+	window, err := NewTestWindow(200, 200, 240, 160)
+	require.NoError(t, err)
+	defer window.Close()
+
+	black := color.RGBA{A: 0xFF}
+	cover, err := newToolWindow(toolWindowSpec{
+		class:      "ScreenshotMCPTestCover",
+		title:      "Screenshot MCP test cover",
+		exStyle:    WS_EX_TOPMOST | WS_EX_TOOLWINDOW,
+		x:          150,
+		y:          150,
+		width:      340,
+		height:     260,
+		background: &black,
+		subsystem:  GDITestWindow,
+	})
+	require.NoError(t, err)
+	defer cover.Close()
+	time.Sleep(testWindowSettle)
+
+	assertShowsColor(t, captureTestWindow(t, window), TestWindowColor)
+}
+
+func TestToolWindowsOfAClassOutliveEachOther(t *testing.T) {
+	first, err := NewTestWindow(200, 200, 240, 160)
+	require.NoError(t, err)
+	second, err := NewTestWindow(260, 260, 240, 160)
+	require.NoError(t, err)
+	defer second.Close()
+
+	// The class's background brush outlives the window that registered it
+	first.Close()
+	time.Sleep(testWindowSettle)
+	assertShowsColor(t, captureTestWindow(t, second), TestWindowColor)
+}
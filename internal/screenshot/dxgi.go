@@ -0,0 +1,371 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+// Direct3D 11 / DXGI desktop duplication
+var (
+	d3d11             = windows.NewLazyDLL("d3d11.dll")
+	d3d11CreateDevice = d3d11.NewProc("D3D11CreateDevice")
+)
+
+// Direct3D / DXGI constants
+const (
+	D3D_DRIVER_TYPE_HARDWARE           = 1
+	D3D11_CREATE_DEVICE_BGRA_SUPPORT   = 0x20
+	D3D11_SDK_VERSION                  = 7
+	D3D11_USAGE_STAGING                = 3
+	D3D11_CPU_ACCESS_READ              = 0x20000
+	D3D11_MAP_READ                     = 1
+	DXGI_ERROR_NOT_FOUND               = 0x887A0002
+	DXGI_ERROR_WAIT_TIMEOUT            = 0x887A0027
+	DXGI_ERROR_ACCESS_LOST             = 0x887A0026
+	DXGI_ERROR_UNSUPPORTED             = 0x887A0004
+	DXGI_ERROR_NOT_CURRENTLY_AVAILABLE = 0x887A0022
+	E_ACCESSDENIED                     = 0x80070005
+
+	dxgiAcquireTimeoutMs = 500
+	dxgiAcquireAttempts  = 4
+	dxgiPresentTimeoutMs = 1000 // Longest wait for an application to present with sync_to_present
+)
+
+// COM vtable indices used for desktop duplication
+const (
+	vtblDXGIDeviceGetAdapter  = 7
+	vtblDXGIAdapterEnumOutput = 7
+	vtblDXGIOutputGetDesc     = 7
+	vtblDXGIOutputDuplicate   = 22
+
+	vtblDuplAcquireNextFrame = 8
+	vtblDuplReleaseFrame     = 14
+
+	vtblD3D11DeviceCreateTexture2D = 5
+	vtblD3D11TextureGetDesc        = 10
+	vtblD3D11ContextMap            = 14
+	vtblD3D11ContextUnmap          = 15
+	vtblD3D11ContextCopyResource   = 47
+)
+
+var (
+	iidIDXGIDevice     = windows.GUID{Data1: 0x54ec77fa, Data2: 0x1377, Data3: 0x44e6, Data4: [8]byte{0x8c, 0x32, 0x88, 0xfd, 0x5f, 0x44, 0xc8, 0x4c}}
+	iidIDXGIOutput1    = windows.GUID{Data1: 0x00cddea8, Data2: 0x939b, Data3: 0x4b83, Data4: [8]byte{0xa3, 0x40, 0xa6, 0x85, 0x22, 0x66, 0x66, 0xcc}}
+	iidID3D11Texture2D = windows.GUID{Data1: 0x6f15aaf2, Data2: 0xd208, Data3: 0x4e89, Data4: [8]byte{0x9a, 0xb4, 0x48, 0x95, 0x35, 0xd3, 0x4f, 0x9c}}
+)
+
+// DXGI_OUTPUT_DESC structure
+type DXGI_OUTPUT_DESC struct {
+	DeviceName         [32]uint16
+	DesktopCoordinates win32.RECT
+	AttachedToDesktop  int32
+	Rotation           uint32
+	Monitor            uintptr
+}
+
+// DXGI_OUTDUPL_FRAME_INFO structure
+type DXGI_OUTDUPL_FRAME_INFO struct {
+	LastPresentTime           int64
+	LastMouseUpdateTime       int64
+	AccumulatedFrames         uint32
+	RectsCoalesced            int32
+	ProtectedContentMaskedOut int32
+	PointerPositionX          int32
+	PointerPositionY          int32
+	PointerVisible            int32
+	TotalMetadataBufferSize   uint32
+	PointerShapeBufferSize    uint32
+}
+
+// D3D11_TEXTURE2D_DESC structure
+type D3D11_TEXTURE2D_DESC struct {
+	Width          uint32
+	Height         uint32
+	MipLevels      uint32
+	ArraySize      uint32
+	Format         uint32
+	SampleCount    uint32
+	SampleQuality  uint32
+	Usage          uint32
+	BindFlags      uint32
+	CPUAccessFlags uint32
+	MiscFlags      uint32
+}
+
+// D3D11_MAPPED_SUBRESOURCE structure
+type D3D11_MAPPED_SUBRESOURCE struct {
+	PData      uintptr
+	RowPitch   uint32
+	DepthPitch uint32
+}
+
+// duplicationError classifies a DuplicateOutput failure. Access is denied while
+// the secure desktop (UAC, lock screen) is shown; duplication is unavailable when
+// too many clients hold it or the output is in a fullscreen exclusive mode.
+func duplicationError(hr uintptr) error {
+	switch uint32(hr) {
+	case E_ACCESSDENIED:
+		return types.NewCaptureError(types.ErrAccessDenied, fmt.Sprintf("DuplicateOutput failed: %x", hr), nil)
+	case DXGI_ERROR_UNSUPPORTED, DXGI_ERROR_NOT_CURRENTLY_AVAILABLE:
+		return types.NewCaptureError(types.ErrDWMUnavailable, fmt.Sprintf("DuplicateOutput failed: %x", hr), nil)
+	}
+	return fmt.Errorf("DuplicateOutput failed: %x", hr)
+}
+
+// captureDXGI grabs the desktop image through DXGI desktop duplication and crops
+// it to the window. It sees exactly what the compositor presents, including
+// GPU-rendered content, but also any windows overlapping the target.
+func (e *WindowsScreenshotEngine) captureDXGI(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	rect := windowInfo.Rect
+	if !options.IncludeFrame && options.Region == nil {
+		// ClientRect is client-relative; locate the client area on screen
+		origin := win32.POINT{}
+		clientToScreen.Call(handle, uintptr(unsafe.Pointer(&origin)))
+		rect = types.Rectangle{
+			X:      int(origin.X),
+			Y:      int(origin.Y),
+			Width:  windowInfo.ClientRect.Width,
+			Height: windowInfo.ClientRect.Height,
+		}
+	}
+	if options.Region != nil {
+		rect = types.Rectangle{
+			X:      rect.X + options.Region.X,
+			Y:      rect.Y + options.Region.Y,
+			Width:  options.Region.Width,
+			Height: options.Region.Height,
+		}
+	}
+	if rect.Width <= 0 || rect.Height <= 0 {
+		return nil, fmt.Errorf("invalid capture dimensions: %dx%d", rect.Width, rect.Height)
+	}
+
+	return duplicateDesktop(rect, false, options.SyncToPresent, windowInfo)
+}
+
+// captureGame duplicates the whole monitor a full-screen window is on. Games in
+// exclusive full-screen mode present straight to the display, so methods that ask the
+// window to draw itself return black or stale frames, and the window rectangle needn't
+// match the display mode the game set. A region still crops to the window.
+func (e *WindowsScreenshotEngine) captureGame(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if options.Region != nil {
+		return e.captureDXGI(handle, windowInfo, options)
+	}
+	rect := windowInfo.Rect
+	if rect.Width <= 0 || rect.Height <= 0 {
+		return nil, fmt.Errorf("invalid window dimensions: %dx%d", rect.Width, rect.Height)
+	}
+	return duplicateDesktop(rect, true, options.SyncToPresent, windowInfo)
+}
+
+// duplicateDesktop grabs the output containing the center of rect through desktop
+// duplication and returns rect clipped to it, or the whole output. With sync, it waits
+// for the next frame an application presents, falling back to the current image when
+// none is presented within dxgiPresentTimeoutMs.
+func duplicateDesktop(rect types.Rectangle, whole, sync bool, windowInfo *types.WindowInfo) (*types.ScreenshotBuffer, error) {
+	var device, context uintptr
+	var featureLevel uint32
+	hr, _, _ := d3d11CreateDevice.Call(
+		0, D3D_DRIVER_TYPE_HARDWARE, 0, D3D11_CREATE_DEVICE_BGRA_SUPPORT,
+		0, 0, D3D11_SDK_VERSION,
+		uintptr(unsafe.Pointer(&device)), uintptr(unsafe.Pointer(&featureLevel)), uintptr(unsafe.Pointer(&context)),
+	)
+	if win32.Failed(hr) {
+		return nil, fmt.Errorf("D3D11CreateDevice failed: %x", hr)
+	}
+	defer win32.ComRelease(device)
+	defer win32.ComRelease(context)
+
+	var dxgiDevice uintptr
+	if hr := win32.ComCall(device, win32.VtblQueryInterface, uintptr(unsafe.Pointer(&iidIDXGIDevice)), uintptr(unsafe.Pointer(&dxgiDevice))); win32.Failed(hr) {
+		return nil, fmt.Errorf("failed to query IDXGIDevice: %x", hr)
+	}
+	defer win32.ComRelease(dxgiDevice)
+
+	var adapter uintptr
+	if hr := win32.ComCall(dxgiDevice, vtblDXGIDeviceGetAdapter, uintptr(unsafe.Pointer(&adapter))); win32.Failed(hr) {
+		return nil, fmt.Errorf("failed to get DXGI adapter: %x", hr)
+	}
+	defer win32.ComRelease(adapter)
+
+	output, desc, err := findDXGIOutput(adapter, rect)
+	if err != nil {
+		return nil, err
+	}
+	defer win32.ComRelease(output)
+
+	var output1 uintptr
+	if hr := win32.ComCall(output, win32.VtblQueryInterface, uintptr(unsafe.Pointer(&iidIDXGIOutput1)), uintptr(unsafe.Pointer(&output1))); win32.Failed(hr) {
+		return nil, types.NewCaptureError(types.ErrDWMUnavailable, fmt.Sprintf("desktop duplication not supported: %x", hr), nil)
+	}
+	defer win32.ComRelease(output1)
+
+	var duplication uintptr
+	if hr := win32.ComCall(output1, vtblDXGIOutputDuplicate, device, uintptr(unsafe.Pointer(&duplication))); win32.Failed(hr) {
+		return nil, duplicationError(hr)
+	}
+	defer win32.ComRelease(duplication)
+
+	// The first acquired frame may not contain an image yet
+	var resource uintptr
+	var frameInfo DXGI_OUTDUPL_FRAME_INFO
+	for attempt := 0; attempt < dxgiAcquireAttempts; attempt++ {
+		hr := win32.ComCall(duplication, vtblDuplAcquireNextFrame, dxgiAcquireTimeoutMs, uintptr(unsafe.Pointer(&frameInfo)), uintptr(unsafe.Pointer(&resource)))
+		if uint32(hr) == DXGI_ERROR_WAIT_TIMEOUT {
+			continue
+		}
+		if uint32(hr) == DXGI_ERROR_ACCESS_LOST {
+			return nil, fmt.Errorf("desktop duplication lost, the display mode changed: %x", hr)
+		}
+		if win32.Failed(hr) {
+			return nil, fmt.Errorf("AcquireNextFrame failed: %x", hr)
+		}
+		if frameInfo.LastPresentTime != 0 {
+			break
+		}
+		win32.ComRelease(resource)
+		resource = 0
+		win32.ComCall(duplication, vtblDuplReleaseFrame)
+	}
+	if resource == 0 {
+		return nil, types.NewCaptureError(types.ErrTimeout, "no desktop frame available", nil)
+	}
+
+	// The first frame is the image already on screen; the next one with a present time
+	// is the frame an application presents next
+	if sync {
+		win32.ComRelease(resource)
+		resource = 0
+		win32.ComCall(duplication, vtblDuplReleaseFrame)
+		for deadline := time.Now().Add(dxgiPresentTimeoutMs * time.Millisecond); resource == 0 && time.Now().Before(deadline); {
+			hr := win32.ComCall(duplication, vtblDuplAcquireNextFrame, uintptr(time.Until(deadline).Milliseconds()), uintptr(unsafe.Pointer(&frameInfo)), uintptr(unsafe.Pointer(&resource)))
+			if uint32(hr) == DXGI_ERROR_WAIT_TIMEOUT {
+				break
+			}
+			if win32.Failed(hr) {
+				return nil, fmt.Errorf("AcquireNextFrame failed: %x", hr)
+			}
+			if frameInfo.LastPresentTime == 0 {
+				// Only the mouse pointer moved
+				win32.ComRelease(resource)
+				resource = 0
+				win32.ComCall(duplication, vtblDuplReleaseFrame)
+			}
+		}
+		if resource == 0 {
+			return duplicateDesktop(rect, whole, false, windowInfo)
+		}
+	}
+	defer win32.ComCall(duplication, vtblDuplReleaseFrame)
+	defer win32.ComRelease(resource)
+
+	var texture uintptr
+	if hr := win32.ComCall(resource, win32.VtblQueryInterface, uintptr(unsafe.Pointer(&iidID3D11Texture2D)), uintptr(unsafe.Pointer(&texture))); win32.Failed(hr) {
+		return nil, fmt.Errorf("failed to query desktop texture: %x", hr)
+	}
+	defer win32.ComRelease(texture)
+
+	// Copy into a CPU-readable staging texture
+	var textureDesc D3D11_TEXTURE2D_DESC
+	win32.ComCall(texture, vtblD3D11TextureGetDesc, uintptr(unsafe.Pointer(&textureDesc)))
+	textureDesc.MipLevels = 1
+	textureDesc.ArraySize = 1
+	textureDesc.SampleCount = 1
+	textureDesc.SampleQuality = 0
+	textureDesc.Usage = D3D11_USAGE_STAGING
+	textureDesc.BindFlags = 0
+	textureDesc.CPUAccessFlags = D3D11_CPU_ACCESS_READ
+	textureDesc.MiscFlags = 0
+
+	var staging uintptr
+	if hr := win32.ComCall(device, vtblD3D11DeviceCreateTexture2D, uintptr(unsafe.Pointer(&textureDesc)), 0, uintptr(unsafe.Pointer(&staging))); win32.Failed(hr) {
+		return nil, fmt.Errorf("failed to create staging texture: %x", hr)
+	}
+	defer win32.ComRelease(staging)
+
+	win32.ComCall(context, vtblD3D11ContextCopyResource, staging, texture)
+
+	var mapped D3D11_MAPPED_SUBRESOURCE
+	if hr := win32.ComCall(context, vtblD3D11ContextMap, staging, 0, D3D11_MAP_READ, 0, uintptr(unsafe.Pointer(&mapped))); win32.Failed(hr) {
+		return nil, fmt.Errorf("failed to map staging texture: %x", hr)
+	}
+	defer win32.ComCall(context, vtblD3D11ContextUnmap, staging, 0)
+
+	// Clip the window rectangle to the output and copy rows
+	outputRect := types.Rectangle{
+		X:      int(desc.DesktopCoordinates.Left),
+		Y:      int(desc.DesktopCoordinates.Top),
+		Width:  int(desc.DesktopCoordinates.Right - desc.DesktopCoordinates.Left),
+		Height: int(desc.DesktopCoordinates.Bottom - desc.DesktopCoordinates.Top),
+	}
+	clip := rect.ToRect().Intersect(outputRect.ToRect())
+	if whole {
+		clip = outputRect.ToRect()
+	}
+	if clip.Empty() {
+		return nil, fmt.Errorf("window is outside the duplicated output")
+	}
+
+	width, height := clip.Dx(), clip.Dy()
+	pixelData := make([]byte, width*height*4)
+	surface := unsafe.Slice((*byte)(unsafe.Pointer(mapped.PData)), int(mapped.RowPitch)*outputRect.Height)
+
+	for y := 0; y < height; y++ {
+		srcY := clip.Min.Y - outputRect.Y + y
+		srcX := clip.Min.X - outputRect.X
+		src := surface[srcY*int(mapped.RowPitch)+srcX*4:]
+		copy(pixelData[y*width*4:(y+1)*width*4], src[:width*4])
+	}
+
+	buffer := &types.ScreenshotBuffer{
+		Data:       pixelData,
+		Width:      width,
+		Height:     height,
+		Stride:     width * 4,
+		Format:     "BGRA32",
+		DPI:        96,
+		Timestamp:  time.Now(),
+		SourceRect: types.FromRect(clip),
+		WindowInfo: *windowInfo,
+	}
+
+	return buffer, nil
+}
+
+// findDXGIOutput returns the adapter output whose desktop area contains the center of rect
+func findDXGIOutput(adapter uintptr, rect types.Rectangle) (uintptr, *DXGI_OUTPUT_DESC, error) {
+	centerX := int32(rect.X + rect.Width/2)
+	centerY := int32(rect.Y + rect.Height/2)
+
+	for index := uintptr(0); ; index++ {
+		var output uintptr
+		hr := win32.ComCall(adapter, vtblDXGIAdapterEnumOutput, index, uintptr(unsafe.Pointer(&output)))
+		if uint32(hr) == DXGI_ERROR_NOT_FOUND {
+			break
+		}
+		if win32.Failed(hr) {
+			return 0, nil, fmt.Errorf("EnumOutputs failed: %x", hr)
+		}
+
+		var desc DXGI_OUTPUT_DESC
+		win32.ComCall(output, vtblDXGIOutputGetDesc, uintptr(unsafe.Pointer(&desc)))
+
+		coords := desc.DesktopCoordinates
+		if desc.AttachedToDesktop != 0 &&
+			centerX >= coords.Left && centerX < coords.Right &&
+			centerY >= coords.Top && centerY < coords.Bottom {
+			return output, &desc, nil
+		}
+
+		win32.ComRelease(output)
+	}
+
+	return 0, nil, fmt.Errorf("no display output contains the window")
+}
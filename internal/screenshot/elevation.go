@@ -0,0 +1,345 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+// Mandatory integrity level RIDs
+const (
+	SECURITY_MANDATORY_LOW_RID    = 0x1000
+	SECURITY_MANDATORY_MEDIUM_RID = 0x2000
+	SECURITY_MANDATORY_HIGH_RID   = 0x3000
+	SECURITY_MANDATORY_SYSTEM_RID = 0x4000
+)
+
+// ElevatedHelperArg is the command-line flag that runs the server binary as an
+// elevated capture helper; it is followed by the name of the pipe to serve
+const ElevatedHelperArg = "--elevated-helper"
+
+const (
+	helperPipePrefix   = `\\.\pipe\screenshot-mcp-elevated-`
+	helperBufferSize   = 1 << 20
+	helperStartTimeout = 60 * time.Second // Time allowed to answer the UAC prompt
+)
+
+// ownIntegrityLevel is the integrity level of the server process
+var ownIntegrityLevel = sync.OnceValues(func() (uint32, error) {
+	return tokenIntegrityLevel(windows.GetCurrentProcessToken())
+})
+
+// tokenIntegrityLevel returns the mandatory integrity RID of an access token
+func tokenIntegrityLevel(token windows.Token) (uint32, error) {
+	var size uint32
+	windows.GetTokenInformation(token, windows.TokenIntegrityLevel, nil, 0, &size)
+	if size == 0 {
+		return 0, fmt.Errorf("failed to query token integrity level")
+	}
+
+	buf := make([]byte, size)
+	if err := windows.GetTokenInformation(token, windows.TokenIntegrityLevel, &buf[0], size, &size); err != nil {
+		return 0, fmt.Errorf("failed to query token integrity level: %w", err)
+	}
+
+	label := (*windows.Tokenmandatorylabel)(unsafe.Pointer(&buf[0]))
+	sid := label.Label.Sid
+	return sid.SubAuthority(uint32(sid.SubAuthorityCount()) - 1), nil
+}
+
+// processIntegrityLevel returns the mandatory integrity RID of a process
+func processIntegrityLevel(pid uint32) (uint32, error) {
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(process)
+
+	var token windows.Token
+	if err := windows.OpenProcessToken(process, windows.TOKEN_QUERY, &token); err != nil {
+		return 0, err
+	}
+	defer token.Close()
+
+	return tokenIntegrityLevel(token)
+}
+
+// integrityLevelName names an integrity RID as reported in WindowInfo
+func integrityLevelName(rid uint32) string {
+	switch {
+	case rid >= SECURITY_MANDATORY_SYSTEM_RID:
+		return "system"
+	case rid >= SECURITY_MANDATORY_HIGH_RID:
+		return "high"
+	case rid >= SECURITY_MANDATORY_MEDIUM_RID:
+		return "medium"
+	case rid >= SECURITY_MANDATORY_LOW_RID:
+		return "low"
+	default:
+		return "untrusted"
+	}
+}
+
+// requiresElevation reports whether the window's process runs at a higher integrity
+// level than the server. UIPI then blocks the messages PrintWindow and WM_PRINT rely
+// on, and captures come back black or fail outright.
+func requiresElevation(info *types.WindowInfo) bool {
+	own, err := ownIntegrityLevel()
+	if err != nil {
+		return false
+	}
+	target, err := processIntegrityLevel(info.ProcessID)
+	if err != nil {
+		// Even limited queries are refused for protected and system processes
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	return target > own
+}
+
+// elevationRequired reports a capture that failed because the target is elevated
+func elevationRequired(info *types.WindowInfo, err error) error {
+	level := info.IntegrityLevel
+	if level == "" {
+		level = "a higher"
+	}
+	own := "lower"
+	if rid, ownErr := ownIntegrityLevel(); ownErr == nil {
+		own = integrityLevelName(rid)
+	}
+	return types.NewCaptureError(types.ErrElevationRequired, fmt.Sprintf(
+		"window %d belongs to process %d running at %s integrity, but the server runs at %s integrity; run the server elevated or enable the elevated helper",
+		info.Handle, info.ProcessID, level, own), err)
+}
+
+// helperRequest asks the elevated helper to capture a window
+type helperRequest struct {
+	Handle  uintptr               `json:"handle"`
+	Options *types.CaptureOptions `json:"options"`
+}
+
+// helperResponse carries the helper's capture, or the error it failed with
+type helperResponse struct {
+	Buffer *types.ScreenshotBuffer `json:"buffer,omitempty"`
+	Data   []byte                  `json:"data,omitempty"` // Buffer.Data, which the buffer doesn't serialize
+	Error  string                  `json:"error,omitempty"`
+	Code   types.ErrorCode         `json:"code,omitempty"`
+}
+
+// ElevatedHelper captures windows of elevated processes through a copy of the server
+// binary running with administrator rights. The helper is launched on first use, which
+// shows a UAC prompt, so it only runs once the user has consented. Requests travel over
+// a local named pipe that only an elevated process is accepted on.
+type ElevatedHelper struct {
+	mu      sync.Mutex
+	pipe    *os.File
+	encoder *json.Encoder
+	decoder *json.Decoder
+}
+
+// NewElevatedHelper creates a helper client; the helper process starts on first capture
+func NewElevatedHelper() *ElevatedHelper {
+	return &ElevatedHelper{}
+}
+
+// Capture captures a window in the elevated helper, starting it if necessary
+func (h *ElevatedHelper) Capture(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pipe == nil {
+		if err := h.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp helperResponse
+	err := h.encoder.Encode(helperRequest{Handle: handle, Options: options})
+	if err == nil {
+		err = h.decoder.Decode(&resp)
+	}
+	if err != nil {
+		// The helper exited or the pipe broke; start a new one next time
+		h.closeLocked()
+		return nil, fmt.Errorf("elevated helper failed: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, types.NewCaptureError(resp.Code, "elevated helper: "+resp.Error, nil)
+	}
+	if resp.Buffer == nil {
+		return nil, fmt.Errorf("elevated helper returned no image")
+	}
+	resp.Buffer.Data = resp.Data
+	return resp.Buffer, nil
+}
+
+// Close stops the helper process by closing its pipe
+func (h *ElevatedHelper) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeLocked()
+}
+
+func (h *ElevatedHelper) closeLocked() {
+	if h.pipe != nil {
+		h.pipe.Close()
+		h.pipe = nil
+		h.encoder = nil
+		h.decoder = nil
+	}
+}
+
+// start creates the pipe, launches the helper with the "runas" verb and waits for it
+// to connect
+func (h *ElevatedHelper) start() error {
+	name := fmt.Sprintf("%s%d-%d", helperPipePrefix, os.Getpid(), time.Now().UnixNano())
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	pipe, err := windows.CreateNamedPipe(namePtr,
+		windows.PIPE_ACCESS_DUPLEX|windows.FILE_FLAG_FIRST_PIPE_INSTANCE,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		1, helperBufferSize, helperBufferSize, 0, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create elevated helper pipe: %w", err)
+	}
+
+	if err := launchElevated(name); err != nil {
+		windows.CloseHandle(pipe)
+		return err
+	}
+
+	if err := connectHelperPipe(pipe, name); err != nil {
+		windows.CloseHandle(pipe)
+		return err
+	}
+
+	// Refuse anything but an elevated client, in case another process raced the helper
+	var clientPID uint32
+	if err := windows.GetNamedPipeClientProcessId(pipe, &clientPID); err != nil {
+		windows.CloseHandle(pipe)
+		return fmt.Errorf("failed to identify elevated helper: %w", err)
+	}
+	if level, err := processIntegrityLevel(clientPID); err != nil || level < SECURITY_MANDATORY_HIGH_RID {
+		windows.CloseHandle(pipe)
+		return fmt.Errorf("elevated helper pipe was opened by non-elevated process %d", clientPID)
+	}
+
+	h.pipe = os.NewFile(uintptr(pipe), name)
+	h.encoder = json.NewEncoder(h.pipe)
+	h.decoder = json.NewDecoder(h.pipe)
+	return nil
+}
+
+// launchElevated starts the server binary as a helper for the named pipe, prompting
+// the user for consent
+func launchElevated(pipeName string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate server executable: %w", err)
+	}
+
+	verb, _ := windows.UTF16PtrFromString("runas")
+	file, err := windows.UTF16PtrFromString(executable)
+	if err != nil {
+		return err
+	}
+	args, err := windows.UTF16PtrFromString(ElevatedHelperArg + " " + pipeName)
+	if err != nil {
+		return err
+	}
+
+	if err := windows.ShellExecute(0, verb, file, args, nil, windows.SW_HIDE); err != nil {
+		if errors.Is(err, windows.ERROR_CANCELLED) {
+			return types.NewCaptureError(types.ErrElevationRequired, "elevation was declined", err)
+		}
+		return fmt.Errorf("failed to launch elevated helper: %w", err)
+	}
+	return nil
+}
+
+// connectHelperPipe waits for the helper to connect, giving up after helperStartTimeout
+func connectHelperPipe(pipe windows.Handle, name string) error {
+	done := make(chan error, 1)
+	go func() {
+		err := windows.ConnectNamedPipe(pipe, nil)
+		if errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+			err = nil
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("elevated helper failed to connect: %w", err)
+		}
+		return nil
+	case <-time.After(helperStartTimeout):
+		// Unblock ConnectNamedPipe by connecting to the pipe ourselves
+		if f, err := os.OpenFile(name, os.O_RDWR, 0); err == nil {
+			f.Close()
+		}
+		<-done
+		return types.NewCaptureError(types.ErrElevationRequired,
+			fmt.Sprintf("elevated helper did not start within %s", helperStartTimeout), nil)
+	}
+}
+
+// RunElevatedHelper serves capture requests on the named pipe until the server
+// closes it. It runs in the elevated copy of the server binary.
+func RunElevatedHelper(pipeName string) error {
+	if !strings.HasPrefix(pipeName, helperPipePrefix) {
+		return fmt.Errorf("invalid helper pipe name: %s", pipeName)
+	}
+
+	pipe, err := os.OpenFile(pipeName, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	defer pipe.Close()
+
+	engine, err := NewEngine()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(pipe)
+	decoder := json.NewDecoder(pipe)
+	for {
+		var req helperRequest
+		if err := decoder.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+
+		var resp helperResponse
+		buffer, err := engine.CaptureByHandle(req.Handle, req.Options)
+		if err != nil {
+			resp.Error = err.Error()
+			resp.Code = types.ErrorCodeOf(err)
+		} else {
+			resp.Buffer = buffer
+			resp.Data = buffer.Data
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to send response: %w", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package screenshot
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// ElevatedHelperArg is the command-line flag that runs the server binary as an
+// elevated capture helper; it is followed by the name of the pipe to serve
+const ElevatedHelperArg = "--elevated-helper"
+
+// ElevatedHelper captures windows of elevated processes on Windows. Elsewhere there is
+// nothing to elevate, so every capture fails.
+type ElevatedHelper struct{}
+
+// NewElevatedHelper creates a helper client
+func NewElevatedHelper() *ElevatedHelper {
+	return &ElevatedHelper{}
+}
+
+// Capture fails: elevated capture needs Windows
+func (h *ElevatedHelper) Capture(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return nil, types.NewCaptureError(types.ErrElevationRequired, "the elevated helper is only supported on Windows", nil)
+}
+
+// Close does nothing; no helper process is ever started
+func (h *ElevatedHelper) Close() {}
+
+// RunElevatedHelper fails: the helper only runs on Windows
+func RunElevatedHelper(pipeName string) error {
+	return fmt.Errorf("the elevated helper is only supported on Windows")
+}
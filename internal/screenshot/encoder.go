@@ -2,11 +2,12 @@ package screenshot
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"image"
 	"image/color"
-	"image/jpeg"
+	_ "image/jpeg" // Decoder
 	"image/png"
 	"io"
 	"os"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/screenshot-mcp-server/internal/jpeg"
+	"github.com/screenshot-mcp-server/internal/tracing"
 	"github.com/screenshot-mcp-server/pkg/types"
 )
 
@@ -38,17 +41,33 @@ func (p *ImageProcessor) SetOutputDirectory(dir string) {
 
 // Encode converts a ScreenshotBuffer to the specified format
 func (p *ImageProcessor) Encode(buffer *types.ScreenshotBuffer, format types.ImageFormat, quality int) ([]byte, error) {
+	return p.EncodeContext(context.Background(), buffer, format, quality)
+}
+
+// EncodeContext encodes like Encode, timing the conversion and compression as stages of
+// the span ctx carries, if any
+func (p *ImageProcessor) EncodeContext(ctx context.Context, buffer *types.ScreenshotBuffer, format types.ImageFormat, quality int) ([]byte, error) {
 	if buffer == nil {
 		return nil, fmt.Errorf("buffer cannot be nil")
 	}
 
-	// Convert buffer to image.Image
-	img, err := p.ToImage(buffer)
+	// Convert buffer to image.Image; the JPEG encoder reads BGRA captures in place
+	_, span := tracing.StartIn(ctx, "convert")
+	var img image.Image
+	var err error
+	if format == types.FormatJPEG && buffer.Format == "BGRA32" {
+		img, err = NewBGRA(buffer)
+	} else {
+		img, err = p.ToImage(buffer)
+	}
+	span.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert buffer to image: %w", err)
 	}
 
 	// Encode to bytes
+	_, span = tracing.StartIn(ctx, "compress")
+	defer span.End()
 	var buf bytes.Buffer
 	switch format {
 	case types.FormatPNG:
@@ -63,13 +82,16 @@ func (p *ImageProcessor) Encode(buffer *types.ScreenshotBuffer, format types.Ima
 		// In a production system, you might want to add a BMP encoder library
 		err = png.Encode(&buf, img)
 	default:
-		return nil, fmt.Errorf("unsupported format: %s", format)
+		return nil, types.NewCaptureError(types.ErrUnsupportedFormat, fmt.Sprintf("unsupported format: %s", format), nil)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode image: %w", err)
 	}
 
+	if buffer.ColorProfile != nil {
+		return embedICCProfile(buf.Bytes(), format, buffer.ColorProfile)
+	}
 	return buf.Bytes(), nil
 }
 
@@ -194,7 +216,9 @@ func (p *ImageProcessor) Resize(buffer *types.ScreenshotBuffer, width, height in
 	resized := imaging.Resize(img, width, height, imaging.Lanczos)
 
 	// Convert back to buffer
-	return p.imageToBuffer(resized), nil
+	result := p.imageToBuffer(resized)
+	result.ColorProfile = buffer.ColorProfile
+	return result, nil
 }
 
 // Crop crops the image buffer to the specified rectangle
@@ -219,7 +243,9 @@ func (p *ImageProcessor) Crop(buffer *types.ScreenshotBuffer, rect types.Rectang
 	cropped := imaging.Crop(img, cropRect)
 
 	// Convert back to buffer
-	return p.imageToBuffer(cropped), nil
+	result := p.imageToBuffer(cropped)
+	result.ColorProfile = buffer.ColorProfile
+	return result, nil
 }
 
 // ToImage converts a ScreenshotBuffer to image.Image
@@ -233,7 +259,11 @@ func (p *ImageProcessor) ToImage(buffer *types.ScreenshotBuffer) (image.Image, e
 	switch buffer.Format {
 	case "BGRA32":
 		// Windows screenshots are typically BGRA
-		img = p.bgraToRGBA(buffer)
+		bgra, err := NewBGRA(buffer)
+		if err != nil {
+			return nil, err
+		}
+		img = bgra.RGBA()
 	case "RGBA32":
 		// Create RGBA image
 		rgba := &image.RGBA{
@@ -256,25 +286,6 @@ func (p *ImageProcessor) ToImage(buffer *types.ScreenshotBuffer) (image.Image, e
 	return img, nil
 }
 
-// bgraToRGBA converts BGRA data to RGBA format
-func (p *ImageProcessor) bgraToRGBA(buffer *types.ScreenshotBuffer) image.Image {
-	// Create new RGBA image
-	rgba := image.NewRGBA(image.Rect(0, 0, buffer.Width, buffer.Height))
-	
-	// Convert BGRA to RGBA
-	for i := 0; i < len(buffer.Data); i += 4 {
-		if i+3 < len(buffer.Data) {
-			// BGRA -> RGBA: swap B and R channels
-			rgba.Pix[i] = buffer.Data[i+2]   // R = B
-			rgba.Pix[i+1] = buffer.Data[i+1] // G = G
-			rgba.Pix[i+2] = buffer.Data[i]   // B = R
-			rgba.Pix[i+3] = buffer.Data[i+3] // A = A
-		}
-	}
-	
-	return rgba
-}
-
 // imageToBuffer converts an image.Image back to ScreenshotBuffer
 func (p *ImageProcessor) imageToBuffer(img image.Image) *types.ScreenshotBuffer {
 	bounds := img.Bounds()
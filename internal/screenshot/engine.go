@@ -1,41 +1,37 @@
+//go:build windows
+
 package screenshot
 
 import (
+	"errors"
 	"fmt"
 	"runtime"
-	"syscall"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/internal/window"
 	"github.com/screenshot-mcp-server/pkg/types"
 	"golang.org/x/sys/windows"
 )
 
 var (
 	// Windows API DLLs
-	user32    = windows.NewLazyDLL("user32.dll")
-	gdi32     = windows.NewLazyDLL("gdi32.dll")
-	dwmapi    = windows.NewLazyDLL("dwmapi.dll")
-	shcore    = windows.NewLazyDLL("shcore.dll")
-	
-	// User32 functions
-	findWindowW           = user32.NewProc("FindWindowW")
-	getWindowTextW        = user32.NewProc("GetWindowTextW")
-	getWindowTextLengthW  = user32.NewProc("GetWindowTextLengthW")
-	getWindowRect         = user32.NewProc("GetWindowRect")
-	getClientRect         = user32.NewProc("GetClientRect")
+	user32    = win32.User32
+	kernel32  = win32.Kernel32
+	gdi32     = win32.Gdi32
+	dwmapi    = win32.Dwmapi
+	shcore    = win32.Shcore
+	shell32   = win32.Shell32
+	
+	// User32 functions; window queries go through the win32 package
 	getWindowDC           = user32.NewProc("GetWindowDC")
 	getDC                 = user32.NewProc("GetDC")
 	releaseDC             = user32.NewProc("ReleaseDC")
 	getDesktopWindow      = user32.NewProc("GetDesktopWindow")
 	printWindow           = user32.NewProc("PrintWindow")
-	isWindowVisible       = user32.NewProc("IsWindowVisible")
-	isIconic              = user32.NewProc("IsIconic")
-	showWindow            = user32.NewProc("ShowWindow")
 	setProcessDPIAware    = user32.NewProc("SetProcessDPIAware")
-	getWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
-	enumWindows           = user32.NewProc("EnumWindows")
-	getClassName          = user32.NewProc("GetClassNameW")
 	
 	// GDI32 functions
 	createCompatibleDC    = gdi32.NewProc("CreateCompatibleDC")
@@ -49,7 +45,6 @@ var (
 	getDeviceCaps         = gdi32.NewProc("GetDeviceCaps")
 	
 	// DWM functions
-	dwmGetWindowAttribute = dwmapi.NewProc("DwmGetWindowAttribute")
 	dwmIsCompositionEnabled = dwmapi.NewProc("DwmIsCompositionEnabled")
 	
 	// ShCore functions (for DPI awareness)
@@ -64,20 +59,12 @@ const (
 	BI_RGB              = 0
 	PW_CLIENTONLY       = 1
 	PW_RENDERFULLCONTENT = 2
-	SW_RESTORE          = 9
-	SW_SHOW             = 5
 	LOGPIXELSX          = 88
 	LOGPIXELSY          = 90
-	DWMWA_EXTENDED_FRAME_BOUNDS = 9
 	PROCESS_DPI_AWARE   = 1
 	MDT_EFFECTIVE_DPI   = 0
 )
 
-// RECT structure for Windows API
-type RECT struct {
-	Left, Top, Right, Bottom int32
-}
-
 // BITMAPINFOHEADER structure
 type BITMAPINFOHEADER struct {
 	Size          uint32
@@ -102,11 +89,14 @@ type BITMAPINFO struct {
 // WindowsScreenshotEngine implements the ScreenshotEngine interface
 type WindowsScreenshotEngine struct {
 	dpiAware bool
+	helper   *ElevatedHelper // Captures elevated windows when set
+	excludedPolicy atomic.Value // types.ExcludedWindowPolicy: handling of windows that block capture
+	ranking  *MethodRanking // Orders fallbacks by how methods fared on each window class
 }
 
 // NewEngine creates a new Windows screenshot engine
 func NewEngine() (*WindowsScreenshotEngine, error) {
-	engine := &WindowsScreenshotEngine{}
+	engine := &WindowsScreenshotEngine{ranking: NewMethodRanking()}
 	
 	// Enable DPI awareness
 	if err := engine.enableDPIAwareness(); err != nil {
@@ -116,6 +106,20 @@ func NewEngine() (*WindowsScreenshotEngine, error) {
 	return engine, nil
 }
 
+// SetElevatedHelper routes captures of windows owned by elevated processes through helper
+func (e *WindowsScreenshotEngine) SetElevatedHelper(helper *ElevatedHelper) {
+	e.helper = helper
+}
+
+// win32Failure describes a failed Win32 call, classifying access-denied failures
+// (elevated targets, secure desktop) as ErrAccessDenied
+func win32Failure(message string, callErr error) error {
+	if errors.Is(callErr, windows.ERROR_ACCESS_DENIED) {
+		return types.NewCaptureError(types.ErrAccessDenied, message, callErr)
+	}
+	return fmt.Errorf("%s", message)
+}
+
 // enableDPIAwareness enables DPI awareness for the process
 func (e *WindowsScreenshotEngine) enableDPIAwareness() error {
 	// Try SetProcessDpiAwareness first (Windows 8.1+)
@@ -144,24 +148,119 @@ func (e *WindowsScreenshotEngine) CaptureByHandle(handle uintptr, options *types
 	if options == nil {
 		options = types.DefaultCaptureOptions()
 	}
+	if options.IncludeOwnedWindows {
+		return e.captureWindowStack(handle, options)
+	}
 	
 	startTime := time.Now()
 	
+	if !win32.IsWindow(handle) {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d does not exist", handle), nil)
+	}
+	
+	// A locked or secure desktop only yields black frames
+	if err := checkDesktopAvailable(); err != nil {
+		return nil, err
+	}
+	
 	// Get window information
 	windowInfo, err := e.getWindowInfo(handle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get window info: %w", err)
 	}
 	
+	// UIPI blocks most capture paths into windows of higher-integrity processes
+	elevated := requiresElevation(windowInfo)
+	if elevated && e.helper != nil {
+		return e.helper.Capture(handle, options)
+	}
+	
+	// Capture paths expect regions relative to the window rectangle
+	if options.Region != nil {
+		region, err := e.resolveRegion(handle, windowInfo, options)
+		if err != nil {
+			return nil, err
+		}
+		resolved := *options
+		resolved.Region = region
+		resolved.RegionRelativeTo = types.RegionRelativeToWindow
+		options = &resolved
+	}
+	
+	// Windows on another virtual desktop are cloaked; only methods that make the window
+	// render itself can capture them without switching desktops
+	if windowInfo.OnCurrentDesktop != nil && !*windowInfo.OnCurrentDesktop {
+		if !options.CaptureOtherDesktops {
+			return nil, types.NewCaptureError(types.ErrOtherDesktop, fmt.Sprintf(
+				"window %d is on virtual desktop %s; set capture_other_desktops to capture it without switching",
+				handle, windowInfo.VirtualDesktopID), nil)
+		}
+		if options.PreferredMethod == "" || options.PreferredMethod == types.CaptureAuto {
+			offDesktop := *options
+			offDesktop.PreferredMethod = types.CaptureRenderFullContent
+			offDesktop.FallbackMethods = []types.CaptureMethod{types.CapturePrintWindow, types.CaptureDWMThumbnail}
+			options = &offDesktop
+		}
+	}
+	
+	// A hung window never answers the messages PrintWindow and WM_PRINT send it, so it is
+	// captured from what was last drawn of it, or its ghost window is captured instead
+	if windowInfo.State == "hung" {
+		if options.CaptureGhost {
+			if ghost := win32.GhostWindow(handle); ghost != 0 {
+				return e.captureGhostWindow(ghost, windowInfo, options)
+			}
+		}
+		if options.PreferredMethod == "" || options.PreferredMethod == types.CaptureAuto {
+			hung := *options
+			hung.PreferredMethod = types.CaptureDWMThumbnail
+			hung.FallbackMethods = []types.CaptureMethod{types.CaptureBitBlt, types.CaptureDXGI}
+			options = &hung
+		}
+	}
+	
+	// Games in exclusive full-screen mode present straight to the display, where only
+	// desktop duplication sees them
+	if windowInfo.Fullscreen == types.FullscreenExclusive &&
+		(options.PreferredMethod == "" || options.PreferredMethod == types.CaptureAuto) {
+		game := *options
+		game.PreferredMethod = types.CaptureGame
+		game.FallbackMethods = []types.CaptureMethod{types.CaptureDXGI, types.CaptureBitBlt}
+		options = &game
+	}
+	
+	// Windows that opted out of capture come back black from every regular method
+	if windowInfo.DisplayAffinity != "" {
+		buffer, err := e.captureExcludedWindow(handle, windowInfo, options)
+		if err != nil {
+			return nil, err
+		}
+		if options.Region != nil {
+			buffer = cropToRegion(buffer, windowInfo, *options.Region)
+		}
+		buffer.Timestamp = time.Now()
+		buffer.WindowInfo = *windowInfo
+		return buffer, nil
+	}
+	
 	// Check if window is minimized and handle accordingly
 	isMinimized := e.isWindowMinimized(handle)
-	wasRestored := false
 	
-	if isMinimized && options.RestoreWindow {
+	if isMinimized && options.RestoreWindow && windowInfo.State != "hung" {
+		// Put the window back exactly as it was, even if the capture fails
+		placement, err := window.GetPlacement(handle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get window placement: %w", err)
+		}
+		defer func() {
+			if err := window.RestorePlacement(handle, placement); err != nil {
+				win32.ShowWindow(handle, win32.SW_SHOWMINNOACTIVE)
+			}
+		}()
+		
 		if err := e.restoreWindow(handle); err != nil {
 			return nil, fmt.Errorf("failed to restore window: %w", err)
 		}
-		wasRestored = true
 		
 		// Wait for window to become visible
 		if options.WaitForVisible > 0 {
@@ -169,24 +268,42 @@ func (e *WindowsScreenshotEngine) CaptureByHandle(handle uintptr, options *types
 		}
 	}
 	
-	// Capture the screenshot
-	var buffer *types.ScreenshotBuffer
-	if isMinimized && options.AllowMinimized && !options.RestoreWindow {
-		// Use DWM/PrintWindow for minimized windows
-		buffer, err = e.captureMinimizedWindow(handle, windowInfo, options)
-	} else {
-		// Use BitBlt for visible windows
-		buffer, err = e.captureVisibleWindow(handle, windowInfo, options)
-	}
-	
+	// Capture the screenshot, retrying with backoff and method rotation
+	buffer, err := e.captureWithRetries(handle, windowInfo, isMinimized, options)
 	if err != nil {
+		if elevated {
+			return nil, elevationRequired(windowInfo, err)
+		}
 		return nil, fmt.Errorf("failed to capture window: %w", err)
 	}
 	
-	// Restore original window state if we changed it
-	if wasRestored && isMinimized {
-		// Minimize the window again
-		showWindow.Call(handle, uintptr(6)) // SW_MINIMIZE
+	// GPU-composited windows (Electron, Chromium) often come back black from GDI
+	if options.DetectBlackFrames && !buffer.BlackFrameDetected && isBlackFrame(buffer) {
+		last := &buffer.Attempts[len(buffer.Attempts)-1]
+		last.Success = false
+		last.Error = "black frame"
+		
+		escalated, escalateErr := e.escalateBlackFrame(handle, windowInfo, options)
+		if escalateErr == nil && !escalated.BlackFrameDetected {
+			escalated.Attempts = append(buffer.Attempts, escalated.Attempts...)
+			buffer = escalated
+		} else if escalated != nil {
+			buffer.Attempts = append(buffer.Attempts, escalated.Attempts...)
+		}
+		buffer.BlackFrameDetected = true
+	}
+	
+	if elevated && isBlackFrame(buffer) {
+		return nil, elevationRequired(windowInfo, nil)
+	}
+	
+	if options.RejectBlackFrames && options.DetectBlackFrames && isBlackFrame(buffer) {
+		return nil, types.NewCaptureError(types.ErrBlackFrame, "every capture method returned a black frame", nil)
+	}
+	
+	// Methods that can't capture a sub-rectangle return the whole window
+	if options.Region != nil {
+		buffer = cropToRegion(buffer, windowInfo, *options.Region)
 	}
 	
 	// Fill in metadata
@@ -199,6 +316,20 @@ func (e *WindowsScreenshotEngine) CaptureByHandle(handle uintptr, options *types
 	return buffer, nil
 }
 
+// captureGhostWindow captures the ghost window Windows shows in place of a hung window,
+// reporting the hung window as the one captured
+func (e *WindowsScreenshotEngine) captureGhostWindow(ghost uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	ghostOptions := *options
+	ghostOptions.CaptureGhost = false
+	buffer, err := e.CaptureByHandle(ghost, &ghostOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture ghost window: %w", err)
+	}
+	buffer.WindowInfo = *windowInfo
+	buffer.GhostWindow = ghost
+	return buffer, nil
+}
+
 // CaptureByTitle captures a screenshot by window title
 func (e *WindowsScreenshotEngine) CaptureByTitle(title string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
 	handle, err := e.findWindowByTitle(title)
@@ -231,6 +362,18 @@ func (e *WindowsScreenshotEngine) CaptureByClassName(className string, options *
 
 // CaptureFullScreen captures the full screen
 func (e *WindowsScreenshotEngine) CaptureFullScreen(monitor int, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if options == nil {
+		options = types.DefaultCaptureOptions()
+	}
+	
+	// With several displays, capture the one asked for rather than the primary
+	if monitors, err := e.Monitors(); err == nil && len(monitors) > 1 {
+		if monitor < 0 || monitor >= len(monitors) {
+			return nil, types.NewCaptureError(types.ErrInvalidRequest, fmt.Sprintf("monitor %d not found (%d monitors)", monitor, len(monitors)), nil)
+		}
+		return e.captureMonitor(monitors[monitor], options)
+	}
+	
 	// Get desktop window handle
 	desktopHandle, _, _ := getDesktopWindow.Call()
 	if desktopHandle == 0 {
@@ -240,25 +383,60 @@ func (e *WindowsScreenshotEngine) CaptureFullScreen(monitor int, options *types.
 	return e.CaptureByHandle(desktopHandle, options)
 }
 
-// captureVisibleWindow captures a visible window using BitBlt
-func (e *WindowsScreenshotEngine) captureVisibleWindow(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
-	// Get window device context
-	var hdc uintptr
-	if options.IncludeFrame {
-		hdc, _, _ = getWindowDC.Call(handle)
-	} else {
-		hdc, _, _ = getDC.Call(handle)
+// captureMonitor captures one display from the screen DC, which uses the virtual
+// screen coordinates monitor rectangles are in
+func (e *WindowsScreenshotEngine) captureMonitor(monitor types.MonitorInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if err := checkDesktopAvailable(); err != nil {
+		return nil, err
+	}
+	
+	// A region is relative to the monitor
+	rect := monitor.Rect
+	if options.Region != nil {
+		clip := types.Rectangle{
+			X:      rect.X + options.Region.X,
+			Y:      rect.Y + options.Region.Y,
+			Width:  options.Region.Width,
+			Height: options.Region.Height,
+		}.ToRect().Intersect(rect.ToRect())
+		if clip.Empty() {
+			return nil, fmt.Errorf("region lies outside monitor %d", monitor.Index)
+		}
+		rect = types.FromRect(clip)
+	}
+	
+	start := time.Now()
+	screen := *options
+	screen.Region = &rect
+	buffer, err := e.captureVisibleWindow(0, &types.WindowInfo{Rect: monitor.Rect}, &screen)
+	attempt := types.CaptureAttempt{Method: types.CaptureBitBlt, Duration: time.Since(start), Success: err == nil}
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture monitor %d: %w", monitor.Index, err)
 	}
 	
+	buffer.Timestamp = time.Now()
+	buffer.SourceRect = rect
+	buffer.MonitorInfo = monitor
+	buffer.CaptureMethod = types.CaptureBitBlt
+	buffer.Attempts = []types.CaptureAttempt{attempt}
+	return buffer, nil
+}
+
+// captureVisibleWindow captures a visible window using BitBlt
+func (e *WindowsScreenshotEngine) captureVisibleWindow(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	// Get window device context; regions are relative to the window rectangle
+	hdc, callErr := getTrackedDC(GDIBitBlt, handle, options.IncludeFrame || options.Region != nil)
 	if hdc == 0 {
-		return nil, fmt.Errorf("failed to get window DC")
+		return nil, win32Failure("failed to get window DC", callErr)
 	}
-	defer releaseDC.Call(handle, hdc)
+	defer releaseTrackedDC(GDIBitBlt, handle, hdc)
 	
-	// Determine capture dimensions
+	// Determine capture dimensions and the source offset within the DC
 	var rect types.Rectangle
+	var srcX, srcY int
 	if options.Region != nil {
 		rect = *options.Region
+		srcX, srcY = rect.X, rect.Y
 	} else if options.IncludeFrame {
 		rect = windowInfo.Rect
 	} else {
@@ -270,11 +448,11 @@ func (e *WindowsScreenshotEngine) captureVisibleWindow(handle uintptr, windowInf
 	}
 	
 	// Create compatible DC and bitmap
-	memDC, _, _ := createCompatibleDC.Call(hdc)
+	memDC := createTrackedDC(GDIBitBlt, hdc)
 	if memDC == 0 {
 		return nil, fmt.Errorf("failed to create compatible DC")
 	}
-	defer deleteDC.Call(memDC)
+	defer deleteTrackedDC(GDIBitBlt, memDC)
 	
 	// Create DIB section for direct pixel access
 	var bmi BITMAPINFO
@@ -286,24 +464,24 @@ func (e *WindowsScreenshotEngine) captureVisibleWindow(handle uintptr, windowInf
 	bmi.Header.Compression = BI_RGB
 	
 	var pBits uintptr
-	bitmap, _, _ := createDIBSection.Call(memDC, uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(&pBits)), 0, 0)
+	bitmap := createTrackedDIB(GDIBitBlt, memDC, &bmi, &pBits)
 	if bitmap == 0 {
 		return nil, fmt.Errorf("failed to create DIB section")
 	}
-	defer deleteObject.Call(bitmap)
+	defer deleteTrackedObject(GDIBitBlt, GDIBitmap, bitmap)
 	
 	// Select bitmap into memory DC
 	oldBitmap, _, _ := selectObject.Call(memDC, bitmap)
 	defer selectObject.Call(memDC, oldBitmap)
 	
 	// Copy pixels from window to memory DC
-	ret, _, _ := bitBlt.Call(
+	ret, _, callErr := bitBlt.Call(
 		memDC, 0, 0, uintptr(rect.Width), uintptr(rect.Height),
-		hdc, uintptr(rect.X), uintptr(rect.Y), SRCCOPY,
+		hdc, uintptr(srcX), uintptr(srcY), SRCCOPY,
 	)
 	
 	if ret == 0 {
-		return nil, fmt.Errorf("BitBlt failed")
+		return nil, win32Failure("BitBlt failed", callErr)
 	}
 	
 	// Get DPI information
@@ -338,6 +516,7 @@ func (e *WindowsScreenshotEngine) captureMinimizedWindow(handle uintptr, windowI
 	// Try PrintWindow first
 	buffer, err := e.tryPrintWindow(handle, windowInfo, options)
 	if err == nil {
+		buffer.CaptureMethod = types.CapturePrintWindow
 		return buffer, nil
 	}
 	
@@ -353,8 +532,19 @@ func (e *WindowsScreenshotEngine) captureMinimizedWindow(handle uintptr, windowI
 	return nil, fmt.Errorf("failed to capture minimized window: %w", err)
 }
 
-// tryPrintWindow attempts to use PrintWindow API for off-screen rendering
-func (e *WindowsScreenshotEngine) tryPrintWindow(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+// captureRenderFullContent uses PrintWindow with PW_RENDERFULLCONTENT, which also
+// renders DirectComposition and GPU content that plain PrintWindow returns as black
+func (e *WindowsScreenshotEngine) captureRenderFullContent(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	flags := uintptr(PW_RENDERFULLCONTENT)
+	if !options.IncludeFrame {
+		flags |= PW_CLIENTONLY
+	}
+	
+	return e.printWindowWithFlags(handle, windowInfo, flags)
+}
+
+// printWindowWithFlags renders a window into an off-screen DIB via PrintWindow
+func (e *WindowsScreenshotEngine) printWindowWithFlags(handle uintptr, windowInfo *types.WindowInfo, flags uintptr) (*types.ScreenshotBuffer, error) {
 	// Get window dimensions
 	rect := windowInfo.Rect
 	if rect.Width <= 0 || rect.Height <= 0 {
@@ -362,18 +552,18 @@ func (e *WindowsScreenshotEngine) tryPrintWindow(handle uintptr, windowInfo *typ
 	}
 	
 	// Create device context
-	screenDC, _, _ := getDC.Call(0)
+	screenDC, _ := getTrackedDC(GDIPrintWindow, 0, false)
 	if screenDC == 0 {
 		return nil, fmt.Errorf("failed to get screen DC")
 	}
-	defer releaseDC.Call(0, screenDC)
+	defer releaseTrackedDC(GDIPrintWindow, 0, screenDC)
 	
 	// Create compatible DC and bitmap
-	memDC, _, _ := createCompatibleDC.Call(screenDC)
+	memDC := createTrackedDC(GDIPrintWindow, screenDC)
 	if memDC == 0 {
 		return nil, fmt.Errorf("failed to create compatible DC")
 	}
-	defer deleteDC.Call(memDC)
+	defer deleteTrackedDC(GDIPrintWindow, memDC)
 	
 	// Create DIB section
 	var bmi BITMAPINFO
@@ -385,25 +575,20 @@ func (e *WindowsScreenshotEngine) tryPrintWindow(handle uintptr, windowInfo *typ
 	bmi.Header.Compression = BI_RGB
 	
 	var pBits uintptr
-	bitmap, _, _ := createDIBSection.Call(memDC, uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(&pBits)), 0, 0)
+	bitmap := createTrackedDIB(GDIPrintWindow, memDC, &bmi, &pBits)
 	if bitmap == 0 {
 		return nil, fmt.Errorf("failed to create DIB section")
 	}
-	defer deleteObject.Call(bitmap)
+	defer deleteTrackedObject(GDIPrintWindow, GDIBitmap, bitmap)
 	
 	// Select bitmap
 	oldBitmap, _, _ := selectObject.Call(memDC, bitmap)
 	defer selectObject.Call(memDC, oldBitmap)
 	
 	// Use PrintWindow to render to our DC
-	flags := uintptr(0)
-	if !options.IncludeFrame {
-		flags = PW_CLIENTONLY
-	}
-	
-	ret, _, _ := printWindow.Call(handle, memDC, flags)
+	ret, _, callErr := printWindow.Call(handle, memDC, flags)
 	if ret == 0 {
-		return nil, fmt.Errorf("PrintWindow failed")
+		return nil, win32Failure("PrintWindow failed", callErr)
 	}
 	
 	// Copy pixel data
@@ -431,19 +616,17 @@ func (e *WindowsScreenshotEngine) tryPrintWindow(handle uintptr, windowInfo *typ
 // Helper functions
 
 func (e *WindowsScreenshotEngine) findWindowByTitle(title string) (uintptr, error) {
-	titlePtr, _ := syscall.UTF16PtrFromString(title)
-	handle, _, _ := findWindowW.Call(0, uintptr(unsafe.Pointer(titlePtr)))
-	if handle == 0 {
-		return 0, fmt.Errorf("window not found")
+	handle, err := win32.FindWindow("", title)
+	if err != nil {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "window not found", nil)
 	}
 	return handle, nil
 }
 
 func (e *WindowsScreenshotEngine) findWindowByClassName(className string) (uintptr, error) {
-	classPtr, _ := syscall.UTF16PtrFromString(className)
-	handle, _, _ := findWindowW.Call(uintptr(unsafe.Pointer(classPtr)), 0)
-	if handle == 0 {
-		return 0, fmt.Errorf("window not found")
+	handle, err := win32.FindWindow(className, "")
+	if err != nil {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "window not found", nil)
 	}
 	return handle, nil
 }
@@ -451,29 +634,19 @@ func (e *WindowsScreenshotEngine) findWindowByClassName(className string) (uintp
 func (e *WindowsScreenshotEngine) findWindowByPID(targetPID uint32) (uintptr, error) {
 	var foundHandle uintptr
 	
-	// Callback function for EnumWindows
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
-		var pid uint32
-		getWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
-		
-		if pid == targetPID {
+	win32.EnumWindows(func(hwnd uintptr) bool {
+		if win32.WindowProcessID(hwnd) == targetPID {
 			// Check if window is visible and has a title
-			visible, _, _ := isWindowVisible.Call(hwnd)
-			if visible != 0 {
-				titleLen, _, _ := getWindowTextLengthW.Call(hwnd)
-				if titleLen > 0 {
-					foundHandle = hwnd
-					return 0 // Stop enumeration
-				}
+			if win32.IsWindowVisible(hwnd) && win32.GetWindowText(hwnd) != "" {
+				foundHandle = hwnd
+				return false // Stop enumeration
 			}
 		}
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
 	
-	enumWindows.Call(callback, 0)
-	
 	if foundHandle == 0 {
-		return 0, fmt.Errorf("no visible window found for PID %d", targetPID)
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no visible window found for PID %d", targetPID), nil)
 	}
 	
 	return foundHandle, nil
@@ -484,69 +657,49 @@ func (e *WindowsScreenshotEngine) getWindowInfo(handle uintptr) (*types.WindowIn
 		Handle: handle,
 	}
 	
-	// Get window title
-	titleLen, _, _ := getWindowTextLengthW.Call(handle)
-	if titleLen > 0 {
-		titleBuf := make([]uint16, titleLen+1)
-		getWindowTextW.Call(handle, uintptr(unsafe.Pointer(&titleBuf[0])), uintptr(len(titleBuf)))
-		info.Title = syscall.UTF16ToString(titleBuf)
-	}
-	
-	// Get class name
-	classBuf := make([]uint16, 256)
-	getClassName.Call(handle, uintptr(unsafe.Pointer(&classBuf[0])), 256)
-	info.ClassName = syscall.UTF16ToString(classBuf)
+	info.Title = win32.GetWindowText(handle)
+	info.ClassName = win32.GetClassName(handle)
 	
 	// Get process and thread IDs
-	var pid uint32
-	threadID, _, _ := getWindowThreadProcessId.Call(handle, uintptr(unsafe.Pointer(&pid)))
-	info.ProcessID = pid
-	info.ThreadID = uint32(threadID)
-	
-	// Get window rectangle
-	var rect RECT
-	getWindowRect.Call(handle, uintptr(unsafe.Pointer(&rect)))
-	info.Rect = types.Rectangle{
-		X:      int(rect.Left),
-		Y:      int(rect.Top),
-		Width:  int(rect.Right - rect.Left),
-		Height: int(rect.Bottom - rect.Top),
-	}
-	
-	// Get client rectangle
-	var clientRect RECT
-	getClientRect.Call(handle, uintptr(unsafe.Pointer(&clientRect)))
-	info.ClientRect = types.Rectangle{
-		X:      0,
-		Y:      0,
-		Width:  int(clientRect.Right),
-		Height: int(clientRect.Bottom),
+	info.ThreadID, info.ProcessID = win32.GetWindowThreadProcessID(handle)
+	if level, err := processIntegrityLevel(info.ProcessID); err == nil {
+		info.IntegrityLevel = integrityLevelName(level)
 	}
+	info.DisplayAffinity = displayAffinity(handle)
+	window.FillVirtualDesktop(info)
+	window.FillOcclusion(info)
+	
+	// Get window and client rectangles
+	rect, _ := win32.GetWindowRect(handle)
+	info.Rect = rect.Rectangle()
+	clientRect, _ := win32.GetClientRect(handle)
+	info.ClientRect = clientRect.Rectangle()
 	
 	// Check window state
-	visible, _, _ := isWindowVisible.Call(handle)
-	info.IsVisible = visible != 0
+	info.IsVisible = win32.IsWindowVisible(handle)
 	
-	minimized, _, _ := isIconic.Call(handle)
-	if minimized != 0 {
+	// A hung window blocks every method that sends it a message, so it has a state of its own
+	if win32.IsHungWindow(handle) {
+		info.State = "hung"
+	} else if win32.IsIconic(handle) {
 		info.State = "minimized"
 	} else if info.IsVisible {
 		info.State = "visible"
 	} else {
 		info.State = "hidden"
 	}
+	window.FillFullscreen(info)
 	
 	return info, nil
 }
 
 func (e *WindowsScreenshotEngine) isWindowMinimized(handle uintptr) bool {
-	ret, _, _ := isIconic.Call(handle)
-	return ret != 0
+	return win32.IsIconic(handle)
 }
 
 func (e *WindowsScreenshotEngine) restoreWindow(handle uintptr) error {
-	ret, _, _ := showWindow.Call(handle, SW_RESTORE)
-	if ret == 0 {
+	// ShowWindow reports the previous visibility; a minimized window counts as visible
+	if !win32.ShowWindow(handle, win32.SW_RESTORE) {
 		return fmt.Errorf("failed to restore window")
 	}
 	return nil
@@ -555,11 +708,6 @@ func (e *WindowsScreenshotEngine) restoreWindow(handle uintptr) error {
 // Ensure we implement the interface
 var _ types.ScreenshotEngine = (*WindowsScreenshotEngine)(nil)
 
-// Add constants for the advanced features
-const (
-	SW_SHOWNOACTIVATE = 4
-)
-
 func init() {
 	// Lock OS thread for Windows API calls
 	runtime.LockOSThread()
@@ -0,0 +1,14 @@
+//go:build !windows && !linux && !(darwin && cgo)
+
+package screenshot
+
+import (
+	"errors"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// newNativeEngine fails: this platform has no native backend
+func newNativeEngine(windows types.WindowManager) (Engine, error) {
+	return nil, errors.New("no native capture backend on this platform")
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package screenshot
+
+import (
+	"github.com/screenshot-mcp-server/internal/window"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Engine is a screenshot engine together with the settings the server configures on it
+type Engine interface {
+	types.ScreenshotEngine
+	SetExcludedWindowPolicy(policy types.ExcludedWindowPolicy)
+	SetElevatedHelper(helper *ElevatedHelper)
+	Monitors() ([]types.MonitorInfo, error)
+}
+
+// NewEngine creates the screenshot engine for the desktop of window.NewManager: an
+// experimental NativeEngine on X11 and macOS, or a FakeEngine over the simulated desktop
+// where there is no display
+func NewEngine() (Engine, error) {
+	windows := window.NewManager()
+	if fake, ok := windows.(*window.FakeManager); ok {
+		return NewFakeEngine(fake), nil
+	}
+	return newNativeEngine(windows)
+}
+
+// QueryDesktopState reports the desktop as always available; only Windows has lock
+// and secure desktop detection
+func QueryDesktopState() types.DesktopState {
+	return types.DesktopAvailable
+}
@@ -0,0 +1,113 @@
+//go:build darwin && cgo
+
+package screenshot
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/internal/quartz"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// quartzCapturer reads windows with CGWindowListCreateImage, which renders a window's
+// own contents even while other windows cover it. macOS windows draw their title bar
+// themselves, so frame and client area are the same.
+type quartzCapturer struct{}
+
+// newNativeEngine creates a macOS engine over the windows of window.NewManager
+func newNativeEngine(windows types.WindowManager) (Engine, error) {
+	return &NativeEngine{windows: windows, capturer: quartzCapturer{}, method: types.CaptureQuartz}, nil
+}
+
+// captureWindow captures a window without its shadow at one pixel per point
+func (quartzCapturer) captureWindow(handle uintptr, client bool) (types.Rectangle, []byte, error) {
+	window, err := findQuartzWindow(func(w *quartz.Window) bool { return uintptr(w.ID) == handle })
+	if err != nil {
+		return types.Rectangle{}, nil, err
+	}
+	width, height, data, err := quartz.CaptureWindow(window.ID)
+	if err != nil {
+		return types.Rectangle{}, nil, types.NewCaptureError(types.ErrCaptureFailed,
+			fmt.Sprintf("failed to capture window %d (is Screen Recording allowed?)", handle), err)
+	}
+	return types.Rectangle{X: window.X, Y: window.Y, Width: width, Height: height}, data, nil
+}
+
+// captureScreen captures a display; monitor 0 is the main display
+func (quartzCapturer) captureScreen(monitor int) (types.MonitorInfo, []byte, error) {
+	displays, err := quartz.Displays()
+	if err != nil {
+		return types.MonitorInfo{}, nil, err
+	}
+	if monitor < 0 || monitor >= len(displays) {
+		return types.MonitorInfo{}, nil, types.NewCaptureError(types.ErrWindowNotFound,
+			fmt.Sprintf("monitor %d not found (%d displays)", monitor, len(displays)), nil)
+	}
+
+	display := displays[monitor]
+	width, height, data, err := quartz.CaptureRect(display.X, display.Y, display.Width, display.Height)
+	if err != nil {
+		return types.MonitorInfo{}, nil, types.NewCaptureError(types.ErrCaptureFailed,
+			fmt.Sprintf("failed to capture display %d", display.ID), err)
+	}
+	rect := types.Rectangle{X: display.X, Y: display.Y, Width: width, Height: height}
+	return types.MonitorInfo{
+		Index:       monitor,
+		Primary:     display.Main,
+		Rect:        rect,
+		WorkArea:    rect,
+		DPI:         72,
+		ScaleFactor: 1,
+		Name:        fmt.Sprintf("display-%d", display.ID),
+	}, data, nil
+}
+
+// monitors lists the displays; index 0 is the main display
+func (quartzCapturer) monitors() ([]types.MonitorInfo, error) {
+	displays, err := quartz.Displays()
+	if err != nil {
+		return nil, err
+	}
+	monitors := make([]types.MonitorInfo, len(displays))
+	for i, display := range displays {
+		rect := types.Rectangle{X: display.X, Y: display.Y, Width: display.Width, Height: display.Height}
+		monitors[i] = types.MonitorInfo{
+			Index:       i,
+			Primary:     display.Main,
+			Rect:        rect,
+			WorkArea:    rect,
+			DPI:         72,
+			ScaleFactor: 1,
+			Name:        fmt.Sprintf("display-%d", display.ID),
+		}
+	}
+	return monitors, nil
+}
+
+// windowIcon is not supported: application icons come from AppKit, not CoreGraphics
+func (quartzCapturer) windowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error) {
+	return nil, fmt.Errorf("window icons are not supported on macOS")
+}
+
+// processName returns the name of the application owning a process's windows
+func (quartzCapturer) processName(pid uint32) string {
+	window, err := findQuartzWindow(func(w *quartz.Window) bool { return w.PID == pid })
+	if err != nil {
+		return ""
+	}
+	return window.Owner
+}
+
+// findQuartzWindow returns the frontmost window accepted by match
+func findQuartzWindow(match func(*quartz.Window) bool) (*quartz.Window, error) {
+	windows, err := quartz.Windows()
+	if err != nil {
+		return nil, err
+	}
+	for i := range windows {
+		if match(&windows[i]) {
+			return &windows[i], nil
+		}
+	}
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, "window not found", nil)
+}
@@ -0,0 +1,174 @@
+//go:build linux
+
+package screenshot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/x11"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// x11Capturer reads windows through the X11 protocol. With the Composite extension each
+// top-level window renders to an off-screen pixmap, so windows are captured whole even
+// when others cover them; without it only what is on screen can be read.
+type x11Capturer struct {
+	conn      *x11.Conn
+	composite bool
+}
+
+// newNativeEngine creates an X11 engine over the display of window.NewManager
+func newNativeEngine(windows types.WindowManager) (Engine, error) {
+	conn, err := x11.Default()
+	if err != nil {
+		return nil, err
+	}
+	return &NativeEngine{
+		windows:  windows,
+		capturer: &x11Capturer{conn: conn, composite: conn.RedirectSubwindows() == nil},
+		method:   types.CaptureX11,
+	}, nil
+}
+
+// captureWindow reads a client window or the frame around it
+func (c *x11Capturer) captureWindow(handle uintptr, client bool) (types.Rectangle, []byte, error) {
+	w := x11.Window(handle)
+	frame, err := c.conn.TopLevel(w)
+	if err != nil {
+		return types.Rectangle{}, nil, err
+	}
+	attrs, err := c.conn.GetWindowAttributes(frame)
+	if err != nil {
+		return types.Rectangle{}, nil, err
+	}
+	if attrs.MapState != x11.IsViewable {
+		return types.Rectangle{}, nil, types.NewCaptureError(types.ErrCaptureFailed,
+			fmt.Sprintf("window %d is not mapped; X11 only keeps the contents of mapped windows", handle), nil)
+	}
+
+	geometry, err := c.conn.GetGeometry(frame)
+	if err != nil {
+		return types.Rectangle{}, nil, err
+	}
+	rect := types.Rectangle{
+		X:      geometry.X,
+		Y:      geometry.Y,
+		Width:  geometry.Width + 2*geometry.Border,
+		Height: geometry.Height + 2*geometry.Border,
+	}
+	if client {
+		clientGeometry, err := c.conn.GetGeometry(w)
+		if err != nil {
+			return types.Rectangle{}, nil, err
+		}
+		x, y, err := c.conn.TranslateCoordinates(w, c.conn.Screen().Root, 0, 0)
+		if err != nil {
+			return types.Rectangle{}, nil, err
+		}
+		rect = types.Rectangle{X: x, Y: y, Width: clientGeometry.Width, Height: clientGeometry.Height}
+	}
+
+	if c.composite {
+		// The pixmap covers the frame including its border
+		if pixmap, err := c.conn.NameWindowPixmap(frame); err == nil {
+			defer c.conn.FreePixmap(pixmap)
+			data, err := c.conn.GetImage(uint32(pixmap), rect.X-geometry.X, rect.Y-geometry.Y, rect.Width, rect.Height)
+			if err == nil {
+				return rect, data, nil
+			}
+		}
+	}
+
+	// Read the screen instead, which shows whatever covers the window
+	screen := c.conn.Screen()
+	visible, ok := intersect(rect, types.Rectangle{Width: screen.Width, Height: screen.Height})
+	if !ok {
+		return types.Rectangle{}, nil, types.NewCaptureError(types.ErrCaptureFailed,
+			fmt.Sprintf("window %d is off screen and the X server lacks Composite", handle), nil)
+	}
+	data, err := c.conn.GetImage(uint32(screen.Root), visible.X, visible.Y, visible.Width, visible.Height)
+	if err != nil {
+		return types.Rectangle{}, nil, err
+	}
+	return visible, data, nil
+}
+
+// captureScreen reads the root window. X11 without RandR has a single screen spanning
+// every monitor, so monitor is ignored like on Windows.
+func (c *x11Capturer) captureScreen(monitor int) (types.MonitorInfo, []byte, error) {
+	screen := c.conn.Screen()
+	data, err := c.conn.GetImage(uint32(screen.Root), 0, 0, screen.Width, screen.Height)
+	if err != nil {
+		return types.MonitorInfo{}, nil, err
+	}
+	rect := types.Rectangle{Width: screen.Width, Height: screen.Height}
+	return types.MonitorInfo{Primary: true, Rect: rect, WorkArea: rect, DPI: 96, ScaleFactor: 1, Name: os.Getenv("DISPLAY")}, data, nil
+}
+
+// monitors returns the root window as the only monitor, as captureScreen sees it
+func (c *x11Capturer) monitors() ([]types.MonitorInfo, error) {
+	screen := c.conn.Screen()
+	rect := types.Rectangle{Width: screen.Width, Height: screen.Height}
+	return []types.MonitorInfo{{Primary: true, Rect: rect, WorkArea: rect, DPI: 96, ScaleFactor: 1, Name: os.Getenv("DISPLAY")}}, nil
+}
+
+// windowIcon picks from the sizes in _NET_WM_ICON the smallest one at least 16 or 32
+// pixels wide, or the largest if all are smaller
+func (c *x11Capturer) windowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error) {
+	prop, err := c.conn.GetProperty(x11.Window(handle), "_NET_WM_ICON")
+	if err != nil {
+		return nil, err
+	}
+	want := 16
+	if large {
+		want = 32
+	}
+
+	// The property holds width, height and width*height ARGB pixels per size
+	values := prop.Uint32s()
+	best, bestWidth, bestHeight := -1, 0, 0
+	for i := 0; i+2 <= len(values); {
+		width, height := int(values[i]), int(values[i+1])
+		if width <= 0 || height <= 0 || i+2+width*height > len(values) {
+			break
+		}
+		better := best < 0 ||
+			(width >= want && (bestWidth < want || width < bestWidth)) ||
+			(width < want && bestWidth < want && width > bestWidth)
+		if better {
+			best, bestWidth, bestHeight = i+2, width, height
+		}
+		i += 2 + width*height
+	}
+	if best < 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d has no icon", handle), nil)
+	}
+
+	// A little-endian ARGB value is laid out as BGRA
+	data := make([]byte, bestWidth*bestHeight*4)
+	copy(data, prop.Value[best*4:])
+	return &types.ScreenshotBuffer{
+		Data:      data,
+		Width:     bestWidth,
+		Height:    bestHeight,
+		Stride:    bestWidth * 4,
+		Format:    "BGRA32",
+		DPI:       96,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// processName reads a process's command name from /proc
+func (c *x11Capturer) processName(pid uint32) string {
+	if pid == 0 {
+		return ""
+	}
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,326 @@
+package screenshot
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// fakeScreen is the size of the simulated desktop
+var fakeScreen = types.Rectangle{Width: 1920, Height: 1080}
+
+// FakeEngine is an in-memory ScreenshotEngine that renders synthetic gradients for the
+// windows a WindowManager reports. It needs no display, so the HTTP, MCP and streaming
+// layers can be developed and tested on any platform. Each capture advances the
+// gradient a little, so consecutive frames of a stream differ.
+type FakeEngine struct {
+	windows        types.WindowManager
+	mu             sync.Mutex
+	frame          int
+	excludedPolicy atomic.Value // types.ExcludedWindowPolicy
+	helper         *ElevatedHelper
+}
+
+// NewFakeEngine creates a fake engine over the windows of a manager, typically a
+// window.FakeManager
+func NewFakeEngine(windows types.WindowManager) *FakeEngine {
+	return &FakeEngine{windows: windows}
+}
+
+// SetExcludedWindowPolicy sets how windows that block capture via their display affinity
+// are handled. It may change while captures run.
+func (e *FakeEngine) SetExcludedWindowPolicy(policy types.ExcludedWindowPolicy) {
+	e.excludedPolicy.Store(policy)
+}
+
+func (e *FakeEngine) excludedWindowPolicy() types.ExcludedWindowPolicy {
+	policy, _ := e.excludedPolicy.Load().(types.ExcludedWindowPolicy)
+	return policy
+}
+
+// SetElevatedHelper is accepted for parity with the Windows engine; simulated windows
+// never need elevation
+func (e *FakeEngine) SetElevatedHelper(helper *ElevatedHelper) {
+	e.helper = helper
+}
+
+// CaptureByHandle renders a window as a gradient the size of its frame, client area or
+// the requested region
+func (e *FakeEngine) CaptureByHandle(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if options == nil {
+		options = types.DefaultCaptureOptions()
+	}
+
+	info, err := e.windows.GetWindowInfo(handle)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.State == "minimized" && !options.AllowMinimized:
+		return nil, types.NewCaptureError(types.ErrCaptureFailed, fmt.Sprintf("window %d is minimized", handle), nil)
+	case !info.IsVisible && !options.AllowHidden:
+		return nil, types.NewCaptureError(types.ErrCaptureFailed, fmt.Sprintf("window %d is hidden", handle), nil)
+	case info.DisplayAffinity != "" && e.excludedWindowPolicy() != types.ExcludedWindowDWMThumbnail:
+		return nil, types.NewCaptureError(types.ErrCaptureExcluded,
+			fmt.Sprintf("window %d is excluded from capture (display affinity %s)", handle, info.DisplayAffinity), nil)
+	}
+
+	source, err := fakeSourceRect(info, options)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, source.Width*source.Height*4)
+	frame := e.nextFrame()
+	drawGradient(data, source, info.Rect, handle, frame)
+
+	return &types.ScreenshotBuffer{
+		Data:          data,
+		Width:         source.Width,
+		Height:        source.Height,
+		Stride:        source.Width * 4,
+		Format:        "BGRA32",
+		DPI:           96,
+		Timestamp:     time.Now(),
+		SourceRect:    source,
+		WindowInfo:    *info,
+		CaptureMethod: types.CaptureSynthetic,
+	}, nil
+}
+
+// CaptureByTitle captures the window with exactly this title
+func (e *FakeEngine) CaptureByTitle(title string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, "window not found", func(info *types.WindowInfo) bool {
+		return info.Title == title
+	})
+}
+
+// CaptureByPID captures the first visible, titled window of a process
+func (e *FakeEngine) CaptureByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, fmt.Sprintf("no visible window found for PID %d", pid), func(info *types.WindowInfo) bool {
+		return info.ProcessID == pid && info.IsVisible && info.Title != ""
+	})
+}
+
+// CaptureByClassName captures the first window of a class
+func (e *FakeEngine) CaptureByClassName(className string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, "window not found", func(info *types.WindowInfo) bool {
+		return info.ClassName == className
+	})
+}
+
+// CaptureByProcessName fails: simulated windows carry no process names
+func (e *FakeEngine) CaptureByProcessName(name string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("process %s not found", name), nil)
+}
+
+// Monitors returns the simulated desktop's one monitor
+func (e *FakeEngine) Monitors() ([]types.MonitorInfo, error) {
+	return []types.MonitorInfo{{Primary: true, Rect: fakeScreen, WorkArea: fakeScreen, DPI: 96, ScaleFactor: 1, Name: "FAKE1"}}, nil
+}
+
+// CaptureFullScreen renders the simulated desktop with its shown windows drawn bottom to top
+func (e *FakeEngine) CaptureFullScreen(monitor int, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	windows, err := e.windows.EnumerateWindows(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, fakeScreen.Width*fakeScreen.Height*4)
+	frame := e.nextFrame()
+	drawGradient(data, fakeScreen, fakeScreen, 0, frame)
+	for i := len(windows) - 1; i >= 0; i-- {
+		info := windows[i]
+		if !info.IsVisible || info.State == "minimized" {
+			continue
+		}
+		if visible, ok := intersect(info.Rect, fakeScreen); ok {
+			layer := make([]byte, visible.Width*visible.Height*4)
+			drawGradient(layer, visible, info.Rect, info.Handle, frame)
+			for y := 0; y < visible.Height; y++ {
+				dst := ((visible.Y+y)*fakeScreen.Width + visible.X) * 4
+				copy(data[dst:dst+visible.Width*4], layer[y*visible.Width*4:(y+1)*visible.Width*4])
+			}
+		}
+	}
+
+	return &types.ScreenshotBuffer{
+		Data:          data,
+		Width:         fakeScreen.Width,
+		Height:        fakeScreen.Height,
+		Stride:        fakeScreen.Width * 4,
+		Format:        "BGRA32",
+		DPI:           96,
+		Timestamp:     time.Now(),
+		SourceRect:    fakeScreen,
+		MonitorInfo:   types.MonitorInfo{Primary: true, Rect: fakeScreen, WorkArea: fakeScreen, DPI: 96, ScaleFactor: 1, Name: "FAKE1"},
+		CaptureMethod: types.CaptureSynthetic,
+	}, nil
+}
+
+// CaptureHiddenByPID captures the first window of a process, shown or not
+func (e *FakeEngine) CaptureHiddenByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, fmt.Sprintf("no windows found for PID %d", pid), func(info *types.WindowInfo) bool {
+		return info.ProcessID == pid
+	})
+}
+
+// CaptureTrayApp fails like CaptureByProcessName
+func (e *FakeEngine) CaptureTrayApp(processName string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.CaptureByProcessName(processName, options)
+}
+
+// CaptureWithFallbacks captures a window; there is only one synthetic method
+func (e *FakeEngine) CaptureWithFallbacks(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.CaptureByHandle(handle, options)
+}
+
+// CaptureShellSurface fails: the simulated desktop has no shell
+func (e *FakeEngine) CaptureShellSurface(surface types.ShellSurface, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("%s not found", surface), nil)
+}
+
+// CapturePopups fails at once: simulated processes never open popups
+func (e *FakeEngine) CapturePopups(pid uint32, classes []string, within time.Duration, maxPopups int, options *types.CaptureOptions) ([]*types.ScreenshotBuffer, error) {
+	return nil, types.NewCaptureError(types.ErrTimeout, fmt.Sprintf("no popup appeared in process %d within %s", pid, within), nil)
+}
+
+// EnumerateAllProcessWindows lists the windows of a process
+func (e *FakeEngine) EnumerateAllProcessWindows(pid uint32) ([]types.WindowInfo, error) {
+	return e.windows.EnumerateWindows(&types.WindowFilter{ProcessIDs: []uint32{pid}})
+}
+
+// FindSystemTrayApps reports no tray apps; the simulated desktop has no tray
+func (e *FakeEngine) FindSystemTrayApps() ([]types.WindowInfo, error) {
+	return nil, nil
+}
+
+// FindHiddenWindows lists windows that are neither shown nor minimized
+func (e *FakeEngine) FindHiddenWindows() ([]types.WindowInfo, error) {
+	return findWindows(e.windows, func(info *types.WindowInfo) bool {
+		return !info.IsVisible && info.State != "minimized"
+	})
+}
+
+// FindCloakedWindows lists windows whose state is "cloaked"
+func (e *FakeEngine) FindCloakedWindows() ([]types.WindowInfo, error) {
+	return findWindows(e.windows, func(info *types.WindowInfo) bool {
+		return info.State == "cloaked"
+	})
+}
+
+// FindToastWindows reports no toasts
+func (e *FakeEngine) FindToastWindows() ([]types.WindowInfo, error) {
+	return nil, nil
+}
+
+// GetWindowIcon renders a gradient icon for a window
+func (e *FakeEngine) GetWindowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error) {
+	if _, err := e.windows.GetWindowInfo(handle); err != nil {
+		return nil, err
+	}
+	return fakeIcon(handle, large), nil
+}
+
+// GetProcessIcon renders a gradient icon for a process that owns a window
+func (e *FakeEngine) GetProcessIcon(pid uint32, large bool) (*types.ScreenshotBuffer, error) {
+	windows, err := e.EnumerateAllProcessWindows(pid)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("process %d not found", pid), nil)
+	}
+	return fakeIcon(uintptr(pid), large), nil
+}
+
+// nextFrame returns the animation step of the next capture
+func (e *FakeEngine) nextFrame() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.frame++
+	return e.frame
+}
+
+// fakeSourceRect returns the screen rectangle a capture covers: the window frame, its
+// client area, or the requested region clipped to the window
+func fakeSourceRect(info *types.WindowInfo, options *types.CaptureOptions) (types.Rectangle, error) {
+	border := (info.Rect.Width - info.ClientRect.Width) / 2
+	client := types.Rectangle{
+		X:      info.Rect.X + border,
+		Y:      info.Rect.Y + info.Rect.Height - info.ClientRect.Height - border,
+		Width:  info.ClientRect.Width,
+		Height: info.ClientRect.Height,
+	}
+
+	if options.Region == nil {
+		if options.IncludeFrame {
+			return info.Rect, nil
+		}
+		return client, nil
+	}
+
+	region := *options.Region
+	if region.Width <= 0 || region.Height <= 0 {
+		return types.Rectangle{}, fmt.Errorf("invalid region dimensions: %dx%d", region.Width, region.Height)
+	}
+	switch options.RegionRelativeTo {
+	case "", types.RegionRelativeToWindow:
+		region.X += info.Rect.X
+		region.Y += info.Rect.Y
+	case types.RegionRelativeToClient:
+		region.X += client.X
+		region.Y += client.Y
+	case types.RegionRelativeToScreen:
+	default:
+		return types.Rectangle{}, fmt.Errorf("unsupported region origin: %s", options.RegionRelativeTo)
+	}
+
+	clipped, ok := intersect(region, info.Rect)
+	if !ok {
+		return types.Rectangle{}, fmt.Errorf("region lies outside the window")
+	}
+	return clipped, nil
+}
+
+// drawGradient fills data, covering source in screen coordinates, with the gradient of
+// a window occupying bounds: red runs left to right, green top to bottom and blue is
+// derived from the seed. frame shifts the red channel so successive captures differ.
+func drawGradient(data []byte, source, bounds types.Rectangle, seed uintptr, frame int) {
+	blue := byte(seed>>4) * 53
+	width, height := max(bounds.Width, 1), max(bounds.Height, 1)
+	for y := 0; y < source.Height; y++ {
+		green := byte((source.Y + y - bounds.Y) * 255 / height)
+		row := data[y*source.Width*4 : (y+1)*source.Width*4]
+		for x := 0; x < source.Width; x++ {
+			red := byte((source.X+x-bounds.X)*255/width + frame*4)
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = blue, green, red, 255
+		}
+	}
+}
+
+// fakeIcon renders a square gradient icon
+func fakeIcon(seed uintptr, large bool) *types.ScreenshotBuffer {
+	size := 16
+	if large {
+		size = 32
+	}
+	bounds := types.Rectangle{Width: size, Height: size}
+	data := make([]byte, size*size*4)
+	drawGradient(data, bounds, bounds, seed, 0)
+	return &types.ScreenshotBuffer{
+		Data:      data,
+		Width:     size,
+		Height:    size,
+		Stride:    size * 4,
+		Format:    "BGRA32",
+		DPI:       96,
+		Timestamp: time.Now(),
+	}
+}
+
+// Ensure FakeEngine implements the interface
+var _ types.ScreenshotEngine = (*FakeEngine)(nil)
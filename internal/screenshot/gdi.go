@@ -0,0 +1,84 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var getGuiResources = user32.NewProc("GetGuiResources")
+
+// GR_GDIOBJECTS asks GetGuiResources for the process's GDI object count
+const GR_GDIOBJECTS = 0
+
+// ProcessGDIObjects returns the number of GDI objects the process holds
+func ProcessGDIObjects() (int, error) {
+	count, _, err := getGuiResources.Call(uintptr(windows.CurrentProcess()), GR_GDIOBJECTS)
+	if count == 0 && err != windows.ERROR_SUCCESS {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// getTrackedDC gets the window DC (including the frame) or client DC of a window, or of
+// the screen for handle 0
+func getTrackedDC(subsystem string, handle uintptr, window bool) (uintptr, error) {
+	var hdc uintptr
+	var err error
+	if window {
+		hdc, _, err = getWindowDC.Call(handle)
+	} else {
+		hdc, _, err = getDC.Call(handle)
+	}
+	if hdc != 0 {
+		trackGDI(subsystem, GDIDeviceContext, 1)
+	}
+	return hdc, err
+}
+
+// releaseTrackedDC releases a DC from getTrackedDC
+func releaseTrackedDC(subsystem string, handle, hdc uintptr) {
+	if ret, _, _ := releaseDC.Call(handle, hdc); ret != 0 {
+		trackGDI(subsystem, GDIDeviceContext, -1)
+	}
+}
+
+// createTrackedDC creates a memory DC compatible with hdc, or with the screen for 0
+func createTrackedDC(subsystem string, hdc uintptr) uintptr {
+	memDC, _, _ := createCompatibleDC.Call(hdc)
+	if memDC != 0 {
+		trackGDI(subsystem, GDIDeviceContext, 1)
+	}
+	return memDC
+}
+
+// deleteTrackedDC deletes a DC from createTrackedDC
+func deleteTrackedDC(subsystem string, hdc uintptr) {
+	if ret, _, _ := deleteDC.Call(hdc); ret != 0 {
+		trackGDI(subsystem, GDIDeviceContext, -1)
+	}
+}
+
+// createTrackedDIB creates a DIB section described by bmi, storing its pixel pointer in bits
+func createTrackedDIB(subsystem string, hdc uintptr, bmi *BITMAPINFO, bits *uintptr) uintptr {
+	bitmap, _, _ := createDIBSection.Call(hdc, uintptr(unsafe.Pointer(bmi)), DIB_RGB_COLORS, uintptr(unsafe.Pointer(bits)), 0, 0)
+	if bitmap != 0 {
+		trackGDI(subsystem, GDIBitmap, 1)
+	}
+	return bitmap
+}
+
+// adoptTrackedObject counts a GDI object that a Windows call handed to the caller to
+// delete, such as the bitmaps of GetIconInfo
+func adoptTrackedObject(subsystem, kind string) {
+	trackGDI(subsystem, kind, 1)
+}
+
+// deleteTrackedObject deletes a bitmap, brush or other GDI object
+func deleteTrackedObject(subsystem, kind string, object uintptr) {
+	if ret, _, _ := deleteObject.Call(object); ret != 0 {
+		trackGDI(subsystem, kind, -1)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package screenshot
+
+import "errors"
+
+// ProcessGDIObjects fails: GDI objects exist on Windows only
+func ProcessGDIObjects() (int, error) {
+	return 0, errors.New("GDI objects are only counted on Windows")
+}
@@ -0,0 +1,361 @@
+package screenshot
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"unicode/utf16"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// ErrNoColorProfile reports that no ICC profile is associated with a display
+var ErrNoColorProfile = errors.New("no color profile for the display")
+
+// srgbFromD50 converts PCS XYZ (D50) to linear sRGB: the inverse of the Bradford-adapted
+// sRGB primaries used by the ICC sRGB profile
+var srgbFromD50 = invert3x3([3][3]float64{
+	{0.4360747, 0.3850649, 0.1430804},
+	{0.2225045, 0.7168786, 0.0606169},
+	{0.0139322, 0.0970790, 0.7141733},
+})
+
+// ICCProfile is a parsed RGB matrix/TRC display profile, the kind Windows, macOS and
+// calibration tools generate for monitors
+type ICCProfile struct {
+	Description string
+	Data        []byte // The profile as read
+
+	matrix [3][3]float64 // Linear device RGB to PCS XYZ; columns are the rXYZ, gXYZ and bXYZ tags
+	curves [3]func(float64) float64
+}
+
+// ParseICCProfile parses an ICC profile. Only RGB matrix/TRC profiles can be converted;
+// LUT-based profiles fail.
+func ParseICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, fmt.Errorf("not an ICC profile")
+	}
+	if space := string(data[16:20]); space != "RGB " {
+		return nil, fmt.Errorf("unsupported ICC color space %q", space)
+	}
+
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[128:132]))
+	for i := 0; i < count; i++ {
+		entry := 132 + 12*i
+		if entry+12 > len(data) {
+			return nil, fmt.Errorf("truncated ICC tag table")
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4:]))
+		size := int(binary.BigEndian.Uint32(data[entry+8:]))
+		if offset < 0 || size < 8 || offset+size > len(data) {
+			return nil, fmt.Errorf("ICC tag %q is out of bounds", data[entry:entry+4])
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	profile := &ICCProfile{Data: data, Description: iccDescription(tags["desc"])}
+	for i, name := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		tag := tags[name]
+		if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+			return nil, fmt.Errorf("ICC profile has no %s tag (only matrix/TRC profiles are supported)", name)
+		}
+		for row := 0; row < 3; row++ {
+			profile.matrix[row][i] = s15Fixed16(tag[8+4*row:])
+		}
+	}
+	for i, name := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := iccCurve(tags[name])
+		if err != nil {
+			return nil, fmt.Errorf("ICC %s tag: %w", name, err)
+		}
+		profile.curves[i] = curve
+	}
+	return profile, nil
+}
+
+// ConvertToSRGB converts a capture's pixels from the profile's color space to sRGB. The
+// result drops any embedded profile, as sRGB is what untagged images are taken to be.
+func (p *ICCProfile) ConvertToSRGB(buffer *types.ScreenshotBuffer) (*types.ScreenshotBuffer, error) {
+	var r, g, b int
+	switch buffer.Format {
+	case "BGRA32":
+		r, g, b = 2, 1, 0
+	case "RGBA32":
+		r, g, b = 0, 1, 2
+	default:
+		return nil, fmt.Errorf("unsupported buffer format for color conversion: %s", buffer.Format)
+	}
+
+	var linear [3][256]float64
+	for c := range linear {
+		for v := range linear[c] {
+			linear[c][v] = p.curves[c](float64(v) / 255)
+		}
+	}
+	m := multiply3x3(srgbFromD50, p.matrix)
+
+	// Linear to sRGB encoding, finely sampled so dark tones keep their precision
+	const steps = 4096
+	var encode [steps + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(srgbEncode(float64(i)/steps) * 255))
+	}
+	quantize := func(v float64) uint8 {
+		return encode[int(math.Round(min(max(v, 0), 1)*steps))]
+	}
+
+	data := make([]byte, len(buffer.Data))
+	copy(data, buffer.Data)
+	for i := 0; i+3 < len(data); i += 4 {
+		lr, lg, lb := linear[0][data[i+r]], linear[1][data[i+g]], linear[2][data[i+b]]
+		data[i+r] = quantize(m[0][0]*lr + m[0][1]*lg + m[0][2]*lb)
+		data[i+g] = quantize(m[1][0]*lr + m[1][1]*lg + m[1][2]*lb)
+		data[i+b] = quantize(m[2][0]*lr + m[2][1]*lg + m[2][2]*lb)
+	}
+
+	converted := *buffer
+	converted.Data = data
+	converted.ColorProfile = nil
+	return &converted, nil
+}
+
+// ApplyColorManagement prepares a capture taken on a display with the given profile:
+// "srgb" converts its pixels to sRGB and "embed" attaches the profile so encoders embed
+// it. It reports what was done; a capture without a profile is returned as is, since
+// it is already taken to be sRGB.
+func ApplyColorManagement(buffer *types.ScreenshotBuffer, mode types.ColorManagement, profileData []byte, source string) (*types.ScreenshotBuffer, *types.ColorProfileInfo, error) {
+	if mode == types.ColorManagementNone || mode == "" {
+		return buffer, nil, nil
+	}
+	info := &types.ColorProfileInfo{Mode: mode, Source: source}
+	if profileData == nil {
+		info.Warning = "the display has no color profile; the image is assumed to be sRGB"
+		return buffer, info, nil
+	}
+
+	switch mode {
+	case types.ColorManagementEmbed:
+		profile, err := ParseICCProfile(profileData)
+		if err == nil {
+			info.Description = profile.Description
+		}
+		embedded := *buffer
+		embedded.ColorProfile = profileData
+		info.Profile = base64.StdEncoding.EncodeToString(profileData)
+		return &embedded, info, nil
+	case types.ColorManagementSRGB:
+		profile, err := ParseICCProfile(profileData)
+		if err != nil {
+			return nil, nil, types.NewCaptureError(types.ErrCaptureFailed, "failed to read the display's color profile", err)
+		}
+		info.Description = profile.Description
+		converted, err := profile.ConvertToSRGB(buffer)
+		if err != nil {
+			return nil, nil, err
+		}
+		info.Converted = true
+		return converted, info, nil
+	}
+	return nil, nil, fmt.Errorf("unknown color management mode %q", mode)
+}
+
+// iccCurve parses a curv or para tone reproduction curve
+func iccCurve(tag []byte) (func(float64) float64, error) {
+	if len(tag) < 12 {
+		return nil, fmt.Errorf("missing or truncated curve")
+	}
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, fmt.Errorf("truncated curve")
+		}
+		switch n {
+		case 0:
+			return func(x float64) float64 { return x }, nil
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(x float64) float64 { return math.Pow(x, gamma) }, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(x float64) float64 {
+			pos := x * float64(n-1)
+			i := min(int(pos), n-2)
+			return table[i] + (table[i+1]-table[i])*(pos-float64(i))
+		}, nil
+	case "para":
+		kind := binary.BigEndian.Uint16(tag[8:])
+		counts := []int{1, 3, 4, 5, 7}
+		if int(kind) >= len(counts) || len(tag) < 12+4*counts[kind] {
+			return nil, fmt.Errorf("unsupported parametric curve type %d", kind)
+		}
+		var v [7]float64
+		for i := 0; i < counts[kind]; i++ {
+			v[i] = s15Fixed16(tag[12+4*i:])
+		}
+		g, a, b, c, d, e, f := v[0], v[1], v[2], v[3], v[4], v[5], v[6]
+		return func(x float64) float64 {
+			switch kind {
+			case 0:
+				return math.Pow(x, g)
+			case 1:
+				if x >= -b/a {
+					return math.Pow(a*x+b, g)
+				}
+				return 0
+			case 2:
+				if x >= -b/a {
+					return math.Pow(a*x+b, g) + c
+				}
+				return c
+			case 3:
+				if x >= d {
+					return math.Pow(a*x+b, g)
+				}
+				return c * x
+			default:
+				if x >= d {
+					return math.Pow(a*x+b, g) + e
+				}
+				return c*x + f
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported curve type %q", tag[:4])
+}
+
+// iccDescription reads a desc (ICC v2) or mluc (v4) description tag
+func iccDescription(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if n == 0 || len(tag) < 12+n {
+			return ""
+		}
+		return string(bytes.TrimRight(tag[12:12+n], "\x00"))
+	case "mluc":
+		if len(tag) < 28 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:]))
+		offset := int(binary.BigEndian.Uint32(tag[24:]))
+		if offset+length > len(tag) {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+2*i:])
+		}
+		return string(utf16.Decode(units))
+	}
+	return ""
+}
+
+// embedICCProfile adds an ICC profile to encoded PNG or JPEG data
+func embedICCProfile(encoded []byte, format types.ImageFormat, profile []byte) ([]byte, error) {
+	switch format {
+	case types.FormatPNG, types.FormatBMP:
+		return embedPNGProfile(encoded, profile)
+	case types.FormatJPEG:
+		return embedJPEGProfile(encoded, profile)
+	}
+	return encoded, nil
+}
+
+// embedPNGProfile inserts an iCCP chunk after the IHDR chunk
+func embedPNGProfile(encoded, profile []byte) ([]byte, error) {
+	const ihdrEnd = 8 + 8 + 13 + 4 // Signature, IHDR length and type, IHDR data, CRC
+	if len(encoded) < ihdrEnd {
+		return nil, fmt.Errorf("invalid PNG data")
+	}
+
+	var chunk bytes.Buffer
+	chunk.WriteString("iCCP")
+	chunk.WriteString("ICC profile")
+	chunk.Write([]byte{0, 0}) // Name terminator, deflate compression
+	zw := zlib.NewWriter(&chunk)
+	zw.Write(profile)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(encoded)+chunk.Len()+8)
+	out = append(out, encoded[:ihdrEnd]...)
+	out = binary.BigEndian.AppendUint32(out, uint32(chunk.Len()-4))
+	out = append(out, chunk.Bytes()...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(chunk.Bytes()))
+	return append(out, encoded[ihdrEnd:]...), nil
+}
+
+// embedJPEGProfile inserts the profile as APP2 ICC_PROFILE segments after SOI
+func embedJPEGProfile(encoded, profile []byte) ([]byte, error) {
+	const maxChunk = 65519 // 65535 less the length, ICC_PROFILE marker and sequence bytes
+	if len(encoded) < 2 || encoded[0] != 0xFF || encoded[1] != 0xD8 {
+		return nil, fmt.Errorf("invalid JPEG data")
+	}
+	chunks := (len(profile) + maxChunk - 1) / maxChunk
+	if chunks > 255 {
+		return nil, fmt.Errorf("ICC profile too large to embed in JPEG")
+	}
+
+	out := make([]byte, 0, len(encoded)+len(profile)+chunks*18)
+	out = append(out, encoded[:2]...)
+	for i := 0; i < chunks; i++ {
+		part := profile[i*maxChunk : min((i+1)*maxChunk, len(profile))]
+		out = append(out, 0xFF, 0xE2)
+		out = binary.BigEndian.AppendUint16(out, uint16(2+12+2+len(part)))
+		out = append(out, "ICC_PROFILE\x00"...)
+		out = append(out, byte(i+1), byte(chunks))
+		out = append(out, part...)
+	}
+	return append(out, encoded[2:]...), nil
+}
+
+// s15Fixed16 reads an ICC signed 15.16 fixed-point number
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+// srgbEncode applies the sRGB transfer function
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func multiply3x3(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func invert3x3(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	return [3][3]float64{
+		{(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}
+}
@@ -0,0 +1,212 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	sendMessageTimeout = user32.NewProc("SendMessageTimeoutW")
+	getClassLongPtr    = user32.NewProc("GetClassLongPtrW")
+	getIconInfo        = user32.NewProc("GetIconInfo")
+	destroyIcon        = user32.NewProc("DestroyIcon")
+	getObject          = gdi32.NewProc("GetObjectW")
+	extractIconEx      = shell32.NewProc("ExtractIconExW")
+)
+
+// Icon constants
+const (
+	WM_GETICON       = 0x007F
+	ICON_SMALL       = 0
+	ICON_BIG         = 1
+	ICON_SMALL2      = 2
+	GCLP_HICON       = -14
+	GCLP_HICONSM     = -34
+	SMTO_ABORTIFHUNG = 0x0002
+
+	// iconMessageTimeout bounds WM_GETICON so a hung window can't stall the request
+	iconMessageTimeout = 500 * time.Millisecond
+)
+
+// ICONINFO structure
+type ICONINFO struct {
+	FIcon    int32
+	XHotspot uint32
+	YHotspot uint32
+	HbmMask  uintptr
+	HbmColor uintptr
+}
+
+// BITMAP structure
+type BITMAP struct {
+	Type       int32
+	Width      int32
+	Height     int32
+	WidthBytes int32
+	Planes     uint16
+	BitsPixel  uint16
+	Bits       uintptr
+}
+
+// GetWindowIcon returns a window's icon as a BGRA buffer. The icon the window set with
+// WM_SETICON is preferred, then its class icon, then the first icon in its executable.
+func (e *WindowsScreenshotEngine) GetWindowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error) {
+	if !win32.IsWindow(handle) {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d not found", handle), nil)
+	}
+
+	requests := []uintptr{ICON_SMALL2, ICON_SMALL, ICON_BIG}
+	classIndex := GCLP_HICONSM
+	if large {
+		requests = []uintptr{ICON_BIG, ICON_SMALL2, ICON_SMALL}
+		classIndex = GCLP_HICON
+	}
+
+	// Window and class icons belong to the window and must not be destroyed
+	for _, which := range requests {
+		var icon uintptr
+		ret, _, _ := sendMessageTimeout.Call(handle, WM_GETICON, which, 0,
+			SMTO_ABORTIFHUNG, uintptr(iconMessageTimeout.Milliseconds()), uintptr(unsafe.Pointer(&icon)))
+		if ret != 0 && icon != 0 {
+			return iconToBuffer(icon)
+		}
+	}
+	if icon, _, _ := getClassLongPtr.Call(handle, uintptr(classIndex)); icon != 0 {
+		return iconToBuffer(icon)
+	}
+
+	return e.GetProcessIcon(win32.WindowProcessID(handle), large)
+}
+
+// GetProcessIcon returns the first icon in a process's executable as a BGRA buffer
+func (e *WindowsScreenshotEngine) GetProcessIcon(pid uint32, large bool) (*types.ScreenshotBuffer, error) {
+	path, err := win32.ProcessImagePath(pid)
+	if err != nil {
+		return nil, types.NewCaptureError(types.ErrAccessDenied, fmt.Sprintf("cannot query process %d", pid), err)
+	}
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var largeIcon, smallIcon uintptr
+	count, _, _ := extractIconEx.Call(uintptr(unsafe.Pointer(pathPtr)), 0,
+		uintptr(unsafe.Pointer(&largeIcon)), uintptr(unsafe.Pointer(&smallIcon)), 1)
+	if largeIcon != 0 {
+		defer destroyIcon.Call(largeIcon)
+	}
+	if smallIcon != 0 {
+		defer destroyIcon.Call(smallIcon)
+	}
+	if count == 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("%s has no icon", path), nil)
+	}
+
+	icon := largeIcon
+	if (!large && smallIcon != 0) || icon == 0 {
+		icon = smallIcon
+	}
+	return iconToBuffer(icon)
+}
+
+// iconToBuffer renders an icon into a premultiplied BGRA buffer. Icons without an
+// alpha channel take their transparency from the icon's AND mask.
+func iconToBuffer(icon uintptr) (*types.ScreenshotBuffer, error) {
+	var info ICONINFO
+	if ret, _, err := getIconInfo.Call(icon, uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return nil, fmt.Errorf("GetIconInfo failed: %w", err)
+	}
+	if info.HbmMask != 0 {
+		adoptTrackedObject(GDIIcon, GDIBitmap)
+		defer deleteTrackedObject(GDIIcon, GDIBitmap, info.HbmMask)
+	}
+	if info.HbmColor == 0 {
+		return nil, fmt.Errorf("monochrome icons are not supported")
+	}
+	adoptTrackedObject(GDIIcon, GDIBitmap)
+	defer deleteTrackedObject(GDIIcon, GDIBitmap, info.HbmColor)
+
+	var bitmap BITMAP
+	if ret, _, _ := getObject.Call(info.HbmColor, unsafe.Sizeof(bitmap), uintptr(unsafe.Pointer(&bitmap))); ret == 0 {
+		return nil, fmt.Errorf("failed to read icon bitmap")
+	}
+	width, height := int(bitmap.Width), int(bitmap.Height)
+
+	hdc := createTrackedDC(GDIIcon, 0)
+	if hdc == 0 {
+		return nil, fmt.Errorf("failed to create compatible DC")
+	}
+	defer deleteTrackedDC(GDIIcon, hdc)
+
+	color, err := readBitmapBits(hdc, info.HbmColor, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	hasAlpha := false
+	for i := 3; i < len(color); i += 4 {
+		if color[i] != 0 {
+			hasAlpha = true
+			break
+		}
+	}
+
+	var mask []byte
+	if !hasAlpha && info.HbmMask != 0 {
+		if mask, err = readBitmapBits(hdc, info.HbmMask, width, height); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < len(color); i += 4 {
+		switch {
+		case hasAlpha:
+			// Icon alpha is straight; the encoder expects premultiplied pixels
+			alpha := uint32(color[i+3])
+			color[i] = byte(uint32(color[i]) * alpha / 255)
+			color[i+1] = byte(uint32(color[i+1]) * alpha / 255)
+			color[i+2] = byte(uint32(color[i+2]) * alpha / 255)
+		case mask != nil && mask[i] != 0:
+			// Set mask bits are transparent
+			color[i], color[i+1], color[i+2], color[i+3] = 0, 0, 0, 0
+		default:
+			color[i+3] = 255
+		}
+	}
+
+	return &types.ScreenshotBuffer{
+		Data:      color,
+		Width:     width,
+		Height:    height,
+		Stride:    width * 4,
+		Format:    "BGRA32",
+		DPI:       96,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// readBitmapBits reads a bitmap as top-down 32-bit BGRA pixels
+func readBitmapBits(hdc, bitmap uintptr, width, height int) ([]byte, error) {
+	var bmi BITMAPINFO
+	bmi.Header.Size = uint32(unsafe.Sizeof(bmi.Header))
+	bmi.Header.Width = int32(width)
+	bmi.Header.Height = -int32(height) // Negative height for top-down DIB
+	bmi.Header.Planes = 1
+	bmi.Header.BitCount = 32
+	bmi.Header.Compression = BI_RGB
+
+	data := make([]byte, width*height*4)
+	ret, _, _ := getDIBits.Call(hdc, bitmap, 0, uintptr(height),
+		uintptr(unsafe.Pointer(&data[0])), uintptr(unsafe.Pointer(&bmi)), DIB_RGB_COLORS)
+	if ret == 0 {
+		return nil, fmt.Errorf("GetDIBits failed for icon bitmap")
+	}
+	return data, nil
+}
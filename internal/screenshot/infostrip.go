@@ -0,0 +1,37 @@
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// infoStripSeparator separates the fields of an info strip
+const infoStripSeparator = "  |  "
+
+// InfoStrip burns fields, such as when and where a frame was captured, into a translucent
+// strip along the bottom of a capture, so saved frames describe themselves. Text wider than
+// the capture is cut off on the right. The result keeps the capture's metadata with the
+// pixels as RGBA32.
+func InfoStrip(buffer *types.ScreenshotBuffer, fields ...string) (*types.ScreenshotBuffer, error) {
+	canvas, err := newCanvas(buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Large frames get large text, which stays readable once scaled down
+	scale := 1
+	if buffer.Width >= 1600 {
+		scale = 2
+	}
+	text := textImage(strings.Join(fields, infoStripSeparator), color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.NRGBA{}, scale)
+	height := min(text.Bounds().Dy(), buffer.Height)
+	strip := image.Rect(0, buffer.Height-height, buffer.Width, buffer.Height)
+
+	draw.Draw(canvas, strip, image.NewUniform(color.NRGBA{A: 160}), image.Point{}, draw.Over)
+	draw.Draw(canvas, strip, text, text.Bounds().Min, draw.Over)
+	return fromCanvas(buffer, canvas), nil
+}
@@ -0,0 +1,85 @@
+package screenshot
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// captureFirst captures the topmost window of a manager accepted by match
+func captureFirst(engine types.ScreenshotEngine, windows types.WindowManager, options *types.CaptureOptions, notFound string, match func(*types.WindowInfo) bool) (*types.ScreenshotBuffer, error) {
+	found, err := findWindows(windows, match)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, notFound, nil)
+	}
+	return engine.CaptureByHandle(found[0].Handle, options)
+}
+
+// findWindows lists the windows of a manager accepted by match in z-order
+func findWindows(windows types.WindowManager, match func(*types.WindowInfo) bool) ([]types.WindowInfo, error) {
+	all, err := windows.EnumerateWindows(nil)
+	if err != nil {
+		return nil, err
+	}
+	var found []types.WindowInfo
+	for i := range all {
+		if match(&all[i]) {
+			found = append(found, all[i])
+		}
+	}
+	return found, nil
+}
+
+// resolveSourceRect applies a capture's region to the screen rectangle bounds of the
+// captured window, frame or client area, returning the rectangle to keep
+func resolveSourceRect(bounds types.Rectangle, options *types.CaptureOptions) (types.Rectangle, error) {
+	if options.Region == nil {
+		return bounds, nil
+	}
+
+	region := *options.Region
+	if region.Width <= 0 || region.Height <= 0 {
+		return types.Rectangle{}, fmt.Errorf("invalid region dimensions: %dx%d", region.Width, region.Height)
+	}
+	switch options.RegionRelativeTo {
+	case "", types.RegionRelativeToWindow, types.RegionRelativeToClient:
+		region.X += bounds.X
+		region.Y += bounds.Y
+	case types.RegionRelativeToScreen:
+	default:
+		return types.Rectangle{}, fmt.Errorf("unsupported region origin: %s", options.RegionRelativeTo)
+	}
+
+	clipped, ok := intersect(region, bounds)
+	if !ok {
+		return types.Rectangle{}, fmt.Errorf("region lies outside the window")
+	}
+	return clipped, nil
+}
+
+// cropPixels copies the part of a tightly packed BGRA image covering bounds that lies
+// in rect, which must be inside bounds
+func cropPixels(data []byte, bounds, rect types.Rectangle) []byte {
+	if rect == bounds {
+		return data
+	}
+	cropped := make([]byte, rect.Width*rect.Height*4)
+	for y := 0; y < rect.Height; y++ {
+		src := ((rect.Y-bounds.Y+y)*bounds.Width + rect.X - bounds.X) * 4
+		copy(cropped[y*rect.Width*4:(y+1)*rect.Width*4], data[src:src+rect.Width*4])
+	}
+	return cropped
+}
+
+// intersect returns the overlap of two rectangles
+func intersect(a, b types.Rectangle) (types.Rectangle, bool) {
+	left, top := max(a.X, b.X), max(a.Y, b.Y)
+	right, bottom := min(a.X+a.Width, b.X+b.Width), min(a.Y+a.Height, b.Y+b.Height)
+	if right <= left || bottom <= top {
+		return types.Rectangle{}, false
+	}
+	return types.Rectangle{X: left, Y: top, Width: right - left, Height: bottom - top}, true
+}
@@ -0,0 +1,253 @@
+//go:build !windows
+
+package screenshot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// capturer reads pixels from a platform's display server for NativeEngine. Images are
+// tightly packed BGRA.
+type capturer interface {
+	// captureWindow captures a window's frame, or only its client area if client is
+	// set, returning the screen rectangle the image covers
+	captureWindow(handle uintptr, client bool) (types.Rectangle, []byte, error)
+
+	// captureScreen captures a monitor
+	captureScreen(monitor int) (types.MonitorInfo, []byte, error)
+
+	// monitors lists the monitors captureScreen can capture
+	monitors() ([]types.MonitorInfo, error)
+
+	// windowIcon returns the icon a window advertises, as close to the requested size
+	// as available
+	windowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error)
+
+	// processName returns the executable name of a process, or "" if unknown
+	processName(pid uint32) string
+}
+
+// NativeEngine is the experimental ScreenshotEngine of X11 and macOS. Window lookup goes
+// through the platform's WindowManager and pixels through a capturer, so the server
+// exposes the same API as on Windows. Windows-only features (shell surfaces, popups,
+// tray apps, cloaking) report nothing or fail.
+type NativeEngine struct {
+	windows        types.WindowManager
+	capturer       capturer
+	method         types.CaptureMethod
+	excludedPolicy types.ExcludedWindowPolicy
+	helper         *ElevatedHelper
+}
+
+// SetExcludedWindowPolicy is accepted for parity with the Windows engine; no native
+// backend reports display affinities
+func (e *NativeEngine) SetExcludedWindowPolicy(policy types.ExcludedWindowPolicy) {
+	e.excludedPolicy = policy
+}
+
+// SetElevatedHelper is accepted for parity with the Windows engine
+func (e *NativeEngine) SetElevatedHelper(helper *ElevatedHelper) {
+	e.helper = helper
+}
+
+// CaptureByHandle captures a window's frame, client area or a region of either
+func (e *NativeEngine) CaptureByHandle(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	if options == nil {
+		options = types.DefaultCaptureOptions()
+	}
+
+	info, err := e.windows.GetWindowInfo(handle)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case info.State == "minimized" && !options.AllowMinimized:
+		return nil, types.NewCaptureError(types.ErrCaptureFailed, fmt.Sprintf("window %d is minimized", handle), nil)
+	case !info.IsVisible && !options.AllowHidden:
+		return nil, types.NewCaptureError(types.ErrCaptureFailed, fmt.Sprintf("window %d is hidden", handle), nil)
+	}
+
+	// Regions are relative to the frame unless they name the client area
+	client := !options.IncludeFrame
+	if options.Region != nil {
+		client = options.RegionRelativeTo == types.RegionRelativeToClient
+	}
+	bounds, data, err := e.capturer.captureWindow(handle, client)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := resolveSourceRect(bounds, options)
+	if err != nil {
+		return nil, err
+	}
+	data = cropPixels(data, bounds, source)
+
+	return &types.ScreenshotBuffer{
+		Data:          data,
+		Width:         source.Width,
+		Height:        source.Height,
+		Stride:        source.Width * 4,
+		Format:        "BGRA32",
+		DPI:           96,
+		Timestamp:     time.Now(),
+		SourceRect:    source,
+		WindowInfo:    *info,
+		CaptureMethod: e.method,
+	}, nil
+}
+
+// CaptureByTitle captures the topmost window with exactly this title
+func (e *NativeEngine) CaptureByTitle(title string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, "window not found", func(info *types.WindowInfo) bool {
+		return info.Title == title
+	})
+}
+
+// CaptureByPID captures the topmost visible, titled window of a process
+func (e *NativeEngine) CaptureByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, fmt.Sprintf("no visible window found for PID %d", pid), func(info *types.WindowInfo) bool {
+		return info.ProcessID == pid && info.IsVisible && info.Title != ""
+	})
+}
+
+// CaptureByClassName captures the topmost window of a class (WM_CLASS on X11, the
+// owning application on macOS)
+func (e *NativeEngine) CaptureByClassName(className string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, "window not found", func(info *types.WindowInfo) bool {
+		return info.ClassName == className
+	})
+}
+
+// CaptureByProcessName captures the topmost visible, titled window of a process with
+// this executable name
+func (e *NativeEngine) CaptureByProcessName(name string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	names := make(map[uint32]string)
+	return captureFirst(e, e.windows, options, fmt.Sprintf("no capturable windows found for process %s", name), func(info *types.WindowInfo) bool {
+		if !info.IsVisible || info.Title == "" {
+			return false
+		}
+		processName, ok := names[info.ProcessID]
+		if !ok {
+			processName = e.capturer.processName(info.ProcessID)
+			names[info.ProcessID] = processName
+		}
+		return processName != "" && strings.EqualFold(processName, strings.TrimSuffix(name, ".exe"))
+	})
+}
+
+// Monitors lists the monitors of the display server
+func (e *NativeEngine) Monitors() ([]types.MonitorInfo, error) {
+	return e.capturer.monitors()
+}
+
+// CaptureFullScreen captures a monitor
+func (e *NativeEngine) CaptureFullScreen(monitor int, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	monitorInfo, data, err := e.capturer.captureScreen(monitor)
+	if err != nil {
+		return nil, err
+	}
+	rect := monitorInfo.Rect
+
+	return &types.ScreenshotBuffer{
+		Data:          data,
+		Width:         rect.Width,
+		Height:        rect.Height,
+		Stride:        rect.Width * 4,
+		Format:        "BGRA32",
+		DPI:           96,
+		Timestamp:     time.Now(),
+		SourceRect:    rect,
+		MonitorInfo:   monitorInfo,
+		CaptureMethod: e.method,
+	}, nil
+}
+
+// CaptureHiddenByPID captures the topmost window of a process, shown or not
+func (e *NativeEngine) CaptureHiddenByPID(pid uint32, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return captureFirst(e, e.windows, options, fmt.Sprintf("no windows found for PID %d", pid), func(info *types.WindowInfo) bool {
+		return info.ProcessID == pid
+	})
+}
+
+// CaptureTrayApp captures a process's window like CaptureByProcessName; tray icons
+// themselves can't be captured
+func (e *NativeEngine) CaptureTrayApp(processName string, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.CaptureByProcessName(processName, options)
+}
+
+// CaptureWithFallbacks captures a window; each backend has a single method
+func (e *NativeEngine) CaptureWithFallbacks(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return e.CaptureByHandle(handle, options)
+}
+
+// CaptureShellSurface fails: shell surfaces are parts of the Windows shell
+func (e *NativeEngine) CaptureShellSurface(surface types.ShellSurface, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("%s not found: shell surfaces exist only on Windows", surface), nil)
+}
+
+// CapturePopups fails: popup watching relies on Windows window events
+func (e *NativeEngine) CapturePopups(pid uint32, classes []string, within time.Duration, maxPopups int, options *types.CaptureOptions) ([]*types.ScreenshotBuffer, error) {
+	return nil, fmt.Errorf("popup capture is only supported on Windows")
+}
+
+// EnumerateAllProcessWindows lists the windows of a process
+func (e *NativeEngine) EnumerateAllProcessWindows(pid uint32) ([]types.WindowInfo, error) {
+	return e.windows.EnumerateWindows(&types.WindowFilter{ProcessIDs: []uint32{pid}})
+}
+
+// FindSystemTrayApps reports no tray apps
+func (e *NativeEngine) FindSystemTrayApps() ([]types.WindowInfo, error) {
+	return nil, nil
+}
+
+// FindHiddenWindows lists windows that are neither shown nor minimized
+func (e *NativeEngine) FindHiddenWindows() ([]types.WindowInfo, error) {
+	return findWindows(e.windows, func(info *types.WindowInfo) bool {
+		return !info.IsVisible && info.State != "minimized"
+	})
+}
+
+// FindCloakedWindows reports no windows; cloaking is a DWM concept
+func (e *NativeEngine) FindCloakedWindows() ([]types.WindowInfo, error) {
+	return nil, nil
+}
+
+// FindToastWindows reports no toasts
+func (e *NativeEngine) FindToastWindows() ([]types.WindowInfo, error) {
+	return nil, nil
+}
+
+// GetWindowIcon returns the icon a window advertises
+func (e *NativeEngine) GetWindowIcon(handle uintptr, large bool) (*types.ScreenshotBuffer, error) {
+	if _, err := e.windows.GetWindowInfo(handle); err != nil {
+		return nil, err
+	}
+	return e.capturer.windowIcon(handle, large)
+}
+
+// GetProcessIcon returns the icon of the first of a process's windows that has one
+func (e *NativeEngine) GetProcessIcon(pid uint32, large bool) (*types.ScreenshotBuffer, error) {
+	windows, err := e.EnumerateAllProcessWindows(pid)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("process %d not found", pid), nil)
+	}
+
+	for _, info := range windows {
+		var icon *types.ScreenshotBuffer
+		if icon, err = e.capturer.windowIcon(info.Handle, large); err == nil {
+			return icon, nil
+		}
+	}
+	return nil, err
+}
+
+// Ensure NativeEngine implements the interface
+var _ types.ScreenshotEngine = (*NativeEngine)(nil)
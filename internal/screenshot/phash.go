@@ -0,0 +1,64 @@
+package screenshot
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// phashSize is the side of the grid of blocks a perceptual hash compares; it yields
+// phashSize*phashSize bits
+const phashSize = 16
+
+// PerceptualHash returns a difference hash of a raw capture as hex: the capture is
+// shrunk to a grid of average brightness, and each bit tells whether a block is brighter
+// than its right neighbor. Captures that look the same hash the same even when a few
+// pixels differ, while changes to the layout or a block's contents flip bits.
+func PerceptualHash(buffer *types.ScreenshotBuffer) (string, error) {
+	r, g, b, err := channelOffsets(buffer)
+	if err != nil {
+		return "", err
+	}
+	if buffer.Width == 0 || buffer.Height == 0 {
+		return "", fmt.Errorf("capture is empty")
+	}
+	if err := checkRows(buffer); err != nil {
+		return "", err
+	}
+
+	// Average the luma of a grid one block wider than the hash
+	const columns, rows = phashSize + 1, phashSize
+	var sums [rows][columns]float64
+	var counts [rows][columns]int
+	stride := bufferStride(buffer)
+	for y := 0; y < buffer.Height; y++ {
+		row := buffer.Data[y*stride:]
+		by := y * rows / buffer.Height
+		for x := 0; x < buffer.Width; x++ {
+			p := row[x*4:]
+			bx := x * columns / buffer.Width
+			sums[by][bx] += 0.299*float64(p[r]) + 0.587*float64(p[g]) + 0.114*float64(p[b])
+			counts[by][bx]++
+		}
+	}
+
+	hash := make([]byte, phashSize*phashSize/8)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < phashSize; x++ {
+			if average(sums[y][x], counts[y][x]) > average(sums[y][x+1], counts[y][x+1]) {
+				bit := y*phashSize + x
+				hash[bit/8] |= 1 << (7 - bit%8)
+			}
+		}
+	}
+	return hex.EncodeToString(hash), nil
+}
+
+// average divides a sum by its count, treating empty blocks of tiny captures as black
+func average(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
@@ -0,0 +1,128 @@
+package screenshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Stage is a post-processing step applied to a capture before it is encoded
+type Stage interface {
+	Apply(img image.Image) (image.Image, error)
+}
+
+// StageFunc adapts a function to the Stage interface
+type StageFunc func(img image.Image) (image.Image, error)
+
+// Apply calls f
+func (f StageFunc) Apply(img image.Image) (image.Image, error) {
+	return f(img)
+}
+
+// StageFactory builds a stage from the "params" object of a pipeline entry, which is
+// empty when the entry has none. It should reject invalid parameters, so a bad pipeline
+// fails before anything is captured.
+type StageFactory func(params json.RawMessage) (Stage, error)
+
+var (
+	stagesMu sync.RWMutex
+	stages   = make(map[string]StageFactory)
+)
+
+// RegisterStage makes a stage available to pipelines under name. Programs embedding the
+// server register their own stages at init; registering a name twice panics.
+func RegisterStage(name string, factory StageFactory) {
+	stagesMu.Lock()
+	defer stagesMu.Unlock()
+	if name == "" || factory == nil {
+		panic("screenshot: RegisterStage needs a name and a factory")
+	}
+	if _, exists := stages[name]; exists {
+		panic("screenshot: stage " + name + " registered twice")
+	}
+	stages[name] = factory
+}
+
+// StageNames returns the registered stage names in order
+func StageNames() []string {
+	stagesMu.RLock()
+	defer stagesMu.RUnlock()
+	names := make([]string, 0, len(stages))
+	for name := range stages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pipeline is an ordered list of post-processing stages
+type Pipeline struct {
+	names  []string
+	stages []Stage
+}
+
+// NewPipeline builds a pipeline from its configuration, validating every stage
+func NewPipeline(specs []types.PipelineStage) (*Pipeline, error) {
+	p := &Pipeline{}
+	for i, spec := range specs {
+		stagesMu.RLock()
+		factory, ok := stages[spec.Stage]
+		stagesMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("pipeline stage %d: unknown stage %q (available: %v)", i, spec.Stage, StageNames())
+		}
+		stage, err := factory(spec.Params)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline stage %d (%s): %w", i, spec.Stage, err)
+		}
+		p.names = append(p.names, spec.Stage)
+		p.stages = append(p.stages, stage)
+	}
+	return p, nil
+}
+
+// Len returns the number of stages
+func (p *Pipeline) Len() int {
+	return len(p.stages)
+}
+
+// Apply runs the stages on an image in order
+func (p *Pipeline) Apply(img image.Image) (image.Image, error) {
+	for i, stage := range p.stages {
+		var err error
+		if img, err = stage.Apply(img); err != nil {
+			return nil, fmt.Errorf("pipeline stage %d (%s): %w", i, p.names[i], err)
+		}
+	}
+	return img, nil
+}
+
+// Process runs the stages on a capture. The result keeps the capture's metadata with the
+// processed pixels as RGBA32; a pipeline without stages returns the capture unchanged.
+func (p *Pipeline) Process(buffer *types.ScreenshotBuffer) (*types.ScreenshotBuffer, error) {
+	if len(p.stages) == 0 {
+		return buffer, nil
+	}
+
+	processor := NewImageProcessor()
+	img, err := processor.ToImage(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert capture to image: %w", err)
+	}
+	if img, err = p.Apply(img); err != nil {
+		return nil, err
+	}
+
+	pixels := processor.imageToBuffer(img)
+	processed := *buffer
+	processed.Data = pixels.Data
+	processed.Width = pixels.Width
+	processed.Height = pixels.Height
+	processed.Stride = pixels.Stride
+	processed.Format = pixels.Format
+	return &processed, nil
+}
@@ -0,0 +1,183 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var (
+	setWinEventHook           = user32.NewProc("SetWinEventHook")
+	unhookWinEvent            = user32.NewProc("UnhookWinEvent")
+	msgWaitForMultipleObjects = user32.NewProc("MsgWaitForMultipleObjects")
+	peekMessage               = user32.NewProc("PeekMessageW")
+	translateMessage          = user32.NewProc("TranslateMessage")
+	dispatchMessage           = user32.NewProc("DispatchMessageW")
+)
+
+// WinEvent and message loop constants
+const (
+	EVENT_OBJECT_SHOW     = 0x8002
+	WINEVENT_OUTOFCONTEXT = 0x0000
+	OBJID_WINDOW          = 0
+	PM_REMOVE             = 0x0001
+	QS_ALLINPUT           = 0x04FF
+)
+
+// DefaultPopupClasses are the window classes of context menus and tooltips
+var DefaultPopupClasses = []string{"#32768", "tooltips_class32"}
+
+// MSG is the Win32 message structure
+type MSG struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      win32.POINT
+}
+
+// popupWatch collects popups shown while a hook is armed. Out-of-context WinEvents are
+// delivered on the thread that set the hook, so a watch is only touched by that thread.
+type popupWatch struct {
+	classes map[string]bool
+	seen    map[uintptr]bool
+	pending []uintptr
+}
+
+// popupWatches maps hook handles to their watch. All hooks share one callback because
+// the runtime only allows a limited number of callbacks per process.
+var popupWatches sync.Map
+
+var popupEventCallback = syscall.NewCallback(handlePopupEvent)
+
+// handlePopupEvent is the WinEventProc for popup hooks
+func handlePopupEvent(hook, event, hwnd, idObject, idChild, idEventThread, eventTime uintptr) uintptr {
+	if event != EVENT_OBJECT_SHOW || int32(idObject) != OBJID_WINDOW || hwnd == 0 {
+		return 0
+	}
+	value, ok := popupWatches.Load(hook)
+	if !ok {
+		return 0
+	}
+	value.(*popupWatch).add(hwnd)
+	return 0
+}
+
+// add queues hwnd for capture if it is a popup class that hasn't been seen yet
+func (w *popupWatch) add(hwnd uintptr) {
+	if w.seen[hwnd] {
+		return
+	}
+	if !w.classes[strings.ToLower(win32.GetClassName(hwnd))] {
+		return
+	}
+	w.seen[hwnd] = true
+	w.pending = append(w.pending, hwnd)
+}
+
+// CapturePopups waits up to within for popup windows (context menus, tooltips) of the
+// given classes to appear in a process and captures each as soon as it is shown. Popups
+// already on screen are captured first. It returns once maxPopups have been captured or
+// the time runs out, failing with ErrTimeout if nothing appeared.
+func (e *WindowsScreenshotEngine) CapturePopups(pid uint32, classes []string, within time.Duration, maxPopups int, options *types.CaptureOptions) ([]*types.ScreenshotBuffer, error) {
+	if len(classes) == 0 {
+		classes = DefaultPopupClasses
+	}
+	if maxPopups <= 0 {
+		maxPopups = 1
+	}
+
+	watch := &popupWatch{
+		classes: make(map[string]bool, len(classes)),
+		seen:    make(map[uintptr]bool),
+	}
+	for _, class := range classes {
+		watch.classes[strings.ToLower(class)] = true
+	}
+
+	// The hook's events are delivered to this thread's message queue
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	hook, _, err := setWinEventHook.Call(
+		EVENT_OBJECT_SHOW, EVENT_OBJECT_SHOW, 0, popupEventCallback,
+		uintptr(pid), 0, WINEVENT_OUTOFCONTEXT,
+	)
+	if hook == 0 {
+		return nil, fmt.Errorf("SetWinEventHook failed: %w", err)
+	}
+	popupWatches.Store(hook, watch)
+	defer func() {
+		unhookWinEvent.Call(hook)
+		popupWatches.Delete(hook)
+	}()
+
+	// A popup may have opened before the hook was armed
+	if windows, err := e.EnumerateAllProcessWindows(pid); err == nil {
+		for _, info := range windows {
+			if info.IsVisible {
+				watch.add(info.Handle)
+			}
+		}
+	}
+
+	var buffers []*types.ScreenshotBuffer
+	var lastErr error
+	deadline := time.Now().Add(within)
+	for {
+		for len(watch.pending) > 0 && len(buffers) < maxPopups {
+			hwnd := watch.pending[0]
+			watch.pending = watch.pending[1:]
+
+			buffer, err := e.CaptureByHandle(hwnd, options)
+			if err != nil {
+				// The popup may already have closed
+				lastErr = err
+				continue
+			}
+			buffers = append(buffers, buffer)
+		}
+		if len(buffers) >= maxPopups {
+			return buffers, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		msgWaitForMultipleObjects.Call(0, 0, 0, uintptr(remaining.Milliseconds()+1), QS_ALLINPUT)
+		pumpMessages()
+	}
+
+	if len(buffers) > 0 {
+		return buffers, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, types.NewCaptureError(types.ErrTimeout, fmt.Sprintf("no popup appeared in process %d within %s", pid, within), nil)
+}
+
+// pumpMessages dispatches the messages queued for the current thread, which delivers
+// pending WinEvents to their callback
+func pumpMessages() {
+	var msg MSG
+	for {
+		ret, _, _ := peekMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, PM_REMOVE)
+		if ret == 0 {
+			return
+		}
+		translateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+	}
+}
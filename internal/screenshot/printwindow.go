@@ -0,0 +1,70 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"strings"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+const (
+	GWL_EXSTYLE               = -20
+	WS_EX_NOREDIRECTIONBITMAP = 0x00200000 // The window draws only through DirectComposition
+)
+
+// gpuWindowClasses are class name prefixes of windows that draw with the GPU or through
+// DirectComposition, which plain PrintWindow returns as black
+var gpuWindowClasses = []string{
+	"Chrome_WidgetWin_",             // Chrome, Edge and Electron apps
+	"MozillaWindowClass",            // Firefox
+	"ApplicationFrameWindow",        // UWP app frames
+	"Windows.UI.Core.CoreWindow",    // UWP app content
+	"WinUIDesktopWin32WindowClass",  // WinUI 3
+	"CASCADIA_HOSTING_WINDOW_CLASS", // Windows Terminal
+	"HwndWrapper[",                  // WPF
+}
+
+// rendersWithGPU reports whether a window's content is composed by DWM rather than drawn
+// with GDI, so PrintWindow needs PW_RENDERFULLCONTENT to see it. Windows on another
+// virtual desktop are included, since only the full content path renders them cloaked.
+func rendersWithGPU(handle uintptr, windowInfo *types.WindowInfo) bool {
+	if win32.GetWindowLongPtr(handle, GWL_EXSTYLE)&WS_EX_NOREDIRECTIONBITMAP != 0 {
+		return true
+	}
+	if windowInfo.State == "cloaked" {
+		return true
+	}
+	for _, prefix := range gpuWindowClasses {
+		if strings.HasPrefix(windowInfo.ClassName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tryPrintWindow renders a window off-screen with PrintWindow, with PW_RENDERFULLCONTENT
+// for windows that render with the GPU. PrintWindow succeeds for windows that don't draw
+// themselves into the bitmap, leaving it black, so a black result is retried with the
+// other choice of flag. A result that is black either way is returned for black frame
+// detection to fall back from.
+func (e *WindowsScreenshotEngine) tryPrintWindow(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	flags := uintptr(0)
+	if !options.IncludeFrame {
+		flags = PW_CLIENTONLY
+	}
+	if rendersWithGPU(handle, windowInfo) {
+		flags |= PW_RENDERFULLCONTENT
+	}
+
+	buffer, err := e.printWindowWithFlags(handle, windowInfo, flags)
+	if err != nil || !isBlackFrame(buffer) {
+		return buffer, err
+	}
+	retry, err := e.printWindowWithFlags(handle, windowInfo, flags^PW_RENDERFULLCONTENT)
+	if err != nil || isBlackFrame(retry) {
+		return buffer, nil
+	}
+	return retry, nil
+}
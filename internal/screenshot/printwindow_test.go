@@ -0,0 +1,45 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRendersWithGPU(t *testing.T) {
+	window, err := NewTestWindow(200, 200, 240, 160)
+	require.NoError(t, err)
+	defer window.Close()
+
+	for class, want := range map[string]bool{
+		"Chrome_WidgetWin_1":          true,
+		"MozillaWindowClass":          true,
+		"HwndWrapper[App;;1234-5678]": true,
+		"Notepad":                     false,
+		testWindowClass:               false,
+	} {
+		info := &types.WindowInfo{ClassName: class, State: "visible"}
+		assert.Equal(t, want, rendersWithGPU(window.Handle, info), class)
+	}
+	assert.True(t, rendersWithGPU(window.Handle, &types.WindowInfo{ClassName: "Notepad", State: "cloaked"}))
+}
+
+func TestCapturePrintWindowReadsWindowPixels(t *testing.T) {
+	window, err := NewTestWindow(200, 200, 240, 160)
+	require.NoError(t, err)
+	defer window.Close()
+	time.Sleep(testWindowSettle)
+
+	engine, err := NewEngine()
+	require.NoError(t, err)
+	options := types.DefaultCaptureOptions()
+	options.IncludeFrame = false
+	buffer, err := engine.CaptureWithMethod(window.Handle, types.CapturePrintWindow, options)
+	require.NoError(t, err)
+	assertShowsColor(t, buffer, TestWindowColor)
+}
@@ -0,0 +1,62 @@
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Privacy modes hiding the windows of a desktop capture that aren't allowed
+const (
+	PrivacyBlur  = "blur"  // Blurred beyond recognition, keeping the layout visible
+	PrivacyBlack = "black" // Blacked out
+)
+
+// privacyBlurFactor is how far a blurred desktop is scaled down before being scaled back
+// up, which is much cheaper than a Gaussian blur of a whole display
+const privacyBlurFactor = 32
+
+// PlacedCapture is a capture of a window and where the window is in a desktop capture
+type PlacedCapture struct {
+	Buffer *types.ScreenshotBuffer
+	At     types.Point
+}
+
+// ObscureDesktop hides everything in a desktop capture except some windows: the capture
+// is blurred or blacked out, then each window's own capture is drawn over it where the
+// window is, in order, so windows should be given bottom first. Parts of the allowed
+// windows covered by other windows show the allowed window, as its own capture does.
+// The result keeps the capture's metadata with the pixels as RGBA32.
+func ObscureDesktop(desktop *types.ScreenshotBuffer, mode string, windows []PlacedCapture) (*types.ScreenshotBuffer, error) {
+	var canvas *image.RGBA
+	switch mode {
+	case PrivacyBlur:
+		var err error
+		if canvas, err = newCanvas(desktop); err != nil {
+			return nil, err
+		}
+		small := imaging.Resize(canvas, max(desktop.Width/privacyBlurFactor, 1), max(desktop.Height/privacyBlurFactor, 1), imaging.Box)
+		blurred := imaging.Resize(small, desktop.Width, desktop.Height, imaging.Linear)
+		draw.Draw(canvas, canvas.Bounds(), blurred, image.Point{}, draw.Src)
+	case PrivacyBlack:
+		canvas = image.NewRGBA(image.Rect(0, 0, desktop.Width, desktop.Height))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.RGBA{A: 255}), image.Point{}, draw.Src)
+	default:
+		return nil, fmt.Errorf("privacy mode must be %s or %s", PrivacyBlur, PrivacyBlack)
+	}
+
+	processor := NewImageProcessor()
+	for _, window := range windows {
+		img, err := processor.ToImage(window.Buffer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert window capture to image: %w", err)
+		}
+		at := image.Pt(window.At.X, window.At.Y)
+		draw.Draw(canvas, img.Bounds().Sub(img.Bounds().Min).Add(at), img, img.Bounds().Min, draw.Src)
+	}
+	return fromCanvas(desktop, canvas), nil
+}
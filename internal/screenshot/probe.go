@@ -0,0 +1,142 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	enumDisplayMonitors    = user32.NewProc("EnumDisplayMonitors")
+	enumDisplayDevices     = user32.NewProc("EnumDisplayDevicesW")
+	getProcessDpiAwareness = shcore.NewProc("GetProcessDpiAwareness")
+)
+
+const MONITORINFOF_PRIMARY = 1
+
+// monitorEnumCallback collects monitor handles for Monitors; enumerations are serialized
+// by monitorEnumMutex, so one callback and one slice serve them all
+var (
+	monitorEnumMutex    sync.Mutex
+	monitorEnumHandles  []uintptr
+	monitorEnumCallback = syscall.NewCallback(func(monitor, hdc, rect, data uintptr) uintptr {
+		monitorEnumHandles = append(monitorEnumHandles, monitor)
+		return 1
+	})
+)
+
+// Monitors lists the display monitors in enumeration order, which Index follows
+func (e *WindowsScreenshotEngine) Monitors() ([]types.MonitorInfo, error) {
+	monitorEnumMutex.Lock()
+	monitorEnumHandles = nil
+	ok, _, callErr := enumDisplayMonitors.Call(0, 0, monitorEnumCallback, 0)
+	handles := monitorEnumHandles
+	monitorEnumMutex.Unlock()
+	if ok == 0 {
+		return nil, fmt.Errorf("EnumDisplayMonitors failed: %w", callErr)
+	}
+
+	monitors := make([]types.MonitorInfo, 0, len(handles))
+	for i, handle := range handles {
+		info := MONITORINFOEXW{Size: uint32(unsafe.Sizeof(MONITORINFOEXW{}))}
+		if ok, _, callErr := getMonitorInfoW.Call(handle, uintptr(unsafe.Pointer(&info))); ok == 0 {
+			return nil, fmt.Errorf("GetMonitorInfo failed: %w", callErr)
+		}
+
+		dpi := 96
+		var dpiX, dpiY uint32
+		if hr, _, _ := getDpiForMonitor.Call(handle, MDT_EFFECTIVE_DPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY))); hr == 0 {
+			dpi = int(dpiX)
+		}
+		monitors = append(monitors, types.MonitorInfo{
+			Index:       i,
+			Primary:     info.Flags&MONITORINFOF_PRIMARY != 0,
+			Rect:        info.Monitor.Rectangle(),
+			WorkArea:    info.Work.Rectangle(),
+			DPI:         dpi,
+			ScaleFactor: float64(dpi) / 96,
+			Name:        windows.UTF16ToString(info.Device[:]),
+		})
+	}
+	return monitors, nil
+}
+
+// CompositionEnabled reports whether DWM composition is on. Windows 8 and later can't
+// turn it off, but it is unavailable in some remote and safe-mode sessions.
+func CompositionEnabled() (bool, error) {
+	var enabled int32
+	hr, _, _ := dwmIsCompositionEnabled.Call(uintptr(unsafe.Pointer(&enabled)))
+	if hr != 0 {
+		return false, fmt.Errorf("DwmIsCompositionEnabled failed: HRESULT 0x%08X", uint32(hr))
+	}
+	return enabled != 0, nil
+}
+
+// OSVersion returns the Windows version as "major.minor.build"
+func OSVersion() string {
+	version := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d.%d", version.MajorVersion, version.MinorVersion, version.BuildNumber)
+}
+
+// DISPLAY_DEVICEW structure
+type displayDevice struct {
+	Size       uint32
+	Name       [32]uint16
+	String     [128]uint16
+	StateFlags uint32
+	ID         [128]uint16
+	Key        [128]uint16
+}
+
+// Display device state flags
+const (
+	DISPLAY_DEVICE_ATTACHED_TO_DESKTOP = 0x1
+	DISPLAY_DEVICE_PRIMARY_DEVICE      = 0x4
+)
+
+// DisplayAdapters lists the display devices with the adapter driving each
+func DisplayAdapters() ([]types.DisplayAdapter, error) {
+	var adapters []types.DisplayAdapter
+	for i := 0; ; i++ {
+		device := displayDevice{Size: uint32(unsafe.Sizeof(displayDevice{}))}
+		if ok, _, _ := enumDisplayDevices.Call(0, uintptr(i), uintptr(unsafe.Pointer(&device)), 0); ok == 0 {
+			break
+		}
+		adapters = append(adapters, types.DisplayAdapter{
+			Name:    windows.UTF16ToString(device.String[:]),
+			Device:  windows.UTF16ToString(device.Name[:]),
+			Active:  device.StateFlags&DISPLAY_DEVICE_ATTACHED_TO_DESKTOP != 0,
+			Primary: device.StateFlags&DISPLAY_DEVICE_PRIMARY_DEVICE != 0,
+		})
+	}
+	if len(adapters) == 0 {
+		return nil, fmt.Errorf("EnumDisplayDevices found no display devices")
+	}
+	return adapters, nil
+}
+
+// DPIAwareness returns the process's DPI awareness: "unaware", "system_aware" or
+// "per_monitor_aware"
+func DPIAwareness() string {
+	if getProcessDpiAwareness.Find() != nil {
+		return "unknown"
+	}
+	var awareness uint32
+	if hr, _, _ := getProcessDpiAwareness.Call(0, uintptr(unsafe.Pointer(&awareness))); hr != 0 {
+		return "unknown"
+	}
+	switch awareness {
+	case 0:
+		return "unaware"
+	case 1:
+		return "system_aware"
+	default:
+		return "per_monitor_aware"
+	}
+}
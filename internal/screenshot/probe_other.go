@@ -0,0 +1,29 @@
+//go:build !windows
+
+package screenshot
+
+import (
+	"errors"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// CompositionEnabled fails: only Windows has DWM composition
+func CompositionEnabled() (bool, error) {
+	return false, errors.New("DWM is only available on Windows")
+}
+
+// OSVersion returns "": the server reports the platform's version on Windows only
+func OSVersion() string {
+	return ""
+}
+
+// DisplayAdapters fails: display devices are reported on Windows only
+func DisplayAdapters() ([]types.DisplayAdapter, error) {
+	return nil, errors.New("display adapters are only reported on Windows")
+}
+
+// DPIAwareness returns "": DPI awareness is a Windows process setting
+func DPIAwareness() string {
+	return ""
+}
@@ -0,0 +1,172 @@
+package screenshot
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Method ranking history
+const (
+	rankingHistory     = 50  // Attempts of a method on a class kept, most recent
+	rankingMinAttempts = 5   // Attempts before a method moves in the order
+	rankingMaxClasses  = 256 // Window classes kept, least recently captured dropped first
+)
+
+// methodHistory is the outcome and duration of a method's recent attempts on a class
+type methodHistory struct {
+	success  [rankingHistory]bool
+	duration [rankingHistory]time.Duration
+	count    int // Attempts recorded, up to rankingHistory
+	next     int // Slot of the next attempt
+}
+
+func (h *methodHistory) add(attempt types.CaptureAttempt) {
+	h.success[h.next] = attempt.Success
+	h.duration[h.next] = attempt.Duration
+	h.next = (h.next + 1) % rankingHistory
+	if h.count < rankingHistory {
+		h.count++
+	}
+}
+
+func (h *methodHistory) score(method types.CaptureMethod) types.MethodScore {
+	score := types.MethodScore{Method: method, Attempts: h.count, Ranked: h.count >= rankingMinAttempts}
+	var total time.Duration
+	for i := 0; i < h.count; i++ {
+		if h.success[i] {
+			score.Successes++
+		}
+		total += h.duration[i]
+	}
+	if h.count > 0 {
+		score.SuccessRate = float64(score.Successes) / float64(h.count)
+		score.AverageLatency = total / time.Duration(h.count)
+	}
+	return score
+}
+
+// classHistory is the history of every method tried on a window class
+type classHistory struct {
+	methods map[types.CaptureMethod]*methodHistory
+	used    time.Time
+}
+
+// MethodRanking learns which capture methods work on which window classes from the
+// outcome and duration of every attempt, so fallbacks are tried best first: a class
+// whose windows come out black with BitBlt, such as Chrome_WidgetWin_1, soon tries the
+// methods that succeed on it before BitBlt. It is safe for concurrent use.
+type MethodRanking struct {
+	mutex   sync.Mutex
+	classes map[string]*classHistory
+}
+
+// NewMethodRanking creates a ranking without history, which keeps every order as given
+func NewMethodRanking() *MethodRanking {
+	return &MethodRanking{classes: make(map[string]*classHistory)}
+}
+
+// Record adds an attempt of a method on a window of a class to its history
+func (r *MethodRanking) Record(className string, attempt types.CaptureAttempt) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	class := r.classes[className]
+	if class == nil {
+		if len(r.classes) >= rankingMaxClasses {
+			r.dropLeastRecent()
+		}
+		class = &classHistory{methods: make(map[types.CaptureMethod]*methodHistory)}
+		r.classes[className] = class
+	}
+	class.used = time.Now()
+
+	history := class.methods[attempt.Method]
+	if history == nil {
+		history = &methodHistory{}
+		class.methods[attempt.Method] = history
+	}
+	history.add(attempt)
+}
+
+func (r *MethodRanking) dropLeastRecent() {
+	var oldest string
+	var oldestUsed time.Time
+	for name, class := range r.classes {
+		if oldestUsed.IsZero() || class.used.Before(oldestUsed) {
+			oldest, oldestUsed = name, class.used
+		}
+	}
+	delete(r.classes, oldest)
+}
+
+// Rank orders methods for a window of a class by their recent success rate, the faster
+// first among equally successful ones. Methods with fewer than rankingMinAttempts count
+// as always successful and never faster than a ranked one, so they stay behind methods
+// that have proven themselves but ahead of ones that fail, and are still tried. Without
+// history the order is kept as given.
+func (r *MethodRanking) Rank(className string, methods []types.CaptureMethod) []types.CaptureMethod {
+	scores := make([]types.MethodScore, len(methods))
+	r.mutex.Lock()
+	class := r.classes[className]
+	for i, method := range methods {
+		scores[i] = types.MethodScore{Method: method}
+		if class != nil {
+			if history := class.methods[method]; history != nil {
+				scores[i] = history.score(method)
+			}
+		}
+	}
+	r.mutex.Unlock()
+
+	sortScores(scores)
+	ranked := make([]types.CaptureMethod, len(scores))
+	for i, score := range scores {
+		ranked[i] = score.Method
+	}
+	return ranked
+}
+
+// Rankings returns every class's methods with their scores, best first, ordered by class
+func (r *MethodRanking) Rankings() []types.MethodRanking {
+	r.mutex.Lock()
+	rankings := make([]types.MethodRanking, 0, len(r.classes))
+	for name, class := range r.classes {
+		ranking := types.MethodRanking{ClassName: name, Methods: make([]types.MethodScore, 0, len(class.methods))}
+		for method, history := range class.methods {
+			ranking.Methods = append(ranking.Methods, history.score(method))
+		}
+		rankings = append(rankings, ranking)
+	}
+	r.mutex.Unlock()
+
+	sort.Slice(rankings, func(i, j int) bool { return rankings[i].ClassName < rankings[j].ClassName })
+	for _, ranking := range rankings {
+		// Methods are listed in a map's order; sort by name before ranking so ties are stable
+		sort.Slice(ranking.Methods, func(i, j int) bool { return ranking.Methods[i].Method < ranking.Methods[j].Method })
+		sortScores(ranking.Methods)
+	}
+	return rankings
+}
+
+// sortScores orders scores best first as Rank does, keeping the order of equal ones
+func sortScores(scores []types.MethodScore) {
+	rate := func(score types.MethodScore) float64 {
+		if !score.Ranked {
+			return 1
+		}
+		return score.SuccessRate
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		if rate(a) != rate(b) {
+			return rate(a) > rate(b)
+		}
+		if a.Ranked != b.Ranked {
+			return a.Ranked
+		}
+		return a.Ranked && a.AverageLatency < b.AverageLatency
+	})
+}
@@ -0,0 +1,61 @@
+package screenshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordAttempts(ranking *MethodRanking, class string, method types.CaptureMethod, n int, success bool, duration time.Duration) {
+	for i := 0; i < n; i++ {
+		ranking.Record(class, types.CaptureAttempt{Method: method, Success: success, Duration: duration})
+	}
+}
+
+func TestMethodRankingKeepsOrderWithoutHistory(t *testing.T) {
+	ranking := NewMethodRanking()
+	methods := []types.CaptureMethod{types.CaptureBitBlt, types.CapturePrintWindow, types.CaptureDWMThumbnail}
+	assert.Equal(t, methods, ranking.Rank("Notepad", methods))
+
+	// Too few attempts to move a method
+	recordAttempts(ranking, "Notepad", types.CaptureBitBlt, rankingMinAttempts-1, false, time.Millisecond)
+	assert.Equal(t, methods, ranking.Rank("Notepad", methods))
+}
+
+func TestMethodRankingPrefersSuccessfulMethodsPerClass(t *testing.T) {
+	ranking := NewMethodRanking()
+	methods := []types.CaptureMethod{types.CaptureBitBlt, types.CapturePrintWindow, types.CaptureDWMThumbnail}
+
+	recordAttempts(ranking, "Chrome_WidgetWin_1", types.CaptureBitBlt, 10, false, time.Millisecond)
+	recordAttempts(ranking, "Chrome_WidgetWin_1", types.CaptureDWMThumbnail, 10, true, 20*time.Millisecond)
+	recordAttempts(ranking, "Chrome_WidgetWin_1", types.CapturePrintWindow, 10, true, 5*time.Millisecond)
+
+	// Equally successful methods go fastest first, failing ones last
+	assert.Equal(t,
+		[]types.CaptureMethod{types.CapturePrintWindow, types.CaptureDWMThumbnail, types.CaptureBitBlt},
+		ranking.Rank("Chrome_WidgetWin_1", methods))
+	// Other classes are unaffected
+	assert.Equal(t, methods, ranking.Rank("Notepad", methods))
+
+	rankings := ranking.Rankings()
+	assert.Len(t, rankings, 1)
+	assert.Equal(t, "Chrome_WidgetWin_1", rankings[0].ClassName)
+	assert.Equal(t, types.CapturePrintWindow, rankings[0].Methods[0].Method)
+	assert.Equal(t, 5*time.Millisecond, rankings[0].Methods[0].AverageLatency)
+	assert.Equal(t, 0.0, rankings[0].Methods[2].SuccessRate)
+}
+
+func TestMethodRankingForgetsOldAttempts(t *testing.T) {
+	ranking := NewMethodRanking()
+	methods := []types.CaptureMethod{types.CaptureBitBlt, types.CapturePrintWindow}
+
+	recordAttempts(ranking, "Notepad", types.CaptureBitBlt, rankingHistory, false, time.Millisecond)
+	assert.Equal(t, []types.CaptureMethod{types.CapturePrintWindow, types.CaptureBitBlt}, ranking.Rank("Notepad", methods))
+
+	// A full history of successes replaces the failures
+	recordAttempts(ranking, "Notepad", types.CaptureBitBlt, rankingHistory, true, time.Millisecond)
+	assert.Equal(t, methods, ranking.Rank("Notepad", methods))
+	assert.Equal(t, rankingHistory, ranking.Rankings()[0].Methods[0].Attempts)
+}
@@ -0,0 +1,96 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var clientToScreen = user32.NewProc("ClientToScreen")
+
+// resolveRegion translates options.Region from its RegionRelativeTo coordinate space
+// into coordinates relative to the top-left corner of the window rectangle, clipped
+// to the window. All offsets come from GetWindowRect and ClientToScreen, which report
+// in the same DPI context, so the translation holds on scaled and mixed-DPI monitors.
+func (e *WindowsScreenshotEngine) resolveRegion(handle uintptr, windowInfo *types.WindowInfo, options *types.CaptureOptions) (*types.Rectangle, error) {
+	region := *options.Region
+	if region.Width <= 0 || region.Height <= 0 {
+		return nil, fmt.Errorf("invalid region dimensions: %dx%d", region.Width, region.Height)
+	}
+
+	switch options.RegionRelativeTo {
+	case "", types.RegionRelativeToWindow:
+	case types.RegionRelativeToClient:
+		origin := win32.POINT{}
+		ret, _, _ := clientToScreen.Call(handle, uintptr(unsafe.Pointer(&origin)))
+		if ret == 0 {
+			return nil, fmt.Errorf("failed to locate client area")
+		}
+		region.X += int(origin.X) - windowInfo.Rect.X
+		region.Y += int(origin.Y) - windowInfo.Rect.Y
+	case types.RegionRelativeToScreen:
+		region.X -= windowInfo.Rect.X
+		region.Y -= windowInfo.Rect.Y
+	default:
+		return nil, fmt.Errorf("unknown region_relative_to: %s", options.RegionRelativeTo)
+	}
+
+	// Clip to the window bounds
+	left := max(region.X, 0)
+	top := max(region.Y, 0)
+	right := min(region.X+region.Width, windowInfo.Rect.Width)
+	bottom := min(region.Y+region.Height, windowInfo.Rect.Height)
+	if right <= left || bottom <= top {
+		return nil, fmt.Errorf("region %dx%d+%d+%d (%s) lies outside the window",
+			options.Region.Width, options.Region.Height, options.Region.X, options.Region.Y, regionOrigin(options))
+	}
+
+	return &types.Rectangle{X: left, Y: top, Width: right - left, Height: bottom - top}, nil
+}
+
+// regionOrigin names the coordinate space of options.Region for error messages
+func regionOrigin(options *types.CaptureOptions) types.RegionOrigin {
+	if options.RegionRelativeTo == "" {
+		return types.RegionRelativeToWindow
+	}
+	return options.RegionRelativeTo
+}
+
+// cropToRegion cuts a window-relative region out of a full-window BGRA capture.
+// Methods that already honored the region return smaller buffers, which are left as-is.
+func cropToRegion(buffer *types.ScreenshotBuffer, windowInfo *types.WindowInfo, region types.Rectangle) *types.ScreenshotBuffer {
+	if buffer.Width != windowInfo.Rect.Width || buffer.Height != windowInfo.Rect.Height {
+		return buffer
+	}
+	if region.Width == buffer.Width && region.Height == buffer.Height {
+		return buffer
+	}
+
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+
+	data := make([]byte, region.Width*region.Height*4)
+	for y := 0; y < region.Height; y++ {
+		src := (region.Y+y)*stride + region.X*4
+		copy(data[y*region.Width*4:(y+1)*region.Width*4], buffer.Data[src:src+region.Width*4])
+	}
+
+	cropped := *buffer
+	cropped.Data = data
+	cropped.Width = region.Width
+	cropped.Height = region.Height
+	cropped.Stride = region.Width * 4
+	cropped.SourceRect = types.Rectangle{
+		X:      windowInfo.Rect.X + region.X,
+		Y:      windowInfo.Rect.Y + region.Y,
+		Width:  region.Width,
+		Height: region.Height,
+	}
+	return &cropped
+}
@@ -0,0 +1,125 @@
+package screenshot
+
+import (
+	"sort"
+	"sync"
+)
+
+// GDI subsystems, the capture paths that create GDI handles
+const (
+	GDIBitBlt       = "bitblt"
+	GDIPrintWindow  = "printwindow"
+	GDIDWMThumbnail = "dwmthumbnail"
+	GDIWMPrint      = "wmprint"
+	GDIIcon         = "icon"
+	GDIColorProfile = "color_profile"
+	GDITestWindow   = "test_window"
+)
+
+// GDI handle kinds
+const (
+	GDIDeviceContext = "dc"
+	GDIBitmap        = "bitmap"
+	GDIBrush         = "brush"
+)
+
+// GDIUsage counts the GDI handles of one kind a subsystem created and released
+type GDIUsage struct {
+	Subsystem string `json:"subsystem"`
+	Kind      string `json:"kind"`
+	Created   uint64 `json:"created"`
+	Released  uint64 `json:"released"`
+}
+
+// Outstanding returns the handles created but not released yet: those in use, or leaked
+func (u GDIUsage) Outstanding() int64 {
+	return int64(u.Created) - int64(u.Released)
+}
+
+type gdiKey struct {
+	subsystem string
+	kind      string
+}
+
+// gdiTracker counts the GDI handles the engine creates and releases
+var gdiTracker = struct {
+	mutex  sync.Mutex
+	counts map[gdiKey]*GDIUsage
+}{counts: make(map[gdiKey]*GDIUsage)}
+
+// trackGDI records a created (delta 1) or released (delta -1) handle
+func trackGDI(subsystem, kind string, delta int) {
+	gdiTracker.mutex.Lock()
+	defer gdiTracker.mutex.Unlock()
+	key := gdiKey{subsystem, kind}
+	usage, ok := gdiTracker.counts[key]
+	if !ok {
+		usage = &GDIUsage{Subsystem: subsystem, Kind: kind}
+		gdiTracker.counts[key] = usage
+	}
+	if delta > 0 {
+		usage.Created++
+	} else {
+		usage.Released++
+	}
+}
+
+// GDIHandleUsage returns the GDI handle counts of each subsystem, sorted by subsystem
+// and kind. Only the Windows engine creates GDI handles.
+func GDIHandleUsage() []GDIUsage {
+	gdiTracker.mutex.Lock()
+	defer gdiTracker.mutex.Unlock()
+	usage := make([]GDIUsage, 0, len(gdiTracker.counts))
+	for _, counts := range gdiTracker.counts {
+		usage = append(usage, *counts)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Subsystem != usage[j].Subsystem {
+			return usage[i].Subsystem < usage[j].Subsystem
+		}
+		return usage[i].Kind < usage[j].Kind
+	})
+	return usage
+}
+
+// GDIFootprint returns the GDI objects the process holds: the count Windows reports, or
+// the engine's outstanding handles where Windows can't report it
+func GDIFootprint() int64 {
+	if objects, err := ProcessGDIObjects(); err == nil {
+		return int64(objects)
+	}
+	var outstanding int64
+	for _, usage := range GDIHandleUsage() {
+		outstanding += usage.Outstanding()
+	}
+	return outstanding
+}
+
+// GDIGrowthWatch detects a GDI footprint that grows with every sample, the sign of a
+// capture path leaking handles. A footprint that also shrinks is just the engine at work.
+type GDIGrowthWatch struct {
+	samples []int64
+	limit   int
+}
+
+// NewGDIGrowthWatch creates a watch that reports growth over samples consecutive samples
+func NewGDIGrowthWatch(samples int) *GDIGrowthWatch {
+	return &GDIGrowthWatch{limit: samples}
+}
+
+// Sample records the current footprint. It reports growing when the footprint rose at
+// every one of the last samples, returning the first and latest of them, and starts
+// over so continued growth is reported once per run of samples.
+func (w *GDIGrowthWatch) Sample() (growing bool, first, latest int64) {
+	footprint := GDIFootprint()
+	if n := len(w.samples); n > 0 && footprint <= w.samples[n-1] {
+		w.samples = w.samples[:0]
+	}
+	w.samples = append(w.samples, footprint)
+	if len(w.samples) < w.limit {
+		return false, 0, footprint
+	}
+	first = w.samples[0]
+	w.samples = append(w.samples[:0], footprint)
+	return true, first, footprint
+}
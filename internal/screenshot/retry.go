@@ -0,0 +1,138 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// methodsError reports that every method in a capture chain failed
+type methodsError struct {
+	attempts []types.CaptureAttempt
+	last     error
+}
+
+func (e *methodsError) Error() string {
+	return fmt.Sprintf("all capture methods failed, last error: %v", e.last)
+}
+
+func (e *methodsError) Unwrap() error {
+	return e.last
+}
+
+// captureWithRetries runs capture passes until one succeeds or RetryCount retries
+// have failed, backing off exponentially between passes. Each retry starts from a
+// different method so a method that fails deterministically isn't simply repeated.
+// Cancelling the options' context stops it between passes. Failures are
+// types.AttemptsError with the attempts of every pass.
+func (e *WindowsScreenshotEngine) captureWithRetries(handle uintptr, windowInfo *types.WindowInfo, isMinimized bool, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	var attempts []types.CaptureAttempt
+
+	for retry := 0; ; retry++ {
+		buffer, passAttempts, err := e.capturePass(handle, windowInfo, isMinimized, options, retry)
+		for i := range passAttempts {
+			passAttempts[i].Retry = retry
+		}
+		attempts = append(attempts, passAttempts...)
+
+		if err == nil {
+			buffer.Attempts = attempts
+			buffer.Retries = retry
+			return buffer, nil
+		}
+
+		if retry >= options.RetryCount {
+			if retry > 0 {
+				err = fmt.Errorf("%w (gave up after %d retries)", err, retry)
+			}
+			return nil, &types.AttemptsError{Attempts: attempts, Err: err}
+		}
+
+		if err := types.Sleep(options.Context, retryDelay(options, retry)); err != nil {
+			return nil, &types.AttemptsError{Attempts: attempts, Err: err}
+		}
+	}
+}
+
+// capturePass performs one capture pass and returns the attempts it made. The first
+// pass uses the configured method chain or the state-based default; retries rotate
+// through the chain, or through the fallback methods suited to the window's state.
+func (e *WindowsScreenshotEngine) capturePass(handle uintptr, windowInfo *types.WindowInfo, isMinimized bool, options *types.CaptureOptions, retry int) (*types.ScreenshotBuffer, []types.CaptureAttempt, error) {
+	var methods []types.CaptureMethod
+	if options.PreferredMethod != "" && options.PreferredMethod != types.CaptureAuto {
+		// Use the explicitly requested method chain
+		chain := e.deduplicateMethods(append([]types.CaptureMethod{options.PreferredMethod}, options.FallbackMethods...))
+		methods = rotateMethods(chain, retry)
+	} else if retry > 0 {
+		rotation := e.selectCaptureMethods(windowInfo, options)
+		methods = []types.CaptureMethod{rotation[retry%len(rotation)]}
+	}
+
+	if len(methods) > 0 {
+		buffer, err := e.captureWithMethods(handle, windowInfo, methods, options)
+		if err != nil {
+			var failure *methodsError
+			if errors.As(err, &failure) {
+				return nil, failure.attempts, err
+			}
+			return nil, nil, err
+		}
+		return buffer, buffer.Attempts, nil
+	}
+
+	start := time.Now()
+	var buffer *types.ScreenshotBuffer
+	var err error
+	method := types.CaptureBitBlt
+	if isMinimized && options.AllowMinimized && !options.RestoreWindow {
+		// Use DWM/PrintWindow for minimized windows
+		method = types.CapturePrintWindow
+		buffer, err = e.captureMinimizedWindow(handle, windowInfo, options)
+	} else {
+		// Use BitBlt for visible windows
+		buffer, err = e.captureVisibleWindow(handle, windowInfo, options)
+	}
+
+	attempt := types.CaptureAttempt{Method: method, Duration: time.Since(start), Success: err == nil}
+	if err != nil {
+		attempt.Error = err.Error()
+		return nil, []types.CaptureAttempt{attempt}, err
+	}
+
+	if buffer.CaptureMethod == "" {
+		buffer.CaptureMethod = method
+	}
+	// A minimized capture that fell back to restoring the window has its own attempts
+	if len(buffer.Attempts) > 0 {
+		return buffer, buffer.Attempts, nil
+	}
+	return buffer, []types.CaptureAttempt{attempt}, nil
+}
+
+// rotateMethods returns methods starting from position n, wrapping around
+func rotateMethods(methods []types.CaptureMethod, n int) []types.CaptureMethod {
+	if len(methods) == 0 {
+		return methods
+	}
+	n %= len(methods)
+	return append(append([]types.CaptureMethod{}, methods[n:]...), methods[:n]...)
+}
+
+// retryDelay returns the exponential backoff before the retry following retry n
+func retryDelay(options *types.CaptureOptions, n int) time.Duration {
+	delay := options.RetryBackoff
+	if delay <= 0 {
+		return 0
+	}
+	for i := 0; i < n && (options.RetryMaxBackoff <= 0 || delay < options.RetryMaxBackoff); i++ {
+		delay *= 2
+	}
+	if options.RetryMaxBackoff > 0 && delay > options.RetryMaxBackoff {
+		return options.RetryMaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,154 @@
+package screenshot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// PixelColor is the color of one pixel of a capture
+type PixelColor struct {
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Color string `json:"color"` // "#rrggbb"
+	R     uint8  `json:"r"`
+	G     uint8  `json:"g"`
+	B     uint8  `json:"b"`
+	A     uint8  `json:"a"`
+}
+
+// ColorCount is one of the dominant colors of a region: the average color of the
+// pixels that fall in the same quantization bucket
+type ColorCount struct {
+	Color    string  `json:"color"` // "#rrggbb"
+	Count    int     `json:"count"`
+	Fraction float64 `json:"fraction"` // Share of the region's pixels
+}
+
+// ColorHistogram summarizes the colors of a region of a capture
+type ColorHistogram struct {
+	Region     types.Rectangle `json:"region"`
+	PixelCount int             `json:"pixel_count"`
+	Average    string          `json:"average"` // "#rrggbb"
+	Dominant   []ColorCount    `json:"dominant"`
+}
+
+// channelOffsets returns the byte offsets of red, green and blue in a raw capture
+func channelOffsets(buffer *types.ScreenshotBuffer) (r, g, b int, err error) {
+	switch buffer.Format {
+	case "BGRA32":
+		return 2, 1, 0, nil
+	case "RGBA32":
+		return 0, 1, 2, nil
+	}
+	return 0, 0, 0, fmt.Errorf("unsupported buffer format for sampling: %s", buffer.Format)
+}
+
+// SamplePixel returns the color of the pixel at x, y of a raw capture
+func SamplePixel(buffer *types.ScreenshotBuffer, x, y int) (PixelColor, error) {
+	r, g, b, err := channelOffsets(buffer)
+	if err != nil {
+		return PixelColor{}, err
+	}
+	if x < 0 || y < 0 || x >= buffer.Width || y >= buffer.Height {
+		return PixelColor{}, fmt.Errorf("pixel %d,%d is outside the %dx%d image", x, y, buffer.Width, buffer.Height)
+	}
+	offset := y*bufferStride(buffer) + x*4
+	if offset+3 >= len(buffer.Data) {
+		return PixelColor{}, fmt.Errorf("pixel %d,%d is outside the image data", x, y)
+	}
+	p := buffer.Data[offset : offset+4]
+	return PixelColor{
+		X:     x,
+		Y:     y,
+		Color: hexColor(p[r], p[g], p[b]),
+		R:     p[r],
+		G:     p[g],
+		B:     p[b],
+		A:     p[3],
+	}, nil
+}
+
+// Histogram returns the average color of a region of a raw capture and its top most
+// common colors. Colors are grouped by their top bits bits per channel, so nearly
+// identical shades from anti-aliasing and gradients count as one.
+func Histogram(buffer *types.ScreenshotBuffer, region types.Rectangle, bits, top int) (*ColorHistogram, error) {
+	r, g, b, err := channelOffsets(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if bits < 1 || bits > 8 {
+		return nil, fmt.Errorf("bits must be between 1 and 8")
+	}
+	bounds, ok := intersect(region, types.Rectangle{Width: buffer.Width, Height: buffer.Height})
+	if !ok {
+		return nil, fmt.Errorf("region is outside the %dx%d image", buffer.Width, buffer.Height)
+	}
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint32]*bucket)
+	var sum [3]int
+	shift := 8 - bits
+	stride := bufferStride(buffer)
+	for y := bounds.Y; y < bounds.Y+bounds.Height; y++ {
+		row := buffer.Data[y*stride:]
+		for x := bounds.X; x < bounds.X+bounds.Width; x++ {
+			p := row[x*4 : x*4+4]
+			key := uint32(p[r]>>shift)<<16 | uint32(p[g]>>shift)<<8 | uint32(p[b]>>shift)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.count++
+			bk.r += int(p[r])
+			bk.g += int(p[g])
+			bk.b += int(p[b])
+			sum[0] += int(p[r])
+			sum[1] += int(p[g])
+			sum[2] += int(p[b])
+		}
+	}
+
+	total := bounds.Width * bounds.Height
+	histogram := &ColorHistogram{
+		Region:     bounds,
+		PixelCount: total,
+		Average:    hexColor(uint8(sum[0]/total), uint8(sum[1]/total), uint8(sum[2]/total)),
+		Dominant:   []ColorCount{},
+	}
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		sorted = append(sorted, bk)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].r+sorted[i].g+sorted[i].b < sorted[j].r+sorted[j].g+sorted[j].b
+	})
+	for _, bk := range sorted[:min(top, len(sorted))] {
+		histogram.Dominant = append(histogram.Dominant, ColorCount{
+			Color:    hexColor(uint8(bk.r/bk.count), uint8(bk.g/bk.count), uint8(bk.b/bk.count)),
+			Count:    bk.count,
+			Fraction: float64(bk.count) / float64(total),
+		})
+	}
+	return histogram, nil
+}
+
+// bufferStride returns the row length of a raw capture
+func bufferStride(buffer *types.ScreenshotBuffer) int {
+	if buffer.Stride > 0 {
+		return buffer.Stride
+	}
+	return buffer.Width * 4
+}
+
+func hexColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
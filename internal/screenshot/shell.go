@@ -0,0 +1,109 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// toastTitle is the title Windows gives toast windows on English installs
+const toastTitle = "New notification"
+
+// CaptureShellSurface captures part of the Windows shell: the taskbar, the tray overflow
+// flyout or the most recent toast notification
+func (e *WindowsScreenshotEngine) CaptureShellSurface(surface types.ShellSurface, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	handle, err := e.findShellSurface(surface)
+	if err != nil {
+		return nil, err
+	}
+	return e.CaptureByHandle(handle, options)
+}
+
+// findShellSurface returns the window that hosts a shell surface
+func (e *WindowsScreenshotEngine) findShellSurface(surface types.ShellSurface) (uintptr, error) {
+	switch surface {
+	case types.ShellTaskbar:
+		handle, err := e.findWindow("Shell_TrayWnd", "")
+		if err != nil {
+			return 0, types.NewCaptureError(types.ErrWindowNotFound, "taskbar not found", err)
+		}
+		return handle, nil
+
+	case types.ShellTrayOverflow:
+		// Windows 10 shows a classic flyout, Windows 11 hosts it in a XAML island
+		for _, className := range []string{"NotifyIconOverflowWindow", "TopLevelWindowForOverflowXamlIsland"} {
+			handle, err := e.findWindow(className, "")
+			if err != nil {
+				continue
+			}
+			if win32.IsWindowVisible(handle) && !win32.IsCloaked(handle) {
+				return handle, nil
+			}
+		}
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "tray overflow flyout is not open", nil)
+
+	case types.ShellNotifications:
+		toasts, err := e.FindToastWindows()
+		if err != nil {
+			return 0, err
+		}
+		if len(toasts) == 0 {
+			return 0, types.NewCaptureError(types.ErrWindowNotFound, "no toast notification is showing", nil)
+		}
+		return toasts[0].Handle, nil
+
+	default:
+		return 0, fmt.Errorf("unknown shell surface: %s", surface)
+	}
+}
+
+// FindToastWindows returns the toast notifications currently on screen, topmost first.
+// Toasts are CoreWindows hosted by ShellExperienceHost; the host's other CoreWindows
+// (Start, Action Center) stay cloaked while closed, so only visible, uncloaked windows
+// are considered, and those titled like a toast are ranked first.
+func (e *WindowsScreenshotEngine) FindToastWindows() ([]types.WindowInfo, error) {
+	hosts, err := e.findProcessesByName("ShellExperienceHost.exe")
+	if err != nil {
+		// No shell experience host means no toasts
+		return nil, nil
+	}
+	isHost := make(map[uint32]bool)
+	for _, pid := range hosts {
+		isHost[pid] = true
+	}
+
+	var toasts []types.WindowInfo
+	zOrder := 0
+	win32.EnumWindows(func(hwnd uintptr) bool {
+		zOrder++
+
+		if win32.GetClassName(hwnd) != "Windows.UI.Core.CoreWindow" {
+			return true
+		}
+		if !isHost[win32.WindowProcessID(hwnd)] {
+			return true
+		}
+		if !win32.IsWindowVisible(hwnd) || win32.IsCloaked(hwnd) {
+			return true
+		}
+
+		if info, err := e.getWindowInfo(hwnd); err == nil {
+			info.ZOrder = zOrder
+			toasts = append(toasts, *info)
+		}
+		return true // Continue enumeration
+	})
+
+	sort.SliceStable(toasts, func(i, j int) bool {
+		iToast, jToast := toasts[i].Title == toastTitle, toasts[j].Title == toastTitle
+		if iToast != jToast {
+			return iToast
+		}
+		return toasts[i].ZOrder < toasts[j].ZOrder
+	})
+	return toasts, nil
+}
@@ -0,0 +1,133 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// captureWindowStack captures a window together with the visible top-level windows it
+// owns (dialogs, tool windows, popups), each drawn at its screen position over the
+// window. The image covers the union of their rectangles so a dialog that extends past
+// the window's edge isn't cut off; a region stays relative to the owner window.
+func (e *WindowsScreenshotEngine) captureWindowStack(handle uintptr, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	single := *options
+	single.IncludeOwnedWindows = false
+
+	owned := e.findOwnedWindows(handle)
+	if len(owned) == 0 {
+		return e.CaptureByHandle(handle, &single)
+	}
+
+	single.Region = nil
+	owner, err := e.CaptureByHandle(handle, &single)
+	if err != nil {
+		return nil, err
+	}
+
+	// Owned windows are drawn bottom to top; the enumeration lists them topmost first
+	layers := []*types.ScreenshotBuffer{owner}
+	var stacked []types.WindowInfo
+	for i := len(owned) - 1; i >= 0; i-- {
+		layer, err := e.CaptureByHandle(owned[i], &single)
+		if err != nil {
+			continue // The dialog may have closed in the meantime
+		}
+		layers = append(layers, layer)
+		stacked = append(stacked, layer.WindowInfo)
+	}
+
+	canvas := layerRect(owner)
+	for _, layer := range layers[1:] {
+		canvas = unionRect(canvas, layerRect(layer))
+	}
+
+	data := make([]byte, canvas.Width*canvas.Height*4)
+	for _, layer := range layers {
+		drawLayer(data, canvas, layer)
+	}
+
+	composite := *owner
+	composite.Data = data
+	composite.Width = canvas.Width
+	composite.Height = canvas.Height
+	composite.Stride = canvas.Width * 4
+	composite.SourceRect = canvas
+	composite.OwnedWindows = stacked
+
+	if options.Region != nil {
+		region, err := e.resolveRegion(handle, &owner.WindowInfo, options)
+		if err != nil {
+			return nil, err
+		}
+		region.X += owner.WindowInfo.Rect.X - canvas.X
+		region.Y += owner.WindowInfo.Rect.Y - canvas.Y
+
+		canvasInfo := owner.WindowInfo
+		canvasInfo.Rect = canvas
+		cropped := cropToRegion(&composite, &canvasInfo, *region)
+		cropped.WindowInfo = owner.WindowInfo
+		return cropped, nil
+	}
+	return &composite, nil
+}
+
+// findOwnedWindows returns the visible, uncloaked top-level windows owned directly or
+// indirectly by owner, topmost first
+func (e *WindowsScreenshotEngine) findOwnedWindows(owner uintptr) []uintptr {
+	var owned []uintptr
+	win32.EnumWindows(func(hwnd uintptr) bool {
+		if hwnd == owner || !win32.IsWindowVisible(hwnd) || win32.IsCloaked(hwnd) || win32.IsIconic(hwnd) {
+			return true
+		}
+
+		for parent := win32.GetWindow(hwnd, win32.GW_OWNER); parent != 0; parent = win32.GetWindow(parent, win32.GW_OWNER) {
+			if parent == owner {
+				owned = append(owned, hwnd)
+				break
+			}
+		}
+		return true // Continue enumeration
+	})
+	return owned
+}
+
+// layerRect returns the screen rectangle a captured window occupies
+func layerRect(layer *types.ScreenshotBuffer) types.Rectangle {
+	return types.Rectangle{
+		X:      layer.WindowInfo.Rect.X,
+		Y:      layer.WindowInfo.Rect.Y,
+		Width:  layer.Width,
+		Height: layer.Height,
+	}
+}
+
+// unionRect returns the smallest rectangle containing a and b
+func unionRect(a, b types.Rectangle) types.Rectangle {
+	left := min(a.X, b.X)
+	top := min(a.Y, b.Y)
+	right := max(a.X+a.Width, b.X+b.Width)
+	bottom := max(a.Y+a.Height, b.Y+b.Height)
+	return types.Rectangle{X: left, Y: top, Width: right - left, Height: bottom - top}
+}
+
+// drawLayer copies a BGRA capture into the canvas at its screen position
+func drawLayer(data []byte, canvas types.Rectangle, layer *types.ScreenshotBuffer) {
+	rect := layerRect(layer)
+	stride := layer.Stride
+	if stride == 0 {
+		stride = layer.Width * 4
+	}
+
+	offsetX, offsetY := rect.X-canvas.X, rect.Y-canvas.Y
+	rowBytes := layer.Width * 4
+	for y := 0; y < layer.Height; y++ {
+		src := y * stride
+		if src+rowBytes > len(layer.Data) {
+			return
+		}
+		dst := ((offsetY+y)*canvas.Width + offsetX) * 4
+		copy(data[dst:dst+rowBytes], layer.Data[src:src+rowBytes])
+	}
+}
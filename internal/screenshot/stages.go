@@ -0,0 +1,433 @@
+package screenshot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Built-in pipeline stages
+func init() {
+	RegisterStage("resize", newResizeStage)
+	RegisterStage("crop", newCropStage)
+	RegisterStage("annotate", newAnnotateStage)
+	RegisterStage("redact", newRedactStage)
+	RegisterStage("watermark", newWatermarkStage)
+	RegisterStage("sharpen", newSharpenStage)
+}
+
+// decodeParams decodes a stage's params into v, rejecting unknown fields
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
+
+// resizeParams sizes the image: an exact width and/or height (0 keeps the aspect ratio),
+// a maximum size it is scaled down to fit, or a scale factor
+type resizeParams struct {
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	MaxWidth  int     `json:"max_width"`
+	MaxHeight int     `json:"max_height"`
+	Scale     float64 `json:"scale"`
+}
+
+func newResizeStage(raw json.RawMessage) (Stage, error) {
+	var params resizeParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Width < 0 || params.Height < 0 || params.MaxWidth < 0 || params.MaxHeight < 0 || params.Scale < 0 {
+		return nil, fmt.Errorf("sizes must not be negative")
+	}
+	modes := 0
+	if params.Width > 0 || params.Height > 0 {
+		modes++
+	}
+	if params.MaxWidth > 0 || params.MaxHeight > 0 {
+		modes++
+	}
+	if params.Scale > 0 {
+		modes++
+	}
+	if modes != 1 {
+		return nil, fmt.Errorf("set one of width/height, max_width/max_height or scale")
+	}
+
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		bounds := img.Bounds()
+		switch {
+		case params.Scale > 0:
+			width := max(int(float64(bounds.Dx())*params.Scale), 1)
+			height := max(int(float64(bounds.Dy())*params.Scale), 1)
+			return imaging.Resize(img, width, height, imaging.Lanczos), nil
+		case params.MaxWidth > 0 || params.MaxHeight > 0:
+			maxWidth, maxHeight := params.MaxWidth, params.MaxHeight
+			if maxWidth == 0 {
+				maxWidth = bounds.Dx()
+			}
+			if maxHeight == 0 {
+				maxHeight = bounds.Dy()
+			}
+			if bounds.Dx() <= maxWidth && bounds.Dy() <= maxHeight {
+				return img, nil
+			}
+			return imaging.Fit(img, maxWidth, maxHeight, imaging.Lanczos), nil
+		default:
+			return imaging.Resize(img, params.Width, params.Height, imaging.Lanczos), nil
+		}
+	}), nil
+}
+
+func newCropStage(raw json.RawMessage) (Stage, error) {
+	var rect types.Rectangle
+	if err := decodeParams(raw, &rect); err != nil {
+		return nil, err
+	}
+	if rect.Width <= 0 || rect.Height <= 0 {
+		return nil, fmt.Errorf("width and height must be positive")
+	}
+
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		bounds := img.Bounds()
+		crop := rectangle(rect).Add(bounds.Min).Intersect(bounds)
+		if crop.Empty() {
+			return nil, fmt.Errorf("crop rectangle is outside the image")
+		}
+		return imaging.Crop(img, crop), nil
+	}), nil
+}
+
+// annotateBox is a rectangle drawn by the annotate stage
+type annotateBox struct {
+	types.Rectangle
+	Label string `json:"label"`
+	Color string `json:"color"` // Overrides the stage's color
+}
+
+// annotateParams outlines boxes, each with an optional label above it
+type annotateParams struct {
+	Boxes     []annotateBox `json:"boxes"`
+	Color     string        `json:"color"`      // Default "#ff0000"
+	Thickness int           `json:"thickness"`  // Outline width in pixels, default 3
+	TextScale int           `json:"text_scale"` // Label size multiplier, default 2
+}
+
+func newAnnotateStage(raw json.RawMessage) (Stage, error) {
+	params := annotateParams{Color: "#ff0000", Thickness: 3, TextScale: 2}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Boxes) == 0 {
+		return nil, fmt.Errorf("boxes must not be empty")
+	}
+	if params.Thickness < 1 || params.TextScale < 1 {
+		return nil, fmt.Errorf("thickness and text_scale must be positive")
+	}
+	defaultColor, err := parseColor(params.Color)
+	if err != nil {
+		return nil, err
+	}
+	colors := make([]color.NRGBA, len(params.Boxes))
+	for i, box := range params.Boxes {
+		colors[i] = defaultColor
+		if box.Color != "" {
+			if colors[i], err = parseColor(box.Color); err != nil {
+				return nil, fmt.Errorf("box %d: %w", i, err)
+			}
+		}
+	}
+
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		canvas := imaging.Clone(img)
+		for i, box := range params.Boxes {
+			rect := rectangle(box.Rectangle)
+			src := image.NewUniform(colors[i])
+			t := params.Thickness
+			for _, edge := range []image.Rectangle{
+				image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+t),
+				image.Rect(rect.Min.X, rect.Max.Y-t, rect.Max.X, rect.Max.Y),
+				image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+t, rect.Max.Y),
+				image.Rect(rect.Max.X-t, rect.Min.Y, rect.Max.X, rect.Max.Y),
+			} {
+				draw.Draw(canvas, edge, src, image.Point{}, draw.Over)
+			}
+
+			if box.Label != "" {
+				label := textImage(box.Label, contrastColor(colors[i]), colors[i], params.TextScale)
+				// Above the box, or inside its top edge when there's no room
+				at := image.Pt(rect.Min.X, rect.Min.Y-label.Bounds().Dy())
+				if at.Y < 0 {
+					at.Y = rect.Min.Y
+				}
+				draw.Draw(canvas, label.Bounds().Add(at), label, image.Point{}, draw.Over)
+			}
+		}
+		return canvas, nil
+	}), nil
+}
+
+// redactParams hides regions by filling, pixelating or blurring them
+type redactParams struct {
+	Regions   []types.Rectangle `json:"regions"`
+	Mode      string            `json:"mode"`       // "fill" (default), "pixelate" or "blur"
+	Color     string            `json:"color"`      // Fill color, default "#000000"
+	BlockSize int               `json:"block_size"` // Pixelate block size and blur strength, default 12
+}
+
+func newRedactStage(raw json.RawMessage) (Stage, error) {
+	params := redactParams{Mode: "fill", Color: "#000000", BlockSize: 12}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Regions) == 0 {
+		return nil, fmt.Errorf("regions must not be empty")
+	}
+	fill, err := parseColor(params.Color)
+	if err != nil {
+		return nil, err
+	}
+	switch params.Mode {
+	case "fill", "pixelate", "blur":
+	default:
+		return nil, fmt.Errorf("mode must be fill, pixelate or blur")
+	}
+	if params.BlockSize < 2 {
+		return nil, fmt.Errorf("block_size must be at least 2")
+	}
+
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		canvas := imaging.Clone(img)
+		for _, region := range params.Regions {
+			rect := rectangle(region).Intersect(canvas.Bounds())
+			if rect.Empty() {
+				continue
+			}
+			switch params.Mode {
+			case "fill":
+				draw.Draw(canvas, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+			case "pixelate":
+				area := imaging.Crop(canvas, rect)
+				small := imaging.Resize(area, max(rect.Dx()/params.BlockSize, 1), max(rect.Dy()/params.BlockSize, 1), imaging.Box)
+				blocks := imaging.Resize(small, rect.Dx(), rect.Dy(), imaging.NearestNeighbor)
+				draw.Draw(canvas, rect, blocks, image.Point{}, draw.Src)
+			case "blur":
+				// A blur strong enough that text can't be recovered
+				blurred := imaging.Blur(imaging.Crop(canvas, rect), float64(params.BlockSize))
+				draw.Draw(canvas, rect, blurred, image.Point{}, draw.Src)
+			}
+		}
+		return canvas, nil
+	}), nil
+}
+
+// watermarkParams labels the image with text and/or a logo
+type watermarkParams struct {
+	Text      string  `json:"text"`
+	Logo      string  `json:"logo"`       // Base64 PNG, optionally as a data URL; drawn left of the text
+	LogoWidth int     `json:"logo_width"` // Logo width in pixels, 0 for its own size
+	Position  string  `json:"position"`   // "top_left", "top_right", "bottom_left", "bottom_right" (default) or "center"
+	Opacity   float64 `json:"opacity"`    // 0-1, default 1
+	Color     string  `json:"color"`      // Default "#ffffff"
+	TextScale int     `json:"text_scale"` // Text size multiplier, default 2
+	Margin    int     `json:"margin"`     // Distance from the edges, default 10
+}
+
+func newWatermarkStage(raw json.RawMessage) (Stage, error) {
+	params := watermarkParams{Position: "bottom_right", Opacity: 1, Color: "#ffffff", TextScale: 2, Margin: 10}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Text == "" && params.Logo == "" {
+		return nil, fmt.Errorf("text or logo is required")
+	}
+	if params.TextScale < 1 || params.Margin < 0 || params.LogoWidth < 0 {
+		return nil, fmt.Errorf("text_scale must be positive and margin and logo_width not negative")
+	}
+	if params.Opacity <= 0 || params.Opacity > 1 {
+		return nil, fmt.Errorf("opacity must be greater than 0 and at most 1")
+	}
+	textColor, err := parseColor(params.Color)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := placement(params.Position, image.Rect(0, 0, 1, 1), image.Rect(0, 0, 1, 1), 0); err != nil {
+		return nil, err
+	}
+
+	var parts []image.Image
+	if params.Logo != "" {
+		logo, err := decodeLogo(params.Logo)
+		if err != nil {
+			return nil, err
+		}
+		if params.LogoWidth > 0 {
+			logo = imaging.Resize(logo, params.LogoWidth, 0, imaging.Lanczos)
+		}
+		parts = append(parts, logo)
+	}
+	if params.Text != "" {
+		// A translucent backing keeps the text readable on any background
+		parts = append(parts, textImage(params.Text, textColor, color.NRGBA{A: 128}, params.TextScale))
+	}
+	mark := joinImages(parts, params.Margin/2)
+	opacity := image.NewUniform(color.Alpha{A: uint8(params.Opacity*255 + 0.5)})
+
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		canvas := imaging.Clone(img)
+		at, _ := placement(params.Position, canvas.Bounds(), mark.Bounds(), params.Margin)
+		bounds := mark.Bounds()
+		draw.DrawMask(canvas, image.Rectangle{Min: at, Max: at.Add(bounds.Size())}, mark, bounds.Min, opacity, image.Point{}, draw.Over)
+		return canvas, nil
+	}), nil
+}
+
+// decodeLogo decodes a base64 PNG, accepting a data URL too
+func decodeLogo(value string) (image.Image, error) {
+	if strings.HasPrefix(value, "data:") {
+		if _, data, ok := strings.Cut(value, ","); ok {
+			value = data
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("logo is not valid base64: %w", err)
+	}
+	logo, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("logo is not a valid PNG: %w", err)
+	}
+	return logo, nil
+}
+
+// joinImages places images side by side, vertically centred, gap pixels apart
+func joinImages(parts []image.Image, gap int) image.Image {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	width, height := gap*(len(parts)-1), 0
+	for _, part := range parts {
+		width += part.Bounds().Dx()
+		height = max(height, part.Bounds().Dy())
+	}
+	joined := image.NewNRGBA(image.Rect(0, 0, width, height))
+	x := 0
+	for _, part := range parts {
+		bounds := part.Bounds()
+		at := image.Pt(x, (height-bounds.Dy())/2)
+		draw.Draw(joined, image.Rectangle{Min: at, Max: at.Add(bounds.Size())}, part, bounds.Min, draw.Src)
+		x += bounds.Dx() + gap
+	}
+	return joined
+}
+
+// sharpenParams sets the strength of the sharpen stage
+type sharpenParams struct {
+	Sigma float64 `json:"sigma"` // Default 1
+}
+
+func newSharpenStage(raw json.RawMessage) (Stage, error) {
+	params := sharpenParams{Sigma: 1}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.Sigma <= 0 {
+		return nil, fmt.Errorf("sigma must be positive")
+	}
+	return StageFunc(func(img image.Image) (image.Image, error) {
+		return imaging.Sharpen(img, params.Sigma), nil
+	}), nil
+}
+
+// rectangle converts a Rectangle to image coordinates
+func rectangle(r types.Rectangle) image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// placement returns where an overlay of size mark goes in bounds for a position
+func placement(position string, bounds, mark image.Rectangle, margin int) (image.Point, error) {
+	left := bounds.Min.X + margin
+	right := bounds.Max.X - mark.Dx() - margin
+	top := bounds.Min.Y + margin
+	bottom := bounds.Max.Y - mark.Dy() - margin
+	switch position {
+	case "top_left":
+		return image.Pt(left, top), nil
+	case "top_right":
+		return image.Pt(right, top), nil
+	case "bottom_left":
+		return image.Pt(left, bottom), nil
+	case "bottom_right", "":
+		return image.Pt(right, bottom), nil
+	case "center":
+		return image.Pt(bounds.Min.X+(bounds.Dx()-mark.Dx())/2, bounds.Min.Y+(bounds.Dy()-mark.Dy())/2), nil
+	}
+	return image.Point{}, fmt.Errorf("position must be top_left, top_right, bottom_left, bottom_right or center")
+}
+
+// textImage renders a line of text on a background, scaled up by scale
+func textImage(text string, fg, bg color.NRGBA, scale int) image.Image {
+	face := basicfont.Face7x13
+	const padding = 2
+	width := font.MeasureString(face, text).Ceil() + 2*padding
+	height := face.Metrics().Height.Ceil() + 2*padding
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(fg),
+		Face: face,
+		Dot:  fixed.P(padding, padding+face.Metrics().Ascent.Ceil()),
+	}
+	drawer.DrawString(text)
+
+	if scale <= 1 {
+		return img
+	}
+	return imaging.Resize(img, width*scale, height*scale, imaging.NearestNeighbor)
+}
+
+// contrastColor returns black or white, whichever reads better on c
+func contrastColor(c color.NRGBA) color.NRGBA {
+	if 299*int(c.R)+587*int(c.G)+114*int(c.B) > 128000 {
+		return color.NRGBA{A: 255}
+	}
+	return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+// parseColor parses "#rrggbb" or "#rrggbbaa"
+func parseColor(value string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q (use #rrggbb or #rrggbbaa)", value)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q (use #rrggbb or #rrggbbaa)", value)
+	}
+	if len(hex) == 6 {
+		n = n<<8 | 0xff
+	}
+	return color.NRGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+}
@@ -0,0 +1,180 @@
+package screenshot
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Stitching limits
+const (
+	minStitchOverlap  = 8    // Rows a shift must overlap the previous capture by to be trusted
+	stitchMismatchMax = 0.02 // Share of overlapping rows allowed to differ, such as a blinking caret
+)
+
+// Stitcher joins captures of a window taken while its content scrolls down into one
+// tall image. Rows that stay put between two captures, such as toolbars at the top and
+// status bars at the bottom, are kept once; the band between them is matched against
+// the previous capture to find how far it scrolled, and only the rows it brought into
+// view are added. Rows are compared without the right margin, where the scroll bar's
+// thumb moves.
+type Stitcher struct {
+	width, height int
+	format        string
+	margin        int
+	first         *types.ScreenshotBuffer
+	rows          [][]byte // Stitched rows; the last ones are those of the previous capture
+	previous      []uint64 // Row hashes of the previous capture
+	unmatched     int
+}
+
+// NewStitcher starts a stitched image with the first capture, ignoring margin columns
+// on the right when comparing rows
+func NewStitcher(first *types.ScreenshotBuffer, margin int) (*Stitcher, error) {
+	if _, _, _, err := channelOffsets(first); err != nil {
+		return nil, err
+	}
+	if first.Width == 0 || first.Height == 0 {
+		return nil, fmt.Errorf("capture is empty")
+	}
+	if err := checkRows(first); err != nil {
+		return nil, err
+	}
+	if margin < 0 || margin >= first.Width {
+		margin = 0
+	}
+	s := &Stitcher{
+		width:  first.Width,
+		height: first.Height,
+		format: first.Format,
+		margin: margin,
+		first:  first,
+	}
+	s.rows = s.copyRows(first, 0, first.Height)
+	s.previous = s.hashRows(first)
+	return s, nil
+}
+
+// Add stitches the next capture below the image and returns the rows it added, 0 when
+// the content didn't move because its end was reached. matched is false when no
+// overlap with the previous capture was found and the whole band was added.
+func (s *Stitcher) Add(next *types.ScreenshotBuffer) (added int, matched bool, err error) {
+	if next.Width != s.width || next.Height != s.height || next.Format != s.format {
+		return 0, false, fmt.Errorf("capture changed from %dx%d %s to %dx%d %s while scrolling",
+			s.width, s.height, s.format, next.Width, next.Height, next.Format)
+	}
+	if err := checkRows(next); err != nil {
+		return 0, false, err
+	}
+	hashes := s.hashRows(next)
+
+	// Fixed rows at the top and bottom are those that didn't change
+	top := 0
+	for top < s.height && hashes[top] == s.previous[top] {
+		top++
+	}
+	if top == s.height {
+		return 0, true, nil
+	}
+	bottom := 0
+	for bottom < s.height-top && hashes[s.height-1-bottom] == s.previous[s.height-1-bottom] {
+		bottom++
+	}
+
+	band := s.height - top - bottom
+	shift, matched := bestShift(s.previous[top:s.height-bottom], hashes[top:s.height-bottom])
+	if !matched {
+		shift = band
+		s.unmatched++
+	}
+
+	// The stitched image ends with the previous capture's bottom rows, which are the
+	// next capture's too; replace them with the rows scrolled into view and the footer
+	s.rows = s.rows[:len(s.rows)-bottom]
+	s.rows = append(s.rows, s.copyRows(next, s.height-bottom-shift, s.height)...)
+	s.previous = hashes
+	return shift, matched, nil
+}
+
+// bestShift returns how many rows the band of the next capture scrolled relative to the
+// previous one: the smallest shift whose overlap matches best
+func bestShift(previous, next []uint64) (int, bool) {
+	best, bestMismatch := 0, stitchMismatchMax
+	for shift := 1; len(previous)-shift >= minStitchOverlap; shift++ {
+		overlap := len(previous) - shift
+		allowed := int(float64(overlap) * bestMismatch)
+		mismatched := 0
+		for i := 0; i < overlap && mismatched <= allowed; i++ {
+			if previous[shift+i] != next[i] {
+				mismatched++
+			}
+		}
+		if mismatched > allowed {
+			continue
+		}
+		if mismatch := float64(mismatched) / float64(overlap); best == 0 || mismatch < bestMismatch {
+			best, bestMismatch = shift, mismatch
+			if mismatched == 0 {
+				break
+			}
+		}
+	}
+	return best, best > 0
+}
+
+// Height returns the height of the stitched image
+func (s *Stitcher) Height() int {
+	return len(s.rows)
+}
+
+// Unmatched returns how many captures were added without an overlap
+func (s *Stitcher) Unmatched() int {
+	return s.unmatched
+}
+
+// Image returns the stitched image, with the window and monitor of the first capture
+func (s *Stitcher) Image() *types.ScreenshotBuffer {
+	stride := s.width * 4
+	data := make([]byte, 0, stride*len(s.rows))
+	for _, row := range s.rows {
+		data = append(data, row...)
+	}
+	image := *s.first
+	image.Data = data
+	image.Height = len(s.rows)
+	image.Stride = stride
+	image.BlackFrameDetected = false
+	image.Stability = nil
+	return &image
+}
+
+// copyRows copies rows [from, to) of a capture
+func (s *Stitcher) copyRows(buffer *types.ScreenshotBuffer, from, to int) [][]byte {
+	stride := bufferStride(buffer)
+	rows := make([][]byte, 0, to-from)
+	for y := from; y < to; y++ {
+		rows = append(rows, append([]byte(nil), buffer.Data[y*stride:y*stride+s.width*4]...))
+	}
+	return rows
+}
+
+// hashRows hashes each row of a capture without the margin
+func (s *Stitcher) hashRows(buffer *types.ScreenshotBuffer) []uint64 {
+	stride := bufferStride(buffer)
+	hashes := make([]uint64, buffer.Height)
+	for y := range hashes {
+		hash := fnv.New64a()
+		hash.Write(buffer.Data[y*stride : y*stride+(s.width-s.margin)*4])
+		hashes[y] = hash.Sum64()
+	}
+	return hashes
+}
+
+// checkRows checks a capture has the data of all its rows
+func checkRows(buffer *types.ScreenshotBuffer) error {
+	if len(buffer.Data) < bufferStride(buffer)*(buffer.Height-1)+buffer.Width*4 {
+		return fmt.Errorf("capture data is shorter than its %dx%d size", buffer.Width, buffer.Height)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package screenshot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Template matching searches a downscaled copy of the image first and refines the best
+// candidates at full resolution, which keeps a desktop-sized search well under a second
+const (
+	coarseTemplateSize = 12  // Smallest template side the coarse search scales down to
+	maxCoarseFactor    = 8   // Largest coarse downscale factor
+	maxCandidates      = 64  // Coarse peaks refined per scale
+	coarseSlack        = 0.2 // Coarse peaks may score this much below the threshold
+	overlapLimit       = 0.3 // Matches overlapping a better one by more than this are dropped
+)
+
+// TemplateOptions controls FindTemplate
+type TemplateOptions struct {
+	Threshold  float64   // Minimum normalized cross-correlation, 0-1
+	Scales     []float64 // Template scales to try; empty means 1
+	MaxResults int       // 0 means no limit
+}
+
+// TemplateMatch is where a template was found in an image
+type TemplateMatch struct {
+	Box        types.Rectangle `json:"box"`
+	Confidence float64         `json:"confidence"` // Normalized cross-correlation, 0-1
+	Scale      float64         `json:"scale"`      // Template scale that matched
+}
+
+// FindTemplate locates a template image in a capture by normalized cross-correlation of
+// their grayscale pixels, trying each scale of the template. Matches are ordered by
+// confidence; overlapping matches keep only the best.
+func FindTemplate(haystack, template *types.ScreenshotBuffer, options TemplateOptions) ([]TemplateMatch, error) {
+	image, err := toGray(haystack)
+	if err != nil {
+		return nil, err
+	}
+	scales := options.Scales
+	if len(scales) == 0 {
+		scales = []float64{1}
+	}
+
+	processor := NewImageProcessor()
+	var matches []TemplateMatch
+	for _, scale := range scales {
+		scaled := template
+		if scale != 1 {
+			width := int(math.Round(float64(template.Width) * scale))
+			height := int(math.Round(float64(template.Height) * scale))
+			if width < 1 || height < 1 {
+				continue
+			}
+			if scaled, err = processor.Resize(template, width, height); err != nil {
+				return nil, err
+			}
+		}
+		if scaled.Width > image.width || scaled.Height > image.height {
+			continue
+		}
+		tmpl, err := toGray(scaled)
+		if err != nil {
+			return nil, err
+		}
+		found, err := matchScale(image, tmpl, options.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range found {
+			m.Scale = scale
+			matches = append(matches, m)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	kept := []TemplateMatch{}
+	for _, m := range matches {
+		if options.MaxResults > 0 && len(kept) == options.MaxResults {
+			break
+		}
+		overlaps := false
+		for _, k := range kept {
+			if overlap(m.Box, k.Box) > overlapLimit {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// matchScale finds the matches of one template scale
+func matchScale(image, tmpl *grayImage, threshold float64) ([]TemplateMatch, error) {
+	factor := min(max(min(tmpl.width, tmpl.height)/coarseTemplateSize, 1), maxCoarseFactor)
+	coarseImage, coarseTmpl := image.downscale(factor), tmpl.downscale(factor)
+	coarse, err := newCorrelator(coarseImage, coarseTmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	// Coarse search: local maxima of the correlation map
+	width := coarseImage.width - coarseTmpl.width + 1
+	height := coarseImage.height - coarseTmpl.height + 1
+	scores := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scores[y*width+x] = coarse.at(x, y)
+		}
+	}
+	type peak struct {
+		x, y  int
+		score float64
+	}
+	var peaks []peak
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			score := scores[y*width+x]
+			if score < threshold-coarseSlack || !localMaximum(scores, width, height, x, y) {
+				continue
+			}
+			peaks = append(peaks, peak{x, y, score})
+		}
+	}
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].score > peaks[j].score })
+	peaks = peaks[:min(len(peaks), maxCandidates)]
+
+	// Refine each peak at full resolution
+	full, err := newCorrelator(image, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var matches []TemplateMatch
+	for _, p := range peaks {
+		best, bestX, bestY := -1.0, 0, 0
+		for y := max(p.y*factor-factor, 0); y <= min(p.y*factor+factor, image.height-tmpl.height); y++ {
+			for x := max(p.x*factor-factor, 0); x <= min(p.x*factor+factor, image.width-tmpl.width); x++ {
+				if score := full.at(x, y); score > best {
+					best, bestX, bestY = score, x, y
+				}
+			}
+		}
+		if best >= threshold {
+			matches = append(matches, TemplateMatch{
+				Box:        types.Rectangle{X: bestX, Y: bestY, Width: tmpl.width, Height: tmpl.height},
+				Confidence: math.Round(best*1000) / 1000,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// localMaximum reports whether a score is at least as high as its 8 neighbours
+func localMaximum(scores []float64, width, height, x, y int) bool {
+	score := scores[y*width+x]
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && ny >= 0 && nx < width && ny < height && scores[ny*width+nx] > score {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// overlap returns the intersection of two rectangles over the smaller one's area
+func overlap(a, b types.Rectangle) float64 {
+	shared, ok := intersect(a, b)
+	if !ok {
+		return 0
+	}
+	return float64(shared.Width*shared.Height) / float64(min(a.Width*a.Height, b.Width*b.Height))
+}
+
+// grayImage is a grayscale image for matching
+type grayImage struct {
+	width, height int
+	pix           []float64
+}
+
+// toGray converts a raw or encoded capture to grayscale
+func toGray(buffer *types.ScreenshotBuffer) (*grayImage, error) {
+	r, g, b, err := channelOffsets(buffer)
+	if err != nil {
+		return nil, err
+	}
+	gray := &grayImage{width: buffer.Width, height: buffer.Height, pix: make([]float64, buffer.Width*buffer.Height)}
+	stride := bufferStride(buffer)
+	for y := 0; y < buffer.Height; y++ {
+		row := buffer.Data[y*stride:]
+		for x := 0; x < buffer.Width; x++ {
+			p := row[x*4:]
+			gray.pix[y*buffer.Width+x] = 0.299*float64(p[r]) + 0.587*float64(p[g]) + 0.114*float64(p[b])
+		}
+	}
+	return gray, nil
+}
+
+// downscale averages factor x factor blocks
+func (g *grayImage) downscale(factor int) *grayImage {
+	if factor <= 1 {
+		return g
+	}
+	small := &grayImage{width: g.width / factor, height: g.height / factor}
+	small.pix = make([]float64, small.width*small.height)
+	area := float64(factor * factor)
+	for y := 0; y < small.height; y++ {
+		for x := 0; x < small.width; x++ {
+			sum := 0.0
+			for dy := 0; dy < factor; dy++ {
+				row := (y*factor + dy) * g.width
+				for dx := 0; dx < factor; dx++ {
+					sum += g.pix[row+x*factor+dx]
+				}
+			}
+			small.pix[y*small.width+x] = sum / area
+		}
+	}
+	return small
+}
+
+// correlator computes the normalized cross-correlation of a template at positions of
+// an image, using integral images for the image window statistics
+type correlator struct {
+	image    *grayImage
+	tmpl     []float64 // Template minus its mean
+	tw, th   int
+	tmplNorm float64 // Sum of squares of tmpl
+	sum      []float64
+	sumSq    []float64
+}
+
+func newCorrelator(image, tmpl *grayImage) (*correlator, error) {
+	n := float64(len(tmpl.pix))
+	mean := 0.0
+	for _, v := range tmpl.pix {
+		mean += v
+	}
+	mean /= n
+	c := &correlator{image: image, tmpl: make([]float64, len(tmpl.pix)), tw: tmpl.width, th: tmpl.height}
+	for i, v := range tmpl.pix {
+		c.tmpl[i] = v - mean
+		c.tmplNorm += c.tmpl[i] * c.tmpl[i]
+	}
+	if c.tmplNorm < 1e-6*n {
+		return nil, fmt.Errorf("template is a single flat color, which matches anywhere")
+	}
+
+	w := image.width + 1
+	c.sum = make([]float64, w*(image.height+1))
+	c.sumSq = make([]float64, w*(image.height+1))
+	for y := 0; y < image.height; y++ {
+		rowSum, rowSq := 0.0, 0.0
+		for x := 0; x < image.width; x++ {
+			v := image.pix[y*image.width+x]
+			rowSum += v
+			rowSq += v * v
+			c.sum[(y+1)*w+x+1] = c.sum[y*w+x+1] + rowSum
+			c.sumSq[(y+1)*w+x+1] = c.sumSq[y*w+x+1] + rowSq
+		}
+	}
+	return c, nil
+}
+
+// at returns the correlation of the template with the image window at x, y; flat
+// windows score 0
+func (c *correlator) at(x, y int) float64 {
+	w := c.image.width + 1
+	window := func(table []float64) float64 {
+		return table[(y+c.th)*w+x+c.tw] - table[y*w+x+c.tw] - table[(y+c.th)*w+x] + table[y*w+x]
+	}
+	n := float64(c.tw * c.th)
+	sum := window(c.sum)
+	variance := window(c.sumSq) - sum*sum/n
+	if variance < 1e-6*n {
+		return 0
+	}
+
+	cross := 0.0
+	for ty := 0; ty < c.th; ty++ {
+		row := c.image.pix[(y+ty)*c.image.width+x : (y+ty)*c.image.width+x+c.tw]
+		tmpl := c.tmpl[ty*c.tw : (ty+1)*c.tw]
+		for i, v := range row {
+			cross += v * tmpl[i]
+		}
+	}
+	return cross / math.Sqrt(variance*c.tmplNorm)
+}
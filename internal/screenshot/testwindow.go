@@ -0,0 +1,217 @@
+//go:build windows
+
+package screenshot
+
+import (
+	"fmt"
+	"image/color"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	registerClassEx  = user32.NewProc("RegisterClassExW")
+	createWindowEx   = user32.NewProc("CreateWindowExW")
+	defWindowProc    = user32.NewProc("DefWindowProcW")
+	getMessage       = user32.NewProc("GetMessageW")
+	updateWindow     = user32.NewProc("UpdateWindow")
+	createSolidBrush = gdi32.NewProc("CreateSolidBrush")
+
+	setLayeredWindowAttributes = user32.NewProc("SetLayeredWindowAttributes")
+)
+
+// Tool window constants
+const (
+	WS_POPUP          = 0x80000000
+	WS_EX_TOPMOST     = 0x00000008
+	WS_EX_TOOLWINDOW  = 0x00000080
+	WS_EX_LAYERED     = 0x00080000
+	WS_EX_NOACTIVATE  = 0x08000000
+	WS_EX_TRANSPARENT = 0x00000020
+	LWA_ALPHA         = 0x00000002
+	WM_CLOSE          = 0x0010
+
+	testWindowClass = "ScreenshotMCPSelfTest"
+	testWindowTitle = "Screenshot MCP self-test"
+)
+
+// TestWindowColor is the solid color a test window is filled with
+var TestWindowColor = color.RGBA{R: 0x33, G: 0x99, B: 0xFF, A: 0xFF}
+
+// wndClassEx is the Win32 WNDCLASSEXW structure
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   windows.Handle
+	Icon       windows.Handle
+	Cursor     windows.Handle
+	Background uintptr
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     windows.Handle
+}
+
+// TestWindow is a borderless, topmost window filled with TestWindowColor that
+// self-tests capture against. It lives on its own OS thread, which pumps its messages.
+type TestWindow struct {
+	*toolWindow
+}
+
+// NewTestWindow creates and shows a test window of the given size at x, y
+func NewTestWindow(x, y, width, height int) (*TestWindow, error) {
+	window, err := newToolWindow(toolWindowSpec{
+		class:      testWindowClass,
+		title:      testWindowTitle,
+		exStyle:    WS_EX_TOPMOST | WS_EX_TOOLWINDOW,
+		x:          x,
+		y:          y,
+		width:      width,
+		height:     height,
+		background: &TestWindowColor,
+		subsystem:  GDITestWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TestWindow{window}, nil
+}
+
+// toolWindowSpec describes a borderless popup window created by newToolWindow
+type toolWindowSpec struct {
+	class      string
+	title      string
+	exStyle    uintptr
+	x, y       int
+	width      int
+	height     int
+	background *color.RGBA // Color the window is filled with, if any
+	subsystem  string      // GDI subsystem the background brush is tracked under
+}
+
+// toolWindowClasses are the window classes registered for tool windows, by name. A class
+// and its background brush last as long as the process, since windows of the class may
+// be open on other threads at any time.
+var toolWindowClasses = struct {
+	sync.Mutex
+	registered map[string]bool
+}{registered: make(map[string]bool)}
+
+// registerToolWindowClass registers the window class of spec unless it is registered.
+// Every window of a class shares the background of the spec that registered it.
+func registerToolWindowClass(spec toolWindowSpec, instance windows.Handle) error {
+	toolWindowClasses.Lock()
+	defer toolWindowClasses.Unlock()
+	if toolWindowClasses.registered[spec.class] {
+		return nil
+	}
+
+	var brush uintptr
+	if spec.background != nil {
+		c := spec.background
+		brush, _, _ = createSolidBrush.Call(uintptr(c.R) | uintptr(c.G)<<8 | uintptr(c.B)<<16)
+		if brush == 0 {
+			return fmt.Errorf("CreateSolidBrush failed")
+		}
+	}
+	className, _ := windows.UTF16PtrFromString(spec.class)
+	class := wndClassEx{
+		WndProc:    defWindowProc.Addr(),
+		Instance:   instance,
+		Background: brush,
+		ClassName:  className,
+	}
+	class.Size = uint32(unsafe.Sizeof(class))
+	if atom, _, err := registerClassEx.Call(uintptr(unsafe.Pointer(&class))); atom == 0 {
+		if brush != 0 {
+			deleteObject.Call(brush)
+		}
+		return fmt.Errorf("RegisterClassEx failed: %v", err)
+	}
+	if brush != 0 {
+		adoptTrackedObject(spec.subsystem, GDIBrush)
+	}
+	toolWindowClasses.registered[spec.class] = true
+	return nil
+}
+
+// toolWindow is a borderless popup window the server creates for itself. It lives on its
+// own OS thread, which pumps its messages until Close.
+type toolWindow struct {
+	Handle uintptr
+	done   chan struct{}
+}
+
+// newToolWindow creates and shows a window without activating it. Layered windows are
+// made fully opaque, since they aren't drawn until their attributes are set.
+func newToolWindow(spec toolWindowSpec) (*toolWindow, error) {
+	created := make(chan error, 1)
+	w := &toolWindow{done: make(chan struct{})}
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(w.done)
+
+		var instance windows.Handle
+		if err := windows.GetModuleHandleEx(0, nil, &instance); err != nil {
+			created <- fmt.Errorf("GetModuleHandleEx failed: %w", err)
+			return
+		}
+		if err := registerToolWindowClass(spec, instance); err != nil {
+			created <- err
+			return
+		}
+
+		className, _ := windows.UTF16PtrFromString(spec.class)
+		title, _ := windows.UTF16PtrFromString(spec.title)
+
+		hwnd, _, callErr := createWindowEx.Call(
+			spec.exStyle,
+			uintptr(unsafe.Pointer(className)),
+			uintptr(unsafe.Pointer(title)),
+			WS_POPUP,
+			uintptr(spec.x), uintptr(spec.y), uintptr(spec.width), uintptr(spec.height),
+			0, 0, uintptr(instance), 0,
+		)
+		if hwnd == 0 {
+			created <- fmt.Errorf("CreateWindowEx failed: %v", callErr)
+			return
+		}
+		if spec.exStyle&WS_EX_LAYERED != 0 {
+			setLayeredWindowAttributes.Call(hwnd, 0, 255, LWA_ALPHA)
+		}
+		win32.ShowWindow(hwnd, win32.SW_SHOWNOACTIVATE)
+		updateWindow.Call(hwnd)
+		w.Handle = hwnd
+		created <- nil
+
+		// Pump messages until Close destroys the window
+		var msg MSG
+		for win32.IsWindow(hwnd) {
+			ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if ret == 0 || int32(ret) == -1 {
+				break
+			}
+			translateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+			dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		}
+	}()
+
+	if err := <-created; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close destroys the window and waits for its thread to finish
+func (w *toolWindow) Close() {
+	postMessage.Call(w.Handle, WM_CLOSE, 0, 0)
+	<-w.done
+}
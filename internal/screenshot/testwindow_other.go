@@ -0,0 +1,24 @@
+//go:build !windows
+
+package screenshot
+
+import (
+	"errors"
+	"image/color"
+)
+
+// TestWindowColor is the solid color a test window is filled with
+var TestWindowColor = color.RGBA{R: 0x33, G: 0x99, B: 0xFF, A: 0xFF}
+
+// TestWindow is a window that self-tests capture against; only Windows can create one
+type TestWindow struct {
+	Handle uintptr
+}
+
+// NewTestWindow fails: test windows are created on Windows only
+func NewTestWindow(x, y, width, height int) (*TestWindow, error) {
+	return nil, errors.New("test windows are only supported on Windows")
+}
+
+// Close does nothing
+func (w *TestWindow) Close() {}
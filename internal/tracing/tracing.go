@@ -0,0 +1,114 @@
+// Package tracing instruments the stages of the capture pipeline: capturing, converting,
+// encoding and sending frames. A span labels the CPU profile samples taken while it runs
+// with its stack, such as "stream;encode;convert", and is a runtime/trace region, so both
+// profiles and execution traces from the pprof endpoints break down by stage. The time
+// spans take is also summed per stack and served as folded stacks for flame graphs.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Label is the pprof label holding a span's stack
+const Label = "stage"
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// Span times one stage of a capture
+type Span struct {
+	stack  string
+	parent context.Context
+	region *trace.Region
+	start  time.Time
+}
+
+// Start begins a span of stage inside the span ctx carries, if any, and returns a
+// context carrying the new span for its own stages. End must be called on the same
+// goroutine.
+func Start(ctx context.Context, stage string) (context.Context, *Span) {
+	stack := stage
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		stack = parent.stack + ";" + stage
+	}
+	span := &Span{stack: stack, parent: ctx}
+	ctx = pprof.WithLabels(context.WithValue(ctx, spanKey{}, span), pprof.Labels(Label, stack))
+	pprof.SetGoroutineLabels(ctx)
+	span.region = trace.StartRegion(ctx, stack)
+	span.start = time.Now()
+	return ctx, span
+}
+
+// StartIn begins a span of stage only inside the span ctx carries, for stages of code
+// that also runs outside the instrumented pipelines. Without one it returns ctx and a nil
+// span, whose End does nothing.
+func StartIn(ctx context.Context, stage string) (context.Context, *Span) {
+	if _, ok := ctx.Value(spanKey{}).(*Span); !ok {
+		return ctx, nil
+	}
+	return Start(ctx, stage)
+}
+
+// End ends the span, adding its duration to its stack's total
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	elapsed := time.Since(s.start)
+	s.region.End()
+	pprof.SetGoroutineLabels(s.parent)
+
+	totals.mutex.Lock()
+	totals.stacks[s.stack] += elapsed
+	totals.mutex.Unlock()
+}
+
+// totals sums the spans of the process since it started or Reset was called
+var totals = struct {
+	mutex  sync.Mutex
+	stacks map[string]time.Duration
+}{stacks: make(map[string]time.Duration)}
+
+// Reset clears the totals
+func Reset() {
+	totals.mutex.Lock()
+	clear(totals.stacks)
+	totals.mutex.Unlock()
+}
+
+// WriteFolded writes the totals as folded stacks, one "stack microseconds" line per stack
+// as flamegraph.pl, speedscope and inferno read them. A line holds a stack's own time,
+// outside the spans nested in it.
+func WriteFolded(w io.Writer) error {
+	totals.mutex.Lock()
+	self := make(map[string]time.Duration, len(totals.stacks))
+	for stack, total := range totals.stacks {
+		self[stack] += total
+		if i := strings.LastIndexByte(stack, ';'); i >= 0 {
+			self[stack[:i]] -= total
+		}
+	}
+	totals.mutex.Unlock()
+
+	stacks := make([]string, 0, len(self))
+	for stack := range self {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		// Nested spans can add up to slightly more than their parent's time
+		micros := max(self[stack].Microseconds(), 0)
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, micros); err != nil {
+			return err
+		}
+	}
+	return nil
+}
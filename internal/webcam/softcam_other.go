@@ -0,0 +1,10 @@
+//go:build !windows
+
+package webcam
+
+import "errors"
+
+// openDevice fails: softcam is a DirectShow filter, available on Windows only
+func openDevice(dll string, width, height, fps int) (device, error) {
+	return nil, errors.New("the virtual webcam is only available on Windows")
+}
@@ -0,0 +1,59 @@
+//go:build windows
+
+package webcam
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// softcam is a camera of softcam's DirectShow filter
+type softcam struct {
+	camera       uintptr
+	sendFrame    *windows.LazyProc
+	isConnected  *windows.LazyProc
+	deleteCamera *windows.LazyProc
+}
+
+// openDevice loads the softcam DLL and creates its camera
+func openDevice(dll string, width, height, fps int) (device, error) {
+	lib := windows.NewLazyDLL(dll)
+	if err := lib.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load %s; install and register softcam for the virtual webcam: %w", dll, err)
+	}
+	create := lib.NewProc("scCreateCamera")
+	cam := &softcam{
+		sendFrame:    lib.NewProc("scSendFrame"),
+		isConnected:  lib.NewProc("scIsConnected"),
+		deleteCamera: lib.NewProc("scDeleteCamera"),
+	}
+	for _, proc := range []*windows.LazyProc{create, cam.sendFrame, cam.isConnected, cam.deleteCamera} {
+		if err := proc.Find(); err != nil {
+			return nil, fmt.Errorf("%s is not a softcam DLL: %w", dll, err)
+		}
+	}
+
+	// The frame rate is a float, which the call passes in the register of its position
+	cam.camera, _, _ = create.Call(uintptr(width), uintptr(height), uintptr(math.Float32bits(float32(fps))))
+	if cam.camera == 0 {
+		return nil, errors.New("softcam couldn't create the camera; another process may be using it")
+	}
+	return cam, nil
+}
+
+func (c *softcam) send(frame []byte) {
+	c.sendFrame.Call(c.camera, uintptr(unsafe.Pointer(&frame[0])))
+}
+
+func (c *softcam) connected() bool {
+	connected, _, _ := c.isConnected.Call(c.camera)
+	return connected&0xff != 0
+}
+
+func (c *softcam) close() {
+	c.deleteCamera.Call(c.camera)
+}
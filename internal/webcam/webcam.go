@@ -0,0 +1,200 @@
+// Package webcam feeds captures to a virtual camera, so video-conferencing and
+// recording applications can pick a captured window as their webcam. The camera is
+// softcam's DirectShow filter: its DLL must be installed and registered with regsvr32,
+// and the server loads the same DLL to send frames to it.
+package webcam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/rawframe"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Camera frame size and rate defaults and limits
+const (
+	DefaultWidth  = 1280
+	DefaultHeight = 720
+	DefaultFPS    = 30
+	maxDimension  = 3840
+	maxFPS        = 60
+)
+
+// ErrRunning is returned when starting the camera while it's already running; softcam
+// offers one camera per process
+var ErrRunning = errors.New("virtual webcam is already running")
+
+// ErrNotRunning is returned when stopping the camera while it isn't running
+var ErrNotRunning = errors.New("virtual webcam is not running")
+
+// CaptureFunc captures the camera's source once, giving up when ctx is cancelled
+type CaptureFunc func(ctx context.Context) (*types.ScreenshotBuffer, error)
+
+// device is the virtual camera frames are sent to
+type device interface {
+	send(frame []byte) // Sends a 24-bit BGR frame, top row first
+	connected() bool   // Whether an application is reading the camera
+	close()
+}
+
+// Camera feeds captures of one source at a time to the virtual camera
+type Camera struct {
+	dll    string
+	logger *zap.Logger
+	mutex  sync.Mutex
+	status types.WebcamStatus
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewCamera returns a camera sending frames through the softcam DLL at dll
+func NewCamera(dll string, logger *zap.Logger) *Camera {
+	return &Camera{dll: dll, logger: logger}
+}
+
+// ValidateRequest fills in the defaults of a request and checks its size and frame rate
+func ValidateRequest(req *types.WebcamRequest) error {
+	if req.Width == 0 {
+		req.Width = DefaultWidth
+	}
+	if req.Height == 0 {
+		req.Height = DefaultHeight
+	}
+	if req.FPS == 0 {
+		req.FPS = DefaultFPS
+	}
+	if req.Width < 16 || req.Height < 16 || req.Width > maxDimension || req.Height > maxDimension {
+		return fmt.Errorf("invalid size %dx%d: width and height must be 16-%d", req.Width, req.Height, maxDimension)
+	}
+	if req.FPS < 1 || req.FPS > maxFPS {
+		return fmt.Errorf("invalid fps %d: must be 1-%d", req.FPS, maxFPS)
+	}
+	return nil
+}
+
+// Start opens the virtual camera and feeds it captures until Stop is called. req has
+// been validated.
+func (c *Camera) Start(req types.WebcamRequest, capture CaptureFunc) (types.WebcamStatus, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.status.Running {
+		return c.status, ErrRunning
+	}
+	dev, err := openDevice(c.dll, req.Width, req.Height, req.FPS)
+	if err != nil {
+		return types.WebcamStatus{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	c.status = types.WebcamStatus{
+		Running:   true,
+		Target:    req.Target,
+		Width:     req.Width,
+		Height:    req.Height,
+		FPS:       req.FPS,
+		StartedAt: &now,
+	}
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.run(ctx, dev, capture, c.done)
+
+	c.logger.Info("Virtual webcam started",
+		zap.String("target", req.Target),
+		zap.Int("width", req.Width),
+		zap.Int("height", req.Height),
+		zap.Int("fps", req.FPS),
+	)
+	return c.status, nil
+}
+
+// Stop stops feeding the camera and closes it, returning its final status
+func (c *Camera) Stop() (types.WebcamStatus, error) {
+	c.mutex.Lock()
+	if !c.status.Running {
+		c.mutex.Unlock()
+		return types.WebcamStatus{}, ErrNotRunning
+	}
+	cancel, done := c.cancel, c.done
+	c.mutex.Unlock()
+
+	cancel()
+	<-done
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.done != done {
+		// Another Stop got here first
+		return types.WebcamStatus{}, ErrNotRunning
+	}
+	status := c.status
+	status.Running, status.Connected = false, false
+	c.status = types.WebcamStatus{}
+	c.cancel, c.done = nil, nil
+	c.logger.Info("Virtual webcam stopped",
+		zap.String("target", status.Target),
+		zap.Int64("frames", status.Frames),
+		zap.Int64("failed_frames", status.FailedFrames),
+	)
+	return status, nil
+}
+
+// Status returns the camera's status
+func (c *Camera) Status() types.WebcamStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.status
+}
+
+// run captures the source at the camera's frame rate and sends the frames, until ctx is
+// cancelled. Frames that fail to capture are skipped; the camera keeps showing the last one.
+func (c *Camera) run(ctx context.Context, dev device, capture CaptureFunc, done chan struct{}) {
+	defer close(done)
+	defer dev.close()
+
+	c.mutex.Lock()
+	width, height, fps := c.status.Width, c.status.Height, c.status.FPS
+	c.mutex.Unlock()
+
+	frame := make([]byte, width*height*3)
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		buffer, err := capture(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = rawframe.FitBGR(frame, width, height, buffer)
+		}
+		if err == nil {
+			dev.send(frame)
+		}
+
+		connected := dev.connected()
+		c.mutex.Lock()
+		if err != nil {
+			if c.status.LastError != err.Error() {
+				c.logger.Warn("Virtual webcam frame failed", zap.Error(err))
+			}
+			c.status.FailedFrames++
+			c.status.LastError = err.Error()
+		} else {
+			c.status.Frames++
+			c.status.LastError = ""
+		}
+		c.status.Connected = connected
+		c.mutex.Unlock()
+	}
+}
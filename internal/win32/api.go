@@ -0,0 +1,52 @@
+//go:build windows
+
+package win32
+
+// API is the window query surface used by the window manager, so it can be exercised
+// against a fake desktop in tests
+type API interface {
+	EnumWindows(fn func(hwnd uintptr) bool) error
+	FindWindow(className, windowName string) (uintptr, error)
+	GetWindowText(hwnd uintptr) string
+	GetClassName(hwnd uintptr) string
+	GetWindowRect(hwnd uintptr) (RECT, error)
+	GetClientRect(hwnd uintptr) (RECT, error)
+	GetWindowThreadProcessID(hwnd uintptr) (threadID, pid uint32)
+	GetWindow(hwnd uintptr, cmd int) uintptr
+	GetWindowLongPtr(hwnd uintptr, index int32) uintptr
+	IsWindow(hwnd uintptr) bool
+	IsWindowVisible(hwnd uintptr) bool
+	IsIconic(hwnd uintptr) bool
+	IsZoomed(hwnd uintptr) bool
+	IsCloaked(hwnd uintptr) bool
+	IsHungWindow(hwnd uintptr) bool
+	ShowWindow(hwnd uintptr, cmd int) bool
+}
+
+// System is the API backed by the real Win32 functions
+type System struct{}
+
+func (System) EnumWindows(fn func(hwnd uintptr) bool) error { return EnumWindows(fn) }
+func (System) FindWindow(className, windowName string) (uintptr, error) {
+	return FindWindow(className, windowName)
+}
+func (System) GetWindowText(hwnd uintptr) string        { return GetWindowText(hwnd) }
+func (System) GetClassName(hwnd uintptr) string         { return GetClassName(hwnd) }
+func (System) GetWindowRect(hwnd uintptr) (RECT, error) { return GetWindowRect(hwnd) }
+func (System) GetClientRect(hwnd uintptr) (RECT, error) { return GetClientRect(hwnd) }
+func (System) GetWindowThreadProcessID(hwnd uintptr) (uint32, uint32) {
+	return GetWindowThreadProcessID(hwnd)
+}
+func (System) GetWindow(hwnd uintptr, cmd int) uintptr { return GetWindow(hwnd, cmd) }
+func (System) GetWindowLongPtr(hwnd uintptr, index int32) uintptr {
+	return GetWindowLongPtr(hwnd, index)
+}
+func (System) IsWindow(hwnd uintptr) bool            { return IsWindow(hwnd) }
+func (System) IsWindowVisible(hwnd uintptr) bool     { return IsWindowVisible(hwnd) }
+func (System) IsIconic(hwnd uintptr) bool            { return IsIconic(hwnd) }
+func (System) IsZoomed(hwnd uintptr) bool            { return IsZoomed(hwnd) }
+func (System) IsCloaked(hwnd uintptr) bool           { return IsCloaked(hwnd) }
+func (System) IsHungWindow(hwnd uintptr) bool        { return IsHungWindow(hwnd) }
+func (System) ShowWindow(hwnd uintptr, cmd int) bool { return ShowWindow(hwnd, cmd) }
+
+var _ API = System{}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package win32
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// COM constants
+const (
+	COINIT_MULTITHREADED = 0x0
+	CLSCTX_INPROC_SERVER = 0x1
+	CLSCTX_LOCAL_SERVER  = 0x4
+	RPC_E_CHANGED_MODE   = 0x80010106
+
+	// IUnknown vtable indices
+	VtblQueryInterface = 0
+	VtblAddRef         = 1
+	VtblRelease        = 2
+)
+
+// ComCall invokes the method at index in a COM object's vtable and returns its HRESULT
+func ComCall(object uintptr, index int, args ...uintptr) uintptr {
+	vtable := *(*uintptr)(unsafe.Pointer(object))
+	method := *(*uintptr)(unsafe.Pointer(vtable + uintptr(index)*unsafe.Sizeof(uintptr(0))))
+	ret, _, _ := syscall.SyscallN(method, append([]uintptr{object}, args...)...)
+	return ret
+}
+
+// ComRelease releases a COM object if it is non-nil
+func ComRelease(object uintptr) {
+	if object != 0 {
+		ComCall(object, VtblRelease)
+	}
+}
+
+// Failed reports whether an HRESULT indicates failure
+func Failed(hr uintptr) bool {
+	return int32(hr) < 0
+}
+
+// CoInitialize initializes COM on the calling thread as a multithreaded apartment. A
+// thread already in another apartment is accepted as is; uninit reports whether the
+// caller must balance the call with CoUninitialize.
+func CoInitialize() (uninit bool, err error) {
+	hr, _, _ := procCoInitializeEx.Call(0, COINIT_MULTITHREADED)
+	if Failed(hr) {
+		if uint32(hr) == RPC_E_CHANGED_MODE {
+			return false, nil
+		}
+		return false, &HRESULTError{Func: "CoInitializeEx", HR: uint32(hr)}
+	}
+	return true, nil
+}
+
+// CoUninitialize balances a CoInitialize that reported uninit
+func CoUninitialize() {
+	procCoUninitialize.Call()
+}
+
+// CoCreateInstance creates a COM object and returns its interface pointer
+func CoCreateInstance(clsid *windows.GUID, context uint32, iid *windows.GUID) (uintptr, error) {
+	var object uintptr
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(clsid)), 0, uintptr(context),
+		uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&object)),
+	)
+	if Failed(hr) {
+		return 0, &HRESULTError{Func: "CoCreateInstance", HR: uint32(hr)}
+	}
+	return object, nil
+}
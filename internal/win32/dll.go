@@ -0,0 +1,54 @@
+//go:build windows
+
+// Package win32 holds the Win32 bindings shared by the capture, window and browser
+// packages: the system DLLs, common structures, typed wrappers that report failures
+// with GetLastError, and COM call helpers.
+package win32
+
+import "golang.org/x/sys/windows"
+
+// System DLLs, loaded from the system directory on first use
+var (
+	User32   = windows.NewLazySystemDLL("user32.dll")
+	Kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	Gdi32    = windows.NewLazySystemDLL("gdi32.dll")
+	Dwmapi   = windows.NewLazySystemDLL("dwmapi.dll")
+	Shcore   = windows.NewLazySystemDLL("shcore.dll")
+	Shell32  = windows.NewLazySystemDLL("shell32.dll")
+	Ole32    = windows.NewLazySystemDLL("ole32.dll")
+	OleAut32 = windows.NewLazySystemDLL("oleaut32.dll")
+	Wtsapi32 = windows.NewLazySystemDLL("wtsapi32.dll")
+)
+
+var (
+	// User32 functions
+	procEnumWindows               = User32.NewProc("EnumWindows")
+	procEnumChildWindows          = User32.NewProc("EnumChildWindows")
+	procEnumThreadWindows         = User32.NewProc("EnumThreadWindows")
+	procFindWindowW               = User32.NewProc("FindWindowW")
+	procGetWindowTextW            = User32.NewProc("GetWindowTextW")
+	procGetWindowTextLengthW      = User32.NewProc("GetWindowTextLengthW")
+	procGetClassNameW             = User32.NewProc("GetClassNameW")
+	procGetWindowRect             = User32.NewProc("GetWindowRect")
+	procGetClientRect             = User32.NewProc("GetClientRect")
+	procGetWindowThreadProcessId  = User32.NewProc("GetWindowThreadProcessId")
+	procIsWindow                  = User32.NewProc("IsWindow")
+	procIsWindowVisible           = User32.NewProc("IsWindowVisible")
+	procIsIconic                  = User32.NewProc("IsIconic")
+	procIsZoomed                  = User32.NewProc("IsZoomed")
+	procShowWindow                = User32.NewProc("ShowWindow")
+	procGetWindow                 = User32.NewProc("GetWindow")
+	procGetWindowLongPtrW         = User32.NewProc("GetWindowLongPtrW")
+	procGetWindowDisplayAffinity  = User32.NewProc("GetWindowDisplayAffinity")
+	procIsHungAppWindow           = User32.NewProc("IsHungAppWindow")
+	procSendMessageTimeoutW       = User32.NewProc("SendMessageTimeoutW")
+	procGhostWindowFromHungWindow = User32.NewProc("GhostWindowFromHungWindow")
+
+	// DWM functions
+	procDwmGetWindowAttribute = Dwmapi.NewProc("DwmGetWindowAttribute")
+
+	// COM functions
+	procCoInitializeEx   = Ole32.NewProc("CoInitializeEx")
+	procCoUninitialize   = Ole32.NewProc("CoUninitialize")
+	procCoCreateInstance = Ole32.NewProc("CoCreateInstance")
+)
@@ -0,0 +1,30 @@
+//go:build windows
+
+package win32
+
+import "unsafe"
+
+// DwmGetWindowAttribute reads a DWM window attribute into value
+func DwmGetWindowAttribute(hwnd uintptr, attribute uint32, value unsafe.Pointer, size uintptr) error {
+	hr, _, _ := procDwmGetWindowAttribute.Call(hwnd, uintptr(attribute), uintptr(value), size)
+	if Failed(hr) {
+		return &HRESULTError{Func: "DwmGetWindowAttribute", HR: uint32(hr)}
+	}
+	return nil
+}
+
+// IsCloaked reports whether DWM is hiding the window, as it does for windows on other
+// virtual desktops and suspended UWP apps
+func IsCloaked(hwnd uintptr) bool {
+	var cloaked uint32
+	err := DwmGetWindowAttribute(hwnd, DWMWA_CLOAKED, unsafe.Pointer(&cloaked), unsafe.Sizeof(cloaked))
+	return err == nil && cloaked != 0
+}
+
+// ExtendedFrameBounds returns the window's visible bounds, without the invisible resize
+// borders GetWindowRect includes
+func ExtendedFrameBounds(hwnd uintptr) (RECT, error) {
+	var rect RECT
+	err := DwmGetWindowAttribute(hwnd, DWMWA_EXTENDED_FRAME_BOUNDS, unsafe.Pointer(&rect), unsafe.Sizeof(rect))
+	return rect, err
+}
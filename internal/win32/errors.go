@@ -0,0 +1,43 @@
+//go:build windows
+
+package win32
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Error is a failed Win32 call with the error code GetLastError reported. It unwraps
+// to the syscall.Errno, so errors.Is(err, windows.ERROR_ACCESS_DENIED) works.
+type Error struct {
+	Func  string
+	Errno syscall.Errno
+}
+
+func (e *Error) Error() string {
+	if e.Errno == 0 {
+		return fmt.Sprintf("%s failed", e.Func)
+	}
+	return fmt.Sprintf("%s failed: %v", e.Func, e.Errno)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Errno
+}
+
+// lastError builds an Error from the error returned by LazyProc.Call, which carries
+// GetLastError
+func lastError(fn string, callErr error) error {
+	errno, _ := callErr.(syscall.Errno)
+	return &Error{Func: fn, Errno: errno}
+}
+
+// HRESULTError is a failed COM call
+type HRESULTError struct {
+	Func string
+	HR   uint32
+}
+
+func (e *HRESULTError) Error() string {
+	return fmt.Sprintf("%s failed: 0x%08x", e.Func, e.HR)
+}
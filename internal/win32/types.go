@@ -0,0 +1,62 @@
+//go:build windows
+
+package win32
+
+import "github.com/screenshot-mcp-server/pkg/types"
+
+// ShowWindow commands
+const (
+	SW_HIDE            = 0
+	SW_SHOWNORMAL      = 1
+	SW_SHOWMINIMIZED   = 2
+	SW_SHOWMAXIMIZED   = 3
+	SW_MAXIMIZE        = 3
+	SW_SHOWNOACTIVATE  = 4
+	SW_SHOW            = 5
+	SW_MINIMIZE        = 6
+	SW_SHOWMINNOACTIVE = 7
+	SW_SHOWNA          = 8
+	SW_RESTORE         = 9
+)
+
+// GetWindow relationships
+const (
+	GW_HWNDFIRST = 0
+	GW_HWNDLAST  = 1
+	GW_HWNDNEXT  = 2
+	GW_HWNDPREV  = 3
+	GW_OWNER     = 4
+	GW_CHILD     = 5
+)
+
+// SendMessageTimeout messages and flags
+const (
+	WM_NULL          = 0x0000
+	SMTO_ABORTIFHUNG = 0x0002
+)
+
+// DwmGetWindowAttribute attributes
+const (
+	DWMWA_EXTENDED_FRAME_BOUNDS = 9
+	DWMWA_CLOAKED               = 14
+)
+
+// RECT structure
+type RECT struct {
+	Left, Top, Right, Bottom int32
+}
+
+// Rectangle converts the RECT to an origin and size
+func (r RECT) Rectangle() types.Rectangle {
+	return types.Rectangle{
+		X:      int(r.Left),
+		Y:      int(r.Top),
+		Width:  int(r.Right - r.Left),
+		Height: int(r.Bottom - r.Top),
+	}
+}
+
+// POINT structure
+type POINT struct {
+	X, Y int32
+}
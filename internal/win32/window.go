@@ -0,0 +1,206 @@
+//go:build windows
+
+package win32
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// enumCallback is the one callback shared by every window enumeration. The runtime
+// can only create a limited number of callbacks per process, so enumerations pass an
+// ID in lParam that selects their Go function instead of creating a callback each.
+var (
+	enumCallback = syscall.NewCallback(dispatchEnum)
+	enumFuncs    sync.Map
+	enumNextID   atomic.Uintptr
+)
+
+func dispatchEnum(hwnd, lParam uintptr) uintptr {
+	fn, ok := enumFuncs.Load(lParam)
+	if !ok || !fn.(func(uintptr) bool)(hwnd) {
+		return 0 // Stop enumeration
+	}
+	return 1
+}
+
+// enumerate runs an EnumXxxWindows function with fn as its callback
+func enumerate(name string, proc *windows.LazyProc, fn func(hwnd uintptr) bool, args ...uintptr) error {
+	id := enumNextID.Add(1)
+	enumFuncs.Store(id, fn)
+	defer enumFuncs.Delete(id)
+
+	ret, _, err := proc.Call(append(args, enumCallback, id)...)
+	if ret == 0 {
+		// A callback that stops early also makes the call return FALSE, without an error
+		if errno, _ := err.(syscall.Errno); errno != 0 {
+			return lastError(name, err)
+		}
+	}
+	return nil
+}
+
+// EnumWindows calls fn for each top-level window, topmost first, until fn returns false
+func EnumWindows(fn func(hwnd uintptr) bool) error {
+	return enumerate("EnumWindows", procEnumWindows, fn)
+}
+
+// EnumChildWindows calls fn for each descendant of parent until fn returns false
+func EnumChildWindows(parent uintptr, fn func(hwnd uintptr) bool) error {
+	return enumerate("EnumChildWindows", procEnumChildWindows, fn, parent)
+}
+
+// EnumThreadWindows calls fn for each top-level window of a thread until fn returns false
+func EnumThreadWindows(threadID uint32, fn func(hwnd uintptr) bool) error {
+	return enumerate("EnumThreadWindows", procEnumThreadWindows, fn, uintptr(threadID))
+}
+
+// FindWindow finds a top-level window by class name and/or title; empty strings match any
+func FindWindow(className, windowName string) (uintptr, error) {
+	var classPtr, namePtr *uint16
+	var err error
+	if className != "" {
+		if classPtr, err = syscall.UTF16PtrFromString(className); err != nil {
+			return 0, err
+		}
+	}
+	if windowName != "" {
+		if namePtr, err = syscall.UTF16PtrFromString(windowName); err != nil {
+			return 0, err
+		}
+	}
+
+	hwnd, _, callErr := procFindWindowW.Call(uintptr(unsafe.Pointer(classPtr)), uintptr(unsafe.Pointer(namePtr)))
+	if hwnd == 0 {
+		return 0, lastError("FindWindow", callErr)
+	}
+	return hwnd, nil
+}
+
+// GetWindowText returns a window's title
+func GetWindowText(hwnd uintptr) string {
+	length, _, _ := procGetWindowTextLengthW.Call(hwnd)
+	if length == 0 {
+		return ""
+	}
+	buf := make([]uint16, length+1)
+	procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+// GetClassName returns a window's class name
+func GetClassName(hwnd uintptr) string {
+	buf := make([]uint16, 256)
+	procGetClassNameW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+// GetWindowRect returns a window's bounds in screen coordinates
+func GetWindowRect(hwnd uintptr) (RECT, error) {
+	var rect RECT
+	if ret, _, err := procGetWindowRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ret == 0 {
+		return rect, lastError("GetWindowRect", err)
+	}
+	return rect, nil
+}
+
+// GetClientRect returns a window's client area; the origin is always 0,0
+func GetClientRect(hwnd uintptr) (RECT, error) {
+	var rect RECT
+	if ret, _, err := procGetClientRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ret == 0 {
+		return rect, lastError("GetClientRect", err)
+	}
+	return rect, nil
+}
+
+// GetWindowThreadProcessID returns the thread that created a window and its process
+func GetWindowThreadProcessID(hwnd uintptr) (threadID, pid uint32) {
+	tid, _, _ := procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	return uint32(tid), pid
+}
+
+// IsWindow reports whether hwnd identifies an existing window
+func IsWindow(hwnd uintptr) bool {
+	ret, _, _ := procIsWindow.Call(hwnd)
+	return ret != 0
+}
+
+// IsWindowVisible reports whether a window has the WS_VISIBLE style
+func IsWindowVisible(hwnd uintptr) bool {
+	ret, _, _ := procIsWindowVisible.Call(hwnd)
+	return ret != 0
+}
+
+// IsIconic reports whether a window is minimized
+func IsIconic(hwnd uintptr) bool {
+	ret, _, _ := procIsIconic.Call(hwnd)
+	return ret != 0
+}
+
+// IsZoomed reports whether a window is maximized
+func IsZoomed(hwnd uintptr) bool {
+	ret, _, _ := procIsZoomed.Call(hwnd)
+	return ret != 0
+}
+
+// hungProbeTimeout bounds how long a busy window has to answer WM_NULL before it
+// counts as hung
+const hungProbeTimeout = 100 * time.Millisecond
+
+// IsHungWindow reports whether a window has stopped processing messages: Windows
+// already considers it hung, or it doesn't answer WM_NULL within hungProbeTimeout.
+// Messages sent to a hung window, such as WM_PRINT, block until it recovers.
+func IsHungWindow(hwnd uintptr) bool {
+	if ret, _, _ := procIsHungAppWindow.Call(hwnd); ret != 0 {
+		return true
+	}
+	var result uintptr
+	ret, _, err := procSendMessageTimeoutW.Call(hwnd, WM_NULL, 0, 0, SMTO_ABORTIFHUNG,
+		uintptr(hungProbeTimeout.Milliseconds()), uintptr(unsafe.Pointer(&result)))
+	return ret == 0 && err == windows.ERROR_TIMEOUT
+}
+
+// GhostWindow returns the ghost window Windows shows in place of a hung top-level
+// window, with "(Not Responding)" in its title, or 0 when there is none yet
+func GhostWindow(hwnd uintptr) uintptr {
+	ret, _, _ := procGhostWindowFromHungWindow.Call(hwnd)
+	return ret
+}
+
+// ShowWindow sets a window's show state and reports whether it was visible before
+func ShowWindow(hwnd uintptr, cmd int) bool {
+	ret, _, _ := procShowWindow.Call(hwnd, uintptr(cmd))
+	return ret != 0
+}
+
+// GetWindow returns the window with the given relationship to hwnd, or 0
+func GetWindow(hwnd uintptr, cmd int) uintptr {
+	ret, _, _ := procGetWindow.Call(hwnd, uintptr(cmd))
+	return ret
+}
+
+// GetWindowLongPtr reads a window attribute such as GWL_STYLE or GWL_EXSTYLE
+func GetWindowLongPtr(hwnd uintptr, index int32) uintptr {
+	ret, _, _ := procGetWindowLongPtrW.Call(hwnd, uintptr(index))
+	return ret
+}
+
+// GetWindowDisplayAffinity returns the affinity a window set with SetWindowDisplayAffinity
+func GetWindowDisplayAffinity(hwnd uintptr) (uint32, error) {
+	var affinity uint32
+	if ret, _, err := procGetWindowDisplayAffinity.Call(hwnd, uintptr(unsafe.Pointer(&affinity))); ret == 0 {
+		return 0, lastError("GetWindowDisplayAffinity", err)
+	}
+	return affinity, nil
+}
+
+// WindowProcessID returns the ID of the process that owns a window
+func WindowProcessID(hwnd uintptr) uint32 {
+	_, pid := GetWindowThreadProcessID(hwnd)
+	return pid
+}
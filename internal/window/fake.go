@@ -0,0 +1,276 @@
+package window
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// FakeManager is an in-memory WindowManager over a simulated desktop. It stands in for
+// the Windows manager on other platforms and in tests, so code built on
+// types.WindowManager can run without a Windows session.
+type FakeManager struct {
+	mu         sync.Mutex
+	windows    []types.WindowInfo
+	foreground uintptr
+	cursor     types.Point
+	elements   map[uintptr][]string
+	lastInput  time.Time
+}
+
+// FakeWindows returns the windows of the default simulated desktop, topmost first
+func FakeWindows() []types.WindowInfo {
+	return []types.WindowInfo{
+		{
+			Handle: 0x10010, Title: "Untitled - Notepad", ClassName: "Notepad",
+			ProcessID: 4100, ThreadID: 4104,
+			Rect:       types.Rectangle{X: 100, Y: 100, Width: 800, Height: 600},
+			ClientRect: types.Rectangle{Width: 784, Height: 541},
+			State:      "visible", IsVisible: true, IntegrityLevel: "medium",
+		},
+		{
+			Handle: 0x10020, Title: "Calculator", ClassName: "ApplicationFrameWindow",
+			ProcessID: 5200, ThreadID: 5204,
+			Rect:       types.Rectangle{X: 950, Y: 120, Width: 320, Height: 500},
+			ClientRect: types.Rectangle{Width: 320, Height: 468},
+			State:      "visible", IsVisible: true, IntegrityLevel: "medium",
+		},
+		{
+			Handle: 0x10030, Title: "Task Manager", ClassName: "TaskManagerWindow",
+			ProcessID: 6300, ThreadID: 6304,
+			Rect:       types.Rectangle{X: 200, Y: 150, Width: 900, Height: 700},
+			ClientRect: types.Rectangle{Width: 884, Height: 661},
+			State:      "minimized", IsVisible: true, IntegrityLevel: "high",
+		},
+		{
+			Handle: 0x10040, Title: "Tray Helper", ClassName: "TrayHelperWindow",
+			ProcessID: 7400, ThreadID: 7404,
+			Rect:       types.Rectangle{Width: 300, Height: 200},
+			ClientRect: types.Rectangle{Width: 300, Height: 200},
+			State:      "hidden", IntegrityLevel: "medium",
+		},
+	}
+}
+
+// NewFakeManager creates a fake manager over windows, given topmost first. The first
+// visible window has the focus and the cursor rests on its center.
+func NewFakeManager(windows []types.WindowInfo) *FakeManager {
+	fm := &FakeManager{
+		windows:   append([]types.WindowInfo(nil), windows...),
+		elements:  make(map[uintptr][]string),
+		lastInput: time.Now(),
+	}
+	for i := range fm.windows {
+		fm.windows[i].ZOrder = i
+	}
+	for _, info := range fm.windows {
+		if info.IsVisible && info.State != "minimized" {
+			fm.foreground = info.Handle
+			fm.cursor = types.Point{X: info.Rect.X + info.Rect.Width/2, Y: info.Rect.Y + info.Rect.Height/2}
+			break
+		}
+	}
+	return fm
+}
+
+// SetCursorPosition moves the simulated cursor
+func (fm *FakeManager) SetCursorPosition(pt types.Point) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.cursor = pt
+	fm.lastInput = time.Now()
+}
+
+// SetElements sets the names of the on-screen UI elements IsElementVisible finds in a window
+func (fm *FakeManager) SetElements(handle uintptr, names ...string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.elements[handle] = names
+}
+
+// EnumerateWindows lists the simulated windows in z-order with optional filtering
+func (fm *FakeManager) EnumerateWindows(filter *types.WindowFilter) ([]types.WindowInfo, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	var windows []types.WindowInfo
+	for i := range fm.windows {
+		if filter != nil && !matchesFilter(&fm.windows[i], filter) {
+			continue
+		}
+		windows = append(windows, fm.windows[i])
+	}
+	return windows, nil
+}
+
+// GetWindowInfo returns a copy of a simulated window
+func (fm *FakeManager) GetWindowInfo(handle uintptr) (*types.WindowInfo, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	info, err := fm.find(handle)
+	if err != nil {
+		return nil, err
+	}
+	copied := *info
+	return &copied, nil
+}
+
+// SetWindowPos moves and resizes a window, keeping its frame thickness
+func (fm *FakeManager) SetWindowPos(handle uintptr, rect types.Rectangle) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	info, err := fm.find(handle)
+	if err != nil {
+		return err
+	}
+	info.ClientRect.Width += rect.Width - info.Rect.Width
+	info.ClientRect.Height += rect.Height - info.Rect.Height
+	info.Rect = rect
+	return nil
+}
+
+// SetWindowVisible shows or hides a window
+func (fm *FakeManager) SetWindowVisible(handle uintptr, visible bool) error {
+	state := "hidden"
+	if visible {
+		state = "visible"
+	}
+	return fm.SetWindowState(handle, state)
+}
+
+// SetWindowState changes window state (minimize, maximize, restore, hide, show)
+func (fm *FakeManager) SetWindowState(handle uintptr, state string) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	info, err := fm.find(handle)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(state) {
+	case "minimize", "minimized":
+		info.State, info.IsVisible = "minimized", true
+	case "maximize", "maximized":
+		info.State, info.IsVisible = "maximized", true
+	case "restore", "normal", "show", "visible":
+		info.State, info.IsVisible = "visible", true
+	case "hide", "hidden":
+		info.State, info.IsVisible = "hidden", false
+	default:
+		return fmt.Errorf("unsupported window state: %s", state)
+	}
+	return nil
+}
+
+// BringToForeground raises a window to the top of the z-order and focuses it
+func (fm *FakeManager) BringToForeground(handle uintptr) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	info, err := fm.find(handle)
+	if err != nil {
+		return err
+	}
+	if info.State == "minimized" {
+		info.State = "visible"
+	}
+	info.ZOrder = -1
+	sort.SliceStable(fm.windows, func(i, j int) bool {
+		return fm.windows[i].ZOrder < fm.windows[j].ZOrder
+	})
+	for i := range fm.windows {
+		fm.windows[i].ZOrder = i
+	}
+	fm.foreground = handle
+	return nil
+}
+
+// GetForegroundWindow returns the focused window
+func (fm *FakeManager) GetForegroundWindow() (uintptr, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if fm.foreground == 0 {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "no window has the focus", nil)
+	}
+	return fm.foreground, nil
+}
+
+// GetCursorPosition returns the simulated cursor position
+func (fm *FakeManager) GetCursorPosition() (types.Point, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.cursor, nil
+}
+
+// WindowFromPoint returns the topmost shown window containing pt. Simulated windows have
+// no children, so topLevel makes no difference.
+func (fm *FakeManager) WindowFromPoint(pt types.Point, topLevel bool) (uintptr, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	for _, info := range fm.windows {
+		if !info.IsVisible || info.State == "minimized" {
+			continue
+		}
+		r := info.Rect
+		if pt.X >= r.X && pt.X < r.X+r.Width && pt.Y >= r.Y && pt.Y < r.Y+r.Height {
+			return info.Handle, nil
+		}
+	}
+	return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no window at (%d, %d)", pt.X, pt.Y), nil)
+}
+
+// GetWindowTitle returns a window's current title
+func (fm *FakeManager) GetWindowTitle(handle uintptr) (string, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	info, err := fm.find(handle)
+	if err != nil {
+		return "", err
+	}
+	return info.Title, nil
+}
+
+// IsElementVisible reports whether name was registered for the window with SetElements
+func (fm *FakeManager) IsElementVisible(handle uintptr, name string) (bool, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if _, err := fm.find(handle); err != nil {
+		return false, err
+	}
+	for _, element := range fm.elements[handle] {
+		if element == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetLastInputTime returns when the simulated cursor last moved
+func (fm *FakeManager) GetLastInputTime() (time.Time, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.lastInput, nil
+}
+
+// find returns the window with handle; callers hold mu
+func (fm *FakeManager) find(handle uintptr) (*types.WindowInfo, error) {
+	for i := range fm.windows {
+		if fm.windows[i].Handle == handle {
+			return &fm.windows[i], nil
+		}
+	}
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d does not exist", handle), nil)
+}
+
+// Ensure FakeManager implements the interface
+var _ types.WindowManager = (*FakeManager)(nil)
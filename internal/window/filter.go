@@ -0,0 +1,123 @@
+package window
+
+import (
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Values accepted by WindowFilter.VirtualDesktop besides a desktop ID
+const (
+	VirtualDesktopCurrent = "current"
+	VirtualDesktopOther   = "other"
+)
+
+// matchesFilter reports whether a window passes every criterion set in filter
+func matchesFilter(info *types.WindowInfo, filter *types.WindowFilter) bool {
+	// Title filter
+	if filter.TitleContains != "" {
+		if !strings.Contains(strings.ToLower(info.Title), strings.ToLower(filter.TitleContains)) {
+			return false
+		}
+	}
+
+	// Class name filter
+	if len(filter.ClassNames) > 0 {
+		found := false
+		for _, className := range filter.ClassNames {
+			if strings.EqualFold(info.ClassName, className) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Process ID filter
+	if len(filter.ProcessIDs) > 0 {
+		found := false
+		for _, pid := range filter.ProcessIDs {
+			if info.ProcessID == pid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Visible only filter
+	if filter.VisibleOnly && !info.IsVisible {
+		return false
+	}
+
+	// Virtual desktop filter
+	if filter.VirtualDesktop != "" && !matchesVirtualDesktop(info, filter.VirtualDesktop) {
+		return false
+	}
+
+	// Size filters
+	if filter.MinimumSize != nil {
+		if info.Rect.Width < filter.MinimumSize.Width || info.Rect.Height < filter.MinimumSize.Height {
+			return false
+		}
+	}
+
+	if filter.MaximumSize != nil {
+		if info.Rect.Width > filter.MaximumSize.Width || info.Rect.Height > filter.MaximumSize.Height {
+			return false
+		}
+	}
+
+	// Exclude system windows
+	if filter.ExcludeSystem {
+		if isSystemWindow(info) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSystemWindow reports whether a window belongs to the shell or is an untitled helper
+func isSystemWindow(info *types.WindowInfo) bool {
+	// Common system window patterns
+	systemClasses := []string{
+		"Shell_TrayWnd",
+		"DV2ControlHost",
+		"MsgrIMEWindowClass",
+		"SysShadow",
+		"Button",
+		"Progman",
+		"WorkerW",
+	}
+
+	for _, sysClass := range systemClasses {
+		if strings.EqualFold(info.ClassName, sysClass) {
+			return true
+		}
+	}
+
+	// Windows with no title and certain characteristics
+	if info.Title == "" && (info.Rect.Width < 100 || info.Rect.Height < 100) {
+		return true
+	}
+
+	return false
+}
+
+// matchesVirtualDesktop checks a window against WindowFilter.VirtualDesktop: "current",
+// "other" or a desktop ID. Windows whose desktop is unknown only match "current".
+func matchesVirtualDesktop(info *types.WindowInfo, desktop string) bool {
+	switch {
+	case strings.EqualFold(desktop, VirtualDesktopCurrent):
+		return info.OnCurrentDesktop == nil || *info.OnCurrentDesktop
+	case strings.EqualFold(desktop, VirtualDesktopOther):
+		return info.OnCurrentDesktop != nil && !*info.OnCurrentDesktop
+	default:
+		return strings.EqualFold(strings.Trim(desktop, "{}"), strings.Trim(info.VirtualDesktopID, "{}"))
+	}
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package window
+
+import (
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+var (
+	monitorFromWindow            = user32.NewProc("MonitorFromWindow")
+	getMonitorInfoW              = user32.NewProc("GetMonitorInfoW")
+	shQueryUserNotificationState = win32.Shell32.NewProc("SHQueryUserNotificationState")
+)
+
+const (
+	MONITOR_DEFAULTTONULL = 0
+
+	// SHQueryUserNotificationState result while a Direct3D application runs in
+	// exclusive full-screen mode
+	QUNS_RUNNING_D3D_FULL_SCREEN = 3
+)
+
+// MONITORINFO structure
+type MONITORINFO struct {
+	Size    uint32
+	Monitor win32.RECT
+	Work    win32.RECT
+	Flags   uint32
+}
+
+// FillFullscreen records whether a visible window covers its whole monitor. Only the
+// foreground window can own the display, so it is "exclusive" when Windows reports a
+// Direct3D application in exclusive full-screen mode; other windows covering their
+// monitor are "borderless".
+func FillFullscreen(info *types.WindowInfo) {
+	info.Fullscreen = ""
+	if !info.IsVisible || info.State == "minimized" {
+		return
+	}
+	monitor, _, _ := monitorFromWindow.Call(info.Handle, MONITOR_DEFAULTTONULL)
+	if monitor == 0 {
+		return
+	}
+	mi := MONITORINFO{Size: uint32(unsafe.Sizeof(MONITORINFO{}))}
+	if ok, _, _ := getMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&mi))); ok == 0 {
+		return
+	}
+
+	rect := info.Rect
+	if int32(rect.X) > mi.Monitor.Left || int32(rect.Y) > mi.Monitor.Top ||
+		int32(rect.X+rect.Width) < mi.Monitor.Right || int32(rect.Y+rect.Height) < mi.Monitor.Bottom {
+		return
+	}
+
+	info.Fullscreen = types.FullscreenBorderless
+	foreground, _, _ := getForegroundWindow.Call()
+	if root, _, _ := getAncestor.Call(foreground, GA_ROOT); root == info.Handle || foreground == info.Handle {
+		var state uint32
+		if hr, _, _ := shQueryUserNotificationState.Call(uintptr(unsafe.Pointer(&state))); !win32.Failed(hr) && state == QUNS_RUNNING_D3D_FULL_SCREEN {
+			info.Fullscreen = types.FullscreenExclusive
+		}
+	}
+}
@@ -1,72 +1,42 @@
+//go:build windows
+
 package window
 
 import (
 	"fmt"
 	"sort"
 	"strings"
-	"syscall"
 	"time"
 	"unsafe"
 
+	"github.com/screenshot-mcp-server/internal/win32"
 	"github.com/screenshot-mcp-server/pkg/types"
-	"golang.org/x/sys/windows"
 )
 
 var (
 	// Windows API DLLs
-	user32   = windows.NewLazyDLL("user32.dll")
-	kernel32 = windows.NewLazyDLL("kernel32.dll")
-	dwmapi   = windows.NewLazyDLL("dwmapi.dll")
-
-	// User32 functions
-	enumWindows              = user32.NewProc("EnumWindows")
-	getWindowTextW           = user32.NewProc("GetWindowTextW")
-	getWindowTextLengthW     = user32.NewProc("GetWindowTextLengthW")
-	getClassName             = user32.NewProc("GetClassNameW")
-	getWindowRect            = user32.NewProc("GetWindowRect")
-	getClientRect            = user32.NewProc("GetClientRect")
-	getWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
-	isWindowVisible          = user32.NewProc("IsWindowVisible")
-	isIconic                 = user32.NewProc("IsIconic")
-	isZoomed                 = user32.NewProc("IsZoomed")
-	showWindow               = user32.NewProc("ShowWindow")
-	setWindowPos             = user32.NewProc("SetWindowPos")
-	setForegroundWindow      = user32.NewProc("SetForegroundWindow")
-	bringWindowToTop         = user32.NewProc("BringWindowToTop")
-	moveWindow               = user32.NewProc("MoveWindow")
-	getWindowPlacement       = user32.NewProc("GetWindowPlacement")
-	setWindowPlacement       = user32.NewProc("SetWindowPlacement")
-	getWindow                = user32.NewProc("GetWindow")
-	getTopWindow             = user32.NewProc("GetTopWindow")
-	findWindow               = user32.NewProc("FindWindowW")
-	getWindowLong            = user32.NewProc("GetWindowLongPtrW")
-	setWindowLong            = user32.NewProc("SetWindowLongPtrW")
+	user32   = win32.User32
+	kernel32 = win32.Kernel32
+
+	// User32 functions; window queries go through win32.API
+	setWindowPos        = user32.NewProc("SetWindowPos")
+	setForegroundWindow = user32.NewProc("SetForegroundWindow")
+	bringWindowToTop    = user32.NewProc("BringWindowToTop")
+	moveWindow          = user32.NewProc("MoveWindow")
+	getWindowPlacement  = user32.NewProc("GetWindowPlacement")
+	setWindowPlacement  = user32.NewProc("SetWindowPlacement")
+	getForegroundWindow = user32.NewProc("GetForegroundWindow")
+	getCursorPos        = user32.NewProc("GetCursorPos")
+	windowFromPoint     = user32.NewProc("WindowFromPoint")
+	getAncestor         = user32.NewProc("GetAncestor")
+	getLastInputInfo    = user32.NewProc("GetLastInputInfo")
 
 	// Kernel32 functions
-	openProcess                   = kernel32.NewProc("OpenProcess")
-	closeHandle                   = kernel32.NewProc("CloseHandle")
-	queryFullProcessImageName     = kernel32.NewProc("QueryFullProcessImageNameW")
-	getProcessTimes               = kernel32.NewProc("GetProcessTimes")
-
-	// DWM functions
-	dwmGetWindowAttribute = dwmapi.NewProc("DwmGetWindowAttribute")
+	getTickCount = kernel32.NewProc("GetTickCount")
 )
 
 // Windows API constants
 const (
-	// ShowWindow constants
-	SW_HIDE            = 0
-	SW_SHOWNORMAL      = 1
-	SW_SHOWMINIMIZED   = 2
-	SW_SHOWMAXIMIZED   = 3
-	SW_MAXIMIZE        = 3
-	SW_SHOWNOACTIVATE  = 4
-	SW_SHOW            = 5
-	SW_MINIMIZE        = 6
-	SW_SHOWMINNOACTIVE = 7
-	SW_SHOWNA          = 8
-	SW_RESTORE         = 9
-
 	// SetWindowPos constants
 	SWP_NOSIZE      = 0x0001
 	SWP_NOMOVE      = 0x0002
@@ -77,13 +47,14 @@ const (
 	SWP_SHOWWINDOW  = 0x0040
 	SWP_HIDEWINDOW  = 0x0080
 
-	// GetWindow constants
-	GW_HWNDFIRST = 0
-	GW_HWNDLAST  = 1
-	GW_HWNDNEXT  = 2
-	GW_HWNDPREV  = 3
-	GW_OWNER     = 4
-	GW_CHILD     = 5
+	// SetWindowDisplayAffinity constants
+	WDA_MONITOR            = 0x01
+	WDA_EXCLUDEFROMCAPTURE = 0x11
+
+	// GetAncestor constants
+	GA_PARENT    = 1
+	GA_ROOT      = 2
+	GA_ROOTOWNER = 3
 
 	// Window attributes
 	GWL_EXSTYLE = -20
@@ -109,40 +80,27 @@ const (
 	WS_BORDER      = 0x00800000
 	WS_DLGFRAME    = 0x00400000
 
-	// Process access rights
-	PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
-	PROCESS_QUERY_INFORMATION         = 0x0400
-
-	// DWM attributes
-	DWMWA_EXTENDED_FRAME_BOUNDS = 9
-	DWMWA_CLOAKED              = 14
-
-	// Maximum path length
-	MAX_PATH = 260
 )
 
-// RECT structure for Windows API
-type RECT struct {
-	Left, Top, Right, Bottom int32
-}
-
 // WINDOWPLACEMENT structure
 type WINDOWPLACEMENT struct {
 	Length           uint32
 	Flags            uint32
 	ShowCmd          uint32
-	PtMinPosition    POINT
-	PtMaxPosition    POINT
-	RcNormalPosition RECT
+	PtMinPosition    win32.POINT
+	PtMaxPosition    win32.POINT
+	RcNormalPosition win32.RECT
 }
 
-// POINT structure
-type POINT struct {
-	X, Y int32
+// LASTINPUTINFO structure
+type LASTINPUTINFO struct {
+	CbSize uint32
+	DwTime uint32
 }
 
 // WindowsManager implements comprehensive window management
 type WindowsManager struct {
+	api         win32.API
 	cache       map[uintptr]*types.WindowInfo
 	cacheExpiry time.Duration
 	lastUpdate  time.Time
@@ -150,7 +108,13 @@ type WindowsManager struct {
 
 // NewManager creates a new Windows manager
 func NewManager() *WindowsManager {
+	return NewManagerWithAPI(win32.System{})
+}
+
+// NewManagerWithAPI creates a Windows manager that queries windows through api
+func NewManagerWithAPI(api win32.API) *WindowsManager {
 	return &WindowsManager{
+		api:         api,
 		cache:       make(map[uintptr]*types.WindowInfo),
 		cacheExpiry: 5 * time.Second, // Cache window info for 5 seconds
 	}
@@ -161,30 +125,42 @@ func (wm *WindowsManager) EnumerateWindows(filter *types.WindowFilter) ([]types.
 	var windows []types.WindowInfo
 	var zOrder int
 
-	// Callback function for EnumWindows
-	callback := syscall.NewCallback(func(hwnd, lParam uintptr) uintptr {
+	// One virtual desktop manager serves the whole enumeration
+	desktops, err := newVirtualDesktopManager()
+	if err == nil {
+		defer desktops.Close()
+	}
+
+	// EnumWindows runs top to bottom, so every window seen so far is above the current one
+	occlusion := &occlusionTracker{}
+
+	// Enumerate all top-level windows
+	err = wm.api.EnumWindows(func(hwnd uintptr) bool {
+		defer occlusion.add(hwnd)
+
 		windowInfo, err := wm.getWindowInfoDetailed(hwnd, zOrder)
 		if err != nil {
-			return 1 // Continue enumeration
+			return true // Continue enumeration
+		}
+		if desktops != nil {
+			desktops.fill(windowInfo)
 		}
+		occlusion.fill(windowInfo)
 
 		// Apply filters
 		if filter != nil {
-			if !wm.matchesFilter(windowInfo, filter) {
+			if !matchesFilter(windowInfo, filter) {
 				zOrder++
-				return 1 // Continue enumeration
+				return true // Continue enumeration
 			}
 		}
 
 		windows = append(windows, *windowInfo)
 		zOrder++
-		return 1 // Continue enumeration
+		return true // Continue enumeration
 	})
-
-	// Enumerate all top-level windows
-	ret, _, _ := enumWindows.Call(callback, 0)
-	if ret == 0 {
-		return nil, fmt.Errorf("EnumWindows failed")
+	if err != nil {
+		return nil, err
 	}
 
 	// Sort by z-order if requested
@@ -208,6 +184,8 @@ func (wm *WindowsManager) GetWindowInfo(handle uintptr) (*types.WindowInfo, erro
 	if err != nil {
 		return nil, err
 	}
+	FillVirtualDesktop(info)
+	FillOcclusion(info)
 
 	// Update cache
 	wm.cache[handle] = info
@@ -237,53 +215,44 @@ func (wm *WindowsManager) SetWindowPos(handle uintptr, rect types.Rectangle) err
 
 // SetWindowVisible shows or hides a window
 func (wm *WindowsManager) SetWindowVisible(handle uintptr, visible bool) error {
-	var cmd uintptr
+	cmd := win32.SW_HIDE
 	if visible {
-		cmd = SW_SHOW
-	} else {
-		cmd = SW_HIDE
-	}
-
-	ret, _, _ := showWindow.Call(handle, cmd)
-	if ret == 0 && visible {
-		// ShowWindow returns 0 if the window was previously hidden, which is not an error
+		cmd = win32.SW_SHOW
 	}
 
+	// ShowWindow returns the previous visibility, not success
+	wm.api.ShowWindow(handle, cmd)
 	return nil
 }
 
 // SetWindowState changes the window state (minimize, maximize, restore)
 func (wm *WindowsManager) SetWindowState(handle uintptr, state string) error {
-	var cmd uintptr
+	var cmd int
 	switch strings.ToLower(state) {
 	case "minimize", "minimized":
-		cmd = SW_MINIMIZE
+		cmd = win32.SW_MINIMIZE
 	case "maximize", "maximized":
-		cmd = SW_MAXIMIZE
+		cmd = win32.SW_MAXIMIZE
 	case "restore", "normal":
-		cmd = SW_RESTORE
+		cmd = win32.SW_RESTORE
 	case "hide", "hidden":
-		cmd = SW_HIDE
+		cmd = win32.SW_HIDE
 	case "show", "visible":
-		cmd = SW_SHOW
+		cmd = win32.SW_SHOW
 	default:
 		return fmt.Errorf("unsupported window state: %s", state)
 	}
 
-	ret, _, _ := showWindow.Call(handle, cmd)
-	if ret == 0 && (state == "show" || state == "visible") {
-		// ShowWindow returns 0 if the window was previously hidden
-	}
-
+	// ShowWindow returns the previous visibility, not success
+	wm.api.ShowWindow(handle, cmd)
 	return nil
 }
 
 // BringToForeground brings a window to the foreground
 func (wm *WindowsManager) BringToForeground(handle uintptr) error {
 	// First, restore the window if it's minimized
-	isMin, _, _ := isIconic.Call(handle)
-	if isMin != 0 {
-		showWindow.Call(handle, SW_RESTORE)
+	if wm.api.IsIconic(handle) {
+		wm.api.ShowWindow(handle, win32.SW_RESTORE)
 	}
 
 	// Bring to top
@@ -301,110 +270,102 @@ func (wm *WindowsManager) BringToForeground(handle uintptr) error {
 	return nil
 }
 
-// MoveWindow moves and resizes a window
-func (wm *WindowsManager) MoveWindow(handle uintptr, x, y, width, height int, repaint bool) error {
-	var repaintFlag uintptr
-	if repaint {
-		repaintFlag = 1
+// GetForegroundWindow returns the window that currently has keyboard focus
+func (wm *WindowsManager) GetForegroundWindow() (uintptr, error) {
+	handle, _, _ := getForegroundWindow.Call()
+	if handle == 0 {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, "no foreground window", nil)
 	}
 
-	ret, _, _ := moveWindow.Call(handle, uintptr(x), uintptr(y), uintptr(width), uintptr(height), repaintFlag)
+	return handle, nil
+}
+
+// GetCursorPosition returns the mouse cursor position in screen coordinates
+func (wm *WindowsManager) GetCursorPosition() (types.Point, error) {
+	var pt win32.POINT
+	ret, _, _ := getCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
 	if ret == 0 {
-		return fmt.Errorf("MoveWindow failed")
+		return types.Point{}, fmt.Errorf("GetCursorPos failed")
 	}
 
-	return nil
+	return types.Point{X: int(pt.X), Y: int(pt.Y)}, nil
 }
 
-// GetWindowPlacement gets the window placement information
-func (wm *WindowsManager) GetWindowPlacement(handle uintptr) (*WindowPlacement, error) {
-	var wp WINDOWPLACEMENT
-	wp.Length = uint32(unsafe.Sizeof(wp))
-
-	ret, _, _ := getWindowPlacement.Call(handle, uintptr(unsafe.Pointer(&wp)))
-	if ret == 0 {
-		return nil, fmt.Errorf("GetWindowPlacement failed")
+// WindowFromPoint returns the window at a screen position. With topLevel set it
+// walks up through parents and owners to the root window, so pointing at a button
+// or an owned popup yields the application window instead.
+func (wm *WindowsManager) WindowFromPoint(pt types.Point, topLevel bool) (uintptr, error) {
+	// POINT is passed by value, which the x64 calling convention packs into one register
+	packed := uintptr(uint32(int32(pt.X))) | uintptr(uint32(int32(pt.Y)))<<32
+	handle, _, _ := windowFromPoint.Call(packed)
+	if handle == 0 {
+		return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no window at %d,%d", pt.X, pt.Y), nil)
 	}
 
-	placement := &WindowPlacement{
-		ShowCmd: wp.ShowCmd,
-		MinPosition: types.Point{
-			X: int(wp.PtMinPosition.X),
-			Y: int(wp.PtMinPosition.Y),
-		},
-		MaxPosition: types.Point{
-			X: int(wp.PtMaxPosition.X),
-			Y: int(wp.PtMaxPosition.Y),
-		},
-		NormalPosition: types.Rectangle{
-			X:      int(wp.RcNormalPosition.Left),
-			Y:      int(wp.RcNormalPosition.Top),
-			Width:  int(wp.RcNormalPosition.Right - wp.RcNormalPosition.Left),
-			Height: int(wp.RcNormalPosition.Bottom - wp.RcNormalPosition.Top),
-		},
+	if topLevel {
+		if root, _, _ := getAncestor.Call(handle, GA_ROOTOWNER); root != 0 {
+			handle = root
+		}
 	}
 
-	return placement, nil
+	return handle, nil
 }
 
-// SetWindowPlacement sets the window placement
-func (wm *WindowsManager) SetWindowPlacement(handle uintptr, placement *WindowPlacement) error {
-	wp := WINDOWPLACEMENT{
-		Length:  uint32(unsafe.Sizeof(WINDOWPLACEMENT{})),
-		ShowCmd: placement.ShowCmd,
-		PtMinPosition: POINT{
-			X: int32(placement.MinPosition.X),
-			Y: int32(placement.MinPosition.Y),
-		},
-		PtMaxPosition: POINT{
-			X: int32(placement.MaxPosition.X),
-			Y: int32(placement.MaxPosition.Y),
-		},
-		RcNormalPosition: RECT{
-			Left:   int32(placement.NormalPosition.X),
-			Top:    int32(placement.NormalPosition.Y),
-			Right:  int32(placement.NormalPosition.X + placement.NormalPosition.Width),
-			Bottom: int32(placement.NormalPosition.Y + placement.NormalPosition.Height),
-		},
+// GetWindowTitle reads a window's current title, bypassing the info cache
+func (wm *WindowsManager) GetWindowTitle(handle uintptr) (string, error) {
+	if !wm.api.IsWindow(handle) {
+		return "", types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d no longer exists", handle), nil)
 	}
 
-	ret, _, _ := setWindowPlacement.Call(handle, uintptr(unsafe.Pointer(&wp)))
+	return wm.api.GetWindowText(handle), nil
+}
+
+// GetLastInputTime returns when the last keyboard or mouse input was received
+func (wm *WindowsManager) GetLastInputTime() (time.Time, error) {
+	info := LASTINPUTINFO{CbSize: uint32(unsafe.Sizeof(LASTINPUTINFO{}))}
+	ret, _, _ := getLastInputInfo.Call(uintptr(unsafe.Pointer(&info)))
 	if ret == 0 {
-		return fmt.Errorf("SetWindowPlacement failed")
+		return time.Time{}, fmt.Errorf("GetLastInputInfo failed")
 	}
 
-	return nil
-}
+	// Both tick counts wrap at 49.7 days; unsigned subtraction handles the wrap
+	now, _, _ := getTickCount.Call()
+	idle := time.Duration(uint32(now)-info.DwTime) * time.Millisecond
 
-// FindWindow finds a window by class name and window name
-func (wm *WindowsManager) FindWindow(className, windowName string) (uintptr, error) {
-	var classPtr, namePtr *uint16
+	return time.Now().Add(-idle), nil
+}
 
-	if className != "" {
-		var err error
-		classPtr, err = syscall.UTF16PtrFromString(className)
-		if err != nil {
-			return 0, err
-		}
+// MoveWindow moves and resizes a window
+func (wm *WindowsManager) MoveWindow(handle uintptr, x, y, width, height int, repaint bool) error {
+	var repaintFlag uintptr
+	if repaint {
+		repaintFlag = 1
 	}
 
-	if windowName != "" {
-		var err error
-		namePtr, err = syscall.UTF16PtrFromString(windowName)
-		if err != nil {
-			return 0, err
-		}
+	ret, _, _ := moveWindow.Call(handle, uintptr(x), uintptr(y), uintptr(width), uintptr(height), repaintFlag)
+	if ret == 0 {
+		return fmt.Errorf("MoveWindow failed")
 	}
 
-	handle, _, _ := findWindow.Call(
-		uintptr(unsafe.Pointer(classPtr)),
-		uintptr(unsafe.Pointer(namePtr)),
-	)
+	return nil
+}
 
-	if handle == 0 {
-		return 0, fmt.Errorf("window not found")
-	}
+// GetWindowPlacement gets the window placement information
+func (wm *WindowsManager) GetWindowPlacement(handle uintptr) (*WindowPlacement, error) {
+	return GetPlacement(handle)
+}
+
+// SetWindowPlacement sets the window placement
+func (wm *WindowsManager) SetWindowPlacement(handle uintptr, placement *WindowPlacement) error {
+	return SetPlacement(handle, placement)
+}
 
+// FindWindow finds a window by class name and window name
+func (wm *WindowsManager) FindWindow(className, windowName string) (uintptr, error) {
+	handle, err := wm.api.FindWindow(className, windowName)
+	if err != nil {
+		return 0, fmt.Errorf("window not found: %w", err)
+	}
 	return handle, nil
 }
 
@@ -413,7 +374,7 @@ func (wm *WindowsManager) GetWindowChildren(parent uintptr) ([]types.WindowInfo,
 	var children []types.WindowInfo
 
 	// Find first child
-	child, _, _ := getWindow.Call(parent, GW_CHILD)
+	child := wm.api.GetWindow(parent, win32.GW_CHILD)
 	if child == 0 {
 		return children, nil // No children
 	}
@@ -423,7 +384,7 @@ func (wm *WindowsManager) GetWindowChildren(parent uintptr) ([]types.WindowInfo,
 		if info, err := wm.GetWindowInfo(child); err == nil {
 			children = append(children, *info)
 		}
-		child, _, _ = getWindow.Call(child, GW_HWNDNEXT)
+		child = wm.api.GetWindow(child, win32.GW_HWNDNEXT)
 	}
 
 	return children, nil
@@ -431,7 +392,7 @@ func (wm *WindowsManager) GetWindowChildren(parent uintptr) ([]types.WindowInfo,
 
 // IsWindowTopMost checks if a window is topmost
 func (wm *WindowsManager) IsWindowTopMost(handle uintptr) bool {
-	exStyle, _, _ := getWindowLong.Call(handle, uintptr(int32(GWL_EXSTYLE)))
+	exStyle := wm.api.GetWindowLongPtr(handle, GWL_EXSTYLE)
 	return (exStyle & WS_EX_TOPMOST) != 0
 }
 
@@ -466,163 +427,56 @@ func (wm *WindowsManager) getWindowInfoDetailed(handle uintptr, zOrder int) (*ty
 		ZOrder: zOrder,
 	}
 
-	// Get window title
-	titleLen, _, _ := getWindowTextLengthW.Call(handle)
-	if titleLen > 0 {
-		titleBuf := make([]uint16, titleLen+1)
-		getWindowTextW.Call(handle, uintptr(unsafe.Pointer(&titleBuf[0])), uintptr(len(titleBuf)))
-		info.Title = syscall.UTF16ToString(titleBuf)
-	}
-
-	// Get class name
-	classBuf := make([]uint16, 256)
-	getClassName.Call(handle, uintptr(unsafe.Pointer(&classBuf[0])), 256)
-	info.ClassName = syscall.UTF16ToString(classBuf)
-
-	// Get process and thread IDs
-	var pid uint32
-	threadID, _, _ := getWindowThreadProcessId.Call(handle, uintptr(unsafe.Pointer(&pid)))
-	info.ProcessID = pid
-	info.ThreadID = uint32(threadID)
+	info.Title = wm.api.GetWindowText(handle)
+	info.ClassName = wm.api.GetClassName(handle)
+	info.ThreadID, info.ProcessID = wm.api.GetWindowThreadProcessID(handle)
 
 	// Get window rectangles
-	var rect RECT
-	getWindowRect.Call(handle, uintptr(unsafe.Pointer(&rect)))
-	info.Rect = types.Rectangle{
-		X:      int(rect.Left),
-		Y:      int(rect.Top),
-		Width:  int(rect.Right - rect.Left),
-		Height: int(rect.Bottom - rect.Top),
+	if rect, err := wm.api.GetWindowRect(handle); err == nil {
+		info.Rect = rect.Rectangle()
 	}
-
-	var clientRect RECT
-	getClientRect.Call(handle, uintptr(unsafe.Pointer(&clientRect)))
-	info.ClientRect = types.Rectangle{
-		X:      0,
-		Y:      0,
-		Width:  int(clientRect.Right),
-		Height: int(clientRect.Bottom),
+	if clientRect, err := wm.api.GetClientRect(handle); err == nil {
+		info.ClientRect = clientRect.Rectangle()
 	}
 
 	// Get window state
-	visible, _, _ := isWindowVisible.Call(handle)
-	info.IsVisible = visible != 0
-
-	minimized, _, _ := isIconic.Call(handle)
-	maximized, _, _ := isZoomed.Call(handle)
-
-	if minimized != 0 {
+	info.IsVisible = wm.api.IsWindowVisible(handle)
+	switch {
+	case wm.api.IsHungWindow(handle):
+		info.State = "hung"
+	case wm.api.IsIconic(handle):
 		info.State = "minimized"
-	} else if maximized != 0 {
+	case wm.api.IsZoomed(handle):
 		info.State = "maximized"
-	} else if info.IsVisible {
+	case info.IsVisible:
 		info.State = "visible"
-	} else {
+	default:
 		info.State = "hidden"
 	}
 
 	// Get additional window properties
 	info.IsTopMost = wm.IsWindowTopMost(handle)
+	info.DisplayAffinity = wm.displayAffinity(handle)
+	FillFullscreen(info)
 	
 	return info, nil
 }
 
-func (wm *WindowsManager) matchesFilter(info *types.WindowInfo, filter *types.WindowFilter) bool {
-	// Title filter
-	if filter.TitleContains != "" {
-		if !strings.Contains(strings.ToLower(info.Title), strings.ToLower(filter.TitleContains)) {
-			return false
-		}
-	}
-
-	// Class name filter
-	if len(filter.ClassNames) > 0 {
-		found := false
-		for _, className := range filter.ClassNames {
-			if strings.EqualFold(info.ClassName, className) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	// Process ID filter
-	if len(filter.ProcessIDs) > 0 {
-		found := false
-		for _, pid := range filter.ProcessIDs {
-			if info.ProcessID == pid {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
-	}
-
-	// Visible only filter
-	if filter.VisibleOnly && !info.IsVisible {
-		return false
-	}
-
-	// Size filters
-	if filter.MinimumSize != nil {
-		if info.Rect.Width < filter.MinimumSize.Width || info.Rect.Height < filter.MinimumSize.Height {
-			return false
-		}
-	}
-
-	if filter.MaximumSize != nil {
-		if info.Rect.Width > filter.MaximumSize.Width || info.Rect.Height > filter.MaximumSize.Height {
-			return false
-		}
-	}
-
-	// Exclude system windows
-	if filter.ExcludeSystem {
-		if wm.isSystemWindow(info) {
-			return false
-		}
-	}
-
-	return true
-}
-
-func (wm *WindowsManager) isSystemWindow(info *types.WindowInfo) bool {
-	// Common system window patterns
-	systemClasses := []string{
-		"Shell_TrayWnd",
-		"DV2ControlHost",
-		"MsgrIMEWindowClass",
-		"SysShadow",
-		"Button",
-		"Progman",
-		"WorkerW",
-	}
-
-	for _, sysClass := range systemClasses {
-		if strings.EqualFold(info.ClassName, sysClass) {
-			return true
-		}
+// displayAffinity reports the display affinity a window set to block capture, or ""
+func (wm *WindowsManager) displayAffinity(handle uintptr) string {
+	affinity, err := win32.GetWindowDisplayAffinity(handle)
+	if err != nil {
+		return ""
 	}
 
-	// Windows with no title and certain characteristics
-	if info.Title == "" && (info.Rect.Width < 100 || info.Rect.Height < 100) {
-		return true
+	switch affinity {
+	case WDA_MONITOR:
+		return types.DisplayAffinityMonitor
+	case WDA_EXCLUDEFROMCAPTURE:
+		return types.DisplayAffinityExcludeFromCapture
+	default:
+		return ""
 	}
-
-	return false
-}
-
-// WindowPlacement represents window placement information
-type WindowPlacement struct {
-	ShowCmd        uint32
-	MinPosition    types.Point
-	MaxPosition    types.Point
-	NormalPosition types.Rectangle
 }
 
 // Additional types for extended window information
@@ -0,0 +1,14 @@
+//go:build !windows && !linux && !(darwin && cgo)
+
+package window
+
+import (
+	"errors"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// newNativeManager fails: this platform has no native backend
+func newNativeManager() (types.WindowManager, error) {
+	return nil, errors.New("no native window manager on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package window
+
+import (
+	"os"
+	"sync"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// desktop is the desktop shared by every manager: the platform's native one if it can
+// be reached, otherwise a simulated one. SCREENSHOT_BACKEND=fake forces the simulated
+// desktop. Sharing it lets the screenshot engine capture the same windows the manager
+// reports and moves.
+var desktop = sync.OnceValue(func() types.WindowManager {
+	if os.Getenv("SCREENSHOT_BACKEND") != "fake" {
+		if manager, err := newNativeManager(); err == nil {
+			return manager
+		}
+	}
+	return NewFakeManager(FakeWindows())
+})
+
+// NewManager returns the window manager of this platform: an experimental native
+// manager on X11 and macOS, or a FakeManager where there is no display to manage
+func NewManager() types.WindowManager {
+	return desktop()
+}
@@ -0,0 +1,160 @@
+//go:build darwin && cgo
+
+package window
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/quartz"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// QuartzManager is an experimental, read-only WindowManager for macOS built on Quartz
+// Window Services. Handles are CGWindowIDs and ClassName is the owning application's
+// name. Only normal application windows (layer 0) are listed; the menu bar, Dock and
+// overlays are left out. Moving, showing or focusing windows needs the Accessibility
+// API and is not supported.
+type QuartzManager struct{}
+
+// NewQuartzManager creates a macOS window manager
+func NewQuartzManager() *QuartzManager {
+	return &QuartzManager{}
+}
+
+// newNativeManager returns the Quartz window manager, which needs no connection
+func newNativeManager() (types.WindowManager, error) {
+	return NewQuartzManager(), nil
+}
+
+// EnumerateWindows lists application windows, frontmost first
+func (m *QuartzManager) EnumerateWindows(filter *types.WindowFilter) ([]types.WindowInfo, error) {
+	all, err := quartz.Windows()
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []types.WindowInfo
+	for _, w := range all {
+		if w.Layer != 0 {
+			continue
+		}
+		info := quartzWindowInfo(&w, len(windows))
+		if filter != nil && !matchesFilter(&info, filter) {
+			continue
+		}
+		windows = append(windows, info)
+	}
+	return windows, nil
+}
+
+// GetWindowInfo returns information about a window
+func (m *QuartzManager) GetWindowInfo(handle uintptr) (*types.WindowInfo, error) {
+	windows, err := m.EnumerateWindows(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range windows {
+		if windows[i].Handle == handle {
+			return &windows[i], nil
+		}
+	}
+	return nil, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d does not exist", handle), nil)
+}
+
+// SetWindowPos is not supported without the Accessibility API
+func (m *QuartzManager) SetWindowPos(handle uintptr, rect types.Rectangle) error {
+	return fmt.Errorf("moving windows is not supported on macOS")
+}
+
+// SetWindowVisible is not supported without the Accessibility API
+func (m *QuartzManager) SetWindowVisible(handle uintptr, visible bool) error {
+	return fmt.Errorf("showing and hiding windows is not supported on macOS")
+}
+
+// SetWindowState is not supported without the Accessibility API
+func (m *QuartzManager) SetWindowState(handle uintptr, state string) error {
+	return fmt.Errorf("changing window state is not supported on macOS")
+}
+
+// BringToForeground is not supported without the Accessibility API
+func (m *QuartzManager) BringToForeground(handle uintptr) error {
+	return fmt.Errorf("activating windows is not supported on macOS")
+}
+
+// GetForegroundWindow returns the frontmost on-screen application window
+func (m *QuartzManager) GetForegroundWindow() (uintptr, error) {
+	windows, err := m.EnumerateWindows(nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, info := range windows {
+		if info.IsVisible {
+			return info.Handle, nil
+		}
+	}
+	return 0, types.NewCaptureError(types.ErrWindowNotFound, "no foreground window", nil)
+}
+
+// GetCursorPosition returns the mouse position in screen coordinates
+func (m *QuartzManager) GetCursorPosition() (types.Point, error) {
+	x, y := quartz.CursorPosition()
+	return types.Point{X: x, Y: y}, nil
+}
+
+// WindowFromPoint returns the frontmost on-screen window containing a point. Only
+// top-level windows are reported, so topLevel makes no difference.
+func (m *QuartzManager) WindowFromPoint(pt types.Point, topLevel bool) (uintptr, error) {
+	windows, err := m.EnumerateWindows(nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, info := range windows {
+		r := info.Rect
+		if info.IsVisible && pt.X >= r.X && pt.X < r.X+r.Width && pt.Y >= r.Y && pt.Y < r.Y+r.Height {
+			return info.Handle, nil
+		}
+	}
+	return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no window at %d,%d", pt.X, pt.Y), nil)
+}
+
+// GetWindowTitle reads a window's current title
+func (m *QuartzManager) GetWindowTitle(handle uintptr) (string, error) {
+	info, err := m.GetWindowInfo(handle)
+	if err != nil {
+		return "", err
+	}
+	return info.Title, nil
+}
+
+// IsElementVisible is not supported without the Accessibility API
+func (m *QuartzManager) IsElementVisible(handle uintptr, name string) (bool, error) {
+	return false, fmt.Errorf("UI element queries are not supported on macOS")
+}
+
+// GetLastInputTime returns the time of the last keyboard or mouse input
+func (m *QuartzManager) GetLastInputTime() (time.Time, error) {
+	return time.Now().Add(-quartz.IdleTime()), nil
+}
+
+// quartzWindowInfo converts a Quartz window description. Off-screen windows may be
+// minimized, hidden or on another Space; Quartz doesn't say which, so all count as hidden.
+func quartzWindowInfo(w *quartz.Window, zorder int) types.WindowInfo {
+	info := types.WindowInfo{
+		Handle:     uintptr(w.ID),
+		Title:      w.Title,
+		ClassName:  w.Owner,
+		ProcessID:  w.PID,
+		Rect:       types.Rectangle{X: w.X, Y: w.Y, Width: w.Width, Height: w.Height},
+		ClientRect: types.Rectangle{Width: w.Width, Height: w.Height},
+		State:      "hidden",
+		ZOrder:     zorder,
+	}
+	if w.OnScreen {
+		info.State, info.IsVisible = "visible", true
+	}
+	return info
+}
+
+// Ensure QuartzManager implements the interface
+var _ types.WindowManager = (*QuartzManager)(nil)
@@ -0,0 +1,361 @@
+//go:build linux
+
+package window
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/x11"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// EWMH _NET_WM_STATE actions and the ICCCM iconic state
+const (
+	netWMStateRemove = 0
+	netWMStateAdd    = 1
+	iconicState      = 3
+)
+
+// X11Manager is an experimental WindowManager for X11 desktops. Window handles are the
+// X IDs of application (client) windows; Rect is the window manager's frame around
+// one and ClientRect the client window itself. Window state changes go through the
+// window manager via EWMH client messages, so they need an EWMH-compliant one.
+type X11Manager struct {
+	conn *x11.Conn
+}
+
+// NewX11Manager creates a window manager over an X connection
+func NewX11Manager(conn *x11.Conn) *X11Manager {
+	return &X11Manager{conn: conn}
+}
+
+// newNativeManager connects to the display named by $DISPLAY
+func newNativeManager() (types.WindowManager, error) {
+	conn, err := x11.Default()
+	if err != nil {
+		return nil, err
+	}
+	return NewX11Manager(conn), nil
+}
+
+// EnumerateWindows lists the managed top-level windows, topmost first
+func (m *X11Manager) EnumerateWindows(filter *types.WindowFilter) ([]types.WindowInfo, error) {
+	clients, err := m.clientWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []types.WindowInfo
+	for i, w := range clients {
+		info, err := m.windowInfo(w, i)
+		if err != nil {
+			continue // The window closed during enumeration
+		}
+		if filter != nil && !matchesFilter(info, filter) {
+			continue
+		}
+		windows = append(windows, *info)
+	}
+	return windows, nil
+}
+
+// GetWindowInfo returns information about a window
+func (m *X11Manager) GetWindowInfo(handle uintptr) (*types.WindowInfo, error) {
+	zorder := 0
+	if clients, err := m.clientWindows(); err == nil {
+		for i, w := range clients {
+			if uintptr(w) == handle {
+				zorder = i
+				break
+			}
+		}
+	}
+	return m.windowInfo(x11.Window(handle), zorder)
+}
+
+// SetWindowPos moves and resizes a window's frame
+func (m *X11Manager) SetWindowPos(handle uintptr, rect types.Rectangle) error {
+	info, err := m.windowInfo(x11.Window(handle), 0)
+	if err != nil {
+		return err
+	}
+	width := rect.Width - (info.Rect.Width - info.ClientRect.Width)
+	height := rect.Height - (info.Rect.Height - info.ClientRect.Height)
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid window size: %dx%d", rect.Width, rect.Height)
+	}
+	return m.conn.ConfigureWindow(x11.Window(handle), rect.X, rect.Y, width, height)
+}
+
+// SetWindowVisible shows or hides a window
+func (m *X11Manager) SetWindowVisible(handle uintptr, visible bool) error {
+	if visible {
+		return m.SetWindowState(handle, "show")
+	}
+	return m.SetWindowState(handle, "hide")
+}
+
+// SetWindowState changes the window state (minimize, maximize, restore, hide, show)
+func (m *X11Manager) SetWindowState(handle uintptr, state string) error {
+	w := x11.Window(handle)
+	switch strings.ToLower(state) {
+	case "minimize", "minimized":
+		return m.conn.SendClientMessage(w, "WM_CHANGE_STATE", iconicState)
+	case "maximize", "maximized":
+		return m.setMaximized(w, netWMStateAdd)
+	case "restore", "normal":
+		if err := m.setMaximized(w, netWMStateRemove); err != nil {
+			return err
+		}
+		return m.conn.MapWindow(w) // Deiconifies a minimized window
+	case "hide", "hidden":
+		return m.conn.UnmapWindow(w)
+	case "show", "visible":
+		return m.conn.MapWindow(w)
+	default:
+		return fmt.Errorf("unsupported window state: %s", state)
+	}
+}
+
+// BringToForeground asks the window manager to raise and focus a window
+func (m *X11Manager) BringToForeground(handle uintptr) error {
+	// Source indication 2 marks the request as coming from a pager, which window
+	// managers honor without focus-stealing prevention
+	return m.conn.SendClientMessage(x11.Window(handle), "_NET_ACTIVE_WINDOW", 2)
+}
+
+// GetForegroundWindow returns the active window according to the window manager
+func (m *X11Manager) GetForegroundWindow() (uintptr, error) {
+	prop, err := m.conn.GetProperty(m.conn.Screen().Root, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return 0, err
+	}
+	if active := prop.Uint32s(); len(active) > 0 && active[0] != 0 {
+		return uintptr(active[0]), nil
+	}
+	return 0, types.NewCaptureError(types.ErrWindowNotFound, "no foreground window", nil)
+}
+
+// GetCursorPosition returns the pointer position in screen coordinates
+func (m *X11Manager) GetCursorPosition() (types.Point, error) {
+	x, y, err := m.conn.QueryPointer()
+	if err != nil {
+		return types.Point{}, err
+	}
+	return types.Point{X: x, Y: y}, nil
+}
+
+// WindowFromPoint returns the topmost shown top-level window containing a point. Only
+// top-level windows are reported, so topLevel makes no difference.
+func (m *X11Manager) WindowFromPoint(pt types.Point, topLevel bool) (uintptr, error) {
+	windows, err := m.EnumerateWindows(nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, info := range windows {
+		if !info.IsVisible || info.State == "minimized" {
+			continue
+		}
+		r := info.Rect
+		if pt.X >= r.X && pt.X < r.X+r.Width && pt.Y >= r.Y && pt.Y < r.Y+r.Height {
+			return info.Handle, nil
+		}
+	}
+	return 0, types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("no window at %d,%d", pt.X, pt.Y), nil)
+}
+
+// GetWindowTitle reads a window's current title
+func (m *X11Manager) GetWindowTitle(handle uintptr) (string, error) {
+	if _, err := m.conn.GetGeometry(x11.Window(handle)); err != nil {
+		return "", x11WindowError(handle, err)
+	}
+	return m.title(x11.Window(handle)), nil
+}
+
+// IsElementVisible is not supported: X11 has no UI Automation equivalent the server
+// can query
+func (m *X11Manager) IsElementVisible(handle uintptr, name string) (bool, error) {
+	return false, fmt.Errorf("UI element queries are not supported on X11")
+}
+
+// GetLastInputTime returns the time of the last input from the MIT-SCREEN-SAVER extension
+func (m *X11Manager) GetLastInputTime() (time.Time, error) {
+	idle, err := m.conn.IdleTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-idle), nil
+}
+
+// clientWindows lists the managed top-level windows, topmost first. Without an EWMH
+// window manager the root window's children, minus override-redirect popups, stand in
+// for them.
+func (m *X11Manager) clientWindows() ([]x11.Window, error) {
+	root := m.conn.Screen().Root
+	for _, name := range []string{"_NET_CLIENT_LIST_STACKING", "_NET_CLIENT_LIST"} {
+		prop, err := m.conn.GetProperty(root, name)
+		if err != nil {
+			return nil, err
+		}
+		if ids := prop.Uint32s(); len(ids) > 0 {
+			windows := make([]x11.Window, len(ids))
+			for i, id := range ids {
+				windows[len(ids)-1-i] = x11.Window(id) // The lists run bottom to top
+			}
+			return windows, nil
+		}
+	}
+
+	_, children, err := m.conn.QueryTree(root)
+	if err != nil {
+		return nil, err
+	}
+	var windows []x11.Window
+	for i := len(children) - 1; i >= 0; i-- {
+		if attrs, err := m.conn.GetWindowAttributes(children[i]); err == nil && !attrs.OverrideRedirect {
+			windows = append(windows, children[i])
+		}
+	}
+	return windows, nil
+}
+
+// windowInfo gathers the WindowInfo of a client window
+func (m *X11Manager) windowInfo(w x11.Window, zorder int) (*types.WindowInfo, error) {
+	handle := uintptr(w)
+	client, err := m.conn.GetGeometry(w)
+	if err != nil {
+		return nil, x11WindowError(handle, err)
+	}
+	frame, err := m.conn.TopLevel(w)
+	if err != nil {
+		return nil, x11WindowError(handle, err)
+	}
+	frameGeometry, err := m.conn.GetGeometry(frame)
+	if err != nil {
+		return nil, x11WindowError(handle, err)
+	}
+	attrs, err := m.conn.GetWindowAttributes(frame)
+	if err != nil {
+		return nil, x11WindowError(handle, err)
+	}
+
+	info := &types.WindowInfo{
+		Handle:    handle,
+		Title:     m.title(w),
+		ClassName: m.className(w),
+		ProcessID: m.processID(w),
+		Rect: types.Rectangle{
+			X:      frameGeometry.X,
+			Y:      frameGeometry.Y,
+			Width:  frameGeometry.Width + 2*frameGeometry.Border,
+			Height: frameGeometry.Height + 2*frameGeometry.Border,
+		},
+		ClientRect: types.Rectangle{Width: client.Width, Height: client.Height},
+		ZOrder:     zorder,
+	}
+
+	states := m.states(w)
+	switch {
+	case states["_NET_WM_STATE_HIDDEN"] || m.iconic(w):
+		info.State, info.IsVisible = "minimized", true
+	case attrs.MapState == x11.IsViewable && states["_NET_WM_STATE_MAXIMIZED_VERT"] && states["_NET_WM_STATE_MAXIMIZED_HORZ"]:
+		info.State, info.IsVisible = "maximized", true
+	case attrs.MapState == x11.IsViewable:
+		info.State, info.IsVisible = "visible", true
+	default:
+		info.State = "hidden"
+	}
+	info.IsTopMost = states["_NET_WM_STATE_ABOVE"]
+	return info, nil
+}
+
+// title returns _NET_WM_NAME, falling back to the legacy WM_NAME
+func (m *X11Manager) title(w x11.Window) string {
+	for _, name := range []string{"_NET_WM_NAME", "WM_NAME"} {
+		if prop, err := m.conn.GetProperty(w, name); err == nil && prop != nil && len(prop.Value) > 0 {
+			return string(prop.Value)
+		}
+	}
+	return ""
+}
+
+// className returns the class part of WM_CLASS
+func (m *X11Manager) className(w x11.Window) string {
+	prop, err := m.conn.GetProperty(w, "WM_CLASS")
+	if err != nil {
+		return ""
+	}
+	if parts := prop.Strings(); len(parts) > 1 {
+		return parts[1]
+	}
+	return ""
+}
+
+// processID returns _NET_WM_PID, which clients set voluntarily
+func (m *X11Manager) processID(w x11.Window) uint32 {
+	prop, err := m.conn.GetProperty(w, "_NET_WM_PID")
+	if err != nil {
+		return 0
+	}
+	if pid := prop.Uint32s(); len(pid) > 0 {
+		return pid[0]
+	}
+	return 0
+}
+
+// states returns the names of the atoms in a window's _NET_WM_STATE that matter here
+func (m *X11Manager) states(w x11.Window) map[string]bool {
+	states := make(map[string]bool)
+	prop, err := m.conn.GetProperty(w, "_NET_WM_STATE")
+	if err != nil {
+		return states
+	}
+	set := make(map[uint32]bool)
+	for _, atom := range prop.Uint32s() {
+		set[atom] = true
+	}
+	for _, name := range []string{"_NET_WM_STATE_HIDDEN", "_NET_WM_STATE_MAXIMIZED_VERT", "_NET_WM_STATE_MAXIMIZED_HORZ", "_NET_WM_STATE_ABOVE"} {
+		if atom, err := m.conn.Atom(name); err == nil && set[uint32(atom)] {
+			states[name] = true
+		}
+	}
+	return states
+}
+
+// iconic reports whether the ICCCM WM_STATE of a window is IconicState
+func (m *X11Manager) iconic(w x11.Window) bool {
+	prop, err := m.conn.GetProperty(w, "WM_STATE")
+	if err != nil {
+		return false
+	}
+	state := prop.Uint32s()
+	return len(state) > 0 && state[0] == iconicState
+}
+
+// setMaximized adds or removes both maximized states through the window manager
+func (m *X11Manager) setMaximized(w x11.Window, action uint32) error {
+	vert, err := m.conn.Atom("_NET_WM_STATE_MAXIMIZED_VERT")
+	if err != nil {
+		return err
+	}
+	horz, err := m.conn.Atom("_NET_WM_STATE_MAXIMIZED_HORZ")
+	if err != nil {
+		return err
+	}
+	return m.conn.SendClientMessage(w, "_NET_WM_STATE", action, uint32(vert), uint32(horz), 2)
+}
+
+// x11WindowError reports a BadWindow or BadDrawable error as a missing window
+func x11WindowError(handle uintptr, err error) error {
+	var xerr *x11.Error
+	if errors.As(err, &xerr) && (xerr.Code == 3 || xerr.Code == 9) {
+		return types.NewCaptureError(types.ErrWindowNotFound, fmt.Sprintf("window %d no longer exists", handle), err)
+	}
+	return err
+}
+
+// Ensure X11Manager implements the interface
+var _ types.WindowManager = (*X11Manager)(nil)
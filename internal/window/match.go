@@ -0,0 +1,263 @@
+package window
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// TitleMatchMode selects how a title pattern is compared against window titles
+type TitleMatchMode string
+
+const (
+	TitleMatchExact    TitleMatchMode = "exact"
+	TitleMatchContains TitleMatchMode = "contains"
+	TitleMatchRegex    TitleMatchMode = "regex"
+	TitleMatchFuzzy    TitleMatchMode = "fuzzy"
+)
+
+// Title matching parameters
+const (
+	fuzzyMatchThreshold = 0.6 // Minimum fuzzy score accepted as a match
+	nearMissThreshold   = 0.3 // Minimum similarity reported as a near-miss
+	maxNearMisses       = 5   // Candidates listed in match errors
+)
+
+// TitleMatch is a window ranked against a title pattern
+type TitleMatch struct {
+	Window types.WindowInfo `json:"window"`
+	Score  float64          `json:"score"` // 1.0 is an exact match
+}
+
+// TitleMatchError reports that a title pattern matched no window or more than one
+type TitleMatchError struct {
+	Pattern    string
+	Mode       TitleMatchMode
+	Matched    int
+	Candidates []TitleMatch
+}
+
+// ErrorCode classifies the failure as a missing or ambiguous window
+func (e *TitleMatchError) ErrorCode() types.ErrorCode {
+	if e.Matched == 0 {
+		return types.ErrWindowNotFound
+	}
+	return types.ErrAmbiguousWindow
+}
+
+func (e *TitleMatchError) Error() string {
+	var b strings.Builder
+	if e.Matched == 0 {
+		fmt.Fprintf(&b, "no window title matches %q (%s)", e.Pattern, e.Mode)
+	} else {
+		fmt.Fprintf(&b, "%d windows match %q (%s); refine the pattern or use method \"handle\"", e.Matched, e.Pattern, e.Mode)
+	}
+	if len(e.Candidates) > 0 {
+		if e.Matched == 0 {
+			b.WriteString("; closest titles: ")
+		} else {
+			b.WriteString("; candidates: ")
+		}
+		for i, candidate := range e.Candidates {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%q (handle %d)", candidate.Window.Title, candidate.Window.Handle)
+		}
+	}
+	return b.String()
+}
+
+// RankWindowsByTitle scores windows against pattern and returns the matches best first.
+// Ties are broken by visibility and then z-order.
+func RankWindowsByTitle(windows []types.WindowInfo, pattern string, mode TitleMatchMode) ([]TitleMatch, error) {
+	score, err := titleScorer(pattern, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []TitleMatch
+	for _, info := range windows {
+		if info.Title == "" {
+			continue
+		}
+		if s := score(info.Title); s > 0 {
+			matches = append(matches, TitleMatch{Window: info, Score: s})
+		}
+	}
+	sortTitleMatches(matches)
+	return matches, nil
+}
+
+// SelectWindowByTitle picks the single window matching pattern. When several windows
+// match, pickBest takes the top-ranked one instead of failing. Failures are returned
+// as *TitleMatchError listing the candidates or, when nothing matched, the near-misses.
+func SelectWindowByTitle(windows []types.WindowInfo, pattern string, mode TitleMatchMode, pickBest bool) (*types.WindowInfo, error) {
+	matches, err := RankWindowsByTitle(windows, pattern, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(matches) == 1:
+		return &matches[0].Window, nil
+	case len(matches) > 1:
+		// An exact title is unambiguous even when other windows contain it
+		if pickBest || (matches[0].Score == 1 && matches[1].Score < 1) {
+			return &matches[0].Window, nil
+		}
+		return nil, &TitleMatchError{Pattern: pattern, Mode: mode, Matched: len(matches), Candidates: truncateMatches(matches)}
+	}
+
+	// Nothing matched; suggest the titles closest to the pattern
+	var nearMisses []TitleMatch
+	for _, info := range windows {
+		if info.Title == "" {
+			continue
+		}
+		if s := similarity(strings.ToLower(pattern), strings.ToLower(info.Title)); s >= nearMissThreshold {
+			nearMisses = append(nearMisses, TitleMatch{Window: info, Score: s})
+		}
+	}
+	sortTitleMatches(nearMisses)
+	return nil, &TitleMatchError{Pattern: pattern, Mode: mode, Candidates: truncateMatches(nearMisses)}
+}
+
+// titleScorer returns a scoring function for mode; a score of 0 means no match
+func titleScorer(pattern string, mode TitleMatchMode) (func(title string) float64, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("title pattern cannot be empty")
+	}
+	lowerPattern := strings.ToLower(pattern)
+
+	switch mode {
+	case TitleMatchExact:
+		return func(title string) float64 {
+			if title == pattern {
+				return 1
+			}
+			return 0
+		}, nil
+	case TitleMatchContains:
+		return func(title string) float64 {
+			return containsScore(lowerPattern, strings.ToLower(title))
+		}, nil
+	case TitleMatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title regex: %w", err)
+		}
+		return func(title string) float64 {
+			loc := re.FindStringIndex(title)
+			if loc == nil {
+				return 0
+			}
+			// Patterns covering more of the title rank higher
+			return coverage(loc[1]-loc[0], len(title))
+		}, nil
+	case TitleMatchFuzzy:
+		return func(title string) float64 {
+			lowerTitle := strings.ToLower(title)
+			if s := containsScore(lowerPattern, lowerTitle); s > 0 {
+				return s
+			}
+			if s := similarity(lowerPattern, lowerTitle); s >= fuzzyMatchThreshold {
+				// Keep fuzzy hits below every substring hit
+				return s * 0.5
+			}
+			return 0
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown title match mode: %s", mode)
+	}
+}
+
+// containsScore scores a case-insensitive substring match by how much of the title it covers
+func containsScore(pattern, title string) float64 {
+	if pattern == title {
+		return 1
+	}
+	if !strings.Contains(title, pattern) {
+		return 0
+	}
+	return 0.5 + 0.49*coverage(len(pattern), len(title))
+}
+
+// coverage returns the fraction of a title of length total covered by n bytes
+func coverage(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	if n >= total {
+		return 1
+	}
+	return float64(n) / float64(total)
+}
+
+// similarity compares pattern against the best-aligned window of title of the same
+// length, so short patterns are not penalised for long titles
+func similarity(pattern, title string) float64 {
+	p := []rune(pattern)
+	t := []rune(title)
+	if len(p) == 0 || len(t) == 0 {
+		return 0
+	}
+	if len(t) <= len(p) {
+		return 1 - float64(levenshtein(p, t))/float64(len(p))
+	}
+
+	best := 0.0
+	for start := 0; start+len(p) <= len(t); start++ {
+		s := 1 - float64(levenshtein(p, t[start:start+len(p)]))/float64(len(p))
+		if s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// sortTitleMatches orders matches by score, then visibility, then z-order
+func sortTitleMatches(matches []TitleMatch) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Window.IsVisible != b.Window.IsVisible {
+			return a.Window.IsVisible
+		}
+		return a.Window.ZOrder < b.Window.ZOrder
+	})
+}
+
+// truncateMatches limits a ranked list to the candidates reported in errors
+func truncateMatches(matches []TitleMatch) []TitleMatch {
+	if len(matches) > maxNearMisses {
+		return matches[:maxNearMisses]
+	}
+	return matches
+}
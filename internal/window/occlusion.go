@@ -0,0 +1,132 @@
+//go:build windows
+
+package window
+
+import (
+	"math"
+	"sort"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// occluder is a window that can cover windows below it in z-order
+type occluder struct {
+	handle uintptr
+	rect   types.Rectangle
+}
+
+// occlusionTracker accumulates the windows seen so far while enumerating top-level
+// windows from the top of the z-order down
+type occlusionTracker struct {
+	above []occluder
+}
+
+// add records a window as covering everything enumerated after it
+func (t *occlusionTracker) add(handle uintptr) {
+	if rect, ok := occludingRect(handle); ok {
+		t.above = append(t.above, occluder{handle: handle, rect: rect})
+	}
+}
+
+// fill sets the occlusion fields of a window from the windows above it
+func (t *occlusionTracker) fill(info *types.WindowInfo) {
+	fillOcclusion(info, t.above)
+}
+
+// FillOcclusion reports how much of a top-level window is covered by the windows above
+// it in z-order. Minimized and hidden windows are left unset.
+func FillOcclusion(info *types.WindowInfo) {
+	var above []occluder
+	for hwnd := win32.GetWindow(info.Handle, win32.GW_HWNDPREV); hwnd != 0; hwnd = win32.GetWindow(hwnd, win32.GW_HWNDPREV) {
+		if rect, ok := occludingRect(hwnd); ok {
+			above = append(above, occluder{handle: hwnd, rect: rect})
+		}
+	}
+	fillOcclusion(info, above)
+}
+
+// fillOcclusion sets OccludedPercent and OccludedBy from the windows above info
+func fillOcclusion(info *types.WindowInfo, above []occluder) {
+	target, ok := occludingRect(info.Handle)
+	if !ok || target.Width <= 0 || target.Height <= 0 {
+		return
+	}
+
+	var covering []types.Rectangle
+	info.OccludedBy = nil
+	for _, other := range above {
+		if overlap, ok := intersectRect(target, other.rect); ok {
+			covering = append(covering, overlap)
+			info.OccludedBy = append(info.OccludedBy, other.handle)
+		}
+	}
+
+	covered := unionArea(covering)
+	percent := float64(covered) * 100 / float64(target.Width*target.Height)
+	info.OccludedPercent = math.Round(percent*10) / 10
+}
+
+// occludingRect returns the on-screen bounds of a window that is actually drawn:
+// visible, not minimized and not cloaked by DWM. The extended frame bounds are used so
+// invisible resize borders and shadows don't count as cover.
+func occludingRect(handle uintptr) (types.Rectangle, bool) {
+	if !win32.IsWindowVisible(handle) || win32.IsIconic(handle) || win32.IsCloaked(handle) {
+		return types.Rectangle{}, false
+	}
+
+	rect, err := win32.ExtendedFrameBounds(handle)
+	if err != nil {
+		rect, _ = win32.GetWindowRect(handle)
+	}
+	bounds := rect.Rectangle()
+	return bounds, bounds.Width > 0 && bounds.Height > 0
+}
+
+// intersectRect returns the overlap of two rectangles
+func intersectRect(a, b types.Rectangle) (types.Rectangle, bool) {
+	left := max(a.X, b.X)
+	top := max(a.Y, b.Y)
+	right := min(a.X+a.Width, b.X+b.Width)
+	bottom := min(a.Y+a.Height, b.Y+b.Height)
+	if right <= left || bottom <= top {
+		return types.Rectangle{}, false
+	}
+	return types.Rectangle{X: left, Y: top, Width: right - left, Height: bottom - top}, true
+}
+
+// unionArea returns the area covered by a set of possibly overlapping rectangles. The
+// edges split the plane into a grid and each cell is counted once if any rectangle
+// covers it.
+func unionArea(rects []types.Rectangle) int {
+	if len(rects) == 0 {
+		return 0
+	}
+
+	var xs, ys []int
+	for _, r := range rects {
+		xs = append(xs, r.X, r.X+r.Width)
+		ys = append(ys, r.Y, r.Y+r.Height)
+	}
+	sort.Ints(xs)
+	sort.Ints(ys)
+
+	area := 0
+	for i := 0; i+1 < len(xs); i++ {
+		if xs[i] == xs[i+1] {
+			continue
+		}
+		for j := 0; j+1 < len(ys); j++ {
+			if ys[j] == ys[j+1] {
+				continue
+			}
+			for _, r := range rects {
+				if xs[i] >= r.X && xs[i+1] <= r.X+r.Width && ys[j] >= r.Y && ys[j+1] <= r.Y+r.Height {
+					area += (xs[i+1] - xs[i]) * (ys[j+1] - ys[j])
+					break
+				}
+			}
+		}
+	}
+	return area
+}
@@ -0,0 +1,98 @@
+//go:build windows
+
+package window
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// WINDOWPLACEMENT flags
+const (
+	WPF_SETMINPOSITION     = 0x0001
+	WPF_RESTORETOMAXIMIZED = 0x0002
+)
+
+// WindowPlacement represents window placement information
+type WindowPlacement struct {
+	Flags          uint32
+	ShowCmd        uint32
+	MinPosition    types.Point
+	MaxPosition    types.Point
+	NormalPosition types.Rectangle
+}
+
+// GetPlacement reads a window's show state and restored, minimized and maximized positions
+func GetPlacement(handle uintptr) (*WindowPlacement, error) {
+	var wp WINDOWPLACEMENT
+	wp.Length = uint32(unsafe.Sizeof(wp))
+
+	ret, _, err := getWindowPlacement.Call(handle, uintptr(unsafe.Pointer(&wp)))
+	if ret == 0 {
+		return nil, fmt.Errorf("GetWindowPlacement failed: %w", err)
+	}
+
+	return &WindowPlacement{
+		Flags:   wp.Flags,
+		ShowCmd: wp.ShowCmd,
+		MinPosition: types.Point{
+			X: int(wp.PtMinPosition.X),
+			Y: int(wp.PtMinPosition.Y),
+		},
+		MaxPosition: types.Point{
+			X: int(wp.PtMaxPosition.X),
+			Y: int(wp.PtMaxPosition.Y),
+		},
+		NormalPosition: types.Rectangle{
+			X:      int(wp.RcNormalPosition.Left),
+			Y:      int(wp.RcNormalPosition.Top),
+			Width:  int(wp.RcNormalPosition.Right - wp.RcNormalPosition.Left),
+			Height: int(wp.RcNormalPosition.Bottom - wp.RcNormalPosition.Top),
+		},
+	}, nil
+}
+
+// SetPlacement applies a placement read with GetPlacement
+func SetPlacement(handle uintptr, placement *WindowPlacement) error {
+	wp := WINDOWPLACEMENT{
+		Length:  uint32(unsafe.Sizeof(WINDOWPLACEMENT{})),
+		Flags:   placement.Flags & WPF_RESTORETOMAXIMIZED,
+		ShowCmd: placement.ShowCmd,
+		PtMinPosition: win32.POINT{
+			X: int32(placement.MinPosition.X),
+			Y: int32(placement.MinPosition.Y),
+		},
+		PtMaxPosition: win32.POINT{
+			X: int32(placement.MaxPosition.X),
+			Y: int32(placement.MaxPosition.Y),
+		},
+		RcNormalPosition: win32.RECT{
+			Left:   int32(placement.NormalPosition.X),
+			Top:    int32(placement.NormalPosition.Y),
+			Right:  int32(placement.NormalPosition.X + placement.NormalPosition.Width),
+			Bottom: int32(placement.NormalPosition.Y + placement.NormalPosition.Height),
+		},
+	}
+
+	ret, _, err := setWindowPlacement.Call(handle, uintptr(unsafe.Pointer(&wp)))
+	if ret == 0 {
+		return fmt.Errorf("SetWindowPlacement failed: %w", err)
+	}
+	return nil
+}
+
+// RestorePlacement puts a window back into a saved placement without activating it, so
+// a window temporarily shown for capture returns to its original state unnoticed
+func RestorePlacement(handle uintptr, placement *WindowPlacement) error {
+	restored := *placement
+	switch restored.ShowCmd {
+	case win32.SW_SHOWMINIMIZED, win32.SW_MINIMIZE:
+		restored.ShowCmd = win32.SW_SHOWMINNOACTIVE
+	case win32.SW_SHOWNORMAL, win32.SW_RESTORE, win32.SW_SHOW:
+		restored.ShowCmd = win32.SW_SHOWNOACTIVATE
+	}
+	return SetPlacement(handle, &restored)
+}
@@ -0,0 +1,122 @@
+//go:build windows
+
+package window
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	sysAllocString = win32.OleAut32.NewProc("SysAllocString")
+	sysFreeString  = win32.OleAut32.NewProc("SysFreeString")
+)
+
+// UI Automation constants
+const (
+	VT_BSTR = 8
+
+	TreeScope_Descendants = 0x4
+
+	UIA_NamePropertyId         = 30005
+	UIA_AutomationIdPropertyId = 30011
+
+	// IUIAutomation vtable indices
+	uiaElementFromHandle       = 6
+	uiaCreatePropertyCondition = 23
+	uiaCreateOrCondition       = 28
+
+	// IUIAutomationElement vtable indices
+	uiaElementFindFirst             = 5
+	uiaElementGetCurrentIsOffscreen = 38
+)
+
+var (
+	clsidCUIAutomation = windows.GUID{Data1: 0xff48dba4, Data2: 0x60ef, Data3: 0x4201, Data4: [8]byte{0xaa, 0x87, 0x54, 0x10, 0x3e, 0xef, 0x59, 0x4e}}
+	iidIUIAutomation   = windows.GUID{Data1: 0x30cbe57d, Data2: 0xd9d0, Data3: 0x452a, Data4: [8]byte{0xab, 0x13, 0x7a, 0xc5, 0xac, 0x48, 0x25, 0xee}}
+)
+
+// VARIANT structure (x64 layout)
+type VARIANT struct {
+	VT       uint16
+	reserved [3]uint16
+	Val      uintptr
+	pad      uintptr
+}
+
+// IsElementVisible reports whether the window contains an on-screen UI Automation
+// element whose name or automation ID equals name
+func (wm *WindowsManager) IsElementVisible(handle uintptr, name string) (bool, error) {
+	// COM apartments are per thread, so keep the goroutine on one for the whole query
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	uninit, err := win32.CoInitialize()
+	if err != nil {
+		return false, err
+	}
+	if !uninit {
+		return false, fmt.Errorf("COM already initialized with a different apartment")
+	}
+	defer win32.CoUninitialize()
+
+	automation, err := win32.CoCreateInstance(&clsidCUIAutomation, win32.CLSCTX_INPROC_SERVER, &iidIUIAutomation)
+	if err != nil {
+		return false, fmt.Errorf("failed to create UI Automation client: %w", err)
+	}
+	defer win32.ComRelease(automation)
+
+	var root uintptr
+	if hr := win32.ComCall(automation, uiaElementFromHandle, handle, uintptr(unsafe.Pointer(&root))); win32.Failed(hr) || root == 0 {
+		return false, fmt.Errorf("failed to get UI Automation element for window: 0x%08x", uint32(hr))
+	}
+	defer win32.ComRelease(root)
+
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return false, err
+	}
+	bstr, _, _ := sysAllocString.Call(uintptr(unsafe.Pointer(namePtr)))
+	if bstr == 0 {
+		return false, fmt.Errorf("SysAllocString failed")
+	}
+	defer sysFreeString.Call(bstr)
+	value := VARIANT{VT: VT_BSTR, Val: bstr}
+
+	// Match either the visible name or the automation ID; VARIANT arguments are
+	// larger than a register so the x64 ABI passes them by reference
+	var byName, byID, condition uintptr
+	if hr := win32.ComCall(automation, uiaCreatePropertyCondition, UIA_NamePropertyId, uintptr(unsafe.Pointer(&value)), uintptr(unsafe.Pointer(&byName))); win32.Failed(hr) {
+		return false, fmt.Errorf("failed to create name condition: 0x%08x", uint32(hr))
+	}
+	defer win32.ComRelease(byName)
+	if hr := win32.ComCall(automation, uiaCreatePropertyCondition, UIA_AutomationIdPropertyId, uintptr(unsafe.Pointer(&value)), uintptr(unsafe.Pointer(&byID))); win32.Failed(hr) {
+		return false, fmt.Errorf("failed to create automation ID condition: 0x%08x", uint32(hr))
+	}
+	defer win32.ComRelease(byID)
+	if hr := win32.ComCall(automation, uiaCreateOrCondition, byName, byID, uintptr(unsafe.Pointer(&condition))); win32.Failed(hr) {
+		return false, fmt.Errorf("failed to create condition: 0x%08x", uint32(hr))
+	}
+	defer win32.ComRelease(condition)
+
+	var element uintptr
+	if hr := win32.ComCall(root, uiaElementFindFirst, TreeScope_Descendants, condition, uintptr(unsafe.Pointer(&element))); win32.Failed(hr) {
+		return false, fmt.Errorf("UI Automation search failed: 0x%08x", uint32(hr))
+	}
+	if element == 0 {
+		return false, nil
+	}
+	defer win32.ComRelease(element)
+
+	var offscreen int32
+	if hr := win32.ComCall(element, uiaElementGetCurrentIsOffscreen, uintptr(unsafe.Pointer(&offscreen))); win32.Failed(hr) {
+		return false, fmt.Errorf("failed to read element visibility: 0x%08x", uint32(hr))
+	}
+
+	return offscreen == 0, nil
+}
@@ -0,0 +1,103 @@
+//go:build windows
+
+package window
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/internal/win32"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"golang.org/x/sys/windows"
+)
+
+// Virtual desktop constants
+const (
+	// IVirtualDesktopManager vtable indices
+	vdmIsWindowOnCurrentVirtualDesktop = 3
+	vdmGetWindowDesktopId              = 4
+)
+
+var (
+	clsidVirtualDesktopManager = windows.GUID{Data1: 0xaa509086, Data2: 0x5ca9, Data3: 0x4c25, Data4: [8]byte{0x8f, 0x95, 0x58, 0x9d, 0x3c, 0x07, 0xb4, 0x8a}}
+	iidIVirtualDesktopManager  = windows.GUID{Data1: 0xa5cd92ff, Data2: 0x29be, Data3: 0x454c, Data4: [8]byte{0x8d, 0x04, 0xd8, 0x28, 0x79, 0xfb, 0x3f, 0x1b}}
+)
+
+// virtualDesktopManager wraps IVirtualDesktopManager. COM is initialized on the
+// calling thread, which stays locked to the goroutine until Close.
+type virtualDesktopManager struct {
+	obj    uintptr
+	uninit bool
+}
+
+// newVirtualDesktopManager connects to the shell's virtual desktop manager
+func newVirtualDesktopManager() (*virtualDesktopManager, error) {
+	runtime.LockOSThread()
+
+	// A thread already in another apartment can still use the (free-threaded) manager
+	uninit, err := win32.CoInitialize()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	m := &virtualDesktopManager{uninit: uninit}
+
+	m.obj, err = win32.CoCreateInstance(&clsidVirtualDesktopManager, win32.CLSCTX_INPROC_SERVER|win32.CLSCTX_LOCAL_SERVER, &iidIVirtualDesktopManager)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("virtual desktops unavailable: %w", err)
+	}
+	return m, nil
+}
+
+// Desktop returns the ID of the virtual desktop the window is on and whether that is
+// the current desktop
+func (m *virtualDesktopManager) Desktop(handle uintptr) (string, bool, error) {
+	var id windows.GUID
+	if hr := win32.ComCall(m.obj, vdmGetWindowDesktopId, handle, uintptr(unsafe.Pointer(&id))); win32.Failed(hr) {
+		return "", false, fmt.Errorf("GetWindowDesktopId failed: 0x%08x", uint32(hr))
+	}
+
+	var onCurrent int32
+	if hr := win32.ComCall(m.obj, vdmIsWindowOnCurrentVirtualDesktop, handle, uintptr(unsafe.Pointer(&onCurrent))); win32.Failed(hr) {
+		return "", false, fmt.Errorf("IsWindowOnCurrentVirtualDesktop failed: 0x%08x", uint32(hr))
+	}
+
+	// Windows that aren't tracked by Task View have no desktop ID
+	if id == (windows.GUID{}) {
+		return "", onCurrent != 0, nil
+	}
+	return id.String(), onCurrent != 0, nil
+}
+
+// Close releases the manager and the thread's COM initialization
+func (m *virtualDesktopManager) Close() {
+	win32.ComRelease(m.obj)
+	m.obj = 0
+	if m.uninit {
+		win32.CoUninitialize()
+	}
+	runtime.UnlockOSThread()
+}
+
+// fill records the window's virtual desktop in info, leaving it unset if unknown
+func (m *virtualDesktopManager) fill(info *types.WindowInfo) {
+	id, current, err := m.Desktop(info.Handle)
+	if err != nil {
+		return
+	}
+	info.VirtualDesktopID = id
+	info.OnCurrentDesktop = &current
+}
+
+// FillVirtualDesktop records which virtual desktop the window is on. Fields stay unset
+// when virtual desktops aren't available (Windows 8 and earlier, no shell).
+func FillVirtualDesktop(info *types.WindowInfo) {
+	m, err := newVirtualDesktopManager()
+	if err != nil {
+		return
+	}
+	defer m.Close()
+	m.fill(info)
+}
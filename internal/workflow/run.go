@@ -0,0 +1,492 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/screenshot-mcp-server/internal/input"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// maxWait is the longest a wait call may pause
+const maxWait = time.Minute
+
+// maxParamsDepth is how deeply the params table of a stage call may nest
+const maxParamsDepth = 16
+
+// ErrInvalidParams is returned when a run's parameters are missing or unknown
+var ErrInvalidParams = errors.New("invalid workflow parameters")
+
+// Host performs the window operations of a workflow
+type Host interface {
+	// Focus brings the window selected by a screenshot method and target to the front
+	Focus(ctx context.Context, method, target string) error
+	// Capture takes a screenshot. The host fills in its default format and quality.
+	Capture(ctx context.Context, req *types.ScreenshotRequest) (*types.ScreenshotResponse, error)
+	// Compare returns the share of pixels, 0-1, of a screenshot that differ from an image file
+	Compare(ctx context.Context, screenshot *types.ScreenshotResponse, reference string) (float64, error)
+	// Click clicks a mouse button at screen coordinates
+	Click(ctx context.Context, x, y int, button string) error
+	// Press presses a key combination such as "ctrl+s"
+	Press(ctx context.Context, combo string) error
+	// Type types text
+	Type(ctx context.Context, text string) error
+}
+
+// state is the state of a running workflow
+type state struct {
+	ctx      context.Context
+	host     Host   // Nil while the script's top level runs
+	chunk    string // Script name in errors
+	dir      string // Directory references are relative to
+	method   string // Selected window
+	target   string
+	pipeline []types.PipelineStage
+	response *types.WorkflowRunResponse
+}
+
+// function is a workflow function. It reads its arguments from L, raising Lua errors for
+// invalid ones, pushes its results and returns how many there are. Host errors are
+// returned to be raised with their line.
+type function func(L *lua.LState, s *state, line int) (int, error)
+
+// argCounts are the minimum and maximum argument counts of each function
+var argCounts = map[string][2]int{
+	"window":    {1, 2},
+	"focus":     {0, 0},
+	"wait":      {1, 1},
+	"stage":     {1, 2},
+	"redact":    {4, 4},
+	"capture":   {0, 1},
+	"click":     {2, 3},
+	"key":       {1, 1},
+	"type_text": {1, 1},
+}
+
+func checkArgCount(name string, count int) error {
+	counts, ok := argCounts[name]
+	if !ok {
+		return fmt.Errorf("unknown function %q", name)
+	}
+	if count < counts[0] || count > counts[1] {
+		return fmt.Errorf("wrong number of arguments for %s", name)
+	}
+	return nil
+}
+
+// libraries are the Lua libraries scripts get
+var libraries = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+}
+
+// unsafeGlobals are the base library functions that load code, reach files or print to
+// the server's output
+var unsafeGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "print", "_printregs", "collectgarbage"}
+
+// load creates a sandboxed Lua state with the workflow functions bound to s and runs the
+// script's top level in it
+func (s *state) load(ctx context.Context, script *Script) (*lua.LState, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range libraries {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+	errorMeta := L.NewTable()
+	L.SetField(errorMeta, "__tostring", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(L.CheckUserData(1).Value.(error).Error()))
+		return 1
+	}))
+	for name, fn := range functions {
+		L.SetGlobal(name, s.bind(L, name, fn, errorMeta))
+	}
+
+	s.ctx, s.chunk = ctx, script.chunkName()
+	L.SetContext(ctx)
+	L.Push(L.NewFunctionFromProto(script.proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		L.Close()
+		return nil, s.scriptError(err)
+	}
+	return L, nil
+}
+
+// bind wraps a workflow function for Lua. A host error is raised as userdata keeping the
+// Go error, which tostring shows, so Run can return it wrapped.
+func (s *state) bind(L *lua.LState, name string, fn function, errorMeta *lua.LTable) *lua.LFunction {
+	return L.NewFunction(func(L *lua.LState) int {
+		if err := checkArgCount(name, L.GetTop()); err != nil {
+			L.RaiseError("%s", err.Error())
+		}
+		if s.host == nil {
+			L.RaiseError("%s can only be called from run", name)
+		}
+		line := callerLine(L)
+		results, err := fn(L, s, line)
+		if err != nil {
+			failure := L.NewUserData()
+			failure.Value = fmt.Errorf("%s:%d: %s: %w", s.chunk, line, name, err)
+			L.SetMetatable(failure, errorMeta)
+			L.Error(failure, 1)
+		}
+		s.response.Steps++
+		return results
+	})
+}
+
+// scriptError turns an error of a protected call into the Go error to return: a host
+// error as raised, the context's error if it ended the run, or the Lua error message
+func (s *state) scriptError(err error) error {
+	var apiErr *lua.ApiError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if failure, ok := apiErr.Object.(*lua.LUserData); ok {
+		if err, ok := failure.Value.(error); ok {
+			return err
+		}
+	}
+	if ctxErr := s.ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%s: %w", s.chunk, ctxErr)
+	}
+	return errors.New(apiErr.Object.String())
+}
+
+// callerLine returns the script line calling the running Go function, skipping Go
+// functions between them such as pcall
+func callerLine(L *lua.LState) int {
+	for level := 1; ; level++ {
+		caller, ok := L.GetStack(level)
+		if !ok {
+			return 0
+		}
+		if _, err := L.GetInfo("l", caller, lua.LNil); err == nil && caller.CurrentLine > 0 {
+			return caller.CurrentLine
+		}
+	}
+}
+
+// functions are the workflow functions scripts call
+var functions = map[string]function{
+	"window": func(L *lua.LState, s *state, line int) (int, error) {
+		s.method, s.target = L.CheckString(1), optString(L, 2)
+		return 0, nil
+	},
+
+	"focus": func(L *lua.LState, s *state, line int) (int, error) {
+		if s.method == "" {
+			L.RaiseError("no window selected; call window first")
+		}
+		return 0, s.host.Focus(s.ctx, s.method, s.target)
+	},
+
+	"wait": func(L *lua.LState, s *state, line int) (int, error) {
+		duration, err := time.ParseDuration(L.CheckString(1))
+		if err != nil {
+			L.ArgError(1, fmt.Sprintf("invalid duration %q", L.CheckString(1)))
+		}
+		if duration < 0 || duration > maxWait {
+			L.ArgError(1, fmt.Sprintf("wait must be between 0 and %s", maxWait))
+		}
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return 0, nil
+		case <-s.ctx.Done():
+			return 0, s.ctx.Err()
+		}
+	},
+
+	"stage": func(L *lua.LState, s *state, line int) (int, error) {
+		stage := types.PipelineStage{Stage: L.CheckString(1)}
+		switch params := L.Get(2).(type) {
+		case *lua.LNilType:
+		case lua.LString:
+			if !json.Valid([]byte(params)) {
+				L.ArgError(2, fmt.Sprintf("invalid params for stage %s", stage.Stage))
+			}
+			stage.Params = json.RawMessage(params)
+		case *lua.LTable:
+			value, err := toJSON(params, 0)
+			if err != nil {
+				L.ArgError(2, err.Error())
+			}
+			stage.Params, _ = json.Marshal(value)
+		default:
+			L.ArgError(2, "table or JSON string expected, got "+params.Type().String())
+		}
+		s.pipeline = append(s.pipeline, stage)
+		return 0, nil
+	},
+
+	"redact": func(L *lua.LState, s *state, line int) (int, error) {
+		var region [4]int
+		for i := range region {
+			region[i] = checkInt(L, i+1)
+		}
+		params, _ := json.Marshal(map[string]interface{}{
+			"regions": []map[string]int{{"x": region[0], "y": region[1], "width": region[2], "height": region[3]}},
+		})
+		s.pipeline = append(s.pipeline, types.PipelineStage{Stage: "redact", Params: params})
+		return 0, nil
+	},
+
+	"click": func(L *lua.LState, s *state, line int) (int, error) {
+		x, y := checkInt(L, 1), checkInt(L, 2)
+		button := input.ButtonLeft
+		if L.GetTop() == 3 {
+			button = L.CheckString(3)
+		}
+		if !input.ValidButton(button) {
+			L.ArgError(3, fmt.Sprintf("unknown mouse button %q", button))
+		}
+		return 0, s.host.Click(s.ctx, x, y, button)
+	},
+
+	"key": func(L *lua.LState, s *state, line int) (int, error) {
+		combo := L.CheckString(1)
+		if _, err := input.ParseCombo(combo); err != nil {
+			L.ArgError(1, err.Error())
+		}
+		return 0, s.host.Press(s.ctx, combo)
+	},
+
+	"type_text": func(L *lua.LState, s *state, line int) (int, error) {
+		return 0, s.host.Type(s.ctx, L.CheckString(1))
+	},
+
+	"capture": func(L *lua.LState, s *state, line int) (int, error) {
+		var label, expect string
+		var req types.ScreenshotRequest
+		options := L.OptTable(1, L.NewTable())
+		options.ForEach(func(key, value lua.LValue) {
+			var ok bool
+			switch key.String() {
+			case "label":
+				label, ok = toString(value)
+			case "format":
+				var format string
+				format, ok = toString(value)
+				req.Format = types.ImageFormat(format)
+			case "quality":
+				req.Quality, ok = toInt(value)
+			case "cursor":
+				req.IncludeCursor, ok = toBool(value)
+			case "expect":
+				expect, ok = toString(value)
+				if ok && !filepath.IsLocal(expect) {
+					L.ArgError(1, fmt.Sprintf("expect must be a path inside the workflows directory, got %q", expect))
+				}
+			default:
+				L.ArgError(1, fmt.Sprintf("unknown capture option %q", key.String()))
+			}
+			if !ok {
+				L.ArgError(1, fmt.Sprintf("invalid capture %s %s", key.String(), value.String()))
+			}
+		})
+
+		if s.method == "" {
+			L.RaiseError("no window selected; call window first")
+		}
+		req.Method, req.Target = s.method, s.target
+		if len(s.pipeline) > 0 {
+			req.Pipeline = append([]types.PipelineStage(nil), s.pipeline...)
+		}
+		screenshot, err := s.host.Capture(s.ctx, &req)
+		if err != nil {
+			return 0, err
+		}
+		result := types.WorkflowCapture{Label: label, Line: line, Screenshot: screenshot}
+		info := L.NewTable()
+		info.RawSetString("width", lua.LNumber(screenshot.Width))
+		info.RawSetString("height", lua.LNumber(screenshot.Height))
+		if expect != "" {
+			difference, err := s.host.Compare(s.ctx, screenshot, filepath.Join(s.dir, expect))
+			if err != nil {
+				return 0, fmt.Errorf("comparing with %s: %w", expect, err)
+			}
+			result.Reference, result.Difference = expect, &difference
+			info.RawSetString("difference", lua.LNumber(difference))
+		}
+		s.response.Captures = append(s.response.Captures, result)
+		L.Push(info)
+		return 1, nil
+	},
+}
+
+// optString returns an optional string or number argument, empty when it's absent
+func optString(L *lua.LState, n int) string {
+	if L.Get(n) == lua.LNil {
+		return ""
+	}
+	return L.CheckString(n)
+}
+
+// checkInt returns an integer argument, raising an argument error for anything else
+func checkInt(L *lua.LState, n int) int {
+	value, ok := toInt(L.Get(n))
+	if !ok {
+		L.ArgError(n, "integer expected, got "+L.Get(n).String())
+	}
+	return value
+}
+
+// toInt converts an integral number, or a string of one as Lua's arithmetic would, such
+// as a parameter's value
+func toInt(value lua.LValue) (int, bool) {
+	if str, ok := value.(lua.LString); ok {
+		number, err := strconv.ParseFloat(strings.TrimSpace(string(str)), 64)
+		if err != nil {
+			return 0, false
+		}
+		value = lua.LNumber(number)
+	}
+	number, ok := value.(lua.LNumber)
+	if !ok || float64(number) != math.Trunc(float64(number)) || math.Abs(float64(number)) > math.MaxInt32 {
+		return 0, false
+	}
+	return int(number), true
+}
+
+// toBool converts a boolean, or a string of one such as a parameter's value
+func toBool(value lua.LValue) (bool, bool) {
+	switch value := value.(type) {
+	case lua.LBool:
+		return bool(value), true
+	case lua.LString:
+		b, err := strconv.ParseBool(string(value))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// toString converts a string or number
+func toString(value lua.LValue) (string, bool) {
+	switch value.(type) {
+	case lua.LString, lua.LNumber:
+		return value.String(), true
+	}
+	return "", false
+}
+
+// toJSON converts a Lua value to one encoding/json marshals: a table whose keys are 1 to
+// its length becomes an array, and other tables objects with string keys
+func toJSON(value lua.LValue, depth int) (interface{}, error) {
+	switch value := value.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(value), nil
+	case lua.LNumber:
+		if math.IsInf(float64(value), 0) || math.IsNaN(float64(value)) {
+			return nil, fmt.Errorf("%s isn't a JSON number", value)
+		}
+		return float64(value), nil
+	case lua.LString:
+		return string(value), nil
+	case *lua.LTable:
+		if depth >= maxParamsDepth {
+			return nil, errors.New("params nest too deeply")
+		}
+		var err error
+		length, keys := value.Len(), 0
+		value.ForEach(func(lua.LValue, lua.LValue) { keys++ })
+		if length > 0 && keys == length {
+			array := make([]interface{}, length)
+			for i := range array {
+				if array[i], err = toJSON(value.RawGetInt(i+1), depth+1); err != nil {
+					return nil, err
+				}
+			}
+			return array, nil
+		}
+		object := make(map[string]interface{}, keys)
+		value.ForEach(func(key, item lua.LValue) {
+			if err != nil {
+				return
+			}
+			name, ok := key.(lua.LString)
+			if !ok {
+				err = fmt.Errorf("params keys must be strings, got %s", key.Type())
+				return
+			}
+			object[string(name)], err = toJSON(item, depth+1)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("%s can't be converted to JSON", value.Type())
+}
+
+// Run runs a script with values for its parameters. Errors name the script line that
+// failed and wrap the host's errors.
+func Run(ctx context.Context, script *Script, host Host, params map[string]string) (*types.WorkflowRunResponse, error) {
+	values, err := bindParams(script, params)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	s := &state{dir: script.Dir, response: &types.WorkflowRunResponse{
+		Workflow: script.Name,
+		Captures: []types.WorkflowCapture{},
+	}}
+	L, err := s.load(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	defer L.Close()
+
+	table := L.NewTable()
+	for name, value := range values {
+		table.RawSetString(name, lua.LString(value))
+	}
+	s.host = host
+	if err := L.CallByParam(lua.P{Fn: L.GetGlobal("run"), Protect: true}, table); err != nil {
+		return nil, s.scriptError(err)
+	}
+	s.response.Duration = time.Since(start)
+	return s.response, nil
+}
+
+// bindParams returns the value of every declared parameter
+func bindParams(script *Script, params map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(script.Params))
+	for _, param := range script.Params {
+		value, ok := params[param.Name]
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidParams, param.Name)
+			}
+			value = param.Default
+		}
+		values[param.Name] = value
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown parameter %s", ErrInvalidParams, name)
+		}
+	}
+	return values, nil
+}
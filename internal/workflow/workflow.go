@@ -0,0 +1,305 @@
+// Package workflow loads and runs workflow scripts: small Lua programs that prepare a
+// window and capture it, such as "bring the window to the front, wait 500ms, then
+// capture it with a region redacted". Scripts live in the workflows directory as
+// <name>.lua files and are read again on every run, so edits apply immediately.
+//
+// A script declares its parameters in a params table and does its work in a run
+// function, which is called with a table of the parameters' values; the leading comment
+// describes the workflow:
+//
+//	-- Saves the document and captures the result
+//	params = {"title", delay = "500ms"}
+//
+//	function run(p)
+//		window("title_contains", p.title)
+//		focus()
+//		wait(p.delay)
+//		key("ctrl+s")
+//		capture{label = "saved"}
+//	end
+//
+// Strings in params are required parameters; keys with values are optional ones and
+// their defaults. run calls these functions:
+//
+//	window(method [, target])          select the window later calls act on
+//	focus()                            bring the selected window to the front
+//	wait(duration)                     pause, such as "500ms", at most one minute
+//	stage(name [, params])             add a pipeline stage, with a table or JSON string of
+//	                                   params, to the following captures
+//	redact(x, y, width, height)        add a redact stage filling a region
+//	capture([options])                 capture the selected window; options are label,
+//	                                   format, quality, cursor and expect, an image file
+//	                                   the capture is compared with. Returns a table of
+//	                                   width, height and, with expect, difference.
+//	click(x, y [, button])             click at screen coordinates
+//	key(combo)                         press keys such as "enter" or "ctrl+s"
+//	type_text(text)                    type text
+//
+// Scripts run in a sandbox with Lua's base, string, table and math libraries, without
+// the functions that load code or reach the file system.
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Extension is the file extension of workflow scripts
+const Extension = ".lua"
+
+// loadTimeout bounds running a script's top level, which only declares params and run
+const loadTimeout = time.Second
+
+// ErrNotFound is returned for a workflow that isn't in the workflows directory
+var ErrNotFound = errors.New("workflow not found")
+
+// Workflow and parameter names
+var (
+	validName  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	validParam = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Script is a parsed workflow
+type Script struct {
+	Name        string
+	Dir         string // Directory the script was loaded from
+	Description string
+	Params      []types.WorkflowParam
+	proto       *lua.FunctionProto
+}
+
+// Info describes the script
+func (s *Script) Info() types.WorkflowInfo {
+	params := s.Params
+	if params == nil {
+		params = []types.WorkflowParam{}
+	}
+	return types.WorkflowInfo{Name: s.Name, Description: s.Description, Params: params}
+}
+
+// chunkName names a script in Lua's error messages
+func (s *Script) chunkName() string {
+	return s.Name + Extension
+}
+
+// Parse compiles a script and runs its top level to read its params and check it
+// defines run. Calls in run are checked when it runs.
+func Parse(name, source string) (*Script, error) {
+	script := &Script{Name: name, Description: description(source)}
+	chunk, err := parse.Parse(strings.NewReader(source), script.chunkName())
+	if err != nil {
+		return nil, syntaxError(script.chunkName(), err)
+	}
+	script.proto, err = lua.Compile(chunk, script.chunkName())
+	if err != nil {
+		return nil, syntaxError(script.chunkName(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	s := &state{}
+	L, err := s.load(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+	defer L.Close()
+
+	if L.GetGlobal("run").Type() != lua.LTFunction {
+		return nil, fmt.Errorf("%s doesn't define a run function", script.chunkName())
+	}
+	script.Params, err = parseParams(L.GetGlobal("params"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", script.chunkName(), err)
+	}
+	return script, nil
+}
+
+// syntaxError formats a parse or compile error as Lua's runtime errors are, with the
+// script and line
+func syntaxError(chunk string, err error) error {
+	var parseErr *parse.Error
+	var compileErr *lua.CompileError
+	switch {
+	case errors.As(err, &parseErr) && parseErr.Pos.Line == parse.EOF:
+		return fmt.Errorf("%s: %s at end of file", chunk, parseErr.Message)
+	case errors.As(err, &parseErr):
+		// An error at the start of a line was found on the newline ending the one before,
+		// such as a string left open
+		line := parseErr.Pos.Line
+		if parseErr.Pos.Column == 0 && line > 1 {
+			line--
+		}
+		return fmt.Errorf("%s:%d: %s near '%s'", chunk, line, parseErr.Message, parseErr.Token)
+	case errors.As(err, &compileErr):
+		return fmt.Errorf("%s:%d: %s", chunk, compileErr.Line, compileErr.Message)
+	}
+	return err
+}
+
+// description joins the lines of a script's leading comment
+func description(source string) string {
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		text, ok := strings.CutPrefix(line, "--")
+		if !ok || strings.HasPrefix(text, "[") {
+			break
+		}
+		lines = append(lines, strings.TrimSpace(text))
+	}
+	return strings.TrimSpace(strings.Join(lines, " "))
+}
+
+// parseParams reads the params table: required parameters in its array part, in order,
+// then optional ones with their defaults, sorted by name
+func parseParams(value lua.LValue) ([]types.WorkflowParam, error) {
+	if value == lua.LNil {
+		return nil, nil
+	}
+	table, ok := value.(*lua.LTable)
+	if !ok {
+		return nil, fmt.Errorf("params must be a table, got %s", value.Type())
+	}
+
+	var required, optional []types.WorkflowParam
+	declared := make(map[string]bool)
+	var err error
+	declare := func(param types.WorkflowParam) {
+		switch {
+		case err != nil:
+		case !validParam.MatchString(param.Name):
+			err = fmt.Errorf("invalid parameter name %q", param.Name)
+		case declared[param.Name]:
+			err = fmt.Errorf("parameter %q is declared twice", param.Name)
+		case param.Required:
+			required = append(required, param)
+		default:
+			optional = append(optional, param)
+		}
+		declared[param.Name] = true
+	}
+
+	length := table.Len()
+	table.ForEach(func(key, value lua.LValue) {
+		if index, ok := key.(lua.LNumber); ok && float64(index) == float64(int(index)) && int(index) >= 1 && int(index) <= length {
+			return
+		}
+		name, ok := key.(lua.LString)
+		if !ok {
+			err = fmt.Errorf("invalid parameter name %s", key.String())
+			return
+		}
+		switch value.(type) {
+		case lua.LString, lua.LNumber, lua.LBool:
+			declare(types.WorkflowParam{Name: string(name), Default: value.String()})
+		default:
+			err = fmt.Errorf("default of parameter %s must be a string, number or boolean, got %s", name, value.Type())
+		}
+	})
+	for i := 1; i <= length && err == nil; i++ {
+		name, ok := table.RawGetInt(i).(lua.LString)
+		if !ok {
+			return nil, fmt.Errorf("required parameter %d must be a name, got %s", i, table.RawGetInt(i).Type())
+		}
+		declare(types.WorkflowParam{Name: string(name), Required: true})
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(optional, func(i, j int) bool { return optional[i].Name < optional[j].Name })
+	return append(required, optional...), nil
+}
+
+// ValidName reports whether name can name a workflow
+func ValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// Load reads and parses the workflow name of dir
+func Load(dir, name string) (*Script, error) {
+	if !validName.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	source, err := os.ReadFile(filepath.Join(dir, name+Extension))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	script, err := Parse(name, string(source))
+	if err != nil {
+		return nil, err
+	}
+	script.Dir = dir
+	return script, nil
+}
+
+// Quote returns a Lua string literal of s
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				// Three digits, so a digit after the escape isn't read into it
+				fmt.Fprintf(&b, `\%03d`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// List parses every workflow of dir, sorted by name. A missing directory has none;
+// scripts that don't parse are returned as errors alongside the others.
+func List(dir string) ([]*Script, []error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var scripts []*Script
+	var errs []error
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), Extension)
+		if !ok || entry.IsDir() || !validName.MatchString(name) {
+			continue
+		}
+		script, err := Load(dir, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("workflow %s: %w", name, err))
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name < scripts[j].Name })
+	return scripts, errs
+}
@@ -0,0 +1,387 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHost records the calls of a workflow
+type fakeHost struct {
+	calls      []string
+	requests   []*types.ScreenshotRequest
+	focusErr   error
+	difference float64
+}
+
+func (h *fakeHost) Focus(ctx context.Context, method, target string) error {
+	h.calls = append(h.calls, fmt.Sprintf("focus %s %s", method, target))
+	return h.focusErr
+}
+
+func (h *fakeHost) Capture(ctx context.Context, req *types.ScreenshotRequest) (*types.ScreenshotResponse, error) {
+	h.calls = append(h.calls, fmt.Sprintf("capture %s %s", req.Method, req.Target))
+	h.requests = append(h.requests, req)
+	return &types.ScreenshotResponse{Success: true, Width: 640, Height: 480}, nil
+}
+
+func (h *fakeHost) Compare(ctx context.Context, screenshot *types.ScreenshotResponse, reference string) (float64, error) {
+	h.calls = append(h.calls, "compare "+filepath.ToSlash(reference))
+	return h.difference, nil
+}
+
+func (h *fakeHost) Click(ctx context.Context, x, y int, button string) error {
+	h.calls = append(h.calls, fmt.Sprintf("click %d %d %s", x, y, button))
+	return nil
+}
+
+func (h *fakeHost) Press(ctx context.Context, combo string) error {
+	h.calls = append(h.calls, "key "+combo)
+	return nil
+}
+
+func (h *fakeHost) Type(ctx context.Context, text string) error {
+	h.calls = append(h.calls, "type "+text)
+	return nil
+}
+
+// runSource parses and runs a script
+func runSource(t *testing.T, source string, params map[string]string) (*fakeHost, *types.WorkflowRunResponse, error) {
+	t.Helper()
+	script, err := Parse("test", source)
+	require.NoError(t, err)
+	script.Dir = "flows"
+	host := &fakeHost{}
+	response, err := Run(context.Background(), script, host, params)
+	return host, response, err
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{name: "valid", source: "-- Saves\nparams = {'name'}\nfunction run(p)\n\twindow('title', p.name)\nend"},
+		{name: "no params", source: "function run() end"},
+		{name: "unterminated string", source: "function run(p)\n\ttype_text(\"hello)\nend", err: "test.lua:2: unterminated string near 'hello)'"},
+		{name: "unterminated long string", source: "function run(p)\n\ttype_text([[hello)\nend", err: "test.lua: unterminated multiline string at end of file"},
+		{name: "missing end", source: "function run(p)\n\tif true then\nend", err: "test.lua: syntax error at end of file"},
+		{name: "no run function", source: "params = {}", err: "test.lua doesn't define a run function"},
+		{name: "run isn't a function", source: "run = 1", err: "test.lua doesn't define a run function"},
+		{name: "top level calls", source: "window('title', 'x')\nfunction run() end", err: "test.lua:1: window can only be called from run"},
+		{name: "top level error", source: "error('nope')", err: "test.lua:1: nope"},
+		{name: "params not a table", source: "params = 'name'\nfunction run() end", err: "test.lua: params must be a table, got string"},
+		{name: "invalid parameter name", source: "params = {'1a'}\nfunction run() end", err: `test.lua: invalid parameter name "1a"`},
+		{name: "invalid default name", source: "params = {['a b'] = 'x'}\nfunction run() end", err: `test.lua: invalid parameter name "a b"`},
+		{name: "declared twice", source: "params = {'a', a = 'x'}\nfunction run() end", err: `test.lua: parameter "a" is declared twice`},
+		{name: "required not a name", source: "params = {{}}\nfunction run() end", err: "test.lua: required parameter 1 must be a name, got table"},
+		{name: "table default", source: "params = {a = {}}\nfunction run() end", err: "test.lua: default of parameter a must be a string, number or boolean, got table"},
+		{name: "sandboxed", source: "dofile('x')\nfunction run() end", err: "test.lua:1: attempt to call a non-function object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("test", tt.source)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseStopsRunawayTopLevel(t *testing.T) {
+	start := time.Now()
+	_, err := Parse("test", "while true do end\nfunction run() end")
+	assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
+	assert.Less(t, time.Since(start), loadTimeout+time.Second)
+}
+
+func TestParseDescriptionAndParams(t *testing.T) {
+	script, err := Parse("save", "-- Saves the\n--   document\n\n-- not described\nparams = {'name', 'path', delay = '1s', retries = 3, quiet = false}\nfunction run(p) end")
+	require.NoError(t, err)
+	assert.Equal(t, "Saves the document", script.Description)
+	assert.Equal(t, []types.WorkflowParam{
+		{Name: "name", Required: true},
+		{Name: "path", Required: true},
+		{Name: "delay", Default: "1s"},
+		{Name: "quiet", Default: "false"},
+		{Name: "retries", Default: "3"},
+	}, script.Params)
+
+	script, err = Parse("bare", "--[[ block\ncomment ]]\nfunction run(p) end")
+	require.NoError(t, err)
+	assert.Empty(t, script.Description)
+	assert.Equal(t, []types.WorkflowParam{}, script.Info().Params)
+}
+
+func TestArgCounts(t *testing.T) {
+	valid := map[string][]string{
+		"window":    {"'title'", "'Notepad'"},
+		"focus":     {},
+		"wait":      {"'1ms'"},
+		"stage":     {"'blur'", "{radius = 2}"},
+		"redact":    {"0", "0", "10", "10"},
+		"capture":   {"{label = 'a'}"},
+		"click":     {"1", "2", "'left'"},
+		"key":       {"'ctrl+s'"},
+		"type_text": {"'text'"},
+	}
+	require.Len(t, valid, len(argCounts))
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+		assert.Contains(t, argCounts, name)
+	}
+	sort.Strings(names)
+	require.Len(t, names, len(argCounts), "every function has argument counts")
+
+	call := func(name string, args []string) error {
+		_, _, err := runSource(t, fmt.Sprintf("function run(p)\n\twindow('title', 'x')\n\t%s(%s)\nend", name, strings.Join(args, ", ")), nil)
+		return err
+	}
+	for _, name := range names {
+		counts, args := argCounts[name], valid[name]
+		t.Run(name, func(t *testing.T) {
+			for n := counts[0]; n <= counts[1]; n++ {
+				assert.NoError(t, call(name, args[:n]), "%d arguments", n)
+			}
+			wrong := "test.lua:3: wrong number of arguments for " + name
+			if counts[0] > 0 {
+				assert.EqualError(t, call(name, args[:counts[0]-1]), wrong)
+			}
+			assert.EqualError(t, call(name, append(append([]string(nil), args[:counts[1]]...), "'extra'")), wrong)
+		})
+	}
+
+	assert.EqualError(t, checkArgCount("print", 1), `unknown function "print"`)
+}
+
+func TestRun(t *testing.T) {
+	host, response, err := runSource(t, `-- Saves
+params = {"title", delay = "1ms", x = "10"}
+
+function run(p)
+	window("title_contains", p.title)
+	focus()
+	wait(p.delay)
+	click(p.x, 20)
+	click(30, 40, "right")
+	key("ctrl+s")
+	type_text(p.title .. " $$ ${x}")
+	stage("blur", {radius = 4, kernel = {1, 2, 1}})
+	redact(1, 2, 3, 4)
+	local shot = capture{label = "saved", format = "png", quality = "80", cursor = true, expect = "saved.png"}
+	if shot.width == 640 and shot.difference == 0 then
+		capture()
+	end
+end
+`, map[string]string{"title": "Note pad"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"focus title_contains Note pad",
+		"click 10 20 left",
+		"click 30 40 right",
+		"key ctrl+s",
+		"type Note pad $$ ${x}",
+		"capture title_contains Note pad",
+		"compare flows/saved.png",
+		"capture title_contains Note pad",
+	}, host.calls)
+
+	assert.Equal(t, "test", response.Workflow)
+	assert.Equal(t, 11, response.Steps)
+	require.Len(t, response.Captures, 2)
+	assert.Equal(t, "saved", response.Captures[0].Label)
+	assert.Equal(t, 14, response.Captures[0].Line)
+	assert.Equal(t, "saved.png", response.Captures[0].Reference)
+	require.NotNil(t, response.Captures[0].Difference)
+	assert.Equal(t, 16, response.Captures[1].Line)
+	assert.Nil(t, response.Captures[1].Difference)
+
+	req := host.requests[0]
+	assert.Equal(t, types.ImageFormat("png"), req.Format)
+	assert.Equal(t, 80, req.Quality)
+	assert.True(t, req.IncludeCursor)
+	require.Len(t, req.Pipeline, 2)
+	assert.Equal(t, "blur", req.Pipeline[0].Stage)
+	assert.JSONEq(t, `{"radius": 4, "kernel": [1, 2, 1]}`, string(req.Pipeline[0].Params))
+	assert.Equal(t, "redact", req.Pipeline[1].Stage)
+	assert.JSONEq(t, `{"regions": [{"x": 1, "y": 2, "width": 3, "height": 4}]}`, string(req.Pipeline[1].Params))
+}
+
+func TestRunStageParams(t *testing.T) {
+	tests := []struct {
+		params string
+		json   string
+		err    string
+	}{
+		{params: `'{"max_width": 800}'`, json: `{"max_width": 800}`},
+		{params: `[[{"note": "a \"b\" {c}", "list": [1, 2]}]]`, json: `{"note": "a \"b\" {c}", "list": [1, 2]}`},
+		{params: `{max_width = 800, nested = {a = {true, "x"}}}`, json: `{"max_width": 800, "nested": {"a": [true, "x"]}}`},
+		{params: `{}`, json: `{}`},
+		{params: `{1, 2, 3}`, json: `[1, 2, 3]`},
+		{params: `{1, 2, x = 3}`, err: "bad argument #2 to stage (params keys must be strings, got number)"},
+		{params: `{[true] = 1}`, err: "bad argument #2 to stage (params keys must be strings, got boolean)"},
+		{params: `{f = type}`, err: "bad argument #2 to stage (function can't be converted to JSON)"},
+		{params: `{n = 1/0}`, err: "bad argument #2 to stage (+Inf isn't a JSON number)"},
+		{params: `'{"max_width": 800'`, err: "bad argument #2 to stage (invalid params for stage resize)"},
+		{params: `42`, err: "bad argument #2 to stage (table or JSON string expected, got number)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.params, func(t *testing.T) {
+			host, _, err := runSource(t, "function run(p)\n\twindow('title', 'x')\n\tstage('resize', "+tt.params+")\n\tcapture()\nend", nil)
+			if tt.err != "" {
+				assert.EqualError(t, err, "test.lua:3: "+tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, host.requests, 1)
+			assert.JSONEq(t, tt.json, string(host.requests[0].Pipeline[0].Params))
+		})
+	}
+
+	_, _, err := runSource(t, "function run(p)\n\tlocal t = {}\n\tt.t = t\n\tstage('resize', t)\nend", nil)
+	assert.EqualError(t, err, "test.lua:4: bad argument #2 to stage (params nest too deeply)")
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{name: "invalid duration", body: "wait('soon')", err: `test.lua:2: bad argument #1 to wait (invalid duration "soon")`},
+		{name: "wait too long", body: "wait('2m')", err: "test.lua:2: bad argument #1 to wait (wait must be between 0 and 1m0s)"},
+		{name: "negative wait", body: "wait('-1s')", err: "test.lua:2: bad argument #1 to wait (wait must be between 0 and 1m0s)"},
+		{name: "no window", body: "focus()", err: "test.lua:2: no window selected; call window first"},
+		{name: "capture without window", body: "capture()", err: "test.lua:2: no window selected; call window first"},
+		{name: "fractional coordinate", body: "redact(0, 0, 1.5, 1)", err: "test.lua:2: bad argument #3 to redact (integer expected, got 1.5)"},
+		{name: "text coordinate", body: "click('left', 1)", err: "test.lua:2: bad argument #1 to click (integer expected, got left)"},
+		{name: "unknown button", body: "click(1, 2, 'thumb')", err: `test.lua:2: bad argument #3 to click (unknown mouse button "thumb")`},
+		{name: "table text", body: "type_text({})", err: "test.lua:2: bad argument #1 to type_text (string expected, got table)"},
+		{name: "unknown capture option", body: "window('title', 'x')\n\tcapture{size = 1}", err: `test.lua:3: bad argument #1 to capture (unknown capture option "size")`},
+		{name: "invalid quality", body: "window('title', 'x')\n\tcapture{quality = 'high'}", err: "test.lua:3: bad argument #1 to capture (invalid capture quality high)"},
+		{name: "expect outside directory", body: "window('title', 'x')\n\tcapture{expect = '../x.png'}",
+			err: `test.lua:3: bad argument #1 to capture (expect must be a path inside the workflows directory, got "../x.png")`},
+		{name: "script error", body: "local x = nil\n\tx.y = 1", err: "test.lua:3: attempt to index a non-table object(nil) with key 'y'"},
+		{name: "error call", body: "error('stop here')", err: "test.lua:2: stop here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runSource(t, "function run(p)\n\t"+tt.body+"\nend", nil)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+
+	_, _, err := runSource(t, "function run(p)\n\tkey('ctrl+nope')\nend", nil)
+	assert.ErrorContains(t, err, "test.lua:2: bad argument #1 to key (")
+}
+
+func TestRunWrapsHostErrors(t *testing.T) {
+	script, err := Parse("test", "function run(p)\n\twindow('title', 'x')\n\tfocus()\nend")
+	require.NoError(t, err)
+	hostErr := errors.New("window not found")
+	_, err = Run(context.Background(), script, &fakeHost{focusErr: hostErr}, nil)
+	assert.ErrorIs(t, err, hostErr)
+	assert.EqualError(t, err, "test.lua:3: focus: window not found")
+
+	// Scripts can catch host errors, and their message survives tostring
+	script, err = Parse("test", "function run(p)\n\twindow('title', 'x')\n\tlocal ok, e = pcall(focus)\n\terror('caught ' .. tostring(e), 0)\nend")
+	require.NoError(t, err)
+	_, err = Run(context.Background(), script, &fakeHost{focusErr: hostErr}, nil)
+	assert.EqualError(t, err, "caught test.lua:3: focus: window not found")
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	for _, body := range []string{"wait('1m')", "while true do end"} {
+		script, err := Parse("test", "function run(p)\n\t"+body+"\nend")
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = Run(ctx, script, &fakeHost{}, nil)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded, body)
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	source := "params = {'name', delay = '1s'}\nfunction run(p)\n\ttype_text(p.name .. '/' .. p.delay)\nend"
+	host, _, err := runSource(t, source, map[string]string{"name": "Notepad"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"type Notepad/1s"}, host.calls)
+
+	host, _, err = runSource(t, source, map[string]string{"name": "$$", "delay": ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"type $$/"}, host.calls)
+
+	tests := []struct {
+		params map[string]string
+		err    string
+	}{
+		{params: nil, err: "name is required"},
+		{params: map[string]string{"delay": "2s"}, err: "name is required"},
+		{params: map[string]string{"name": "Notepad", "other": "x"}, err: "unknown parameter other"},
+	}
+	for _, tt := range tests {
+		host, _, err := runSource(t, source, tt.params)
+		assert.ErrorIs(t, err, ErrInvalidParams)
+		assert.ErrorContains(t, err, tt.err)
+		assert.Empty(t, host.calls, "nothing runs with invalid params")
+	}
+}
+
+func TestQuoteReadsBack(t *testing.T) {
+	for _, s := range []string{"plain", "", "two words", `say "hi"`, `C:\temp\`, "tab\there", "line\nbreak\r\n", "\x00\x01\x7f1", "\x1b[0m", "ünïcödé ✓", "]]", "$name"} {
+		host, _, err := runSource(t, "function run(p)\n\ttype_text("+Quote(s)+")\nend", nil)
+		require.NoError(t, err, Quote(s))
+		assert.Equal(t, []string{"type " + s}, host.calls, Quote(s))
+	}
+}
+
+func TestLoadAndList(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, source string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644))
+	}
+	write("b.lua", "-- Second\nfunction run(p) end")
+	write("a.lua", "-- First\nparams = {'x'}\nfunction run(p) end")
+	write("broken.lua", "function run(p)")
+	write("notes.txt", "not a workflow")
+	write("bad name.lua", "function run(p) end")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "dir.lua"), 0o755))
+
+	scripts, errs := List(dir)
+	require.Len(t, scripts, 2)
+	assert.Equal(t, "a", scripts[0].Name)
+	assert.Equal(t, "First", scripts[0].Description)
+	assert.Equal(t, dir, scripts[0].Dir)
+	assert.Equal(t, "b", scripts[1].Name)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "workflow broken: broken.lua: syntax error at end of file")
+
+	_, err := Load(dir, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = Load(dir, "../a")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	scripts, errs = List(filepath.Join(dir, "missing"))
+	assert.Empty(t, scripts)
+	assert.Empty(t, errs)
+
+	info, err := json.Marshal(scripts)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(info))
+}
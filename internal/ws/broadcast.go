@@ -0,0 +1,193 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// viewerQueueSize is how many messages a viewer may fall behind before messages are
+// dropped for it; a slow viewer never holds back the session or the other viewers
+const viewerQueueSize = 8
+
+// viewer is a view-only client of another client's session. It receives the session's
+// frames and state changes but cannot control the session.
+type viewer struct {
+	conn       *websocket.Conn
+	clientInfo *ClientInfo
+	writer     *connWriter
+	dropped    atomic.Int64
+}
+
+// enqueue queues a message for the viewer, dropping it if the viewer is too far behind
+func (v *viewer) enqueue(message *websocket.PreparedMessage) {
+	if !v.writer.trySend(message) {
+		v.dropped.Add(1)
+	}
+}
+
+// close ends the viewer's connection, sending a close frame unless code is CloseAbnormalClosure
+func (v *viewer) close(code int, reason string) {
+	v.writer.close(code, reason)
+}
+
+// WatchSession joins a connection to a session as a view-only viewer and serves it
+// until the viewer disconnects or the session ends. Frames are captured and encoded
+// once for the session and fanned out to all of its viewers.
+func (sm *StreamManager) WatchSession(sessionID string, conn *websocket.Conn, clientInfo *ClientInfo) error {
+	sm.sessionsMux.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.sessionsMux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	v := &viewer{
+		conn:       conn,
+		clientInfo: clientInfo,
+	}
+
+	// Register under the write lock so the joined message precedes any broadcast frame
+	session.writeMutex.Lock()
+	session.mutex.Lock()
+	if session.Context.Err() != nil {
+		session.mutex.Unlock()
+		session.writeMutex.Unlock()
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	v.writer = newConnWriter(conn, viewerQueueSize)
+	status := StatusMessage{
+		SessionID:    session.ID,
+		WindowID:     session.WindowID,
+		Active:       session.Active,
+		FPS:          session.Options.FPS,
+		FrameCount:   session.FrameCount,
+		Duration:     time.Since(session.StartTime),
+		Options:      session.Options,
+		ViewOnly:     true,
+		SharedMemory: session.sharedMemory,
+	}
+	if session.viewers == nil {
+		session.viewers = make(map[*viewer]struct{})
+	}
+	session.viewers[v] = struct{}{}
+	viewers := len(session.viewers)
+	session.mutex.Unlock()
+
+	err := v.writer.sendJSON(StreamMessage{
+		Type:      "session_joined",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      status,
+	})
+	session.writeMutex.Unlock()
+	if err != nil {
+		session.removeViewer(v)
+		v.close(websocket.CloseAbnormalClosure, "")
+		return fmt.Errorf("failed to send join message: %w", err)
+	}
+
+	sm.logger.Info("Viewer joined streaming session",
+		zap.String("session_id", session.ID),
+		zap.String("client_addr", clientInfo.RemoteAddr),
+		zap.Int("viewers", viewers),
+	)
+
+	sm.readViewer(session, v)
+	session.removeViewer(v)
+	v.close(websocket.CloseAbnormalClosure, "")
+
+	sm.logger.Info("Viewer left streaming session",
+		zap.String("session_id", session.ID),
+		zap.String("client_addr", clientInfo.RemoteAddr),
+		zap.Int64("dropped_messages", v.dropped.Load()),
+	)
+	return nil
+}
+
+// readViewer reads a viewer's messages until it disconnects, refusing any command
+func (sm *StreamManager) readViewer(session *StreamSession, v *viewer) {
+	keepAlive(v.conn)
+	for {
+		var msg ControlMessage
+		if err := v.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		v.conn.SetReadDeadline(time.Now().Add(pongWait))
+		data, err := json.Marshal(StreamMessage{
+			Type:      "error",
+			Timestamp: time.Now(),
+			SessionID: session.ID,
+			Error:     fmt.Sprintf("view-only session: command %q not allowed", msg.Command),
+		})
+		if err != nil {
+			continue
+		}
+		if message, err := websocket.NewPreparedMessage(websocket.TextMessage, data); err == nil {
+			v.enqueue(message)
+		}
+	}
+}
+
+// removeViewer unregisters a viewer from the session
+func (s *StreamSession) removeViewer(v *viewer) {
+	s.mutex.Lock()
+	delete(s.viewers, v)
+	s.mutex.Unlock()
+}
+
+// closeViewers disconnects every viewer of an ended session
+func (s *StreamSession) closeViewers(reason string) {
+	s.mutex.Lock()
+	viewers := s.viewers
+	s.viewers = nil
+	s.mutex.Unlock()
+
+	for v := range viewers {
+		v.close(websocket.CloseNormalClosure, reason)
+	}
+}
+
+// Broadcast writes a message to the session's connection, if any, and queues it for
+// every viewer. The message is serialized once; the error is the connection's.
+func (s *StreamSession) Broadcast(msg StreamMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.broadcast(websocket.TextMessage, data)
+}
+
+// BroadcastBinary writes a binary message, such as a raw frame, to the session's
+// connection, if any, and queues it for every viewer
+func (s *StreamSession) BroadcastBinary(data []byte) error {
+	return s.broadcast(websocket.BinaryMessage, data)
+}
+
+// broadcast prepares a message once and sends it to the connection and the viewers
+func (s *StreamSession) broadcast(messageType int, data []byte) error {
+	message, err := websocket.NewPreparedMessage(messageType, data)
+	if err != nil {
+		return err
+	}
+
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.mutex.RLock()
+	writer := s.writer
+	for v := range s.viewers {
+		v.enqueue(message)
+	}
+	s.mutex.RUnlock()
+
+	if writer == nil {
+		return nil
+	}
+	return writer.send(message)
+}
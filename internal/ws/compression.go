@@ -0,0 +1,35 @@
+package ws
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// Compression is the permessage-deflate level of WebSocket connections: 0 leaves
+// compression off, 1 (fastest) to 9 (smallest) negotiate it with clients offering it
+type Compression int
+
+// DefaultCompression trades little CPU for much smaller JSON and base64 frames
+const DefaultCompression Compression = 1
+
+// ParseCompression validates a compression level
+func ParseCompression(level int) (Compression, error) {
+	if level < 0 || level > 9 {
+		return 0, fmt.Errorf("invalid stream_compression %d: must be 0-9", level)
+	}
+	return Compression(level), nil
+}
+
+// Configure makes upgrader negotiate compression with clients that offer it
+func (c Compression) Configure(upgrader *websocket.Upgrader) {
+	upgrader.EnableCompression = c > 0
+}
+
+// Apply sets the level on an upgraded connection. Messages are compressed only if its
+// client negotiated compression.
+func (c Compression) Apply(conn *websocket.Conn) {
+	if c > 0 {
+		conn.SetCompressionLevel(int(c))
+	}
+}
@@ -0,0 +1,101 @@
+package ws
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// DefaultCursorSmoothing is how much of the distance to the cursor the zoom region of a
+// session with CursorZoom keeps each frame, unless the session sets its own
+const DefaultCursorSmoothing = 0.5
+
+// MaxCursorSmoothing keeps the zoom region from lagging behind the cursor indefinitely
+const MaxCursorSmoothing = 0.95
+
+// cursorZoom moves the region a session with CursorZoom streams along with the cursor.
+// The region's center eases towards the cursor rather than jumping to it, so the
+// picture glides after the pointer and small jitters barely move it.
+type cursorZoom struct {
+	x, y    float64 // Center of the region, in screen coordinates
+	started bool
+	region  *types.Rectangle // Region of the last frame
+}
+
+// next returns the region of the next frame: the zoom size, centered on the smoothed
+// cursor position, relative to and kept inside bounds, the target's screen rectangle
+func (z *cursorZoom) next(cursor types.Point, bounds types.Rectangle, size types.Size, smoothing float64) *types.Rectangle {
+	if !z.started {
+		z.x, z.y, z.started = float64(cursor.X), float64(cursor.Y), true
+	} else {
+		z.x += (float64(cursor.X) - z.x) * (1 - smoothing)
+		z.y += (float64(cursor.Y) - z.y) * (1 - smoothing)
+	}
+
+	width, height := min(size.Width, bounds.Width), min(size.Height, bounds.Height)
+	x := int(z.x) - bounds.X - width/2
+	y := int(z.y) - bounds.Y - height/2
+	z.region = &types.Rectangle{
+		X:      max(min(x, bounds.Width-width), 0),
+		Y:      max(min(y, bounds.Height-height), 0),
+		Width:  width,
+		Height: height,
+	}
+	return z.region
+}
+
+// zoomRegion returns the region around the cursor the next frame of a session with
+// CursorZoom shows. While the cursor or the target can't be located, the region of the
+// previous frame is kept; before the first one, the target is streamed whole.
+func (sm *StreamManager) zoomRegion(session *StreamSession, zoom *cursorZoom, options *types.StreamOptions) *types.Rectangle {
+	if sm.windows == nil {
+		return zoom.region
+	}
+	cursor, err := sm.windows.GetCursorPosition()
+	if err != nil {
+		return zoom.region
+	}
+	bounds, err := sm.targetBounds(session)
+	if err != nil {
+		return zoom.region
+	}
+	return zoom.next(cursor, bounds, *options.CursorZoom, options.CursorSmoothing)
+}
+
+// targetBounds returns the screen rectangle of the session's display or window. The
+// desktop, window 0, is the primary display.
+func (sm *StreamManager) targetBounds(session *StreamSession) (types.Rectangle, error) {
+	session.mutex.RLock()
+	windowID := session.WindowID
+	session.mutex.RUnlock()
+
+	monitor := session.Options.Monitor
+	if monitor == nil && windowID != 0 {
+		info, err := sm.windows.GetWindowInfo(windowID)
+		if err != nil {
+			return types.Rectangle{}, err
+		}
+		return info.Rect, nil
+	}
+
+	engine := sm.engine
+	if scheduled, ok := engine.(interface{ Unwrap() types.ScreenshotEngine }); ok {
+		engine = scheduled.Unwrap()
+	}
+	lister, ok := engine.(interface {
+		Monitors() ([]types.MonitorInfo, error)
+	})
+	if !ok {
+		return types.Rectangle{}, fmt.Errorf("the capture engine doesn't list monitors")
+	}
+	monitors, err := lister.Monitors()
+	if err != nil {
+		return types.Rectangle{}, err
+	}
+	for i, info := range monitors {
+		if (monitor != nil && i == *monitor) || (monitor == nil && info.Primary) {
+			return info.Rect, nil
+		}
+	}
+	return types.Rectangle{}, fmt.Errorf("monitor not found")
+}
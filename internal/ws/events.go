@@ -0,0 +1,252 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/internal/crash"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// DefaultNotificationPollInterval is how often the notification watcher looks for new toasts
+const DefaultNotificationPollInterval = 500 * time.Millisecond
+
+// eventSendBuffer is the number of events queued per client before new ones are dropped
+const eventSendBuffer = 16
+
+// NotificationMessage carries a toast notification captured as it appeared
+type NotificationMessage struct {
+	Window  types.WindowInfo `json:"window"`
+	Width   int              `json:"width,omitempty"`
+	Height  int              `json:"height,omitempty"`
+	Format  string           `json:"format,omitempty"`
+	DataURL string           `json:"data_url,omitempty"`
+	Size    int              `json:"size,omitempty"`
+}
+
+// EventHub pushes desktop events, such as new toast notifications, to WebSocket
+// clients. The notification watcher only runs while at least one client is connected.
+type EventHub struct {
+	mu           sync.Mutex
+	clients      map[*eventClient]struct{}
+	stopWatcher  context.CancelFunc
+	upgrader     websocket.Upgrader
+	engine       types.ScreenshotEngine
+	processor    types.ImageProcessor
+	logger       *zap.Logger
+	pollInterval time.Duration
+	watermark    *screenshot.Pipeline // Applied to notification captures
+	crashes      *crash.Reporter      // Records panics of the notification watcher
+	compression  Compression          // permessage-deflate level of subscriber connections
+}
+
+// eventClient is a connected events subscriber with its own writer goroutine
+type eventClient struct {
+	conn *websocket.Conn
+	send chan StreamMessage
+}
+
+// NewEventHub creates an event hub that captures notifications with engine
+func NewEventHub(engine types.ScreenshotEngine, logger *zap.Logger) *EventHub {
+	return &EventHub{
+		clients: make(map[*eventClient]struct{}),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true // Allow all origins for development
+			},
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024 * 1024,
+		},
+		engine:       engine,
+		processor:    screenshot.NewImageProcessor(),
+		logger:       logger,
+		pollInterval: DefaultNotificationPollInterval,
+	}
+}
+
+// HandleWebSocket subscribes a client to events until it disconnects
+func (h *EventHub) HandleWebSocket(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error("Failed to upgrade events connection", zap.Error(err))
+		return
+	}
+	h.compression.Apply(conn)
+
+	client := &eventClient{conn: conn, send: make(chan StreamMessage, eventSendBuffer)}
+	h.subscribe(client)
+	defer h.unsubscribe(client)
+
+	go func() {
+		for msg := range client.send {
+			if err := conn.WriteJSON(msg); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// Clients don't send anything meaningful; reading detects the disconnect
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// Publish queues an event for every connected client, dropping it for clients that
+// have fallen behind
+func (h *EventHub) Publish(msg StreamMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		select {
+		case client.send <- msg:
+		default:
+			h.logger.Warn("Dropping event for slow client",
+				zap.String("type", msg.Type),
+				zap.String("client_addr", client.conn.RemoteAddr().String()),
+			)
+		}
+	}
+}
+
+// Close disconnects all clients and stops the notification watcher
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		delete(h.clients, client)
+		close(client.send)
+		client.conn.Close()
+	}
+	if h.stopWatcher != nil {
+		h.stopWatcher()
+		h.stopWatcher = nil
+	}
+}
+
+func (h *EventHub) subscribe(client *eventClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.clients[client] = struct{}{}
+	client.send <- StreamMessage{Type: "subscribed", Timestamp: time.Now()}
+
+	if h.stopWatcher == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.stopWatcher = cancel
+		go h.watchNotifications(ctx)
+	}
+}
+
+func (h *EventHub) unsubscribe(client *eventClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return // Already removed by Close
+	}
+	delete(h.clients, client)
+	close(client.send)
+	client.conn.Close()
+
+	if len(h.clients) == 0 && h.stopWatcher != nil {
+		h.stopWatcher()
+		h.stopWatcher = nil
+	}
+}
+
+// watchNotifications polls for toast windows and publishes each one that appears.
+// Toasts already on screen when watching starts are reported too.
+func (h *EventHub) watchNotifications(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.logger.Error("Notification watcher panicked", zap.Any("error", r))
+			h.crashes.Capture("notification_watcher", r, nil)
+		}
+	}()
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	showing := make(map[uintptr]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			toasts, err := h.engine.FindToastWindows()
+			if err != nil {
+				h.logger.Warn("Failed to find toast windows", zap.Error(err))
+				continue
+			}
+
+			// The host reuses toast windows, so a window that was hidden and is shown
+			// again counts as a new notification
+			current := make(map[uintptr]bool, len(toasts))
+			for _, toast := range toasts {
+				current[toast.Handle] = true
+				if !showing[toast.Handle] {
+					h.publishNotification(toast)
+				}
+			}
+			showing = current
+		}
+	}
+}
+
+// SetWatermark sets a pipeline applied to notification captures before encoding
+func (h *EventHub) SetWatermark(watermark *screenshot.Pipeline) {
+	h.watermark = watermark
+}
+
+// SetCrashReporter sets the reporter that records panics of the notification watcher
+func (h *EventHub) SetCrashReporter(crashes *crash.Reporter) {
+	h.crashes = crashes
+}
+
+// SetCompression sets the permessage-deflate level negotiated on subscriber connections
+func (h *EventHub) SetCompression(level Compression) {
+	h.compression = level
+	level.Configure(&h.upgrader)
+}
+
+// publishNotification captures a toast and publishes it as a "notification" event
+func (h *EventHub) publishNotification(toast types.WindowInfo) {
+	msg := StreamMessage{Type: "notification", Timestamp: time.Now()}
+	notification := NotificationMessage{Window: toast}
+
+	options := types.DefaultCaptureOptions()
+	options.RestoreWindow = false
+	options.Priority = types.PriorityScheduled
+	buffer, err := h.engine.CaptureByHandle(toast.Handle, options)
+	if err == nil && h.watermark != nil {
+		buffer, err = h.watermark.Process(buffer)
+	}
+	if err == nil {
+		var encoded []byte
+		if encoded, err = h.processor.Encode(buffer, types.FormatPNG, 100); err == nil {
+			notification.Width = buffer.Width
+			notification.Height = buffer.Height
+			notification.Format = string(types.FormatPNG)
+			notification.DataURL = encodeDataURL(types.FormatPNG, encoded)
+			notification.Size = len(encoded)
+		}
+	}
+	if err != nil {
+		// Still report the notification so agents know something popped up
+		msg.Error = err.Error()
+	}
+
+	msg.Data = notification
+	h.Publish(msg)
+}
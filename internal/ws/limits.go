@@ -0,0 +1,73 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reapInterval is how often sessions are checked against the duration and idle limits
+const reapInterval = 5 * time.Second
+
+// SetSessionLimits limits how many sessions may exist at once, how long each may run and
+// how long each may go without its client connected; 0 removes a limit. New sessions past
+// maxSessions are refused, while ReapSessions closes sessions past the other two. The
+// limits may change while sessions run.
+func (sm *StreamManager) SetSessionLimits(maxSessions int, maxDuration, idleTimeout time.Duration) {
+	sm.maxSessions.Store(int64(maxSessions))
+	sm.maxDuration.Store(int64(maxDuration))
+	sm.idleTimeout.Store(int64(idleTimeout))
+}
+
+// ReapSessions closes sessions that ran past the maximum duration or went without their
+// client past the idle timeout, until stop is closed. Each client and viewer gets a close
+// frame with the policy violation code and the reason.
+func (sm *StreamManager) ReapSessions(stop <-chan struct{}) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			sm.reapSessions(now)
+		}
+	}
+}
+
+// reapSessions closes the sessions past a limit at now
+func (sm *StreamManager) reapSessions(now time.Time) {
+	maxDuration := time.Duration(sm.maxDuration.Load())
+	idleTimeout := time.Duration(sm.idleTimeout.Load())
+	if maxDuration <= 0 && idleTimeout <= 0 {
+		return
+	}
+
+	reasons := make(map[string]string)
+	sm.sessionsMux.RLock()
+	for id, session := range sm.sessions {
+		session.mutex.RLock()
+		detachedAt := session.DetachedAt
+		session.mutex.RUnlock()
+
+		switch {
+		case maxDuration > 0 && now.Sub(session.StartTime) >= maxDuration:
+			reasons[id] = fmt.Sprintf("session reached the maximum duration of %s", maxDuration)
+		case idleTimeout > 0 && !detachedAt.IsZero() && now.Sub(detachedAt) >= idleTimeout:
+			reasons[id] = fmt.Sprintf("session went without its client for %s", idleTimeout)
+		}
+	}
+	sm.sessionsMux.RUnlock()
+
+	for id, reason := range reasons {
+		if err := sm.CloseSession(id, reason); err != nil {
+			continue
+		}
+		sm.logger.Info("Streaming session reaped",
+			zap.String("session_id", id),
+			zap.String("reason", reason),
+		)
+	}
+}
@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSessionRefusedPastMaxSessions(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+	sm.SetSessionLimits(2, 0, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err := sm.StartSession(notepad, nil)
+		require.NoError(t, err)
+	}
+	_, err := sm.StartSession(notepad, nil)
+	assert.ErrorContains(t, err, "too many streaming sessions")
+	assert.Equal(t, 2, sessionCount(sm))
+}
+
+func TestReapSessionsPastMaxDuration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sm := newTestManager()
+	defer sm.Cleanup()
+	sm.SetSessionLimits(0, time.Minute, 0)
+
+	router := gin.New()
+	router.GET("/stream/:windowId", sm.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/65552", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	var started StreamMessage
+	require.NoError(t, conn.ReadJSON(&started))
+
+	sm.reapSessions(time.Now())
+	assert.Equal(t, 1, sessionCount(sm))
+
+	sm.reapSessions(time.Now().Add(time.Minute))
+	assert.Equal(t, 0, sessionCount(sm))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	var closed *websocket.CloseError
+	require.ErrorAs(t, err, &closed)
+	assert.Equal(t, websocket.ClosePolicyViolation, closed.Code)
+	assert.Contains(t, closed.Text, "maximum duration")
+}
+
+func TestReapSessionsIdleWithoutClient(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+	sm.SetResumeGracePeriod(time.Hour)
+	sm.SetSessionLimits(0, 0, time.Minute)
+
+	_, err := sm.StartSession(notepad, nil)
+	require.NoError(t, err)
+
+	sm.reapSessions(time.Now())
+	assert.Equal(t, 1, sessionCount(sm))
+
+	sm.reapSessions(time.Now().Add(time.Minute))
+	assert.Equal(t, 0, sessionCount(sm))
+}
@@ -0,0 +1,59 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/tracing"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Picture-in-picture defaults and limits
+const (
+	DefaultInsetPosition = "bottom_right"
+	DefaultInsetScale    = 0.25
+	MinInsetScale        = 0.05
+	MaxInsetScale        = 0.5
+	MaxInsets            = 4
+)
+
+// composeInsets captures the inset windows of a picture-in-picture stream and draws them
+// into the frame. An inset window that can't be captured, such as one that was closed,
+// is left out of the frame rather than failing it; if compositing fails, the frame is
+// sent without insets.
+func (sm *StreamManager) composeInsets(ctx context.Context, session *StreamSession, buffer *types.ScreenshotBuffer, specs []types.StreamInset, options *types.CaptureOptions) *types.ScreenshotBuffer {
+	_, span := tracing.Start(ctx, "compose")
+	defer span.End()
+
+	insetOptions := *options
+	insetOptions.Region = nil
+	insetOptions.SyncToPresent = false
+
+	insets := make([]screenshot.Inset, 0, len(specs))
+	for _, spec := range specs {
+		inset, err := sm.engine.CaptureByHandle(spec.WindowID, &insetOptions)
+		if err != nil {
+			sm.logger.Debug("Failed to capture inset window",
+				zap.String("session_id", session.ID),
+				zap.Uintptr("window_id", spec.WindowID),
+				zap.Error(err),
+			)
+			continue
+		}
+		insets = append(insets, screenshot.Inset{Buffer: inset, Position: spec.Position, Scale: spec.Scale})
+	}
+	if len(insets) == 0 {
+		return buffer
+	}
+
+	composed, err := screenshot.ComposeInsets(buffer, insets)
+	if err != nil {
+		sm.logger.Warn("Failed to composite insets",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+		return buffer
+	}
+	return composed
+}
@@ -0,0 +1,71 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/tracing"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// obscureDesktop hides every window of a privacy stream's desktop or monitor frame
+// except the allowed ones, which are captured on their own and drawn back where they
+// are, bottommost first. Allowed windows that are minimized, hidden, on another virtual
+// desktop or can't be captured are left out. Unlike picture-in-picture, failing to
+// obscure fails the frame, so nothing that should be hidden is ever sent.
+func (sm *StreamManager) obscureDesktop(ctx context.Context, session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions, captureOptions *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	_, span := tracing.Start(ctx, "obscure")
+	defer span.End()
+
+	var windows []*types.WindowInfo
+	if len(options.PrivacyAllow) > 0 {
+		if sm.windows == nil {
+			return nil, fmt.Errorf("no window manager to locate allowed windows")
+		}
+		for _, handle := range options.PrivacyAllow {
+			info, err := sm.windows.GetWindowInfo(handle)
+			if err != nil {
+				continue
+			}
+			if !info.IsVisible || info.State == "minimized" || info.State == "hidden" ||
+				(info.OnCurrentDesktop != nil && !*info.OnCurrentDesktop) {
+				continue
+			}
+			windows = append(windows, info)
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool { return windows[i].ZOrder > windows[j].ZOrder })
+
+	var placed []screenshot.PlacedCapture
+	if len(windows) > 0 {
+		bounds, err := sm.targetBounds(session)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate the streamed display: %w", err)
+		}
+		windowOptions := *captureOptions
+		windowOptions.Region = nil
+		windowOptions.SyncToPresent = false
+		windowOptions.IncludeFrame = true
+		windowOptions.AllowMinimized = false
+		for _, info := range windows {
+			capture, err := sm.engine.CaptureByHandle(info.Handle, &windowOptions)
+			if err != nil {
+				sm.logger.Debug("Failed to capture allowed window",
+					zap.String("session_id", session.ID),
+					zap.Uintptr("window_id", info.Handle),
+					zap.Error(err),
+				)
+				continue
+			}
+			placed = append(placed, screenshot.PlacedCapture{
+				Buffer: capture,
+				At:     types.Point{X: info.Rect.X - bounds.X, Y: info.Rect.Y - bounds.Y},
+			})
+		}
+	}
+
+	return screenshot.ObscureDesktop(buffer, options.Privacy, placed)
+}
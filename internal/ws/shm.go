@@ -0,0 +1,144 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/screenshot-mcp-server/internal/tracing"
+	"github.com/screenshot-mcp-server/pkg/shmring"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"go.uber.org/zap"
+)
+
+// sharedMemorySlots is how many frames a session's shared-memory ring holds. A consumer
+// in ack mode with fewer frames unacknowledged reads every frame before it's overwritten.
+const sharedMemorySlots = 4
+
+// SharedMemoryMessage tells a consumer where a session's frame ring is. It's sent in a
+// "shm_attached" message before the first frame written to the ring, and again when
+// frames outgrow the ring and it's replaced by a larger one.
+type SharedMemoryMessage struct {
+	Path     string `json:"path"`
+	Slots    int    `json:"slots"`
+	SlotSize int    `json:"slot_size"` // Bytes of a slot, including its header
+	Capacity int    `json:"capacity"`  // Most pixel bytes a slot holds
+}
+
+// SetSharedMemoryDir sets the directory the frame rings of the shm transport are created
+// in; "" disables the transport
+func (sm *StreamManager) SetSharedMemoryDir(dir string) {
+	sm.shmDir = dir
+}
+
+// sendSharedFrame writes a frame's pixels to the session's shared-memory ring and sends
+// a frame message naming its slot instead of the image
+func (sm *StreamManager) sendSharedFrame(ctx context.Context, session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions, timing *types.CaptureTiming, processStart time.Time) error {
+	writeStart := time.Now()
+	timing.Process = writeStart.Sub(processStart)
+	frameNumber := session.FrameCount + 1
+	if err := sm.ensureRing(session, buffer, frameNumber); err != nil {
+		return err
+	}
+	_, span := tracing.Start(ctx, "encode")
+	slot, err := session.ring.Write(frameNumber, timing.CapturedAt, buffer)
+	span.End()
+	if err != nil {
+		return fmt.Errorf("failed to write frame to shared memory: %w", err)
+	}
+	timing.Encode = time.Since(writeStart)
+
+	encoded, err := sm.encodeForRecording(session, buffer, options)
+	if err != nil {
+		return err
+	}
+
+	session.mutex.RLock()
+	timing.Send = session.lastSend
+	session.mutex.RUnlock()
+
+	size := buffer.Width * buffer.Height * 4
+	sendStart := time.Now()
+	_, span = tracing.Start(ctx, "send")
+	err = session.Broadcast(StreamMessage{
+		Type:      "frame",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data: FrameMessage{
+			FrameNumber: frameNumber,
+			Width:       buffer.Width,
+			Height:      buffer.Height,
+			Format:      sharedFrameFormat,
+			Size:        size,
+			Timestamp:   time.Now(),
+			AckRequired: options.AckMode,
+			Timing:      timing,
+			Slot:        &slot,
+		},
+	})
+	span.End()
+	return sm.frameSent(session, buffer, timing, frameNumber, encoded, size, time.Since(sendStart), err)
+}
+
+// sharedFrameFormat is the format of frames written to shared memory
+const sharedFrameFormat = "bgra"
+
+// ensureRing creates the session's ring before its first frame, or a larger one when a
+// frame doesn't fit, and tells the consumer where it is. Slots hold a frame the size of
+// the window's monitor, so the ring is seldom replaced as the window is resized.
+func (sm *StreamManager) ensureRing(session *StreamSession, buffer *types.ScreenshotBuffer, frameNumber int64) error {
+	need := buffer.Width * buffer.Height * 4
+	if session.ring != nil && session.ring.Capacity() >= need {
+		return nil
+	}
+	capacity := need
+	if monitor := buffer.MonitorInfo.Rect.Width * buffer.MonitorInfo.Rect.Height * 4; monitor > capacity {
+		capacity = monitor
+	}
+
+	if err := os.MkdirAll(sm.shmDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create shared memory directory: %w", err)
+	}
+	path := filepath.Join(sm.shmDir, fmt.Sprintf("%s_%d.shm", session.ID, frameNumber))
+	ring, err := shmring.Create(path, sharedMemorySlots, capacity)
+	if err != nil {
+		return fmt.Errorf("failed to create shared memory ring: %w", err)
+	}
+	sm.closeRing(session)
+	session.ring = ring
+
+	attached := &SharedMemoryMessage{Path: ring.Path(), Slots: ring.Slots(), SlotSize: ring.SlotSize(), Capacity: ring.Capacity()}
+	session.mutex.Lock()
+	session.sharedMemory = attached
+	session.mutex.Unlock()
+
+	sm.logger.Info("Shared memory ring created",
+		zap.String("session_id", session.ID),
+		zap.String("path", ring.Path()),
+		zap.Int("slot_size", ring.SlotSize()),
+	)
+	return session.Broadcast(StreamMessage{
+		Type:      "shm_attached",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      attached,
+	})
+}
+
+// closeRing unmaps the session's ring, if any, and removes its file. Windows keeps the
+// file while a consumer has it mapped.
+func (sm *StreamManager) closeRing(session *StreamSession) {
+	if session.ring == nil {
+		return
+	}
+	if err := session.ring.Close(); err != nil {
+		sm.logger.Debug("Failed to remove shared memory ring",
+			zap.String("session_id", session.ID),
+			zap.String("path", session.ring.Path()),
+			zap.Error(err),
+		)
+	}
+	session.ring = nil
+}
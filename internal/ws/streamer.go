@@ -2,19 +2,51 @@ package ws
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/internal/activity"
+	"github.com/screenshot-mcp-server/internal/budget"
+	"github.com/screenshot-mcp-server/internal/crash"
+	"github.com/screenshot-mcp-server/internal/power"
+	"github.com/screenshot-mcp-server/internal/recording"
 	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/tracing"
+	"github.com/screenshot-mcp-server/pkg/framecrypt"
+	"github.com/screenshot-mcp-server/pkg/rawframe"
+	"github.com/screenshot-mcp-server/pkg/shmring"
 	"github.com/screenshot-mcp-server/pkg/types"
 	"go.uber.org/zap"
 )
 
+// DefaultResumeGracePeriod is how long a disconnected session waits for its client to resume
+const DefaultResumeGracePeriod = 30 * time.Second
+
+// fpsWindow is the span the achieved frame rate of a session is measured over
+const fpsWindow = 5 * time.Second
+
+// GDI leak detection: a session whose GDI footprint grows at each of gdiGrowthSamples
+// samples taken gdiSampleInterval apart is logged as leaking
+const (
+	gdiSampleInterval = 10 * time.Second
+	gdiGrowthSamples  = 7
+)
+
+// errFrameNotSent marks frames that were encoded but could not be written to the client
+var errFrameNotSent = errors.New("failed to send frame")
+
 // StreamManager manages WebSocket streaming sessions
 type StreamManager struct {
 	sessions    map[string]*StreamSession
@@ -22,7 +54,23 @@ type StreamManager struct {
 	upgrader    websocket.Upgrader
 	engine      types.ScreenshotEngine
 	processor   types.ImageProcessor
+	windows     types.WindowManager      // Finds the foreground window for sessions with Follow
 	logger      *zap.Logger
+	resumeGrace time.Duration
+	watermark   *screenshot.Pipeline // Applied to every frame before encoding
+	recorder    *recording.Recorder  // Stores the frames of sessions started with Record
+	frameKey    *framecrypt.Key      // Signs and encrypts frames of sessions with Security
+	crashes     *crash.Reporter      // Records panics of streaming goroutines
+	usage       UsageRecorder        // Counts the sessions and frames of all streams
+	activity    *activity.Monitor    // Pauses streams depending on the user's activity
+	power       *power.Monitor       // Caps streams on battery and in battery saver
+	cpuBudget   atomic.Int64         // Percent of a core each stream may use; 0 for no limit
+	maxSessions atomic.Int64         // Sessions that may exist at once; 0 for no limit
+	maxDuration atomic.Int64         // Longest a session may run, in nanoseconds; 0 for no limit
+	idleTimeout atomic.Int64         // Longest a session may go without its client, in nanoseconds; 0 for no limit
+	compression Compression          // permessage-deflate level of stream connections
+	shmDir      string               // Directory of the shm transport's frame rings; "" disables it
+	startTime   time.Time
 }
 
 // StreamSession represents an active streaming session
@@ -40,7 +88,28 @@ type StreamSession struct {
 	Context     context.Context           `json:"-"`
 	Cancel      context.CancelFunc        `json:"-"`
 	ClientInfo  *ClientInfo               `json:"client_info"`
+	ResumeToken string                    `json:"-"`
+	DetachedAt  time.Time                 `json:"detached_at,omitempty"`
+	MissedFrames int64                    `json:"missed_frames"`
+	Reconnects  int                       `json:"reconnects"`
+	LastAcked   int64                     `json:"last_acked"`
+	ThrottledFrames int64                 `json:"throttled_frames"`
+	lastSend    time.Duration             // Time taken to write the previous frame
+	windowTitle string                    // Title of the window in the last frame
+	frameBytes  int64                     // Bytes of regular frames, keyframes excluded
+	failedFrames int64                    // Frames that failed to capture or encode
+	lastError   string
+	lastErrorAt time.Time
+	recentFrames []time.Time              // Delivery times within fpsWindow
+	recordingID string                    // Recording the delivered frames are teed into
+	viewers     map[*viewer]struct{}      // View-only clients receiving broadcast messages
+	cpuUsage    float64                   // Percent of a core used over the last second
+	cpuThrottle *types.CPUThrottle        // Frame rate cap of the CPU budget, if any
+	ring        *shmring.Ring             // Frame ring of the shm transport, owned by the streaming goroutine
+	sharedMemory *SharedMemoryMessage     // Where the ring is, for resuming and watching clients
+	writer      *connWriter               // The only goroutine writing to Conn
 	mutex       sync.RWMutex
+	writeMutex  sync.Mutex                // Orders opening messages before the frames that follow them
 }
 
 // ClientInfo contains information about the connected client
@@ -66,9 +135,18 @@ type FrameMessage struct {
 	Width       int    `json:"width"`
 	Height      int    `json:"height"`
 	Format      string `json:"format"`
-	DataURL     string `json:"data_url"` // Base64 encoded image as data URL
+	DataURL     string `json:"data_url,omitempty"` // Base64 encoded image as data URL
+	Payload     string `json:"payload,omitempty"`  // Encrypted frames: base64 nonce and ciphertext of the image
+	Encrypted   bool   `json:"encrypted,omitempty"`
+	Signature   string `json:"signature,omitempty"` // Signed frames: hex HMAC-SHA256 of the header and image or payload
 	Size        int    `json:"size"`
 	Timestamp   time.Time `json:"timestamp"`
+	AckRequired bool   `json:"ack_required,omitempty"`
+	Keyframe    bool   `json:"keyframe,omitempty"` // On-demand full-quality capture
+	Quality     int    `json:"quality,omitempty"`  // Quality ladder streams: the quality the frame was encoded at
+	Region      *types.Rectangle `json:"region,omitempty"` // Cursor zoom streams: the part of the window or display shown
+	Timing      *types.CaptureTiming `json:"timing"` // Capture timestamps and latencies
+	Slot        *int   `json:"slot,omitempty"` // shm transport: ring slot holding the pixels
 }
 
 // StatusMessage contains session status information
@@ -81,6 +159,35 @@ type StatusMessage struct {
 	BytesSent   int64                `json:"bytes_sent"`
 	Duration    time.Duration        `json:"duration"`
 	Options     *types.StreamOptions `json:"options"`
+	MissedFrames int64               `json:"missed_frames"`
+	Reconnects  int                  `json:"reconnects"`
+	LastAcked   int64                `json:"last_acked"`
+	Unacked     int64                `json:"unacked"`
+	ThrottledFrames int64            `json:"throttled_frames"` // Frames skipped while waiting for acks
+	RecordingID string               `json:"recording_id,omitempty"` // Recording the session's frames are teed into
+	ViewOnly    bool                 `json:"view_only,omitempty"`    // Sent to viewers, which cannot control the session
+	ResumeToken string               `json:"resume_token,omitempty"` // Only sent when a connection is (re)established
+	Power       *types.PowerThrottle `json:"power,omitempty"`        // Caps applied on battery or in battery saver
+	CPU         *types.CPUThrottle   `json:"cpu,omitempty"`          // Frame rate cap applied for exceeding the CPU budget
+	SharedMemory *SharedMemoryMessage `json:"shared_memory,omitempty"` // shm transport: the frame ring, once created
+}
+
+// TargetChangedMessage tells the client of a session with Follow that it streams another
+// window from the next frame on
+type TargetChangedMessage struct {
+	WindowID uintptr           `json:"window_id"`
+	Window   *types.WindowInfo `json:"window,omitempty"`
+}
+
+// ActivityMessage reports streaming pausing or resuming with the user's activity
+type ActivityMessage struct {
+	State string             `json:"state"` // "active" or "idle"
+	Gate  types.ActivityGate `json:"gate"`
+}
+
+// DesktopStateMessage reports the desktop becoming unavailable or available again
+type DesktopStateMessage struct {
+	State types.DesktopState `json:"state"`
 }
 
 // ControlMessage represents control commands
@@ -89,6 +196,8 @@ type ControlMessage struct {
 	SessionID string                   `json:"session_id,omitempty"`
 	Options   *types.StreamOptions     `json:"options,omitempty"`
 	WindowID  *uintptr                 `json:"window_id,omitempty"`
+	FrameNumber int64                  `json:"frame_number,omitempty"` // Frame acknowledged by "ack" (0 = latest)
+	Region      *types.Rectangle       `json:"region,omitempty"`       // Region streamed after "set_region" (none = all of it)
 }
 
 // NewStreamManager creates a new stream manager
@@ -104,9 +213,86 @@ func NewStreamManager(logger *zap.Logger) *StreamManager {
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024 * 1024, // 1MB buffer for large frames
 		},
-		processor: processor,
-		logger:    logger,
+		processor:   processor,
+		logger:      logger,
+		resumeGrace: DefaultResumeGracePeriod,
+		startTime:   time.Now(),
+	}
+}
+
+// SetWatermark sets a pipeline applied to every frame and keyframe before encoding
+func (sm *StreamManager) SetWatermark(watermark *screenshot.Pipeline) {
+	sm.watermark = watermark
+}
+
+// SetRecorder sets the recorder that sessions started with Record tee their frames into
+func (sm *StreamManager) SetRecorder(recorder *recording.Recorder) {
+	sm.recorder = recorder
+}
+
+// SetFrameKey sets the key that signs and encrypts the frames of sessions with Security
+func (sm *StreamManager) SetFrameKey(key *framecrypt.Key) {
+	sm.frameKey = key
+}
+
+// SetCrashReporter sets the reporter that records panics of streaming goroutines
+func (sm *StreamManager) SetCrashReporter(crashes *crash.Reporter) {
+	sm.crashes = crashes
+}
+
+// UsageRecorder counts the sessions started and the frames delivered across all streams
+type UsageRecorder interface {
+	RecordSession()
+	RecordFrame(bytes int)
+}
+
+// SetUsageRecorder sets where the sessions and frames of all streams are counted
+func (sm *StreamManager) SetUsageRecorder(usage UsageRecorder) {
+	sm.usage = usage
+}
+
+// SetActivityMonitor pauses streams while the monitor's gate holds
+func (sm *StreamManager) SetActivityMonitor(monitor *activity.Monitor) {
+	sm.activity = monitor
+}
+
+// SetWindowManager sets the window manager that sessions with Follow track the foreground
+// window with
+func (sm *StreamManager) SetWindowManager(windows types.WindowManager) {
+	sm.windows = windows
+}
+
+// SetPowerMonitor caps the frame rate and quality of streams by the monitor's policy
+func (sm *StreamManager) SetPowerMonitor(monitor *power.Monitor) {
+	sm.power = monitor
+}
+
+// SetCPUBudget limits the CPU time each stream may spend capturing and encoding frames
+// to percent of a core, lowering its frame rate while it uses more; 0 removes the limit
+func (sm *StreamManager) SetCPUBudget(percent int) {
+	sm.cpuBudget.Store(int64(percent))
+}
+
+// powerThrottle returns the caps the power policy applies to stream options now, or nil
+// when it lowers nothing
+func (sm *StreamManager) powerThrottle(options *types.StreamOptions) *types.PowerThrottle {
+	limits, state := sm.power.Limits()
+	fps, quality, capped := power.Apply(limits, options.FPS, options.Quality)
+	if !capped {
+		return nil
 	}
+	return &types.PowerThrottle{Power: state, Limits: limits, FPS: fps, Quality: quality}
+}
+
+// SetCompression sets the permessage-deflate level negotiated on stream connections
+func (sm *StreamManager) SetCompression(level Compression) {
+	sm.compression = level
+	level.Configure(&sm.upgrader)
+}
+
+// SetResumeGracePeriod sets how long disconnected sessions stay resumable (0 disables resume)
+func (sm *StreamManager) SetResumeGracePeriod(grace time.Duration) {
+	sm.resumeGrace = grace
 }
 
 // HandleWebSocket handles WebSocket connections for streaming
@@ -131,27 +317,63 @@ func (sm *StreamManager) HandleWebSocket(c *gin.Context) {
 		sm.logger.Error("Failed to upgrade WebSocket connection", zap.Error(err))
 		return
 	}
+	sm.compression.Apply(conn)
 
 	// Create client info
-	clientInfo := &ClientInfo{
-		RemoteAddr:  conn.RemoteAddr().String(),
-		UserAgent:   c.Request.Header.Get("User-Agent"),
-		ConnectedAt: time.Now(),
-		Headers:     make(map[string]string),
+	clientInfo := NewClientInfo(c, conn)
+
+	// Resume an existing session when the client presents its token
+	if resumeID := c.Query("session_id"); resumeID != "" {
+		session, err := sm.ResumeSession(resumeID, c.Query("resume_token"), conn)
+		if err != nil {
+			conn.WriteJSON(StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				SessionID: resumeID,
+				Error:     err.Error(),
+			})
+			conn.Close()
+			return
+		}
+
+		session.SetClientInfo(clientInfo)
+		sm.handleClientMessages(session)
+		return
 	}
-	
-	// Copy relevant headers
-	for key, values := range c.Request.Header {
-		if len(values) > 0 {
-			clientInfo.Headers[key] = values[0]
+
+	// Join another client's session as a view-only viewer
+	if watchID := c.Query("watch"); watchID != "" {
+		if err := sm.WatchSession(watchID, conn, clientInfo); err != nil {
+			conn.WriteJSON(StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				SessionID: watchID,
+				Error:     err.Error(),
+			})
+			conn.Close()
 		}
+		return
 	}
 
-	// Start streaming session
-	sessionID := fmt.Sprintf("stream_%d_%d", windowID, time.Now().Unix())
+	// Start streaming session, bound to the connection from the outset
 	options := types.DefaultStreamOptions()
 	
-	session, err := sm.StartSession(windowID, options)
+	session, err := sm.ConnectSession(windowID, options, conn, clientInfo, func(session *StreamSession) interface{} {
+		return StreamMessage{
+			Type:      "session_started",
+			Timestamp: time.Now(),
+			SessionID: session.ID,
+			Data: StatusMessage{
+				SessionID:   session.ID,
+				WindowID:    windowID,
+				Active:      true,
+				FPS:         options.FPS,
+				Options:     options,
+				ResumeToken: session.ResumeToken,
+				RecordingID: session.recordingID,
+			},
+		}
+	})
 	if err != nil {
 		conn.WriteJSON(StreamMessage{
 			Type:      "error",
@@ -162,78 +384,176 @@ func (sm *StreamManager) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	// Update session with WebSocket connection and client info
-	sm.sessionsMux.Lock()
-	if existingSession, exists := sm.sessions[sessionID]; exists {
-		existingSession.Conn = conn
-		existingSession.ClientInfo = clientInfo
-		session = existingSession
-	}
-	sm.sessionsMux.Unlock()
-
 	sm.logger.Info("WebSocket streaming session started",
-		zap.String("session_id", sessionID),
+		zap.String("session_id", session.ID),
 		zap.Uintptr("window_id", windowID),
 		zap.String("client_addr", clientInfo.RemoteAddr),
 	)
 
-	// Send initial status message
-	conn.WriteJSON(StreamMessage{
-		Type:      "session_started",
-		Timestamp: time.Now(),
-		SessionID: sessionID,
-		Data: StatusMessage{
-			SessionID: sessionID,
-			WindowID:  windowID,
-			Active:    true,
-			FPS:       options.FPS,
-			Options:   options,
-		},
-	})
+	// Handle incoming messages until the connection drops or the session ends
+	sm.handleClientMessages(session)
+}
 
-	// Handle incoming messages
-	go sm.handleClientMessages(session)
+// NewClientInfo describes the client of a WebSocket connection
+func NewClientInfo(c *gin.Context, conn *websocket.Conn) *ClientInfo {
+	clientInfo := &ClientInfo{
+		RemoteAddr:  conn.RemoteAddr().String(),
+		UserAgent:   c.Request.Header.Get("User-Agent"),
+		ConnectedAt: time.Now(),
+		Headers:     make(map[string]string),
+	}
 
-	// Wait for session to end
-	<-session.Context.Done()
-	
-	sm.logger.Info("WebSocket streaming session ended",
-		zap.String("session_id", sessionID),
-		zap.Int64("frames_sent", session.FrameCount),
-		zap.Int64("bytes_sent", session.BytesSent),
-	)
-	
-	conn.Close()
+	// Copy relevant headers
+	for key, values := range c.Request.Header {
+		if len(values) > 0 {
+			clientInfo.Headers[key] = values[0]
+		}
+	}
+	return clientInfo
 }
 
-// StartSession starts a new streaming session
+// SetClientInfo records the client currently connected to the session
+func (s *StreamSession) SetClientInfo(clientInfo *ClientInfo) {
+	s.mutex.Lock()
+	s.ClientInfo = clientInfo
+	s.mutex.Unlock()
+}
+
+// RecordingID returns the recording the session's frames are teed into, if any
+func (s *StreamSession) RecordingID() string {
+	return s.recordingID
+}
+
+// StartSession starts a new streaming session without a connection. It starts out
+// detached, so it's stopped after the resume grace period unless a client resumes it
+// with its ID and resume token; clients connecting over WebSocket use ConnectSession.
 func (sm *StreamManager) StartSession(windowID uintptr, options *types.StreamOptions) (*StreamSession, error) {
+	if sm.resumeGrace <= 0 {
+		return nil, fmt.Errorf("sessions can't start without a connection while resume is disabled")
+	}
+	return sm.startSession(windowID, options, nil, nil, nil)
+}
+
+// ConnectSession starts a new streaming session for a client's WebSocket connection. The
+// connection and client info are bound to the session as it's created, and started, the
+// message opening the stream, is written to the connection before the session captures
+// anything, so it always precedes the first frame. If it can't be written, the session
+// is stopped and the error returned.
+func (sm *StreamManager) ConnectSession(windowID uintptr, options *types.StreamOptions, conn *websocket.Conn, clientInfo *ClientInfo, started func(*StreamSession) interface{}) (*StreamSession, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("no connection for the session")
+	}
+	return sm.startSession(windowID, options, conn, clientInfo, started)
+}
+
+// startSession creates, registers and starts a session, bound to conn unless it's nil
+func (sm *StreamManager) startSession(windowID uintptr, options *types.StreamOptions, conn *websocket.Conn, clientInfo *ClientInfo, started func(*StreamSession) interface{}) (*StreamSession, error) {
 	if options == nil {
 		options = types.DefaultStreamOptions()
 	}
 
+	security, err := types.ParseStreamSecurity(string(options.Security))
+	if err != nil {
+		return nil, err
+	}
+	if security != types.StreamSecurityNone && sm.frameKey == nil {
+		return nil, fmt.Errorf("stream security %q needs a stream key, and none is configured", security)
+	}
+	transport, err := types.ParseStreamTransport(string(options.Transport))
+	if err != nil {
+		return nil, err
+	}
+	if transport != types.StreamTransportJSON && security != types.StreamSecurityNone {
+		return nil, fmt.Errorf("%s frames can't be signed or encrypted; use the json transport with security %q", transport, security)
+	}
+	if transport == types.StreamTransportShm && sm.shmDir == "" {
+		return nil, fmt.Errorf("the shm transport is disabled")
+	}
+
 	sessionID := fmt.Sprintf("stream_%d_%d", windowID, time.Now().UnixNano())
+
+	resumeToken, err := newResumeToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate resume token: %w", err)
+	}
 	
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	session := &StreamSession{
-		ID:        sessionID,
-		WindowID:  windowID,
-		Options:   options,
-		Active:    true,
-		StartTime: time.Now(),
-		StopChan:  make(chan struct{}),
-		Context:   ctx,
-		Cancel:    cancel,
+		ID:          sessionID,
+		WindowID:    windowID,
+		Options:     options,
+		Active:      true,
+		StartTime:   time.Now(),
+		StopChan:    make(chan struct{}),
+		Context:     ctx,
+		Cancel:      cancel,
+		ResumeToken: resumeToken,
+		Conn:        conn,
+		ClientInfo:  clientInfo,
+	}
+	if conn == nil {
+		session.DetachedAt = session.StartTime
+	}
+
+	// Tee the delivered frames into a recording
+	if options.Record {
+		if sm.recorder == nil {
+			cancel()
+			return nil, fmt.Errorf("recording is not available")
+		}
+		info, err := sm.recorder.StartStream(sessionID, &types.RecordingOptions{
+			WindowID: windowID,
+			FPS:      float64(options.FPS),
+			Format:   options.Format,
+			Quality:  options.Quality,
+			MaxWidth: options.MaxWidth,
+			Output:   options.RecordOutput,
+		})
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to start recording: %w", err)
+		}
+		session.recordingID = info.ID
 	}
 
-	// Store session
+	// Store session unless there are as many as allowed already, writing the opening
+	// message before anything else can be sent
+	session.writeMutex.Lock()
 	sm.sessionsMux.Lock()
+	if limit := sm.maxSessions.Load(); limit > 0 && int64(len(sm.sessions)) >= limit {
+		sm.sessionsMux.Unlock()
+		session.writeMutex.Unlock()
+		cancel()
+		if session.recordingID != "" {
+			go sm.stopRecording(session)
+		}
+		return nil, fmt.Errorf("too many streaming sessions: the limit is %d", limit)
+	}
+	if conn != nil {
+		session.writer = newConnWriter(conn, 1)
+	}
 	sm.sessions[sessionID] = session
 	sm.sessionsMux.Unlock()
 
+	if conn != nil && started != nil {
+		if err := session.writer.sendJSON(started(session)); err != nil {
+			session.writeMutex.Unlock()
+			sm.StopSession(sessionID)
+			return nil, fmt.Errorf("failed to send session started message: %w", err)
+		}
+	}
+	session.writeMutex.Unlock()
+
+	if sm.usage != nil {
+		sm.usage.RecordSession()
+	}
+
 	// Start streaming goroutine
 	go sm.streamFrames(session)
+	if conn == nil {
+		sm.expireDetached(session, session.DetachedAt)
+	}
 
 	sm.logger.Info("Streaming session started",
 		zap.String("session_id", sessionID),
@@ -244,8 +564,159 @@ func (sm *StreamManager) StartSession(windowID uintptr, options *types.StreamOpt
 	return session, nil
 }
 
+// ResumeSession re-attaches a client to a detached session using its resume token.
+// A still-attached connection is replaced, covering clients that reconnect before
+// the server has noticed the old connection dropping.
+func (sm *StreamManager) ResumeSession(sessionID, token string, conn *websocket.Conn) (*StreamSession, error) {
+	sm.sessionsMux.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.sessionsMux.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("session not found or resume window expired: %s", sessionID)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(session.ResumeToken), []byte(token)) != 1 {
+		return nil, fmt.Errorf("invalid resume token")
+	}
+
+	// Hold the write lock so the resumed message precedes any frame on the new connection
+	session.writeMutex.Lock()
+	defer session.writeMutex.Unlock()
+
+	writer := newConnWriter(conn, 1)
+	session.mutex.Lock()
+	previous := session.writer
+	session.Conn = conn
+	session.writer = writer
+	session.DetachedAt = time.Time{}
+	session.Reconnects++
+	// Frames in flight on the old connection will never be acknowledged
+	session.LastAcked = session.FrameCount
+	status := StatusMessage{
+		SessionID:    session.ID,
+		WindowID:     session.WindowID,
+		Active:       session.Active,
+		FPS:          session.Options.FPS,
+		FrameCount:   session.FrameCount,
+		BytesSent:    session.BytesSent,
+		Duration:     time.Since(session.StartTime),
+		Options:      session.Options,
+		MissedFrames: session.MissedFrames,
+		Reconnects:   session.Reconnects,
+		LastAcked: session.LastAcked,
+		Unacked: session.FrameCount - session.LastAcked,
+		ThrottledFrames: session.ThrottledFrames,
+		RecordingID: session.recordingID,
+		ResumeToken:  session.ResumeToken,
+		SharedMemory: session.sharedMemory,
+	}
+	session.mutex.Unlock()
+
+	if previous != nil {
+		previous.close(websocket.CloseAbnormalClosure, "")
+	}
+
+	sm.logger.Info("Streaming session resumed",
+		zap.String("session_id", session.ID),
+		zap.Int("reconnects", status.Reconnects),
+		zap.Int64("missed_frames", status.MissedFrames),
+	)
+
+	err := writer.sendJSON(StreamMessage{
+		Type:      "session_resumed",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      status,
+	})
+	if err != nil {
+		// Leave the session resumable by the client's next attempt
+		go sm.detachSession(session, conn)
+		return nil, fmt.Errorf("failed to send resume message: %w", err)
+	}
+
+	return session, nil
+}
+
+// detachSession keeps a session alive without a connection for the resume grace period
+func (sm *StreamManager) detachSession(session *StreamSession, conn *websocket.Conn) {
+	if sm.resumeGrace <= 0 {
+		sm.StopSession(session.ID)
+		return
+	}
+
+	session.mutex.Lock()
+	if session.Conn != conn {
+		// The session was already resumed on another connection
+		session.mutex.Unlock()
+		return
+	}
+	session.Conn = nil
+	session.writer = nil
+	detachedAt := time.Now()
+	session.DetachedAt = detachedAt
+	session.mutex.Unlock()
+
+	sm.logger.Info("Streaming session detached, awaiting resume",
+		zap.String("session_id", session.ID),
+		zap.Duration("grace_period", sm.resumeGrace),
+	)
+	sm.expireDetached(session, detachedAt)
+}
+
+// expireDetached stops a session detached at detachedAt once the resume grace period
+// is over, unless a client has resumed it by then
+func (sm *StreamManager) expireDetached(session *StreamSession, detachedAt time.Time) {
+	time.AfterFunc(sm.resumeGrace, func() {
+		session.mutex.RLock()
+		expired := session.Conn == nil && session.DetachedAt.Equal(detachedAt)
+		session.mutex.RUnlock()
+
+		if expired {
+			sm.StopSession(session.ID)
+		}
+	})
+}
+
+// Send writes a message to the session's current connection, if any
+func (s *StreamSession) Send(msg StreamMessage) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	s.mutex.RLock()
+	writer := s.writer
+	s.mutex.RUnlock()
+
+	if writer == nil {
+		return nil
+	}
+	return writer.sendJSON(msg)
+}
+
+// maxUnacked returns the ack-mode window size, defaulting to one frame in flight
+func maxUnacked(options *types.StreamOptions) int {
+	if options.MaxUnacked > 0 {
+		return options.MaxUnacked
+	}
+	return 1
+}
+
+// newResumeToken generates a random token that authorizes resuming a session
+func newResumeToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
 // StopSession stops a streaming session
 func (sm *StreamManager) StopSession(sessionID string) error {
+	return sm.stopSession(sessionID, "session ended")
+}
+
+// stopSession stops a streaming session, telling its viewers why
+func (sm *StreamManager) stopSession(sessionID, reason string) error {
 	sm.sessionsMux.Lock()
 	defer sm.sessionsMux.Unlock()
 
@@ -262,6 +733,12 @@ func (sm *StreamManager) StopSession(sessionID string) error {
 
 	// Remove from active sessions
 	delete(sm.sessions, sessionID)
+	session.closeViewers(reason)
+
+	// Finalizing a recording can take a while when it assembles a video
+	if session.recordingID != "" {
+		go sm.stopRecording(session)
+	}
 
 	sm.logger.Info("Streaming session stopped",
 		zap.String("session_id", sessionID),
@@ -271,6 +748,32 @@ func (sm *StreamManager) StopSession(sessionID string) error {
 	return nil
 }
 
+// CloseSession force-closes a session: its client gets a WebSocket close frame with the
+// policy violation code and the reason, and the session ends without a resume window
+func (sm *StreamManager) CloseSession(sessionID, reason string) error {
+	sm.sessionsMux.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.sessionsMux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	session.mutex.RLock()
+	writer := session.writer
+	session.mutex.RUnlock()
+
+	// Stop before closing the connection, so the dropped connection isn't taken for a
+	// network failure and left open to resume
+	if err := sm.stopSession(sessionID, reason); err != nil {
+		return err
+	}
+	if writer != nil {
+		writer.close(websocket.ClosePolicyViolation, reason)
+	}
+	return nil
+}
+
 // GetActiveSessions returns all active streaming sessions
 func (sm *StreamManager) GetActiveSessions() ([]*types.StreamSession, error) {
 	sm.sessionsMux.RLock()
@@ -314,7 +817,8 @@ func (sm *StreamManager) UpdateSession(sessionID string, options *types.StreamOp
 	if options.Quality > 0 {
 		session.Options.Quality = options.Quality
 	}
-	if options.Format != "" {
+	// The recording of a recorded session holds frames in one format
+	if options.Format != "" && session.recordingID == "" {
 		session.Options.Format = options.Format
 	}
 	if options.MaxWidth > 0 {
@@ -323,6 +827,20 @@ func (sm *StreamManager) UpdateSession(sessionID string, options *types.StreamOp
 	if options.MaxHeight > 0 {
 		session.Options.MaxHeight = options.MaxHeight
 	}
+	if options.AckMode && !session.Options.AckMode {
+		session.Options.AckMode = true
+		session.LastAcked = session.FrameCount
+	}
+	if options.MaxUnacked > 0 {
+		session.Options.MaxUnacked = options.MaxUnacked
+	}
+	if options.DetailInterval > 0 {
+		session.Options.DetailInterval = options.DetailInterval
+	}
+	if options.DetailQuality > 0 {
+		session.Options.DetailQuality = options.DetailQuality
+	}
+	cpuThrottle := session.cpuThrottle
 	session.mutex.Unlock()
 
 	sm.logger.Info("Streaming session updated",
@@ -332,21 +850,73 @@ func (sm *StreamManager) UpdateSession(sessionID string, options *types.StreamOp
 	)
 
 	// Send status update to client
-	if session.Conn != nil {
-		session.Conn.WriteJSON(StreamMessage{
-			Type:      "session_updated",
-			Timestamp: time.Now(),
+	session.Broadcast(StreamMessage{
+		Type:      "session_updated",
+		Timestamp: time.Now(),
+		SessionID: sessionID,
+		Data: StatusMessage{
 			SessionID: sessionID,
-			Data: StatusMessage{
-				SessionID: sessionID,
-				WindowID:  session.WindowID,
-				Active:    session.Active,
-				FPS:       session.Options.FPS,
-				Options:   session.Options,
-			},
-		})
+			WindowID:  session.WindowID,
+			Active:    session.Active,
+			FPS:       session.Options.FPS,
+			Options:   session.Options,
+			Power:     sm.powerThrottle(session.Options),
+			CPU:       cpuThrottle,
+		},
+	})
+
+	return nil
+}
+
+// SetRegion moves or resizes the region of interest a session streams, or with a nil
+// region goes back to streaming the whole window or display. Frames are cropped while
+// they're captured, so the next frame already shows the new region.
+func (sm *StreamManager) SetRegion(sessionID string, region *types.Rectangle) error {
+	sm.sessionsMux.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.sessionsMux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if region != nil && (region.Width <= 0 || region.Height <= 0) {
+		return fmt.Errorf("region width and height must be positive")
 	}
 
+	session.mutex.Lock()
+	if session.Options.CursorZoom != nil {
+		session.mutex.Unlock()
+		return fmt.Errorf("the region of a cursor zoom stream follows the cursor")
+	}
+	if region != nil && session.Options.Privacy != "" {
+		session.mutex.Unlock()
+		return fmt.Errorf("privacy streams show the whole display")
+	}
+	session.Options.Region = region
+	options := *session.Options
+	cpuThrottle := session.cpuThrottle
+	session.mutex.Unlock()
+
+	sm.logger.Info("Streaming region changed",
+		zap.String("session_id", sessionID),
+		zap.Any("region", region),
+	)
+
+	session.Broadcast(StreamMessage{
+		Type:      "session_updated",
+		Timestamp: time.Now(),
+		SessionID: sessionID,
+		Data: StatusMessage{
+			SessionID: sessionID,
+			WindowID:  session.WindowID,
+			Active:    session.Active,
+			FPS:       options.FPS,
+			Options:   &options,
+			Power:     sm.powerThrottle(&options),
+			CPU:       cpuThrottle,
+		},
+	})
+
 	return nil
 }
 
@@ -358,59 +928,364 @@ func (sm *StreamManager) streamFrames(session *StreamSession) {
 				zap.String("session_id", session.ID),
 				zap.Any("error", r),
 			)
+			sm.crashes.Capture("stream", r, map[string]string{
+				"session_id": session.ID,
+				"window_id":  fmt.Sprintf("%d", session.WindowID),
+			})
 		}
 	}()
 
+	defer sm.closeRing(session)
+
 	captureOptions := types.DefaultCaptureOptions()
 	captureOptions.AllowMinimized = true
 	captureOptions.RestoreWindow = false
+	captureOptions.Priority = types.PriorityStream
+	captureOptions.Context = session.Context
+	if session.Options.Game {
+		// Each capture waits for the game's next frame, so the stream follows its frame
+		// rate up to the requested one
+		captureOptions.PreferredMethod = types.CaptureGame
+		captureOptions.FallbackMethods = []types.CaptureMethod{types.CaptureDXGI}
+		captureOptions.SyncToPresent = true
+	}
 
 	frameDuration := time.Duration(1000/session.Options.FPS) * time.Millisecond
 	ticker := time.NewTicker(frameDuration)
 	defer ticker.Stop()
 
+	// Set while the desktop is locked or otherwise uncapturable
+	var desktopState types.DesktopState
+	// Set while the activity gate pauses the stream
+	var gated bool
+	// Caps of the power policy the stream runs under, if any
+	var throttle *types.PowerThrottle
+	// Caps the frame rate while capturing and encoding exceed the CPU budget
+	governor := budget.NewGovernor()
+	// Region around the cursor of a cursor zoom
+	var zoom cursorZoom
+
+	gdiTicker := time.NewTicker(gdiSampleInterval)
+	defer gdiTicker.Stop()
+	gdiWatch := screenshot.NewGDIGrowthWatch(gdiGrowthSamples)
+
 	for {
 		select {
 		case <-session.Context.Done():
 			return
+		case <-gdiTicker.C:
+			if growing, first, latest := gdiWatch.Sample(); growing {
+				sm.logger.Warn("GDI handle footprint keeps growing during stream",
+					zap.String("session_id", session.ID),
+					zap.Int64("from", first),
+					zap.Int64("to", latest),
+					zap.Duration("over", gdiSampleInterval*(gdiGrowthSamples-1)),
+				)
+			}
 		case <-ticker.C:
 			if !session.Active {
 				return
 			}
 
-			// Update ticker if FPS changed
 			session.mutex.RLock()
-			newFrameDuration := time.Duration(1000/session.Options.FPS) * time.Millisecond
+			currentOptions := *session.Options
+			detached := !session.DetachedAt.IsZero()
+			throttled := currentOptions.AckMode && session.FrameCount-session.LastAcked >= int64(maxUnacked(&currentOptions))
+			session.mutex.RUnlock()
+
+			// Cap the frame rate and quality on battery or in battery saver, and the
+			// frame rate further while the stream exceeds its CPU budget, telling the
+			// client whenever the caps change
+			requested := currentOptions
+			next := sm.powerThrottle(&currentOptions)
+			changed := !samePowerThrottle(next, throttle)
+			throttle = next
+			if throttle != nil {
+				currentOptions.FPS, currentOptions.Quality = throttle.FPS, throttle.Quality
+				if limit := throttle.Limits.MaxQuality; limit > 0 {
+					currentOptions.DetailQuality = min(detailQuality(&currentOptions), limit)
+				}
+			}
+			cpuBudget := int(sm.cpuBudget.Load())
+			var cpuChanged bool
+			currentOptions.FPS, cpuChanged = governor.Limit(currentOptions.FPS, cpuBudget, time.Now())
+			cpuThrottle := governor.Throttle(cpuBudget)
+			session.mutex.Lock()
+			session.cpuUsage, session.cpuThrottle = governor.Usage(), cpuThrottle
+			session.mutex.Unlock()
+			if changed || cpuChanged {
+				sm.sendThrottle(session, &requested, currentOptions.FPS, throttle, cpuThrottle)
+			}
+
+			// Update ticker if FPS changed
+			newFrameDuration := time.Duration(1000/currentOptions.FPS) * time.Millisecond
 			if newFrameDuration != frameDuration {
 				frameDuration = newFrameDuration
 				ticker.Reset(frameDuration)
 			}
-			currentOptions := *session.Options
-			session.mutex.RUnlock()
 
-			// Capture screenshot
-			buffer, err := sm.engine.CaptureByHandle(session.WindowID, captureOptions)
+			// Frames that fall due while the client is away are counted, not captured
+			if detached {
+				session.mutex.Lock()
+				session.MissedFrames++
+				session.mutex.Unlock()
+				continue
+			}
+
+			// In ack mode, hold back frames until the client catches up
+			if throttled {
+				session.mutex.Lock()
+				session.ThrottledFrames++
+				session.mutex.Unlock()
+				continue
+			}
+
+			// Skip frames while the user's activity pauses streaming, with one event
+			// on each change
+			if paused := sm.activity.Paused(); paused != gated {
+				gated = paused
+				event := "capture_resumed"
+				if paused {
+					event = "capture_paused"
+				}
+				sm.sendActivity(session, event)
+			}
+			if gated {
+				continue
+			}
+
+			// Switch to the window the user brought to the foreground
+			if currentOptions.Follow {
+				sm.followForeground(session)
+			}
+
+			// Center the region of a cursor zoom on the cursor
+			if currentOptions.CursorZoom != nil {
+				captureOptions.Region = sm.zoomRegion(session, &zoom, &currentOptions)
+				currentOptions.Region = captureOptions.Region
+			}
+
+			// Capture screenshot; the frame's stages are traced as spans
+			ctx, frameSpan := tracing.Start(context.Background(), "stream")
+			_, captureSpan := tracing.Start(ctx, "capture")
+			captureStart := time.Now()
+			var buffer *types.ScreenshotBuffer
+			var err error
+			cpu := budget.Measure(func() {
+				buffer, err = sm.captureTarget(session, captureOptions)
+			})
+			captureSpan.End()
+			if err != nil && session.Context.Err() != nil {
+				// The session stopped while the capture waited for a slot
+				frameSpan.End()
+				return
+			}
+			var unavailable *types.DesktopUnavailableError
+			if errors.As(err, &unavailable) {
+				frameSpan.End()
+				// Pause the stream with a single event instead of failing every frame
+				if unavailable.State != desktopState {
+					desktopState = unavailable.State
+					sm.sendDesktopState(session, "desktop_unavailable", desktopState)
+				}
+				continue
+			}
 			if err != nil {
+				frameSpan.End()
 				sm.logger.Warn("Failed to capture frame",
 					zap.String("session_id", session.ID),
 					zap.Error(err),
 				)
+				session.recordFailure(err)
+				governor.Add(cpu)
 				continue
 			}
 
+			if desktopState != "" {
+				desktopState = ""
+				sm.sendDesktopState(session, "desktop_available", types.DesktopAvailable)
+			}
+
+			// Hide the windows a privacy stream doesn't allow; a frame that can't be
+			// obscured isn't sent at all
+			if currentOptions.Privacy != "" {
+				cpu += budget.Measure(func() {
+					buffer, err = sm.obscureDesktop(ctx, session, buffer, &currentOptions, captureOptions)
+				})
+				if err != nil {
+					frameSpan.End()
+					sm.logger.Warn("Failed to obscure frame",
+						zap.String("session_id", session.ID),
+						zap.Error(err),
+					)
+					session.recordFailure(err)
+					governor.Add(cpu)
+					continue
+				}
+			}
+
+			// Composite the other windows of a picture-in-picture stream
+			if len(currentOptions.Insets) > 0 {
+				cpu += budget.Measure(func() {
+					buffer = sm.composeInsets(ctx, session, buffer, currentOptions.Insets, captureOptions)
+				})
+			}
+
 			// Process frame
-			if err := sm.processAndSendFrame(session, buffer, &currentOptions); err != nil {
+			timing := types.NewCaptureTiming(captureStart, time.Now())
+			cpu += budget.Measure(func() {
+				err = sm.processAndSendFrame(ctx, session, buffer, &currentOptions, timing)
+			})
+			frameSpan.End()
+			governor.Add(cpu)
+			if err != nil {
 				sm.logger.Error("Failed to process frame",
 					zap.String("session_id", session.ID),
 					zap.Error(err),
 				)
+				if !errors.Is(err, errFrameNotSent) {
+					session.recordFailure(err)
+				}
 			}
 		}
 	}
 }
 
+// followForeground switches a session with Follow to the foreground window when another
+// window has come to the foreground, telling the client with a target_changed message.
+// While no window is in the foreground, such as on the lock screen, the session keeps
+// streaming the last one.
+func (sm *StreamManager) followForeground(session *StreamSession) {
+	if sm.windows == nil {
+		return
+	}
+	handle, err := sm.windows.GetForegroundWindow()
+	if err != nil || handle == 0 {
+		return
+	}
+
+	session.mutex.Lock()
+	previous := session.WindowID
+	session.WindowID = handle
+	session.mutex.Unlock()
+	if handle == previous {
+		return
+	}
+
+	message := TargetChangedMessage{WindowID: handle}
+	if info, err := sm.windows.GetWindowInfo(handle); err == nil {
+		message.Window = info
+	}
+	sm.logger.Info("Followed foreground window",
+		zap.String("session_id", session.ID),
+		zap.Uintptr("from", previous),
+		zap.Uintptr("to", handle),
+	)
+
+	err = session.Broadcast(StreamMessage{
+		Type:      "target_changed",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      message,
+	})
+	if err != nil {
+		sm.logger.Warn("Failed to send target change",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// sendDesktopState notifies the client that the stream paused or resumed with the desktop
+func (sm *StreamManager) sendDesktopState(session *StreamSession, event string, state types.DesktopState) {
+	sm.logger.Info("Desktop state changed",
+		zap.String("session_id", session.ID),
+		zap.String("state", string(state)),
+	)
+
+	err := session.Broadcast(StreamMessage{
+		Type:      event,
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      DesktopStateMessage{State: state},
+	})
+	if err != nil {
+		sm.logger.Warn("Failed to send desktop state",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// samePowerThrottle reports whether two power throttles cap a stream the same way
+func samePowerThrottle(a, b *types.PowerThrottle) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.FPS == b.FPS && a.Quality == b.Quality
+}
+
+// sendThrottle sends a session_updated message when the power policy or CPU budget
+// starts, stops or changes capping the stream, with the frame rate streamed
+func (sm *StreamManager) sendThrottle(session *StreamSession, options *types.StreamOptions, fps int, throttle *types.PowerThrottle, cpu *types.CPUThrottle) {
+	sm.logger.Info("Stream throttle changed",
+		zap.String("session_id", session.ID),
+		zap.Int("requested_fps", options.FPS),
+		zap.Int("fps", fps),
+		zap.Bool("power", throttle != nil),
+		zap.Bool("cpu", cpu != nil),
+	)
+
+	err := session.Broadcast(StreamMessage{
+		Type:      "session_updated",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data: StatusMessage{
+			SessionID: session.ID,
+			WindowID:  session.WindowID,
+			Active:    session.Active,
+			FPS:       fps,
+			Options:   options,
+			Power:     throttle,
+			CPU:       cpu,
+		},
+	})
+	if err != nil {
+		sm.logger.Warn("Failed to send stream throttle",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// sendActivity notifies the client that the stream paused or resumed with the user's activity
+func (sm *StreamManager) sendActivity(session *StreamSession, event string) {
+	state := sm.activity.State()
+	sm.logger.Info("Stream gated by user activity",
+		zap.String("session_id", session.ID),
+		zap.String("event", event),
+		zap.String("state", state.State),
+	)
+
+	err := session.Broadcast(StreamMessage{
+		Type:      event,
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      ActivityMessage{State: state.State, Gate: state.Gate},
+	})
+	if err != nil {
+		sm.logger.Warn("Failed to send activity state",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
 // processAndSendFrame processes and sends a frame to the client
-func (sm *StreamManager) processAndSendFrame(session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions) error {
+func (sm *StreamManager) processAndSendFrame(ctx context.Context, session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions, timing *types.CaptureTiming) error {
+	processStart := time.Now()
+	_, span := tracing.Start(ctx, "process")
+
 	// Resize if needed
 	if options.MaxWidth > 0 && buffer.Width > options.MaxWidth {
 		aspectRatio := float64(buffer.Height) / float64(buffer.Width)
@@ -422,33 +1297,43 @@ func (sm *StreamManager) processAndSendFrame(session *StreamSession, buffer *typ
 		
 		resized, err := sm.processor.Resize(buffer, options.MaxWidth, newHeight)
 		if err != nil {
+			span.End()
 			return fmt.Errorf("failed to resize frame: %w", err)
 		}
 		buffer = resized
 	}
 
-	// Encode frame
-	encoded, err := sm.processor.Encode(buffer, options.Format, options.Quality)
+	buffer, err := sm.applyWatermark(buffer)
+	if err == nil && options.Overlay {
+		buffer, err = sm.drawInfoStrip(session, buffer, timing.CapturedAt)
+	}
+	span.End()
 	if err != nil {
-		return fmt.Errorf("failed to encode frame: %w", err)
+		return err
 	}
 
-	// Create data URL
-	var mimeType string
-	switch options.Format {
-	case types.FormatPNG:
-		mimeType = "image/png"
-	case types.FormatJPEG:
-		mimeType = "image/jpeg"
-	case types.FormatWebP:
-		mimeType = "image/webp"
-	default:
-		mimeType = "image/png"
+	switch options.Transport {
+	case types.StreamTransportRaw:
+		return sm.sendRawFrame(ctx, session, buffer, options, timing, processStart)
+	case types.StreamTransportShm:
+		return sm.sendSharedFrame(ctx, session, buffer, options, timing, processStart)
+	}
+
+	// Encode frame
+	encodeStart := time.Now()
+	timing.Process = encodeStart.Sub(processStart)
+	quality := frameQuality(options, session.FrameCount+1)
+	encodeCtx, span := tracing.Start(ctx, "encode")
+	encoded, err := sm.processor.EncodeContext(encodeCtx, buffer, options.Format, quality)
+	span.End()
+	if err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
 	}
+	timing.Encode = time.Since(encodeStart)
 
-	// Convert to base64
-	base64Data := base64.StdEncoding.EncodeToString(encoded)
-	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64Data)
+	session.mutex.RLock()
+	timing.Send = session.lastSend
+	session.mutex.RUnlock()
 
 	// Create frame message
 	frame := FrameMessage{
@@ -456,43 +1341,373 @@ func (sm *StreamManager) processAndSendFrame(session *StreamSession, buffer *typ
 		Width:       buffer.Width,
 		Height:      buffer.Height,
 		Format:      string(options.Format),
-		DataURL:     dataURL,
 		Size:        len(encoded),
 		Timestamp:   time.Now(),
+		AckRequired: options.AckMode,
+		Timing:      timing,
+	}
+	if options.DetailInterval > 1 {
+		frame.Quality = quality
+	}
+	if options.CursorZoom != nil {
+		frame.Region = options.Region
+	}
+	if err := sm.sealFrame(session.ID, &frame, options.Format, encoded, options.Security); err != nil {
+		return err
 	}
 
 	// Send frame to client
-	if session.Conn != nil {
-		err := session.Conn.WriteJSON(StreamMessage{
-			Type:      "frame",
-			Timestamp: time.Now(),
-			SessionID: session.ID,
-			Data:      frame,
-		})
-		
-		if err != nil {
-			return fmt.Errorf("failed to send frame: %w", err)
-		}
+	sendStart := time.Now()
+	_, span = tracing.Start(ctx, "send")
+	err = session.Broadcast(StreamMessage{
+		Type:      "frame",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      frame,
+	})
+	span.End()
+	return sm.frameSent(session, buffer, timing, frame.FrameNumber, encoded, len(encoded), time.Since(sendStart), err)
+}
+
+// defaultDetailQuality is the quality of detail frames when a stream doesn't set one
+const defaultDetailQuality = 95
+
+// detailQuality returns the quality detail frames of a quality ladder are encoded at
+func detailQuality(options *types.StreamOptions) int {
+	if options.DetailQuality > 0 {
+		return options.DetailQuality
+	}
+	return defaultDetailQuality
+}
+
+// frameQuality returns the quality to encode a frame at. With a quality ladder every
+// DetailInterval-th frame, starting with the first, is a detail frame at the detail
+// quality, and the frames between them use the stream's lower quality.
+func frameQuality(options *types.StreamOptions, frameNumber int64) int {
+	if options.DetailInterval > 1 && (frameNumber-1)%int64(options.DetailInterval) == 0 {
+		return detailQuality(options)
+	}
+	return options.Quality
+}
+
+// sendRawFrame sends a frame's pixels as a raw BGRA binary message, skipping encoding.
+// Only a recorded session's frames are encoded, for the recording.
+func (sm *StreamManager) sendRawFrame(ctx context.Context, session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions, timing *types.CaptureTiming, processStart time.Time) error {
+	packStart := time.Now()
+	timing.Process = packStart.Sub(processStart)
+	frameNumber := session.FrameCount + 1
+	_, span := tracing.Start(ctx, "encode")
+	frame, err := rawframe.Encode(rawframe.Header{
+		FrameNumber: frameNumber,
+		CapturedAt:  timing.CapturedAt,
+		AckRequired: options.AckMode,
+	}, buffer)
+	span.End()
+	if err != nil {
+		return fmt.Errorf("failed to pack raw frame: %w", err)
+	}
+	timing.Encode = time.Since(packStart)
+
+	encoded, err := sm.encodeForRecording(session, buffer, options)
+	if err != nil {
+		return err
+	}
+
+	sendStart := time.Now()
+	_, span = tracing.Start(ctx, "send")
+	err = session.BroadcastBinary(frame)
+	span.End()
+	return sm.frameSent(session, buffer, timing, frameNumber, encoded, len(frame), time.Since(sendStart), err)
+}
+
+// encodeForRecording encodes a frame sent unencoded for the session's recording, if any
+func (sm *StreamManager) encodeForRecording(session *StreamSession, buffer *types.ScreenshotBuffer, options *types.StreamOptions) ([]byte, error) {
+	if session.recordingID == "" {
+		return nil, nil
+	}
+	encoded, err := sm.processor.Encode(buffer, options.Format, options.Quality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frame for recording: %w", err)
+	}
+	return encoded, nil
+}
+
+// frameSent updates the session's stats after a frame of size bytes was sent, or
+// counts it as missed if sending failed with err, and tees the encoded frame, if any,
+// into the session's recording
+func (sm *StreamManager) frameSent(session *StreamSession, buffer *types.ScreenshotBuffer, timing *types.CaptureTiming, frameNumber int64, encoded []byte, size int, sent time.Duration, err error) error {
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errFrameNotSent, err)
+		session.mutex.Lock()
+		session.MissedFrames++
+		session.lastError, session.lastErrorAt = err.Error(), time.Now()
+		session.mutex.Unlock()
+		return err
 	}
 
 	// Update session stats
+	now := time.Now()
 	session.mutex.Lock()
 	session.FrameCount++
+	session.BytesSent += int64(size)
+	session.frameBytes += int64(size)
+	session.LastFrame = now
+	session.lastSend = sent
+	session.windowTitle = buffer.WindowInfo.Title
+	session.recentFrames = append(trimFrameTimes(session.recentFrames, now), now)
+	session.mutex.Unlock()
+	if sm.usage != nil {
+		sm.usage.RecordFrame(size)
+	}
+
+	if session.recordingID != "" {
+		if err := sm.recorder.WriteFrame(session.recordingID, encoded, buffer.Width, buffer.Height, frameNumber, timing.CapturedAt); err != nil {
+			sm.logger.Warn("Failed to record stream frame",
+				zap.String("session_id", session.ID),
+				zap.String("recording_id", session.recordingID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// stopRecording stops the recording a session's frames are teed into
+func (sm *StreamManager) stopRecording(session *StreamSession) {
+	info, err := sm.recorder.Stop(session.recordingID)
+	if err != nil {
+		sm.logger.Warn("Failed to stop stream recording",
+			zap.String("session_id", session.ID),
+			zap.String("recording_id", session.recordingID),
+			zap.Error(err),
+		)
+		return
+	}
+	sm.logger.Info("Stream recording finished",
+		zap.String("session_id", session.ID),
+		zap.String("recording_id", info.ID),
+		zap.String("status", info.Status),
+		zap.Int64("frames", info.FrameCount),
+	)
+}
+
+// recordFailure counts a frame that could not be captured or encoded
+func (s *StreamSession) recordFailure(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failedFrames++
+	s.lastError, s.lastErrorAt = err.Error(), time.Now()
+}
+
+// achievedFPS returns the frame rate delivered over the last fpsWindow. The caller holds
+// the session's mutex.
+func (s *StreamSession) achievedFPS(now time.Time) float64 {
+	s.recentFrames = trimFrameTimes(s.recentFrames, now)
+	window := min(now.Sub(s.StartTime), fpsWindow)
+	if window <= 0 {
+		return 0
+	}
+	return math.Round(float64(len(s.recentFrames))/window.Seconds()*10) / 10
+}
+
+// drawInfoStrip burns when a frame was captured, what it shows, the session and the frame
+// rate it achieves into the bottom of a frame of a session with Overlay
+func (sm *StreamManager) drawInfoStrip(session *StreamSession, buffer *types.ScreenshotBuffer, capturedAt time.Time) (*types.ScreenshotBuffer, error) {
+	session.mutex.Lock()
+	fps := session.achievedFPS(time.Now())
+	session.mutex.Unlock()
+
+	source := buffer.WindowInfo.Title
+	if monitor := session.Options.Monitor; monitor != nil {
+		source = fmt.Sprintf("Monitor %d", *monitor)
+	}
+	fields := []string{capturedAt.Format("2006-01-02 15:04:05.000")}
+	if source != "" {
+		fields = append(fields, source)
+	}
+	fields = append(fields, session.ID, fmt.Sprintf("%.1f fps", fps))
+
+	drawn, err := screenshot.InfoStrip(buffer, fields...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw overlay: %w", err)
+	}
+	return drawn, nil
+}
+
+// trimFrameTimes drops delivery times that fell out of the frame rate window
+func trimFrameTimes(times []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-fpsWindow)
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return append(times[:0], times[i:]...)
+}
+
+// applyWatermark applies the watermark, if any, to a frame
+func (sm *StreamManager) applyWatermark(buffer *types.ScreenshotBuffer) (*types.ScreenshotBuffer, error) {
+	if sm.watermark == nil {
+		return buffer, nil
+	}
+	watermarked, err := sm.watermark.Process(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watermark frame: %w", err)
+	}
+	return watermarked, nil
+}
+
+// captureTarget captures the session's display for monitor targets, otherwise its window,
+// cropped to the session's region of interest if it has one
+func (sm *StreamManager) captureTarget(session *StreamSession, options *types.CaptureOptions) (*types.ScreenshotBuffer, error) {
+	session.mutex.RLock()
+	region := session.Options.Region
+	windowID := session.WindowID
+	session.mutex.RUnlock()
+	if region != nil {
+		cropped := *options
+		cropped.Region = region
+		options = &cropped
+	}
+	if monitor := session.Options.Monitor; monitor != nil {
+		return sm.engine.CaptureFullScreen(*monitor, options)
+	}
+	return sm.engine.CaptureByHandle(windowID, options)
+}
+
+// sendKeyframe captures and sends a full-quality PNG immediately, outside the FPS cadence.
+// Keyframes don't advance the frame counter or count against the ack window.
+func (sm *StreamManager) sendKeyframe(session *StreamSession) error {
+	captureOptions := types.DefaultCaptureOptions()
+	captureOptions.AllowMinimized = true
+	captureOptions.RestoreWindow = false
+	captureOptions.Context = session.Context
+
+	captureStart := time.Now()
+	buffer, err := sm.captureTarget(session, captureOptions)
+	if err != nil {
+		return fmt.Errorf("failed to capture keyframe: %w", err)
+	}
+	timing := types.NewCaptureTiming(captureStart, time.Now())
+	if buffer, err = sm.applyWatermark(buffer); err != nil {
+		return err
+	}
+
+	encodeStart := time.Now()
+	timing.Process = encodeStart.Sub(timing.CapturedAt)
+	encoded, err := sm.processor.Encode(buffer, types.FormatPNG, 100)
+	if err != nil {
+		return fmt.Errorf("failed to encode keyframe: %w", err)
+	}
+	timing.Encode = time.Since(encodeStart)
+
+	session.mutex.RLock()
+	lastFrame := session.FrameCount
+	timing.Send = session.lastSend
+	security := session.Options.Security
+	session.mutex.RUnlock()
+
+	keyframe := FrameMessage{
+		FrameNumber: lastFrame, // Most recent regular frame, for correlation
+		Width:       buffer.Width,
+		Height:      buffer.Height,
+		Format:      string(types.FormatPNG),
+		Size:        len(encoded),
+		Timestamp:   time.Now(),
+		Keyframe:    true,
+		Timing:      timing,
+	}
+	if err := sm.sealFrame(session.ID, &keyframe, types.FormatPNG, encoded, security); err != nil {
+		return err
+	}
+
+	err = session.Broadcast(StreamMessage{
+		Type:      "keyframe",
+		Timestamp: time.Now(),
+		SessionID: session.ID,
+		Data:      keyframe,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send keyframe: %w", err)
+	}
+
+	session.mutex.Lock()
 	session.BytesSent += int64(len(encoded))
-	session.LastFrame = time.Now()
 	session.mutex.Unlock()
 
 	return nil
 }
 
-// handleClientMessages handles incoming WebSocket messages from clients
+// sealFrame sets the image of a frame message: a data URL, signed at the sign security
+// level, or at the encrypt level an encrypted and signed payload
+func (sm *StreamManager) sealFrame(sessionID string, frame *FrameMessage, format types.ImageFormat, encoded []byte, security types.StreamSecurity) error {
+	if security == "" || security == types.StreamSecurityNone {
+		frame.DataURL = encodeDataURL(format, encoded)
+		return nil
+	}
+
+	header := framecrypt.Header{
+		SessionID:   sessionID,
+		FrameNumber: frame.FrameNumber,
+		Keyframe:    frame.Keyframe,
+		Width:       frame.Width,
+		Height:      frame.Height,
+		Format:      frame.Format,
+		MonotonicNs: frame.Timing.MonotonicNs,
+	}
+	payload := encoded
+	if security == types.StreamSecurityEncrypt {
+		sealed, err := sm.frameKey.Encrypt(header, encoded)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt frame: %w", err)
+		}
+		payload = sealed
+		frame.Payload = base64.StdEncoding.EncodeToString(sealed)
+		frame.Encrypted = true
+	} else {
+		frame.DataURL = encodeDataURL(format, encoded)
+	}
+	frame.Signature = sm.frameKey.Sign(header, payload)
+	return nil
+}
+
+// encodeDataURL wraps encoded image data in a base64 data URL
+func encodeDataURL(format types.ImageFormat, encoded []byte) string {
+	var mimeType string
+	switch format {
+	case types.FormatPNG:
+		mimeType = "image/png"
+	case types.FormatJPEG:
+		mimeType = "image/jpeg"
+	case types.FormatWebP:
+		mimeType = "image/webp"
+	default:
+		mimeType = "image/png"
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(encoded))
+}
+
+// handleClientMessages handles incoming WebSocket messages from the session's
+// current connection until it drops or the session ends
 func (sm *StreamManager) handleClientMessages(session *StreamSession) {
+	session.mutex.RLock()
+	conn, writer := session.Conn, session.writer
+	session.mutex.RUnlock()
+
+	if conn == nil {
+		return
+	}
+	defer writer.close(websocket.CloseAbnormalClosure, "")
+	keepAlive(conn)
+
 	defer func() {
 		if r := recover(); r != nil {
 			sm.logger.Error("Client message handler panicked",
 				zap.String("session_id", session.ID),
 				zap.Any("error", r),
 			)
+			sm.crashes.Capture("stream_control", r, map[string]string{"session_id": session.ID})
 		}
 	}()
 
@@ -502,7 +1717,7 @@ func (sm *StreamManager) handleClientMessages(session *StreamSession) {
 			return
 		default:
 			var msg ControlMessage
-			err := session.Conn.ReadJSON(&msg)
+			err := conn.ReadJSON(&msg)
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					sm.logger.Error("WebSocket error",
@@ -510,10 +1725,15 @@ func (sm *StreamManager) handleClientMessages(session *StreamSession) {
 						zap.Error(err),
 					)
 				}
-				// Stop session on connection error
-				sm.StopSession(session.ID)
+				// A normal close from the client ends the session; anything else may be resumed
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) || session.Context.Err() != nil {
+					sm.StopSession(session.ID)
+				} else {
+					sm.detachSession(session, conn)
+				}
 				return
 			}
+			conn.SetReadDeadline(time.Now().Add(pongWait))
 
 			sm.handleControlMessage(session, &msg)
 		}
@@ -527,7 +1747,7 @@ func (sm *StreamManager) handleControlMessage(session *StreamSession, msg *Contr
 		if msg.Options != nil {
 			err := sm.UpdateSession(session.ID, msg.Options)
 			if err != nil {
-				session.Conn.WriteJSON(StreamMessage{
+				session.Send(StreamMessage{
 					Type:      "error",
 					Timestamp: time.Now(),
 					SessionID: session.ID,
@@ -547,21 +1767,63 @@ func (sm *StreamManager) handleControlMessage(session *StreamSession, msg *Contr
 			BytesSent:  session.BytesSent,
 			Duration:   time.Since(session.StartTime),
 			Options:    session.Options,
+			MissedFrames: session.MissedFrames,
+			Reconnects: session.Reconnects,
+			LastAcked: session.LastAcked,
+			Unacked: session.FrameCount - session.LastAcked,
+			ThrottledFrames: session.ThrottledFrames,
+			RecordingID: session.recordingID,
 		}
 		session.mutex.RUnlock()
 		
-		session.Conn.WriteJSON(StreamMessage{
+		session.Send(StreamMessage{
 			Type:      "status",
 			Timestamp: time.Now(),
 			SessionID: session.ID,
 			Data:      status,
 		})
 		
+	case "ack":
+		session.mutex.Lock()
+		acked := msg.FrameNumber
+		if acked <= 0 || acked > session.FrameCount {
+			acked = session.FrameCount
+		}
+		// Acknowledgements are cumulative; stale acks never move the window back
+		if acked > session.LastAcked {
+			session.LastAcked = acked
+		}
+		session.mutex.Unlock()
+
+	case "capture_now":
+		if err := sm.sendKeyframe(session); err != nil {
+			sm.logger.Warn("Failed to send keyframe",
+				zap.String("session_id", session.ID),
+				zap.Error(err),
+			)
+			session.Send(StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				SessionID: session.ID,
+				Error:     err.Error(),
+			})
+		}
+
+	case "set_region":
+		if err := sm.SetRegion(session.ID, msg.Region); err != nil {
+			session.Send(StreamMessage{
+				Type:      "error",
+				Timestamp: time.Now(),
+				SessionID: session.ID,
+				Error:     err.Error(),
+			})
+		}
+
 	case "stop":
 		sm.StopSession(session.ID)
 		
 	default:
-		session.Conn.WriteJSON(StreamMessage{
+		session.Send(StreamMessage{
 			Type:      "error",
 			Timestamp: time.Now(),
 			SessionID: session.ID,
@@ -592,20 +1854,46 @@ func (sm *StreamManager) GetSessionStats(sessionID string) (*StatusMessage, erro
 		BytesSent:  session.BytesSent,
 		Duration:   time.Since(session.StartTime),
 		Options:    session.Options,
+		MissedFrames: session.MissedFrames,
+		Reconnects: session.Reconnects,
+		LastAcked: session.LastAcked,
+		Unacked: session.FrameCount - session.LastAcked,
+		ThrottledFrames: session.ThrottledFrames,
+		RecordingID: session.recordingID,
 	}, nil
 }
 
+// GetSessionDetail returns the statistics entry of one session
+func (sm *StreamManager) GetSessionDetail(sessionID string) (*types.StreamSessionStats, error) {
+	sm.sessionsMux.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.sessionsMux.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	stats := session.stats()
+	return &stats, nil
+}
+
 // Cleanup stops all sessions and cleans up resources
 func (sm *StreamManager) Cleanup() {
 	sm.sessionsMux.Lock()
 	defer sm.sessionsMux.Unlock()
 
 	for sessionID, session := range sm.sessions {
+		session.mutex.Lock()
 		session.Active = false
 		session.Cancel()
-		if session.Conn != nil {
-			session.Conn.Close()
+		writer := session.writer
+		session.mutex.Unlock()
+		if writer != nil {
+			writer.close(websocket.CloseAbnormalClosure, "")
 		}
+		if session.recordingID != "" {
+			go sm.stopRecording(session)
+		}
+		session.closeViewers("server shutting down")
 		delete(sm.sessions, sessionID)
 	}
 
@@ -628,12 +1916,70 @@ func (sm *StreamManager) GetStats() *StreamStats {
 		session.mutex.RUnlock()
 	}
 
+	sessions := make([]types.StreamSessionStats, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session.stats())
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
 	return &StreamStats{
 		ActiveSessions: activeCount,
 		TotalSessions:  len(sm.sessions),
 		TotalFrames:    totalFrames,
-		Uptime:         time.Since(time.Now()), // This should be set when manager starts
+		Uptime:         time.Since(sm.startTime),
+		Sessions:       sessions,
+	}
+}
+
+// stats returns the session's detail for the streaming statistics
+func (s *StreamSession) stats() types.StreamSessionStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := types.StreamSessionStats{
+		ID:              s.ID,
+		WindowID:        s.WindowID,
+		WindowTitle:     s.windowTitle,
+		Monitor:         s.Options.Monitor,
+		Connected:       s.Conn != nil,
+		Reconnects:      s.Reconnects,
+		Format:          s.Options.Format,
+		Quality:         s.Options.Quality,
+		RequestedFPS:    s.Options.FPS,
+		FrameCount:      s.FrameCount,
+		BytesSent:       s.BytesSent,
+		DroppedFrames:   s.MissedFrames + s.ThrottledFrames + s.failedFrames,
+		MissedFrames:    s.MissedFrames,
+		ThrottledFrames: s.ThrottledFrames,
+		FailedFrames:    s.failedFrames,
+		LastError:       s.lastError,
+		StartTime:       s.StartTime,
+		RecordingID:     s.recordingID,
+		Viewers:         len(s.viewers),
+		CPUUsage:        s.cpuUsage,
+		CPUThrottle:     s.cpuThrottle,
+		AchievedFPS:     s.achievedFPS(time.Now()),
+	}
+	if s.ClientInfo != nil {
+		stats.ClientAddr = s.ClientInfo.RemoteAddr
+		stats.UserAgent = s.ClientInfo.UserAgent
+		connectedAt := s.ClientInfo.ConnectedAt
+		stats.ConnectedAt = &connectedAt
+	}
+	if s.FrameCount > 0 {
+		stats.AverageFrameSize = s.frameBytes / s.FrameCount
+	}
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		stats.LastErrorAt = &lastErrorAt
+	}
+	if !s.LastFrame.IsZero() {
+		lastFrame := s.LastFrame
+		stats.LastFrame = &lastFrame
 	}
+	return stats
 }
 
 // StreamStats contains overall streaming statistics
@@ -641,7 +1987,8 @@ type StreamStats struct {
 	ActiveSessions int           `json:"active_sessions"`
 	TotalSessions  int           `json:"total_sessions"`
 	TotalFrames    int64         `json:"total_frames"`
-	Uptime         time.Duration `json:"uptime"`
+	Uptime         time.Duration `json:"uptime"` // Since the stream manager was created
+	Sessions       []types.StreamSessionStats `json:"sessions"`
 }
 
 // SetEngine sets the screenshot engine (public method)
@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/internal/screenshot"
+	"github.com/screenshot-mcp-server/internal/window"
+	"github.com/screenshot-mcp-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// notepad is the handle of the fake desktop's Notepad window
+const notepad = 0x10010
+
+func newTestManager() *StreamManager {
+	sm := NewStreamManager(zap.NewNop())
+	windows := window.NewFakeManager(window.FakeWindows())
+	sm.SetEngine(screenshot.NewFakeEngine(windows))
+	sm.SetWindowManager(windows)
+	return sm
+}
+
+func sessionCount(sm *StreamManager) int {
+	sm.sessionsMux.RLock()
+	defer sm.sessionsMux.RUnlock()
+	return len(sm.sessions)
+}
+
+func TestHandleWebSocketBindsConnection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sm := newTestManager()
+	defer sm.Cleanup()
+
+	router := gin.New()
+	router.GET("/stream/:windowId", sm.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	header := http.Header{"User-Agent": {"streamer-test"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/65552", header)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var started StreamMessage
+	require.NoError(t, conn.ReadJSON(&started))
+	require.Equal(t, "session_started", started.Type)
+	require.NotEmpty(t, started.SessionID)
+
+	// The session the client was told about is the one streaming to it
+	sm.sessionsMux.RLock()
+	session := sm.sessions[started.SessionID]
+	sm.sessionsMux.RUnlock()
+	require.NotNil(t, session)
+	session.mutex.RLock()
+	assert.NotNil(t, session.Conn)
+	assert.True(t, session.DetachedAt.IsZero())
+	require.NotNil(t, session.ClientInfo)
+	assert.Equal(t, "streamer-test", session.ClientInfo.UserAgent)
+	session.mutex.RUnlock()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg StreamMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		if msg.Type == "frame" {
+			assert.Equal(t, started.SessionID, msg.SessionID)
+			break
+		}
+	}
+	assert.Equal(t, 1, sessionCount(sm))
+}
+
+func TestConnectSessionStopsWhenStartedFails(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+
+	result := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := sm.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			result <- err
+			return
+		}
+		conn.Close()
+		_, err = sm.ConnectSession(notepad, nil, conn, &ClientInfo{}, func(session *StreamSession) interface{} {
+			return StreamMessage{Type: "session_started", SessionID: session.ID}
+		})
+		result <- err
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Error(t, <-result)
+	assert.Equal(t, 0, sessionCount(sm))
+}
+
+func TestConnectSessionRequiresConnection(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+
+	_, err := sm.ConnectSession(notepad, nil, nil, nil, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, sessionCount(sm))
+}
+
+func TestStartSessionWithoutConnectionExpires(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+	sm.SetResumeGracePeriod(50 * time.Millisecond)
+
+	session, err := sm.StartSession(notepad, types.DefaultStreamOptions())
+	require.NoError(t, err)
+	session.mutex.RLock()
+	assert.False(t, session.DetachedAt.IsZero())
+	session.mutex.RUnlock()
+
+	assert.Eventually(t, func() bool { return sessionCount(sm) == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStartSessionWithoutResume(t *testing.T) {
+	sm := newTestManager()
+	defer sm.Cleanup()
+	sm.SetResumeGracePeriod(0)
+
+	_, err := sm.StartSession(notepad, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, sessionCount(sm))
+}
@@ -0,0 +1,154 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Keepalive and write timing of stream connections
+const (
+	writeWait  = 10 * time.Second  // Longest a single message may take to write
+	pongWait   = 60 * time.Second  // Longest a client may go without answering a ping
+	pingPeriod = pongWait * 9 / 10 // How often clients are pinged, within pongWait
+)
+
+// errWriterClosed is returned for messages sent after a connection's writer stopped
+var errWriterClosed = errors.New("connection closed")
+
+// outbound is a message queued for a connection's writer, with where to report the
+// outcome of writing it, if anywhere
+type outbound struct {
+	message *websocket.PreparedMessage
+	result  chan error
+}
+
+// connWriter is the one goroutine writing to a WebSocket connection, which gorilla/websocket
+// doesn't allow more than one of. Frames, status and error messages are queued to it in
+// order; between them it pings the client, and every write has a deadline, so a client that
+// stops reading or answering is disconnected instead of holding up its session.
+type connWriter struct {
+	conn      *websocket.Conn
+	queue     chan outbound
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newConnWriter starts the writer of a connection, queueing up to size messages
+func newConnWriter(conn *websocket.Conn, size int) *connWriter {
+	w := &connWriter{
+		conn:  conn,
+		queue: make(chan outbound, size),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// run writes queued messages and pings until the writer is closed or a write fails
+func (w *connWriter) run() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case out := <-w.queue:
+			w.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err := w.conn.WritePreparedMessage(out.message)
+			if out.result != nil {
+				out.result <- err
+			}
+			if err != nil {
+				w.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				w.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+// send queues a message and waits until it's written
+func (w *connWriter) send(message *websocket.PreparedMessage) error {
+	out := outbound{message: message, result: make(chan error, 1)}
+	select {
+	case w.queue <- out:
+	case <-w.done:
+		return errWriterClosed
+	}
+	select {
+	case err := <-out.result:
+		return err
+	case <-w.done:
+		// The message may have been written just before the writer stopped
+		select {
+		case err := <-out.result:
+			return err
+		default:
+			return errWriterClosed
+		}
+	}
+}
+
+// sendJSON queues a message serialized as JSON and waits until it's written
+func (w *connWriter) sendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	message, err := websocket.NewPreparedMessage(websocket.TextMessage, data)
+	if err != nil {
+		return err
+	}
+	return w.send(message)
+}
+
+// trySend queues a message without waiting, reporting false if the queue is full or the
+// writer has stopped
+func (w *connWriter) trySend(message *websocket.PreparedMessage) bool {
+	select {
+	case <-w.done:
+		return false
+	default:
+	}
+	select {
+	case w.queue <- outbound{message: message}:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the writer and closes the connection, sending a close frame first unless
+// code is CloseAbnormalClosure
+func (w *connWriter) close(code int, reason string) {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if code != websocket.CloseAbnormalClosure {
+			// Control frames carry at most 125 bytes, two of them the close code
+			if len(reason) > 123 {
+				reason = reason[:123]
+			}
+			message := websocket.FormatCloseMessage(code, reason)
+			w.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		}
+		w.conn.Close()
+	})
+}
+
+// keepAlive makes reads from a connection fail once the client has gone pongWait without
+// answering a ping or sending anything, so dead connections are noticed
+func keepAlive(conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+}
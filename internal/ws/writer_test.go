@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWriter connects to a server whose end of the connection is written by a connWriter
+func dialWriter(t *testing.T, size int) (*connWriter, *websocket.Conn) {
+	writers := make(chan *connWriter, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		writers <- newConnWriter(conn, size)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return <-writers, conn
+}
+
+func TestConnWriterSerializesConcurrentSends(t *testing.T) {
+	writer, conn := dialWriter(t, 1)
+	defer writer.close(websocket.CloseAbnormalClosure, "")
+
+	const senders, messages = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < messages; j++ {
+				assert.NoError(t, writer.sendJSON(StreamMessage{Type: "status", SessionID: strings.Repeat("x", 4096)}))
+			}
+		}()
+	}
+
+	for i := 0; i < senders*messages; i++ {
+		var msg StreamMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		assert.Equal(t, "status", msg.Type)
+		assert.Len(t, msg.SessionID, 4096)
+	}
+	wg.Wait()
+}
+
+func TestConnWriterClose(t *testing.T) {
+	writer, conn := dialWriter(t, 1)
+	writer.close(websocket.ClosePolicyViolation, "closed by an administrator")
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+	assert.ErrorIs(t, writer.sendJSON(StreamMessage{Type: "status"}), errWriterClosed)
+
+	message, err := websocket.NewPreparedMessage(websocket.TextMessage, []byte("{}"))
+	require.NoError(t, err)
+	assert.False(t, writer.trySend(message))
+}
@@ -0,0 +1,72 @@
+package x11
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Xauthority address families
+const (
+	familyLocal = 256
+	familyWild  = 65535
+)
+
+// readAuthority returns the MIT-MAGIC-COOKIE-1 entry of the Xauthority file for a
+// display, or empty values if there is none and the server must allow the connection
+// by other means
+func readAuthority(host, number string) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	if host == "" || host == "unix" || filepath.IsAbs(host) {
+		host, _ = os.Hostname()
+	}
+
+	for {
+		var family uint16
+		if err := binary.Read(file, binary.BigEndian, &family); err != nil {
+			return "", nil
+		}
+		address, err1 := readCounted(file)
+		display, err2 := readCounted(file)
+		name, err3 := readCounted(file)
+		data, err4 := readCounted(file)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return "", nil
+		}
+
+		if family != familyWild && (family != familyLocal || string(address) != host) {
+			continue
+		}
+		if len(display) > 0 && string(display) != number {
+			continue
+		}
+		if string(name) == "MIT-MAGIC-COOKIE-1" {
+			return string(name), data
+		}
+	}
+}
+
+// readCounted reads a big-endian length-prefixed string of an Xauthority entry
+func readCounted(r io.Reader) ([]byte, error) {
+	var n uint16
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
@@ -0,0 +1,296 @@
+// Package x11 is a minimal client for the X11 wire protocol, covering the requests the
+// Linux capture backend needs: window tree and property queries, GetImage, and the
+// Composite extension for capturing obscured windows. It talks to the server directly
+// over its socket, so it needs neither cgo nor Xlib.
+package x11
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Window, Pixmap and Atom are X resource IDs
+type (
+	Window uint32
+	Pixmap uint32
+	Atom   uint32
+)
+
+// Conn is a connection to an X server. Requests are serialized, so a Conn may be
+// shared by goroutines.
+type Conn struct {
+	mu        sync.Mutex
+	conn      net.Conn
+	seq       uint16
+	idBase    uint32
+	idMask    uint32
+	idNext    uint32
+	byteOrder byte // Image byte order: 0 LSBFirst, 1 MSBFirst
+	formats   map[byte]byte
+	screen    Screen
+	atoms     map[string]Atom
+	ext       map[string]*extension
+}
+
+// Screen describes the root window of the connection's screen
+type Screen struct {
+	Root   Window
+	Width  int
+	Height int
+	Depth  byte
+}
+
+// extension is a queried protocol extension; major is 0 if the server lacks it
+type extension struct {
+	major byte
+	ready bool // Version negotiated
+}
+
+// Default returns the connection to the display named by $DISPLAY, dialing it once
+var Default = sync.OnceValues(func() (*Conn, error) {
+	return Dial(os.Getenv("DISPLAY"))
+})
+
+// Dial connects to an X display named like "host:display.screen", ":0" or, as XQuartz
+// sets it, "/path/to/socket:0"
+func Dial(display string) (*Conn, error) {
+	if display == "" {
+		return nil, errors.New("DISPLAY is not set")
+	}
+
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return nil, fmt.Errorf("invalid display %q", display)
+	}
+	host, number := display[:colon], display[colon+1:]
+	screen := 0
+	if dot := strings.Index(number, "."); dot >= 0 {
+		screen, _ = strconv.Atoi(number[dot+1:])
+		number = number[:dot]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return nil, fmt.Errorf("invalid display %q", display)
+	}
+
+	var conn net.Conn
+	var err error
+	switch {
+	case strings.HasPrefix(host, "/"):
+		conn, err = net.Dial("unix", host+":"+number)
+	case host == "" || host == "unix":
+		conn, err = net.Dial("unix", "/tmp/.X11-unix/X"+number)
+	default:
+		port, _ := strconv.Atoi(number)
+		conn, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(6000+port)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to display %s: %w", display, err)
+	}
+
+	c := &Conn{
+		conn:    conn,
+		formats: make(map[byte]byte),
+		atoms:   make(map[string]Atom),
+		ext:     make(map[string]*extension),
+	}
+	if err := c.setup(host, number, screen); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to display %s: %w", display, err)
+	}
+	return c, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Screen returns the connection's screen
+func (c *Conn) Screen() Screen {
+	return c.screen
+}
+
+// setup performs the connection handshake and reads the server's screens and formats
+func (c *Conn) setup(host, number string, screen int) error {
+	authName, authData := readAuthority(host, number)
+
+	req := make([]byte, 12, 12+pad(len(authName))+pad(len(authData)))
+	req[0] = 'l' // Little-endian
+	put16(req[2:], 11)
+	put16(req[6:], uint16(len(authName)))
+	put16(req[8:], uint16(len(authData)))
+	req = appendPadded(req, []byte(authName))
+	req = appendPadded(req, authData)
+	if _, err := c.conn.Write(req); err != nil {
+		return err
+	}
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, head); err != nil {
+		return err
+	}
+	data := make([]byte, int(get16(head[6:]))*4)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return err
+	}
+	switch head[0] {
+	case 0:
+		return fmt.Errorf("server refused connection: %s", data[:min(int(head[1]), len(data))])
+	case 2:
+		return fmt.Errorf("server requires further authentication: %s", strings.TrimRight(string(data), "\x00"))
+	}
+	if len(data) < 32 {
+		return errors.New("truncated connection setup")
+	}
+
+	c.idBase, c.idMask = get32(data[4:]), get32(data[8:])
+	vendorLen := int(get16(data[16:]))
+	screens, formats := int(data[20]), int(data[21])
+	c.byteOrder = data[22]
+
+	offset := 32 + pad(vendorLen)
+	for i := 0; i < formats && offset+8 <= len(data); i++ {
+		c.formats[data[offset]] = data[offset+1] // Depth -> bits per pixel
+		offset += 8
+	}
+
+	for i := 0; i < screens && offset+40 <= len(data); i++ {
+		if i == screen {
+			c.screen = Screen{
+				Root:   Window(get32(data[offset:])),
+				Width:  int(get16(data[offset+20:])),
+				Height: int(get16(data[offset+22:])),
+				Depth:  data[offset+38],
+			}
+			return nil
+		}
+		depths := int(data[offset+39])
+		offset += 40
+		for d := 0; d < depths && offset+8 <= len(data); d++ {
+			visuals := int(get16(data[offset+2:]))
+			offset += 8 + visuals*24
+		}
+	}
+	return fmt.Errorf("screen %d does not exist", screen)
+}
+
+// newID allocates a resource ID
+func (c *Conn) newID() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idNext++
+	return c.idBase | (c.idNext*(c.idMask&-c.idMask))&c.idMask
+}
+
+// Error is an error reply from the X server
+type Error struct {
+	Code     byte
+	Major    byte
+	Minor    uint16
+	BadValue uint32
+}
+
+var errorNames = map[byte]string{
+	1: "BadRequest", 2: "BadValue", 3: "BadWindow", 4: "BadPixmap", 5: "BadAtom",
+	8: "BadMatch", 9: "BadDrawable", 10: "BadAccess", 11: "BadAlloc", 16: "BadLength",
+	17: "BadImplementation",
+}
+
+func (e *Error) Error() string {
+	name := errorNames[e.Code]
+	if name == "" {
+		name = fmt.Sprintf("error %d", e.Code)
+	}
+	return fmt.Sprintf("X11 %s (request %d.%d, value 0x%x)", name, e.Major, e.Minor, e.BadValue)
+}
+
+// request sends a request and, if reply is set, returns its reply. A request without
+// a reply is followed by GetInputFocus so errors it causes are reported here.
+func (c *Conn) request(opcode, data byte, body []byte, reply bool) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := make([]byte, 4, 4+pad(len(body)))
+	msg[0], msg[1] = opcode, data
+	msg = appendPadded(msg, body)
+	put16(msg[2:], uint16(len(msg)/4))
+	if !reply {
+		msg = append(msg, 43, 0, 1, 0) // GetInputFocus
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	seq := c.seq + 1
+	c.seq = seq
+	if !reply {
+		c.seq++
+	}
+
+	for {
+		head := make([]byte, 32)
+		if _, err := io.ReadFull(c.conn, head); err != nil {
+			return nil, err
+		}
+		switch head[0] {
+		case 0: // Error
+			if get16(head[2:]) == seq {
+				if !reply {
+					c.discardReply(seq + 1)
+				}
+				return nil, &Error{Code: head[1], BadValue: get32(head[4:]), Minor: get16(head[8:]), Major: head[10]}
+			}
+		case 1: // Reply
+			extra := make([]byte, int(get32(head[4:]))*4)
+			if _, err := io.ReadFull(c.conn, extra); err != nil {
+				return nil, err
+			}
+			if get16(head[2:]) == c.seq {
+				if !reply {
+					return nil, nil
+				}
+				return append(head, extra...), nil
+			}
+		}
+		// Anything else is an event or a stale reply; no events are selected
+	}
+}
+
+// discardReply reads up to the reply of the sync request that follows a failed one
+func (c *Conn) discardReply(seq uint16) {
+	for {
+		head := make([]byte, 32)
+		if _, err := io.ReadFull(c.conn, head); err != nil {
+			return
+		}
+		if head[0] == 1 {
+			io.CopyN(io.Discard, c.conn, int64(get32(head[4:]))*4)
+			if get16(head[2:]) == seq {
+				return
+			}
+		}
+	}
+}
+
+// pad rounds n up to a multiple of four
+func pad(n int) int {
+	return (n + 3) &^ 3
+}
+
+// appendPadded appends b and zero bytes up to a multiple of four
+func appendPadded(buf, b []byte) []byte {
+	buf = append(buf, b...)
+	return append(buf, make([]byte, pad(len(b))-len(b))...)
+}
+
+func put16(b []byte, v uint16) { binary.LittleEndian.PutUint16(b, v) }
+func put32(b []byte, v uint32) { binary.LittleEndian.PutUint32(b, v) }
+func get16(b []byte) uint16    { return binary.LittleEndian.Uint16(b) }
+func get32(b []byte) uint32    { return binary.LittleEndian.Uint32(b) }
@@ -0,0 +1,95 @@
+package x11
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoExtension is returned when the server lacks a protocol extension
+var ErrNoExtension = errors.New("X server lacks the extension")
+
+// Composite extension requests
+const (
+	compositeQueryVersion       = 0
+	compositeRedirectSubwindows = 2
+	compositeNameWindowPixmap   = 6
+)
+
+// MIT-SCREEN-SAVER extension requests
+const (
+	screenSaverQueryInfo = 1
+)
+
+// RedirectSubwindows asks the Composite extension to keep the contents of every
+// top-level window in an off-screen pixmap, so windows can be read even while other
+// windows cover them. Automatic redirection doesn't change what is shown and may be
+// requested alongside a compositing window manager.
+func (c *Conn) RedirectSubwindows() error {
+	major, err := c.composite()
+	if err != nil {
+		return err
+	}
+	body := u32s(uint32(c.screen.Root), 0) // CompositeRedirectAutomatic
+	_, err = c.request(major, compositeRedirectSubwindows, body, false)
+	return err
+}
+
+// NameWindowPixmap returns a pixmap holding the off-screen contents of a redirected
+// top-level window, border included. Release it with FreePixmap.
+func (c *Conn) NameWindowPixmap(w Window) (Pixmap, error) {
+	major, err := c.composite()
+	if err != nil {
+		return 0, err
+	}
+	pixmap := Pixmap(c.newID())
+	if _, err := c.request(major, compositeNameWindowPixmap, u32s(uint32(w), uint32(pixmap)), false); err != nil {
+		return 0, err
+	}
+	return pixmap, nil
+}
+
+// composite returns the Composite major opcode, negotiating version 0.4 on first use
+// as the extension requires
+func (c *Conn) composite() (byte, error) {
+	ext, err := c.queryExtension("Composite")
+	if err != nil {
+		return 0, err
+	}
+	if ext.major == 0 {
+		return 0, fmt.Errorf("Composite: %w", ErrNoExtension)
+	}
+
+	c.mu.Lock()
+	ready := ext.ready
+	c.mu.Unlock()
+	if !ready {
+		reply, err := c.request(ext.major, compositeQueryVersion, u32s(0, 4), true)
+		if err != nil {
+			return 0, err
+		}
+		if get32(reply[8:]) == 0 && get32(reply[12:]) < 2 {
+			return 0, fmt.Errorf("Composite %d.%d lacks NameWindowPixmap", get32(reply[8:]), get32(reply[12:]))
+		}
+		c.mu.Lock()
+		ext.ready = true
+		c.mu.Unlock()
+	}
+	return ext.major, nil
+}
+
+// IdleTime returns how long the user has been idle, from the MIT-SCREEN-SAVER extension
+func (c *Conn) IdleTime() (time.Duration, error) {
+	ext, err := c.queryExtension("MIT-SCREEN-SAVER")
+	if err != nil {
+		return 0, err
+	}
+	if ext.major == 0 {
+		return 0, fmt.Errorf("MIT-SCREEN-SAVER: %w", ErrNoExtension)
+	}
+	reply, err := c.request(ext.major, screenSaverQueryInfo, u32s(uint32(c.screen.Root)), true)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(get32(reply[16:])) * time.Millisecond, nil
+}
@@ -0,0 +1,322 @@
+package x11
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Core protocol opcodes
+const (
+	opGetWindowAttributes  = 3
+	opMapWindow            = 8
+	opUnmapWindow          = 10
+	opConfigureWindow      = 12
+	opGetGeometry          = 14
+	opQueryTree            = 15
+	opInternAtom           = 16
+	opGetProperty          = 20
+	opSendEvent            = 25
+	opQueryPointer         = 38
+	opTranslateCoordinates = 40
+	opFreePixmap           = 54
+	opGetImage             = 73
+	opQueryExtension       = 98
+)
+
+// Map states reported by WindowAttributes
+const (
+	IsUnmapped   = 0
+	IsUnviewable = 1
+	IsViewable   = 2
+)
+
+// WindowAttributes holds the attributes of a window the capture backend uses
+type WindowAttributes struct {
+	MapState         byte
+	OverrideRedirect bool
+}
+
+// Geometry is a drawable's position relative to its parent, size and border width
+type Geometry struct {
+	X, Y          int
+	Width, Height int
+	Border        int
+	Depth         byte
+}
+
+// Property is the value of a window property
+type Property struct {
+	Type   Atom
+	Format byte // 8, 16 or 32 bits per item
+	Value  []byte
+}
+
+// Uint32s decodes a 32-bit property such as a CARDINAL, WINDOW or ATOM list
+func (p *Property) Uint32s() []uint32 {
+	if p == nil || p.Format != 32 {
+		return nil
+	}
+	values := make([]uint32, len(p.Value)/4)
+	for i := range values {
+		values[i] = get32(p.Value[i*4:])
+	}
+	return values
+}
+
+// Strings splits an 8-bit property into its NUL-separated strings
+func (p *Property) Strings() []string {
+	if p == nil || p.Format != 8 {
+		return nil
+	}
+	var values []string
+	for _, part := range bytes.Split(bytes.TrimRight(p.Value, "\x00"), []byte{0}) {
+		values = append(values, string(part))
+	}
+	return values
+}
+
+// GetWindowAttributes returns a window's map state and override-redirect flag
+func (c *Conn) GetWindowAttributes(w Window) (*WindowAttributes, error) {
+	reply, err := c.request(opGetWindowAttributes, 0, u32s(uint32(w)), true)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowAttributes{MapState: reply[26], OverrideRedirect: reply[27] != 0}, nil
+}
+
+// MapWindow shows a window
+func (c *Conn) MapWindow(w Window) error {
+	_, err := c.request(opMapWindow, 0, u32s(uint32(w)), false)
+	return err
+}
+
+// UnmapWindow hides a window
+func (c *Conn) UnmapWindow(w Window) error {
+	_, err := c.request(opUnmapWindow, 0, u32s(uint32(w)), false)
+	return err
+}
+
+// ConfigureWindow moves and resizes a window; the window manager may adjust the request
+func (c *Conn) ConfigureWindow(w Window, x, y, width, height int) error {
+	body := make([]byte, 8, 24)
+	put32(body, uint32(w))
+	put16(body[4:], 0x1|0x2|0x4|0x8) // X, Y, width, height
+	body = append(body, u32s(uint32(int32(x)), uint32(int32(y)), uint32(width), uint32(height))...)
+	_, err := c.request(opConfigureWindow, 0, body, false)
+	return err
+}
+
+// GetGeometry returns a drawable's geometry
+func (c *Conn) GetGeometry(w Window) (*Geometry, error) {
+	reply, err := c.request(opGetGeometry, 0, u32s(uint32(w)), true)
+	if err != nil {
+		return nil, err
+	}
+	return &Geometry{
+		X:      int(int16(get16(reply[12:]))),
+		Y:      int(int16(get16(reply[14:]))),
+		Width:  int(get16(reply[16:])),
+		Height: int(get16(reply[18:])),
+		Border: int(get16(reply[20:])),
+		Depth:  reply[1],
+	}, nil
+}
+
+// QueryTree returns a window's parent and its children in stacking order, bottommost first
+func (c *Conn) QueryTree(w Window) (Window, []Window, error) {
+	reply, err := c.request(opQueryTree, 0, u32s(uint32(w)), true)
+	if err != nil {
+		return 0, nil, err
+	}
+	children := make([]Window, get16(reply[16:]))
+	for i := range children {
+		children[i] = Window(get32(reply[32+i*4:]))
+	}
+	return Window(get32(reply[12:])), children, nil
+}
+
+// TopLevel returns the child of the root window containing w: the frame a reparenting
+// window manager put around it, or w itself
+func (c *Conn) TopLevel(w Window) (Window, error) {
+	for {
+		parent, _, err := c.QueryTree(w)
+		if err != nil {
+			return 0, err
+		}
+		if parent == c.screen.Root || parent == 0 {
+			return w, nil
+		}
+		w = parent
+	}
+}
+
+// Atom returns the atom with a name, creating it if needed. Atoms are cached.
+func (c *Conn) Atom(name string) (Atom, error) {
+	c.mu.Lock()
+	atom, ok := c.atoms[name]
+	c.mu.Unlock()
+	if ok {
+		return atom, nil
+	}
+
+	body := make([]byte, 4, 4+len(name))
+	put16(body, uint16(len(name)))
+	body = append(body, name...)
+	reply, err := c.request(opInternAtom, 0, body, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to intern atom %s: %w", name, err)
+	}
+	atom = Atom(get32(reply[8:]))
+
+	c.mu.Lock()
+	c.atoms[name] = atom
+	c.mu.Unlock()
+	return atom, nil
+}
+
+// GetProperty reads a window property of any type. It returns nil if the window has
+// no such property.
+func (c *Conn) GetProperty(w Window, name string) (*Property, error) {
+	property, err := c.Atom(name)
+	if err != nil {
+		return nil, err
+	}
+	// Read up to 4 MiB; the properties used here are far smaller
+	reply, err := c.request(opGetProperty, 0, u32s(uint32(w), uint32(property), 0, 0, 1<<20), true)
+	if err != nil {
+		return nil, err
+	}
+	if get32(reply[8:]) == 0 {
+		return nil, nil // No such property
+	}
+
+	format := reply[1]
+	length := int(get32(reply[16:])) * int(format) / 8
+	return &Property{
+		Type:   Atom(get32(reply[8:])),
+		Format: format,
+		Value:  reply[32 : 32+length],
+	}, nil
+}
+
+// SendClientMessage sends a 32-bit client message about w to the root window, the way
+// clients ask the window manager to change a window's state
+func (c *Conn) SendClientMessage(w Window, messageType string, data ...uint32) error {
+	atom, err := c.Atom(messageType)
+	if err != nil {
+		return err
+	}
+
+	event := make([]byte, 32)
+	event[0], event[1] = 33, 32 // ClientMessage, format 32
+	put32(event[4:], uint32(w))
+	put32(event[8:], uint32(atom))
+	for i := 0; i < len(data) && i < 5; i++ {
+		put32(event[12+i*4:], data[i])
+	}
+
+	// SubstructureNotify | SubstructureRedirect
+	body := append(u32s(uint32(c.screen.Root), 1<<19|1<<20), event...)
+	_, err = c.request(opSendEvent, 0, body, false)
+	return err
+}
+
+// QueryPointer returns the pointer position on the root window
+func (c *Conn) QueryPointer() (int, int, error) {
+	reply, err := c.request(opQueryPointer, 0, u32s(uint32(c.screen.Root)), true)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(int16(get16(reply[16:]))), int(int16(get16(reply[18:]))), nil
+}
+
+// TranslateCoordinates converts a point in src to the coordinates of dst
+func (c *Conn) TranslateCoordinates(src, dst Window, x, y int) (int, int, error) {
+	body := u32s(uint32(src), uint32(dst), 0)
+	put16(body[8:], uint16(int16(x)))
+	put16(body[10:], uint16(int16(y)))
+	reply, err := c.request(opTranslateCoordinates, 0, body, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(int16(get16(reply[12:]))), int(int16(get16(reply[14:]))), nil
+}
+
+// FreePixmap releases a pixmap
+func (c *Conn) FreePixmap(p Pixmap) error {
+	_, err := c.request(opFreePixmap, 0, u32s(uint32(p)), false)
+	return err
+}
+
+// GetImage reads a rectangle of a window or pixmap as tightly packed BGRA pixels.
+// Drawables must use 24- or 32-bit TrueColor with 32 bits per pixel, which covers
+// every current X server.
+func (c *Conn) GetImage(drawable uint32, x, y, width, height int) ([]byte, error) {
+	body := u32s(drawable, 0, 0, 0xFFFFFFFF)
+	put16(body[4:], uint16(int16(x)))
+	put16(body[6:], uint16(int16(y)))
+	put16(body[8:], uint16(width))
+	put16(body[10:], uint16(height))
+	reply, err := c.request(opGetImage, 2, body, true) // ZPixmap
+	if err != nil {
+		return nil, err
+	}
+
+	depth := reply[1]
+	if bpp := c.formats[depth]; bpp != 32 {
+		return nil, fmt.Errorf("unsupported pixel format: depth %d with %d bits per pixel", depth, bpp)
+	}
+	data := reply[32:]
+	if len(data) < width*height*4 {
+		return nil, fmt.Errorf("short image: %d bytes for %dx%d", len(data), width, height)
+	}
+	data = data[:width*height*4]
+
+	for i := 0; i < len(data); i += 4 {
+		if c.byteOrder == 1 { // MSBFirst stores pixels as XRGB
+			data[i], data[i+1], data[i+2], data[i+3] = data[i+3], data[i+2], data[i+1], data[i]
+		}
+		if depth != 32 {
+			data[i+3] = 255
+		}
+	}
+	return data, nil
+}
+
+// queryExtension looks up an extension's major opcode, which is 0 if the server lacks
+// it. Results are cached.
+func (c *Conn) queryExtension(name string) (*extension, error) {
+	c.mu.Lock()
+	ext, ok := c.ext[name]
+	c.mu.Unlock()
+	if ok {
+		return ext, nil
+	}
+
+	body := make([]byte, 4, 4+len(name))
+	put16(body, uint16(len(name)))
+	body = append(body, name...)
+	reply, err := c.request(opQueryExtension, 0, body, true)
+	if err != nil {
+		return nil, err
+	}
+	ext = &extension{}
+	if reply[8] != 0 {
+		ext.major = reply[9]
+	}
+
+	c.mu.Lock()
+	c.ext[name] = ext
+	c.mu.Unlock()
+	return ext, nil
+}
+
+// u32s encodes values as a little-endian request body
+func u32s(values ...uint32) []byte {
+	body := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(body[i*4:], v)
+	}
+	return body
+}
@@ -0,0 +1,588 @@
+// Package client is a Go client for the screenshot server's REST, WebSocket and MCP
+// APIs. It lets other programs capture windows, list windows and Chrome tabs, stream
+// frames and call MCP methods without hand-rolling HTTP and WebSocket code:
+//
+//	c, err := client.New("http://localhost:8080")
+//	shot, err := c.Screenshot(ctx, &types.ScreenshotRequest{Method: "title", Target: "Calculator"})
+//	png, err := shot.Image()
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/framecrypt"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Default retry policy
+const (
+	DefaultRetries = 2
+	DefaultBackoff = 200 * time.Millisecond
+)
+
+// Client talks to one screenshot server. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	header     http.Header
+	retries    int
+	backoff    time.Duration
+	rpcID      atomic.Int64
+	streamKey  string
+	frameKey   *framecrypt.Key
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for REST and MCP calls
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBearerToken sends "Authorization: Bearer <token>" with every request, for servers
+// behind an authenticating proxy
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithBasicAuth sends HTTP basic credentials with every request
+func WithBasicAuth(username, password string) Option {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return WithHeader("Authorization", "Basic "+credentials)
+}
+
+// WithHeader adds a header to every request, including WebSocket handshakes
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithStreamKey sets the server's pre-shared stream key. Streams then verify the
+// signature of every frame, rejecting unsigned ones, and decrypt encrypted frames.
+func WithStreamKey(psk string) Option {
+	return func(c *Client) {
+		c.streamKey = psk
+	}
+}
+
+// WithRetries sets how many times a failed call is retried and the delay before the
+// first retry, which doubles on each further retry. Only network errors and gateway
+// errors without a server error code are retried; 0 disables retries.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		header:     make(http.Header),
+		retries:    DefaultRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.streamKey != "" {
+		if c.frameKey, err = framecrypt.NewKey(c.streamKey); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Error is a failed call. Code is set when the server classified the failure, so
+// types.ErrorCodeOf works on errors returned by the client.
+type Error struct {
+	StatusCode   int
+	Message      string
+	Code         types.ErrorCode
+	Candidates   []types.WindowInfo // Windows an ambiguous title matched
+	DesktopState types.DesktopState // Why the desktop can't be captured
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s (%s, HTTP %d)", e.Message, e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// ErrorCode returns the server's error code, or CAPTURE_FAILED if it sent none
+func (e *Error) ErrorCode() types.ErrorCode {
+	if e.Code == "" {
+		return types.ErrCaptureFailed
+	}
+	return e.Code
+}
+
+// Health is the server's health report
+type Health struct {
+	Status       string             `json:"status"`
+	Timestamp    time.Time          `json:"timestamp"`
+	Version      string             `json:"version"`
+	DesktopState types.DesktopState `json:"desktop_state"`
+}
+
+// StreamStatus summarizes the server's WebSocket streams
+type StreamStatus struct {
+	ActiveSessions int                        `json:"active_sessions"`
+	TotalSessions  int                        `json:"total_sessions"`
+	TotalFrames    int64                      `json:"total_frames"`
+	Uptime         string                     `json:"uptime"`
+	MaxSessions    int                        `json:"max_sessions"`
+	Sessions       []types.StreamSessionStats `json:"sessions"`
+}
+
+// Screenshot is a capture result
+type Screenshot struct {
+	types.ScreenshotResponse
+}
+
+// Image decodes the captured image
+func (s *Screenshot) Image() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s.Data)
+}
+
+// Health reports the server's status
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var health Health
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Screenshot captures a window, shell surface or the desktop
+func (c *Client) Screenshot(ctx context.Context, req *types.ScreenshotRequest) (*Screenshot, error) {
+	var shot Screenshot
+	if err := c.do(ctx, http.MethodPost, "/v1/screenshot", nil, req, &shot); err != nil {
+		return nil, err
+	}
+	return &shot, nil
+}
+
+// BatchScreenshot captures several windows at once. Targets of the form "machine:window"
+// are captured on that federated target; a failed capture fails only its own result.
+func (c *Client) BatchScreenshot(ctx context.Context, reqs []types.ScreenshotRequest) (*types.BatchScreenshotResponse, error) {
+	var resp types.BatchScreenshotResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/screenshot/batch", nil, types.BatchScreenshotRequest{Requests: reqs}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ShellScreenshot captures the taskbar, tray overflow or latest notification
+func (c *Client) ShellScreenshot(ctx context.Context, surface types.ShellSurface, format types.ImageFormat) (*Screenshot, error) {
+	query := url.Values{}
+	if format != "" {
+		query.Set("format", string(format))
+	}
+	var shot Screenshot
+	if err := c.do(ctx, http.MethodGet, "/v1/shell/"+url.PathEscape(string(surface)), query, nil, &shot); err != nil {
+		return nil, err
+	}
+	return &shot, nil
+}
+
+// ListWindows lists top-level windows; filter may be nil. Only the title, visibility,
+// system window and virtual desktop filters are sent.
+func (c *Client) ListWindows(ctx context.Context, filter *types.WindowFilter) ([]types.WindowInfo, error) {
+	query := url.Values{}
+	if filter != nil {
+		if filter.TitleContains != "" {
+			query.Set("title_contains", filter.TitleContains)
+		}
+		if filter.VisibleOnly {
+			query.Set("visible_only", "true")
+		}
+		if filter.ExcludeSystem {
+			query.Set("exclude_system", "true")
+		}
+		if filter.VirtualDesktop != "" {
+			query.Set("virtual_desktop", filter.VirtualDesktop)
+		}
+	}
+
+	var resp struct {
+		Windows []types.WindowInfo `json:"windows"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/windows", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Windows, nil
+}
+
+// TrayWindows lists windows of processes with notification area icons
+func (c *Client) TrayWindows(ctx context.Context) ([]types.WindowInfo, error) {
+	var resp struct {
+		Windows []types.WindowInfo `json:"windows"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/tray", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Windows, nil
+}
+
+// WindowIcon returns a window's icon as PNG; large selects the 32x32 icon
+func (c *Client) WindowIcon(ctx context.Context, handle uintptr, large bool) ([]byte, error) {
+	return c.icon(ctx, "/v1/windows/"+strconv.FormatUint(uint64(handle), 10)+"/icon", large)
+}
+
+// ProcessIcon returns a process's icon as PNG; large selects the 32x32 icon
+func (c *Client) ProcessIcon(ctx context.Context, pid uint32, large bool) ([]byte, error) {
+	return c.icon(ctx, "/v1/processes/"+strconv.FormatUint(uint64(pid), 10)+"/icon", large)
+}
+
+func (c *Client) icon(ctx context.Context, path string, large bool) ([]byte, error) {
+	query := url.Values{"size": {"small"}}
+	if large {
+		query.Set("size", "large")
+	}
+	var buf bytes.Buffer
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ChromeInstances lists Chrome instances with remote debugging enabled
+func (c *Client) ChromeInstances(ctx context.Context) ([]types.ChromeInstance, error) {
+	var resp struct {
+		Instances []types.ChromeInstance `json:"instances"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/chrome/instances", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Instances, nil
+}
+
+// ChromeTabs lists the tabs of all Chrome instances
+func (c *Client) ChromeTabs(ctx context.Context) ([]types.ChromeTab, error) {
+	var resp struct {
+		Tabs []types.ChromeTab `json:"tabs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/chrome/tabs", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tabs, nil
+}
+
+// ChromeTabScreenshot captures a Chrome tab with its out-of-process iframes composited
+func (c *Client) ChromeTabScreenshot(ctx context.Context, tabID string) (*Screenshot, error) {
+	var shot Screenshot
+	if err := c.do(ctx, http.MethodPost, "/v1/chrome/tabs/"+url.PathEscape(tabID)+"/screenshot", nil, nil, &shot); err != nil {
+		return nil, err
+	}
+	return &shot, nil
+}
+
+// ChromeTabFrames lists a tab's out-of-process iframes
+func (c *Client) ChromeTabFrames(ctx context.Context, tabID string) ([]types.ChromeFrame, error) {
+	var resp struct {
+		Frames []types.ChromeFrame `json:"frames"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/chrome/tabs/"+url.PathEscape(tabID)+"/frames", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Frames, nil
+}
+
+// StreamStatus summarizes the server's WebSocket streams
+func (c *Client) StreamStatus(ctx context.Context) (*StreamStatus, error) {
+	var status StreamStatus
+	if err := c.do(ctx, http.MethodGet, "/v1/stream/status", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Stats returns the captures, stream frames and capture method success rates counted since
+// the server was first used, across restarts
+func (c *Client) Stats(ctx context.Context) (*types.UsageStats, error) {
+	var stats types.UsageStats
+	if err := c.do(ctx, http.MethodGet, "/v1/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// MethodRankings returns how each capture method fared on each window class, best first,
+// which is the order the server tries its fallbacks in
+func (c *Client) MethodRankings(ctx context.Context) ([]types.MethodRanking, error) {
+	var response struct {
+		Rankings []types.MethodRanking `json:"rankings"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/capture/rankings", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Rankings, nil
+}
+
+// StreamSessions lists every stream session with its client. It needs the server's admin
+// token, set with WithBearerToken.
+func (c *Client) StreamSessions(ctx context.Context) ([]types.StreamSessionStats, error) {
+	var response struct {
+		Sessions []types.StreamSessionStats `json:"sessions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/admin/sessions", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Sessions, nil
+}
+
+// CloseStreamSession force-closes a stream session, sending its client a close frame with
+// the reason. It needs the server's admin token.
+func (c *Client) CloseStreamSession(ctx context.Context, sessionID, reason string) error {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+	return c.do(ctx, http.MethodDelete, "/v1/admin/sessions/"+url.PathEscape(sessionID), query, nil, nil)
+}
+
+// StartRecording starts a recording
+func (c *Client) StartRecording(ctx context.Context, req *types.RecordingRequest) (*types.RecordingInfo, error) {
+	var info types.RecordingInfo
+	if err := c.do(ctx, http.MethodPost, "/v1/recordings", nil, req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// StopRecording stops a recording
+func (c *Client) StopRecording(ctx context.Context, id string) (*types.RecordingInfo, error) {
+	var info types.RecordingInfo
+	if err := c.do(ctx, http.MethodPost, "/v1/recordings/"+url.PathEscape(id)+"/stop", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Recording returns a recording's status
+func (c *Client) Recording(ctx context.Context, id string) (*types.RecordingInfo, error) {
+	var info types.RecordingInfo
+	if err := c.do(ctx, http.MethodGet, "/v1/recordings/"+url.PathEscape(id), nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Recordings lists recordings
+func (c *Client) Recordings(ctx context.Context) ([]types.RecordingInfo, error) {
+	var resp struct {
+		Recordings []types.RecordingInfo `json:"recordings"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/recordings", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Recordings, nil
+}
+
+// Agents lists the capture agents connected to the server through the relay
+func (c *Client) Agents(ctx context.Context) ([]types.AgentInfo, error) {
+	var resp struct {
+		Agents []types.AgentInfo `json:"agents"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/agents", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Agents, nil
+}
+
+// Targets lists the servers and agents captures can be addressed to as "name:window",
+// checking each one's health when check is set
+func (c *Client) Targets(ctx context.Context, check bool) ([]types.TargetInfo, error) {
+	query := url.Values{}
+	if check {
+		query.Set("check", "true")
+	}
+	var resp struct {
+		Targets []types.TargetInfo `json:"targets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/targets", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Targets, nil
+}
+
+// TargetAlias returns a target alias
+func (c *Client) TargetAlias(ctx context.Context, name string) (*types.TargetAlias, error) {
+	var alias types.TargetAlias
+	if err := c.do(ctx, http.MethodGet, "/v1/targets/"+url.PathEscape(name), nil, nil, &alias); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// PutTargetAlias creates or replaces a target alias, which requests then name with
+// method "alias"
+func (c *Client) PutTargetAlias(ctx context.Context, alias *types.TargetAlias) (*types.TargetAlias, error) {
+	var saved types.TargetAlias
+	if err := c.do(ctx, http.MethodPut, "/v1/targets/"+url.PathEscape(alias.Name), nil, alias, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteTargetAlias removes a target alias
+func (c *Client) DeleteTargetAlias(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/targets/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// Agent returns a client for a capture agent connected to the server. Its calls and
+// streams go through the server to the agent, with this client's options.
+func (c *Client) Agent(id string) *Client {
+	return &Client{
+		baseURL:    c.baseURL.JoinPath("/v1/agents", id, "api"),
+		httpClient: c.httpClient,
+		header:     c.header,
+		retries:    c.retries,
+		backoff:    c.backoff,
+		streamKey:  c.streamKey,
+		frameKey:   c.frameKey,
+	}
+}
+
+// do sends a request, retrying per the client's policy, and decodes a JSON response
+// into out, or copies the body when out is a *bytes.Buffer
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	endpoint := c.baseURL.JoinPath(path)
+	endpoint.RawQuery = query.Encode()
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, endpoint.String(), payload, out)
+		if err == nil || attempt >= c.retries || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, out any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if buf, ok := out.(*bytes.Buffer); ok {
+		_, err = buf.ReadFrom(resp.Body)
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// decodeError turns an error response into an *Error
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Error        string             `json:"error"`
+		Code         types.ErrorCode    `json:"code"`
+		Candidates   []types.WindowInfo `json:"candidates"`
+		DesktopState types.DesktopState `json:"desktop_state"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		apiErr.Message = body.Error
+		apiErr.Code = body.Code
+		apiErr.Candidates = body.Candidates
+		apiErr.DesktopState = body.DesktopState
+	} else if text := strings.TrimSpace(string(data)); text != "" {
+		apiErr.Message = text
+	} else {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed call may succeed if repeated: network errors and
+// gateway errors a proxy produced. Errors the server classified are final.
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != "" {
+			return false
+		}
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// RPCError is a JSON-RPC error returned by an MCP method. Capture failures carry their
+// error code in Data, so types.ErrorCodeOf works on them.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    json.RawMessage
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (JSON-RPC %d)", e.Message, e.Code)
+}
+
+// ErrorCode returns the capture error code in the error's data, or CAPTURE_FAILED
+func (e *RPCError) ErrorCode() types.ErrorCode {
+	var data struct {
+		Code types.ErrorCode `json:"code"`
+	}
+	if json.Unmarshal(e.Data, &data) == nil && data.Code != "" {
+		return data.Code
+	}
+	return types.ErrCaptureFailed
+}
+
+// Call invokes an MCP method over POST /rpc and decodes its result into result, which
+// may be nil to discard it
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	req := types.MCPRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      c.rpcID.Add(1),
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rpc", nil, req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return &RPCError{Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
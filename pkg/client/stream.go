@@ -0,0 +1,672 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/screenshot-mcp-server/pkg/framecrypt"
+	"github.com/screenshot-mcp-server/pkg/rawframe"
+	"github.com/screenshot-mcp-server/pkg/shmring"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// rawFrameFormat is the Format of frames received over the raw transport
+const rawFrameFormat = "bgra"
+
+// streamDialer dials stream connections, offering permessage-deflate, which the server
+// accepts unless its stream compression is off
+var streamDialer = &websocket.Dialer{
+	Proxy:             http.ProxyFromEnvironment,
+	HandshakeTimeout:  45 * time.Second,
+	EnableCompression: true,
+}
+
+// StreamOptions configures a WebSocket stream; zero values use the server's defaults
+type StreamOptions struct {
+	FPS     int
+	Quality int
+	Format  types.ImageFormat // "png" or "jpeg"
+	// Ack makes the server wait for acknowledgements, which the stream sends as frames
+	// are received from Frames, so a slow consumer slows the server down instead of
+	// queueing frames
+	Ack        bool
+	MaxUnacked int // Frames in flight in ack mode, default 1
+	// DetailInterval has every Nth frame encoded at DetailQuality (default 95) and the
+	// others at Quality, so a low-quality stream still shows full detail now and then
+	DetailInterval int
+	DetailQuality  int
+	// Region streams only part of the window or display, relative to its top-left
+	// corner; SetRegion moves it while streaming
+	Region *types.Rectangle
+	// CursorZoom streams a region this size that follows the cursor, easing after it by
+	// CursorSmoothing, 0 to 0.95 (0 uses the server's default, 0.5)
+	CursorZoom      *types.Size
+	CursorSmoothing float64
+	// Insets are other windows composited picture-in-picture into the frames, scaled
+	// down in a corner; zero Position and Scale use the server's defaults
+	Insets []types.StreamInset
+	// Overlay has the server burn the capture time, window title, session ID and achieved
+	// frame rate into a strip along the bottom of each frame
+	Overlay bool
+	// Privacy, "blur" or "black", hides every window of a desktop stream except the
+	// PrivacyAllow ones, for sharing the desktop without what else is open on it
+	Privacy      string
+	PrivacyAllow []uintptr
+	// Follow streams whichever window is in the foreground instead of the handle passed
+	// to Stream, switching as the user moves between windows; WindowID tells which
+	Follow bool
+	// Record tees the frames the server delivers into a recording, fetched afterwards
+	// through the recordings API
+	Record       bool
+	RecordOutput string // "frames" (default) or "mp4"
+	// Security asks the server to sign or encrypt frames; it needs WithStreamKey
+	Security types.StreamSecurity
+	// Transport "raw" has the server send unencoded BGRA pixels, which saves encoding and
+	// decoding for viewers on the same machine or LAN, and "shm" hands them over in shared
+	// memory, for clients on the server's machine; neither can be combined with Security
+	Transport types.StreamTransport
+}
+
+// Frame is a decoded stream frame
+type Frame struct {
+	Number    int64
+	Width     int
+	Height    int
+	Format    string
+	Data      []byte // Encoded image, or the pixels of raw and shm frames (Format "bgra")
+	Timestamp time.Time
+	Keyframe  bool                 // Full-quality PNG requested with CaptureNow
+	Quality   int                  // Quality the frame was encoded at, on streams with a DetailInterval
+	Region    *types.Rectangle     // Part of the window or display shown, on streams with a CursorZoom
+	Timing    *types.CaptureTiming // Capture timestamps and server-side latencies
+}
+
+// Stream receives frames of a window over WebSocket. A dropped connection is resumed
+// with the session's resume token, up to the client's retry count.
+type Stream struct {
+	client   *Client
+	windowID uintptr // Guarded by mu; changes with the foreground window if follow is set
+	follow   bool
+	frames   chan Frame
+
+	conn        *websocket.Conn
+	connMu      sync.Mutex // Guards conn and serializes writes
+	sessionID   string
+	resumeToken string
+	recordingID string
+	desktop     types.DesktopState
+	ring        *shmring.Reader // Frame ring of the shm transport, used by the reading goroutine
+
+	err       error
+	done      chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+}
+
+// streamMessage is a message the server sends on a stream
+type streamMessage struct {
+	Type        string          `json:"type"`
+	SessionID   string          `json:"session_id"`
+	ResumeToken string          `json:"resume_token"`
+	RecordingID string          `json:"recording_id"`
+	WindowID    uintptr         `json:"window_id"` // session_started of streams with Follow
+	Data        json.RawMessage `json:"data"`
+	Error       string          `json:"error"`
+}
+
+// frameMessage is the data of "frame" and "keyframe" messages
+type frameMessage struct {
+	FrameNumber int64                `json:"frame_number"`
+	Width       int                  `json:"width"`
+	Height      int                  `json:"height"`
+	Format      string               `json:"format"`
+	DataURL     string               `json:"data_url"`
+	Payload     string               `json:"payload"`
+	Encrypted   bool                 `json:"encrypted"`
+	Signature   string               `json:"signature"`
+	Timestamp   time.Time            `json:"timestamp"`
+	AckRequired bool                 `json:"ack_required"`
+	Keyframe    bool                 `json:"keyframe"`
+	Quality     int                  `json:"quality"`
+	Region      *types.Rectangle     `json:"region"`
+	Timing      *types.CaptureTiming `json:"timing"`
+	Slot        *int                 `json:"slot"`
+}
+
+// Stream starts streaming a window; handle 0 streams the desktop. Frames are delivered
+// on Frames until ctx is cancelled, Close is called or the stream fails for good.
+func (c *Client) Stream(ctx context.Context, handle uintptr, opts StreamOptions) (*Stream, error) {
+	s := &Stream{
+		client:   c,
+		windowID: handle,
+		follow:   opts.Follow,
+		frames:   make(chan Frame),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+
+	query := url.Values{}
+	if opts.FPS > 0 {
+		query.Set("fps", strconv.Itoa(opts.FPS))
+	}
+	if opts.Quality > 0 {
+		query.Set("quality", strconv.Itoa(opts.Quality))
+	}
+	if opts.Format != "" {
+		query.Set("format", string(opts.Format))
+	}
+	if opts.Ack {
+		query.Set("ack", "true")
+		if opts.MaxUnacked > 0 {
+			query.Set("max_unacked", strconv.Itoa(opts.MaxUnacked))
+		}
+	}
+	if opts.DetailInterval > 1 {
+		query.Set("detail_interval", strconv.Itoa(opts.DetailInterval))
+		if opts.DetailQuality > 0 {
+			query.Set("detail_quality", strconv.Itoa(opts.DetailQuality))
+		}
+	}
+	if r := opts.Region; r != nil {
+		query.Set("region", fmt.Sprintf("%d,%d,%d,%d", r.X, r.Y, r.Width, r.Height))
+	}
+	if z := opts.CursorZoom; z != nil {
+		query.Set("cursor_zoom", fmt.Sprintf("%dx%d", z.Width, z.Height))
+		if opts.CursorSmoothing > 0 {
+			query.Set("cursor_smoothing", strconv.FormatFloat(opts.CursorSmoothing, 'f', -1, 64))
+		}
+	}
+	if len(opts.Insets) > 0 {
+		specs := make([]string, len(opts.Insets))
+		for i, inset := range opts.Insets {
+			specs[i] = strconv.FormatUint(uint64(inset.WindowID), 10) + ":" + inset.Position
+			if inset.Scale > 0 {
+				specs[i] += ":" + strconv.FormatFloat(inset.Scale, 'f', -1, 64)
+			}
+		}
+		query.Set("pip", strings.Join(specs, ","))
+	}
+	if opts.Overlay {
+		query.Set("overlay", "true")
+	}
+	if opts.Privacy != "" {
+		query.Set("privacy", opts.Privacy)
+		if len(opts.PrivacyAllow) > 0 {
+			ids := make([]string, len(opts.PrivacyAllow))
+			for i, handle := range opts.PrivacyAllow {
+				ids[i] = strconv.FormatUint(uint64(handle), 10)
+			}
+			query.Set("allow", strings.Join(ids, ","))
+		}
+	}
+	if opts.Security != "" {
+		if c.frameKey == nil {
+			return nil, fmt.Errorf("stream security %q needs a stream key (WithStreamKey)", opts.Security)
+		}
+		query.Set("security", string(opts.Security))
+	}
+	if opts.Transport != "" {
+		query.Set("transport", string(opts.Transport))
+	}
+	if opts.Record {
+		query.Set("record", "true")
+		if opts.RecordOutput != "" {
+			query.Set("record_output", opts.RecordOutput)
+		}
+	}
+
+	conn, first, err := s.dial(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if first.Type != "session_started" {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected stream message %q", first.Type)
+	}
+	s.conn = conn
+	s.sessionID = first.SessionID
+	s.resumeToken = first.ResumeToken
+	s.recordingID = first.RecordingID
+	if s.follow {
+		s.windowID = first.WindowID
+	}
+
+	go s.run(ctx)
+	return s, nil
+}
+
+// Frames returns the channel frames are delivered on. It is closed when the stream ends.
+func (s *Stream) Frames() <-chan Frame {
+	return s.frames
+}
+
+// Err returns the error that ended the stream, or nil if it was closed or cancelled
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// SessionID returns the server's session ID
+func (s *Stream) SessionID() string {
+	return s.sessionID
+}
+
+// RecordingID returns the recording the stream is teed into, empty unless started with Record
+func (s *Stream) RecordingID() string {
+	return s.recordingID
+}
+
+// DesktopState returns the desktop state last reported by the server; frames pause
+// while it is anything but available
+func (s *Stream) DesktopState() types.DesktopState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.desktop == "" {
+		return types.DesktopAvailable
+	}
+	return s.desktop
+}
+
+// WindowID returns the window streamed, which for a stream with Follow is the foreground
+// window the server last switched to
+func (s *Stream) WindowID() uintptr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.windowID
+}
+
+// CaptureNow asks for an immediate full-quality PNG keyframe
+func (s *Stream) CaptureNow() error {
+	return s.command(map[string]any{"command": "capture_now"})
+}
+
+// UpdateOptions changes the stream's rate, quality, format or size
+func (s *Stream) UpdateOptions(options *types.StreamOptions) error {
+	return s.command(map[string]any{"command": "update_options", "options": options})
+}
+
+// SetRegion moves or resizes the region streamed, or with nil streams the whole window
+// or display again
+func (s *Stream) SetRegion(region *types.Rectangle) error {
+	return s.command(map[string]any{"command": "set_region", "region": region})
+}
+
+// Close stops the server session and ends the stream
+func (s *Stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		select {
+		case <-s.done:
+		default:
+			err = s.command(map[string]any{"command": "stop"})
+		}
+		s.connMu.Lock()
+		s.conn.Close()
+		s.connMu.Unlock()
+	})
+	<-s.done
+	return err
+}
+
+// Done is closed when the stream has ended
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Stream) command(msg map[string]any) error {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.conn.WriteJSON(msg)
+}
+
+// dial opens a stream connection and reads its first message, failing on an error message
+func (s *Stream) dial(ctx context.Context, query url.Values) (*websocket.Conn, *streamMessage, error) {
+	target := strconv.FormatUint(uint64(s.windowID), 10)
+	if s.follow {
+		target = "active"
+	}
+	endpoint := s.client.baseURL.JoinPath("/v1/stream", target)
+	endpoint.Scheme = strings.Replace(endpoint.Scheme, "http", "ws", 1)
+	endpoint.RawQuery = query.Encode()
+
+	conn, resp, err := streamDialer.DialContext(ctx, endpoint.String(), s.client.header)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			return nil, nil, decodeError(resp)
+		}
+		return nil, nil, err
+	}
+
+	var first streamMessage
+	if err := conn.ReadJSON(&first); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to read stream message: %w", err)
+	}
+	if first.Type == "error" {
+		conn.Close()
+		return nil, nil, errors.New(first.Error)
+	}
+	return conn, &first, nil
+}
+
+// run reads messages until the stream ends, resuming dropped connections
+func (s *Stream) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.frames)
+	defer s.closeRing()
+
+	stop := context.AfterFunc(ctx, func() {
+		s.connMu.Lock()
+		s.conn.Close()
+		s.connMu.Unlock()
+	})
+	defer stop()
+
+	for {
+		err := s.read(ctx)
+		if s.ended(ctx) {
+			return
+		}
+		// A frame that can't be decoded or verified would fail again after resuming, and
+		// a session the server closed, such as for reaching a limit, is gone
+		var invalid *invalidFrameError
+		var closed *websocket.CloseError
+		if !errors.As(err, &invalid) && !(errors.As(err, &closed) && closed.Code == websocket.ClosePolicyViolation) {
+			err = s.resume(ctx, err)
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			s.connMu.Lock()
+			s.conn.Close()
+			s.connMu.Unlock()
+			return
+		}
+	}
+}
+
+// ended reports whether the stream was closed or cancelled
+func (s *Stream) ended(ctx context.Context) bool {
+	select {
+	case <-s.closed:
+		return true
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// read delivers messages of the current connection until it fails
+func (s *Stream) read(ctx context.Context) error {
+	s.connMu.Lock()
+	conn := s.conn
+	s.connMu.Unlock()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if messageType == websocket.BinaryMessage {
+			if stop, err := s.readRawFrame(ctx, data); stop || err != nil {
+				return err
+			}
+			continue
+		}
+		var msg streamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+
+		switch msg.Type {
+		case "frame", "keyframe":
+			var data frameMessage
+			if err := json.Unmarshal(msg.Data, &data); err != nil {
+				return &invalidFrameError{fmt.Errorf("invalid frame: %w", err)}
+			}
+			var frame Frame
+			var err error
+			if data.Slot != nil {
+				frame, err = s.readSharedFrame(&data)
+			} else {
+				frame, err = decodeFrame(&data, msg.SessionID, s.client.frameKey)
+			}
+			switch {
+			case errors.Is(err, errFrameOverwritten):
+				// Lost; acknowledged below all the same, so the stream goes on
+			case err != nil:
+				return &invalidFrameError{err}
+			default:
+				select {
+				case s.frames <- frame:
+				case <-s.closed:
+					return nil
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			if data.AckRequired {
+				if err := s.command(map[string]any{"command": "ack", "frame_number": data.FrameNumber}); err != nil {
+					return err
+				}
+			}
+
+		case "shm_attached":
+			var data struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(msg.Data, &data); err != nil {
+				return &invalidFrameError{fmt.Errorf("invalid shared memory message: %w", err)}
+			}
+			ring, err := shmring.Open(data.Path)
+			if err != nil {
+				return &invalidFrameError{fmt.Errorf("failed to attach shared memory: %w", err)}
+			}
+			s.closeRing()
+			s.ring = ring
+
+		case "target_changed":
+			var data struct {
+				WindowID uintptr `json:"window_id"`
+			}
+			if json.Unmarshal(msg.Data, &data) == nil {
+				s.mu.Lock()
+				s.windowID = data.WindowID
+				s.mu.Unlock()
+			}
+
+		case "desktop_unavailable", "desktop_available":
+			var data struct {
+				State types.DesktopState `json:"state"`
+			}
+			if json.Unmarshal(msg.Data, &data) == nil {
+				s.mu.Lock()
+				s.desktop = data.State
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+// errFrameOverwritten is returned for a frame in shared memory that was overwritten
+// before it was read, as happens to a consumer that isn't in ack mode and falls behind
+var errFrameOverwritten = errors.New("frame overwritten in shared memory")
+
+// readSharedFrame reads the frame a frame message of the shm transport announces from
+// the session's ring
+func (s *Stream) readSharedFrame(msg *frameMessage) (Frame, error) {
+	if s.ring == nil {
+		return Frame{}, errors.New("frame in shared memory before shm_attached")
+	}
+	header, pixels, err := s.ring.Read(*msg.Slot)
+	if errors.Is(err, shmring.ErrSlotBusy) || (err == nil && header.FrameNumber != msg.FrameNumber) {
+		return Frame{}, errFrameOverwritten
+	}
+	if err != nil {
+		return Frame{}, err
+	}
+	return Frame{
+		Number:    header.FrameNumber,
+		Width:     header.Width,
+		Height:    header.Height,
+		Format:    rawFrameFormat,
+		Data:      pixels,
+		Timestamp: header.CapturedAt,
+		Timing:    msg.Timing,
+	}, nil
+}
+
+// closeRing unmaps the shared-memory ring, if any
+func (s *Stream) closeRing() {
+	if s.ring != nil {
+		s.ring.Close()
+		s.ring = nil
+	}
+}
+
+// readRawFrame delivers a raw frame, reporting whether the stream was closed meanwhile
+func (s *Stream) readRawFrame(ctx context.Context, data []byte) (bool, error) {
+	header, pixels, err := rawframe.Decode(data)
+	if err != nil {
+		return false, &invalidFrameError{err}
+	}
+	frame := Frame{
+		Number:    header.FrameNumber,
+		Width:     header.Width,
+		Height:    header.Height,
+		Format:    rawFrameFormat,
+		Data:      pixels,
+		Timestamp: header.CapturedAt,
+	}
+
+	select {
+	case s.frames <- frame:
+	case <-s.closed:
+		return true, nil
+	case <-ctx.Done():
+		return true, nil
+	}
+
+	if header.AckRequired {
+		if err := s.command(map[string]any{"command": "ack", "frame_number": header.FrameNumber}); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// resume reconnects to the session after its connection failed with cause
+func (s *Stream) resume(ctx context.Context, cause error) error {
+	query := url.Values{"session_id": {s.sessionID}, "resume_token": {s.resumeToken}}
+
+	backoff := s.client.backoff
+	for attempt := 0; attempt < s.client.retries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.closed:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		conn, _, err := s.dial(ctx, query)
+		if err != nil {
+			cause = err
+			continue
+		}
+		s.connMu.Lock()
+		s.conn = conn
+		s.connMu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("stream connection lost: %w", cause)
+}
+
+// invalidFrameError is a frame that couldn't be decoded, verified or decrypted
+type invalidFrameError struct {
+	err error
+}
+
+func (e *invalidFrameError) Error() string { return e.err.Error() }
+func (e *invalidFrameError) Unwrap() error { return e.err }
+
+// decodeFrame decodes a frame's data URL or, with a stream key, verifies its signature
+// and decrypts it if it is encrypted
+func decodeFrame(msg *frameMessage, sessionID string, key *framecrypt.Key) (Frame, error) {
+	var data []byte
+	if msg.Encrypted {
+		if key == nil {
+			return Frame{}, errors.New("frame is encrypted and no stream key is set")
+		}
+		payload, err := base64.StdEncoding.DecodeString(msg.Payload)
+		if err != nil {
+			return Frame{}, fmt.Errorf("invalid frame payload: %w", err)
+		}
+		header := frameHeader(msg, sessionID)
+		if err := key.Verify(header, payload, msg.Signature); err != nil {
+			return Frame{}, fmt.Errorf("frame %d: %w", msg.FrameNumber, err)
+		}
+		if data, err = key.Decrypt(header, payload); err != nil {
+			return Frame{}, err
+		}
+	} else {
+		_, encoded, ok := strings.Cut(msg.DataURL, ";base64,")
+		if !ok {
+			return Frame{}, errors.New("invalid frame data URL")
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return Frame{}, fmt.Errorf("invalid frame data: %w", err)
+		}
+		if key != nil {
+			if err := key.Verify(frameHeader(msg, sessionID), data, msg.Signature); err != nil {
+				return Frame{}, fmt.Errorf("frame %d: %w", msg.FrameNumber, err)
+			}
+		}
+	}
+	return Frame{
+		Number:    msg.FrameNumber,
+		Width:     msg.Width,
+		Height:    msg.Height,
+		Format:    msg.Format,
+		Data:      data,
+		Timestamp: msg.Timestamp,
+		Keyframe:  msg.Keyframe,
+		Quality:   msg.Quality,
+		Region:    msg.Region,
+		Timing:    msg.Timing,
+	}, nil
+}
+
+// frameHeader returns the signed header of a frame
+func frameHeader(msg *frameMessage, sessionID string) framecrypt.Header {
+	header := framecrypt.Header{
+		SessionID:   sessionID,
+		FrameNumber: msg.FrameNumber,
+		Keyframe:    msg.Keyframe,
+		Width:       msg.Width,
+		Height:      msg.Height,
+		Format:      msg.Format,
+	}
+	if msg.Timing != nil {
+		header.MonotonicNs = msg.Timing.MonotonicNs
+	}
+	return header
+}
@@ -0,0 +1,122 @@
+// Package framecrypt signs and encrypts stream frames with a pre-shared key, so
+// consumers of a plain ws:// stream, possibly through relays, can verify that frames
+// come from the server unmodified and keep their content private.
+//
+// Both keys are derived from the pre-shared key with HMAC-SHA256. A frame's signature
+// is the hex HMAC-SHA256 of its header, a newline, and its payload: the encoded image,
+// or for encrypted frames a 12-byte nonce followed by the AES-256-GCM ciphertext of the
+// image, sealed with the header as additional data.
+package framecrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// MinKeyLength is the shortest pre-shared key accepted, in bytes
+const MinKeyLength = 16
+
+// Key derivation labels
+const (
+	encryptionLabel = "screenshot-mcp frame encryption"
+	signatureLabel  = "screenshot-mcp frame signature"
+)
+
+// ErrInvalidSignature is returned for frames whose signature doesn't match
+var ErrInvalidSignature = errors.New("invalid frame signature")
+
+// Header is the part of a frame message covered by its signature
+type Header struct {
+	SessionID   string
+	FrameNumber int64
+	Keyframe    bool
+	Width       int
+	Height      int
+	Format      string
+	MonotonicNs int64 // timing.monotonic_ns of the frame
+}
+
+// bytes returns the header's canonical form: its fields in order, one per line
+func (h Header) bytes() []byte {
+	return fmt.Appendf(nil, "%s\n%d\n%t\n%d\n%d\n%s\n%d", h.SessionID, h.FrameNumber, h.Keyframe, h.Width, h.Height, h.Format, h.MonotonicNs)
+}
+
+// Key signs, verifies, encrypts and decrypts frames
+type Key struct {
+	aead cipher.AEAD
+	mac  []byte
+}
+
+// NewKey derives the frame keys from a pre-shared key
+func NewKey(psk string) (*Key, error) {
+	if len(psk) < MinKeyLength {
+		return nil, fmt.Errorf("stream key must be at least %d bytes", MinKeyLength)
+	}
+	block, err := aes.NewCipher(derive(psk, encryptionLabel))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead, mac: derive(psk, signatureLabel)}, nil
+}
+
+// derive returns HMAC-SHA256(psk, label)
+func derive(psk, label string) []byte {
+	mac := hmac.New(sha256.New, []byte(psk))
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// Sign returns the hex signature of a frame's header and payload
+func (k *Key) Sign(header Header, payload []byte) string {
+	return hex.EncodeToString(k.sum(header, payload))
+}
+
+// Verify checks a frame's signature
+func (k *Key) Verify(header Header, payload []byte, signature string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, k.sum(header, payload)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (k *Key) sum(header Header, payload []byte) []byte {
+	mac := hmac.New(sha256.New, k.mac)
+	mac.Write(header.bytes())
+	mac.Write([]byte{'\n'})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Encrypt returns the encrypted payload of an encoded image: a random nonce followed by
+// the ciphertext
+func (k *Key) Encrypt(header Header, image []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(image)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, image, header.bytes()), nil
+}
+
+// Decrypt returns the encoded image of an encrypted payload
+func (k *Key) Decrypt(header Header, payload []byte) ([]byte, error) {
+	if len(payload) < k.aead.NonceSize() {
+		return nil, errors.New("encrypted frame is too short")
+	}
+	nonce, ciphertext := payload[:k.aead.NonceSize()], payload[k.aead.NonceSize():]
+	image, err := k.aead.Open(nil, nonce, ciphertext, header.bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt frame: %w", err)
+	}
+	return image, nil
+}
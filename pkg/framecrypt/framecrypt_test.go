@@ -0,0 +1,153 @@
+package framecrypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "0123456789abcdef-stream-key"
+
+func testHeader() Header {
+	return Header{
+		SessionID:   "stream_65552_1700000000",
+		FrameNumber: 42,
+		Keyframe:    true,
+		Width:       800,
+		Height:      600,
+		Format:      "jpeg",
+		MonotonicNs: 123456789,
+	}
+}
+
+func newTestKey(t *testing.T) *Key {
+	key, err := NewKey(testKey)
+	require.NoError(t, err)
+	return key
+}
+
+func TestNewKeyRejectsShortKeys(t *testing.T) {
+	_, err := NewKey(strings.Repeat("k", MinKeyLength-1))
+	assert.Error(t, err)
+	_, err = NewKey("")
+	assert.Error(t, err)
+
+	_, err = NewKey(strings.Repeat("k", MinKeyLength))
+	assert.NoError(t, err)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	key := newTestKey(t)
+	image := []byte("\xff\xd8\xff\xe0 not really a jpeg")
+
+	payload, err := key.Encrypt(testHeader(), image)
+	require.NoError(t, err)
+	assert.NotContains(t, string(payload), string(image))
+
+	decrypted, err := key.Decrypt(testHeader(), payload)
+	require.NoError(t, err)
+	assert.Equal(t, image, decrypted)
+
+	// Nonces are random, so the same frame encrypts differently each time
+	again, err := key.Encrypt(testHeader(), image)
+	require.NoError(t, err)
+	assert.NotEqual(t, payload, again)
+}
+
+func TestDecryptFailsWhenHeaderChanges(t *testing.T) {
+	key := newTestKey(t)
+	payload, err := key.Encrypt(testHeader(), []byte("frame"))
+	require.NoError(t, err)
+
+	changes := map[string]func(*Header){
+		"session":      func(h *Header) { h.SessionID += "x" },
+		"frame number": func(h *Header) { h.FrameNumber++ },
+		"keyframe":     func(h *Header) { h.Keyframe = !h.Keyframe },
+		"width":        func(h *Header) { h.Width++ },
+		"height":       func(h *Header) { h.Height++ },
+		"format":       func(h *Header) { h.Format = "png" },
+		"monotonic":    func(h *Header) { h.MonotonicNs++ },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			header := testHeader()
+			change(&header)
+			_, err := key.Decrypt(header, payload)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecryptFailsOnTamperedOrShortPayloads(t *testing.T) {
+	key := newTestKey(t)
+	payload, err := key.Encrypt(testHeader(), []byte("frame"))
+	require.NoError(t, err)
+
+	tampered := append([]byte(nil), payload...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = key.Decrypt(testHeader(), tampered)
+	assert.Error(t, err)
+
+	_, err = key.Decrypt(testHeader(), payload[:5])
+	assert.ErrorContains(t, err, "too short")
+
+	other, err := NewKey(testKey + "-other")
+	require.NoError(t, err)
+	_, err = other.Decrypt(testHeader(), payload)
+	assert.Error(t, err)
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	key := newTestKey(t)
+	payload := []byte("frame payload")
+
+	signature := key.Sign(testHeader(), payload)
+	assert.Len(t, signature, 64)
+	assert.NoError(t, key.Verify(testHeader(), payload, signature))
+
+	header := testHeader()
+	header.FrameNumber++
+	assert.ErrorIs(t, key.Verify(header, payload, signature), ErrInvalidSignature)
+}
+
+func TestVerifyRejectsTamperedFrames(t *testing.T) {
+	key := newTestKey(t)
+	payload := []byte("frame payload")
+	signature := key.Sign(testHeader(), payload)
+
+	for i := range payload {
+		flipped := append([]byte(nil), payload...)
+		flipped[i] ^= 1
+		assert.ErrorIs(t, key.Verify(testHeader(), flipped, signature), ErrInvalidSignature, "payload byte %d", i)
+	}
+
+	raw := []byte(signature)
+	for i := range raw {
+		flipped := append([]byte(nil), raw...)
+		// Change the hex digit to another valid one
+		if flipped[i] == '0' {
+			flipped[i] = '1'
+		} else {
+			flipped[i] = '0'
+		}
+		assert.ErrorIs(t, key.Verify(testHeader(), payload, string(flipped)), ErrInvalidSignature, "signature byte %d", i)
+	}
+
+	cases := map[string]string{
+		"not hex":  strings.Repeat("zz", 32),
+		"odd hex":  signature[:63],
+		"short":    signature[:32],
+		"empty":    "",
+		"too long": signature + "00",
+	}
+	for name, bad := range cases {
+		assert.ErrorIs(t, key.Verify(testHeader(), payload, bad), ErrInvalidSignature, name)
+	}
+
+	// Signatures cover the whole payload, including short ones
+	assert.ErrorIs(t, key.Verify(testHeader(), payload[:4], signature), ErrInvalidSignature)
+	assert.ErrorIs(t, key.Verify(testHeader(), nil, signature), ErrInvalidSignature)
+	assert.NoError(t, key.Verify(testHeader(), nil, key.Sign(testHeader(), nil)))
+}
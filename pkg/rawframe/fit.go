@@ -0,0 +1,52 @@
+package rawframe
+
+import (
+	"fmt"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// FitBGR scales a capture into dst, a width x height frame of 24-bit BGR pixels with no
+// padding between rows, keeping its aspect ratio with black bars. It feeds outputs of a
+// fixed size, such as the virtual webcam; nearest-neighbour scaling keeps it cheap at
+// video frame rates.
+func FitBGR(dst []byte, width, height int, buffer *types.ScreenshotBuffer) error {
+	var r, b int
+	switch buffer.Format {
+	case "BGRA32":
+		r, b = 2, 0
+	case "RGBA32":
+		r, b = 0, 2
+	default:
+		return fmt.Errorf("unsupported buffer format: %s", buffer.Format)
+	}
+	if buffer.Width <= 0 || buffer.Height <= 0 {
+		return fmt.Errorf("empty %dx%d capture", buffer.Width, buffer.Height)
+	}
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+	if len(buffer.Data) < stride*(buffer.Height-1)+buffer.Width*4 {
+		return fmt.Errorf("capture data is shorter than its %dx%d size", buffer.Width, buffer.Height)
+	}
+
+	// The largest size of the capture's aspect ratio that fits, centered
+	w, h := width, buffer.Height*width/buffer.Width
+	if h > height {
+		w, h = buffer.Width*height/buffer.Height, height
+	}
+	w, h = max(w, 1), max(h, 1)
+	left, top := (width-w)/2, (height-h)/2
+
+	clear(dst)
+	for y := 0; y < h; y++ {
+		row := buffer.Data[(y*buffer.Height/h)*stride:]
+		out := dst[((top+y)*width+left)*3:]
+		for x := 0; x < w; x++ {
+			p := row[(x*buffer.Width/w)*4:]
+			out[x*3], out[x*3+1], out[x*3+2] = p[b], p[1], p[r]
+		}
+	}
+	return nil
+}
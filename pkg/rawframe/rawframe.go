@@ -0,0 +1,127 @@
+// Package rawframe packs stream frames as unencoded BGRA pixels, for viewers on the
+// same machine or LAN that would rather blit pixels than decode JPEG or PNG.
+//
+// A raw frame is a binary WebSocket message: a 32-byte little-endian header followed by
+// the pixels, top row first, 4 bytes per pixel in B, G, R, A order and no padding
+// between rows, so a frame is HeaderSize + width*height*4 bytes.
+//
+//	offset  size  field
+//	0       4     magic "BGRA"
+//	4       2     header size, 32
+//	6       2     flags: 1 = the frame must be acknowledged
+//	8       4     width
+//	12      4     height
+//	16      8     frame number
+//	24      8     capture time, Unix nanoseconds
+package rawframe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// HeaderSize is the size of a raw frame's header, in bytes
+const HeaderSize = 32
+
+// Magic starts every raw frame
+const Magic = "BGRA"
+
+// flagAckRequired marks frames of sessions in ack mode
+const flagAckRequired = 1
+
+// Header describes the pixels of a raw frame
+type Header struct {
+	Width       int
+	Height      int
+	FrameNumber int64
+	CapturedAt  time.Time
+	AckRequired bool
+}
+
+// Encode packs a capture into a raw frame. Captures in RGBA order are swapped to BGRA.
+func Encode(header Header, buffer *types.ScreenshotBuffer) ([]byte, error) {
+	frame := make([]byte, HeaderSize+buffer.Width*buffer.Height*4)
+	if err := CopyPixels(frame[HeaderSize:], buffer); err != nil {
+		return nil, err
+	}
+	header.Width, header.Height = buffer.Width, buffer.Height
+	header.put(frame)
+	return frame, nil
+}
+
+// CopyPixels copies a capture's pixels to dst in the layout of raw frames: BGRA, with no
+// padding between rows. dst holds at least width*height*4 bytes.
+func CopyPixels(dst []byte, buffer *types.ScreenshotBuffer) error {
+	swap := false
+	switch buffer.Format {
+	case "BGRA32":
+	case "RGBA32":
+		swap = true
+	default:
+		return fmt.Errorf("unsupported buffer format for raw frames: %s", buffer.Format)
+	}
+	stride := buffer.Stride
+	if stride == 0 {
+		stride = buffer.Width * 4
+	}
+	row := buffer.Width * 4
+	if buffer.Height > 0 && len(buffer.Data) < stride*(buffer.Height-1)+row {
+		return fmt.Errorf("capture data is shorter than its %dx%d size", buffer.Width, buffer.Height)
+	}
+	if len(dst) < row*buffer.Height {
+		return fmt.Errorf("%d bytes can't hold %dx%d pixels", len(dst), buffer.Width, buffer.Height)
+	}
+
+	for y := 0; y < buffer.Height; y++ {
+		line := dst[y*row : (y+1)*row]
+		copy(line, buffer.Data[y*stride:y*stride+row])
+		if swap {
+			for x := 0; x < row; x += 4 {
+				line[x], line[x+2] = line[x+2], line[x]
+			}
+		}
+	}
+	return nil
+}
+
+// Decode splits a raw frame into its header and pixels. The pixels alias data.
+func Decode(data []byte) (Header, []byte, error) {
+	if len(data) < HeaderSize || string(data[:4]) != Magic {
+		return Header{}, nil, errors.New("not a raw frame")
+	}
+	size := int(binary.LittleEndian.Uint16(data[4:]))
+	if size < HeaderSize || size > len(data) {
+		return Header{}, nil, fmt.Errorf("invalid raw frame header size %d", size)
+	}
+	header := Header{
+		Width:       int(binary.LittleEndian.Uint32(data[8:])),
+		Height:      int(binary.LittleEndian.Uint32(data[12:])),
+		FrameNumber: int64(binary.LittleEndian.Uint64(data[16:])),
+		CapturedAt:  time.Unix(0, int64(binary.LittleEndian.Uint64(data[24:]))),
+		AckRequired: binary.LittleEndian.Uint16(data[6:])&flagAckRequired != 0,
+	}
+	pixels := data[size:]
+	if len(pixels) != header.Width*header.Height*4 {
+		return Header{}, nil, fmt.Errorf("raw frame holds %d bytes of pixels, want %d for %dx%d", len(pixels), header.Width*header.Height*4, header.Width, header.Height)
+	}
+	return header, pixels, nil
+}
+
+// put writes the header to the start of frame
+func (h Header) put(frame []byte) {
+	var flags uint16
+	if h.AckRequired {
+		flags |= flagAckRequired
+	}
+	copy(frame, Magic)
+	binary.LittleEndian.PutUint16(frame[4:], HeaderSize)
+	binary.LittleEndian.PutUint16(frame[6:], flags)
+	binary.LittleEndian.PutUint32(frame[8:], uint32(h.Width))
+	binary.LittleEndian.PutUint32(frame[12:], uint32(h.Height))
+	binary.LittleEndian.PutUint64(frame[16:], uint64(h.FrameNumber))
+	binary.LittleEndian.PutUint64(frame[24:], uint64(h.CapturedAt.UnixNano()))
+}
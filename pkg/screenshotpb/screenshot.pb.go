@@ -0,0 +1,2098 @@
+// gRPC API of the screenshot server. It mirrors the HTTP and MCP methods for clients
+// that prefer typed stubs and HTTP/2 streaming; requests are validated and captured by
+// the same code paths, so fields behave as documented for POST /v1/screenshot.
+//
+// Regenerate pkg/screenshotpb with `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.28.0
+// source: screenshot/v1/screenshot.proto
+
+package screenshotpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Rectangle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X      int32 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y      int32 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Width  int32 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height int32 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *Rectangle) Reset() {
+	*x = Rectangle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rectangle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rectangle) ProtoMessage() {}
+
+func (x *Rectangle) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rectangle.ProtoReflect.Descriptor instead.
+func (*Rectangle) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Rectangle) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Rectangle) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Rectangle) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Rectangle) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type PixelCondition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X         int32  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y         int32  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Color     string `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"` // "#RRGGBB"
+	Tolerance int32  `protobuf:"varint,4,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+}
+
+func (x *PixelCondition) Reset() {
+	*x = PixelCondition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PixelCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PixelCondition) ProtoMessage() {}
+
+func (x *PixelCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PixelCondition.ProtoReflect.Descriptor instead.
+func (*PixelCondition) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PixelCondition) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *PixelCondition) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *PixelCondition) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *PixelCondition) GetTolerance() int32 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+type WaitCondition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TitleRegex   string          `protobuf:"bytes,1,opt,name=title_regex,json=titleRegex,proto3" json:"title_regex,omitempty"`
+	Element      string          `protobuf:"bytes,2,opt,name=element,proto3" json:"element,omitempty"`
+	Pixel        *PixelCondition `protobuf:"bytes,3,opt,name=pixel,proto3" json:"pixel,omitempty"`
+	StableFrames int32           `protobuf:"varint,4,opt,name=stable_frames,json=stableFrames,proto3" json:"stable_frames,omitempty"`
+	Timeout      string          `protobuf:"bytes,5,opt,name=timeout,proto3" json:"timeout,omitempty"`   // Duration string, default "10s"
+	Interval     string          `protobuf:"bytes,6,opt,name=interval,proto3" json:"interval,omitempty"` // Duration string, default "250ms"
+}
+
+func (x *WaitCondition) Reset() {
+	*x = WaitCondition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WaitCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitCondition) ProtoMessage() {}
+
+func (x *WaitCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitCondition.ProtoReflect.Descriptor instead.
+func (*WaitCondition) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WaitCondition) GetTitleRegex() string {
+	if x != nil {
+		return x.TitleRegex
+	}
+	return ""
+}
+
+func (x *WaitCondition) GetElement() string {
+	if x != nil {
+		return x.Element
+	}
+	return ""
+}
+
+func (x *WaitCondition) GetPixel() *PixelCondition {
+	if x != nil {
+		return x.Pixel
+	}
+	return nil
+}
+
+func (x *WaitCondition) GetStableFrames() int32 {
+	if x != nil {
+		return x.StableFrames
+	}
+	return 0
+}
+
+func (x *WaitCondition) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+func (x *WaitCondition) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+type PopupCapture struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Within    string   `protobuf:"bytes,1,opt,name=within,proto3" json:"within,omitempty"` // Duration string, default "5s"
+	MaxPopups int32    `protobuf:"varint,2,opt,name=max_popups,json=maxPopups,proto3" json:"max_popups,omitempty"`
+	Classes   []string `protobuf:"bytes,3,rep,name=classes,proto3" json:"classes,omitempty"`
+}
+
+func (x *PopupCapture) Reset() {
+	*x = PopupCapture{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PopupCapture) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PopupCapture) ProtoMessage() {}
+
+func (x *PopupCapture) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PopupCapture.ProtoReflect.Descriptor instead.
+func (*PopupCapture) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PopupCapture) GetWithin() string {
+	if x != nil {
+		return x.Within
+	}
+	return ""
+}
+
+func (x *PopupCapture) GetMaxPopups() int32 {
+	if x != nil {
+		return x.MaxPopups
+	}
+	return 0
+}
+
+func (x *PopupCapture) GetClasses() []string {
+	if x != nil {
+		return x.Classes
+	}
+	return nil
+}
+
+type CaptureRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// "title", "title_contains", "title_regex", "title_fuzzy", "pid", "process",
+	// "handle", "class", "foreground", "under_cursor", a shell surface or "alias"
+	// with a target alias name as target
+	Method               string         `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Target               string         `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Format               string         `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"` // "png", "jpeg" or "bmp"; default from the server config
+	Quality              int32          `protobuf:"varint,4,opt,name=quality,proto3" json:"quality,omitempty"`
+	IncludeCursor        bool           `protobuf:"varint,5,opt,name=include_cursor,json=includeCursor,proto3" json:"include_cursor,omitempty"`
+	Region               *Rectangle     `protobuf:"bytes,6,opt,name=region,proto3" json:"region,omitempty"`
+	RegionRelativeTo     string         `protobuf:"bytes,7,opt,name=region_relative_to,json=regionRelativeTo,proto3" json:"region_relative_to,omitempty"` // "window" (default), "client" or "screen"
+	CaptureMethod        string         `protobuf:"bytes,8,opt,name=capture_method,json=captureMethod,proto3" json:"capture_method,omitempty"`
+	FallbackMethods      []string       `protobuf:"bytes,9,rep,name=fallback_methods,json=fallbackMethods,proto3" json:"fallback_methods,omitempty"`
+	Match                string         `protobuf:"bytes,10,opt,name=match,proto3" json:"match,omitempty"` // "best" picks the top-ranked window for ambiguous title_* lookups
+	TopLevel             bool           `protobuf:"varint,11,opt,name=top_level,json=topLevel,proto3" json:"top_level,omitempty"`
+	WaitFor              *WaitCondition `protobuf:"bytes,12,opt,name=wait_for,json=waitFor,proto3" json:"wait_for,omitempty"`
+	RetryCount           *int32         `protobuf:"varint,13,opt,name=retry_count,json=retryCount,proto3,oneof" json:"retry_count,omitempty"`
+	RetryBackoff         string         `protobuf:"bytes,14,opt,name=retry_backoff,json=retryBackoff,proto3" json:"retry_backoff,omitempty"`
+	RejectBlackFrames    bool           `protobuf:"varint,15,opt,name=reject_black_frames,json=rejectBlackFrames,proto3" json:"reject_black_frames,omitempty"`
+	CaptureOtherDesktops bool           `protobuf:"varint,16,opt,name=capture_other_desktops,json=captureOtherDesktops,proto3" json:"capture_other_desktops,omitempty"`
+	IncludeOwnedWindows  bool           `protobuf:"varint,17,opt,name=include_owned_windows,json=includeOwnedWindows,proto3" json:"include_owned_windows,omitempty"`
+	IncludeFrame         *bool          `protobuf:"varint,18,opt,name=include_frame,json=includeFrame,proto3,oneof" json:"include_frame,omitempty"`       // Default true
+	AllowMinimized       *bool          `protobuf:"varint,19,opt,name=allow_minimized,json=allowMinimized,proto3,oneof" json:"allow_minimized,omitempty"` // Default true
+	RestoreWindow        bool           `protobuf:"varint,20,opt,name=restore_window,json=restoreWindow,proto3" json:"restore_window,omitempty"`
+	Popups               *PopupCapture  `protobuf:"bytes,21,opt,name=popups,proto3" json:"popups,omitempty"`
+}
+
+func (x *CaptureRequest) Reset() {
+	*x = CaptureRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureRequest) ProtoMessage() {}
+
+func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureRequest.ProtoReflect.Descriptor instead.
+func (*CaptureRequest) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CaptureRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetIncludeCursor() bool {
+	if x != nil {
+		return x.IncludeCursor
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetRegion() *Rectangle {
+	if x != nil {
+		return x.Region
+	}
+	return nil
+}
+
+func (x *CaptureRequest) GetRegionRelativeTo() string {
+	if x != nil {
+		return x.RegionRelativeTo
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetCaptureMethod() string {
+	if x != nil {
+		return x.CaptureMethod
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetFallbackMethods() []string {
+	if x != nil {
+		return x.FallbackMethods
+	}
+	return nil
+}
+
+func (x *CaptureRequest) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetTopLevel() bool {
+	if x != nil {
+		return x.TopLevel
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetWaitFor() *WaitCondition {
+	if x != nil {
+		return x.WaitFor
+	}
+	return nil
+}
+
+func (x *CaptureRequest) GetRetryCount() int32 {
+	if x != nil && x.RetryCount != nil {
+		return *x.RetryCount
+	}
+	return 0
+}
+
+func (x *CaptureRequest) GetRetryBackoff() string {
+	if x != nil {
+		return x.RetryBackoff
+	}
+	return ""
+}
+
+func (x *CaptureRequest) GetRejectBlackFrames() bool {
+	if x != nil {
+		return x.RejectBlackFrames
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetCaptureOtherDesktops() bool {
+	if x != nil {
+		return x.CaptureOtherDesktops
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetIncludeOwnedWindows() bool {
+	if x != nil {
+		return x.IncludeOwnedWindows
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetIncludeFrame() bool {
+	if x != nil && x.IncludeFrame != nil {
+		return *x.IncludeFrame
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetAllowMinimized() bool {
+	if x != nil && x.AllowMinimized != nil {
+		return *x.AllowMinimized
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetRestoreWindow() bool {
+	if x != nil {
+		return x.RestoreWindow
+	}
+	return false
+}
+
+func (x *CaptureRequest) GetPopups() *PopupCapture {
+	if x != nil {
+		return x.Popups
+	}
+	return nil
+}
+
+type WindowInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Handle           uint64     `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	Title            string     `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	ClassName        string     `protobuf:"bytes,3,opt,name=class_name,json=className,proto3" json:"class_name,omitempty"`
+	ProcessId        uint32     `protobuf:"varint,4,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+	ThreadId         uint32     `protobuf:"varint,5,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Rect             *Rectangle `protobuf:"bytes,6,opt,name=rect,proto3" json:"rect,omitempty"`
+	ClientRect       *Rectangle `protobuf:"bytes,7,opt,name=client_rect,json=clientRect,proto3" json:"client_rect,omitempty"`
+	State            string     `protobuf:"bytes,8,opt,name=state,proto3" json:"state,omitempty"`
+	ZOrder           int32      `protobuf:"varint,9,opt,name=z_order,json=zOrder,proto3" json:"z_order,omitempty"`
+	IsVisible        bool       `protobuf:"varint,10,opt,name=is_visible,json=isVisible,proto3" json:"is_visible,omitempty"`
+	IsTopmost        bool       `protobuf:"varint,11,opt,name=is_topmost,json=isTopmost,proto3" json:"is_topmost,omitempty"`
+	Monitor          int32      `protobuf:"varint,12,opt,name=monitor,proto3" json:"monitor,omitempty"`
+	IntegrityLevel   string     `protobuf:"bytes,13,opt,name=integrity_level,json=integrityLevel,proto3" json:"integrity_level,omitempty"`
+	DisplayAffinity  string     `protobuf:"bytes,14,opt,name=display_affinity,json=displayAffinity,proto3" json:"display_affinity,omitempty"`
+	VirtualDesktopId string     `protobuf:"bytes,15,opt,name=virtual_desktop_id,json=virtualDesktopId,proto3" json:"virtual_desktop_id,omitempty"`
+	OnCurrentDesktop *bool      `protobuf:"varint,16,opt,name=on_current_desktop,json=onCurrentDesktop,proto3,oneof" json:"on_current_desktop,omitempty"`
+	OccludedPercent  float64    `protobuf:"fixed64,17,opt,name=occluded_percent,json=occludedPercent,proto3" json:"occluded_percent,omitempty"`
+	OccludedBy       []uint64   `protobuf:"varint,18,rep,packed,name=occluded_by,json=occludedBy,proto3" json:"occluded_by,omitempty"`
+}
+
+func (x *WindowInfo) Reset() {
+	*x = WindowInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WindowInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WindowInfo) ProtoMessage() {}
+
+func (x *WindowInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WindowInfo.ProtoReflect.Descriptor instead.
+func (*WindowInfo) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WindowInfo) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetClassName() string {
+	if x != nil {
+		return x.ClassName
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetProcessId() uint32 {
+	if x != nil {
+		return x.ProcessId
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetThreadId() uint32 {
+	if x != nil {
+		return x.ThreadId
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetRect() *Rectangle {
+	if x != nil {
+		return x.Rect
+	}
+	return nil
+}
+
+func (x *WindowInfo) GetClientRect() *Rectangle {
+	if x != nil {
+		return x.ClientRect
+	}
+	return nil
+}
+
+func (x *WindowInfo) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetZOrder() int32 {
+	if x != nil {
+		return x.ZOrder
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetIsVisible() bool {
+	if x != nil {
+		return x.IsVisible
+	}
+	return false
+}
+
+func (x *WindowInfo) GetIsTopmost() bool {
+	if x != nil {
+		return x.IsTopmost
+	}
+	return false
+}
+
+func (x *WindowInfo) GetMonitor() int32 {
+	if x != nil {
+		return x.Monitor
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetIntegrityLevel() string {
+	if x != nil {
+		return x.IntegrityLevel
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetDisplayAffinity() string {
+	if x != nil {
+		return x.DisplayAffinity
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetVirtualDesktopId() string {
+	if x != nil {
+		return x.VirtualDesktopId
+	}
+	return ""
+}
+
+func (x *WindowInfo) GetOnCurrentDesktop() bool {
+	if x != nil && x.OnCurrentDesktop != nil {
+		return *x.OnCurrentDesktop
+	}
+	return false
+}
+
+func (x *WindowInfo) GetOccludedPercent() float64 {
+	if x != nil {
+		return x.OccludedPercent
+	}
+	return 0
+}
+
+func (x *WindowInfo) GetOccludedBy() []uint64 {
+	if x != nil {
+		return x.OccludedBy
+	}
+	return nil
+}
+
+type CaptureAttempt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Method     string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	DurationUs int64  `protobuf:"varint,2,opt,name=duration_us,json=durationUs,proto3" json:"duration_us,omitempty"`
+	Success    bool   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	Error      string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Retry      int32  `protobuf:"varint,5,opt,name=retry,proto3" json:"retry,omitempty"`
+}
+
+func (x *CaptureAttempt) Reset() {
+	*x = CaptureAttempt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureAttempt) ProtoMessage() {}
+
+func (x *CaptureAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureAttempt.ProtoReflect.Descriptor instead.
+func (*CaptureAttempt) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CaptureAttempt) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CaptureAttempt) GetDurationUs() int64 {
+	if x != nil {
+		return x.DurationUs
+	}
+	return 0
+}
+
+func (x *CaptureAttempt) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CaptureAttempt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CaptureAttempt) GetRetry() int32 {
+	if x != nil {
+		return x.Retry
+	}
+	return 0
+}
+
+type Metadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CaptureMethod      string            `protobuf:"bytes,1,opt,name=capture_method,json=captureMethod,proto3" json:"capture_method,omitempty"`
+	ProcessingTimeUs   int64             `protobuf:"varint,2,opt,name=processing_time_us,json=processingTimeUs,proto3" json:"processing_time_us,omitempty"`
+	WindowVisible      bool              `protobuf:"varint,3,opt,name=window_visible,json=windowVisible,proto3" json:"window_visible,omitempty"`
+	WindowMinimized    bool              `protobuf:"varint,4,opt,name=window_minimized,json=windowMinimized,proto3" json:"window_minimized,omitempty"`
+	DpiScaling         float64           `protobuf:"fixed64,5,opt,name=dpi_scaling,json=dpiScaling,proto3" json:"dpi_scaling,omitempty"`
+	BlackFrameDetected bool              `protobuf:"varint,6,opt,name=black_frame_detected,json=blackFrameDetected,proto3" json:"black_frame_detected,omitempty"`
+	Attempts           []*CaptureAttempt `protobuf:"bytes,7,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	Retries            int32             `protobuf:"varint,8,opt,name=retries,proto3" json:"retries,omitempty"`
+	OccludedPercent    float64           `protobuf:"fixed64,9,opt,name=occluded_percent,json=occludedPercent,proto3" json:"occluded_percent,omitempty"`
+	OccludedBy         []uint64          `protobuf:"varint,10,rep,packed,name=occluded_by,json=occludedBy,proto3" json:"occluded_by,omitempty"`
+	OwnedWindows       []*WindowInfo     `protobuf:"bytes,11,rep,name=owned_windows,json=ownedWindows,proto3" json:"owned_windows,omitempty"`
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Metadata) GetCaptureMethod() string {
+	if x != nil {
+		return x.CaptureMethod
+	}
+	return ""
+}
+
+func (x *Metadata) GetProcessingTimeUs() int64 {
+	if x != nil {
+		return x.ProcessingTimeUs
+	}
+	return 0
+}
+
+func (x *Metadata) GetWindowVisible() bool {
+	if x != nil {
+		return x.WindowVisible
+	}
+	return false
+}
+
+func (x *Metadata) GetWindowMinimized() bool {
+	if x != nil {
+		return x.WindowMinimized
+	}
+	return false
+}
+
+func (x *Metadata) GetDpiScaling() float64 {
+	if x != nil {
+		return x.DpiScaling
+	}
+	return 0
+}
+
+func (x *Metadata) GetBlackFrameDetected() bool {
+	if x != nil {
+		return x.BlackFrameDetected
+	}
+	return false
+}
+
+func (x *Metadata) GetAttempts() []*CaptureAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+func (x *Metadata) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+func (x *Metadata) GetOccludedPercent() float64 {
+	if x != nil {
+		return x.OccludedPercent
+	}
+	return 0
+}
+
+func (x *Metadata) GetOccludedBy() []uint64 {
+	if x != nil {
+		return x.OccludedBy
+	}
+	return nil
+}
+
+func (x *Metadata) GetOwnedWindows() []*WindowInfo {
+	if x != nil {
+		return x.OwnedWindows
+	}
+	return nil
+}
+
+type Image struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data      []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Format    string                 `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	Width     int32                  `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+	Height    int32                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Image) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Image) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Image) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Image) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Image) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type Popup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Window *WindowInfo `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	Image  *Image      `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+}
+
+func (x *Popup) Reset() {
+	*x = Popup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Popup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Popup) ProtoMessage() {}
+
+func (x *Popup) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Popup.ProtoReflect.Descriptor instead.
+func (*Popup) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Popup) GetWindow() *WindowInfo {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *Popup) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+type CaptureResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Image    *Image      `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	Window   *WindowInfo `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	Metadata *Metadata   `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Popups   []*Popup    `protobuf:"bytes,4,rep,name=popups,proto3" json:"popups,omitempty"`
+}
+
+func (x *CaptureResponse) Reset() {
+	*x = CaptureResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureResponse) ProtoMessage() {}
+
+func (x *CaptureResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureResponse.ProtoReflect.Descriptor instead.
+func (*CaptureResponse) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CaptureResponse) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *CaptureResponse) GetWindow() *WindowInfo {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *CaptureResponse) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CaptureResponse) GetPopups() []*Popup {
+	if x != nil {
+		return x.Popups
+	}
+	return nil
+}
+
+type ListWindowsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TitleContains  string `protobuf:"bytes,1,opt,name=title_contains,json=titleContains,proto3" json:"title_contains,omitempty"`
+	VisibleOnly    bool   `protobuf:"varint,2,opt,name=visible_only,json=visibleOnly,proto3" json:"visible_only,omitempty"`
+	ExcludeSystem  bool   `protobuf:"varint,3,opt,name=exclude_system,json=excludeSystem,proto3" json:"exclude_system,omitempty"`
+	VirtualDesktop string `protobuf:"bytes,4,opt,name=virtual_desktop,json=virtualDesktop,proto3" json:"virtual_desktop,omitempty"` // "current", "other" or a virtual desktop ID
+}
+
+func (x *ListWindowsRequest) Reset() {
+	*x = ListWindowsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListWindowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWindowsRequest) ProtoMessage() {}
+
+func (x *ListWindowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWindowsRequest.ProtoReflect.Descriptor instead.
+func (*ListWindowsRequest) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListWindowsRequest) GetTitleContains() string {
+	if x != nil {
+		return x.TitleContains
+	}
+	return ""
+}
+
+func (x *ListWindowsRequest) GetVisibleOnly() bool {
+	if x != nil {
+		return x.VisibleOnly
+	}
+	return false
+}
+
+func (x *ListWindowsRequest) GetExcludeSystem() bool {
+	if x != nil {
+		return x.ExcludeSystem
+	}
+	return false
+}
+
+func (x *ListWindowsRequest) GetVirtualDesktop() string {
+	if x != nil {
+		return x.VirtualDesktop
+	}
+	return ""
+}
+
+type ListWindowsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Windows []*WindowInfo `protobuf:"bytes,1,rep,name=windows,proto3" json:"windows,omitempty"`
+}
+
+func (x *ListWindowsResponse) Reset() {
+	*x = ListWindowsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListWindowsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWindowsResponse) ProtoMessage() {}
+
+func (x *ListWindowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWindowsResponse.ProtoReflect.Descriptor instead.
+func (*ListWindowsResponse) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListWindowsResponse) GetWindows() []*WindowInfo {
+	if x != nil {
+		return x.Windows
+	}
+	return nil
+}
+
+type StreamFramesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Handle    uint64 `protobuf:"varint,1,opt,name=handle,proto3" json:"handle,omitempty"`   // 0 streams the desktop
+	Fps       int32  `protobuf:"varint,2,opt,name=fps,proto3" json:"fps,omitempty"`         // 1-60; default from the server config
+	Format    string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`    // "png" or "jpeg"; default from the server config
+	Quality   int32  `protobuf:"varint,4,opt,name=quality,proto3" json:"quality,omitempty"` // 1-100; default from the server config
+	MaxWidth  int32  `protobuf:"varint,5,opt,name=max_width,json=maxWidth,proto3" json:"max_width,omitempty"`
+	MaxHeight int32  `protobuf:"varint,6,opt,name=max_height,json=maxHeight,proto3" json:"max_height,omitempty"`
+}
+
+func (x *StreamFramesRequest) Reset() {
+	*x = StreamFramesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamFramesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamFramesRequest) ProtoMessage() {}
+
+func (x *StreamFramesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamFramesRequest.ProtoReflect.Descriptor instead.
+func (*StreamFramesRequest) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StreamFramesRequest) GetHandle() uint64 {
+	if x != nil {
+		return x.Handle
+	}
+	return 0
+}
+
+func (x *StreamFramesRequest) GetFps() int32 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *StreamFramesRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *StreamFramesRequest) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *StreamFramesRequest) GetMaxWidth() int32 {
+	if x != nil {
+		return x.MaxWidth
+	}
+	return 0
+}
+
+func (x *StreamFramesRequest) GetMaxHeight() int32 {
+	if x != nil {
+		return x.MaxHeight
+	}
+	return 0
+}
+
+type Frame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FrameNumber int64  `protobuf:"varint,1,opt,name=frame_number,json=frameNumber,proto3" json:"frame_number,omitempty"`
+	Image       *Image `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"` // Encoded in the requested format
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Frame) GetFrameNumber() int64 {
+	if x != nil {
+		return x.FrameNumber
+	}
+	return 0
+}
+
+func (x *Frame) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+type DesktopStateChange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Available bool   `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
+	State     string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"` // Why the desktop is unavailable, e.g. "locked"
+}
+
+func (x *DesktopStateChange) Reset() {
+	*x = DesktopStateChange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DesktopStateChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DesktopStateChange) ProtoMessage() {}
+
+func (x *DesktopStateChange) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DesktopStateChange.ProtoReflect.Descriptor instead.
+func (*DesktopStateChange) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DesktopStateChange) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *DesktopStateChange) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type StreamEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*StreamEvent_Frame
+	//	*StreamEvent_DesktopState
+	Event isStreamEvent_Event `protobuf_oneof:"event"`
+}
+
+func (x *StreamEvent) Reset() {
+	*x = StreamEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_screenshot_v1_screenshot_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEvent) ProtoMessage() {}
+
+func (x *StreamEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_screenshot_v1_screenshot_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEvent.ProtoReflect.Descriptor instead.
+func (*StreamEvent) Descriptor() ([]byte, []int) {
+	return file_screenshot_v1_screenshot_proto_rawDescGZIP(), []int{16}
+}
+
+func (m *StreamEvent) GetEvent() isStreamEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *StreamEvent) GetFrame() *Frame {
+	if x, ok := x.GetEvent().(*StreamEvent_Frame); ok {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *StreamEvent) GetDesktopState() *DesktopStateChange {
+	if x, ok := x.GetEvent().(*StreamEvent_DesktopState); ok {
+		return x.DesktopState
+	}
+	return nil
+}
+
+type isStreamEvent_Event interface {
+	isStreamEvent_Event()
+}
+
+type StreamEvent_Frame struct {
+	Frame *Frame `protobuf:"bytes,1,opt,name=frame,proto3,oneof"`
+}
+
+type StreamEvent_DesktopState struct {
+	DesktopState *DesktopStateChange `protobuf:"bytes,2,opt,name=desktop_state,json=desktopState,proto3,oneof"`
+}
+
+func (*StreamEvent_Frame) isStreamEvent_Event() {}
+
+func (*StreamEvent_DesktopState) isStreamEvent_Event() {}
+
+var File_screenshot_v1_screenshot_proto protoreflect.FileDescriptor
+
+var file_screenshot_v1_screenshot_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2f, 0x76, 0x31, 0x2f,
+	0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0d, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x1a,
+	0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0x55, 0x0a, 0x09, 0x52, 0x65, 0x63, 0x74, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x12, 0x0c, 0x0a,
+	0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64,
+	0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12,
+	0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x60, 0x0a, 0x0e, 0x50, 0x69, 0x78, 0x65, 0x6c,
+	0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x78, 0x12, 0x0c, 0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x01, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09,
+	0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x22, 0xda, 0x01, 0x0a, 0x0d, 0x57, 0x61,
+	0x69, 0x74, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x74,
+	0x69, 0x74, 0x6c, 0x65, 0x5f, 0x72, 0x65, 0x67, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x52, 0x65, 0x67, 0x65, 0x78, 0x12, 0x18, 0x0a, 0x07,
+	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65,
+	0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a, 0x05, 0x70, 0x69, 0x78, 0x65, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68,
+	0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x43, 0x6f, 0x6e, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x70, 0x69, 0x78, 0x65, 0x6c, 0x12, 0x23, 0x0a, 0x0d, 0x73,
+	0x74, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0c, 0x73, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0x5f, 0x0a, 0x0c, 0x50, 0x6f, 0x70, 0x75, 0x70, 0x43,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69, 0x74, 0x68, 0x69, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x69, 0x74, 0x68, 0x69, 0x6e, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x5f, 0x70, 0x6f, 0x70, 0x75, 0x70, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x50, 0x6f, 0x70, 0x75, 0x70, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x63, 0x6c, 0x61, 0x73, 0x73, 0x65, 0x73, 0x22, 0x86, 0x07, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68,
+	0x6f, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x25, 0x0a, 0x0e,
+	0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x75, 0x72,
+	0x73, 0x6f, 0x72, 0x12, 0x30, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x74, 0x61, 0x6e, 0x67, 0x6c, 0x65, 0x52, 0x06, 0x72,
+	0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x12, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x5f,
+	0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x74, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x76,
+	0x65, 0x54, 0x6f, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x6d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x66, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x18, 0x09,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x4d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x74,
+	0x6f, 0x70, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x74, 0x6f, 0x70, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x37, 0x0a, 0x08, 0x77, 0x61, 0x69, 0x74,
+	0x5f, 0x66, 0x6f, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x69, 0x74, 0x43,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x77, 0x61, 0x69, 0x74, 0x46, 0x6f,
+	0x72, 0x12, 0x24, 0x0a, 0x0b, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0a, 0x72, 0x65, 0x74, 0x72, 0x79, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x74, 0x72, 0x79,
+	0x5f, 0x62, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x72, 0x65, 0x74, 0x72, 0x79, 0x42, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x12, 0x2e, 0x0a, 0x13,
+	0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x62, 0x6c, 0x61, 0x63, 0x6b, 0x5f, 0x66, 0x72, 0x61,
+	0x6d, 0x65, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x72, 0x65, 0x6a, 0x65, 0x63,
+	0x74, 0x42, 0x6c, 0x61, 0x63, 0x6b, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x34, 0x0a, 0x16,
+	0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x5f, 0x64, 0x65,
+	0x73, 0x6b, 0x74, 0x6f, 0x70, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x63, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x4f, 0x74, 0x68, 0x65, 0x72, 0x44, 0x65, 0x73, 0x6b, 0x74, 0x6f,
+	0x70, 0x73, 0x12, 0x32, 0x0a, 0x15, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x6f, 0x77,
+	0x6e, 0x65, 0x64, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x13, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x4f, 0x77, 0x6e, 0x65, 0x64, 0x57,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x12, 0x28, 0x0a, 0x0d, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x08, 0x48, 0x01, 0x52,
+	0x0c, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x2c, 0x0a, 0x0f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x6d, 0x69, 0x6e, 0x69, 0x6d, 0x69,
+	0x7a, 0x65, 0x64, 0x18, 0x13, 0x20, 0x01, 0x28, 0x08, 0x48, 0x02, 0x52, 0x0e, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x4d, 0x69, 0x6e, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x88, 0x01, 0x01, 0x12, 0x25,
+	0x0a, 0x0e, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x57,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x33, 0x0a, 0x06, 0x70, 0x6f, 0x70, 0x75, 0x70, 0x73, 0x18,
+	0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68,
+	0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x70, 0x75, 0x70, 0x43, 0x61, 0x70, 0x74, 0x75,
+	0x72, 0x65, 0x52, 0x06, 0x70, 0x6f, 0x70, 0x75, 0x70, 0x73, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x72,
+	0x65, 0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x69,
+	0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x42, 0x12, 0x0a, 0x10,
+	0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x5f, 0x6d, 0x69, 0x6e, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x64,
+	0x22, 0x9d, 0x05, 0x0a, 0x0a, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x16, 0x0a, 0x06, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x74,
+	0x68, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08,
+	0x74, 0x68, 0x72, 0x65, 0x61, 0x64, 0x49, 0x64, 0x12, 0x2c, 0x0a, 0x04, 0x72, 0x65, 0x63, 0x74,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73,
+	0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x74, 0x61, 0x6e, 0x67, 0x6c, 0x65,
+	0x52, 0x04, 0x72, 0x65, 0x63, 0x74, 0x12, 0x39, 0x0a, 0x0b, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x5f, 0x72, 0x65, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x74,
+	0x61, 0x6e, 0x67, 0x6c, 0x65, 0x52, 0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x63,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x7a, 0x5f, 0x6f, 0x72, 0x64,
+	0x65, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x7a, 0x4f, 0x72, 0x64, 0x65, 0x72,
+	0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x73, 0x5f, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x56, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x69, 0x73, 0x5f, 0x74, 0x6f, 0x70, 0x6d, 0x6f, 0x73, 0x74, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x54, 0x6f, 0x70, 0x6d, 0x6f, 0x73, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x07, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x74, 0x65,
+	0x67, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x29, 0x0a, 0x10, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x61, 0x66, 0x66,
+	0x69, 0x6e, 0x69, 0x74, 0x79, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x64, 0x69, 0x73,
+	0x70, 0x6c, 0x61, 0x79, 0x41, 0x66, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x12, 0x2c, 0x0a, 0x12,
+	0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x5f,
+	0x69, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61,
+	0x6c, 0x44, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x49, 0x64, 0x12, 0x31, 0x0a, 0x12, 0x6f, 0x6e,
+	0x5f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x10, 0x6f, 0x6e, 0x43, 0x75, 0x72, 0x72,
+	0x65, 0x6e, 0x74, 0x44, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a,
+	0x10, 0x6f, 0x63, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e,
+	0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x6f, 0x63, 0x63, 0x6c, 0x75, 0x64, 0x65,
+	0x64, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6f, 0x63, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x18, 0x12, 0x20, 0x03, 0x28, 0x04, 0x52, 0x0a, 0x6f,
+	0x63, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x42, 0x79, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x6f, 0x6e,
+	0x5f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70,
+	0x22, 0x8f, 0x01, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x41, 0x74, 0x74, 0x65,
+	0x6d, 0x70, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x55, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73,
+	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x72, 0x65, 0x74, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x72, 0x65, 0x74,
+	0x72, 0x79, 0x22, 0xe5, 0x03, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x25, 0x0a, 0x0e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73,
+	0x73, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x10, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x54, 0x69,
+	0x6d, 0x65, 0x55, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x76,
+	0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x77, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x56, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x77,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6d, 0x69, 0x6e, 0x69, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4d, 0x69, 0x6e,
+	0x69, 0x6d, 0x69, 0x7a, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x70, 0x69, 0x5f, 0x73, 0x63,
+	0x61, 0x6c, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x64, 0x70, 0x69,
+	0x53, 0x63, 0x61, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x30, 0x0a, 0x14, 0x62, 0x6c, 0x61, 0x63, 0x6b,
+	0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x64, 0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x62, 0x6c, 0x61, 0x63, 0x6b, 0x46, 0x72, 0x61, 0x6d,
+	0x65, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x39, 0x0a, 0x08, 0x61, 0x74, 0x74,
+	0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x52, 0x08, 0x61, 0x74, 0x74, 0x65,
+	0x6d, 0x70, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x73, 0x12, 0x29,
+	0x0a, 0x10, 0x6f, 0x63, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65,
+	0x6e, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f, 0x6f, 0x63, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x64, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6f, 0x63, 0x63,
+	0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x04, 0x52, 0x0a,
+	0x6f, 0x63, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x42, 0x79, 0x12, 0x3e, 0x0a, 0x0d, 0x6f, 0x77,
+	0x6e, 0x65, 0x64, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0c, 0x6f, 0x77,
+	0x6e, 0x65, 0x64, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x22, 0x9b, 0x01, 0x0a, 0x05, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x38,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x66, 0x0a, 0x05, 0x50, 0x6f, 0x70, 0x75,
+	0x70, 0x12, 0x31, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x77, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x12, 0x2a, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x22, 0xd3, 0x01, 0x0a, 0x0f, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74,
+	0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x12, 0x31, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31,
+	0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x77, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x12, 0x33, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68,
+	0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x2c, 0x0a, 0x06, 0x70, 0x6f, 0x70, 0x75,
+	0x70, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65,
+	0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x70, 0x75, 0x70, 0x52, 0x06,
+	0x70, 0x6f, 0x70, 0x75, 0x70, 0x73, 0x22, 0xae, 0x01, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x57,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x25, 0x0a,
+	0x0e, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x43, 0x6f, 0x6e, 0x74,
+	0x61, 0x69, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x5f,
+	0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x76, 0x69, 0x73, 0x69,
+	0x62, 0x6c, 0x65, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x25, 0x0a, 0x0e, 0x65, 0x78, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x5f, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x27,
+	0x0a, 0x0f, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f,
+	0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x44, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x22, 0x4a, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x57,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33,
+	0x0a, 0x07, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e,
+	0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x77, 0x69, 0x6e, 0x64,
+	0x6f, 0x77, 0x73, 0x22, 0xad, 0x01, 0x0a, 0x13, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x46, 0x72,
+	0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68,
+	0x61, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x68, 0x61, 0x6e,
+	0x64, 0x6c, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x70, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x03, 0x66, 0x70, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x77,
+	0x69, 0x64, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x57,
+	0x69, 0x64, 0x74, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x5f, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x48, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x22, 0x56, 0x0a, 0x05, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0b, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x2a, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x48, 0x0a, 0x12, 0x44,
+	0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x53, 0x74, 0x61, 0x74, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x8e, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x05, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f,
+	0x74, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x48, 0x00, 0x52, 0x05, 0x66, 0x72,
+	0x61, 0x6d, 0x65, 0x12, 0x48, 0x0a, 0x0d, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x5f, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x73, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x73, 0x6b, 0x74,
+	0x6f, 0x70, 0x53, 0x74, 0x61, 0x74, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x48, 0x00, 0x52,
+	0x0c, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x53, 0x74, 0x61, 0x74, 0x65, 0x42, 0x07, 0x0a,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x32, 0x85, 0x02, 0x0a, 0x11, 0x53, 0x63, 0x72, 0x65, 0x65,
+	0x6e, 0x73, 0x68, 0x6f, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x48, 0x0a, 0x07,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1d, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e,
+	0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73,
+	0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x54, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x73, 0x12, 0x21, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68,
+	0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65,
+	0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0c,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x22, 0x2e, 0x73,
+	0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1a, 0x2e, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x33,
+	0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x2d, 0x6d, 0x63, 0x70, 0x2d, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f,
+	0x74, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_screenshot_v1_screenshot_proto_rawDescOnce sync.Once
+	file_screenshot_v1_screenshot_proto_rawDescData = file_screenshot_v1_screenshot_proto_rawDesc
+)
+
+func file_screenshot_v1_screenshot_proto_rawDescGZIP() []byte {
+	file_screenshot_v1_screenshot_proto_rawDescOnce.Do(func() {
+		file_screenshot_v1_screenshot_proto_rawDescData = protoimpl.X.CompressGZIP(file_screenshot_v1_screenshot_proto_rawDescData)
+	})
+	return file_screenshot_v1_screenshot_proto_rawDescData
+}
+
+var file_screenshot_v1_screenshot_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_screenshot_v1_screenshot_proto_goTypes = []any{
+	(*Rectangle)(nil),             // 0: screenshot.v1.Rectangle
+	(*PixelCondition)(nil),        // 1: screenshot.v1.PixelCondition
+	(*WaitCondition)(nil),         // 2: screenshot.v1.WaitCondition
+	(*PopupCapture)(nil),          // 3: screenshot.v1.PopupCapture
+	(*CaptureRequest)(nil),        // 4: screenshot.v1.CaptureRequest
+	(*WindowInfo)(nil),            // 5: screenshot.v1.WindowInfo
+	(*CaptureAttempt)(nil),        // 6: screenshot.v1.CaptureAttempt
+	(*Metadata)(nil),              // 7: screenshot.v1.Metadata
+	(*Image)(nil),                 // 8: screenshot.v1.Image
+	(*Popup)(nil),                 // 9: screenshot.v1.Popup
+	(*CaptureResponse)(nil),       // 10: screenshot.v1.CaptureResponse
+	(*ListWindowsRequest)(nil),    // 11: screenshot.v1.ListWindowsRequest
+	(*ListWindowsResponse)(nil),   // 12: screenshot.v1.ListWindowsResponse
+	(*StreamFramesRequest)(nil),   // 13: screenshot.v1.StreamFramesRequest
+	(*Frame)(nil),                 // 14: screenshot.v1.Frame
+	(*DesktopStateChange)(nil),    // 15: screenshot.v1.DesktopStateChange
+	(*StreamEvent)(nil),           // 16: screenshot.v1.StreamEvent
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_screenshot_v1_screenshot_proto_depIdxs = []int32{
+	1,  // 0: screenshot.v1.WaitCondition.pixel:type_name -> screenshot.v1.PixelCondition
+	0,  // 1: screenshot.v1.CaptureRequest.region:type_name -> screenshot.v1.Rectangle
+	2,  // 2: screenshot.v1.CaptureRequest.wait_for:type_name -> screenshot.v1.WaitCondition
+	3,  // 3: screenshot.v1.CaptureRequest.popups:type_name -> screenshot.v1.PopupCapture
+	0,  // 4: screenshot.v1.WindowInfo.rect:type_name -> screenshot.v1.Rectangle
+	0,  // 5: screenshot.v1.WindowInfo.client_rect:type_name -> screenshot.v1.Rectangle
+	6,  // 6: screenshot.v1.Metadata.attempts:type_name -> screenshot.v1.CaptureAttempt
+	5,  // 7: screenshot.v1.Metadata.owned_windows:type_name -> screenshot.v1.WindowInfo
+	17, // 8: screenshot.v1.Image.timestamp:type_name -> google.protobuf.Timestamp
+	5,  // 9: screenshot.v1.Popup.window:type_name -> screenshot.v1.WindowInfo
+	8,  // 10: screenshot.v1.Popup.image:type_name -> screenshot.v1.Image
+	8,  // 11: screenshot.v1.CaptureResponse.image:type_name -> screenshot.v1.Image
+	5,  // 12: screenshot.v1.CaptureResponse.window:type_name -> screenshot.v1.WindowInfo
+	7,  // 13: screenshot.v1.CaptureResponse.metadata:type_name -> screenshot.v1.Metadata
+	9,  // 14: screenshot.v1.CaptureResponse.popups:type_name -> screenshot.v1.Popup
+	5,  // 15: screenshot.v1.ListWindowsResponse.windows:type_name -> screenshot.v1.WindowInfo
+	8,  // 16: screenshot.v1.Frame.image:type_name -> screenshot.v1.Image
+	14, // 17: screenshot.v1.StreamEvent.frame:type_name -> screenshot.v1.Frame
+	15, // 18: screenshot.v1.StreamEvent.desktop_state:type_name -> screenshot.v1.DesktopStateChange
+	4,  // 19: screenshot.v1.ScreenshotService.Capture:input_type -> screenshot.v1.CaptureRequest
+	11, // 20: screenshot.v1.ScreenshotService.ListWindows:input_type -> screenshot.v1.ListWindowsRequest
+	13, // 21: screenshot.v1.ScreenshotService.StreamFrames:input_type -> screenshot.v1.StreamFramesRequest
+	10, // 22: screenshot.v1.ScreenshotService.Capture:output_type -> screenshot.v1.CaptureResponse
+	12, // 23: screenshot.v1.ScreenshotService.ListWindows:output_type -> screenshot.v1.ListWindowsResponse
+	16, // 24: screenshot.v1.ScreenshotService.StreamFrames:output_type -> screenshot.v1.StreamEvent
+	22, // [22:25] is the sub-list for method output_type
+	19, // [19:22] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_screenshot_v1_screenshot_proto_init() }
+func file_screenshot_v1_screenshot_proto_init() {
+	if File_screenshot_v1_screenshot_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_screenshot_v1_screenshot_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Rectangle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*PixelCondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*WaitCondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*PopupCapture); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CaptureRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*WindowInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*CaptureAttempt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Metadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Image); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*Popup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*CaptureResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*ListWindowsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*ListWindowsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamFramesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*Frame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*DesktopStateChange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_screenshot_v1_screenshot_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_screenshot_v1_screenshot_proto_msgTypes[4].OneofWrappers = []any{}
+	file_screenshot_v1_screenshot_proto_msgTypes[5].OneofWrappers = []any{}
+	file_screenshot_v1_screenshot_proto_msgTypes[16].OneofWrappers = []any{
+		(*StreamEvent_Frame)(nil),
+		(*StreamEvent_DesktopState)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_screenshot_v1_screenshot_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_screenshot_v1_screenshot_proto_goTypes,
+		DependencyIndexes: file_screenshot_v1_screenshot_proto_depIdxs,
+		MessageInfos:      file_screenshot_v1_screenshot_proto_msgTypes,
+	}.Build()
+	File_screenshot_v1_screenshot_proto = out.File
+	file_screenshot_v1_screenshot_proto_rawDesc = nil
+	file_screenshot_v1_screenshot_proto_goTypes = nil
+	file_screenshot_v1_screenshot_proto_depIdxs = nil
+}
@@ -0,0 +1,213 @@
+// gRPC API of the screenshot server. It mirrors the HTTP and MCP methods for clients
+// that prefer typed stubs and HTTP/2 streaming; requests are validated and captured by
+// the same code paths, so fields behave as documented for POST /v1/screenshot.
+//
+// Regenerate pkg/screenshotpb with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.0
+// source: screenshot/v1/screenshot.proto
+
+package screenshotpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ScreenshotService_Capture_FullMethodName      = "/screenshot.v1.ScreenshotService/Capture"
+	ScreenshotService_ListWindows_FullMethodName  = "/screenshot.v1.ScreenshotService/ListWindows"
+	ScreenshotService_StreamFrames_FullMethodName = "/screenshot.v1.ScreenshotService/StreamFrames"
+)
+
+// ScreenshotServiceClient is the client API for ScreenshotService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ScreenshotServiceClient interface {
+	// Capture takes a screenshot of a window, shell surface or the desktop
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (*CaptureResponse, error)
+	// ListWindows lists top-level windows
+	ListWindows(ctx context.Context, in *ListWindowsRequest, opts ...grpc.CallOption) (*ListWindowsResponse, error)
+	// StreamFrames captures a window at a fixed rate until the client cancels
+	StreamFrames(ctx context.Context, in *StreamFramesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamEvent], error)
+}
+
+type screenshotServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScreenshotServiceClient(cc grpc.ClientConnInterface) ScreenshotServiceClient {
+	return &screenshotServiceClient{cc}
+}
+
+func (c *screenshotServiceClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (*CaptureResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CaptureResponse)
+	err := c.cc.Invoke(ctx, ScreenshotService_Capture_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *screenshotServiceClient) ListWindows(ctx context.Context, in *ListWindowsRequest, opts ...grpc.CallOption) (*ListWindowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWindowsResponse)
+	err := c.cc.Invoke(ctx, ScreenshotService_ListWindows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *screenshotServiceClient) StreamFrames(ctx context.Context, in *StreamFramesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScreenshotService_ServiceDesc.Streams[0], ScreenshotService_StreamFrames_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamFramesRequest, StreamEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenshotService_StreamFramesClient = grpc.ServerStreamingClient[StreamEvent]
+
+// ScreenshotServiceServer is the server API for ScreenshotService service.
+// All implementations must embed UnimplementedScreenshotServiceServer
+// for forward compatibility.
+type ScreenshotServiceServer interface {
+	// Capture takes a screenshot of a window, shell surface or the desktop
+	Capture(context.Context, *CaptureRequest) (*CaptureResponse, error)
+	// ListWindows lists top-level windows
+	ListWindows(context.Context, *ListWindowsRequest) (*ListWindowsResponse, error)
+	// StreamFrames captures a window at a fixed rate until the client cancels
+	StreamFrames(*StreamFramesRequest, grpc.ServerStreamingServer[StreamEvent]) error
+	mustEmbedUnimplementedScreenshotServiceServer()
+}
+
+// UnimplementedScreenshotServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedScreenshotServiceServer struct{}
+
+func (UnimplementedScreenshotServiceServer) Capture(context.Context, *CaptureRequest) (*CaptureResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
+func (UnimplementedScreenshotServiceServer) ListWindows(context.Context, *ListWindowsRequest) (*ListWindowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWindows not implemented")
+}
+func (UnimplementedScreenshotServiceServer) StreamFrames(*StreamFramesRequest, grpc.ServerStreamingServer[StreamEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFrames not implemented")
+}
+func (UnimplementedScreenshotServiceServer) mustEmbedUnimplementedScreenshotServiceServer() {}
+func (UnimplementedScreenshotServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeScreenshotServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScreenshotServiceServer will
+// result in compilation errors.
+type UnsafeScreenshotServiceServer interface {
+	mustEmbedUnimplementedScreenshotServiceServer()
+}
+
+func RegisterScreenshotServiceServer(s grpc.ServiceRegistrar, srv ScreenshotServiceServer) {
+	// If the following call pancis, it indicates UnimplementedScreenshotServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ScreenshotService_ServiceDesc, srv)
+}
+
+func _ScreenshotService_Capture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CaptureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScreenshotServiceServer).Capture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScreenshotService_Capture_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScreenshotServiceServer).Capture(ctx, req.(*CaptureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScreenshotService_ListWindows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWindowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScreenshotServiceServer).ListWindows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScreenshotService_ListWindows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScreenshotServiceServer).ListWindows(ctx, req.(*ListWindowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScreenshotService_StreamFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFramesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScreenshotServiceServer).StreamFrames(m, &grpc.GenericServerStream[StreamFramesRequest, StreamEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScreenshotService_StreamFramesServer = grpc.ServerStreamingServer[StreamEvent]
+
+// ScreenshotService_ServiceDesc is the grpc.ServiceDesc for ScreenshotService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ScreenshotService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "screenshot.v1.ScreenshotService",
+	HandlerType: (*ScreenshotServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Capture",
+			Handler:    _ScreenshotService_Capture_Handler,
+		},
+		{
+			MethodName: "ListWindows",
+			Handler:    _ScreenshotService_ListWindows_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFrames",
+			Handler:       _ScreenshotService_StreamFrames_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "screenshot/v1/screenshot.proto",
+}
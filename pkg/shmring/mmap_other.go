@@ -0,0 +1,22 @@
+//go:build !windows
+
+package shmring
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapFile maps size bytes of file shared with other processes mapping it
+func mapFile(file *os.File, size int, writable bool) ([]byte, error) {
+	prot := unix.PROT_READ
+	if writable {
+		prot |= unix.PROT_WRITE
+	}
+	return unix.Mmap(int(file.Fd()), 0, size, prot, unix.MAP_SHARED)
+}
+
+func unmapFile(mem []byte) error {
+	return unix.Munmap(mem)
+}
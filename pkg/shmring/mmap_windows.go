@@ -0,0 +1,33 @@
+//go:build windows
+
+package shmring
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// mapFile maps size bytes of file shared with other processes mapping it
+func mapFile(file *os.File, size int, writable bool) ([]byte, error) {
+	protect, access := uint32(windows.PAGE_READONLY), uint32(windows.FILE_MAP_READ)
+	if writable {
+		protect, access = windows.PAGE_READWRITE, windows.FILE_MAP_WRITE
+	}
+	mapping, err := windows.CreateFileMapping(windows.Handle(file.Fd()), nil, protect, uint32(uint64(size)>>32), uint32(size), nil)
+	if err != nil {
+		return nil, err
+	}
+	// The view keeps the mapping alive
+	defer windows.CloseHandle(mapping)
+	addr, err := windows.MapViewOfFile(mapping, access, 0, 0, uintptr(size))
+	if err != nil {
+		return nil, err
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(addr)), size), nil
+}
+
+func unmapFile(mem []byte) error {
+	return windows.UnmapViewOfFile(uintptr(unsafe.Pointer(&mem[0])))
+}
@@ -0,0 +1,266 @@
+// Package shmring hands stream frames to consumers on the same machine through a ring
+// of frame slots in a memory-mapped file, so they read pixels in place instead of
+// receiving them over a socket.
+//
+// The file starts with a 64-byte header, followed by the slots. All integers are little
+// endian.
+//
+//	offset  size  field
+//	0       4     magic "SHMR"
+//	4       4     version, 1
+//	8       4     header size, 64
+//	12      4     slot count
+//	16      8     slot size, including the slot's header
+//	24      8     frames written; the latest is in slot (written-1) % slot count
+//
+// Each slot starts with a 64-byte header, followed by the pixels in the layout of
+// rawframe: BGRA, top row first, with no padding between rows.
+//
+//	offset  size  field
+//	0       8     sequence: odd while the slot is being written
+//	8       8     frame number
+//	16      4     width
+//	20      4     height
+//	24      8     capture time, Unix nanoseconds
+//
+// A reader copies a slot only if its sequence is even and unchanged after the copy;
+// otherwise the writer overwrote the slot meanwhile.
+package shmring
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/screenshot-mcp-server/pkg/rawframe"
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// Layout of the file
+const (
+	Magic          = "SHMR"
+	Version        = 1
+	HeaderSize     = 64
+	SlotHeaderSize = 64
+)
+
+// Offsets of the header fields updated while frames are written
+const (
+	writtenOffset     = 24
+	sequenceOffset    = 0
+	frameNumberOffset = 8
+)
+
+// ErrSlotBusy is returned for a slot that's being written or was overwritten while it
+// was read; the reader moves on to a newer frame
+var ErrSlotBusy = errors.New("slot is being written")
+
+// ErrNoFrame is returned before any frame was written
+var ErrNoFrame = errors.New("no frame written yet")
+
+// Header describes the frame in a slot
+type Header struct {
+	FrameNumber int64
+	Width       int
+	Height      int
+	CapturedAt  time.Time
+}
+
+// Ring is the writer's side of a frame ring
+type Ring struct {
+	path     string
+	file     *os.File
+	mem      []byte
+	slots    int
+	slotSize int
+}
+
+// Create creates a ring of slots holding frames of up to capacity bytes of pixels in a
+// new file at path, readable only by the current user
+func Create(path string, slots, capacity int) (*Ring, error) {
+	if slots < 1 || capacity < 1 {
+		return nil, fmt.Errorf("invalid ring of %d slots of %d bytes", slots, capacity)
+	}
+	slotSize := SlotHeaderSize + (capacity+7)&^7
+	size := HeaderSize + slots*slotSize
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(int64(size)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	mem, err := mapFile(file, size, true)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to map %s: %w", path, err)
+	}
+
+	copy(mem, Magic)
+	binary.LittleEndian.PutUint32(mem[4:], Version)
+	binary.LittleEndian.PutUint32(mem[8:], HeaderSize)
+	binary.LittleEndian.PutUint32(mem[12:], uint32(slots))
+	binary.LittleEndian.PutUint64(mem[16:], uint64(slotSize))
+	return &Ring{path: path, file: file, mem: mem, slots: slots, slotSize: slotSize}, nil
+}
+
+// Path returns the ring's file
+func (r *Ring) Path() string { return r.path }
+
+// Slots returns the number of slots
+func (r *Ring) Slots() int { return r.slots }
+
+// SlotSize returns the size of a slot, including its header
+func (r *Ring) SlotSize() int { return r.slotSize }
+
+// Capacity returns the most pixel bytes a slot holds
+func (r *Ring) Capacity() int { return r.slotSize - SlotHeaderSize }
+
+// Write copies a capture into the next slot and returns the slot's index. Only one
+// goroutine writes to a ring.
+func (r *Ring) Write(frameNumber int64, capturedAt time.Time, buffer *types.ScreenshotBuffer) (int, error) {
+	if need := buffer.Width * buffer.Height * 4; need > r.Capacity() {
+		return 0, fmt.Errorf("%dx%d frame doesn't fit slots of %d bytes", buffer.Width, buffer.Height, r.Capacity())
+	}
+	written := atomic.LoadUint64(word(r.mem, writtenOffset))
+	index := int(written % uint64(r.slots))
+	slot := r.mem[HeaderSize+index*r.slotSize : HeaderSize+(index+1)*r.slotSize]
+
+	sequence := word(slot, sequenceOffset)
+	atomic.AddUint64(sequence, 1)
+	binary.LittleEndian.PutUint32(slot[16:], uint32(buffer.Width))
+	binary.LittleEndian.PutUint32(slot[20:], uint32(buffer.Height))
+	binary.LittleEndian.PutUint64(slot[24:], uint64(capturedAt.UnixNano()))
+	atomic.StoreUint64(word(slot, frameNumberOffset), uint64(frameNumber))
+	err := rawframe.CopyPixels(slot[SlotHeaderSize:], buffer)
+	atomic.AddUint64(sequence, 1)
+	if err != nil {
+		return 0, err
+	}
+	atomic.StoreUint64(word(r.mem, writtenOffset), written+1)
+	return index, nil
+}
+
+// Close unmaps the ring and removes its file. Readers that still have it mapped keep
+// their mapping.
+func (r *Ring) Close() error {
+	err := unmapFile(r.mem)
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(r.path); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// Reader is a consumer's side of a frame ring
+type Reader struct {
+	file     *os.File
+	mem      []byte
+	slots    int
+	slotSize int
+}
+
+// Open maps the ring in the file at path for reading
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() < HeaderSize {
+		file.Close()
+		return nil, fmt.Errorf("%s is not a frame ring", path)
+	}
+	mem, err := mapFile(file, int(info.Size()), false)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to map %s: %w", path, err)
+	}
+
+	r := &Reader{
+		file:     file,
+		mem:      mem,
+		slots:    int(binary.LittleEndian.Uint32(mem[12:])),
+		slotSize: int(binary.LittleEndian.Uint64(mem[16:])),
+	}
+	if string(mem[:4]) != Magic || binary.LittleEndian.Uint32(mem[4:]) != Version ||
+		r.slots < 1 || r.slotSize <= SlotHeaderSize || HeaderSize+r.slots*r.slotSize > len(mem) {
+		r.Close()
+		return nil, fmt.Errorf("%s is not a version %d frame ring", path, Version)
+	}
+	return r, nil
+}
+
+// Slots returns the number of slots
+func (r *Reader) Slots() int { return r.slots }
+
+// Latest copies the most recently written frame
+func (r *Reader) Latest() (Header, []byte, error) {
+	written := atomic.LoadUint64(word(r.mem, writtenOffset))
+	if written == 0 {
+		return Header{}, nil, ErrNoFrame
+	}
+	return r.Read(int((written - 1) % uint64(r.slots)))
+}
+
+// Read copies the frame in a slot, such as the one a stream's frame message names
+func (r *Reader) Read(index int) (Header, []byte, error) {
+	if index < 0 || index >= r.slots {
+		return Header{}, nil, fmt.Errorf("slot %d out of range", index)
+	}
+	slot := r.mem[HeaderSize+index*r.slotSize : HeaderSize+(index+1)*r.slotSize]
+	sequence := word(slot, sequenceOffset)
+
+	before := atomic.LoadUint64(sequence)
+	if before == 0 {
+		return Header{}, nil, ErrNoFrame
+	}
+	if before%2 == 1 {
+		return Header{}, nil, ErrSlotBusy
+	}
+	header := Header{
+		FrameNumber: int64(atomic.LoadUint64(word(slot, frameNumberOffset))),
+		Width:       int(binary.LittleEndian.Uint32(slot[16:])),
+		Height:      int(binary.LittleEndian.Uint32(slot[20:])),
+		CapturedAt:  time.Unix(0, int64(binary.LittleEndian.Uint64(slot[24:]))),
+	}
+	size := header.Width * header.Height * 4
+	if size > r.slotSize-SlotHeaderSize {
+		return Header{}, nil, ErrSlotBusy
+	}
+	pixels := make([]byte, size)
+	copy(pixels, slot[SlotHeaderSize:])
+	if atomic.LoadUint64(sequence) != before {
+		return Header{}, nil, ErrSlotBusy
+	}
+	return header, pixels, nil
+}
+
+// Close unmaps the ring
+func (r *Reader) Close() error {
+	err := unmapFile(r.mem)
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// word returns the 8-byte aligned word at offset of mem, for atomic access. Mappings are
+// page aligned and every offset used is a multiple of 8.
+func word(mem []byte, offset int) *uint64 {
+	return (*uint64)(unsafe.Pointer(&mem[offset]))
+}
@@ -0,0 +1,261 @@
+package shmring
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/screenshot-mcp-server/pkg/types"
+)
+
+// frame returns a BGRA buffer whose every byte is fill, with padding after each row
+func frame(width, height int, fill byte) *types.ScreenshotBuffer {
+	stride := width*4 + 8
+	data := make([]byte, stride*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width*4; x++ {
+			data[y*stride+x] = fill
+		}
+	}
+	return &types.ScreenshotBuffer{Data: data, Width: width, Height: height, Stride: stride, Format: "BGRA32"}
+}
+
+func createRing(t *testing.T, slots, capacity int) (*Ring, *Reader) {
+	t.Helper()
+	ring, err := Create(filepath.Join(t.TempDir(), "frames.ring"), slots, capacity)
+	require.NoError(t, err)
+	t.Cleanup(func() { ring.Close() })
+	reader, err := Open(ring.Path())
+	require.NoError(t, err)
+	t.Cleanup(func() { reader.Close() })
+	return ring, reader
+}
+
+func TestWriteAndRead(t *testing.T) {
+	ring, reader := createRing(t, 3, 16*16*4)
+	assert.Equal(t, 3, ring.Slots())
+	assert.Equal(t, 3, reader.Slots())
+	assert.Equal(t, 16*16*4, ring.Capacity())
+	assert.Equal(t, SlotHeaderSize+16*16*4, ring.SlotSize())
+
+	capturedAt := time.Unix(1700000000, 123456789)
+	for n := int64(1); n <= 4; n++ {
+		index, err := ring.Write(n, capturedAt, frame(int(n)*2, 5, byte(n)))
+		require.NoError(t, err)
+		assert.Equal(t, int(n-1)%3, index)
+
+		header, pixels, err := reader.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, Header{FrameNumber: n, Width: int(n) * 2, Height: 5, CapturedAt: capturedAt}, header)
+		require.Len(t, pixels, int(n)*2*5*4)
+		for _, b := range pixels {
+			require.Equal(t, byte(n), b)
+		}
+	}
+
+	// Frame 4 overwrote frame 1 in slot 0; frames 2 and 3 are still in theirs
+	header, _, err := reader.Read(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), header.FrameNumber)
+	header, _, err = reader.Read(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), header.FrameNumber)
+
+	_, _, err = reader.Read(3)
+	assert.Error(t, err)
+	_, _, err = reader.Read(-1)
+	assert.Error(t, err)
+}
+
+func TestWriteRejectsFramesThatDontFit(t *testing.T) {
+	ring, _ := createRing(t, 1, 8*8*4)
+	_, err := ring.Write(1, time.Now(), frame(9, 8, 1))
+	assert.Error(t, err)
+
+	buffer := frame(2, 2, 1)
+	buffer.Format = "NV12"
+	_, err = ring.Write(1, time.Now(), buffer)
+	assert.Error(t, err)
+}
+
+func TestCreateRemovesFileOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frames.ring")
+	ring, err := Create(path, 1, 64)
+	require.NoError(t, err)
+
+	_, err = Create(path, 1, 64)
+	assert.Error(t, err, "an existing ring isn't replaced")
+
+	require.NoError(t, ring.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = Create(path, 0, 64)
+	assert.Error(t, err)
+	_, err = Create(path, 1, 0)
+	assert.Error(t, err)
+}
+
+func TestNoFrame(t *testing.T) {
+	ring, reader := createRing(t, 2, 64)
+	_, _, err := reader.Latest()
+	assert.ErrorIs(t, err, ErrNoFrame)
+	_, _, err = reader.Read(0)
+	assert.ErrorIs(t, err, ErrNoFrame)
+
+	_, err = ring.Write(1, time.Now(), frame(2, 2, 1))
+	require.NoError(t, err)
+	_, _, err = reader.Latest()
+	assert.NoError(t, err)
+	_, _, err = reader.Read(1)
+	assert.ErrorIs(t, err, ErrNoFrame)
+}
+
+func TestSlotBusy(t *testing.T) {
+	ring, reader := createRing(t, 1, 64)
+	_, err := ring.Write(1, time.Now(), frame(2, 2, 1))
+	require.NoError(t, err)
+
+	// A writer that stopped halfway leaves the sequence odd
+	slot := ring.mem[HeaderSize:]
+	binary.LittleEndian.PutUint64(slot[sequenceOffset:], 3)
+	_, _, err = reader.Latest()
+	assert.ErrorIs(t, err, ErrSlotBusy)
+
+	// A size read mid-write can exceed the slot
+	binary.LittleEndian.PutUint64(slot[sequenceOffset:], 4)
+	binary.LittleEndian.PutUint32(slot[16:], 100)
+	_, _, err = reader.Read(0)
+	assert.ErrorIs(t, err, ErrSlotBusy)
+}
+
+func TestConcurrentReadsNeverTear(t *testing.T) {
+	const readers, attempts = 4, 2000
+	ring, reader := createRing(t, 2, 32*32*4)
+
+	// Frames differ in size and fill so that a mix of two shows up in the pixels
+	buffers := make([]*types.ScreenshotBuffer, 4)
+	for i := range buffers {
+		buffers[i] = frame(8*(i+1), 32-i*4, byte(i+1))
+	}
+	_, err := ring.Write(0, time.Unix(0, 0), buffers[0])
+	require.NoError(t, err)
+
+	// The writer laps the readers until they're done
+	stop := make(chan struct{})
+	written := make(chan int64)
+	go func() {
+		n := int64(1)
+		for ; ; n++ {
+			select {
+			case <-stop:
+				written <- n - 1
+				return
+			default:
+			}
+			if _, err := ring.Write(n, time.Unix(0, n), buffers[n%4]); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var read atomic.Int64
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < attempts; i++ {
+				header, pixels, err := reader.Latest()
+				if err == ErrSlotBusy {
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				want := buffers[header.FrameNumber%4]
+				if header.Width != want.Width || header.Height != want.Height ||
+					header.CapturedAt.UnixNano() != header.FrameNumber || len(pixels) != want.Width*want.Height*4 {
+					t.Errorf("frame %d has header %+v", header.FrameNumber, header)
+					return
+				}
+				for i, b := range pixels {
+					if b != want.Data[0] {
+						t.Errorf("frame %d is torn at byte %d", header.FrameNumber, i)
+						return
+					}
+				}
+				read.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	last := <-written
+	t.Logf("%d of %d reads succeeded while %d frames were written", read.Load(), readers*attempts, last)
+	assert.NotZero(t, read.Load())
+
+	header, _, err := reader.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, last, header.FrameNumber)
+}
+
+func TestOpenValidatesHeader(t *testing.T) {
+	ring, err := Create(filepath.Join(t.TempDir(), "frames.ring"), 2, 64)
+	require.NoError(t, err)
+	_, err = ring.Write(1, time.Now(), frame(2, 2, 1))
+	require.NoError(t, err)
+	valid, err := os.ReadFile(ring.Path())
+	require.NoError(t, err)
+	require.NoError(t, ring.Close())
+
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+	}{
+		{"empty", func(b []byte) []byte { return nil }},
+		{"shorter than the header", func(b []byte) []byte { return b[:HeaderSize-1] }},
+		{"truncated slots", func(b []byte) []byte { return b[:len(b)-1] }},
+		{"header only", func(b []byte) []byte { return b[:HeaderSize] }},
+		{"magic", func(b []byte) []byte { copy(b, "RMHS"); return b }},
+		{"version", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[4:], Version+1); return b }},
+		{"no slots", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[12:], 0); return b }},
+		{"too many slots", func(b []byte) []byte { binary.LittleEndian.PutUint32(b[12:], 3); return b }},
+		{"slot without pixels", func(b []byte) []byte {
+			binary.LittleEndian.PutUint64(b[16:], SlotHeaderSize)
+			return b
+		}},
+		{"oversized slots", func(b []byte) []byte { binary.LittleEndian.PutUint64(b[16:], 1<<40); return b }},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(append([]byte(nil), valid...)), 0o600))
+			reader, err := Open(path)
+			if !assert.Error(t, err) {
+				reader.Close()
+			}
+		})
+	}
+
+	path := filepath.Join(dir, "valid")
+	require.NoError(t, os.WriteFile(path, valid, 0o600))
+	reader, err := Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+	header, _, err := reader.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), header.FrameNumber)
+
+	_, err = Open(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
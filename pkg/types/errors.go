@@ -0,0 +1,135 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrorCode classifies a capture failure so clients can branch on the cause
+type ErrorCode string
+
+const (
+	ErrWindowNotFound     ErrorCode = "WINDOW_NOT_FOUND"    // Target did not resolve to a window
+	ErrAmbiguousWindow    ErrorCode = "AMBIGUOUS_WINDOW"    // Target matched several windows
+	ErrAccessDenied       ErrorCode = "ACCESS_DENIED"       // Windows refused access to the window or desktop
+	ErrElevationRequired  ErrorCode = "ELEVATION_REQUIRED"  // Target runs at a higher integrity level than the server
+	ErrCaptureExcluded    ErrorCode = "CAPTURE_EXCLUDED"    // Window blocks capture via its display affinity
+	ErrOtherDesktop       ErrorCode = "ON_OTHER_DESKTOP"    // Window is on another virtual desktop
+	ErrBlackFrame         ErrorCode = "BLACK_FRAME"         // Every method produced an all-black image
+	ErrDWMUnavailable     ErrorCode = "DWM_UNAVAILABLE"     // Composition, thumbnails or desktop duplication unavailable
+	ErrDesktopUnavailable ErrorCode = "DESKTOP_UNAVAILABLE" // Session locked, secure desktop shown or session disconnected
+	ErrTimeout            ErrorCode = "TIMEOUT"             // A wait or capture did not complete in time
+	ErrUnsupportedFormat  ErrorCode = "UNSUPPORTED_FORMAT"  // Requested image format can't be produced
+	ErrInvalidRequest     ErrorCode = "INVALID_REQUEST"     // Request parameters failed validation
+	ErrResponseTooLarge   ErrorCode = "RESPONSE_TOO_LARGE"  // Result can't be shrunk to fit max_response_bytes
+	ErrTargetUnreachable  ErrorCode = "TARGET_UNREACHABLE"  // A federated target did not answer
+	ErrCancelled          ErrorCode = "CANCELLED"           // The caller cancelled the operation
+	ErrCaptureFailed      ErrorCode = "CAPTURE_FAILED"      // Any other capture failure
+)
+
+// CaptureError is an error tagged with an ErrorCode
+type CaptureError struct {
+	Code    ErrorCode
+	Message string
+	Err     error
+}
+
+// NewCaptureError creates a CaptureError wrapping an optional underlying error
+func NewCaptureError(code ErrorCode, message string, err error) *CaptureError {
+	return &CaptureError{Code: code, Message: message, Err: err}
+}
+
+func (e *CaptureError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the error's classification
+func (e *CaptureError) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+// AttemptsError is a failed capture with the method attempts it made
+type AttemptsError struct {
+	Attempts []CaptureAttempt
+	Err      error
+}
+
+func (e *AttemptsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// AttemptsOf returns the method attempts of a failed capture, from the outermost
+// AttemptsError in err's chain
+func AttemptsOf(err error) []CaptureAttempt {
+	var failure *AttemptsError
+	if errors.As(err, &failure) {
+		return failure.Attempts
+	}
+	return nil
+}
+
+// DesktopState describes whether the interactive desktop can be captured
+type DesktopState string
+
+const (
+	DesktopAvailable    DesktopState = "available"
+	DesktopLocked       DesktopState = "locked"         // Session is locked
+	DesktopSecure       DesktopState = "secure_desktop" // UAC prompt or another Winlogon desktop has input
+	DesktopDisconnected DesktopState = "disconnected"   // Remote session is disconnected
+)
+
+// DesktopUnavailableError reports a capture refused because the desktop can't be captured
+type DesktopUnavailableError struct {
+	State DesktopState
+}
+
+func (e *DesktopUnavailableError) Error() string {
+	return "desktop unavailable: " + string(e.State)
+}
+
+// ErrorCode returns ErrDesktopUnavailable
+func (e *DesktopUnavailableError) ErrorCode() ErrorCode {
+	return ErrDesktopUnavailable
+}
+
+// ErrorCodeOf returns the code of the outermost error in err's chain that carries
+// one, ErrCancelled for a cancelled context, or ErrCaptureFailed otherwise
+func ErrorCodeOf(err error) ErrorCode {
+	var coded interface{ ErrorCode() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrCancelled
+	}
+	return ErrCaptureFailed
+}
+
+// Sleep waits for d, failing with ErrCancelled if ctx is cancelled first. A nil ctx
+// waits the whole time.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return NewCaptureError(ErrCancelled, "operation cancelled", ctx.Err())
+	}
+}
@@ -1,19 +1,232 @@
 package types
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"image"
 	"time"
 )
 
 // ScreenshotRequest represents a request to capture a screenshot
 type ScreenshotRequest struct {
-	Method        string            `json:"method"`         // "title", "pid", "handle", "class"
-	Target        string            `json:"target"`         // Window title, PID, handle, or class name
+	Method        string            `json:"method"`         // "title", "title_contains", "title_regex", "title_fuzzy", "pid", "process", "handle", "class", "foreground", "under_cursor", "alias"
+	Target        string            `json:"target"`         // Window title, PID, executable name, handle, class name, or alias name
 	Format        ImageFormat       `json:"format"`         // Output format
 	Quality       int               `json:"quality"`        // JPEG quality (1-100)
 	IncludeCursor bool              `json:"include_cursor"` // Include mouse cursor
 	Region        *Rectangle        `json:"region"`         // Specific region to capture
+	RegionRelativeTo RegionOrigin   `json:"region_relative_to"` // "window" (default), "client" or "screen"
 	Options       map[string]string `json:"options"`        // Additional options
+	CaptureMethod   CaptureMethod   `json:"capture_method"`   // Force a capture method ("auto" = engine default)
+	FallbackMethods []CaptureMethod `json:"fallback_methods"` // Methods tried after capture_method fails
+	Match           string          `json:"match"`            // "best" picks the top-ranked window when a title_* method is ambiguous
+	TopLevel        bool            `json:"top_level"`        // under_cursor: capture the top-level owner instead of the child window
+	WaitFor         *WaitCondition  `json:"wait_for"`         // Conditions to wait for before capturing
+	RetryCount      *int            `json:"retry_count"`      // Capture retries after a failed pass (default 3)
+	RetryBackoff    string          `json:"retry_backoff"`    // Duration string for the first retry delay (default "100ms")
+	RejectBlackFrames bool          `json:"reject_black_frames"` // Fail with BLACK_FRAME instead of returning an all-black image
+	CaptureOtherDesktops bool       `json:"capture_other_desktops"` // Capture a window on another virtual desktop without switching
+	CaptureGhost    bool            `json:"capture_ghost"`    // Capture the ghost window Windows shows in place of a hung window
+	SyncToPresent   bool            `json:"sync_to_present"`  // DXGI captures wait for the next frame the application presents
+	Popups          *PopupCapture   `json:"popups"`           // Also capture context menus and tooltips the target process shows
+	IncludeOwnedWindows bool        `json:"include_owned_windows"` // Composite the window's owned dialogs over it as they appear on screen
+	MaxResponseBytes int            `json:"max_response_bytes"` // Shrink images until the response fits in this many bytes (0 = no limit)
+	Pipeline        []PipelineStage `json:"pipeline"`         // Post-processing stages; replaces the server's default pipeline, [] disables it
+	ColorManagement ColorManagement `json:"color_management"` // "none", "embed" or "srgb" (default: the server's setting)
+	WaitForStable   *StabilityCondition `json:"wait_for_stable"` // Recapture until the target stops changing
+	Machine         string          `json:"machine,omitempty"` // Federated target to capture on; a "machine:" prefix of target does the same
+	IfNoneMatch     string          `json:"if_none_match,omitempty"` // ETag of an earlier response; answered with unchanged while the capture looks the same
+}
+
+// ScrollCaptureRequest captures a window's scrollable content by scrolling it down and
+// stitching the captures into one tall image
+type ScrollCaptureRequest struct {
+	Method           string          `json:"method"`
+	Target           string          `json:"target"`
+	Scroll           string          `json:"scroll,omitempty"`        // "wheel" (default) or "page" to press Page Down
+	WheelNotches     int             `json:"wheel_notches,omitempty"` // Wheel notches per scroll (default 5)
+	MaxPages         int             `json:"max_pages,omitempty"`     // Scrolls before giving up on reaching the end (default 20, at most 100)
+	Settle           string          `json:"settle,omitempty"`        // Wait after each scroll for the window to repaint (default "300ms")
+	Format           ImageFormat     `json:"format,omitempty"`
+	Quality          int             `json:"quality,omitempty"`
+	MaxResponseBytes int             `json:"max_response_bytes,omitempty"`
+	Pipeline         []PipelineStage `json:"pipeline,omitempty"` // Applied to the stitched image
+}
+
+// ScrollCaptureResponse is a stitched capture of a window's scrollable content
+type ScrollCaptureResponse struct {
+	Screenshot *ScreenshotResponse `json:"screenshot"`
+	Pages      int                 `json:"pages"`     // Captures stitched
+	Unmatched  int                 `json:"unmatched"` // Captures without an overlap with the previous one, where content may repeat or be missing
+	Complete   bool                `json:"complete"`  // Scrolling stopped at the end of the content
+}
+
+// BatchScreenshotRequest captures several windows, possibly on several machines, at once
+type BatchScreenshotRequest struct {
+	Requests []ScreenshotRequest `json:"requests"`
+}
+
+// BatchScreenshotResult is the outcome of one request of a batch
+type BatchScreenshotResult struct {
+	Machine  string              `json:"machine,omitempty"` // Federated target the capture ran on; empty for this server
+	Target   string              `json:"target"`
+	Response *ScreenshotResponse `json:"response,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Code     ErrorCode           `json:"code,omitempty"`
+}
+
+// BatchScreenshotResponse holds a batch's results in request order
+type BatchScreenshotResponse struct {
+	Results   []BatchScreenshotResult `json:"results"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+}
+
+// CaptureSet is a saved group of targets captured together, as close to the same
+// instant as possible
+type CaptureSet struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Requests    []ScreenshotRequest `json:"requests"` // Members; each may name a target alias or a federated machine
+}
+
+// CaptureSetResponse is the bundle of a capture set's captures, with a result per member
+// in order
+type CaptureSetResponse struct {
+	Name      string        `json:"name"`
+	Timestamp time.Time     `json:"timestamp"` // When the members' captures were started together
+	Spread    time.Duration `json:"spread"`    // Between the earliest and latest member capture
+	BatchScreenshotResponse
+}
+
+// JobInfo describes an operation in flight that can be cancelled: a REST or MCP call,
+// or a running recording
+type JobInfo struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"` // MCP method, "POST /v1/screenshot" or "recording"
+	Source    string    `json:"source"` // "http", "mcp" or "recording"
+	StartedAt time.Time `json:"started_at"`
+	Cancelled bool      `json:"cancelled,omitempty"` // Cancelled and winding down
+}
+
+// WebcamRequest starts the virtual webcam on a window or display
+type WebcamRequest struct {
+	Target string `json:"target"`           // Window handle, 0 for the desktop, "monitor:N" or "alias:name"
+	Width  int    `json:"width,omitempty"`  // Camera frame size, default 1280x720; the capture is letterboxed into it
+	Height int    `json:"height,omitempty"`
+	FPS    int    `json:"fps,omitempty"`    // Default 30
+}
+
+// WebcamStatus describes the virtual webcam
+type WebcamStatus struct {
+	Running      bool       `json:"running"`
+	Target       string     `json:"target,omitempty"`
+	Width        int        `json:"width,omitempty"`
+	Height       int        `json:"height,omitempty"`
+	FPS          int        `json:"fps,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	Frames       int64      `json:"frames"`
+	FailedFrames int64      `json:"failed_frames"`
+	Connected    bool       `json:"connected"` // An application is reading the camera
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// OutputRequest starts publishing a window or display to an RTMP server
+type OutputRequest struct {
+	Target  string `json:"target"`            // Window handle, 0 for the desktop, "monitor:N" or "alias:name"
+	URL     string `json:"url"`               // rtmp:// or rtmps:// URL, including the stream key
+	Width   int    `json:"width,omitempty"`   // Video frame size, default 1280x720; the capture is letterboxed into it
+	Height  int    `json:"height,omitempty"`
+	FPS     int    `json:"fps,omitempty"`     // Default 30
+	Bitrate int    `json:"bitrate,omitempty"` // Video bitrate in kbit/s, default 4000
+}
+
+// OutputStatus describes an RTMP output
+type OutputStatus struct {
+	ID           string     `json:"id"`
+	Running      bool       `json:"running"` // False once the output failed; it's listed until deleted
+	Target       string     `json:"target"`
+	URL          string     `json:"url"` // With the stream key masked
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	FPS          int        `json:"fps"`
+	Bitrate      int        `json:"bitrate"`
+	StartedAt    time.Time  `json:"started_at"`
+	StoppedAt    *time.Time `json:"stopped_at,omitempty"`
+	Frames       int64      `json:"frames"`
+	FailedFrames int64      `json:"failed_frames"` // Captures that failed; the previous frame was repeated
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// PipelineStage is one step of an image post-processing pipeline, e.g.
+// {"stage": "resize", "params": {"max_width": 1280}}
+type PipelineStage struct {
+	Stage  string          `json:"stage"`            // resize, crop, annotate, redact, watermark, sharpen or a registered stage
+	Params json.RawMessage `json:"params,omitempty"` // Stage-specific parameters
+}
+
+// PopupCapture arms a request to capture transient popup windows (context menus,
+// tooltips) of the target's process the moment they are shown
+type PopupCapture struct {
+	Within    string   `json:"within"`     // Duration string to wait for popups, default "5s"
+	MaxPopups int      `json:"max_popups"` // Stop after this many popups, default 1
+	Classes   []string `json:"classes"`    // Window classes to capture, default "#32768" and "tooltips_class32"
+}
+
+// WaitCondition describes what a screenshot request waits for before capturing.
+// The target window must exist before the other conditions are checked.
+type WaitCondition struct {
+	TitleRegex   string          `json:"title_regex"`   // Target window title matches this regular expression
+	Element      string          `json:"element"`       // UI Automation element with this name or automation ID is on screen
+	Pixel        *PixelCondition `json:"pixel"`         // Pixel in the captured image has a color
+	StableFrames int             `json:"stable_frames"` // Consecutive identical captures required
+	Timeout      string          `json:"timeout"`       // Duration string, default "10s"
+	Interval     string          `json:"interval"`      // Poll interval, default "250ms"
+}
+
+// StabilityCondition recaptures the target until consecutive captures barely differ, so
+// animations, page loads and dialog transitions settle before the image is returned.
+// It is checked after any wait_for conditions hold.
+type StabilityCondition struct {
+	Threshold     float64 `json:"threshold"`       // Largest share of pixels that may change between captures, default 0.001
+	Tolerance     int     `json:"tolerance"`       // Per-channel difference ignored as noise, default 8
+	Frames        int     `json:"frames"`          // Consecutive captures within the threshold required, default 3
+	Timeout       string  `json:"timeout"`         // Duration string, default "5s"
+	Interval      string  `json:"interval"`        // Time between captures, default "100ms"
+	FailOnTimeout bool    `json:"fail_on_timeout"` // Fail with TIMEOUT instead of returning the last capture
+}
+
+// StabilityInfo reports how a wait_for_stable capture settled
+type StabilityInfo struct {
+	Stable     bool          `json:"stable"`     // The capture stopped changing before the timeout
+	Frames     int           `json:"frames"`     // Captures taken
+	Difference float64       `json:"difference"` // Share of pixels that changed between the last two captures
+	Waited     time.Duration `json:"waited"`
+}
+
+// PixelCondition matches the color of one pixel of the captured image
+type PixelCondition struct {
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Color     string `json:"color"`     // "#RRGGBB"
+	Tolerance int    `json:"tolerance"` // Maximum per-channel difference
+}
+
+// RecordingRequest represents a request to start a recording
+type RecordingRequest struct {
+	Mode         RecordingMode `json:"mode"`          // Recording mode (default "session")
+	WindowID     uintptr       `json:"window_id"`     // Window handle, 0 for the full desktop
+	FPS          float64       `json:"fps"`           // Capture rate
+	Format       ImageFormat   `json:"format"`        // Frame format
+	Quality      int           `json:"quality"`       // JPEG quality (1-100)
+	MaxWidth     int           `json:"max_width"`     // Downscale frames wider than this
+	IncludeInput bool          `json:"include_input"` // Record cursor and input activity
+	MaxDuration  string        `json:"max_duration"`  // Duration string, e.g. "10m"
+	PlaybackFPS  float64       `json:"playback_fps"`  // Timelapse output frame rate
+	MaxSize      int64         `json:"max_size"`      // Timelapse spool size cap in bytes
+	Output       string        `json:"output"`        // Timelapse output: "gif" or "mp4"
+	Overlay      bool          `json:"overlay"`       // Burn the capture time, window title, recording and FPS into each frame
 }
 
 // ScreenshotResponse represents the response containing screenshot data
@@ -27,6 +240,33 @@ type ScreenshotResponse struct {
 	Timestamp time.Time `json:"timestamp"`  // When captured
 	Metadata  Metadata  `json:"metadata"`   // Additional metadata
 	Error     string    `json:"error"`      // Error message if failed
+	Popups    []PopupImage `json:"popups,omitempty"` // Popups captured when the request armed popup capture
+	ETag      string    `json:"etag,omitempty"`      // Identifies the request and what the capture looks like
+	Unchanged bool      `json:"unchanged,omitempty"` // The capture looks the same as that of if_none_match; data is left out
+	Cached    bool      `json:"cached,omitempty"`    // The capture looked the same as the last one, whose response and timestamp are returned
+}
+
+// PopupImage is a popup window captured alongside a screenshot
+type PopupImage struct {
+	Window    WindowInfo `json:"window"`
+	Data      string     `json:"data"` // Base64 encoded image data
+	Format    string     `json:"format"`
+	Width     int        `json:"width"`
+	Height    int        `json:"height"`
+	Timestamp time.Time  `json:"timestamp"`
+	Reduction *ResponseReduction `json:"reduction,omitempty"` // Set when the image was shrunk to fit max_response_bytes
+}
+
+// ResponseReduction records how an image was re-encoded or downscaled to fit a
+// response size budget
+type ResponseReduction struct {
+	MaxResponseBytes int     `json:"max_response_bytes"`
+	OriginalFormat   string  `json:"original_format"`
+	OriginalWidth    int     `json:"original_width"`
+	OriginalHeight   int     `json:"original_height"`
+	OriginalSize     int64   `json:"original_size"`     // Bytes before re-encoding
+	Quality          int     `json:"quality,omitempty"` // JPEG quality used
+	Scale            float64 `json:"scale"`             // Downscale factor, 1 if only re-encoded
 }
 
 // WindowInfo contains information about a window
@@ -38,13 +278,32 @@ type WindowInfo struct {
 	ThreadID   uint32    `json:"thread_id"`   // Thread ID
 	Rect       Rectangle `json:"rect"`        // Window rectangle
 	ClientRect Rectangle `json:"client_rect"` // Client area rectangle
-	State      string    `json:"state"`       // "visible", "minimized", "maximized", "hidden"
+	State      string    `json:"state"`       // "visible", "minimized", "maximized", "hidden", "hung"
 	ZOrder     int       `json:"z_order"`     // Z-order position
 	IsVisible  bool      `json:"is_visible"`  // Whether window is visible
 	IsTopMost  bool      `json:"is_topmost"`  // Whether window is always on top
 	Monitor    int       `json:"monitor"`     // Monitor index
+	IntegrityLevel string `json:"integrity_level,omitempty"` // Owning process's integrity: "low", "medium", "high", "system"
+	DisplayAffinity string `json:"display_affinity,omitempty"` // Set when the window blocks capture: "monitor", "exclude_from_capture"
+	VirtualDesktopID string `json:"virtual_desktop_id,omitempty"` // Task View desktop the window is on
+	OnCurrentDesktop *bool  `json:"on_current_desktop,omitempty"` // Whether that desktop is the current one (unset if unknown)
+	OccludedPercent float64 `json:"occluded_percent"`          // Share of the window covered by windows above it (0-100)
+	OccludedBy      []uintptr `json:"occluded_by,omitempty"`   // Handles of the windows covering it, topmost first
+	Fullscreen      string    `json:"fullscreen,omitempty"`    // Set when the window covers its monitor: "exclusive", "borderless"
 }
 
+// Display affinities reported in WindowInfo
+const (
+	DisplayAffinityMonitor            = "monitor"              // WDA_MONITOR: content is blacked out in captures
+	DisplayAffinityExcludeFromCapture = "exclude_from_capture" // WDA_EXCLUDEFROMCAPTURE: window is left out of captures
+)
+
+// Full-screen modes reported in WindowInfo
+const (
+	FullscreenExclusive  = "exclusive"  // Direct3D exclusive full-screen: the application owns the display
+	FullscreenBorderless = "borderless" // A borderless window covering the monitor
+)
+
 // ChromeTab represents a Chrome browser tab
 type ChromeTab struct {
 	ID          string `json:"id"`
@@ -58,6 +317,21 @@ type ChromeTab struct {
 	Active      bool   `json:"active"`
 }
 
+// ChromeFrame represents an out-of-process iframe (OOPIF) attached to a tab
+type ChromeFrame struct {
+	TargetID  string    `json:"target_id"`  // DevTools target ID (equals the frame ID)
+	SessionID string    `json:"session_id"` // Flattened DevTools session ID
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Rect      Rectangle `json:"rect"` // Frame position within the tab viewport (device pixels)
+}
+
+// ChromeFrameCapture pairs an out-of-process iframe with its own screenshot
+type ChromeFrameCapture struct {
+	Frame  ChromeFrame       `json:"frame"`
+	Buffer *ScreenshotBuffer `json:"-"`
+}
+
 // ChromeInstance represents a Chrome browser instance
 type ChromeInstance struct {
 	PID         uint32      `json:"pid"`
@@ -99,6 +373,14 @@ type MonitorInfo struct {
 	Name      string    `json:"name"`
 }
 
+// DisplayAdapter is a display device, as reported for diagnostics
+type DisplayAdapter struct {
+	Name    string `json:"name"`   // Adapter description, such as the GPU model
+	Device  string `json:"device"` // Device name, such as \\.\DISPLAY1
+	Active  bool   `json:"active"` // Attached to the desktop
+	Primary bool   `json:"primary"`
+}
+
 // ImageFormat represents supported image formats
 type ImageFormat string
 
@@ -121,6 +403,14 @@ type ScreenshotBuffer struct {
 	SourceRect  Rectangle  `json:"source_rect"`
 	WindowInfo  WindowInfo `json:"window_info"`
 	MonitorInfo MonitorInfo `json:"monitor_info"`
+	CaptureMethod CaptureMethod `json:"capture_method"` // Method that produced the image
+	BlackFrameDetected bool    `json:"black_frame_detected"` // First attempt returned an all-black frame
+	Attempts    []CaptureAttempt `json:"attempts"`     // Methods tried, in order
+	Retries     int        `json:"retries"`          // Capture passes retried before success
+	OwnedWindows []WindowInfo `json:"owned_windows,omitempty"` // Owned windows composited over the window, bottom to top
+	ColorProfile []byte     `json:"-"`                // ICC profile of the pixels, embedded by encoders; nil means sRGB
+	Stability   *StabilityInfo `json:"stability,omitempty"` // Set by wait_for_stable
+	GhostWindow uintptr    `json:"ghost_window,omitempty"` // Ghost window captured in place of the hung window
 }
 
 // Metadata contains additional information about a screenshot
@@ -129,9 +419,51 @@ type Metadata struct {
 	ProcessingTime  time.Duration     `json:"processing_time"`  // Time to process
 	WindowVisible   bool              `json:"window_visible"`   // Was window visible
 	WindowMinimized bool              `json:"window_minimized"` // Was window minimized
+	WindowHung      bool              `json:"window_hung,omitempty"` // Window wasn't processing messages
+	GhostWindow     uintptr           `json:"ghost_window,omitempty"` // Ghost window captured instead, with capture_ghost
 	DPIScaling      float64           `json:"dpi_scaling"`      // DPI scale factor
 	ColorDepth      int               `json:"color_depth"`      // Bits per pixel
 	Properties      map[string]string `json:"properties"`       // Additional properties
+	BlackFrameDetected bool           `json:"black_frame_detected,omitempty"` // Initial capture was black and was escalated
+	Attempts        []CaptureAttempt  `json:"attempts,omitempty"` // Per-method attempts and timings
+	Retries         int               `json:"retries"`          // Capture passes retried before success
+	OccludedPercent float64           `json:"occluded_percent"` // Share of the window covered by other windows when captured
+	OccludedBy      []uintptr         `json:"occluded_by,omitempty"` // Windows covering it; BitBlt captures may include their content
+	OwnedWindows    []WindowInfo      `json:"owned_windows,omitempty"` // Owned dialogs composited into the image
+	Reduction       *ResponseReduction `json:"reduction,omitempty"` // Set when the image was shrunk to fit max_response_bytes
+	ColorProfile    *ColorProfileInfo  `json:"color_profile,omitempty"` // Set when color management was requested
+	Stability       *StabilityInfo     `json:"stability,omitempty"` // Set when wait_for_stable was requested
+	Timing          *CaptureTiming     `json:"timing,omitempty"`    // When the image was captured and where the time went
+}
+
+// clockOrigin anchors monotonic timestamps. Times from time.Now carry a monotonic clock
+// reading, so their offsets from it ignore wall clock adjustments.
+var clockOrigin = time.Now()
+
+// MonotonicNanos returns t as nanoseconds since the server started, on the monotonic clock
+func MonotonicNanos(t time.Time) int64 {
+	return t.Sub(clockOrigin).Nanoseconds()
+}
+
+// CaptureTiming timestamps a capture on the wall clock and the server's monotonic clock,
+// and measures the latency of each step. Monotonic timestamps of frames from different
+// streams of one server are directly comparable, even across clock changes.
+type CaptureTiming struct {
+	CapturedAt  time.Time     `json:"captured_at"`    // Wall clock time the capture completed
+	MonotonicNs int64         `json:"monotonic_ns"`   // The same instant on the server's monotonic clock
+	Capture     time.Duration `json:"capture"`        // Capturing, including waits and retries
+	Process     time.Duration `json:"process"`        // Resizing, color management and post-processing
+	Encode      time.Duration `json:"encode"`         // Encoding the image
+	Send        time.Duration `json:"send,omitempty"` // Streams: writing the previous frame to the client
+}
+
+// NewCaptureTiming times a capture that started at start and completed at captured
+func NewCaptureTiming(start, captured time.Time) *CaptureTiming {
+	return &CaptureTiming{
+		CapturedAt:  captured,
+		MonotonicNs: MonotonicNanos(captured),
+		Capture:     captured.Sub(start),
+	}
 }
 
 // StreamSession represents an active streaming session
@@ -147,6 +479,210 @@ type StreamSession struct {
 	BytesSent  int64      `json:"bytes_sent"`
 }
 
+// StreamSessionStats is the detail of one streaming session in the streaming statistics
+type StreamSessionStats struct {
+	ID               string      `json:"id"`
+	WindowID         uintptr     `json:"window_id"`
+	WindowTitle      string      `json:"window_title"` // As of the last frame captured
+	Monitor          *int        `json:"monitor,omitempty"` // Display streamed, for "monitor:N" targets
+	ClientAddr       string      `json:"client_addr"`
+	UserAgent        string      `json:"user_agent"`
+	Connected        bool        `json:"connected"` // False while waiting for the client to resume
+	ConnectedAt      *time.Time  `json:"connected_at,omitempty"` // When the current client connected
+	Reconnects       int         `json:"reconnects"`
+	Format           ImageFormat `json:"format"`
+	Quality          int         `json:"quality"`
+	RequestedFPS     int         `json:"requested_fps"`
+	AchievedFPS      float64     `json:"achieved_fps"` // Frames delivered per second over the last few seconds
+	FrameCount       int64       `json:"frame_count"`
+	BytesSent        int64       `json:"bytes_sent"`
+	AverageFrameSize int64       `json:"average_frame_size"` // Bytes
+	DroppedFrames    int64       `json:"dropped_frames"`     // Missed, throttled and failed frames
+	MissedFrames     int64       `json:"missed_frames"`      // Due while disconnected or failed to send
+	ThrottledFrames  int64       `json:"throttled_frames"`   // Held back waiting for acks
+	FailedFrames     int64       `json:"failed_frames"`      // Failed to capture or encode
+	LastError        string      `json:"last_error,omitempty"`
+	LastErrorAt      *time.Time  `json:"last_error_at,omitempty"`
+	StartTime        time.Time   `json:"start_time"`
+	LastFrame        *time.Time  `json:"last_frame,omitempty"`
+	RecordingID      string      `json:"recording_id,omitempty"` // Recording the stream is teed into
+	Viewers          int         `json:"viewers"`                // View-only clients watching the stream
+	CPUUsage         float64     `json:"cpu_usage"`              // Percent of a core capturing and encoding used over the last second
+	CPUThrottle      *CPUThrottle `json:"cpu_throttle,omitempty"` // Set while the CPU budget caps the frame rate
+}
+
+// TargetInfo is a screenshot server captures can be addressed to as "name:window"
+type TargetInfo struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`              // "server" (configured), "agent" (connected through the relay) or "alias"
+	URL     string `json:"url,omitempty"`     // Configured servers only
+	Status  string `json:"status,omitempty"`  // With check: "online" or "offline"
+	Version string `json:"version,omitempty"` // With check, of online targets
+	Error   string `json:"error,omitempty"`   // With check, why the target is offline
+	Alias   *TargetAlias `json:"alias,omitempty"` // What an alias stands for
+}
+
+// TargetAlias is a saved target: a name standing for a window lookup and the settings
+// it's captured with. Requests use it with method "alias" and the name as target.
+type TargetAlias struct {
+	Name            string          `json:"name"`
+	Description     string          `json:"description,omitempty"`
+	Method          string          `json:"method"`           // Window lookup method, such as "title_regex"
+	Target          string          `json:"target,omitempty"` // Its target; a "machine:" prefix captures on a federated target
+	Match           string          `json:"match,omitempty"`
+	CaptureMethod   CaptureMethod   `json:"capture_method,omitempty"`   // Preferred capture method, unless the request sets one
+	FallbackMethods []CaptureMethod `json:"fallback_methods,omitempty"` // Unless the request sets its own
+	Format          ImageFormat     `json:"format,omitempty"`           // Default output format
+	Quality         int             `json:"quality,omitempty"`          // Default quality
+	Pipeline        []PipelineStage `json:"pipeline,omitempty"`         // Stages, such as redactions, run ahead of the request's own pipeline
+}
+
+// ReadinessReport is the result of probing whether the server can really capture
+type ReadinessReport struct {
+	Status       string                      `json:"status"` // "ready", or "not_ready" when a required capability isn't ok
+	Timestamp    time.Time                   `json:"timestamp"`
+	Version      string                      `json:"version"`
+	GoVersion    string                      `json:"go_version"`
+	Platform     string                      `json:"platform"` // GOOS/GOARCH of the server
+	OSVersion    string                      `json:"os_version,omitempty"`
+	DesktopState DesktopState                `json:"desktop_state"`
+	Monitors     []MonitorInfo               `json:"monitors,omitempty"`
+	Capabilities map[string]CapabilityStatus `json:"capabilities"`
+	ProbeTime    time.Duration               `json:"probe_time"`
+}
+
+// CapabilityStatus is the outcome of probing one capability
+type CapabilityStatus struct {
+	Status   string        `json:"status"`   // "ok", "unavailable", "unsupported" on this platform, or "timeout"
+	Required bool          `json:"required"` // The server isn't ready unless the capability is ok
+	Detail   string        `json:"detail,omitempty"`
+	Version  string        `json:"version,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// PluginInfo describes a plugin found in the plugins directory
+type PluginInfo struct {
+	Name        string        `json:"name"`
+	Version     string        `json:"version,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Dir         string        `json:"dir"`
+	Running     bool          `json:"running"`
+	Error       string        `json:"error,omitempty"` // Why the plugin failed to start or stopped
+	Tools       []PluginTool  `json:"tools"`
+	Stages      []PluginStage `json:"stages"`
+}
+
+// PluginTool is an MCP method a plugin provides
+type PluginTool struct {
+	Name        string          `json:"name"` // Method name, "plugin.tool"
+	Description string          `json:"description,omitempty"`
+	Params      json.RawMessage `json:"params,omitempty"` // JSON Schema of the params, if the plugin gives one
+}
+
+// PluginStage is a pipeline stage a plugin provides
+type PluginStage struct {
+	Name        string `json:"name"` // Stage name, "plugin.stage"
+	Description string `json:"description,omitempty"`
+}
+
+// WorkflowInfo describes a workflow script of the workflows directory
+type WorkflowInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"` // The script's leading comment
+	Params      []WorkflowParam `json:"params"`
+}
+
+// WorkflowParam is a parameter a workflow declares
+type WorkflowParam struct {
+	Name     string `json:"name"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required"` // Declared without a default
+}
+
+// WorkflowRunRequest runs a workflow with values for its parameters
+type WorkflowRunRequest struct {
+	Params map[string]string `json:"params"`
+}
+
+// WorkflowCapture is a screenshot a workflow took
+type WorkflowCapture struct {
+	Label      string              `json:"label,omitempty"`
+	Line       int                 `json:"line"` // Script line of the capture call
+	Screenshot *ScreenshotResponse `json:"screenshot"`
+	Reference  string              `json:"reference,omitempty"`  // Image the capture was compared with
+	Difference *float64            `json:"difference,omitempty"` // Share of pixels, 0-1, that differ from the reference
+}
+
+// WorkflowRunResponse is the outcome of a workflow run
+type WorkflowRunResponse struct {
+	Workflow string            `json:"workflow"`
+	Captures []WorkflowCapture `json:"captures"`
+	Steps    int               `json:"steps"` // Workflow functions called
+	Duration time.Duration     `json:"duration"`
+}
+
+// MacroRecordRequest starts recording a macro of the user's input
+type MacroRecordRequest struct {
+	Name      string `json:"name"`
+	Method    string `json:"method,omitempty"`   // Window replayed against and captured; foreground by default
+	Target    string `json:"target,omitempty"`
+	Interval  string `json:"interval,omitempty"` // Between screenshots, such as "5s"; none between the first and last by default
+	Overwrite bool   `json:"overwrite,omitempty"` // Replace a workflow of the same name
+}
+
+// MacroResult describes a recorded macro, saved as a workflow
+type MacroResult struct {
+	Name     string        `json:"name"`
+	Workflow string        `json:"workflow"` // Script file
+	Steps    int           `json:"steps"`    // Input commands recorded
+	Frames   int           `json:"frames"`   // Screenshots taken
+	Duration time.Duration `json:"duration"`
+	Script   string        `json:"script"`
+}
+
+// AgentInfo describes a capture agent connected to a central server through the relay
+type AgentInfo struct {
+	ID          string    `json:"id"`
+	Hostname    string    `json:"hostname"`
+	Platform    string    `json:"platform"` // GOOS/GOARCH of the agent
+	Version     string    `json:"version"`
+	RemoteAddr  string    `json:"remote_addr"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Requests    int64     `json:"requests"` // Requests forwarded since the agent connected
+	Streams     int       `json:"streams"`  // Streams being relayed
+}
+
+// RecordingInfo describes a recording and the artifact it produces on disk
+type RecordingInfo struct {
+	ID         string            `json:"id"`
+	Mode       RecordingMode     `json:"mode"`
+	Status     string            `json:"status"` // "recording", "assembling", "completed", "failed"
+	WindowID   uintptr           `json:"window_id"`
+	Options    *RecordingOptions `json:"options"`
+	Directory  string            `json:"directory"`
+	StartTime  time.Time         `json:"start_time"`
+	EndTime    *time.Time        `json:"end_time,omitempty"`
+	FrameCount int64             `json:"frame_count"`
+	BytesWritten int64           `json:"bytes_written"`
+	EventCount int64             `json:"event_count"`
+	Decimation int64             `json:"decimation,omitempty"` // Timelapse: keep one of every N samples
+	Output     string            `json:"output,omitempty"`     // Timelapse: assembled GIF/video path
+	SessionID  string            `json:"session_id,omitempty"` // Stream: the stream session teed into the recording
+	Error      string            `json:"error,omitempty"`
+}
+
+// TimelineEvent is a single entry in a recording's metadata timeline
+type TimelineEvent struct {
+	Offset    time.Duration          `json:"offset"`    // Time since the recording started
+	Timestamp time.Time              `json:"timestamp"` // Wall-clock time of the event
+	Type      string                 `json:"type"`      // "frame", "foreground_changed", "window_changed", "cursor_moved", "input_activity", ...
+	Frame     int64                  `json:"frame,omitempty"`
+	Window    *WindowInfo            `json:"window,omitempty"`
+	Cursor    *Point                 `json:"cursor,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
 // MCPRequest represents a JSON-RPC 2.0 request
 type MCPRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -170,6 +706,57 @@ type MCPError struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// MCPResource is an item MCP hosts can read with resources/read
+type MCPResource struct {
+	URI         string `json:"uri"`  // Such as "screenshot://history/12"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPResourceTemplate describes a family of resources by a URI template
+type MCPResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"` // Such as "screenshot://history/{id}"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// MCPResourceContents is the content of a resource: text, or a base64 blob for images
+type MCPResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// MCPPrompt is a ready-made prompt MCP hosts can fetch with prompts/get
+type MCPPrompt struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Arguments   []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument is an argument a prompt is filled in with
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPPromptMessage is a message of a filled-in prompt
+type MCPPromptMessage struct {
+	Role    string     `json:"role"` // "user" or "assistant"
+	Content MCPContent `json:"content"`
+}
+
+// MCPContent is text, or an embedded resource, in a prompt message
+type MCPContent struct {
+	Type     string               `json:"type"` // "text" or "resource"
+	Text     string               `json:"text,omitempty"`
+	Resource *MCPResourceContents `json:"resource,omitempty"`
+}
+
 // Interfaces
 
 // ScreenshotEngine defines the core screenshot functionality
@@ -179,18 +766,44 @@ type ScreenshotEngine interface {
 	CaptureByTitle(title string, options *CaptureOptions) (*ScreenshotBuffer, error)
 	CaptureByPID(pid uint32, options *CaptureOptions) (*ScreenshotBuffer, error)
 	CaptureByClassName(className string, options *CaptureOptions) (*ScreenshotBuffer, error)
+	CaptureByProcessName(name string, options *CaptureOptions) (*ScreenshotBuffer, error)
 	CaptureFullScreen(monitor int, options *CaptureOptions) (*ScreenshotBuffer, error)
 	
 	// Advanced capture methods for hidden/tray applications
 	CaptureHiddenByPID(pid uint32, options *CaptureOptions) (*ScreenshotBuffer, error)
 	CaptureTrayApp(processName string, options *CaptureOptions) (*ScreenshotBuffer, error)
 	CaptureWithFallbacks(handle uintptr, options *CaptureOptions) (*ScreenshotBuffer, error)
+	CaptureShellSurface(surface ShellSurface, options *CaptureOptions) (*ScreenshotBuffer, error)
+	CapturePopups(pid uint32, classes []string, within time.Duration, maxPopups int, options *CaptureOptions) ([]*ScreenshotBuffer, error)
 	
 	// Window discovery methods
 	EnumerateAllProcessWindows(pid uint32) ([]WindowInfo, error)
 	FindSystemTrayApps() ([]WindowInfo, error)
 	FindHiddenWindows() ([]WindowInfo, error)
 	FindCloakedWindows() ([]WindowInfo, error)
+	FindToastWindows() ([]WindowInfo, error)
+
+	// Icons, as BGRA buffers
+	GetWindowIcon(handle uintptr, large bool) (*ScreenshotBuffer, error)
+	GetProcessIcon(pid uint32, large bool) (*ScreenshotBuffer, error)
+}
+
+// ShellSurface identifies a part of the Windows shell that can be captured directly
+type ShellSurface string
+
+const (
+	ShellTaskbar       ShellSurface = "taskbar"       // Primary taskbar
+	ShellTrayOverflow  ShellSurface = "tray_overflow" // Hidden tray icons flyout (must be open)
+	ShellNotifications ShellSurface = "notifications" // Most recent toast notification
+)
+
+// IsShellSurface reports whether name is a known shell surface
+func IsShellSurface(name string) bool {
+	switch ShellSurface(name) {
+	case ShellTaskbar, ShellTrayOverflow, ShellNotifications:
+		return true
+	}
+	return false
 }
 
 // WindowManager defines window management operations
@@ -212,6 +825,24 @@ type WindowManager interface {
 	
 	// Bring window to foreground
 	BringToForeground(handle uintptr) error
+	
+	// Get the window that currently has focus
+	GetForegroundWindow() (uintptr, error)
+	
+	// Get the current mouse cursor position in screen coordinates
+	GetCursorPosition() (Point, error)
+	
+	// Get the window at a screen position, optionally walking up to its top-level owner
+	WindowFromPoint(pt Point, topLevel bool) (uintptr, error)
+	
+	// Get a window's current title, bypassing any cache
+	GetWindowTitle(handle uintptr) (string, error)
+	
+	// Check for an on-screen UI Automation element by name or automation ID
+	IsElementVisible(handle uintptr, name string) (bool, error)
+	
+	// Get the time of the last keyboard or mouse input
+	GetLastInputTime() (time.Time, error)
 }
 
 // ChromeManager defines Chrome browser interaction
@@ -225,6 +856,12 @@ type ChromeManager interface {
 	// Capture screenshot of a tab
 	CaptureTab(tab *ChromeTab, options *CaptureOptions) (*ScreenshotBuffer, error)
 	
+	// List out-of-process iframes attached to a tab
+	GetFrames(tab *ChromeTab) ([]ChromeFrame, error)
+	
+	// Capture each out-of-process iframe of a tab individually
+	CaptureFrames(tab *ChromeTab, options *CaptureOptions) ([]ChromeFrameCapture, error)
+	
 	// Execute JavaScript in tab context
 	ExecuteScript(tab *ChromeTab, script string) (interface{}, error)
 }
@@ -234,6 +871,9 @@ type ImageProcessor interface {
 	// Encode buffer to specific format
 	Encode(buffer *ScreenshotBuffer, format ImageFormat, quality int) ([]byte, error)
 	
+	// Encode, tracing its stages inside the span ctx carries
+	EncodeContext(ctx context.Context, buffer *ScreenshotBuffer, format ImageFormat, quality int) ([]byte, error)
+	
 	// Decode image data to buffer
 	Decode(data []byte) (*ScreenshotBuffer, error)
 	
@@ -275,13 +915,185 @@ const (
 	CaptureWMPrint     CaptureMethod = "wmprint"      // WM_PRINT message
 	CaptureStealthRestore CaptureMethod = "stealth"   // Temporarily restore minimized windows
 	CaptureProcessMemory CaptureMethod = "memory"     // Direct process memory access
+	CaptureRenderFullContent CaptureMethod = "printwindow_full" // PrintWindow with PW_RENDERFULLCONTENT (DirectComposition content)
+	CaptureDXGI        CaptureMethod = "dxgi"         // DXGI desktop duplication cropped to the window (visible windows only)
+	CaptureGame        CaptureMethod = "game"         // DXGI desktop duplication of the full-screen window's whole monitor
+	CaptureSynthetic   CaptureMethod = "synthetic"    // Generated test image from the fake engine
+	CaptureX11         CaptureMethod = "x11"          // X11 GetImage, from Composite's off-screen pixmap when available
+	CaptureQuartz      CaptureMethod = "quartz"       // macOS CGWindowListCreateImage
 )
 
+// selectableCaptureMethods lists the methods clients may request explicitly
+var selectableCaptureMethods = []CaptureMethod{
+	CaptureAuto, CaptureBitBlt, CapturePrintWindow, CaptureDWMThumbnail, CaptureWMPrint,
+	CaptureStealthRestore, CaptureRenderFullContent, CaptureDXGI, CaptureGame,
+}
+
+// ParseCaptureMethod validates a client-supplied capture method name
+func ParseCaptureMethod(name string) (CaptureMethod, error) {
+	for _, method := range selectableCaptureMethods {
+		if string(method) == name {
+			return method, nil
+		}
+	}
+	return "", fmt.Errorf("unknown capture method %q (valid: %v)", name, selectableCaptureMethods)
+}
+
+// RegionOrigin defines the coordinate space a capture region is expressed in
+type RegionOrigin string
+
+const (
+	RegionRelativeToWindow RegionOrigin = "window" // Window rectangle including the frame (default)
+	RegionRelativeToClient RegionOrigin = "client" // Client area, in the target application's own coordinates
+	RegionRelativeToScreen RegionOrigin = "screen" // Virtual screen coordinates
+)
+
+// ParseRegionOrigin validates a client-supplied region origin; empty means window
+func ParseRegionOrigin(name string) (RegionOrigin, error) {
+	switch origin := RegionOrigin(name); origin {
+	case "":
+		return RegionRelativeToWindow, nil
+	case RegionRelativeToWindow, RegionRelativeToClient, RegionRelativeToScreen:
+		return origin, nil
+	default:
+		return "", fmt.Errorf("unknown region_relative_to %q (valid: window, client, screen)", name)
+	}
+}
+
+// ExcludedWindowPolicy decides how windows that block capture via SetWindowDisplayAffinity are handled
+type ExcludedWindowPolicy string
+
+const (
+	ExcludedWindowFail         ExcludedWindowPolicy = "fail"          // Fail with CAPTURE_EXCLUDED (default)
+	ExcludedWindowDWMThumbnail ExcludedWindowPolicy = "dwm_thumbnail" // Try a DWM thumbnail before failing
+)
+
+// ParseExcludedWindowPolicy validates an excluded window policy; empty means fail
+func ParseExcludedWindowPolicy(name string) (ExcludedWindowPolicy, error) {
+	switch policy := ExcludedWindowPolicy(name); policy {
+	case "":
+		return ExcludedWindowFail, nil
+	case ExcludedWindowFail, ExcludedWindowDWMThumbnail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown excluded window policy %q (valid: fail, dwm_thumbnail)", name)
+	}
+}
+
+// ColorManagement decides how captures from displays with an ICC color profile are
+// treated
+type ColorManagement string
+
+const (
+	ColorManagementNone  ColorManagement = "none"  // Return the display's pixels untagged (default)
+	ColorManagementEmbed ColorManagement = "embed" // Embed the display's profile in encoded images
+	ColorManagementSRGB  ColorManagement = "srgb"  // Convert the pixels to sRGB
+)
+
+// ParseColorManagement validates a color management mode; empty means none
+func ParseColorManagement(name string) (ColorManagement, error) {
+	switch mode := ColorManagement(name); mode {
+	case "":
+		return ColorManagementNone, nil
+	case ColorManagementNone, ColorManagementEmbed, ColorManagementSRGB:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown color management mode %q (valid: none, embed, srgb)", name)
+	}
+}
+
+// ActivityGate decides whether streams and recordings pause depending on whether the
+// user is using the machine
+type ActivityGate string
+
+const (
+	ActivityGateNone   ActivityGate = "none"              // Never pause (default)
+	ActivityGateActive ActivityGate = "pause_when_active" // Pause while the user is active, for privacy
+	ActivityGateIdle   ActivityGate = "pause_when_idle"   // Pause while the user is idle, to save resources
+)
+
+// ParseActivityGate validates an activity gate; empty means none
+func ParseActivityGate(name string) (ActivityGate, error) {
+	switch gate := ActivityGate(name); gate {
+	case "":
+		return ActivityGateNone, nil
+	case ActivityGateNone, ActivityGateActive, ActivityGateIdle:
+		return gate, nil
+	default:
+		return "", fmt.Errorf("unknown activity gate %q (valid: none, pause_when_active, pause_when_idle)", name)
+	}
+}
+
+// ActivityState reports whether the user is using the machine
+type ActivityState struct {
+	State         string        `json:"state"` // "active", "idle", or "unknown" when input can't be observed
+	LastInput     *time.Time    `json:"last_input,omitempty"`
+	IdleFor       time.Duration `json:"idle_for"`
+	IdleAfter     time.Duration `json:"idle_after"` // Time without input after which the user is idle
+	Gate          ActivityGate  `json:"gate"`
+	CapturePaused bool          `json:"capture_paused"` // Streams and recordings are paused by the gate
+}
+
+// PowerState is the machine's power source and saving mode
+type PowerState struct {
+	Source         string `json:"source"`                    // "ac", "battery", or "unknown" where it can't be queried
+	BatterySaver   bool   `json:"battery_saver"`             // Windows battery (energy) saver is on
+	BatteryPercent *int   `json:"battery_percent,omitempty"` // Charge left, when known
+}
+
+// PowerLimits caps the frame rate and quality of streams; 0 leaves a value uncapped
+type PowerLimits struct {
+	MaxFPS     int `json:"max_fps,omitempty"`
+	MaxQuality int `json:"max_quality,omitempty"`
+}
+
+// PowerPolicy caps streams while the machine runs on battery and while battery saver is
+// on; when both apply, the lower caps win. A nil member leaves that state uncapped.
+type PowerPolicy struct {
+	Battery *PowerLimits `json:"battery,omitempty"`
+	Saver   *PowerLimits `json:"saver,omitempty"`
+}
+
+// CPUThrottle is the frame rate a stream is capped at for exceeding its CPU budget
+type CPUThrottle struct {
+	Budget int     `json:"budget"` // Percent of a CPU core the stream may use capturing and encoding
+	Usage  float64 `json:"usage"`  // Percent of a core used over the last second
+	FPS    int     `json:"fps"`    // Frame rate streamed
+}
+
+// PowerThrottle reports the power caps applied to a stream
+type PowerThrottle struct {
+	Power   PowerState  `json:"power"`
+	Limits  PowerLimits `json:"limits"`
+	FPS     int         `json:"fps"`     // Frame rate the stream runs at
+	Quality int         `json:"quality"` // Quality frames are encoded with
+}
+
+// ColorProfileInfo reports the color management applied to a capture
+type ColorProfileInfo struct {
+	Mode        ColorManagement `json:"mode"`
+	Description string          `json:"description,omitempty"` // Description of the display's profile
+	Source      string          `json:"source,omitempty"`      // Profile file the display uses
+	Converted   bool            `json:"converted"`             // Pixels were converted to sRGB
+	Profile     string          `json:"profile,omitempty"`     // Base64 ICC profile, for "embed"; encoded images carry it too
+	Warning     string          `json:"warning,omitempty"`     // Why nothing was applied
+}
+
+// CaptureAttempt records a single capture method attempt
+type CaptureAttempt struct {
+	Method   CaptureMethod `json:"method"`
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Retry    int           `json:"retry"` // Capture pass the attempt belonged to, 0 for the first
+}
+
 // CaptureOptions defines options for screenshot capture
 type CaptureOptions struct {
 	IncludeCursor    bool          `json:"include_cursor"`
 	IncludeFrame     bool          `json:"include_frame"`
 	Region           *Rectangle    `json:"region"`
+	RegionRelativeTo RegionOrigin  `json:"region_relative_to"` // Coordinate space of Region
 	ScaleFactor      float64       `json:"scale_factor"`
 	
 	// Visibility options
@@ -299,15 +1111,62 @@ type CaptureOptions struct {
 	PreferredMethod  CaptureMethod `json:"preferred_method"`  // Preferred capture method
 	UseDWMThumbnails bool          `json:"use_dwm_thumbnails"` // Force use of DWM thumbnails
 	ForceRender      bool          `json:"force_render"`      // Force window to render before capture
+	DetectBlackFrames bool         `json:"detect_black_frames"` // Escalate to GPU-aware methods when a capture comes back black
+	RejectBlackFrames bool         `json:"reject_black_frames"` // Fail with ErrBlackFrame instead of returning a black image
 	DetectTrayApps   bool          `json:"detect_tray_apps"`  // Automatically detect tray applications
 	
 	// Fallback options
 	RetryCount       int           `json:"retry_count"`       // Number of retry attempts
+	RetryBackoff     time.Duration `json:"retry_backoff"`     // Delay before the first retry, doubled for each further retry
+	RetryMaxBackoff  time.Duration `json:"retry_max_backoff"` // Upper bound on the retry delay
 	FallbackMethods  []CaptureMethod `json:"fallback_methods"` // Methods to try if preferred fails
 	
+	// Browser options
+	CompositeFrames  bool          `json:"composite_frames"`  // Composite out-of-process iframes into Chrome tab captures
+	
+	// Virtual desktop options
+	CaptureOtherDesktops bool      `json:"capture_other_desktops"` // Capture windows on other virtual desktops without switching
+	
+	// Hung window options
+	CaptureGhost     bool          `json:"capture_ghost"`     // Capture a hung window's ghost window ("Not Responding") instead of its last frame
+	
+	// Game capture options
+	SyncToPresent    bool          `json:"sync_to_present"`   // DXGI and game captures wait for the application's next present
+	
+	// Window stack options
+	IncludeOwnedWindows bool       `json:"include_owned_windows"` // Composite owned dialogs and popups over the window
+	
+	// Scheduling options
+	Priority         CapturePriority `json:"-"` // Order of the capture among those waiting for a capture slot
+	SlotHeld         bool            `json:"-"` // The caller holds the capture slot, shared by the members of a capture set
+	Context          context.Context `json:"-"` // Cancels waits for a slot, retries and wait conditions; nil never does
+	
 	CustomProperties map[string]string `json:"custom_properties"`
 }
 
+// CapturePriority orders captures waiting for the capture scheduler's slots
+type CapturePriority int
+
+// Capture priorities, the most urgent first
+const (
+	PriorityInteractive CapturePriority = iota // API, MCP and gRPC requests
+	PriorityScheduled                          // Recordings, workflows, macros and desktop events
+	PriorityStream                             // Stream ticks
+)
+
+// String returns the priority's name
+func (p CapturePriority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityScheduled:
+		return "scheduled"
+	case PriorityStream:
+		return "stream"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
 // WindowFilter defines filtering options for window enumeration
 type WindowFilter struct {
 	TitleContains  string   `json:"title_contains"`
@@ -317,6 +1176,7 @@ type WindowFilter struct {
 	MinimumSize    *Size    `json:"minimum_size"`
 	MaximumSize    *Size    `json:"maximum_size"`
 	ExcludeSystem  bool     `json:"exclude_system"`
+	VirtualDesktop string   `json:"virtual_desktop"` // "current", "other" or a virtual desktop ID
 }
 
 // StreamOptions defines options for streaming
@@ -328,6 +1188,109 @@ type StreamOptions struct {
 	MaxHeight      int         `json:"max_height"`
 	BufferSize     int         `json:"buffer_size"`
 	CompressionLevel int       `json:"compression_level"`
+	AckMode        bool        `json:"ack_mode"`    // Wait for client acknowledgements before sending more frames
+	MaxUnacked     int         `json:"max_unacked"` // Frames that may be in flight unacknowledged in ack mode
+	Record         bool        `json:"record"`        // Tee every delivered frame into a recording
+	RecordOutput   string      `json:"record_output"` // "frames" (default) or "mp4" (requires ffmpeg)
+	Security       StreamSecurity `json:"security"`   // Frame signing or encryption with the server's stream key
+	Game           bool        `json:"game"`          // Capture with desktop duplication of the monitor, synced to the game's presents
+	Monitor        *int        `json:"monitor,omitempty"` // Display streamed instead of a window, for "monitor:N" targets
+	Transport      StreamTransport `json:"transport,omitempty"` // How frames are sent: "json" (default), "raw" or "shm"
+	DetailInterval int         `json:"detail_interval,omitempty"` // Every Nth frame is encoded at DetailQuality, the others at Quality
+	DetailQuality  int         `json:"detail_quality,omitempty"`  // Quality of detail frames (default 95)
+	Region         *Rectangle  `json:"region,omitempty"` // Part of the window or display streamed, relative to its top-left corner
+	Follow         bool        `json:"follow,omitempty"` // Stream whichever window is in the foreground, for the "active" target
+	CursorZoom     *Size       `json:"cursor_zoom,omitempty"`      // Stream a region this size centered on the cursor
+	CursorSmoothing float64    `json:"cursor_smoothing,omitempty"` // Part of the distance to the cursor the zoom region keeps each frame (0-0.95)
+	Insets         []StreamInset `json:"insets,omitempty"` // Other windows composited picture-in-picture into each frame
+	Overlay        bool        `json:"overlay,omitempty"` // Burn the capture time, window title, session and FPS into each frame
+	Privacy        string      `json:"privacy,omitempty"`       // Desktop and monitor streams: "blur" or "black" hides every window not in PrivacyAllow
+	PrivacyAllow   []uintptr   `json:"privacy_allow,omitempty"` // Windows left visible by Privacy
+}
+
+// StreamInset is another window shown scaled down in a corner of a stream's frames
+type StreamInset struct {
+	WindowID uintptr `json:"window_id"`
+	Position string  `json:"position"` // "top_left", "top_right", "bottom_left" or "bottom_right" (default)
+	Scale    float64 `json:"scale"`    // Width as a share of the frame's width, 0.05-0.5 (default 0.25)
+}
+
+// StreamTransport selects how stream frames are sent over WebSocket
+type StreamTransport string
+
+const (
+	StreamTransportJSON StreamTransport = "json" // Encoded images in JSON frame messages
+	StreamTransportRaw  StreamTransport = "raw"  // Unencoded BGRA pixels in binary messages, for local viewers
+	StreamTransportShm  StreamTransport = "shm"  // BGRA pixels in a shared-memory ring, announced in frame messages
+)
+
+// ParseStreamTransport validates a stream transport; empty means json
+func ParseStreamTransport(name string) (StreamTransport, error) {
+	switch transport := StreamTransport(name); transport {
+	case "":
+		return StreamTransportJSON, nil
+	case StreamTransportJSON, StreamTransportRaw, StreamTransportShm:
+		return transport, nil
+	default:
+		return "", fmt.Errorf("unknown stream transport %q (valid: json, raw, shm)", name)
+	}
+}
+
+// StreamSecurity protects stream frames with the server's pre-shared stream key
+type StreamSecurity string
+
+const (
+	StreamSecurityNone    StreamSecurity = "none"    // Frames are sent as they are
+	StreamSecuritySign    StreamSecurity = "sign"    // Frames carry an HMAC signature
+	StreamSecurityEncrypt StreamSecurity = "encrypt" // Frames are encrypted and signed
+)
+
+// ParseStreamSecurity validates a stream security level; empty means none
+func ParseStreamSecurity(name string) (StreamSecurity, error) {
+	switch level := StreamSecurity(name); level {
+	case "":
+		return StreamSecurityNone, nil
+	case StreamSecurityNone, StreamSecuritySign, StreamSecurityEncrypt:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown stream security %q (valid: none, sign, encrypt)", name)
+	}
+}
+
+// Stronger returns the stronger of two stream security levels
+func (s StreamSecurity) Stronger(other StreamSecurity) StreamSecurity {
+	rank := map[StreamSecurity]int{StreamSecuritySign: 1, StreamSecurityEncrypt: 2}
+	if rank[other] > rank[s] {
+		return other
+	}
+	return s
+}
+
+// RecordingMode selects how a recording captures and stores frames
+type RecordingMode string
+
+const (
+	RecordingSession   RecordingMode = "session"   // Frames plus a synchronized window/input timeline
+	RecordingTimelapse RecordingMode = "timelapse" // Long low-rate capture assembled into a GIF or video
+	RecordingStream    RecordingMode = "stream"    // Frames delivered by a live stream, as the client received them
+)
+
+// RecordingOptions defines options for recording a window or the desktop to disk
+type RecordingOptions struct {
+	Mode         RecordingMode `json:"mode"`
+	WindowID     uintptr       `json:"window_id"`     // 0 records the full desktop
+	FPS          float64       `json:"fps"`           // Capture rate (fractional rates allowed)
+	Format       ImageFormat   `json:"format"`        // Frame image format
+	Quality      int           `json:"quality"`       // JPEG quality (1-100)
+	MaxWidth     int           `json:"max_width"`     // Downscale frames wider than this
+	IncludeInput bool          `json:"include_input"` // Record cursor movement and input activity
+	MaxDuration  time.Duration `json:"max_duration"`  // Stop automatically after this long (0 = unlimited)
+
+	// Timelapse options
+	PlaybackFPS float64 `json:"playback_fps"` // Frame rate of the assembled output
+	MaxSize     int64   `json:"max_size"`     // Cap on spooled frame bytes; frames are decimated beyond it
+	Output      string  `json:"output"`       // "gif" or "mp4" (requires ffmpeg); stream recordings: "frames" or "mp4"
+	Overlay     bool    `json:"overlay"`      // Burn the capture time, window title, recording and FPS into each frame
 }
 
 // DefaultCaptureOptions returns sensible defaults for screenshot capture
@@ -352,10 +1315,13 @@ func DefaultCaptureOptions() *CaptureOptions {
 		PreferredMethod:  CaptureAuto,
 		UseDWMThumbnails: false,
 		ForceRender:      false,
+		DetectBlackFrames: true,
 		DetectTrayApps:   true,
 		
 		// Fallback options
 		RetryCount:       3,
+		RetryBackoff:     100 * time.Millisecond,
+		RetryMaxBackoff:  2 * time.Second,
 		FallbackMethods:  []CaptureMethod{CaptureDWMThumbnail, CapturePrintWindow, CaptureWMPrint, CaptureStealthRestore},
 		
 		CustomProperties: make(map[string]string),
@@ -372,6 +1338,24 @@ func DefaultStreamOptions() *StreamOptions {
 		MaxHeight:        1080,
 		BufferSize:       5,
 		CompressionLevel: 6,
+		AckMode:          false,
+		MaxUnacked:       1,
+	}
+}
+
+// DefaultRecordingOptions returns sensible defaults for recording
+func DefaultRecordingOptions() *RecordingOptions {
+	return &RecordingOptions{
+		Mode:         RecordingSession,
+		FPS:          2,
+		Format:       FormatJPEG,
+		Quality:      75,
+		MaxWidth:     1920,
+		IncludeInput: false,
+		MaxDuration:  time.Hour,
+		PlaybackFPS:  25,
+		MaxSize:      512 * 1024 * 1024,
+		Output:       "gif",
 	}
 }
 
@@ -443,4 +1427,39 @@ func min(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+
+// UsageStats are cumulative usage counters of a server, kept across restarts
+type UsageStats struct {
+	Since          time.Time              `json:"since"`           // When counting started
+	Captures       int64                  `json:"captures"`        // Captures by any API, recording or stream
+	FailedCaptures int64                  `json:"failed_captures"`
+	StreamSessions int64                  `json:"stream_sessions"` // Stream sessions started
+	StreamFrames   int64                  `json:"stream_frames"`   // Frames delivered to stream clients
+	StreamBytes    int64                  `json:"stream_bytes"`
+	Methods        map[string]MethodUsage `json:"methods"`         // Attempts of each capture method
+}
+
+// MethodUsage counts how a capture method fares
+type MethodUsage struct {
+	Attempts    int64   `json:"attempts"`
+	Successes   int64   `json:"successes"`
+	SuccessRate float64 `json:"success_rate"` // Successes per attempt, 0-1
+}
+
+// MethodRanking is the order capture methods are tried in for windows of a class, learned
+// from how each fared on them recently
+type MethodRanking struct {
+	ClassName string        `json:"class_name"`
+	Methods   []MethodScore `json:"methods"` // Best first
+}
+
+// MethodScore is how a capture method fared over its recent attempts on a window class
+type MethodScore struct {
+	Method         CaptureMethod `json:"method"`
+	Attempts       int           `json:"attempts"`
+	Successes      int           `json:"successes"`
+	SuccessRate    float64       `json:"success_rate"`    // Successes per attempt, 0-1
+	AverageLatency time.Duration `json:"average_latency"` // Mean duration of an attempt
+	Ranked         bool          `json:"ranked"`          // Enough attempts to move the method in the order
+}